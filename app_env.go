@@ -3,9 +3,11 @@ package goboot
 import (
 	"os"
 
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/viper"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // AppEnv contains all application-scoped variables.
@@ -13,7 +15,16 @@ type AppEnv struct {
 	Config   *viper.Viper
 	Log      zerolog.Logger
 	ConfDir  string
+	Env      string
 	Services []AppService
+
+	// Metrics is the Prometheus registry shared by all services. Expose it
+	// using promhttp.HandlerFor(env.Metrics, promhttp.HandlerOpts{}).
+	Metrics *prometheus.Registry
+
+	// TracerProvider enables OpenTelemetry tracing in services when set,
+	// e.g. to an SDK tracer provider exporting spans. Nil disables tracing.
+	TracerProvider trace.TracerProvider
 }
 
 // NewAppEnv creates an AppEnv by loading configuration settings.
@@ -39,9 +50,11 @@ func NewAppEnv(confDir string, env string) *AppEnv {
 
 	return &AppEnv{
 		ConfDir:  confDir,
+		Env:      env,
 		Config:   cfg,
 		Log:      logger,
 		Services: make([]AppService, 0),
+		Metrics:  prometheus.NewRegistry(),
 	}
 }
 
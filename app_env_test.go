@@ -1,6 +1,8 @@
 package goboot_test
 
 import (
+	"context"
+	"errors"
 	"testing"
 
 	"github.com/nielskrijger/goboot"
@@ -76,3 +78,34 @@ func TestAppContext_Close(t *testing.T) {
 	serviceMock1.AssertExpectations(t)
 	serviceMock2.AssertExpectations(t)
 }
+
+func TestAppContext_Env(t *testing.T) {
+	ctx := goboot.NewAppEnv("./testdata", "prod")
+	assert.Equal(t, "prod", ctx.Env)
+}
+
+type healthCheckService struct {
+	mocks.AppService
+	err error
+}
+
+func (s *healthCheckService) HealthCheck(context.Context) error {
+	return s.err
+}
+
+func TestAppContext_HealthCheck(t *testing.T) {
+	ctx := goboot.NewAppEnv("./testdata", "")
+	healthy := &healthCheckService{}
+	healthy.On("Name").Return("healthy")
+
+	unhealthy := &healthCheckService{err: errors.New("down")}
+	unhealthy.On("Name").Return("unhealthy")
+
+	ctx.AddService(healthy)
+	ctx.AddService(unhealthy)
+	ctx.AddService(&mocks.AppService{})
+
+	result := ctx.HealthCheck(context.Background())
+	assert.Len(t, result, 1)
+	assert.EqualError(t, result["unhealthy"], "down")
+}
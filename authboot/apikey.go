@@ -0,0 +1,364 @@
+package authboot
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nielskrijger/goboot"
+	"github.com/nielskrijger/goboot/validate"
+	"github.com/rs/zerolog"
+)
+
+const (
+	defaultAPIKeyHeader    = "X-API-Key"
+	defaultRateLimitWindow = time.Minute
+	apiKeyIDBytes          = 12
+)
+
+var (
+	// ErrAPIKeyNotFound is returned by an APIKeyStore for unknown key IDs.
+	ErrAPIKeyNotFound = errors.New("API key not found")
+
+	// ErrInvalidAPIKey is returned when an API key is malformed, unknown,
+	// revoked or expired. Errors wrapping it describe the reason.
+	ErrInvalidAPIKey = errors.New("invalid API key")
+
+	// ErrRateLimited is returned when an API key exceeds its rate limit.
+	ErrRateLimited = errors.New("API key rate limit exceeded")
+
+	errMissingAPIKey = errors.New("missing API key")
+	errAPIKeyRevoked = errors.New("key is revoked")
+	errAPIKeyExpired = errors.New("key is expired")
+)
+
+// APIKey is a stored API key. The key itself is only returned when created,
+// only its hash is stored.
+type APIKey struct {
+	ID   string `json:"id"`
+	Hash string `json:"hash"`
+
+	// Principal the key authenticates, e.g. a user or service ID.
+	Principal string `json:"principal"`
+
+	Scopes []string `json:"scopes,omitempty"`
+
+	// Maximum number of requests per rate limit window, 0 is unlimited.
+	RateLimit int `json:"rateLimit,omitempty"`
+
+	CreatedAt time.Time  `json:"createdAt"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+	RevokedAt *time.Time `json:"revokedAt,omitempty"`
+}
+
+// HasScope returns true if the key has scope, e.g. "read:users".
+func (k *APIKey) HasScope(scope string) bool {
+	for _, s := range k.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+
+	return false
+}
+
+// APIKeyStore stores API keys, e.g. redisauth.APIKeyStore or
+// pgauth.APIKeyStore.
+type APIKeyStore interface {
+	// GetAPIKey returns ErrAPIKeyNotFound if the key doesn't exist.
+	GetAPIKey(ctx context.Context, id string) (*APIKey, error)
+	SaveAPIKey(ctx context.Context, key *APIKey) error
+}
+
+// RateLimiter counts requests in fixed windows, e.g.
+// redisauth.RateLimiter for limits across instances.
+type RateLimiter interface {
+	// Allow counts a request of key and returns false and the time until
+	// the window resets if more than limit requests were made in the
+	// current window.
+	Allow(ctx context.Context, key string, limit int, window time.Duration) (bool, time.Duration, error)
+}
+
+type APIKeysConfig struct {
+	// Request header of the API key. Default is "X-API-Key". Keys are also
+	// accepted as "Authorization: ApiKey {key}".
+	Header string `yaml:"header"`
+
+	// Prefix of generated keys identifying them, e.g. "sk_live_".
+	Prefix string `yaml:"prefix"`
+
+	// Secret used to hash keys with HMAC-SHA256, so leaked hashes can't be
+	// verified without it. Read from a file or environment variable if
+	// prefixed with "file:" or "env:", see goboot.ResolveSecret.
+	Pepper string `yaml:"pepper"`
+
+	// Window of per-key rate limits. Default is 1 minute.
+	RateLimitWindow time.Duration `yaml:"rateLimitWindow"`
+}
+
+// APIKeys implements the AppService interface and authenticates requests
+// using API keys stored in an APIKeyStore, configured in the optional
+// "apiKeys" configuration.
+//
+// Keys are formatted as "{prefix}{id}.{secret}", the ID is used to look up
+// the key and the secret is compared to its hash.
+type APIKeys struct {
+	config  *APIKeysConfig
+	store   APIKeyStore
+	limiter RateLimiter
+	pepper  []byte
+	log     zerolog.Logger
+}
+
+// NewAPIKeys creates an API key service using store, and limiter to enforce
+// the rate limits of keys. Without limiter rate limits are counted per
+// instance, see NewMemoryRateLimiter.
+func NewAPIKeys(store APIKeyStore, limiter RateLimiter) *APIKeys {
+	if limiter == nil {
+		limiter = NewMemoryRateLimiter()
+	}
+
+	return &APIKeys{store: store, limiter: limiter}
+}
+
+func (s *APIKeys) Name() string {
+	return "APIKeys"
+}
+
+// Configure loads the optional "apiKeys" configuration.
+func (s *APIKeys) Configure(env *goboot.AppEnv) error {
+	s.log = env.Log
+	s.config = &APIKeysConfig{}
+
+	if env.Config.InConfig("apiKeys") {
+		if err := env.Config.Sub("apiKeys").Unmarshal(s.config); err != nil {
+			return fmt.Errorf("parsing apiKeys configuration: %w", err)
+		}
+	}
+
+	if s.config.Header == "" {
+		s.config.Header = defaultAPIKeyHeader
+	}
+
+	if s.config.RateLimitWindow == 0 {
+		s.config.RateLimitWindow = defaultRateLimitWindow
+	}
+
+	pepper, err := goboot.ResolveSecret(s.config.Pepper)
+	if err != nil {
+		return fmt.Errorf("reading apiKeys pepper: %w", err)
+	}
+
+	s.pepper = []byte(pepper)
+
+	return nil
+}
+
+func (s *APIKeys) Init() error {
+	return nil
+}
+
+func (s *APIKeys) Close() error {
+	return nil
+}
+
+// APIKeyOptions configures a new API key.
+type APIKeyOptions struct {
+	Scopes    []string
+	RateLimit int
+	ExpiresAt *time.Time
+}
+
+// Create stores a new API key of principal and returns the key, which can't
+// be retrieved later.
+func (s *APIKeys) Create(ctx context.Context, principal string, opts APIKeyOptions) (string, *APIKey, error) {
+	id, err := randomString(apiKeyIDBytes, hex.EncodeToString)
+	if err != nil {
+		return "", nil, err
+	}
+
+	secret, err := randomString(randomBytes, base64.RawURLEncoding.EncodeToString)
+	if err != nil {
+		return "", nil, err
+	}
+
+	key := &APIKey{
+		ID:        id,
+		Hash:      s.hash(secret),
+		Principal: principal,
+		Scopes:    opts.Scopes,
+		RateLimit: opts.RateLimit,
+		CreatedAt: time.Now().UTC(),
+		ExpiresAt: opts.ExpiresAt,
+	}
+
+	if err := s.store.SaveAPIKey(ctx, key); err != nil {
+		return "", nil, fmt.Errorf("creating API key: %w", err)
+	}
+
+	return s.config.Prefix + id + "." + secret, key, nil
+}
+
+func randomString(n int, encode func([]byte) string) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generating API key: %w", err)
+	}
+
+	return encode(b), nil
+}
+
+func (s *APIKeys) hash(secret string) string {
+	mac := hmac.New(sha256.New, s.pepper)
+	mac.Write([]byte(secret))
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Revoke revokes the key with id, which is rejected from then on.
+func (s *APIKeys) Revoke(ctx context.Context, id string) error {
+	key, err := s.store.GetAPIKey(ctx, id)
+	if err != nil {
+		return fmt.Errorf("revoking API key %q: %w", id, err)
+	}
+
+	if key.RevokedAt != nil {
+		return nil
+	}
+
+	now := time.Now().UTC()
+	key.RevokedAt = &now
+
+	if err := s.store.SaveAPIKey(ctx, key); err != nil {
+		return fmt.Errorf("revoking API key %q: %w", id, err)
+	}
+
+	return nil
+}
+
+// Verify returns the stored key of apiKey, or ErrInvalidAPIKey if it's
+// unknown, revoked or expired.
+func (s *APIKeys) Verify(ctx context.Context, apiKey string) (*APIKey, error) {
+	if !strings.HasPrefix(apiKey, s.config.Prefix) {
+		return nil, fmt.Errorf("%w: missing prefix %q", ErrInvalidAPIKey, s.config.Prefix)
+	}
+
+	id, secret, ok := strings.Cut(strings.TrimPrefix(apiKey, s.config.Prefix), ".")
+	if !ok || id == "" || secret == "" {
+		return nil, fmt.Errorf("%w: malformed key", ErrInvalidAPIKey)
+	}
+
+	key, err := s.store.GetAPIKey(ctx, id)
+	if errors.Is(err, ErrAPIKeyNotFound) {
+		return nil, fmt.Errorf("%w: unknown key", ErrInvalidAPIKey)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("verifying API key: %w", err)
+	}
+
+	if !hmac.Equal([]byte(s.hash(secret)), []byte(key.Hash)) {
+		return nil, fmt.Errorf("%w: unknown key", ErrInvalidAPIKey)
+	}
+
+	if key.RevokedAt != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidAPIKey, errAPIKeyRevoked) //nolint:errorlint
+	}
+
+	if key.ExpiresAt != nil && time.Now().After(*key.ExpiresAt) {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidAPIKey, errAPIKeyExpired) //nolint:errorlint
+	}
+
+	return key, nil
+}
+
+// Middleware responds 401 Unauthorized to requests without a valid API key
+// and 429 Too Many Requests to requests exceeding the rate limit of their
+// key. The key is added to the request context, see APIKeyFromContext.
+func (s *APIKeys) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		apiKey := s.requestKey(r)
+		if apiKey == "" {
+			writeProblem(w, http.StatusUnauthorized, errMissingAPIKey)
+
+			return
+		}
+
+		key, err := s.Verify(r.Context(), apiKey)
+		if err != nil {
+			if !errors.Is(err, ErrInvalidAPIKey) {
+				s.log.Error().Err(err).Msg("failed to verify API key")
+				writeProblem(w, http.StatusInternalServerError, nil)
+
+				return
+			}
+
+			s.log.Debug().Err(err).Str("path", r.URL.Path).Msg("unauthorized request")
+			writeProblem(w, http.StatusUnauthorized, err)
+
+			return
+		}
+
+		if key.RateLimit > 0 {
+			allowed, retryAfter, err := s.limiter.Allow(r.Context(), "apikey:"+key.ID, key.RateLimit, s.config.RateLimitWindow)
+			if err != nil {
+				// availability over strict limits
+				s.log.Warn().Err(err).Msg("failed to rate limit API key")
+			} else if !allowed {
+				w.Header().Set("Retry-After", strconv.Itoa(int(retryAfter.Round(time.Second).Seconds())))
+				writeProblem(w, http.StatusTooManyRequests, ErrRateLimited)
+
+				return
+			}
+		}
+
+		next.ServeHTTP(w, r.WithContext(WithAPIKey(r.Context(), key)))
+	})
+}
+
+// requestKey returns the API key of the header, or of an "ApiKey"
+// Authorization header.
+func (s *APIKeys) requestKey(r *http.Request) string {
+	if key := r.Header.Get(s.config.Header); key != "" {
+		return key
+	}
+
+	if scheme, key, ok := strings.Cut(r.Header.Get("Authorization"), " "); ok && strings.EqualFold(scheme, "ApiKey") {
+		return strings.TrimSpace(key)
+	}
+
+	return ""
+}
+
+func writeProblem(w http.ResponseWriter, status int, err error) {
+	p := &validate.Problem{Type: "about:blank", Title: http.StatusText(status), Status: status}
+	if err != nil {
+		p.Detail = err.Error()
+	}
+
+	p.Write(w)
+}
+
+type apiKeyKey struct{}
+
+// WithAPIKey returns a context with the verified API key of a request.
+func WithAPIKey(ctx context.Context, key *APIKey) context.Context {
+	return context.WithValue(ctx, apiKeyKey{}, key)
+}
+
+// APIKeyFromContext returns the verified API key of the request of ctx, or
+// false if the request isn't authenticated by an API key.
+func APIKeyFromContext(ctx context.Context) (*APIKey, bool) {
+	key, ok := ctx.Value(apiKeyKey{}).(*APIKey)
+
+	return key, ok
+}
@@ -0,0 +1,168 @@
+package authboot_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nielskrijger/goboot"
+	"github.com/nielskrijger/goboot/authboot"
+	"github.com/stretchr/testify/assert"
+)
+
+type memoryKeyStore struct {
+	mu   sync.Mutex
+	keys map[string]authboot.APIKey
+}
+
+func (s *memoryKeyStore) GetAPIKey(_ context.Context, id string) (*authboot.APIKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key, ok := s.keys[id]
+	if !ok {
+		return nil, authboot.ErrAPIKeyNotFound
+	}
+
+	return &key, nil
+}
+
+func (s *memoryKeyStore) SaveAPIKey(_ context.Context, key *authboot.APIKey) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.keys[key.ID] = *key
+
+	return nil
+}
+
+func newAPIKeys(t *testing.T) (*authboot.APIKeys, *memoryKeyStore) {
+	t.Helper()
+
+	store := &memoryKeyStore{keys: map[string]authboot.APIKey{}}
+
+	s := authboot.NewAPIKeys(store, nil)
+	assert.Nil(t, s.Configure(goboot.NewAppEnv("./testdata", "")))
+	assert.Nil(t, s.Init())
+
+	t.Cleanup(func() { _ = s.Close() })
+
+	return s, store
+}
+
+func TestAPIKeys_Verify(t *testing.T) {
+	s, store := newAPIKeys(t)
+	ctx := context.Background()
+
+	apiKey, created, err := s.Create(ctx, "service-1", authboot.APIKeyOptions{Scopes: []string{"read:users"}})
+	assert.Nil(t, err)
+	assert.Regexp(t, `^sk_test_[0-9a-f]{24}\.[\w-]{43}$`, apiKey)
+
+	assert.Equal(t, created.Hash, store.keys[created.ID].Hash)
+	assert.NotContains(t, created.Hash, apiKey)
+
+	key, err := s.Verify(ctx, apiKey)
+	assert.Nil(t, err)
+	assert.Equal(t, "service-1", key.Principal)
+	assert.True(t, key.HasScope("read:users"))
+
+	_, err = s.Verify(ctx, apiKey+"x")
+	assert.EqualError(t, err, "invalid API key: unknown key")
+
+	_, err = s.Verify(ctx, "sk_test_unknown.secret")
+	assert.EqualError(t, err, "invalid API key: unknown key")
+
+	_, err = s.Verify(ctx, "sk_test_malformed")
+	assert.EqualError(t, err, "invalid API key: malformed key")
+
+	// keys without the prefix are rejected, even when valid otherwise
+	_, err = s.Verify(ctx, strings.TrimPrefix(apiKey, "sk_test_"))
+	assert.EqualError(t, err, `invalid API key: missing prefix "sk_test_"`)
+
+	assert.Nil(t, s.Revoke(ctx, created.ID))
+
+	_, err = s.Verify(ctx, apiKey)
+	assert.EqualError(t, err, "invalid API key: key is revoked")
+	assert.ErrorIs(t, err, authboot.ErrInvalidAPIKey)
+}
+
+func TestAPIKeys_VerifyExpired(t *testing.T) {
+	s, _ := newAPIKeys(t)
+
+	expiresAt := time.Now().Add(-time.Second)
+
+	apiKey, _, err := s.Create(context.Background(), "service-1", authboot.APIKeyOptions{ExpiresAt: &expiresAt})
+	assert.Nil(t, err)
+
+	_, err = s.Verify(context.Background(), apiKey)
+	assert.EqualError(t, err, "invalid API key: key is expired")
+}
+
+func TestAPIKeys_Middleware(t *testing.T) {
+	s, _ := newAPIKeys(t)
+
+	apiKey, _, err := s.Create(context.Background(), "service-1", authboot.APIKeyOptions{RateLimit: 2})
+	assert.Nil(t, err)
+
+	h := s.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key, ok := authboot.APIKeyFromContext(r.Context())
+		assert.True(t, ok)
+		_, _ = w.Write([]byte(key.Principal))
+	}))
+
+	serve := func(header string, value string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if header != "" {
+			req.Header.Set(header, value)
+		}
+
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+
+		return rec
+	}
+
+	rec := serve("X-API-Key", apiKey)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "service-1", rec.Body.String())
+
+	rec = serve("Authorization", "ApiKey "+apiKey)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	rec = serve("X-API-Key", apiKey)
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "3600", rec.Header().Get("Retry-After"))
+	assert.Contains(t, rec.Body.String(), `"detail":"API key rate limit exceeded"`)
+
+	rec = serve("", "")
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"detail":"missing API key"`)
+
+	rec = serve("X-API-Key", "sk_test_unknown.secret")
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"detail":"invalid API key: unknown key"`)
+}
+
+func TestMemoryRateLimiter(t *testing.T) {
+	l := authboot.NewMemoryRateLimiter()
+	ctx := context.Background()
+
+	allowed, _, _ := l.Allow(ctx, "a", 1, 20*time.Millisecond)
+	assert.True(t, allowed)
+
+	allowed, retryAfter, _ := l.Allow(ctx, "a", 1, 20*time.Millisecond)
+	assert.False(t, allowed)
+	assert.Greater(t, retryAfter, time.Duration(0))
+
+	allowed, _, _ = l.Allow(ctx, "b", 1, 20*time.Millisecond)
+	assert.True(t, allowed)
+
+	time.Sleep(25 * time.Millisecond)
+
+	allowed, _, _ = l.Allow(ctx, "a", 1, 20*time.Millisecond)
+	assert.True(t, allowed)
+}
@@ -0,0 +1,199 @@
+// Package authboot authenticates requests using JWT bearer tokens signed by
+// the keys of an issuer's JWKS, see Auth.
+package authboot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/nielskrijger/goboot"
+	"github.com/rs/zerolog"
+)
+
+const (
+	defaultLeeway             = time.Minute
+	defaultRefreshInterval    = time.Hour
+	defaultMinRefreshInterval = time.Minute
+	defaultTimeout            = 10 * time.Second
+)
+
+var (
+	errMissingConfig = errors.New("missing \"auth\" configuration")
+	errMissingIssuer = errors.New("config \"auth.issuer\" is required")
+
+	// ErrMissingToken is returned when a request has no bearer token.
+	ErrMissingToken = errors.New("missing bearer token")
+
+	// ErrInvalidToken is returned when a token is malformed, expired or not
+	// signed by the issuer. Errors wrapping it describe the reason.
+	ErrInvalidToken = errors.New("invalid token")
+)
+
+// defaultAlgorithms are the asymmetric signing algorithms of JWKS keys.
+var defaultAlgorithms = []string{
+	"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512", "EdDSA",
+}
+
+type AuthConfig struct {
+	// Issuer of the tokens, e.g. "https://example.eu.auth0.com/". The "iss"
+	// claim of tokens must match it exactly.
+	Issuer string `yaml:"issuer"`
+
+	// Tokens must have one of these audiences, leave empty to accept all
+	// audiences.
+	Audience []string `yaml:"audience"`
+
+	// URL of the JWKS. Defaults to the "jwks_uri" of the OpenID Connect
+	// discovery document of the issuer.
+	JWKSURL string `yaml:"jwksUrl"`
+
+	// Signing algorithms accepted. Default is all RSA, ECDSA and EdDSA
+	// algorithms.
+	Algorithms []string `yaml:"algorithms"`
+
+	// Allowed clock skew when checking the "exp", "nbf" and "iat" claims.
+	// Default is 1 minute.
+	Leeway time.Duration `yaml:"leeway"`
+
+	// Interval of refreshing the JWKS. Default is 1 hour.
+	RefreshInterval time.Duration `yaml:"refreshInterval"`
+
+	// Tokens signed by an unknown key refresh the JWKS at most once per this
+	// interval, to pick up rotated keys. Default is 1 minute.
+	MinRefreshInterval time.Duration `yaml:"minRefreshInterval"`
+
+	// Timeout of fetching the JWKS. Default is 10 seconds.
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+// Auth implements the AppService interface and verifies JWT bearer tokens.
+// Use Middleware or the gRPC interceptors to authenticate requests, the
+// verified claims are added to the request context, see FromContext.
+type Auth struct {
+	HTTPClient *http.Client
+
+	config   *AuthConfig
+	verifier *verifier
+	log      zerolog.Logger
+
+	wg     sync.WaitGroup
+	ctx    context.Context //nolint:containedctx
+	cancel context.CancelFunc
+}
+
+func (a *Auth) Name() string {
+	return "Auth"
+}
+
+// Configure loads the "auth" configuration.
+func (a *Auth) Configure(env *goboot.AppEnv) error {
+	a.log = env.Log
+	a.config = &AuthConfig{}
+
+	if !env.Config.InConfig("auth") {
+		return errMissingConfig
+	}
+
+	if err := env.Config.Sub("auth").Unmarshal(a.config); err != nil {
+		return fmt.Errorf("parsing auth configuration: %w", err)
+	}
+
+	if a.config.Issuer == "" {
+		return errMissingIssuer
+	}
+
+	a.config.setDefaults()
+
+	if a.HTTPClient == nil {
+		a.HTTPClient = &http.Client{Timeout: a.config.Timeout}
+	}
+
+	return nil
+}
+
+func (c *AuthConfig) setDefaults() {
+	if len(c.Algorithms) == 0 {
+		c.Algorithms = defaultAlgorithms
+	}
+
+	if c.Leeway == 0 {
+		c.Leeway = defaultLeeway
+	}
+
+	if c.RefreshInterval == 0 {
+		c.RefreshInterval = defaultRefreshInterval
+	}
+
+	if c.MinRefreshInterval == 0 {
+		c.MinRefreshInterval = defaultMinRefreshInterval
+	}
+
+	if c.Timeout == 0 {
+		c.Timeout = defaultTimeout
+	}
+}
+
+// Init fetches the JWKS, discovering its URL if not configured, and
+// refreshes it every RefreshInterval.
+func (a *Auth) Init() error {
+	a.ctx, a.cancel = context.WithCancel(context.Background())
+
+	jwksURL := a.config.JWKSURL
+	if jwksURL == "" {
+		meta, err := discover(a.ctx, a.HTTPClient, a.config.Issuer)
+		if err != nil {
+			return err
+		}
+
+		jwksURL = meta.JWKSURI
+	}
+
+	a.verifier = newVerifier(a.config, newKeySet(jwksURL, a.HTTPClient, a.config.MinRefreshInterval))
+	if err := a.verifier.keys.refresh(a.ctx); err != nil {
+		return err
+	}
+
+	a.wg.Add(1)
+
+	go a.refreshLoop()
+
+	return nil
+}
+
+func (a *Auth) refreshLoop() {
+	defer a.wg.Done()
+
+	ticker := time.NewTicker(a.config.RefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-a.ctx.Done():
+			return
+		case <-ticker.C:
+			// keeps using the current keys until the JWKS is available again
+			if err := a.verifier.keys.refresh(a.ctx); err != nil {
+				a.log.Warn().Err(err).Msg("failed to refresh JWKS")
+			}
+		}
+	}
+}
+
+// Verify returns the claims of token if it's signed by a key of the JWKS and
+// its issuer, audience and time claims are valid.
+func (a *Auth) Verify(ctx context.Context, token string) (*Claims, error) {
+	return a.verifier.verify(ctx, token)
+}
+
+func (a *Auth) Close() error {
+	if a.cancel != nil {
+		a.cancel()
+		a.wg.Wait()
+	}
+
+	return nil
+}
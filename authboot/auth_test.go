@@ -0,0 +1,358 @@
+package authboot_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/nielskrijger/goboot"
+	"github.com/nielskrijger/goboot/authboot"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// issuer serves the discovery document and JWKS of its keys.
+type issuer struct {
+	srv *httptest.Server
+	mux *http.ServeMux
+
+	mu       sync.Mutex
+	keys     map[string]any
+	requests int
+}
+
+func newIssuer(t *testing.T) *issuer {
+	t.Helper()
+
+	iss := &issuer{keys: map[string]any{}, mux: http.NewServeMux()}
+
+	iss.mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 iss.url(),
+			"authorization_endpoint": iss.srv.URL + "/authorize",
+			"token_endpoint":         iss.srv.URL + "/token",
+			"jwks_uri":               iss.srv.URL + "/jwks.json",
+		})
+	})
+	iss.mux.HandleFunc("/jwks.json", func(w http.ResponseWriter, r *http.Request) {
+		iss.mu.Lock()
+		defer iss.mu.Unlock()
+
+		iss.requests++
+
+		var keys []map[string]string
+
+		for kid, key := range iss.keys {
+			switch k := key.(type) {
+			case *rsa.PrivateKey:
+				keys = append(keys, map[string]string{
+					"kty": "RSA", "kid": kid, "use": "sig", "alg": "RS256",
+					"n": encode(k.N), "e": encode(big.NewInt(int64(k.E))),
+				})
+			case *ecdsa.PrivateKey:
+				keys = append(keys, map[string]string{
+					"kty": "EC", "kid": kid, "crv": "P-256", "x": encode(k.X), "y": encode(k.Y),
+				})
+			}
+		}
+
+		_ = json.NewEncoder(w).Encode(map[string]any{"keys": keys})
+	})
+
+	iss.srv = httptest.NewServer(iss.mux)
+	t.Cleanup(iss.srv.Close)
+
+	return iss
+}
+
+func encode(i *big.Int) string {
+	return base64.RawURLEncoding.EncodeToString(i.Bytes())
+}
+
+func (iss *issuer) url() string {
+	return iss.srv.URL + "/"
+}
+
+func (iss *issuer) addKey(t *testing.T, kid string, key any) {
+	t.Helper()
+
+	iss.mu.Lock()
+	defer iss.mu.Unlock()
+
+	iss.keys[kid] = key
+}
+
+// token returns a token signed by key with valid claims, overridden by claims.
+func (iss *issuer) token(t *testing.T, kid string, key any, claims jwt.MapClaims) string {
+	t.Helper()
+
+	c := jwt.MapClaims{
+		"iss": iss.url(),
+		"aud": "api",
+		"sub": "user-1",
+		"exp": time.Now().Add(time.Hour).Unix(),
+		"iat": time.Now().Unix(),
+	}
+
+	for k, v := range claims {
+		c[k] = v
+	}
+
+	method := jwt.SigningMethod(jwt.SigningMethodRS256)
+	if _, ok := key.(*ecdsa.PrivateKey); ok {
+		method = jwt.SigningMethodES256
+	}
+
+	token := jwt.NewWithClaims(method, c)
+	token.Header["kid"] = kid
+
+	s, err := token.SignedString(key)
+	assert.Nil(t, err)
+
+	return s
+}
+
+var (
+	rsaKey     *rsa.PrivateKey
+	rsaKeyOnce sync.Once
+)
+
+func newRSAKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+
+	rsaKeyOnce.Do(func() {
+		var err error
+		rsaKey, err = rsa.GenerateKey(rand.Reader, 2048)
+		assert.Nil(t, err)
+	})
+
+	return rsaKey
+}
+
+func newAuth(t *testing.T, iss *issuer) *authboot.Auth {
+	t.Helper()
+
+	env := goboot.NewAppEnv("./testdata", "")
+	env.Config.Set("auth.issuer", iss.url())
+
+	a := &authboot.Auth{}
+	assert.Nil(t, a.Configure(env))
+	assert.Nil(t, a.Init())
+
+	t.Cleanup(func() { _ = a.Close() })
+
+	return a
+}
+
+func TestAuth_Verify(t *testing.T) {
+	iss := newIssuer(t)
+	key := newRSAKey(t)
+	iss.addKey(t, "rsa", key)
+
+	a := newAuth(t, iss)
+
+	claims, err := a.Verify(context.Background(), iss.token(t, "rsa", key, jwt.MapClaims{
+		"scope": "read:users write:users",
+		"org":   "acme",
+	}))
+	assert.Nil(t, err)
+	assert.Equal(t, "user-1", claims.Subject)
+	assert.Equal(t, "acme", claims.Get("org"))
+	assert.True(t, claims.HasScope("write:users"))
+	assert.False(t, claims.HasScope("admin"))
+
+	var custom struct {
+		Org string `json:"org"`
+	}
+
+	assert.Nil(t, claims.Decode(&custom))
+	assert.Equal(t, "acme", custom.Org)
+}
+
+func TestAuth_VerifyInvalidClaims(t *testing.T) {
+	iss := newIssuer(t)
+	key := newRSAKey(t)
+	iss.addKey(t, "rsa", key)
+
+	a := newAuth(t, iss)
+
+	tests := map[string]struct {
+		claims jwt.MapClaims
+		err    string
+	}{
+		"expired": {
+			claims: jwt.MapClaims{"exp": time.Now().Add(-time.Minute).Unix()},
+			err:    "invalid token: token is expired",
+		},
+		"expired within leeway": {
+			claims: jwt.MapClaims{"exp": time.Now().Add(-10 * time.Second).Unix()},
+		},
+		"no expiry": {
+			claims: jwt.MapClaims{"exp": nil},
+			err:    "invalid token: token has no expiry",
+		},
+		"not valid yet": {
+			claims: jwt.MapClaims{"nbf": time.Now().Add(time.Minute).Unix()},
+			err:    "invalid token: token is not valid yet",
+		},
+		"issuer": {
+			claims: jwt.MapClaims{"iss": "https://evil.example.com/"},
+			err:    "invalid token: token has a different issuer",
+		},
+		"audience": {
+			claims: jwt.MapClaims{"aud": []string{"other", "another"}},
+			err:    "invalid token: token has a different audience",
+		},
+		"one of audiences": {
+			claims: jwt.MapClaims{"aud": []string{"other", "api"}},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := a.Verify(context.Background(), iss.token(t, "rsa", key, test.claims))
+			if test.err == "" {
+				assert.Nil(t, err)
+			} else {
+				assert.EqualError(t, err, test.err)
+				assert.ErrorIs(t, err, authboot.ErrInvalidToken)
+			}
+		})
+	}
+}
+
+func TestAuth_VerifyInvalidSignature(t *testing.T) {
+	iss := newIssuer(t)
+	iss.addKey(t, "rsa", newRSAKey(t))
+
+	a := newAuth(t, iss)
+
+	other, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(t, err)
+
+	_, err = a.Verify(context.Background(), iss.token(t, "rsa", other, nil))
+	assert.EqualError(t, err, "invalid token: token algorithm doesn't match the key")
+
+	_, err = a.Verify(context.Background(), iss.token(t, "unknown", other, nil))
+	assert.EqualError(t, err, `invalid token: token is signed by an unknown key "unknown"`)
+
+	_, err = a.Verify(context.Background(), "not-a-token")
+	assert.ErrorIs(t, err, authboot.ErrInvalidToken)
+
+	hmac := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"iss": iss.url()})
+	s, _ := hmac.SignedString([]byte("secret"))
+	_, err = a.Verify(context.Background(), s)
+	assert.EqualError(t, err, "invalid token: signing method HS256 is invalid")
+}
+
+func TestAuth_RefreshesRotatedKeys(t *testing.T) {
+	iss := newIssuer(t)
+	iss.addKey(t, "rsa", newRSAKey(t))
+
+	a := newAuth(t, iss)
+
+	rotated, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(t, err)
+	iss.addKey(t, "ec", rotated)
+
+	claims, err := a.Verify(context.Background(), iss.token(t, "ec", rotated, nil))
+	assert.Nil(t, err)
+	assert.Equal(t, "user-1", claims.Subject)
+	assert.Equal(t, 2, iss.requests)
+}
+
+func TestAuth_Middleware(t *testing.T) {
+	iss := newIssuer(t)
+	key := newRSAKey(t)
+	iss.addKey(t, "rsa", key)
+
+	a := newAuth(t, iss)
+
+	h := a.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims, ok := authboot.FromContext(r.Context())
+		assert.True(t, ok)
+		_, _ = w.Write([]byte(claims.Subject))
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Authorization", "Bearer "+iss.token(t, "rsa", key, nil))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "user-1", rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Equal(t, "Bearer", rec.Header().Get("WWW-Authenticate"))
+	assert.Contains(t, rec.Body.String(), `"detail":"missing bearer token"`)
+
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Authorization", "Bearer "+iss.token(t, "rsa", key, jwt.MapClaims{"aud": "other"}))
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Equal(t,
+		`Bearer error="invalid_token", error_description="invalid token: token has a different audience"`,
+		rec.Header().Get("WWW-Authenticate"))
+}
+
+func TestAuth_UnaryServerInterceptor(t *testing.T) {
+	iss := newIssuer(t)
+	key := newRSAKey(t)
+	iss.addKey(t, "rsa", key)
+
+	a := newAuth(t, iss)
+	interceptor := a.UnaryServerInterceptor()
+
+	handler := func(ctx context.Context, req any) (any, error) {
+		claims, _ := authboot.FromContext(ctx)
+
+		return claims.Subject, nil
+	}
+
+	ctx := metadata.NewIncomingContext(context.Background(),
+		metadata.Pairs("authorization", "Bearer "+iss.token(t, "rsa", key, nil)))
+
+	resp, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{}, handler)
+	assert.Nil(t, err)
+	assert.Equal(t, "user-1", resp)
+
+	_, err = interceptor(context.Background(), nil, &grpc.UnaryServerInfo{}, handler)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	assert.Equal(t, "missing bearer token", status.Convert(err).Message())
+}
+
+func TestAuth_ErrorInvalidConfig(t *testing.T) {
+	a := &authboot.Auth{}
+	err := a.Configure(goboot.NewAppEnv("./testdata", "invalid"))
+	assert.EqualError(t, err, `config "auth.issuer" is required`)
+}
+
+func TestAuth_ErrorUnreachableIssuer(t *testing.T) {
+	iss := newIssuer(t)
+	iss.srv.Close()
+
+	env := goboot.NewAppEnv("./testdata", "")
+	env.Config.Set("auth.issuer", iss.url())
+
+	a := &authboot.Auth{}
+	assert.Nil(t, a.Configure(env))
+	assert.ErrorContains(t, a.Init(), `discovering issuer "`+iss.url()+`"`)
+}
@@ -0,0 +1,142 @@
+package authboot
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+var (
+	errMissingExpiry = errors.New("token has no expiry")
+	errExpired       = errors.New("token is expired")
+	errNotValidYet   = errors.New("token is not valid yet")
+	errIssuedLater   = errors.New("token is issued in the future")
+	errIssuer        = errors.New("token has a different issuer")
+	errAudience      = errors.New("token has a different audience")
+)
+
+// Claims are the verified claims of a token. Custom claims are read using
+// Get or Decode.
+type Claims struct {
+	jwt.RegisteredClaims
+
+	raw json.RawMessage
+}
+
+func (c *Claims) UnmarshalJSON(b []byte) error {
+	if err := json.Unmarshal(b, &c.RegisteredClaims); err != nil {
+		return err //nolint:wrapcheck
+	}
+
+	c.raw = append(json.RawMessage{}, b...)
+
+	return nil
+}
+
+// Decode unmarshals the claims into v, e.g. a struct with custom claims.
+func (c *Claims) Decode(v any) error {
+	if err := json.Unmarshal(c.raw, v); err != nil {
+		return fmt.Errorf("decoding claims: %w", err)
+	}
+
+	return nil
+}
+
+// Get returns the value of claim name, or nil if the token doesn't have it.
+func (c *Claims) Get(name string) any {
+	var claims map[string]any
+
+	_ = json.Unmarshal(c.raw, &claims)
+
+	return claims[name]
+}
+
+// Scopes returns the space-separated "scope" claim, or the "scp" claim some
+// issuers use instead.
+func (c *Claims) Scopes() []string {
+	switch scp := c.Get("scp").(type) {
+	case []any:
+		scopes := make([]string, 0, len(scp))
+
+		for _, s := range scp {
+			if s, ok := s.(string); ok {
+				scopes = append(scopes, s)
+			}
+		}
+
+		return scopes
+	case string:
+		return strings.Fields(scp)
+	}
+
+	scope, _ := c.Get("scope").(string)
+
+	return strings.Fields(scope)
+}
+
+// HasScope returns true if the token has scope, e.g. "read:users".
+func (c *Claims) HasScope(scope string) bool {
+	for _, s := range c.Scopes() {
+		if s == scope {
+			return true
+		}
+	}
+
+	return false
+}
+
+// validate checks the time claims allowing for config.Leeway, and the issuer
+// and audience.
+func (c *Claims) validate(now time.Time, config *AuthConfig) error {
+	if c.ExpiresAt == nil {
+		return errMissingExpiry
+	}
+
+	if now.After(c.ExpiresAt.Add(config.Leeway)) {
+		return errExpired
+	}
+
+	if c.NotBefore != nil && now.Add(config.Leeway).Before(c.NotBefore.Time) {
+		return errNotValidYet
+	}
+
+	if c.IssuedAt != nil && now.Add(config.Leeway).Before(c.IssuedAt.Time) {
+		return errIssuedLater
+	}
+
+	if c.Issuer != config.Issuer {
+		return errIssuer
+	}
+
+	if len(config.Audience) == 0 {
+		return nil
+	}
+
+	for _, aud := range config.Audience {
+		if c.VerifyAudience(aud, true) {
+			return nil
+		}
+	}
+
+	return errAudience
+}
+
+type claimsKey struct{}
+
+// WithClaims returns a context with the verified claims of a request.
+func WithClaims(ctx context.Context, claims *Claims) context.Context {
+	return context.WithValue(ctx, claimsKey{}, claims)
+}
+
+// FromContext returns the verified claims of the request of ctx, or false
+// if the request isn't authenticated.
+func FromContext(ctx context.Context) (*Claims, bool) {
+	claims, ok := ctx.Value(claimsKey{}).(*Claims)
+
+	return claims, ok
+}
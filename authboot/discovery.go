@@ -0,0 +1,43 @@
+package authboot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+var (
+	errMissingJWKSURI = errors.New("discovery document has no jwks_uri")
+	errIssuerMismatch = errors.New("discovery document has a different issuer")
+)
+
+// providerMetadata is the OpenID Connect discovery document of an issuer,
+// see https://openid.net/specs/openid-connect-discovery-1_0.html.
+type providerMetadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// discover returns the discovery document of issuer.
+func discover(ctx context.Context, client *http.Client, issuer string) (*providerMetadata, error) {
+	url := strings.TrimSuffix(issuer, "/") + "/.well-known/openid-configuration"
+
+	meta := &providerMetadata{}
+	if err := getJSON(ctx, client, url, meta); err != nil {
+		return nil, fmt.Errorf("discovering issuer %q: %w", issuer, err)
+	}
+
+	if meta.Issuer != issuer {
+		return nil, fmt.Errorf("discovering issuer %q: %w %q", issuer, errIssuerMismatch, meta.Issuer)
+	}
+
+	if meta.JWKSURI == "" {
+		return nil, fmt.Errorf("discovering issuer %q: %w", issuer, errMissingJWKSURI)
+	}
+
+	return meta, nil
+}
@@ -0,0 +1,71 @@
+package authboot
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// UnaryServerInterceptor rejects calls without a valid bearer token in the
+// "authorization" metadata with codes.Unauthenticated, and adds the claims
+// of valid tokens to the context, see FromContext.
+func (a *Auth) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx, err := a.authenticate(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor is the streaming equivalent of
+// UnaryServerInterceptor.
+func (a *Auth) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := a.authenticate(ss.Context())
+		if err != nil {
+			return err
+		}
+
+		return handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+func (a *Auth) authenticate(ctx context.Context) (context.Context, error) {
+	var header string
+
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get("authorization"); len(values) > 0 {
+			header = values[0]
+		}
+	}
+
+	token, ok := bearerToken(header)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, ErrMissingToken.Error()) //nolint:wrapcheck
+	}
+
+	claims, err := a.Verify(ctx, token)
+	if err != nil {
+		a.log.Debug().Err(err).Msg("unauthenticated call")
+
+		return nil, status.Error(codes.Unauthenticated, err.Error()) //nolint:wrapcheck
+	}
+
+	return WithClaims(ctx, claims), nil
+}
+
+// serverStream overrides the context of a stream.
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context //nolint:containedctx
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,55 @@
+package authboot
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Middleware responds 401 Unauthorized to requests without a valid bearer
+// token in the Authorization header, and adds the claims of valid tokens to
+// the request context, see FromContext.
+func (a *Auth) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := bearerToken(r.Header.Get("Authorization"))
+		if !ok {
+			writeUnauthorized(w, ErrMissingToken)
+
+			return
+		}
+
+		claims, err := a.Verify(r.Context(), token)
+		if err != nil {
+			a.log.Debug().Err(err).Str("path", r.URL.Path).Msg("unauthorized request")
+			writeUnauthorized(w, err)
+
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(WithClaims(r.Context(), claims)))
+	})
+}
+
+// bearerToken returns the token of an Authorization header value, e.g.
+// "Bearer eyJhbGci...".
+func bearerToken(header string) (string, bool) {
+	scheme, token, ok := strings.Cut(header, " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") || strings.TrimSpace(token) == "" {
+		return "", false
+	}
+
+	return strings.TrimSpace(token), true
+}
+
+// writeUnauthorized responds 401 with a WWW-Authenticate header as described
+// in RFC 6750.
+func writeUnauthorized(w http.ResponseWriter, err error) {
+	challenge := "Bearer"
+	if errors.Is(err, ErrInvalidToken) {
+		challenge = fmt.Sprintf(`Bearer error="invalid_token", error_description=%q`, err.Error())
+	}
+
+	w.Header().Set("WWW-Authenticate", challenge)
+	writeProblem(w, http.StatusUnauthorized, err)
+}
@@ -0,0 +1,251 @@
+package authboot
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const maxResponseSize = 1 << 20 // 1 MiB
+
+var (
+	errUnknownKey     = errors.New("token is signed by an unknown key")
+	errKeyAlgorithm   = errors.New("token algorithm doesn't match the key")
+	errUnsupportedKey = errors.New("unsupported key")
+	errStatus         = errors.New("unexpected status")
+)
+
+// keySet caches the public keys of a JWKS by key ID.
+type keySet struct {
+	url        string
+	client     *http.Client
+	minRefresh time.Duration
+
+	// refreshMu makes concurrent lookups of an unknown key fetch once
+	refreshMu sync.Mutex
+
+	mu        sync.RWMutex
+	keys      map[string]*publicKey
+	refreshed time.Time
+}
+
+type publicKey struct {
+	key any
+	alg string
+}
+
+func newKeySet(url string, client *http.Client, minRefresh time.Duration) *keySet {
+	return &keySet{url: url, client: client, minRefresh: minRefresh}
+}
+
+// key returns the public key with kid to verify a token signed using alg.
+// An unknown kid refreshes the keys, unless they were refreshed less than
+// minRefresh ago. A token without kid uses the only key of the JWKS.
+func (s *keySet) key(ctx context.Context, kid string, alg string) (any, error) {
+	k, ok := s.lookup(kid)
+	if !ok {
+		s.refreshMu.Lock()
+
+		if k, ok = s.lookup(kid); !ok && s.sinceRefresh() >= s.minRefresh {
+			if err := s.refresh(ctx); err != nil {
+				s.refreshMu.Unlock()
+
+				return nil, err
+			}
+
+			k, ok = s.lookup(kid)
+		}
+
+		s.refreshMu.Unlock()
+	}
+
+	if !ok {
+		return nil, fmt.Errorf("%w %q", errUnknownKey, kid)
+	}
+
+	if k.alg != "" && k.alg != alg {
+		return nil, errKeyAlgorithm
+	}
+
+	return k.key, nil
+}
+
+func (s *keySet) lookup(kid string) (*publicKey, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if kid == "" && len(s.keys) == 1 {
+		for _, k := range s.keys {
+			return k, true
+		}
+	}
+
+	k, ok := s.keys[kid]
+
+	return k, ok
+}
+
+func (s *keySet) sinceRefresh() time.Duration {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return time.Since(s.refreshed)
+}
+
+// refresh replaces the keys with those of the JWKS. Keys of unsupported
+// types or not used for signatures are skipped.
+func (s *keySet) refresh(ctx context.Context) error {
+	var jwks struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+
+	if err := getJSON(ctx, s.client, s.url, &jwks); err != nil {
+		return fmt.Errorf("fetching JWKS: %w", err)
+	}
+
+	keys := make(map[string]*publicKey, len(jwks.Keys))
+
+	for _, jwk := range jwks.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+
+		key, err := jwk.publicKey()
+		if err != nil {
+			continue
+		}
+
+		keys[jwk.Kid] = &publicKey{key: key, alg: jwk.Alg}
+	}
+
+	s.mu.Lock()
+	s.keys = keys
+	s.refreshed = time.Now()
+	s.mu.Unlock()
+
+	return nil
+}
+
+// jsonWebKey is a public key of a JWKS, see RFC 7517.
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+
+	// RSA
+	N string `json:"n"`
+	E string `json:"e"`
+
+	// EC and OKP
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func (k *jsonWebKey) publicKey() (any, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		curve, err := ellipticCurve(k.Crv)
+		if err != nil {
+			return nil, err
+		}
+
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+
+		if !curve.IsOnCurve(x, y) {
+			return nil, fmt.Errorf("%w: point isn't on curve %s", errUnsupportedKey, k.Crv)
+		}
+
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	case "OKP":
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil || k.Crv != "Ed25519" || len(x) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("%w: OKP curve %s", errUnsupportedKey, k.Crv)
+		}
+
+		return ed25519.PublicKey(x), nil
+	default:
+		return nil, fmt.Errorf("%w: type %s", errUnsupportedKey, k.Kty)
+	}
+}
+
+func ellipticCurve(crv string) (elliptic.Curve, error) {
+	switch crv {
+	case "P-256":
+		return elliptic.P256(), nil
+	case "P-384":
+		return elliptic.P384(), nil
+	case "P-521":
+		return elliptic.P521(), nil
+	default:
+		return nil, fmt.Errorf("%w: EC curve %s", errUnsupportedKey, crv)
+	}
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+	if err != nil || len(b) == 0 {
+		return nil, fmt.Errorf("%w: invalid base64url value %q", errUnsupportedKey, s)
+	}
+
+	return new(big.Int).SetBytes(b), nil
+}
+
+// getJSON decodes the JSON response of url into v.
+func getJSON(ctx context.Context, client *http.Client, url string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("requesting %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("requesting %s: %w %d", url, errStatus, resp.StatusCode)
+	}
+
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxResponseSize)).Decode(v); err != nil {
+		return fmt.Errorf("decoding %s: %w", url, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,370 @@
+package authboot
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/nielskrijger/goboot"
+	"github.com/rs/zerolog"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
+)
+
+const randomBytes = 32
+
+var (
+	errMissingOIDCConfig = errors.New("missing \"oidc\" configuration")
+	errUnknownProvider   = errors.New("unknown OIDC provider")
+	errStateMismatch     = errors.New("authorization state doesn't match")
+	errNonceMismatch     = errors.New("ID token nonce doesn't match")
+	errMissingEndpoints  = errors.New("requires an issuer or both authUrl and tokenUrl")
+	errMissingClientID   = errors.New("requires a clientId")
+)
+
+type OIDCConfig struct {
+	// Providers by name, e.g. "google". Viper lowercases the names.
+	Providers map[string]*ProviderConfig `yaml:"providers"`
+
+	// Timeout of requests to providers. Default is 10 seconds.
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+type ProviderConfig struct {
+	// Issuer whose discovery document provides the endpoints and the JWKS
+	// to verify ID tokens, e.g. "https://accounts.google.com".
+	Issuer string `yaml:"issuer"`
+
+	ClientID string `yaml:"clientId"`
+
+	// The client secret, read from a file or environment variable if
+	// prefixed with "file:" or "env:", see goboot.ResolveSecret.
+	ClientSecret string `yaml:"clientSecret"`
+
+	// Callback URL of the authorization-code flow.
+	RedirectURL string `yaml:"redirectUrl"`
+
+	// Scopes requested. Default is "openid".
+	Scopes []string `yaml:"scopes"`
+
+	// Audience of client-credentials tokens, sent as "audience" parameter
+	// which some providers require, e.g. Auth0.
+	Audience string `yaml:"audience"`
+
+	// Endpoints of OAuth2 providers without discovery, overriding the
+	// discovered endpoints.
+	AuthURL  string `yaml:"authUrl"`
+	TokenURL string `yaml:"tokenUrl"`
+}
+
+// OIDC implements the AppService interface and is a client of the OAuth2 and
+// OpenID Connect providers configured in "oidc":
+//
+//	oidc:
+//	  providers:
+//	    google:
+//	      issuer: https://accounts.google.com
+//	      clientId: 123.apps.googleusercontent.com
+//	      clientSecret: env:GOOGLE_CLIENT_SECRET
+//	      redirectUrl: https://example.com/auth/callback
+//	      scopes: [openid, email]
+//
+// See Provider for the supported flows.
+type OIDC struct {
+	HTTPClient *http.Client
+
+	config    *OIDCConfig
+	providers map[string]*Provider
+	log       zerolog.Logger
+}
+
+func (o *OIDC) Name() string {
+	return "OIDC"
+}
+
+// Configure loads the "oidc" configuration and resolves the client secrets.
+func (o *OIDC) Configure(env *goboot.AppEnv) error {
+	o.log = env.Log
+	o.config = &OIDCConfig{}
+
+	if !env.Config.InConfig("oidc") {
+		return errMissingOIDCConfig
+	}
+
+	if err := env.Config.Sub("oidc").Unmarshal(o.config); err != nil {
+		return fmt.Errorf("parsing oidc configuration: %w", err)
+	}
+
+	if o.config.Timeout == 0 {
+		o.config.Timeout = defaultTimeout
+	}
+
+	if o.HTTPClient == nil {
+		o.HTTPClient = &http.Client{Timeout: o.config.Timeout}
+	}
+
+	o.providers = make(map[string]*Provider, len(o.config.Providers))
+
+	for name, config := range o.config.Providers {
+		if config.ClientID == "" {
+			return fmt.Errorf("oidc provider %q %w", name, errMissingClientID)
+		}
+
+		if config.Issuer == "" && (config.AuthURL == "" || config.TokenURL == "") {
+			return fmt.Errorf("oidc provider %q %w", name, errMissingEndpoints)
+		}
+
+		secret, err := goboot.ResolveSecret(config.ClientSecret)
+		if err != nil {
+			return fmt.Errorf("oidc provider %q: %w", name, err)
+		}
+
+		if len(config.Scopes) == 0 {
+			config.Scopes = []string{"openid"}
+		}
+
+		o.providers[name] = &Provider{name: name, config: config, clientSecret: secret, client: o.HTTPClient}
+	}
+
+	return nil
+}
+
+// Init discovers the endpoints and JWKS of the providers with an issuer.
+func (o *OIDC) Init() error {
+	names := make([]string, 0, len(o.providers))
+	for name := range o.providers {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		if err := o.providers[name].init(context.Background()); err != nil {
+			return fmt.Errorf("oidc provider %q: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+// Provider returns the provider with name.
+func (o *OIDC) Provider(name string) (*Provider, error) {
+	p, ok := o.providers[name]
+	if !ok {
+		return nil, fmt.Errorf("%w %q", errUnknownProvider, name)
+	}
+
+	return p, nil
+}
+
+func (o *OIDC) Close() error {
+	return nil
+}
+
+// Provider is an OAuth2 or OpenID Connect provider. It supports:
+//
+//   - the authorization-code flow with PKCE to sign in users, see
+//     NewAuthRequest and Exchange;
+//   - refreshing tokens of users, see Refresh and TokenSource;
+//   - client-credentials tokens for service-to-service calls, see Token and
+//     Client.
+type Provider struct {
+	name         string
+	config       *ProviderConfig
+	clientSecret string
+	client       *http.Client
+
+	oauth        *oauth2.Config
+	clientTokens oauth2.TokenSource
+	verifier     *verifier
+}
+
+func (p *Provider) init(ctx context.Context) error {
+	endpoint := oauth2.Endpoint{AuthURL: p.config.AuthURL, TokenURL: p.config.TokenURL}
+
+	if p.config.Issuer != "" {
+		meta, err := discover(ctx, p.client, p.config.Issuer)
+		if err != nil {
+			return err
+		}
+
+		if endpoint.AuthURL == "" {
+			endpoint.AuthURL = meta.AuthorizationEndpoint
+		}
+
+		if endpoint.TokenURL == "" {
+			endpoint.TokenURL = meta.TokenEndpoint
+		}
+
+		config := &AuthConfig{Issuer: p.config.Issuer, Audience: []string{p.config.ClientID}}
+		config.setDefaults()
+
+		// keys are fetched when verifying the first ID token
+		p.verifier = newVerifier(config, newKeySet(meta.JWKSURI, p.client, config.MinRefreshInterval))
+	}
+
+	p.oauth = &oauth2.Config{
+		ClientID:     p.config.ClientID,
+		ClientSecret: p.clientSecret,
+		Endpoint:     endpoint,
+		RedirectURL:  p.config.RedirectURL,
+		Scopes:       p.config.Scopes,
+	}
+
+	cc := &clientcredentials.Config{
+		ClientID:     p.config.ClientID,
+		ClientSecret: p.clientSecret,
+		TokenURL:     endpoint.TokenURL,
+		Scopes:       withoutOpenID(p.config.Scopes),
+	}
+
+	if p.config.Audience != "" {
+		cc.EndpointParams = map[string][]string{"audience": {p.config.Audience}}
+	}
+
+	// reuses tokens until they expire
+	p.clientTokens = cc.TokenSource(p.context(context.Background()))
+
+	return nil
+}
+
+// withoutOpenID returns scopes without "openid", which only applies to
+// users.
+func withoutOpenID(scopes []string) []string {
+	var result []string
+
+	for _, s := range scopes {
+		if s != "openid" {
+			result = append(result, s)
+		}
+	}
+
+	return result
+}
+
+// context returns ctx using the HTTP client of the provider for oauth2
+// requests.
+func (p *Provider) context(ctx context.Context) context.Context {
+	return context.WithValue(ctx, oauth2.HTTPClient, p.client)
+}
+
+// AuthRequest is an authorization request of the authorization-code flow.
+// Store its values, e.g. in an encrypted cookie, until the user returns to
+// the redirect URL.
+type AuthRequest struct {
+	// URL to redirect the user to.
+	URL string
+
+	State        string
+	Nonce        string
+	CodeVerifier string
+}
+
+// NewAuthRequest returns an authorization request with a random state,
+// nonce and PKCE code verifier. Use opts to add parameters, e.g.
+// oauth2.SetAuthURLParam("prompt", "login").
+func (p *Provider) NewAuthRequest(opts ...oauth2.AuthCodeOption) (*AuthRequest, error) {
+	req := &AuthRequest{}
+
+	for _, v := range []*string{&req.State, &req.Nonce, &req.CodeVerifier} {
+		b := make([]byte, randomBytes)
+		if _, err := rand.Read(b); err != nil {
+			return nil, fmt.Errorf("generating auth request: %w", err)
+		}
+
+		*v = base64.RawURLEncoding.EncodeToString(b)
+	}
+
+	challenge := sha256.Sum256([]byte(req.CodeVerifier))
+
+	opts = append(opts,
+		oauth2.SetAuthURLParam("nonce", req.Nonce),
+		oauth2.SetAuthURLParam("code_challenge", base64.RawURLEncoding.EncodeToString(challenge[:])),
+		oauth2.SetAuthURLParam("code_challenge_method", "S256"),
+	)
+
+	req.URL = p.oauth.AuthCodeURL(req.State, opts...)
+
+	return req, nil
+}
+
+// Tokens are the tokens of a user.
+type Tokens struct {
+	*oauth2.Token
+
+	// IDToken is the raw ID token, empty if the provider returned none.
+	IDToken string
+
+	// Claims of the verified ID token, nil if the provider returned none.
+	Claims *Claims
+}
+
+// Exchange exchanges the authorization code of the redirect to the callback
+// URL for tokens, the state and code are the query parameters of the
+// callback. The ID token is verified if the provider has an issuer.
+func (p *Provider) Exchange(ctx context.Context, req *AuthRequest, state string, code string) (*Tokens, error) {
+	if state == "" || state != req.State {
+		return nil, errStateMismatch
+	}
+
+	token, err := p.oauth.Exchange(p.context(ctx), code, oauth2.SetAuthURLParam("code_verifier", req.CodeVerifier))
+	if err != nil {
+		return nil, fmt.Errorf("exchanging %s authorization code: %w", p.name, err)
+	}
+
+	tokens := &Tokens{Token: token}
+	tokens.IDToken, _ = token.Extra("id_token").(string)
+
+	if tokens.IDToken == "" || p.verifier == nil {
+		return tokens, nil
+	}
+
+	if tokens.Claims, err = p.verifier.verify(ctx, tokens.IDToken); err != nil {
+		return nil, fmt.Errorf("verifying %s ID token: %w", p.name, err)
+	}
+
+	if nonce, _ := tokens.Claims.Get("nonce").(string); nonce != req.Nonce {
+		return nil, fmt.Errorf("verifying %s ID token: %w", p.name, errNonceMismatch)
+	}
+
+	return tokens, nil
+}
+
+// Refresh returns new tokens using refreshToken.
+func (p *Provider) Refresh(ctx context.Context, refreshToken string) (*oauth2.Token, error) {
+	token, err := p.oauth.TokenSource(p.context(ctx), &oauth2.Token{RefreshToken: refreshToken}).Token()
+	if err != nil {
+		return nil, fmt.Errorf("refreshing %s token: %w", p.name, err)
+	}
+
+	return token, nil
+}
+
+// TokenSource returns a token source refreshing token when it expires, e.g.
+// to call APIs on behalf of a user using oauth2.NewClient.
+func (p *Provider) TokenSource(ctx context.Context, token *oauth2.Token) oauth2.TokenSource {
+	return p.oauth.TokenSource(p.context(ctx), token)
+}
+
+// Token returns a client-credentials token of the service, which is reused
+// until it expires.
+func (p *Provider) Token() (*oauth2.Token, error) {
+	token, err := p.clientTokens.Token()
+	if err != nil {
+		return nil, fmt.Errorf("requesting %s client-credentials token: %w", p.name, err)
+	}
+
+	return token, nil
+}
+
+// Client returns an HTTP client authenticating service-to-service requests
+// using client-credentials tokens, see Token.
+func (p *Provider) Client(ctx context.Context) *http.Client {
+	return oauth2.NewClient(p.context(ctx), p.clientTokens)
+}
@@ -0,0 +1,195 @@
+package authboot_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/nielskrijger/goboot"
+	"github.com/nielskrijger/goboot/authboot"
+	"github.com/stretchr/testify/assert"
+)
+
+// provider is an issuer with a token endpoint.
+type provider struct {
+	*issuer
+
+	// challenge is the PKCE code challenge of the authorization request
+	challenge string
+	nonce     string
+
+	clientTokens atomic.Int32
+}
+
+func newProvider(t *testing.T) *provider {
+	t.Helper()
+
+	p := &provider{issuer: newIssuer(t)}
+	key := newRSAKey(t)
+	p.addKey(t, "rsa", key)
+
+	p.mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		if id, secret, ok := r.BasicAuth(); !ok || id != "client-1" || secret != "secret-1" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"error":"invalid_client"}`))
+
+			return
+		}
+
+		_ = r.ParseForm()
+
+		resp := map[string]any{"token_type": "Bearer", "expires_in": 3600}
+
+		switch r.Form.Get("grant_type") {
+		case "authorization_code":
+			verifier := sha256.Sum256([]byte(r.Form.Get("code_verifier")))
+			if r.Form.Get("code") != "code-1" || base64.RawURLEncoding.EncodeToString(verifier[:]) != p.challenge {
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = w.Write([]byte(`{"error":"invalid_grant"}`))
+
+				return
+			}
+
+			resp["access_token"] = "access-1"
+			resp["refresh_token"] = "refresh-1"
+			resp["id_token"] = p.token(t, "rsa", key, jwt.MapClaims{"aud": "client-1", "nonce": p.nonce})
+		case "refresh_token":
+			resp["access_token"] = "access-2"
+		case "client_credentials":
+			assert.Equal(t, "api", r.Form.Get("audience"))
+			assert.Equal(t, "email", r.Form.Get("scope"))
+
+			p.clientTokens.Add(1)
+			resp["access_token"] = "service-token"
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(resp)
+	})
+
+	return p
+}
+
+func newOIDC(t *testing.T, p *provider) *authboot.Provider {
+	t.Helper()
+
+	t.Setenv("GOBOOT_TEST_CLIENT_SECRET", "secret-1")
+
+	env := goboot.NewAppEnv("./testdata", "")
+	env.Config.Set("oidc.providers.example.issuer", p.url())
+
+	o := &authboot.OIDC{}
+	assert.Nil(t, o.Configure(env))
+	assert.Nil(t, o.Init())
+
+	t.Cleanup(func() { _ = o.Close() })
+
+	provider, err := o.Provider("example")
+	assert.Nil(t, err)
+
+	return provider
+}
+
+func TestOIDC_AuthorizationCodeFlow(t *testing.T) {
+	p := newProvider(t)
+	provider := newOIDC(t, p)
+
+	req, err := provider.NewAuthRequest()
+	assert.Nil(t, err)
+
+	u, err := url.Parse(req.URL)
+	assert.Nil(t, err)
+	assert.Equal(t, p.srv.URL+"/authorize", u.Scheme+"://"+u.Host+u.Path)
+
+	q := u.Query()
+	assert.Equal(t, "client-1", q.Get("client_id"))
+	assert.Equal(t, "https://app.example.com/callback", q.Get("redirect_uri"))
+	assert.Equal(t, "openid email", q.Get("scope"))
+	assert.Equal(t, req.State, q.Get("state"))
+	assert.Equal(t, req.Nonce, q.Get("nonce"))
+	assert.Equal(t, "S256", q.Get("code_challenge_method"))
+
+	p.challenge = q.Get("code_challenge")
+	p.nonce = req.Nonce
+
+	tokens, err := provider.Exchange(context.Background(), req, req.State, "code-1")
+	assert.Nil(t, err)
+	assert.Equal(t, "access-1", tokens.AccessToken)
+	assert.Equal(t, "refresh-1", tokens.RefreshToken)
+	assert.Equal(t, "user-1", tokens.Claims.Subject)
+
+	token, err := provider.Refresh(context.Background(), tokens.RefreshToken)
+	assert.Nil(t, err)
+	assert.Equal(t, "access-2", token.AccessToken)
+}
+
+func TestOIDC_ExchangeErrors(t *testing.T) {
+	p := newProvider(t)
+	provider := newOIDC(t, p)
+
+	req, err := provider.NewAuthRequest()
+	assert.Nil(t, err)
+
+	u, _ := url.Parse(req.URL)
+	p.challenge = u.Query().Get("code_challenge")
+
+	_, err = provider.Exchange(context.Background(), req, "other-state", "code-1")
+	assert.EqualError(t, err, "authorization state doesn't match")
+
+	p.nonce = "other-nonce"
+	_, err = provider.Exchange(context.Background(), req, req.State, "code-1")
+	assert.EqualError(t, err, "verifying example ID token: ID token nonce doesn't match")
+
+	req.CodeVerifier = "other-verifier"
+	_, err = provider.Exchange(context.Background(), req, req.State, "code-1")
+	assert.ErrorContains(t, err, "exchanging example authorization code: oauth2: cannot fetch token: 400 Bad Request")
+}
+
+func TestOIDC_ClientCredentials(t *testing.T) {
+	p := newProvider(t)
+	provider := newOIDC(t, p)
+
+	for i := 0; i < 2; i++ {
+		token, err := provider.Token()
+		assert.Nil(t, err)
+		assert.Equal(t, "service-token", token.AccessToken)
+		assert.WithinDuration(t, time.Now().Add(time.Hour), token.Expiry, time.Minute)
+	}
+
+	assert.Equal(t, int32(1), p.clientTokens.Load())
+
+	p.mux.HandleFunc("/api", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.Header.Get("Authorization")))
+	})
+
+	resp, err := provider.Client(context.Background()).Get(p.srv.URL + "/api")
+	assert.Nil(t, err)
+
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	assert.Nil(t, err)
+	assert.Equal(t, "Bearer service-token", string(body))
+}
+
+func TestOIDC_ErrorInvalidConfig(t *testing.T) {
+	t.Setenv("GOBOOT_TEST_CLIENT_SECRET", "secret-1")
+
+	o := &authboot.OIDC{}
+	err := o.Configure(goboot.NewAppEnv("./testdata", "invalid"))
+	assert.EqualError(t, err, `oidc provider "broken" requires a clientId`)
+
+	o = &authboot.OIDC{}
+	assert.Nil(t, o.Configure(goboot.NewAppEnv("./testdata", "")))
+
+	_, err = o.Provider("unknown")
+	assert.EqualError(t, err, `unknown OIDC provider "unknown"`)
+}
@@ -0,0 +1,104 @@
+// Package pgauth stores the API keys of authboot in Postgres.
+package pgauth
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/nielskrijger/goboot/authboot"
+	"github.com/nielskrijger/goboot/pgboot"
+)
+
+// APIKeysTable is the table of the APIKeyStore.
+const APIKeysTable = "api_keys"
+
+// APIKeyStore is an authboot.APIKeyStore storing keys in the api_keys table,
+// see CreateTable.
+type APIKeyStore struct {
+	pg *pgboot.Postgres
+}
+
+func NewAPIKeyStore(pg *pgboot.Postgres) *APIKeyStore {
+	return &APIKeyStore{pg: pg}
+}
+
+type apiKeyRow struct {
+	ID        string     `db:"id"`
+	Hash      string     `db:"hash"`
+	Principal string     `db:"principal"`
+	Scopes    string     `db:"scopes"`
+	RateLimit int        `db:"rate_limit"`
+	CreatedAt time.Time  `db:"created_at"`
+	ExpiresAt *time.Time `db:"expires_at"`
+	RevokedAt *time.Time `db:"revoked_at"`
+}
+
+// CreateTable creates the api_keys table if it doesn't exist, call it from
+// Init or add the table to the migrations instead.
+func (s *APIKeyStore) CreateTable(ctx context.Context) error {
+	_, err := s.pg.DB.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS `+APIKeysTable+` (
+		id text PRIMARY KEY,
+		hash text NOT NULL,
+		principal text NOT NULL,
+		scopes text NOT NULL DEFAULT '',
+		rate_limit int NOT NULL DEFAULT 0,
+		created_at timestamptz NOT NULL DEFAULT now(),
+		expires_at timestamptz,
+		revoked_at timestamptz
+	)`)
+	if err != nil {
+		return fmt.Errorf("creating %s table: %w", APIKeysTable, err)
+	}
+
+	return nil
+}
+
+func (s *APIKeyStore) GetAPIKey(ctx context.Context, id string) (*authboot.APIKey, error) {
+	var row apiKeyRow
+
+	err := s.pg.DB.GetContext(ctx, &row, `SELECT id, hash, principal, scopes, rate_limit, created_at, expires_at, revoked_at
+		FROM `+APIKeysTable+` WHERE id = $1`, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, authboot.ErrAPIKeyNotFound
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("reading API key: %w", err)
+	}
+
+	return &authboot.APIKey{
+		ID:        row.ID,
+		Hash:      row.Hash,
+		Principal: row.Principal,
+		Scopes:    strings.Fields(row.Scopes),
+		RateLimit: row.RateLimit,
+		CreatedAt: row.CreatedAt,
+		ExpiresAt: row.ExpiresAt,
+		RevokedAt: row.RevokedAt,
+	}, nil
+}
+
+// SaveAPIKey inserts or updates key, the scopes are stored space-separated.
+func (s *APIKeyStore) SaveAPIKey(ctx context.Context, key *authboot.APIKey) error {
+	_, err := s.pg.DB.ExecContext(ctx, `INSERT INTO `+APIKeysTable+`
+		(id, hash, principal, scopes, rate_limit, created_at, expires_at, revoked_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (id) DO UPDATE SET
+			hash = excluded.hash,
+			principal = excluded.principal,
+			scopes = excluded.scopes,
+			rate_limit = excluded.rate_limit,
+			expires_at = excluded.expires_at,
+			revoked_at = excluded.revoked_at`,
+		key.ID, key.Hash, key.Principal, strings.Join(key.Scopes, " "), key.RateLimit, key.CreatedAt,
+		key.ExpiresAt, key.RevokedAt)
+	if err != nil {
+		return fmt.Errorf("saving API key: %w", err)
+	}
+
+	return nil
+}
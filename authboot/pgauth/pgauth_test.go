@@ -0,0 +1,55 @@
+package pgauth_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nielskrijger/goboot"
+	"github.com/nielskrijger/goboot/authboot"
+	"github.com/nielskrijger/goboot/authboot/pgauth"
+	"github.com/nielskrijger/goboot/pgboot"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPIKeyStore(t *testing.T) {
+	env := goboot.NewAppEnv("./testdata", "")
+	pg := &pgboot.Postgres{}
+	require.Nil(t, pg.Configure(env))
+
+	defer pg.Close()
+
+	ctx := context.Background()
+	store := pgauth.NewAPIKeyStore(pg)
+	require.Nil(t, store.CreateTable(ctx))
+
+	keys := authboot.NewAPIKeys(store, nil)
+	require.Nil(t, keys.Configure(env))
+
+	expiresAt := time.Now().Add(time.Hour).UTC().Truncate(time.Microsecond)
+
+	apiKey, created, err := keys.Create(ctx, "service-1", authboot.APIKeyOptions{
+		Scopes:    []string{"read", "write"},
+		RateLimit: 10,
+		ExpiresAt: &expiresAt,
+	})
+	require.Nil(t, err)
+
+	key, err := keys.Verify(ctx, apiKey)
+	require.Nil(t, err)
+	assert.Equal(t, created.ID, key.ID)
+	assert.Equal(t, "service-1", key.Principal)
+	assert.Equal(t, []string{"read", "write"}, key.Scopes)
+	assert.Equal(t, 10, key.RateLimit)
+	require.NotNil(t, key.ExpiresAt)
+	assert.True(t, expiresAt.Equal(*key.ExpiresAt))
+
+	assert.Nil(t, keys.Revoke(ctx, created.ID))
+
+	_, err = keys.Verify(ctx, apiKey)
+	assert.EqualError(t, err, "invalid API key: key is revoked")
+
+	_, err = store.GetAPIKey(ctx, "unknown")
+	assert.ErrorIs(t, err, authboot.ErrAPIKeyNotFound)
+}
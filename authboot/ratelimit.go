@@ -0,0 +1,58 @@
+package authboot
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryRateLimiter is a RateLimiter counting requests per instance.
+type MemoryRateLimiter struct {
+	mu      sync.Mutex
+	windows map[string]*rateWindow
+	swept   time.Time
+}
+
+type rateWindow struct {
+	count int
+	reset time.Time
+}
+
+func NewMemoryRateLimiter() *MemoryRateLimiter {
+	return &MemoryRateLimiter{windows: make(map[string]*rateWindow)}
+}
+
+func (l *MemoryRateLimiter) Allow(_ context.Context, key string, limit int, window time.Duration) (bool, time.Duration, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+
+	w, ok := l.windows[key]
+	if !ok || !now.Before(w.reset) {
+		if now.Sub(l.swept) >= window {
+			l.removeExpired(now)
+			l.swept = now
+		}
+
+		w = &rateWindow{reset: now.Add(window)}
+		l.windows[key] = w
+	}
+
+	w.count++
+
+	if w.count > limit {
+		return false, w.reset.Sub(now), nil
+	}
+
+	return true, 0, nil
+}
+
+// removeExpired removes the windows that reset before now.
+func (l *MemoryRateLimiter) removeExpired(now time.Time) {
+	for key, w := range l.windows {
+		if !now.Before(w.reset) {
+			delete(l.windows, key)
+		}
+	}
+}
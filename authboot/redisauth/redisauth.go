@@ -0,0 +1,91 @@
+// Package redisauth stores the API keys and rate limits of authboot in Redis.
+package redisauth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/nielskrijger/goboot/authboot"
+	"github.com/nielskrijger/goboot/redisboot"
+	"github.com/redis/go-redis/v9"
+)
+
+// rateLimitScript increments the counter of the current window and returns
+// the count and the milliseconds until the window resets.
+var rateLimitScript = redis.NewScript(`
+local count = redis.call("incr", KEYS[1])
+if count == 1 then
+	redis.call("pexpire", KEYS[1], ARGV[1])
+end
+return {count, redis.call("pttl", KEYS[1])}`)
+
+// APIKeyStore is an authboot.APIKeyStore storing keys as JSON in
+// "apikey:{id}". Use a Redis instance that never evicts keys.
+type APIKeyStore struct {
+	redis *redisboot.Redis
+}
+
+func NewAPIKeyStore(redis *redisboot.Redis) *APIKeyStore {
+	return &APIKeyStore{redis: redis}
+}
+
+func (k *APIKeyStore) GetAPIKey(ctx context.Context, id string) (*authboot.APIKey, error) {
+	b, err := k.redis.Client.Get(ctx, k.key(id)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, authboot.ErrAPIKeyNotFound
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("reading API key: %w", err)
+	}
+
+	key := &authboot.APIKey{}
+	if err := json.Unmarshal(b, key); err != nil {
+		return nil, fmt.Errorf("decoding API key: %w", err)
+	}
+
+	return key, nil
+}
+
+func (k *APIKeyStore) SaveAPIKey(ctx context.Context, key *authboot.APIKey) error {
+	b, err := json.Marshal(key)
+	if err != nil {
+		return fmt.Errorf("encoding API key: %w", err)
+	}
+
+	if err := k.redis.Client.Set(ctx, k.key(key.ID), b, 0).Err(); err != nil {
+		return fmt.Errorf("saving API key: %w", err)
+	}
+
+	return nil
+}
+
+func (k *APIKeyStore) key(id string) string {
+	return k.redis.Key("apikey:" + id)
+}
+
+// RateLimiter is an authboot.RateLimiter counting requests in fixed windows
+// as "ratelimit:{key}", so limits apply across instances.
+type RateLimiter struct {
+	redis *redisboot.Redis
+}
+
+func NewRateLimiter(redis *redisboot.Redis) *RateLimiter {
+	return &RateLimiter{redis: redis}
+}
+
+func (l *RateLimiter) Allow(ctx context.Context, key string, limit int, window time.Duration) (bool, time.Duration, error) {
+	res, err := rateLimitScript.Run(ctx, l.redis.Client, []string{l.redis.Key("ratelimit:" + key)}, window.Milliseconds()).Int64Slice()
+	if err != nil {
+		return false, 0, fmt.Errorf("rate limiting %q: %w", key, err)
+	}
+
+	if res[0] > int64(limit) {
+		return false, time.Duration(res[1]) * time.Millisecond, nil
+	}
+
+	return true, 0, nil
+}
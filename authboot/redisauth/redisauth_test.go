@@ -0,0 +1,83 @@
+package redisauth_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/nielskrijger/goboot"
+	"github.com/nielskrijger/goboot/authboot"
+	"github.com/nielskrijger/goboot/authboot/redisauth"
+	"github.com/nielskrijger/goboot/redisboot"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPIKeyStore(t *testing.T) {
+	env := goboot.NewAppEnv("./testdata", "")
+	r := &redisboot.Redis{}
+	require.Nil(t, r.Configure(env))
+
+	defer r.Close()
+
+	keys := authboot.NewAPIKeys(redisauth.NewAPIKeyStore(r), redisauth.NewRateLimiter(r))
+	require.Nil(t, keys.Configure(env))
+
+	ctx := context.Background()
+
+	apiKey, created, err := keys.Create(ctx, "service-1", authboot.APIKeyOptions{Scopes: []string{"read"}, RateLimit: 2})
+	require.Nil(t, err)
+
+	key, err := keys.Verify(ctx, apiKey)
+	require.Nil(t, err)
+	assert.Equal(t, created.ID, key.ID)
+	assert.Equal(t, "service-1", key.Principal)
+	assert.Equal(t, []string{"read"}, key.Scopes)
+
+	h := keys.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	codes := make([]int, 3)
+
+	for i := range codes {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("X-API-Key", apiKey)
+
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		codes[i] = rec.Code
+	}
+
+	assert.Equal(t, []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests}, codes)
+
+	assert.Nil(t, keys.Revoke(ctx, created.ID))
+
+	_, err = keys.Verify(ctx, apiKey)
+	assert.EqualError(t, err, "invalid API key: key is revoked")
+
+	_, err = redisauth.NewAPIKeyStore(r).GetAPIKey(ctx, "unknown")
+	assert.ErrorIs(t, err, authboot.ErrAPIKeyNotFound)
+}
+
+func TestRateLimiter(t *testing.T) {
+	r := &redisboot.Redis{}
+	require.Nil(t, r.Configure(goboot.NewAppEnv("./testdata", "")))
+
+	defer r.Close()
+
+	limiter := redisauth.NewRateLimiter(r)
+	ctx := context.Background()
+	key := "test:" + time.Now().String()
+
+	for i := 0; i < 2; i++ {
+		allowed, _, err := limiter.Allow(ctx, key, 2, time.Minute)
+		assert.Nil(t, err)
+		assert.True(t, allowed)
+	}
+
+	allowed, retryAfter, err := limiter.Allow(ctx, key, 2, time.Minute)
+	assert.Nil(t, err)
+	assert.False(t, allowed)
+	assert.InDelta(t, time.Minute, retryAfter, float64(time.Second))
+}
@@ -0,0 +1,56 @@
+package authboot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// verifier verifies tokens signed by the keys of a JWKS, both access tokens
+// of Auth and ID tokens of OIDC providers.
+type verifier struct {
+	config *AuthConfig
+	parser *jwt.Parser
+	keys   *keySet
+}
+
+func newVerifier(config *AuthConfig, keys *keySet) *verifier {
+	return &verifier{
+		config: config,
+		parser: jwt.NewParser(jwt.WithValidMethods(config.Algorithms), jwt.WithoutClaimsValidation()),
+		keys:   keys,
+	}
+}
+
+func (v *verifier) verify(ctx context.Context, token string) (*Claims, error) {
+	claims := &Claims{}
+
+	_, err := v.parser.ParseWithClaims(token, claims, func(t *jwt.Token) (any, error) {
+		kid, _ := t.Header["kid"].(string)
+
+		return v.keys.key(ctx, kid, t.Method.Alg())
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, unwrapParseError(err)) //nolint:errorlint
+	}
+
+	if err := claims.validate(time.Now(), v.config); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err) //nolint:errorlint
+	}
+
+	return claims, nil
+}
+
+// unwrapParseError returns the error of the key function or the reason
+// parsing failed, which jwt.ValidationError wraps.
+func unwrapParseError(err error) error {
+	var validationErr *jwt.ValidationError
+	if errors.As(err, &validationErr) && validationErr.Inner != nil {
+		return validationErr.Inner
+	}
+
+	return err
+}
@@ -0,0 +1,53 @@
+// Package cacheboot contains the Cache interface implemented by the in-memory
+// cache of this package and by redisboot.Redis, and typed helpers to store
+// JSON-encoded values in any Cache.
+package cacheboot
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrNotFound is returned by Get when the key doesn't exist or expired.
+var ErrNotFound = errors.New("not found")
+
+// Cache stores values by key.
+type Cache interface {
+	// Get returns the value of key, or ErrNotFound.
+	Get(ctx context.Context, key string) ([]byte, error)
+
+	// Set stores the value of key, a ttl of 0 never expires.
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+
+	// Delete removes key, deleting a key that doesn't exist is not an error.
+	Delete(ctx context.Context, key string) error
+}
+
+// Get returns the JSON-decoded value of key, or ErrNotFound.
+func Get[T any](ctx context.Context, c Cache, key string) (T, error) {
+	var value T
+
+	b, err := c.Get(ctx, key)
+	if err != nil {
+		return value, err //nolint:wrapcheck
+	}
+
+	if err := json.Unmarshal(b, &value); err != nil {
+		return value, fmt.Errorf("decoding cached value of %q: %w", key, err)
+	}
+
+	return value, nil
+}
+
+// Set stores the JSON-encoded value of key, a ttl of 0 never expires.
+func Set[T any](ctx context.Context, c Cache, key string, value T, ttl time.Duration) error {
+	b, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("encoding cached value of %q: %w", key, err)
+	}
+
+	return c.Set(ctx, key, b, ttl) //nolint:wrapcheck
+}
@@ -0,0 +1,250 @@
+package cacheboot
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/nielskrijger/goboot"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const defaultMaxEntries = 10000
+
+var errInvalidMaxEntries = errors.New("config \"memoryCache.maxEntries\" must be positive")
+
+type MemoryConfig struct {
+	// Maximum number of entries, the least recently used entry is evicted
+	// when full. Default is 10000.
+	MaxEntries int `yaml:"maxEntries"`
+}
+
+// Memory implements the AppService and Cache interfaces. It's a bounded
+// in-process LRU cache for hot data that shouldn't incur a network round trip.
+// Expired entries are removed when accessed or evicted. A Memory that isn't
+// configured uses the default config without metrics.
+type Memory struct {
+	config  *MemoryConfig
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	lru     *list.List
+	metrics *memoryMetrics
+}
+
+type memoryEntry struct {
+	key       string
+	value     []byte
+	expiresAt time.Time
+}
+
+func (s *Memory) Name() string {
+	return "MemoryCache"
+}
+
+func (s *Memory) Configure(env *goboot.AppEnv) error {
+	s.config = &MemoryConfig{}
+
+	if env.Config.InConfig("memoryCache") {
+		if err := env.Config.Sub("memoryCache").Unmarshal(s.config); err != nil {
+			return fmt.Errorf("parsing memory cache configuration: %w", err)
+		}
+	}
+
+	if s.config.MaxEntries == 0 {
+		s.config.MaxEntries = defaultMaxEntries
+	}
+
+	if s.config.MaxEntries < 0 {
+		return errInvalidMaxEntries
+	}
+
+	s.entries = make(map[string]*list.Element)
+	s.lru = list.New()
+
+	return s.registerMetrics(env.Metrics)
+}
+
+// lazyInit sets up the default config when the cache isn't configured, the
+// caller must hold mu.
+func (s *Memory) lazyInit() {
+	if s.config == nil {
+		s.config = &MemoryConfig{MaxEntries: defaultMaxEntries}
+	}
+
+	if s.lru == nil {
+		s.entries = make(map[string]*list.Element)
+		s.lru = list.New()
+	}
+}
+
+func (s *Memory) Init() error {
+	return nil
+}
+
+// Close removes all entries.
+func (s *Memory) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.lazyInit()
+	s.metrics.addEntries(-s.lru.Len())
+	s.entries = make(map[string]*list.Element)
+	s.lru.Init()
+
+	return nil
+}
+
+// Get returns a copy of the value of key, or ErrNotFound.
+func (s *Memory) Get(_ context.Context, key string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.lazyInit()
+
+	el, ok := s.entries[key]
+	if !ok {
+		s.metrics.observe("miss")
+
+		return nil, ErrNotFound
+	}
+
+	entry := el.Value.(*memoryEntry)
+	if !entry.expiresAt.IsZero() && time.Now().After(entry.expiresAt) {
+		s.remove(el)
+		s.metrics.evict("expired")
+		s.metrics.observe("miss")
+
+		return nil, ErrNotFound
+	}
+
+	s.lru.MoveToFront(el)
+	s.metrics.observe("hit")
+
+	return append([]byte(nil), entry.value...), nil
+}
+
+// Set stores a copy of the value of key, a ttl of 0 never expires.
+func (s *Memory) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	entry := &memoryEntry{key: key, value: append([]byte(nil), value...)}
+	if ttl > 0 {
+		entry.expiresAt = time.Now().Add(ttl)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.lazyInit()
+
+	if el, ok := s.entries[key]; ok {
+		el.Value = entry
+		s.lru.MoveToFront(el)
+
+		return nil
+	}
+
+	s.entries[key] = s.lru.PushFront(entry)
+	s.metrics.addEntries(1)
+
+	for s.lru.Len() > s.config.MaxEntries {
+		s.remove(s.lru.Back())
+		s.metrics.evict("capacity")
+	}
+
+	return nil
+}
+
+// Delete removes key.
+func (s *Memory) Delete(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.lazyInit()
+
+	if el, ok := s.entries[key]; ok {
+		s.remove(el)
+	}
+
+	return nil
+}
+
+// Len returns the number of entries, including expired entries not yet removed.
+func (s *Memory) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.lazyInit()
+
+	return s.lru.Len()
+}
+
+func (s *Memory) remove(el *list.Element) {
+	s.lru.Remove(el)
+	delete(s.entries, el.Value.(*memoryEntry).key)
+	s.metrics.addEntries(-1)
+}
+
+type memoryMetrics struct {
+	requests  *prometheus.CounterVec
+	evictions *prometheus.CounterVec
+	entries   prometheus.Gauge
+}
+
+func (s *Memory) registerMetrics(reg *prometheus.Registry) error {
+	if reg == nil {
+		return nil
+	}
+
+	m := &memoryMetrics{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "memory_cache_requests_total",
+			Help: "Number of memory cache lookups by result: hit or miss.",
+		}, []string{"result"}),
+		evictions: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "memory_cache_evictions_total",
+			Help: "Number of entries evicted from the memory cache by reason: capacity or expired.",
+		}, []string{"reason"}),
+		entries: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "memory_cache_entries",
+			Help: "Number of entries in the memory cache.",
+		}),
+	}
+
+	if err := goboot.RegisterCollector(reg, &m.requests); err != nil {
+		return fmt.Errorf("registering memory cache metrics: %w", err)
+	}
+
+	if err := goboot.RegisterCollector(reg, &m.evictions); err != nil {
+		return fmt.Errorf("registering memory cache metrics: %w", err)
+	}
+
+	if err := goboot.RegisterCollector(reg, &m.entries); err != nil {
+		return fmt.Errorf("registering memory cache metrics: %w", err)
+	}
+
+	s.metrics = m
+
+	return nil
+}
+
+func (m *memoryMetrics) observe(result string) {
+	if m != nil {
+		m.requests.WithLabelValues(result).Inc()
+	}
+}
+
+func (m *memoryMetrics) evict(reason string) {
+	if m != nil {
+		m.evictions.WithLabelValues(reason).Inc()
+	}
+}
+
+// addEntries adjusts the entries gauge by delta rather than setting it, the
+// gauge is shared when multiple caches use the same registry.
+func (m *memoryMetrics) addEntries(delta int) {
+	if m != nil {
+		m.entries.Add(float64(delta))
+	}
+}
@@ -0,0 +1,137 @@
+package cacheboot_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nielskrijger/goboot"
+	"github.com/nielskrijger/goboot/cacheboot"
+	"github.com/stretchr/testify/assert"
+)
+
+type product struct {
+	Name string `json:"name"`
+}
+
+func TestMemory_TypedGetSet(t *testing.T) {
+	s := &cacheboot.Memory{}
+	assert.Nil(t, s.Configure(goboot.NewAppEnv("./testdata", "")))
+
+	ctx := context.Background()
+	assert.Nil(t, cacheboot.Set(ctx, s, "product:1", product{Name: "chair"}, 0))
+
+	p, err := cacheboot.Get[product](ctx, s, "product:1")
+	assert.Nil(t, err)
+	assert.Equal(t, "chair", p.Name)
+
+	assert.Nil(t, s.Delete(ctx, "product:1"))
+
+	_, err = cacheboot.Get[product](ctx, s, "product:1")
+	assert.ErrorIs(t, err, cacheboot.ErrNotFound)
+}
+
+func TestMemory_EvictsLeastRecentlyUsed(t *testing.T) {
+	env := goboot.NewAppEnv("./testdata", "small")
+	s := &cacheboot.Memory{}
+	assert.Nil(t, s.Configure(env))
+
+	ctx := context.Background()
+	assert.Nil(t, s.Set(ctx, "a", []byte("1"), 0))
+	assert.Nil(t, s.Set(ctx, "b", []byte("2"), 0))
+
+	_, err := s.Get(ctx, "a")
+	assert.Nil(t, err)
+
+	assert.Nil(t, s.Set(ctx, "c", []byte("3"), 0))
+	assert.Equal(t, 2, s.Len())
+
+	_, err = s.Get(ctx, "b")
+	assert.ErrorIs(t, err, cacheboot.ErrNotFound)
+
+	families, err := env.Metrics.Gather()
+	assert.Nil(t, err)
+
+	names := make([]string, len(families))
+	for i, f := range families {
+		names[i] = f.GetName()
+	}
+
+	assert.Contains(t, names, "memory_cache_evictions_total")
+}
+
+func TestMemory_Expires(t *testing.T) {
+	s := &cacheboot.Memory{}
+	assert.Nil(t, s.Configure(goboot.NewAppEnv("./testdata", "")))
+
+	ctx := context.Background()
+	assert.Nil(t, s.Set(ctx, "a", []byte("1"), 10*time.Millisecond))
+	time.Sleep(20 * time.Millisecond)
+
+	_, err := s.Get(ctx, "a")
+	assert.ErrorIs(t, err, cacheboot.ErrNotFound)
+	assert.Equal(t, 0, s.Len())
+}
+
+func TestMemory_WithoutMetrics(t *testing.T) {
+	env := goboot.NewAppEnv("./testdata", "")
+	env.Metrics = nil
+
+	s := &cacheboot.Memory{}
+	assert.Nil(t, s.Configure(env))
+	assert.Nil(t, s.Set(context.Background(), "a", []byte("1"), 0))
+}
+
+func TestMemory_Unconfigured(t *testing.T) {
+	s := &cacheboot.Memory{}
+
+	ctx := context.Background()
+	assert.Nil(t, s.Set(ctx, "a", []byte("1"), 0))
+
+	value, err := s.Get(ctx, "a")
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("1"), value)
+	assert.Nil(t, s.Close())
+}
+
+func TestMemory_CopiesValues(t *testing.T) {
+	s := &cacheboot.Memory{}
+
+	ctx := context.Background()
+	value := []byte("1")
+	assert.Nil(t, s.Set(ctx, "a", value, 0))
+	value[0] = '2'
+
+	got, err := s.Get(ctx, "a")
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("1"), got)
+
+	got[0] = '3'
+
+	got, err = s.Get(ctx, "a")
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("1"), got)
+}
+
+func TestMemory_SharedRegistry(t *testing.T) {
+	env := goboot.NewAppEnv("./testdata", "")
+	first := &cacheboot.Memory{}
+	second := &cacheboot.Memory{}
+
+	assert.Nil(t, first.Configure(env))
+	assert.Nil(t, second.Configure(env))
+
+	ctx := context.Background()
+	assert.Nil(t, first.Set(ctx, "a", []byte("1"), 0))
+	assert.Nil(t, second.Set(ctx, "a", []byte("1"), 0))
+	assert.Nil(t, second.Set(ctx, "b", []byte("2"), 0))
+
+	families, err := env.Metrics.Gather()
+	assert.Nil(t, err)
+
+	for _, f := range families {
+		if f.GetName() == "memory_cache_entries" {
+			assert.Equal(t, 3.0, f.GetMetric()[0].GetGauge().GetValue())
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package cacheboot
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/nielskrijger/goboot"
+	"github.com/rs/zerolog"
+)
+
+const defaultLocalTTL = time.Minute
+
+// Invalidator broadcasts invalidated keys to all instances of the application,
+// e.g. redisboot.Redis.Invalidator.
+type Invalidator interface {
+	// Invalidate notifies the other instances that key changed.
+	Invalidate(ctx context.Context, key string) error
+
+	// OnInvalidate calls fn for every key invalidated by another instance
+	// until ctx is cancelled.
+	OnInvalidate(ctx context.Context, fn func(key string)) error
+}
+
+type TieredConfig struct {
+	// Maximum time an entry is kept in the local cache, limiting staleness
+	// when an invalidation message is lost. Default is 1 minute.
+	LocalTTL time.Duration `yaml:"localTTL"`
+}
+
+// Tiered implements the AppService and Cache interfaces. It combines a local
+// Memory cache with a shared remote cache such as Redis: reads are served
+// from the local cache when possible, writes and deletes go to both caches
+// and are broadcast so all other instances evict their stale local entry.
+type Tiered struct {
+	config      *TieredConfig
+	local       *Memory
+	remote      Cache
+	invalidator Invalidator
+	cancel      context.CancelFunc
+	log         zerolog.Logger
+}
+
+// NewTiered creates a two-tier cache. Register local as a service as well so
+// it's configured before Init.
+func NewTiered(local *Memory, remote Cache, invalidator Invalidator) *Tiered {
+	return &Tiered{local: local, remote: remote, invalidator: invalidator}
+}
+
+func (s *Tiered) Name() string {
+	return "TieredCache"
+}
+
+func (s *Tiered) Configure(env *goboot.AppEnv) error {
+	s.log = env.Log
+	s.config = &TieredConfig{}
+
+	if env.Config.InConfig("tieredCache") {
+		if err := env.Config.Sub("tieredCache").Unmarshal(s.config); err != nil {
+			return fmt.Errorf("parsing tiered cache configuration: %w", err)
+		}
+	}
+
+	if s.config.LocalTTL == 0 {
+		s.config.LocalTTL = defaultLocalTTL
+	}
+
+	return nil
+}
+
+// Init subscribes to invalidations of the other instances until Close.
+func (s *Tiered) Init() error {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	err := s.invalidator.OnInvalidate(ctx, func(key string) {
+		_ = s.local.Delete(context.Background(), key)
+	})
+	if err != nil {
+		cancel()
+
+		return fmt.Errorf("subscribing to cache invalidations: %w", err)
+	}
+
+	s.cancel = cancel
+
+	return nil
+}
+
+// Close stops the invalidation subscription.
+func (s *Tiered) Close() error {
+	if s.cancel != nil {
+		s.cancel()
+	}
+
+	return nil
+}
+
+// Get returns the value of key from the local cache, or from the remote
+// cache storing it locally.
+func (s *Tiered) Get(ctx context.Context, key string) ([]byte, error) {
+	if b, err := s.local.Get(ctx, key); err == nil {
+		return b, nil
+	}
+
+	b, err := s.remote.Get(ctx, key)
+	if err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+
+	_ = s.local.Set(ctx, key, b, s.config.LocalTTL)
+
+	return b, nil
+}
+
+// Set stores the value of key in both caches and invalidates it on the other
+// instances. The local entry expires after at most "tieredCache.localTTL".
+func (s *Tiered) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	if err := s.remote.Set(ctx, key, value, ttl); err != nil {
+		return err //nolint:wrapcheck
+	}
+
+	localTTL := s.config.LocalTTL
+	if ttl > 0 && ttl < localTTL {
+		localTTL = ttl
+	}
+
+	_ = s.local.Set(ctx, key, value, localTTL)
+
+	return s.invalidate(ctx, key)
+}
+
+// Delete removes key from both caches and invalidates it on the other instances.
+func (s *Tiered) Delete(ctx context.Context, key string) error {
+	_ = s.local.Delete(ctx, key)
+
+	if err := s.remote.Delete(ctx, key); err != nil {
+		return err //nolint:wrapcheck
+	}
+
+	return s.invalidate(ctx, key)
+}
+
+func (s *Tiered) invalidate(ctx context.Context, key string) error {
+	if err := s.invalidator.Invalidate(ctx, key); err != nil {
+		return fmt.Errorf("invalidating cache key %q: %w", key, err)
+	}
+
+	return nil
+}
+
+var _ Cache = (*Tiered)(nil)
@@ -0,0 +1,131 @@
+package cacheboot_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/nielskrijger/goboot"
+	"github.com/nielskrijger/goboot/cacheboot"
+	"github.com/stretchr/testify/assert"
+)
+
+// memoryBus delivers invalidations to the invalidators of all instances.
+type memoryBus struct {
+	mu          sync.Mutex
+	subscribers []*memoryInvalidator
+}
+
+type memoryInvalidator struct {
+	bus *memoryBus
+	ctx context.Context //nolint:containedctx
+	fn  func(key string)
+}
+
+func (b *memoryBus) invalidator() *memoryInvalidator {
+	i := &memoryInvalidator{bus: b}
+
+	b.mu.Lock()
+	b.subscribers = append(b.subscribers, i)
+	b.mu.Unlock()
+
+	return i
+}
+
+func (i *memoryInvalidator) Invalidate(_ context.Context, key string) error {
+	i.bus.mu.Lock()
+	defer i.bus.mu.Unlock()
+
+	for _, sub := range i.bus.subscribers {
+		if sub != i && sub.fn != nil && sub.ctx.Err() == nil {
+			sub.fn(key)
+		}
+	}
+
+	return nil
+}
+
+func (i *memoryInvalidator) OnInvalidate(ctx context.Context, fn func(key string)) error {
+	i.bus.mu.Lock()
+	defer i.bus.mu.Unlock()
+
+	i.ctx = ctx
+	i.fn = fn
+
+	return nil
+}
+
+func newTiered(t *testing.T, remote cacheboot.Cache, bus *memoryBus) *cacheboot.Tiered {
+	t.Helper()
+
+	s := cacheboot.NewTiered(&cacheboot.Memory{}, remote, bus.invalidator())
+	assert.Nil(t, s.Configure(goboot.NewAppEnv("./testdata", "")))
+	assert.Nil(t, s.Init())
+
+	return s
+}
+
+func TestTiered_GetStoresRemoteValueLocally(t *testing.T) {
+	remote := &cacheboot.Memory{}
+	s := newTiered(t, remote, &memoryBus{})
+
+	ctx := context.Background()
+	assert.Nil(t, remote.Set(ctx, "a", []byte("1"), 0))
+
+	value, err := s.Get(ctx, "a")
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("1"), value)
+
+	assert.Nil(t, remote.Delete(ctx, "a"))
+
+	value, err = s.Get(ctx, "a")
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("1"), value)
+}
+
+func TestTiered_InvalidatesOtherInstances(t *testing.T) {
+	remote := &cacheboot.Memory{}
+	bus := &memoryBus{}
+	first := newTiered(t, remote, bus)
+	second := newTiered(t, remote, bus)
+
+	ctx := context.Background()
+	assert.Nil(t, first.Set(ctx, "a", []byte("1"), 0))
+
+	value, err := second.Get(ctx, "a")
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("1"), value)
+
+	assert.Nil(t, first.Set(ctx, "a", []byte("2"), 0))
+
+	value, err = second.Get(ctx, "a")
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("2"), value)
+
+	assert.Nil(t, first.Delete(ctx, "a"))
+
+	_, err = second.Get(ctx, "a")
+	assert.ErrorIs(t, err, cacheboot.ErrNotFound)
+}
+
+func TestTiered_CloseStopsInvalidations(t *testing.T) {
+	remote := &cacheboot.Memory{}
+	bus := &memoryBus{}
+	first := newTiered(t, remote, bus)
+	second := newTiered(t, remote, bus)
+
+	ctx := context.Background()
+	assert.Nil(t, first.Set(ctx, "a", []byte("1"), 0))
+
+	_, err := second.Get(ctx, "a")
+	assert.Nil(t, err)
+
+	assert.Nil(t, second.Close())
+	assert.NotNil(t, bus.subscribers[1].ctx.Err())
+
+	assert.Nil(t, first.Set(ctx, "a", []byte("2"), 0))
+
+	value, err := second.Get(ctx, "a")
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("1"), value)
+}
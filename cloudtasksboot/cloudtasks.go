@@ -0,0 +1,380 @@
+package cloudtasksboot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	cloudtasks "cloud.google.com/go/cloudtasks/apiv2"
+	taskspb "cloud.google.com/go/cloudtasks/apiv2/cloudtaskspb"
+	"github.com/nielskrijger/goboot"
+	"github.com/rs/zerolog"
+	"google.golang.org/api/idtoken"
+	"google.golang.org/api/option"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var errQueueNotFound = errors.New("queue not found")
+
+const (
+	// EmulatorHostEnv is the environment variable pointing to a local Cloud
+	// Tasks emulator, e.g. "localhost:8123".
+	EmulatorHostEnv = "CLOUD_TASKS_EMULATOR_HOST"
+
+	DefaultMaxAttempts = 5
+	DefaultMinBackoff  = 10 * time.Second
+	DefaultMaxBackoff  = 10 * time.Minute
+)
+
+// CloudTasks wraps the Google Cloud Tasks client and ensures all configured
+// queues exist on boot.
+//
+// Like the PubSub service, queues are referenced by a self-chosen ID rather
+// than the actual Cloud Tasks queue name.
+type CloudTasks struct {
+	*cloudtasks.Client
+
+	Queues map[string]*Queue
+
+	projectID  string
+	locationID string
+	log        zerolog.Logger
+	options    []Option
+
+	// oidcAudience and oidcServiceAccount are the expected claims of the OIDC
+	// token TaskHandler verifies.
+	oidcAudience       string
+	oidcServiceAccount string
+	validator          TokenValidator
+}
+
+// Queue describes a Cloud Tasks queue and its retry behaviour.
+type Queue struct {
+	ID      string
+	QueueID string
+
+	// MaxAttempts is the number of attempts per task including the first one.
+	// Default is 5, set -1 for unlimited attempts.
+	MaxAttempts int32
+
+	// MinBackoff and MaxBackoff define the exponential backoff between retries.
+	// Defaults are 10 seconds and 10 minutes.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+
+	// MaxRetryDuration limits the time a task is retried since its first attempt.
+	// Zero means unlimited.
+	MaxRetryDuration time.Duration
+
+	// MaxDispatchesPerSecond and MaxConcurrentDispatches rate limit the queue.
+	// Zero uses the Cloud Tasks defaults.
+	MaxDispatchesPerSecond  float64
+	MaxConcurrentDispatches int32
+}
+
+// HTTPTask is a task dispatched to an arbitrary HTTP endpoint.
+type HTTPTask struct {
+	// Name is optional; when set Cloud Tasks deduplicates tasks with the same name.
+	Name    string
+	URL     string
+	Method  taskspb.HttpMethod
+	Headers map[string]string
+	Body    []byte
+
+	// ScheduleTime delays the task until specified time, zero dispatches immediately.
+	ScheduleTime time.Time
+
+	// DispatchDeadline is the time Cloud Tasks waits for a response, zero uses
+	// the default of 10 minutes.
+	DispatchDeadline time.Duration
+
+	// OIDCServiceAccount adds an OIDC token of specified service account to the
+	// request. OIDCAudience defaults to the URL when left empty.
+	OIDCServiceAccount string
+	OIDCAudience       string
+}
+
+// AppEngineTask is a task dispatched to an App Engine service.
+type AppEngineTask struct {
+	Name         string
+	Service      string
+	Version      string
+	RelativeURI  string
+	Method       taskspb.HttpMethod
+	Headers      map[string]string
+	Body         []byte
+	ScheduleTime time.Time
+}
+
+type Option func(*CloudTasks)
+
+// TokenValidator validates a Google-signed ID token for specified audience.
+type TokenValidator func(ctx context.Context, token string, audience string) (*idtoken.Payload, error)
+
+// WithOIDC option makes TaskHandler verify the OIDC token Cloud Tasks adds to
+// HTTP target requests. The token must be issued for specified audience to
+// specified service account, see HTTPTask.OIDCServiceAccount.
+func WithOIDC(audience string, serviceAccount string) func(*CloudTasks) {
+	return func(s *CloudTasks) {
+		s.oidcAudience = audience
+		s.oidcServiceAccount = serviceAccount
+	}
+}
+
+// WithTokenValidator option replaces the validator of OIDC tokens, which
+// defaults to idtoken.Validate. Mainly useful for testing.
+func WithTokenValidator(v TokenValidator) func(*CloudTasks) {
+	return func(s *CloudTasks) {
+		s.validator = v
+	}
+}
+
+// WithQueue option adds a queue that is created on Init if it doesn't exist.
+func WithQueue(q *Queue) func(*CloudTasks) {
+	return func(s *CloudTasks) {
+		if q.MaxAttempts == 0 {
+			q.MaxAttempts = DefaultMaxAttempts
+		}
+
+		if q.MinBackoff == 0 {
+			q.MinBackoff = DefaultMinBackoff
+		}
+
+		if q.MaxBackoff == 0 {
+			q.MaxBackoff = DefaultMaxBackoff
+		}
+
+		s.Queues[q.ID] = q
+	}
+}
+
+// NewCloudTasksService creates a new Cloud Tasks service for specified project and location.
+func NewCloudTasksService(projectID string, locationID string, options ...Option) *CloudTasks {
+	return &CloudTasks{
+		projectID:  projectID,
+		locationID: locationID,
+		Queues:     make(map[string]*Queue),
+		options:    options,
+		validator:  idtoken.Validate,
+	}
+}
+
+func (s *CloudTasks) Name() string {
+	return "CloudTasks"
+}
+
+// Configure implements the AppService interface and instantiates the client
+// connection to Cloud Tasks.
+func (s *CloudTasks) Configure(env *goboot.AppEnv) error {
+	s.log = env.Log
+	for _, option := range s.options {
+		option(s)
+	}
+
+	var opts []option.ClientOption
+
+	if host, ok := os.LookupEnv(EmulatorHostEnv); ok {
+		s.log.Info().Msgf("using Cloud Tasks emulator on %s", host)
+
+		opts = append(opts,
+			option.WithEndpoint(host),
+			option.WithoutAuthentication(),
+			option.WithGRPCDialOption(grpc.WithTransportCredentials(insecure.NewCredentials())),
+		)
+	}
+
+	client, err := cloudtasks.NewClient(context.Background(), opts...)
+	if err != nil {
+		return fmt.Errorf("connecting to Cloud Tasks: %w", err)
+	}
+
+	s.log.Info().Msgf("connected to %s Cloud Tasks", s.projectID)
+	s.Client = client
+
+	return nil
+}
+
+// Init implements the AppService interface and ensures all queues exist.
+func (s *CloudTasks) Init() error {
+	s.log.Info().Msg("ensuring all Cloud Tasks queues exist")
+
+	for _, q := range s.Queues {
+		if err := s.EnsureQueue(context.Background(), q); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Close releases any resources held by the Cloud Tasks client.
+func (s *CloudTasks) Close() error {
+	if err := s.Client.Close(); err != nil {
+		return fmt.Errorf("closing %s service: %w", s.Name(), err)
+	}
+
+	return nil
+}
+
+// Queue returns the queue with specified id or nil if it doesn't exist.
+func (s *CloudTasks) Queue(id string) *Queue {
+	return s.Queues[id]
+}
+
+// QueuePath returns the fully qualified queue name.
+func (s *CloudTasks) QueuePath(queueID string) string {
+	return fmt.Sprintf("projects/%s/locations/%s/queues/%s", s.projectID, s.locationID, queueID)
+}
+
+// EnsureQueue creates a queue if it doesn't exist already. In most cases Init
+// takes care of this.
+func (s *CloudTasks) EnsureQueue(ctx context.Context, q *Queue) error {
+	name := s.QueuePath(q.QueueID)
+	s.log.Info().Msgf("ensure queue %q exists", name)
+
+	_, err := s.GetQueue(ctx, &taskspb.GetQueueRequest{Name: name})
+
+	switch {
+	case status.Code(err) == codes.NotFound:
+		_, err := s.CreateQueue(ctx, &taskspb.CreateQueueRequest{
+			Parent: fmt.Sprintf("projects/%s/locations/%s", s.projectID, s.locationID),
+			Queue:  s.queueConfig(name, q),
+		})
+		if err != nil {
+			return fmt.Errorf("creating queue %s: %w", name, err)
+		}
+
+		s.log.Info().Msgf("created new queue %q", name)
+	case err != nil:
+		return fmt.Errorf("checking if queue %s exists: %w", name, err)
+	default:
+		s.log.Info().Msgf("queue %q already exists", name)
+	}
+
+	return nil
+}
+
+func (s *CloudTasks) queueConfig(name string, q *Queue) *taskspb.Queue {
+	queue := &taskspb.Queue{
+		Name: name,
+		RetryConfig: &taskspb.RetryConfig{
+			MaxAttempts: q.MaxAttempts,
+			MinBackoff:  durationpb.New(q.MinBackoff),
+			MaxBackoff:  durationpb.New(q.MaxBackoff),
+		},
+	}
+
+	if q.MaxRetryDuration != 0 {
+		queue.RetryConfig.MaxRetryDuration = durationpb.New(q.MaxRetryDuration)
+	}
+
+	if q.MaxDispatchesPerSecond != 0 || q.MaxConcurrentDispatches != 0 {
+		queue.RateLimits = &taskspb.RateLimits{
+			MaxDispatchesPerSecond:  q.MaxDispatchesPerSecond,
+			MaxConcurrentDispatches: q.MaxConcurrentDispatches,
+		}
+	}
+
+	return queue
+}
+
+// EnqueueHTTP adds an HTTP task to the queue with specified id.
+func (s *CloudTasks) EnqueueHTTP(ctx context.Context, queue string, task *HTTPTask) (*taskspb.Task, error) {
+	q := s.Queues[queue]
+	if q == nil {
+		return nil, fmt.Errorf("%w: %q", errQueueNotFound, queue)
+	}
+
+	req := &taskspb.HttpRequest{
+		Url:        task.URL,
+		HttpMethod: task.Method,
+		Headers:    task.Headers,
+		Body:       task.Body,
+	}
+
+	if task.OIDCServiceAccount != "" {
+		audience := task.OIDCAudience
+		if audience == "" {
+			audience = task.URL
+		}
+
+		req.AuthorizationHeader = &taskspb.HttpRequest_OidcToken{
+			OidcToken: &taskspb.OidcToken{
+				ServiceAccountEmail: task.OIDCServiceAccount,
+				Audience:            audience,
+			},
+		}
+	}
+
+	t := &taskspb.Task{
+		MessageType: &taskspb.Task_HttpRequest{HttpRequest: req},
+	}
+
+	if task.DispatchDeadline != 0 {
+		t.DispatchDeadline = durationpb.New(task.DispatchDeadline)
+	}
+
+	return s.createTask(ctx, q, task.Name, task.ScheduleTime, t)
+}
+
+// EnqueueAppEngine adds an App Engine task to the queue with specified id.
+func (s *CloudTasks) EnqueueAppEngine(ctx context.Context, queue string, task *AppEngineTask) (*taskspb.Task, error) {
+	q := s.Queues[queue]
+	if q == nil {
+		return nil, fmt.Errorf("%w: %q", errQueueNotFound, queue)
+	}
+
+	t := &taskspb.Task{
+		MessageType: &taskspb.Task_AppEngineHttpRequest{
+			AppEngineHttpRequest: &taskspb.AppEngineHttpRequest{
+				HttpMethod: task.Method,
+				AppEngineRouting: &taskspb.AppEngineRouting{
+					Service: task.Service,
+					Version: task.Version,
+				},
+				RelativeUri: task.RelativeURI,
+				Headers:     task.Headers,
+				Body:        task.Body,
+			},
+		},
+	}
+
+	return s.createTask(ctx, q, task.Name, task.ScheduleTime, t)
+}
+
+func (s *CloudTasks) createTask(
+	ctx context.Context,
+	q *Queue,
+	name string,
+	scheduleTime time.Time,
+	task *taskspb.Task,
+) (*taskspb.Task, error) {
+	queuePath := s.QueuePath(q.QueueID)
+
+	if name != "" {
+		task.Name = queuePath + "/tasks/" + name
+	}
+
+	if !scheduleTime.IsZero() {
+		task.ScheduleTime = timestamppb.New(scheduleTime)
+	}
+
+	res, err := s.CreateTask(ctx, &taskspb.CreateTaskRequest{
+		Parent: queuePath,
+		Task:   task,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("creating task in queue %q: %w", queuePath, err)
+	}
+
+	s.log.Debug().Msgf("created task %q", res.Name)
+
+	return res, nil
+}
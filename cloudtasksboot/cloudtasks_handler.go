@@ -0,0 +1,191 @@
+package cloudtasksboot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	errMissingTaskHeader = errors.New("missing Cloud Tasks header")
+	errOIDCNotConfigured = errors.New("no OIDC audience and service account configured")
+	errMissingToken      = errors.New("missing bearer token")
+	errUnexpectedIssuer  = errors.New("unexpected token issuer")
+	errUnexpectedAccount = errors.New("token not issued to expected service account")
+)
+
+// Headers set by Cloud Tasks on task requests. App Engine strips these
+// headers from external requests, for HTTP targets however anyone can set
+// them and the OIDC token must be verified instead.
+const (
+	HeaderQueueName          = "X-CloudTasks-QueueName"
+	HeaderTaskName           = "X-CloudTasks-TaskName"
+	HeaderTaskRetryCount     = "X-CloudTasks-TaskRetryCount"
+	HeaderTaskExecutionCount = "X-CloudTasks-TaskExecutionCount"
+	HeaderTaskETA            = "X-CloudTasks-TaskETA"
+	HeaderTaskRetryReason    = "X-CloudTasks-TaskRetryReason"
+)
+
+type taskInfoKey struct{}
+
+// TaskInfo contains the task details Cloud Tasks sends along with each request.
+type TaskInfo struct {
+	QueueName      string
+	TaskName       string
+	RetryCount     int
+	ExecutionCount int
+	ETA            time.Time
+	RetryReason    string
+}
+
+// ParseTaskInfo validates and parses the Cloud Tasks headers of an inbound request.
+func ParseTaskInfo(r *http.Request) (*TaskInfo, error) {
+	info := &TaskInfo{
+		QueueName:   r.Header.Get(HeaderQueueName),
+		TaskName:    r.Header.Get(HeaderTaskName),
+		RetryReason: r.Header.Get(HeaderTaskRetryReason),
+	}
+
+	if info.QueueName == "" {
+		return nil, fmt.Errorf("%w %q", errMissingTaskHeader, HeaderQueueName)
+	}
+
+	if info.TaskName == "" {
+		return nil, fmt.Errorf("%w %q", errMissingTaskHeader, HeaderTaskName)
+	}
+
+	var err error
+
+	if info.RetryCount, err = parseIntHeader(r, HeaderTaskRetryCount); err != nil {
+		return nil, err
+	}
+
+	if info.ExecutionCount, err = parseIntHeader(r, HeaderTaskExecutionCount); err != nil {
+		return nil, err
+	}
+
+	if eta := r.Header.Get(HeaderTaskETA); eta != "" {
+		secs, err := strconv.ParseFloat(eta, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %q header: %w", HeaderTaskETA, err)
+		}
+
+		info.ETA = time.Unix(0, int64(secs*float64(time.Second))).UTC()
+	}
+
+	return info, nil
+}
+
+func parseIntHeader(r *http.Request, header string) (int, error) {
+	val := r.Header.Get(header)
+	if val == "" {
+		return 0, nil
+	}
+
+	i, err := strconv.Atoi(val)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %q header: %w", header, err)
+	}
+
+	return i, nil
+}
+
+// TaskInfoFromContext returns the TaskInfo added by TaskHandler or nil if
+// the request was not handled by TaskHandler.
+func TaskInfoFromContext(ctx context.Context) *TaskInfo {
+	info, _ := ctx.Value(taskInfoKey{}).(*TaskInfo)
+
+	return info
+}
+
+// TaskHandler wraps an http.Handler of an HTTP target and rejects any request
+// with 403 Forbidden that did not originate from the queue with specified id.
+// Requests must carry an OIDC token matching the audience and service account
+// of WithOIDC, without that option all requests are rejected. The parsed
+// TaskInfo is available in the handler using TaskInfoFromContext.
+func (s *CloudTasks) TaskHandler(queue string, next http.Handler) http.Handler {
+	return s.taskHandler(queue, true, next)
+}
+
+// AppEngineTaskHandler is like TaskHandler but trusts the Cloud Tasks headers
+// without verifying a token. Only use this for App Engine targets, App Engine
+// removes these headers from requests not sent by Cloud Tasks.
+func (s *CloudTasks) AppEngineTaskHandler(queue string, next http.Handler) http.Handler {
+	return s.taskHandler(queue, false, next)
+}
+
+func (s *CloudTasks) taskHandler(queue string, verifyToken bool, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if verifyToken {
+			if err := s.verifyToken(r); err != nil {
+				s.log.Warn().Err(err).Msg("rejected Cloud Tasks request")
+				http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+
+				return
+			}
+		}
+
+		info, err := ParseTaskInfo(r)
+		if err != nil {
+			s.log.Warn().Err(err).Msg("rejected Cloud Tasks request")
+			http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+
+			return
+		}
+
+		if q := s.Queues[queue]; q == nil || q.QueueID != info.QueueName {
+			s.log.Warn().Msgf("rejected Cloud Tasks request from unexpected queue %q", info.QueueName)
+			http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), taskInfoKey{}, info)))
+	})
+}
+
+// verifyToken checks the OIDC token in the Authorization header is signed by
+// Google for the configured audience and service account.
+func (s *CloudTasks) verifyToken(r *http.Request) error {
+	if s.oidcAudience == "" || s.oidcServiceAccount == "" {
+		return errOIDCNotConfigured
+	}
+
+	token, ok := bearerToken(r)
+	if !ok {
+		return errMissingToken
+	}
+
+	payload, err := s.validator(r.Context(), token, s.oidcAudience)
+	if err != nil {
+		return fmt.Errorf("validating OIDC token: %w", err)
+	}
+
+	if payload.Issuer != "https://accounts.google.com" && payload.Issuer != "accounts.google.com" {
+		return fmt.Errorf("%w %q", errUnexpectedIssuer, payload.Issuer)
+	}
+
+	email, _ := payload.Claims["email"].(string)
+	verified, _ := payload.Claims["email_verified"].(bool)
+
+	if !verified || email != s.oidcServiceAccount {
+		return fmt.Errorf("%w %q", errUnexpectedAccount, s.oidcServiceAccount)
+	}
+
+	return nil
+}
+
+func bearerToken(r *http.Request) (string, bool) {
+	header := r.Header.Get("Authorization")
+	if len(header) < 7 || !strings.EqualFold(header[:7], "Bearer ") {
+		return "", false
+	}
+
+	token := strings.TrimSpace(header[7:])
+
+	return token, token != ""
+}
@@ -0,0 +1,184 @@
+package cloudtasksboot_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/nielskrijger/goboot"
+	"github.com/nielskrijger/goboot/cloudtasksboot"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/api/idtoken"
+)
+
+const taskServiceAccount = "tasks@test-project.iam.gserviceaccount.com"
+
+// validateTestToken accepts the token "valid" and returns a payload issued to
+// the service account set in the token "other".
+func validateTestToken(_ context.Context, token string, audience string) (*idtoken.Payload, error) {
+	if audience != "https://example.com/tasks" {
+		return nil, errors.New("audience mismatch")
+	}
+
+	payload := &idtoken.Payload{
+		Issuer:   "https://accounts.google.com",
+		Audience: audience,
+		Claims:   map[string]any{"email": taskServiceAccount, "email_verified": true},
+	}
+
+	switch token {
+	case "valid":
+		return payload, nil
+	case "other":
+		payload.Claims["email"] = "other@test-project.iam.gserviceaccount.com"
+
+		return payload, nil
+	default:
+		return nil, errors.New("invalid token")
+	}
+}
+
+func newCloudTasksService(t *testing.T, options ...cloudtasksboot.Option) *cloudtasksboot.CloudTasks {
+	t.Helper()
+
+	// Configure doesn't connect, using the emulator avoids credential lookup
+	t.Setenv(cloudtasksboot.EmulatorHostEnv, "localhost:8123")
+
+	options = append([]cloudtasksboot.Option{
+		cloudtasksboot.WithQueue(&cloudtasksboot.Queue{ID: "emails", QueueID: "email-queue"}),
+		cloudtasksboot.WithOIDC("https://example.com/tasks", taskServiceAccount),
+		cloudtasksboot.WithTokenValidator(validateTestToken),
+	}, options...)
+
+	s := cloudtasksboot.NewCloudTasksService("test-project", "europe-west1", options...)
+	assert.Nil(t, s.Configure(goboot.NewAppEnv("../testdata", "")))
+
+	return s
+}
+
+func TestCloudTasksParseTaskInfo_Success(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/", nil)
+	r.Header.Set(cloudtasksboot.HeaderQueueName, "email-queue")
+	r.Header.Set(cloudtasksboot.HeaderTaskName, "task-1")
+	r.Header.Set(cloudtasksboot.HeaderTaskRetryCount, "2")
+	r.Header.Set(cloudtasksboot.HeaderTaskExecutionCount, "1")
+	r.Header.Set(cloudtasksboot.HeaderTaskETA, "1600000000.5")
+
+	info, err := cloudtasksboot.ParseTaskInfo(r)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "email-queue", info.QueueName)
+	assert.Equal(t, "task-1", info.TaskName)
+	assert.Equal(t, 2, info.RetryCount)
+	assert.Equal(t, 1, info.ExecutionCount)
+	assert.Equal(t, int64(1600000000), info.ETA.Unix())
+}
+
+func TestCloudTasksParseTaskInfo_ErrorMissingHeader(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/", nil)
+
+	_, err := cloudtasksboot.ParseTaskInfo(r)
+
+	assert.EqualError(t, err, "missing Cloud Tasks header \"X-CloudTasks-QueueName\"")
+}
+
+func newTaskRequest(queue string, token string) *http.Request {
+	r := httptest.NewRequest(http.MethodPost, "/", nil)
+	r.Header.Set(cloudtasksboot.HeaderQueueName, queue)
+	r.Header.Set(cloudtasksboot.HeaderTaskName, "task-1")
+
+	if token != "" {
+		r.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	return r
+}
+
+func TestCloudTasksTaskHandler_Success(t *testing.T) {
+	s := newCloudTasksService(t)
+	defer s.Close()
+
+	var info *cloudtasksboot.TaskInfo
+
+	h := s.TaskHandler("emails", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		info = cloudtasksboot.TaskInfoFromContext(r.Context())
+	}))
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, newTaskRequest("email-queue", "valid"))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "task-1", info.TaskName)
+}
+
+func TestCloudTasksTaskHandler_ForbiddenUnknownQueue(t *testing.T) {
+	s := newCloudTasksService(t)
+	defer s.Close()
+
+	h := s.TaskHandler("emails", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, newTaskRequest("other-queue", "valid"))
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func TestCloudTasksTaskHandler_ForbiddenWithoutHeaders(t *testing.T) {
+	s := newCloudTasksService(t)
+	defer s.Close()
+
+	h := s.TaskHandler("emails", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	r := httptest.NewRequest(http.MethodPost, "/", nil)
+	r.Header.Set("Authorization", "Bearer valid")
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func TestCloudTasksTaskHandler_ForbiddenInvalidToken(t *testing.T) {
+	s := newCloudTasksService(t)
+	defer s.Close()
+
+	h := s.TaskHandler("emails", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	for _, token := range []string{"", "forged", "other"} {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, newTaskRequest("email-queue", token))
+
+		assert.Equal(t, http.StatusForbidden, w.Code, "token %q", token)
+	}
+}
+
+func TestCloudTasksTaskHandler_ForbiddenWithoutOIDC(t *testing.T) {
+	s := newCloudTasksService(t, cloudtasksboot.WithOIDC("", ""))
+	defer s.Close()
+
+	h := s.TaskHandler("emails", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, newTaskRequest("email-queue", "valid"))
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func TestCloudTasksAppEngineTaskHandler_Success(t *testing.T) {
+	s := newCloudTasksService(t)
+	defer s.Close()
+
+	var info *cloudtasksboot.TaskInfo
+
+	h := s.AppEngineTaskHandler("emails", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		info = cloudtasksboot.TaskInfoFromContext(r.Context())
+	}))
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, newTaskRequest("email-queue", ""))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "task-1", info.TaskName)
+}
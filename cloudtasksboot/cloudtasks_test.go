@@ -0,0 +1,273 @@
+package cloudtasksboot_test
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	taskspb "cloud.google.com/go/cloudtasks/apiv2/cloudtaskspb"
+	"github.com/nielskrijger/goboot"
+	"github.com/nielskrijger/goboot/cloudtasksboot"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+const emailQueuePath = "projects/test-project/locations/europe-west1/queues/email-queue"
+
+// fakeCloudTasks is an in-memory Cloud Tasks server that records created
+// queues and tasks.
+type fakeCloudTasks struct {
+	taskspb.UnimplementedCloudTasksServer
+
+	mu      sync.Mutex
+	getErr  error
+	queues  map[string]*taskspb.Queue
+	created []*taskspb.CreateQueueRequest
+	tasks   []*taskspb.CreateTaskRequest
+}
+
+func (f *fakeCloudTasks) GetQueue(_ context.Context, req *taskspb.GetQueueRequest) (*taskspb.Queue, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.getErr != nil {
+		return nil, f.getErr
+	}
+
+	q, ok := f.queues[req.Name]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "queue %q not found", req.Name)
+	}
+
+	return q, nil
+}
+
+func (f *fakeCloudTasks) CreateQueue(_ context.Context, req *taskspb.CreateQueueRequest) (*taskspb.Queue, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.created = append(f.created, req)
+	f.queues[req.Queue.Name] = req.Queue
+
+	return req.Queue, nil
+}
+
+func (f *fakeCloudTasks) CreateTask(_ context.Context, req *taskspb.CreateTaskRequest) (*taskspb.Task, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.tasks = append(f.tasks, req)
+
+	task, _ := proto.Clone(req.Task).(*taskspb.Task)
+	if task.Name == "" {
+		task.Name = req.Parent + "/tasks/generated"
+	}
+
+	return task, nil
+}
+
+// newFakeCloudTasksService returns a service connected to a fake Cloud Tasks
+// server through the emulator host.
+func newFakeCloudTasksService(
+	t *testing.T,
+	fake *fakeCloudTasks,
+	options ...cloudtasksboot.Option,
+) *cloudtasksboot.CloudTasks {
+	t.Helper()
+
+	fake.queues = make(map[string]*taskspb.Queue)
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+
+	srv := grpc.NewServer()
+	taskspb.RegisterCloudTasksServer(srv, fake)
+
+	go func() {
+		_ = srv.Serve(lis)
+	}()
+
+	t.Cleanup(srv.Stop)
+	t.Setenv(cloudtasksboot.EmulatorHostEnv, lis.Addr().String())
+
+	if len(options) == 0 {
+		options = []cloudtasksboot.Option{
+			cloudtasksboot.WithQueue(&cloudtasksboot.Queue{ID: "emails", QueueID: "email-queue"}),
+		}
+	}
+
+	s := cloudtasksboot.NewCloudTasksService("test-project", "europe-west1", options...)
+	assert.Nil(t, s.Configure(goboot.NewAppEnv("../testdata", "")))
+	t.Cleanup(func() { _ = s.Close() })
+
+	return s
+}
+
+func TestCloudTasksInit_CreatesMissingQueue(t *testing.T) {
+	fake := &fakeCloudTasks{}
+	s := newFakeCloudTasksService(t, fake)
+
+	assert.Nil(t, s.Init())
+
+	assert.Len(t, fake.created, 1)
+	assert.Equal(t, "projects/test-project/locations/europe-west1", fake.created[0].Parent)
+
+	q := fake.created[0].Queue
+	assert.Equal(t, emailQueuePath, q.Name)
+	assert.Equal(t, int32(cloudtasksboot.DefaultMaxAttempts), q.RetryConfig.MaxAttempts)
+	assert.Equal(t, cloudtasksboot.DefaultMinBackoff, q.RetryConfig.MinBackoff.AsDuration())
+	assert.Equal(t, cloudtasksboot.DefaultMaxBackoff, q.RetryConfig.MaxBackoff.AsDuration())
+	assert.Nil(t, q.RetryConfig.MaxRetryDuration)
+	assert.Nil(t, q.RateLimits)
+}
+
+func TestCloudTasksInit_QueueRetryAndRateLimits(t *testing.T) {
+	fake := &fakeCloudTasks{}
+	s := newFakeCloudTasksService(t, fake, cloudtasksboot.WithQueue(&cloudtasksboot.Queue{
+		ID:                      "emails",
+		QueueID:                 "email-queue",
+		MaxAttempts:             -1,
+		MinBackoff:              time.Second,
+		MaxBackoff:              time.Minute,
+		MaxRetryDuration:        time.Hour,
+		MaxDispatchesPerSecond:  2.5,
+		MaxConcurrentDispatches: 10,
+	}))
+
+	assert.Nil(t, s.Init())
+
+	q := fake.created[0].Queue
+	assert.Equal(t, int32(-1), q.RetryConfig.MaxAttempts)
+	assert.Equal(t, time.Second, q.RetryConfig.MinBackoff.AsDuration())
+	assert.Equal(t, time.Minute, q.RetryConfig.MaxBackoff.AsDuration())
+	assert.Equal(t, time.Hour, q.RetryConfig.MaxRetryDuration.AsDuration())
+	assert.Equal(t, 2.5, q.RateLimits.MaxDispatchesPerSecond)
+	assert.Equal(t, int32(10), q.RateLimits.MaxConcurrentDispatches)
+}
+
+func TestCloudTasksEnsureQueue_AlreadyExists(t *testing.T) {
+	fake := &fakeCloudTasks{}
+	s := newFakeCloudTasksService(t, fake)
+	fake.queues[emailQueuePath] = &taskspb.Queue{Name: emailQueuePath}
+
+	assert.Nil(t, s.EnsureQueue(context.Background(), s.Queue("emails")))
+	assert.Empty(t, fake.created)
+}
+
+func TestCloudTasksEnsureQueue_Error(t *testing.T) {
+	fake := &fakeCloudTasks{getErr: status.Error(codes.PermissionDenied, "denied")}
+	s := newFakeCloudTasksService(t, fake)
+
+	err := s.EnsureQueue(context.Background(), s.Queue("emails"))
+
+	assert.ErrorContains(t, err, "checking if queue "+emailQueuePath+" exists")
+	assert.Empty(t, fake.created)
+}
+
+func TestCloudTasksEnqueueHTTP_Success(t *testing.T) {
+	fake := &fakeCloudTasks{}
+	s := newFakeCloudTasksService(t, fake)
+	scheduleTime := time.Now().Add(time.Hour).UTC()
+
+	task, err := s.EnqueueHTTP(context.Background(), "emails", &cloudtasksboot.HTTPTask{
+		Name:               "welcome-1",
+		URL:                "https://example.com/tasks/welcome",
+		Method:             taskspb.HttpMethod_POST,
+		Headers:            map[string]string{"Content-Type": "application/json"},
+		Body:               []byte(`{"id":"1"}`),
+		ScheduleTime:       scheduleTime,
+		DispatchDeadline:   30 * time.Second,
+		OIDCServiceAccount: taskServiceAccount,
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, emailQueuePath+"/tasks/welcome-1", task.Name)
+	assert.Len(t, fake.tasks, 1)
+	assert.Equal(t, emailQueuePath, fake.tasks[0].Parent)
+
+	sent := fake.tasks[0].Task
+	assert.Equal(t, emailQueuePath+"/tasks/welcome-1", sent.Name)
+	assert.True(t, scheduleTime.Equal(sent.ScheduleTime.AsTime()))
+	assert.Equal(t, 30*time.Second, sent.DispatchDeadline.AsDuration())
+
+	req := sent.GetHttpRequest()
+	assert.Equal(t, "https://example.com/tasks/welcome", req.Url)
+	assert.Equal(t, taskspb.HttpMethod_POST, req.HttpMethod)
+	assert.Equal(t, "application/json", req.Headers["Content-Type"])
+	assert.Equal(t, `{"id":"1"}`, string(req.Body))
+	assert.Equal(t, taskServiceAccount, req.GetOidcToken().ServiceAccountEmail)
+	assert.Equal(t, "https://example.com/tasks/welcome", req.GetOidcToken().Audience)
+}
+
+func TestCloudTasksEnqueueHTTP_Defaults(t *testing.T) {
+	fake := &fakeCloudTasks{}
+	s := newFakeCloudTasksService(t, fake)
+
+	_, err := s.EnqueueHTTP(context.Background(), "emails", &cloudtasksboot.HTTPTask{
+		URL:                "https://example.com/tasks/welcome",
+		OIDCServiceAccount: taskServiceAccount,
+		OIDCAudience:       "https://example.com/tasks",
+	})
+
+	assert.Nil(t, err)
+
+	sent := fake.tasks[0].Task
+	assert.Empty(t, sent.Name)
+	assert.Nil(t, sent.ScheduleTime)
+	assert.Nil(t, sent.DispatchDeadline)
+	assert.Equal(t, "https://example.com/tasks", sent.GetHttpRequest().GetOidcToken().Audience)
+}
+
+func TestCloudTasksEnqueueHTTP_ErrorUnknownQueue(t *testing.T) {
+	fake := &fakeCloudTasks{}
+	s := newFakeCloudTasksService(t, fake)
+
+	_, err := s.EnqueueHTTP(context.Background(), "unknown", &cloudtasksboot.HTTPTask{URL: "https://example.com"})
+
+	assert.EqualError(t, err, `queue not found: "unknown"`)
+	assert.Empty(t, fake.tasks)
+}
+
+func TestCloudTasksEnqueueAppEngine_Success(t *testing.T) {
+	fake := &fakeCloudTasks{}
+	s := newFakeCloudTasksService(t, fake)
+	scheduleTime := time.Now().Add(time.Minute).UTC()
+
+	_, err := s.EnqueueAppEngine(context.Background(), "emails", &cloudtasksboot.AppEngineTask{
+		Name:         "welcome-1",
+		Service:      "worker",
+		Version:      "v2",
+		RelativeURI:  "/tasks/welcome",
+		Method:       taskspb.HttpMethod_PUT,
+		Body:         []byte("payload"),
+		ScheduleTime: scheduleTime,
+	})
+
+	assert.Nil(t, err)
+
+	sent := fake.tasks[0].Task
+	assert.Equal(t, emailQueuePath+"/tasks/welcome-1", sent.Name)
+	assert.True(t, scheduleTime.Equal(sent.ScheduleTime.AsTime()))
+
+	req := sent.GetAppEngineHttpRequest()
+	assert.Equal(t, "worker", req.AppEngineRouting.Service)
+	assert.Equal(t, "v2", req.AppEngineRouting.Version)
+	assert.Equal(t, "/tasks/welcome", req.RelativeUri)
+	assert.Equal(t, taskspb.HttpMethod_PUT, req.HttpMethod)
+	assert.Equal(t, "payload", string(req.Body))
+}
+
+func TestCloudTasksEnqueueAppEngine_ErrorUnknownQueue(t *testing.T) {
+	fake := &fakeCloudTasks{}
+	s := newFakeCloudTasksService(t, fake)
+
+	_, err := s.EnqueueAppEngine(context.Background(), "unknown", &cloudtasksboot.AppEngineTask{RelativeURI: "/"})
+
+	assert.EqualError(t, err, `queue not found: "unknown"`)
+	assert.Empty(t, fake.tasks)
+}
@@ -0,0 +1,15 @@
+package goboot
+
+import "context"
+
+// Elector runs work on a single instance of the app, e.g. a scheduler or a
+// relay. See redisboot.Elector and pgboot.Elector.
+type Elector interface {
+	// RunWhenLeader waits until this instance becomes the leader and runs
+	// fn. The context of fn is cancelled when leadership is lost, after
+	// which RunWhenLeader campaigns again.
+	//
+	// Returns the result of fn when it returns while still leader, or nil
+	// when ctx is done.
+	RunWhenLeader(ctx context.Context, fn func(ctx context.Context) error) error
+}
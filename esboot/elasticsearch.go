@@ -7,32 +7,91 @@ import (
 	"fmt"
 	"io"
 	"net/http"
-	"os"
+	"time"
 
 	elasticsearch7 "github.com/elastic/go-elasticsearch/v7"
 	"github.com/elastic/go-elasticsearch/v7/esapi"
-	"github.com/elastic/go-elasticsearch/v7/estransport"
 	"github.com/nielskrijger/goboot"
 	"github.com/rs/zerolog"
 	"github.com/tidwall/gjson"
 )
 
-var errMissingElasticsearchAddresses = errors.New("config \"elasticsearch.addresses\" is required")
+var (
+	errMissingElasticsearchAddresses = errors.New("config \"elasticsearch.addresses\" or \"elasticsearch.cloudID\" is required")
+	errUnknownBackend                = errors.New("unknown Elasticsearch backend")
+	errOpenSearchAuth                = errors.New("OpenSearch only supports username and password authentication")
+	errInvalidWaitForStatus          = errors.New("config \"elasticsearch.waitForStatus\" must be yellow or green")
+	errClusterHealthTimeout          = errors.New("timed out waiting for Elasticsearch cluster health")
+)
 
-const defaultMigrationsIndex = "migrations"
+const (
+	defaultMigrationsIndex      = "migrations"
+	defaultWaitForStatusTimeout = 30 * time.Second
+)
+
+// Supported values of "elasticsearch.backend".
+const (
+	BackendElasticsearch7 = "elasticsearch7"
+	BackendElasticsearch8 = "elasticsearch8"
+	BackendOpenSearch     = "opensearch"
+)
 
 type ESClusterInfo struct {
 	ClusterName string `json:"cluster_name"`
 }
 
+// Elasticsearch connects to Elasticsearch 7, Elasticsearch 8 or OpenSearch
+// depending on "elasticsearch.backend", default is Elasticsearch 7.
+//
+// Use Transport to perform esapi requests against any backend, e.g.
+// req.Do(ctx, s.Transport). The esapi v7 requests are compatible with all
+// backends for common operations such as indexing, searching and index management.
 type Elasticsearch struct {
-	Migrations      []*Migration
+	Migrations []*Migration
+
+	// MigrationsIndex is the name of the index recording the applied
+	// migrations without IndexPrefix. Defaults to config
+	// "elasticsearch.migrationsIndex" or "migrations".
 	MigrationsIndex string
 
+	// Declared resources provisioned at Init before running the migrations,
+	// see Provision.
+	ILMPolicies    []*ILMPolicy
+	IndexTemplates []*IndexTemplate
+	Indices        []*Index
+
+	// ProvisionDir optionally contains JSON declarations, see Provision.
+	ProvisionDir string
+
+	// DryRun logs the pending migrations instead of running them.
+	DryRun bool
+
+	// IndexPrefix is prepended to index names by IndexName, the migrations
+	// index and the declared Indices. Defaults to config
+	// "elasticsearch.indexPrefix". Allows multiple applications or test runs
+	// to share a cluster.
+	IndexPrefix string
+
+	// PreMigrationSnapshotRepository takes a snapshot of all indices in this
+	// repository before running pending migrations. Defaults to config
+	// "elasticsearch.preMigrationSnapshotRepository", disabled if empty.
+	PreMigrationSnapshotRepository string
+
+	// Transport performs requests against the configured backend.
+	Transport esapi.Transport
+
+	// Client is only set when the backend is Elasticsearch 7.
 	*elasticsearch7.Client
+
+	// Config is set from config by Configure, except CACert, Header,
+	// Transport and the retry settings which can be set before calling
+	// Configure and apply to all backends.
 	*elasticsearch7.Config
 
-	log zerolog.Logger
+	backend        string
+	configPolicies []*ILMPolicy
+	metrics        *elasticsearchMetrics
+	log            zerolog.Logger
 }
 
 func (s *Elasticsearch) Name() string {
@@ -45,16 +104,60 @@ func (s *Elasticsearch) Configure(env *goboot.AppEnv) error {
 	// Fetch config from viper. Avoid unmarshal directly into elasticsearch7.Config
 	// as it doesn't work with env vars:
 	// https://github.com/spf13/viper/issues/761
-	s.Config = &elasticsearch7.Config{
+	cfg := &elasticsearch7.Config{
 		Addresses: env.Config.GetStringSlice("elasticsearch.addresses"),
+		CloudID:   env.Config.GetString("elasticsearch.cloudID"),
 		Username:  env.Config.GetString("elasticsearch.username"),
-		Password:  env.Config.GetString("elasticsearch.password"),
 	}
 
-	if len(s.Config.Addresses) == 0 {
+	// keep the connection and retry settings set before calling Configure
+	if s.Config != nil {
+		cfg.CACert = s.Config.CACert
+		cfg.Header = s.Config.Header
+		cfg.Transport = s.Config.Transport
+		cfg.MaxRetries = s.Config.MaxRetries
+		cfg.DisableRetry = s.Config.DisableRetry
+		cfg.RetryOnStatus = s.Config.RetryOnStatus
+		cfg.RetryBackoff = s.Config.RetryBackoff
+	}
+
+	s.Config = cfg
+
+	if len(s.Config.Addresses) == 0 && s.Config.CloudID == "" {
 		return errMissingElasticsearchAddresses
 	}
 
+	if err := s.configureCredentials(env); err != nil {
+		return err
+	}
+
+	s.configureRetries(env)
+
+	if err := s.configureILMPolicies(env); err != nil {
+		return err
+	}
+
+	s.backend = env.Config.GetString("elasticsearch.backend")
+	if s.backend == "" {
+		s.backend = BackendElasticsearch7
+	}
+
+	if s.backend == BackendOpenSearch && (s.Config.APIKey != "" || s.Config.ServiceToken != "" || s.Config.CloudID != "") {
+		return errOpenSearchAuth
+	}
+
+	if status := env.Config.GetString("elasticsearch.waitForStatus"); status != "" && status != "yellow" && status != "green" {
+		return errInvalidWaitForStatus
+	}
+
+	if s.PreMigrationSnapshotRepository == "" {
+		s.PreMigrationSnapshotRepository = env.Config.GetString("elasticsearch.preMigrationSnapshotRepository")
+	}
+
+	if s.IndexPrefix == "" {
+		s.IndexPrefix = env.Config.GetString("elasticsearch.indexPrefix")
+	}
+
 	if s.MigrationsIndex == "" {
 		if env.Config.IsSet("elasticsearch.migrationsIndex") {
 			s.MigrationsIndex = env.Config.GetString("elasticsearch.migrationsIndex")
@@ -63,37 +166,109 @@ func (s *Elasticsearch) Configure(env *goboot.AppEnv) error {
 		}
 	}
 
-	// setup debug logging
-	if env.Log.Debug().Enabled() {
-		human := env.Config.Get("log.human")
-		if human == "true" {
-			s.Config.Logger = &estransport.ColorLogger{
-				Output:             os.Stdout,
-				EnableRequestBody:  true,
-				EnableResponseBody: true,
-			}
-		} else {
-			s.Config.Logger = &estransport.JSONLogger{
-				Output:             os.Stdout,
-				EnableRequestBody:  true,
-				EnableResponseBody: true,
-			}
-		}
+	if err := s.registerMetrics(env.Metrics); err != nil {
+		return err
 	}
 
-	// Start client
-	client, err := elasticsearch7.NewClient(*s.Config)
+	rt, err := s.roundTripper(env)
 	if err != nil {
-		return fmt.Errorf("creating Elasticsearch client: %w", err)
+		return err
 	}
 
-	s.Client = client
+	transport, err := s.newTransport(env, rt)
+	if err != nil {
+		return err
+	}
 
-	return s.testConnectivity(env)
+	s.Transport = transport
+
+	if err := s.testConnectivity(env); err != nil {
+		return err
+	}
+
+	return s.waitForClusterHealth(env)
+}
+
+// waitForClusterHealth blocks until the cluster reaches the health status of
+// "elasticsearch.waitForStatus" (yellow or green) or the timeout
+// "elasticsearch.waitForStatusTimeout" (default 30s) expires. Skipped if no
+// status is configured.
+func (s *Elasticsearch) waitForClusterHealth(env *goboot.AppEnv) error {
+	status := env.Config.GetString("elasticsearch.waitForStatus")
+	if status == "" {
+		return nil
+	}
+
+	timeout := defaultWaitForStatusTimeout
+	if env.Config.IsSet("elasticsearch.waitForStatusTimeout") {
+		timeout = env.Config.GetDuration("elasticsearch.waitForStatusTimeout")
+	}
+
+	res, err := esapi.ClusterHealthRequest{
+		WaitForStatus: status,
+		Timeout:       timeout,
+	}.Do(context.Background(), s.Transport)
+	if err != nil {
+		return fmt.Errorf("fetch Elasticsearch cluster health: %w", err)
+	}
+
+	// a timeout responds with 408 Request Timeout and the current health
+	var health struct {
+		Status   string `json:"status"`
+		TimedOut bool   `json:"timed_out"`
+	}
+
+	defer func() { _ = res.Body.Close() }()
+
+	if err := json.NewDecoder(res.Body).Decode(&health); err != nil {
+		return fmt.Errorf("decoding cluster health: %w", err)
+	}
+
+	if health.TimedOut {
+		return fmt.Errorf("%w: status is %s after %s, expected %s", errClusterHealthTimeout, health.Status, timeout, status)
+	}
+
+	if res.IsError() {
+		return fmt.Errorf("fetch Elasticsearch cluster health: %s", res.Status()) //nolint:goerr113
+	}
+
+	env.Log.Info().Msgf("Elasticsearch cluster health is %s", health.Status)
+
+	return nil
+}
+
+// IndexName returns the name of the index including IndexPrefix.
+func (s *Elasticsearch) IndexName(name string) string {
+	return s.IndexPrefix + name
+}
+
+// migrationsIndex returns the name of the migrations index including IndexPrefix.
+func (s *Elasticsearch) migrationsIndex() string {
+	return s.IndexName(s.MigrationsIndex)
+}
+
+// configureCredentials resolves the password, API key and service token
+// secrets, see goboot.ResolveSecret. The API key takes precedence over the
+// service token, which takes precedence over username and password.
+func (s *Elasticsearch) configureCredentials(env *goboot.AppEnv) error {
+	for key, target := range map[string]*string{
+		"password":     &s.Config.Password,
+		"apiKey":       &s.Config.APIKey,
+		"serviceToken": &s.Config.ServiceToken,
+	} {
+		secret, err := goboot.ResolveSecret(env.Config.GetString("elasticsearch." + key))
+		if err != nil {
+			return fmt.Errorf("resolving config \"elasticsearch.%s\": %w", key, err)
+		}
+
+		*target = secret
+	}
+
+	return nil
 }
 
 func (s *Elasticsearch) testConnectivity(env *goboot.AppEnv) error {
-	res, err := s.Client.Info()
+	res, err := esapi.InfoRequest{}.Do(context.Background(), s.Transport)
 	if err != nil {
 		return fmt.Errorf("fetch Elasticsearch cluster info: %w", err)
 	}
@@ -117,13 +292,17 @@ func (s *Elasticsearch) testConnectivity(env *goboot.AppEnv) error {
 		return fmt.Errorf("decoding cluster info: %w", err)
 	}
 
-	env.Log.Info().Msgf("successfully connected to Elasticsearch cluster \"%s\"", info.ClusterName)
+	env.Log.Info().Msgf("successfully connected to %s cluster \"%s\"", s.backend, info.ClusterName)
 
 	return nil
 }
 
-// Init runs the Elasticsearch migrations.
+// Init provisions the declared resources and runs the Elasticsearch migrations.
 func (s *Elasticsearch) Init() error {
+	if err := s.Provision(context.Background()); err != nil {
+		return fmt.Errorf("provisioning Elasticsearch: %w", err)
+	}
+
 	if err := s.Migrate(context.Background()); err != nil {
 		return fmt.Errorf("running Elasticsearch migrations: %w", err)
 	}
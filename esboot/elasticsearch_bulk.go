@@ -0,0 +1,399 @@
+package esboot
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/elastic/go-elasticsearch/v7/esapi"
+)
+
+var (
+	ErrBulkIndexerClosed = errors.New("bulk indexer is closed")
+	errTooManyRequests   = errors.New("too many requests, retries exhausted")
+	errMissingBulkItem   = errors.New("bulk response is missing the item")
+)
+
+// BulkIndexerConfig configures a BulkIndexer, zero values use the defaults.
+type BulkIndexerConfig struct {
+	// Index is the default index of items that don't specify one.
+	Index string
+
+	// NumWorkers is the number of concurrent bulk requests, defaults to 2.
+	NumWorkers int
+
+	// A worker flushes its batch when FlushBytes (defaults to 5MB) or
+	// FlushItems (defaults to 1000) is reached, or after FlushInterval
+	// (defaults to 5s).
+	FlushBytes    int
+	FlushItems    int
+	FlushInterval time.Duration
+
+	// MaxRetries is the number of times items rejected with 429 Too Many
+	// Requests are retried, defaults to 3. Set -1 to disable retries. The
+	// backoff starts at RetryBackoff (defaults to 100ms) and doubles every
+	// attempt. Bulk requests rejected as a whole are only retried by the
+	// transport, see "elasticsearch.maxRetries".
+	MaxRetries   int
+	RetryBackoff time.Duration
+
+	// Refresh is the refresh parameter of the bulk API: "true", "false" or "wait_for".
+	Refresh string
+
+	// OnError is called when a bulk request fails as a whole.
+	OnError func(ctx context.Context, err error)
+
+	// OnFailure is called for every item that failed to index.
+	OnFailure func(ctx context.Context, item BulkItem, res BulkItemResponse, err error)
+}
+
+// BulkItem is a single bulk operation.
+type BulkItem struct {
+	// Action is "index" (default), "create", "update" or "delete".
+	Action     string
+	Index      string
+	DocumentID string
+
+	// Body is marshalled to JSON, use json.RawMessage to pass raw JSON. Update
+	// actions expect a partial document body like {"doc": {...}}.
+	Body any
+}
+
+// BulkItemResponse is the result of a single bulk operation.
+type BulkItemResponse struct {
+	Index      string `json:"_index"`
+	DocumentID string `json:"_id"`
+	Result     string `json:"result"`
+	Status     int    `json:"status"`
+	Error      *struct {
+		Type   string `json:"type"`
+		Reason string `json:"reason"`
+	} `json:"error,omitempty"`
+}
+
+// BulkIndexerStats contains the totals of a BulkIndexer.
+type BulkIndexerStats struct {
+	NumAdded    uint64
+	NumIndexed  uint64
+	NumFailed   uint64
+	NumRetried  uint64
+	NumRequests uint64
+}
+
+// BulkIndexer batches documents into bulk requests using concurrent workers.
+type BulkIndexer struct {
+	es     *Elasticsearch
+	config BulkIndexerConfig
+	queue  chan *bulkEntry
+	wg     sync.WaitGroup
+
+	// mu guards closing the queue while Add sends to it
+	mu     sync.RWMutex
+	closed bool
+
+	numAdded    uint64
+	numIndexed  uint64
+	numFailed   uint64
+	numRetried  uint64
+	numRequests uint64
+}
+
+type bulkEntry struct {
+	item BulkItem
+	data []byte
+}
+
+// NewBulkIndexer starts a BulkIndexer, call Close to flush the remaining items.
+func (s *Elasticsearch) NewBulkIndexer(cfg BulkIndexerConfig) *BulkIndexer {
+	if cfg.NumWorkers <= 0 {
+		cfg.NumWorkers = 2
+	}
+
+	if cfg.FlushBytes <= 0 {
+		cfg.FlushBytes = 5 << 20
+	}
+
+	if cfg.FlushItems <= 0 {
+		cfg.FlushItems = 1000
+	}
+
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = 5 * time.Second
+	}
+
+	switch {
+	case cfg.MaxRetries == 0:
+		cfg.MaxRetries = 3
+	case cfg.MaxRetries < 0:
+		cfg.MaxRetries = 0
+	}
+
+	if cfg.RetryBackoff <= 0 {
+		cfg.RetryBackoff = 100 * time.Millisecond
+	}
+
+	bi := &BulkIndexer{
+		es:     s,
+		config: cfg,
+		queue:  make(chan *bulkEntry, cfg.NumWorkers),
+	}
+
+	for i := 0; i < cfg.NumWorkers; i++ {
+		bi.wg.Add(1)
+
+		go bi.worker()
+	}
+
+	return bi
+}
+
+// Add queues an item, blocks while all workers are busy.
+func (bi *BulkIndexer) Add(ctx context.Context, item BulkItem) error {
+	if item.Action == "" {
+		item.Action = "index"
+	}
+
+	if item.Index == "" {
+		item.Index = bi.config.Index
+	}
+
+	entry, err := newBulkEntry(item)
+	if err != nil {
+		return err
+	}
+
+	bi.mu.RLock()
+	defer bi.mu.RUnlock()
+
+	if bi.closed {
+		return ErrBulkIndexerClosed
+	}
+
+	select {
+	case <-ctx.Done():
+		return fmt.Errorf("adding bulk item: %w", ctx.Err())
+	case bi.queue <- entry:
+		atomic.AddUint64(&bi.numAdded, 1)
+		bi.es.metrics.addQueueDepth(1)
+
+		return nil
+	}
+}
+
+// Close flushes the remaining items and waits for the workers to finish.
+// Adding items after Close returns ErrBulkIndexerClosed.
+func (bi *BulkIndexer) Close(ctx context.Context) error {
+	// waits for blocked Add calls, the workers keep emptying the queue
+	bi.mu.Lock()
+	if !bi.closed {
+		bi.closed = true
+		close(bi.queue)
+	}
+	bi.mu.Unlock()
+
+	done := make(chan struct{})
+
+	go func() {
+		bi.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-ctx.Done():
+		return fmt.Errorf("closing bulk indexer: %w", ctx.Err())
+	case <-done:
+		return nil
+	}
+}
+
+// Stats returns the current totals.
+func (bi *BulkIndexer) Stats() BulkIndexerStats {
+	return BulkIndexerStats{
+		NumAdded:    atomic.LoadUint64(&bi.numAdded),
+		NumIndexed:  atomic.LoadUint64(&bi.numIndexed),
+		NumFailed:   atomic.LoadUint64(&bi.numFailed),
+		NumRetried:  atomic.LoadUint64(&bi.numRetried),
+		NumRequests: atomic.LoadUint64(&bi.numRequests),
+	}
+}
+
+// newBulkEntry encodes the item as NDJSON action and source lines.
+func newBulkEntry(item BulkItem) (*bulkEntry, error) {
+	meta := map[string]string{}
+	if item.Index != "" {
+		meta["_index"] = item.Index
+	}
+
+	if item.DocumentID != "" {
+		meta["_id"] = item.DocumentID
+	}
+
+	b, err := json.Marshal(map[string]any{item.Action: meta})
+	if err != nil {
+		return nil, fmt.Errorf("marshalling bulk item: %w", err)
+	}
+
+	b = append(b, '\n')
+
+	if item.Action != "delete" {
+		body, err := json.Marshal(item.Body)
+		if err != nil {
+			return nil, fmt.Errorf("marshalling bulk item body: %w", err)
+		}
+
+		b = append(append(b, body...), '\n')
+	}
+
+	return &bulkEntry{item: item, data: b}, nil
+}
+
+func (bi *BulkIndexer) worker() {
+	defer bi.wg.Done()
+
+	ticker := time.NewTicker(bi.config.FlushInterval)
+	defer ticker.Stop()
+
+	var (
+		batch []*bulkEntry
+		size  int
+	)
+
+	flush := func() {
+		if len(batch) > 0 {
+			bi.flush(context.Background(), batch)
+			batch, size = nil, 0
+		}
+	}
+
+	for {
+		select {
+		case entry, ok := <-bi.queue:
+			if !ok {
+				flush()
+
+				return
+			}
+
+			batch = append(batch, entry)
+			size += len(entry.data)
+
+			if size >= bi.config.FlushBytes || len(batch) >= bi.config.FlushItems {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
+
+// flush sends the batch, retrying the items rejected with 429 Too Many Requests.
+func (bi *BulkIndexer) flush(ctx context.Context, batch []*bulkEntry) {
+	defer bi.es.metrics.addQueueDepth(-len(batch))
+
+	backoff := bi.config.RetryBackoff
+
+	for attempt := 0; ; attempt++ {
+		retry, err := bi.send(ctx, batch)
+		if err != nil {
+			if bi.config.OnError != nil {
+				bi.config.OnError(ctx, err)
+			}
+
+			for _, entry := range batch {
+				bi.fail(ctx, entry, BulkItemResponse{}, err)
+			}
+
+			return
+		}
+
+		if len(retry) == 0 {
+			return
+		}
+
+		if attempt >= bi.config.MaxRetries {
+			for _, entry := range retry {
+				bi.fail(ctx, entry, BulkItemResponse{Status: http.StatusTooManyRequests}, errTooManyRequests)
+			}
+
+			return
+		}
+
+		atomic.AddUint64(&bi.numRetried, uint64(len(retry)))
+		bi.es.metrics.observeBulkItems("retried", len(retry))
+		time.Sleep(backoff)
+
+		backoff *= 2
+		batch = retry
+	}
+}
+
+// send performs a single bulk request and returns the items to retry.
+func (bi *BulkIndexer) send(ctx context.Context, batch []*bulkEntry) ([]*bulkEntry, error) {
+	var buf bytes.Buffer
+	for _, entry := range batch {
+		buf.Write(entry.data)
+	}
+
+	atomic.AddUint64(&bi.numRequests, 1)
+
+	res, err := esapi.BulkRequest{Body: &buf, Refresh: bi.config.Refresh}.Do(ctx, bi.es.Transport)
+	if err != nil {
+		return nil, fmt.Errorf("sending bulk request: %w", err)
+	}
+
+	b, err := bi.es.ParseResponseBytes(res)
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		Items []map[string]BulkItemResponse `json:"items"`
+	}
+	if err := json.Unmarshal(b, &result); err != nil {
+		return nil, fmt.Errorf("parsing bulk response: %w", err)
+	}
+
+	var retry []*bulkEntry
+
+	for i, entry := range batch {
+		if i >= len(result.Items) {
+			bi.fail(ctx, entry, BulkItemResponse{}, errMissingBulkItem)
+
+			continue
+		}
+
+		for _, item := range result.Items[i] {
+			switch {
+			case item.Status == http.StatusTooManyRequests:
+				retry = append(retry, entry)
+			case item.Status > 299:
+				err := fmt.Errorf("bulk item failed with status %d", item.Status) //nolint:goerr113
+				if item.Error != nil {
+					err = fmt.Errorf("%s: %s", item.Error.Type, item.Error.Reason) //nolint:goerr113
+				}
+
+				bi.fail(ctx, entry, item, err)
+			default:
+				atomic.AddUint64(&bi.numIndexed, 1)
+				bi.es.metrics.observeBulkItems("indexed", 1)
+			}
+		}
+	}
+
+	return retry, nil
+}
+
+func (bi *BulkIndexer) fail(ctx context.Context, entry *bulkEntry, res BulkItemResponse, err error) {
+	atomic.AddUint64(&bi.numFailed, 1)
+	bi.es.metrics.observeBulkItems("failed", 1)
+
+	if bi.config.OnFailure != nil {
+		bi.config.OnFailure(ctx, entry.item, res, err)
+	}
+}
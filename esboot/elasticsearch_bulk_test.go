@@ -0,0 +1,175 @@
+package esboot_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/elastic/go-elasticsearch/v7"
+	"github.com/nielskrijger/goboot"
+	"github.com/nielskrijger/goboot/esboot"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBulkIndexer_Success(t *testing.T) {
+	s := &esboot.Elasticsearch{}
+	setupElasticsearchEnv(t, s)
+
+	bi := s.NewBulkIndexer(esboot.BulkIndexerConfig{Index: "test", FlushItems: 10, Refresh: "true"})
+	for i := 0; i < 25; i++ {
+		assert.Nil(t, bi.Add(context.Background(), esboot.BulkItem{Body: map[string]any{"n": i}}))
+	}
+
+	assert.Nil(t, bi.Close(context.Background()))
+
+	stats := bi.Stats()
+	assert.Equal(t, uint64(25), stats.NumAdded)
+	assert.Equal(t, uint64(25), stats.NumIndexed)
+	assert.Equal(t, uint64(0), stats.NumFailed)
+	assert.GreaterOrEqual(t, stats.NumRequests, uint64(3))
+}
+
+func TestBulkIndexer_OnFailure(t *testing.T) {
+	s := &esboot.Elasticsearch{}
+	setupElasticsearchEnv(t, s)
+
+	var (
+		mu       sync.Mutex
+		failures []esboot.BulkItemResponse
+	)
+
+	bi := s.NewBulkIndexer(esboot.BulkIndexerConfig{
+		Index: "test",
+		OnFailure: func(_ context.Context, _ esboot.BulkItem, res esboot.BulkItemResponse, _ error) {
+			mu.Lock()
+			defer mu.Unlock()
+			failures = append(failures, res)
+		},
+	})
+	assert.Nil(t, bi.Add(context.Background(), esboot.BulkItem{DocumentID: "1", Body: json.RawMessage(`{"n": 1}`)}))
+	assert.Nil(t, bi.Add(context.Background(), esboot.BulkItem{Action: "update", DocumentID: "unknown", Body: map[string]any{"doc": map[string]any{"n": 2}}}))
+	assert.Nil(t, bi.Close(context.Background()))
+
+	assert.Equal(t, uint64(1), bi.Stats().NumIndexed)
+	assert.Equal(t, uint64(1), bi.Stats().NumFailed)
+	assert.Len(t, failures, 1)
+	assert.Equal(t, 404, failures[0].Status)
+}
+
+func TestBulkIndexer_RequestRetriedByTransportOnly(t *testing.T) {
+	var bulkCalls int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/_bulk" {
+			atomic.AddInt32(&bulkCalls, 1)
+			w.WriteHeader(http.StatusTooManyRequests)
+
+			return
+		}
+
+		_, _ = w.Write([]byte(`{"cluster_name": "test"}`))
+	}))
+	defer server.Close()
+
+	env := goboot.NewAppEnv("./testdata", "opensearch")
+	env.Config.Set("elasticsearch.addresses", []string{server.URL})
+
+	s := &esboot.Elasticsearch{Config: &elasticsearch.Config{
+		MaxRetries:   1,
+		RetryBackoff: func(int) time.Duration { return time.Millisecond },
+	}}
+	assert.Nil(t, s.Configure(env))
+
+	bi := s.NewBulkIndexer(esboot.BulkIndexerConfig{Index: "test", RetryBackoff: time.Millisecond})
+	assert.Nil(t, bi.Add(context.Background(), esboot.BulkItem{Body: map[string]any{"n": 1}}))
+	assert.Nil(t, bi.Close(context.Background()))
+
+	assert.Equal(t, int32(2), atomic.LoadInt32(&bulkCalls))
+	assert.Equal(t, uint64(1), bi.Stats().NumFailed)
+}
+
+func TestBulkIndexer_MissingItems(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/_bulk" {
+			_, _ = w.Write([]byte(`{"items": [{"index": {"_index": "test", "_id": "1", "status": 201}}]}`))
+
+			return
+		}
+
+		_, _ = w.Write([]byte(`{"cluster_name": "test"}`))
+	}))
+	defer server.Close()
+
+	env := goboot.NewAppEnv("./testdata", "opensearch")
+	env.Config.Set("elasticsearch.addresses", []string{server.URL})
+
+	s := &esboot.Elasticsearch{}
+	assert.Nil(t, s.Configure(env))
+
+	var (
+		mu       sync.Mutex
+		failures []error
+	)
+
+	bi := s.NewBulkIndexer(esboot.BulkIndexerConfig{
+		Index: "test",
+		OnFailure: func(_ context.Context, _ esboot.BulkItem, _ esboot.BulkItemResponse, err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			failures = append(failures, err)
+		},
+	})
+	for i := 0; i < 3; i++ {
+		assert.Nil(t, bi.Add(context.Background(), esboot.BulkItem{Body: map[string]any{"n": i}}))
+	}
+
+	assert.Nil(t, bi.Close(context.Background()))
+
+	stats := bi.Stats()
+	assert.Equal(t, uint64(1), stats.NumIndexed)
+	assert.Equal(t, uint64(2), stats.NumFailed)
+	assert.Len(t, failures, 2)
+}
+
+func TestBulkIndexer_AddAfterClose(t *testing.T) {
+	bi := (&esboot.Elasticsearch{}).NewBulkIndexer(esboot.BulkIndexerConfig{})
+	assert.Nil(t, bi.Close(context.Background()))
+	assert.ErrorIs(t, bi.Add(context.Background(), esboot.BulkItem{Body: "{}"}), esboot.ErrBulkIndexerClosed)
+}
+
+func TestBulkIndexer_AddWhileClosing(t *testing.T) {
+	s := &esboot.Elasticsearch{}
+	setupElasticsearchEnv(t, s)
+
+	bi := s.NewBulkIndexer(esboot.BulkIndexerConfig{Index: "test", NumWorkers: 1})
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for {
+				err := bi.Add(context.Background(), esboot.BulkItem{Body: map[string]any{"n": 1}})
+				if err != nil {
+					assert.ErrorIs(t, err, esboot.ErrBulkIndexerClosed)
+
+					return
+				}
+			}
+		}()
+	}
+
+	assert.Nil(t, bi.Close(context.Background()))
+	wg.Wait()
+
+	stats := bi.Stats()
+	assert.Equal(t, stats.NumAdded, stats.NumIndexed+stats.NumFailed)
+}
@@ -0,0 +1,111 @@
+package esboot
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/elastic/go-elasticsearch/v7/esapi"
+)
+
+// TimestampField is the field data streams use to order documents.
+const TimestampField = "@timestamp"
+
+// CreateDataStream creates a data stream if it doesn't exist yet. A data
+// stream requires a matching index template with DataStream enabled, e.g.:
+//
+//	es.IndexTemplates = append(es.IndexTemplates, &esboot.IndexTemplate{
+//		Name:       "logs",
+//		DataStream: true,
+//		ILMPolicy:  "logs",
+//		Body:       map[string]any{"index_patterns": []string{"logs-*"}},
+//	})
+func (s *Elasticsearch) CreateDataStream(ctx context.Context, name string) error {
+	res, err := esapi.IndicesGetDataStreamRequest{Name: []string{name}}.Do(ctx, s.Transport)
+	if err != nil {
+		return fmt.Errorf("checking if ES data stream %q exists: %w", name, err)
+	}
+
+	_ = res.Body.Close()
+
+	if res.StatusCode == http.StatusOK {
+		return nil
+	}
+
+	if err := s.do(ctx, esapi.IndicesCreateDataStreamRequest{Name: name}); err != nil {
+		return fmt.Errorf("creating ES data stream %q: %w", name, err)
+	}
+
+	s.log.Info().Msgf("created ES data stream %q", name)
+
+	return nil
+}
+
+// DeleteDataStream deletes a data stream and all its backing indices.
+func (s *Elasticsearch) DeleteDataStream(ctx context.Context, name string) error {
+	if err := s.do(ctx, esapi.IndicesDeleteDataStreamRequest{Name: []string{name}}); err != nil {
+		return fmt.Errorf("deleting ES data stream %q: %w", name, err)
+	}
+
+	return nil
+}
+
+// IndexDataStream appends a document to a data stream, the @timestamp field
+// is set to the current time if the document doesn't have one.
+func (s *Elasticsearch) IndexDataStream(ctx context.Context, name string, doc any) error {
+	body, err := WithTimestamp(doc)
+	if err != nil {
+		return err
+	}
+
+	req := esapi.IndexRequest{Index: name, OpType: "create", Body: bytes.NewReader(body)}
+	if err := s.do(ctx, req); err != nil {
+		return fmt.Errorf("indexing into ES data stream %q: %w", name, err)
+	}
+
+	return nil
+}
+
+// RolloverDataStream creates a new write index for the data stream. Usually
+// ILM takes care of this, rollover manually after changing the mapping of the
+// index template to apply it to new documents.
+func (s *Elasticsearch) RolloverDataStream(ctx context.Context, name string) error {
+	if err := s.do(ctx, esapi.IndicesRolloverRequest{Alias: name}); err != nil {
+		return fmt.Errorf("rolling over ES data stream %q: %w", name, err)
+	}
+
+	s.log.Info().Msgf("rolled over ES data stream %q", name)
+
+	return nil
+}
+
+// WithTimestamp marshals doc to JSON and adds the @timestamp field with the
+// current time if missing. Use it as BulkItem.Body with action "create" to
+// bulk index into data streams.
+func WithTimestamp(doc any) (json.RawMessage, error) {
+	b, err := json.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("marshalling document: %w", err)
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(b, &fields); err != nil {
+		return nil, fmt.Errorf("document must be a JSON object: %w", err)
+	}
+
+	if _, ok := fields[TimestampField]; ok {
+		return b, nil
+	}
+
+	fields[TimestampField], _ = json.Marshal(time.Now().UTC().Format(time.RFC3339Nano))
+
+	b, err = json.Marshal(fields)
+	if err != nil {
+		return nil, fmt.Errorf("marshalling document: %w", err)
+	}
+
+	return b, nil
+}
@@ -0,0 +1,49 @@
+package esboot_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/nielskrijger/goboot/esboot"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestElasticsearch_DataStream(t *testing.T) {
+	s := &esboot.Elasticsearch{
+		IndexTemplates: []*esboot.IndexTemplate{{
+			Name:       "test-logs",
+			DataStream: true,
+			Body:       map[string]any{"index_patterns": []string{"test-logs-*"}},
+		}},
+	}
+	setupElasticsearchEnv(t, s)
+	_ = s.DeleteDataStream(context.Background(), "test-logs-app")
+
+	ctx := context.Background()
+	assert.Nil(t, s.Provision(ctx))
+	assert.Nil(t, s.CreateDataStream(ctx, "test-logs-app"))
+	assert.Nil(t, s.CreateDataStream(ctx, "test-logs-app"))
+	assert.Nil(t, s.IndexDataStream(ctx, "test-logs-app", map[string]any{"message": "hello"}))
+	assert.Nil(t, s.RolloverDataStream(ctx, "test-logs-app"))
+}
+
+func TestWithTimestamp(t *testing.T) {
+	b, err := esboot.WithTimestamp(map[string]any{"message": "hello"})
+	assert.Nil(t, err)
+
+	var doc map[string]string
+	assert.Nil(t, json.Unmarshal(b, &doc))
+
+	ts, err := time.Parse(time.RFC3339Nano, doc["@timestamp"])
+	assert.Nil(t, err)
+	assert.WithinDuration(t, time.Now(), ts, time.Minute)
+
+	b, err = esboot.WithTimestamp(map[string]any{"@timestamp": "2022-01-01T00:00:00Z"})
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{"@timestamp": "2022-01-01T00:00:00Z"}`, string(b))
+
+	_, err = esboot.WithTimestamp([]string{"not an object"})
+	assert.ErrorContains(t, err, "document must be a JSON object")
+}
@@ -0,0 +1,312 @@
+package esboot
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/elastic/go-elasticsearch/v7/esapi"
+)
+
+// ErrReindexRequired is returned when an index differs from the desired state
+// in a way that can't be updated in place, see ReindexWithAlias.
+var ErrReindexRequired = errors.New("reindex required")
+
+// staticSettings can only be set at index creation, prefixes match nested settings.
+var staticSettings = []string{
+	"index.number_of_shards",
+	"index.number_of_routing_shards",
+	"index.codec",
+	"index.routing_partition_size",
+	"index.sort.",
+	"index.analysis.",
+	"index.mode",
+}
+
+// updatableMappingParams are mapping parameters that can be changed without
+// reindexing.
+var updatableMappingParams = []string{
+	"dynamic",
+	"ignore_above",
+}
+
+// IndexChange is a difference between the current and desired index state.
+type IndexChange struct {
+	// Path is the setting or mapping, e.g. "index.number_of_replicas" or
+	// "properties.name.type".
+	Path     string
+	Current  any
+	Desired  any
+	Breaking bool
+}
+
+// IndexDiffError lists the breaking changes of an index.
+type IndexDiffError struct {
+	Index   string
+	Changes []IndexChange
+}
+
+func (e *IndexDiffError) Error() string {
+	changes := make([]string, len(e.Changes))
+	for i, c := range e.Changes {
+		changes[i] = fmt.Sprintf("%s: %v -> %v", c.Path, c.Current, c.Desired)
+	}
+
+	return fmt.Sprintf("%s of ES index %q: %s", ErrReindexRequired, e.Index, strings.Join(changes, ", "))
+}
+
+func (e *IndexDiffError) Unwrap() error {
+	return ErrReindexRequired
+}
+
+// EnsureIndex creates the index if it doesn't exist, otherwise it compares
+// its settings and mappings to the desired state. Dynamic settings and new
+// mapping fields are updated in place. Static settings and changed field
+// mappings return an *IndexDiffError wrapping ErrReindexRequired.
+//
+// Settings can be specified flat or nested, with or without "index." prefix.
+func (s *Elasticsearch) EnsureIndex(ctx context.Context, name string, settings map[string]any, mappings map[string]any) error {
+	exists, err := s.IndexExists(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	if !exists {
+		body := map[string]any{}
+		if len(settings) > 0 {
+			body["settings"] = settings
+		}
+
+		if len(mappings) > 0 {
+			body["mappings"] = mappings
+		}
+
+		b, _ := json.Marshal(body)
+		if err := s.do(ctx, esapi.IndicesCreateRequest{Index: name, Body: bytes.NewReader(b)}); err != nil {
+			return fmt.Errorf("creating ES index %q: %w", name, err)
+		}
+
+		s.log.Info().Msgf("created ES index %q", name)
+
+		return nil
+	}
+
+	return s.updateIndex(ctx, name, settings, mappings)
+}
+
+// updateIndex applies the non-breaking changes of an existing index, nothing
+// is changed if there are breaking changes.
+func (s *Elasticsearch) updateIndex(ctx context.Context, name string, settings map[string]any, mappings map[string]any) error {
+	var settingChanges, mappingChanges []IndexChange
+
+	if len(settings) > 0 {
+		changes, err := s.diffSettings(ctx, name, settings)
+		if err != nil {
+			return err
+		}
+
+		settingChanges = changes
+	}
+
+	if len(mappings) > 0 {
+		changes, err := s.diffMappings(ctx, name, mappings)
+		if err != nil {
+			return err
+		}
+
+		mappingChanges = changes
+	}
+
+	var breaking []IndexChange
+
+	for _, c := range append(append([]IndexChange{}, settingChanges...), mappingChanges...) {
+		if c.Breaking {
+			breaking = append(breaking, c)
+		}
+	}
+
+	if len(breaking) > 0 {
+		return &IndexDiffError{Index: name, Changes: breaking}
+	}
+
+	if len(settingChanges) > 0 {
+		dynamic := map[string]any{}
+		for _, c := range settingChanges {
+			dynamic[c.Path] = c.Desired
+		}
+
+		b, _ := json.Marshal(dynamic)
+		if err := s.do(ctx, esapi.IndicesPutSettingsRequest{Index: []string{name}, Body: bytes.NewReader(b)}); err != nil {
+			return fmt.Errorf("updating settings of ES index %q: %w", name, err)
+		}
+
+		s.log.Info().Msgf("updated settings of ES index %q", name)
+	}
+
+	if len(mappingChanges) > 0 {
+		b, _ := json.Marshal(mappings)
+		if err := s.do(ctx, esapi.IndicesPutMappingRequest{Index: []string{name}, Body: bytes.NewReader(b)}); err != nil {
+			return fmt.Errorf("updating mapping of ES index %q: %w: %v", name, ErrReindexRequired, err)
+		}
+
+		s.log.Info().Msgf("updated mapping of ES index %q", name)
+	}
+
+	return nil
+}
+
+func (s *Elasticsearch) diffSettings(ctx context.Context, name string, settings map[string]any) ([]IndexChange, error) {
+	res, err := s.getJSON(ctx, esapi.IndicesGetSettingsRequest{Index: []string{name}})
+	if err != nil {
+		return nil, fmt.Errorf("reading settings of ES index %q: %w", name, err)
+	}
+
+	current := map[string]any{}
+
+	// keyed by the concrete index name which differs from name for aliases
+	for _, v := range res {
+		if m, ok := v.(map[string]any); ok {
+			current = flatten("", m["settings"])
+		}
+	}
+
+	var changes []IndexChange
+
+	for path, desired := range flatten("", normalizeSettings(settings)) {
+		if containsJSON(current[path], desired) {
+			continue
+		}
+
+		changes = append(changes, IndexChange{
+			Path:     path,
+			Current:  current[path],
+			Desired:  desired,
+			Breaking: isStaticSetting(path),
+		})
+	}
+
+	sortChanges(changes)
+
+	return changes, nil
+}
+
+func (s *Elasticsearch) diffMappings(ctx context.Context, name string, mappings map[string]any) ([]IndexChange, error) {
+	res, err := s.getJSON(ctx, esapi.IndicesGetMappingRequest{Index: []string{name}})
+	if err != nil {
+		return nil, fmt.Errorf("reading mapping of ES index %q: %w", name, err)
+	}
+
+	current := map[string]any{}
+
+	for _, v := range res {
+		if m, ok := v.(map[string]any); ok {
+			current = flatten("", m["mappings"])
+		}
+	}
+
+	var changes []IndexChange
+
+	for path, desired := range flatten("", mappings) {
+		actual, exists := current[path]
+		if exists && containsJSON(actual, desired) {
+			continue
+		}
+
+		// new fields and updatable parameters are changed in place, other
+		// changes require a reindex
+		changes = append(changes, IndexChange{
+			Path:     path,
+			Current:  actual,
+			Desired:  desired,
+			Breaking: !isUpdatableMapping(path) && (exists || !isNewField(path, current)),
+		})
+	}
+
+	sortChanges(changes)
+
+	return changes, nil
+}
+
+// isNewField returns true if path is a parameter of a field that doesn't
+// exist in the current mapping, e.g. "properties.age.type".
+func isNewField(path string, current map[string]any) bool {
+	if !strings.HasPrefix(path, "properties.") {
+		return false
+	}
+
+	field := path[:strings.LastIndex(path, ".")]
+
+	for p := range current {
+		if strings.HasPrefix(p, field+".") {
+			return false
+		}
+	}
+
+	return true
+}
+
+// isUpdatableMapping returns true if the mapping parameter at path can be
+// changed in place, e.g. "dynamic", "properties.address.dynamic" or
+// "_meta.version".
+func isUpdatableMapping(path string) bool {
+	if strings.HasPrefix(path, "_meta.") {
+		return true
+	}
+
+	param := path[strings.LastIndex(path, ".")+1:]
+
+	for _, updatable := range updatableMappingParams {
+		if param == updatable {
+			return true
+		}
+	}
+
+	return false
+}
+
+func isStaticSetting(path string) bool {
+	for _, static := range staticSettings {
+		if path == static || (strings.HasSuffix(static, ".") && strings.HasPrefix(path, static)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// flatten converts nested objects to a map of dotted paths to values.
+func flatten(prefix string, v any) map[string]any {
+	result := map[string]any{}
+
+	m, ok := v.(map[string]any)
+	if !ok || len(m) == 0 {
+		if prefix != "" {
+			result[prefix] = v
+		}
+
+		return result
+	}
+
+	for key, value := range m {
+		path := key
+		if prefix != "" {
+			path = prefix + "." + key
+		}
+
+		for k, v := range flatten(path, value) {
+			result[k] = v
+		}
+	}
+
+	return result
+}
+
+func sortChanges(changes []IndexChange) {
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Path < changes[j].Path
+	})
+}
@@ -0,0 +1,84 @@
+package esboot_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/nielskrijger/goboot/esboot"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestElasticsearch_EnsureIndex(t *testing.T) {
+	s := &esboot.Elasticsearch{}
+	setupElasticsearchEnv(t, s)
+
+	ctx := context.Background()
+	mappings := map[string]any{
+		"properties": map[string]any{"name": map[string]any{"type": "keyword"}},
+	}
+
+	assert.Nil(t, s.EnsureIndex(ctx, "test", map[string]any{"number_of_shards": 1}, mappings))
+
+	// unchanged
+	assert.Nil(t, s.EnsureIndex(ctx, "test", map[string]any{"number_of_shards": 1}, mappings))
+
+	// dynamic setting and new field are updated in place
+	mappings["properties"].(map[string]any)["age"] = map[string]any{"type": "integer"}
+	assert.Nil(t, s.EnsureIndex(ctx, "test", map[string]any{"index.number_of_replicas": 0}, mappings))
+
+	// dynamic mapping parameter is updated in place
+	mappings["dynamic"] = "strict"
+	assert.Nil(t, s.EnsureIndex(ctx, "test", nil, mappings))
+}
+
+func TestElasticsearch_EnsureIndexWithoutSettings(t *testing.T) {
+	s := &esboot.Elasticsearch{}
+	setupElasticsearchEnv(t, s)
+
+	assert.Nil(t, s.EnsureIndex(context.Background(), "test", nil, map[string]any{
+		"properties": map[string]any{"name": map[string]any{"type": "keyword"}},
+	}))
+}
+
+func TestElasticsearch_EnsureIndexBreakingChanges(t *testing.T) {
+	s := &esboot.Elasticsearch{}
+	setupElasticsearchEnv(t, s)
+
+	ctx := context.Background()
+	assert.Nil(t, s.EnsureIndex(ctx, "test", map[string]any{"number_of_shards": 1}, map[string]any{
+		"properties": map[string]any{"name": map[string]any{"type": "keyword"}},
+	}))
+
+	err := s.EnsureIndex(ctx, "test", map[string]any{"number_of_shards": 2}, map[string]any{
+		"properties": map[string]any{"name": map[string]any{"type": "text"}},
+	})
+	assert.ErrorIs(t, err, esboot.ErrReindexRequired)
+
+	var diff *esboot.IndexDiffError
+	if assert.True(t, errors.As(err, &diff)) {
+		assert.Equal(t, []esboot.IndexChange{
+			{Path: "index.number_of_shards", Current: "1", Desired: 2, Breaking: true},
+			{Path: "properties.name.type", Current: "keyword", Desired: "text", Breaking: true},
+		}, diff.Changes)
+	}
+
+	// dynamic settings aren't applied when there are breaking changes
+	err = s.EnsureIndex(ctx, "test", map[string]any{"number_of_replicas": 0}, map[string]any{
+		"properties": map[string]any{"name": map[string]any{"type": "text"}},
+	})
+	assert.ErrorIs(t, err, esboot.ErrReindexRequired)
+
+	res, err := s.Client.Indices.GetSettings(
+		s.Client.Indices.GetSettings.WithIndex("test"),
+		s.Client.Indices.GetSettings.WithName("index.number_of_replicas"),
+	)
+	assert.Nil(t, err)
+
+	defer res.Body.Close()
+
+	b, err := io.ReadAll(res.Body)
+	assert.Nil(t, err)
+	assert.Contains(t, string(b), `"number_of_replicas":"1"`)
+}
@@ -0,0 +1,127 @@
+package esboot
+
+import (
+	"fmt"
+
+	"github.com/nielskrijger/goboot"
+)
+
+// ILMPhases declares a hot-warm-delete lifecycle policy, use Policy as the
+// body of an ILMPolicy. Phases that are nil are omitted.
+//
+// ILM policies can also be declared in config, e.g.:
+//
+//	elasticsearch:
+//	  ilmPolicies:
+//	    logs:
+//	      hot:
+//	        maxAge: 7d
+//	        maxPrimaryShardSize: 50gb
+//	      warm:
+//	        minAge: 30d
+//	        forceMergeSegments: 1
+//	      delete:
+//	        minAge: 90d
+type ILMPhases struct {
+	Hot    *ILMHotPhase    `yaml:"hot"`
+	Warm   *ILMWarmPhase   `yaml:"warm"`
+	Delete *ILMDeletePhase `yaml:"delete"`
+}
+
+// ILMHotPhase rolls over the write index when any of the conditions is met.
+type ILMHotPhase struct {
+	MaxAge              string `yaml:"maxAge"`
+	MaxPrimaryShardSize string `yaml:"maxPrimaryShardSize"`
+	MaxDocs             int64  `yaml:"maxDocs"`
+}
+
+// ILMWarmPhase optionally shrinks and force merges indices older than MinAge.
+type ILMWarmPhase struct {
+	MinAge             string `yaml:"minAge"`
+	ShrinkShards       int    `yaml:"shrinkShards"`
+	ForceMergeSegments int    `yaml:"forceMergeSegments"`
+}
+
+// ILMDeletePhase deletes indices older than MinAge.
+type ILMDeletePhase struct {
+	MinAge string `yaml:"minAge"`
+}
+
+// Policy returns the body of the put lifecycle API.
+func (p ILMPhases) Policy() map[string]any {
+	phases := map[string]any{}
+
+	if p.Hot != nil {
+		rollover := map[string]any{}
+
+		if p.Hot.MaxAge != "" {
+			rollover["max_age"] = p.Hot.MaxAge
+		}
+
+		if p.Hot.MaxPrimaryShardSize != "" {
+			rollover["max_primary_shard_size"] = p.Hot.MaxPrimaryShardSize
+		}
+
+		if p.Hot.MaxDocs > 0 {
+			rollover["max_docs"] = p.Hot.MaxDocs
+		}
+
+		actions := map[string]any{}
+		if len(rollover) > 0 {
+			actions["rollover"] = rollover
+		}
+
+		phases["hot"] = map[string]any{"min_age": "0ms", "actions": actions}
+	}
+
+	if p.Warm != nil {
+		actions := map[string]any{}
+
+		if p.Warm.ShrinkShards > 0 {
+			actions["shrink"] = map[string]any{"number_of_shards": p.Warm.ShrinkShards}
+		}
+
+		if p.Warm.ForceMergeSegments > 0 {
+			actions["forcemerge"] = map[string]any{"max_num_segments": p.Warm.ForceMergeSegments}
+		}
+
+		phases["warm"] = map[string]any{"min_age": minAge(p.Warm.MinAge), "actions": actions}
+	}
+
+	if p.Delete != nil {
+		phases["delete"] = map[string]any{
+			"min_age": minAge(p.Delete.MinAge),
+			"actions": map[string]any{"delete": map[string]any{}},
+		}
+	}
+
+	return map[string]any{"policy": map[string]any{"phases": phases}}
+}
+
+func minAge(age string) string {
+	if age == "" {
+		return "0ms"
+	}
+
+	return age
+}
+
+// configureILMPolicies reads the policies declared in "elasticsearch.ilmPolicies".
+func (s *Elasticsearch) configureILMPolicies(env *goboot.AppEnv) error {
+	s.configPolicies = nil
+
+	if !env.Config.IsSet("elasticsearch.ilmPolicies") {
+		return nil
+	}
+
+	var policies map[string]ILMPhases
+	if err := env.Config.UnmarshalKey("elasticsearch.ilmPolicies", &policies); err != nil {
+		return fmt.Errorf("parsing Elasticsearch ILM policies: %w", err)
+	}
+
+	for name, phases := range policies {
+		s.configPolicies = append(s.configPolicies, &ILMPolicy{Name: name, Body: phases.Policy()})
+	}
+
+	return nil
+}
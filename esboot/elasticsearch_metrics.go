@@ -0,0 +1,126 @@
+package esboot
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nielskrijger/goboot"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type elasticsearchMetrics struct {
+	requestDuration *prometheus.HistogramVec
+	requests        *prometheus.CounterVec
+	bulkItems       *prometheus.CounterVec
+	bulkQueueDepth  prometheus.Gauge
+}
+
+func newElasticsearchMetrics() *elasticsearchMetrics {
+	return &elasticsearchMetrics{
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "elasticsearch_request_duration_seconds",
+			Help:    "Duration of Elasticsearch requests by method and operation.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"method", "operation"}),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "elasticsearch_requests_total",
+			Help: "Number of Elasticsearch requests by operation and status code, status is \"error\" if no response was received.",
+		}, []string{"operation", "status"}),
+		bulkItems: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "elasticsearch_bulk_items_total",
+			Help: "Number of bulk items by result: indexed, failed or retried.",
+		}, []string{"result"}),
+		bulkQueueDepth: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "elasticsearch_bulk_queue_depth",
+			Help: "Number of bulk items added but not yet flushed.",
+		}),
+	}
+}
+
+func (m *elasticsearchMetrics) observeBulkItems(result string, n int) {
+	if m != nil && n > 0 {
+		m.bulkItems.WithLabelValues(result).Add(float64(n))
+	}
+}
+
+func (m *elasticsearchMetrics) addQueueDepth(n int) {
+	if m != nil {
+		m.bulkQueueDepth.Add(float64(n))
+	}
+}
+
+// registerMetrics registers the metrics with the shared metrics registry.
+func (s *Elasticsearch) registerMetrics(reg *prometheus.Registry) error {
+	if reg == nil {
+		return nil
+	}
+
+	m := newElasticsearchMetrics()
+
+	if err := goboot.RegisterCollector(reg, &m.requestDuration); err != nil {
+		return fmt.Errorf("registering Elasticsearch metrics: %w", err)
+	}
+
+	if err := goboot.RegisterCollector(reg, &m.requests); err != nil {
+		return fmt.Errorf("registering Elasticsearch metrics: %w", err)
+	}
+
+	if err := goboot.RegisterCollector(reg, &m.bulkItems); err != nil {
+		return fmt.Errorf("registering Elasticsearch metrics: %w", err)
+	}
+
+	if err := goboot.RegisterCollector(reg, &m.bulkQueueDepth); err != nil {
+		return fmt.Errorf("registering Elasticsearch metrics: %w", err)
+	}
+
+	s.metrics = m
+
+	return nil
+}
+
+// metricsTransport records the duration and status of every request attempt,
+// retries are recorded separately.
+type metricsTransport struct {
+	next    http.RoundTripper
+	metrics *elasticsearchMetrics
+}
+
+func (t *metricsTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	op := operation(req)
+	start := time.Now()
+
+	res, err := t.next.RoundTrip(req)
+
+	t.metrics.requestDuration.WithLabelValues(req.Method, op).Observe(time.Since(start).Seconds())
+
+	status := "error"
+	if err == nil {
+		status = strconv.Itoa(res.StatusCode)
+	}
+
+	t.metrics.requests.WithLabelValues(op, status).Inc()
+
+	return res, err //nolint:wrapcheck
+}
+
+// operation returns the last API endpoint of the path like "_search" or
+// "_bulk", "index" for index level requests and "info" for the root path.
+// Index names and document ids are omitted to keep label cardinality low.
+func operation(req *http.Request) string {
+	path := strings.Trim(req.URL.Path, "/")
+	if path == "" {
+		return "info"
+	}
+
+	segments := strings.Split(path, "/")
+	for i := len(segments) - 1; i >= 0; i-- {
+		if strings.HasPrefix(segments[i], "_") {
+			return segments[i]
+		}
+	}
+
+	return "index"
+}
@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/elastic/go-elasticsearch/v7/esapi"
@@ -17,21 +18,48 @@ type Migration struct {
 }
 
 type MigrationRecord struct {
-	ID        string    `json:"id"`
+	ID string `json:"id"`
+
+	// Sequence is the position of the migration starting at 1, records
+	// created before sequence numbers were introduced have sequence 0.
+	Sequence  int       `json:"sequence"`
 	Timestamp time.Time `json:"timestamp"`
 	Duration  string    `json:"duration"`
 }
 
+// maxMigrations is the maximum number of migration records retrieved in a
+// single search request.
+const maxMigrations = 10000
+
+// migrationsMapping prevents dynamic mapping from guessing the wrong types.
+const migrationsMapping = `{
+	"mappings": {
+		"dynamic": "strict",
+		"properties": {
+			"id":        {"type": "keyword"},
+			"sequence":  {"type": "integer"},
+			"timestamp": {"type": "date"},
+			"duration":  {"type": "keyword"}
+		}
+	}
+}`
+
+// Migrate runs all pending migrations. When DryRun is set the pending
+// migrations are logged without running them.
 func (s *Elasticsearch) Migrate(ctx context.Context) error {
-	exists, err := s.IndexExists(ctx, s.MigrationsIndex)
+	exists, err := s.IndexExists(ctx, s.migrationsIndex())
 	if err != nil {
 		return err
 	}
 
+	if !exists && s.DryRun {
+		return s.runMigrations(ctx, s.Migrations, 0)
+	}
+
 	if !exists {
-		s.log.Info().Msgf("elasticsearch %q index not found; run all migrations", s.MigrationsIndex)
+		s.log.Info().Msgf("elasticsearch %q index not found; run all migrations", s.migrationsIndex())
 
-		if err := s.IndexCreate(ctx, s.MigrationsIndex); err != nil {
+		if err := s.createMigrationsIndex(ctx); err != nil {
 			return err
 		}
 	}
@@ -47,7 +75,13 @@ func (s *Elasticsearch) Migrate(ctx context.Context) error {
 		return nil
 	}
 
-	return s.runMigrations(ctx, newMigrations)
+	if len(newMigrations) > 0 && !s.DryRun {
+		if err := s.snapshotBeforeMigrate(ctx); err != nil {
+			return err
+		}
+	}
+
+	return s.runMigrations(ctx, newMigrations, len(s.Migrations)-len(newMigrations))
 }
 
 // getNewMigrations retrieves the migration history and returns all migrations
@@ -57,8 +91,8 @@ func (s *Elasticsearch) Migrate(ctx context.Context) error {
 // - One of the new migrations has not been added to the back.
 // - The migrations are ordered differently than the migration history.
 func (s *Elasticsearch) getNewMigrations(ctx context.Context) ([]*Migration, error) {
-	var records []MigrationRecord
-	if err := s.getMigrations(ctx, &records); err != nil {
+	records, err := s.getMigrations(ctx)
+	if err != nil {
 		return nil, err
 	}
 
@@ -88,8 +122,15 @@ func (s *Elasticsearch) getNewMigrations(ctx context.Context) ([]*Migration, err
 	return newMigrations, nil
 }
 
-func (s *Elasticsearch) runMigrations(ctx context.Context, migrations []*Migration) error {
-	for _, migration := range migrations {
+// runMigrations runs the migrations, applied is the number of migrations that ran before.
+func (s *Elasticsearch) runMigrations(ctx context.Context, migrations []*Migration, applied int) error {
+	for i, migration := range migrations {
+		if s.DryRun {
+			s.log.Info().Msgf("dry-run: would run Elasticsearch migration %q", migration.ID)
+
+			continue
+		}
+
 		start := time.Now()
 
 		if err := migration.Migrate(s); err != nil {
@@ -97,7 +138,7 @@ func (s *Elasticsearch) runMigrations(ctx context.Context, migrations []*Migrati
 		}
 
 		elapsed := time.Since(start)
-		if err := s.InsertMigrationRecord(ctx, migration.ID, elapsed); err != nil {
+		if err := s.insertMigrationRecord(ctx, applied+i+1, migration.ID, elapsed); err != nil {
 			return err
 		}
 	}
@@ -105,9 +146,41 @@ func (s *Elasticsearch) runMigrations(ctx context.Context, migrations []*Migrati
 	return nil
 }
 
+// InsertMigrationRecord records a migration as applied after the migrations
+// that have run so far.
 func (s *Elasticsearch) InsertMigrationRecord(ctx context.Context, id string, elapsed time.Duration) error {
+	exists, err := s.IndexExists(ctx, s.migrationsIndex())
+	if err != nil {
+		return err
+	}
+
+	if !exists {
+		if err := s.createMigrationsIndex(ctx); err != nil {
+			return err
+		}
+	}
+
+	records, err := s.getMigrations(ctx)
+	if err != nil {
+		return err
+	}
+
+	return s.insertMigrationRecord(ctx, len(records)+1, id, elapsed)
+}
+
+func (s *Elasticsearch) createMigrationsIndex(ctx context.Context) error {
+	req := esapi.IndicesCreateRequest{Index: s.migrationsIndex(), Body: strings.NewReader(migrationsMapping)}
+	if err := s.do(ctx, req); err != nil {
+		return fmt.Errorf("creating ES index %q: %w", s.migrationsIndex(), err)
+	}
+
+	return nil
+}
+
+func (s *Elasticsearch) insertMigrationRecord(ctx context.Context, sequence int, id string, elapsed time.Duration) error {
 	newRecord, err := json.Marshal(MigrationRecord{
 		ID:        id,
+		Sequence:  sequence,
 		Timestamp: time.Now().UTC(),
 		Duration:  elapsed.Truncate(time.Millisecond).String(),
 	})
@@ -116,13 +189,13 @@ func (s *Elasticsearch) InsertMigrationRecord(ctx context.Context, id string, el
 	}
 
 	req := &esapi.IndexRequest{
-		Index:      s.MigrationsIndex,
+		Index:      s.migrationsIndex(),
 		DocumentID: id,
 		Body:       bytes.NewReader(newRecord),
 		Refresh:    "true",
 	}
 
-	if _, err = req.Do(ctx, s.Client); err != nil {
+	if err := s.do(ctx, req); err != nil {
 		return fmt.Errorf("insert ES migration record: %w", err)
 	}
 
@@ -134,7 +207,7 @@ func (s *Elasticsearch) IndexExists(ctx context.Context, idx string) (bool, erro
 		Index: []string{idx},
 	}
 
-	res, err := req.Do(ctx, s.Client)
+	res, err := req.Do(ctx, s.Transport)
 	if err != nil {
 		return false, fmt.Errorf("check if ES index %q exists: %w", idx, err)
 	}
@@ -145,7 +218,7 @@ func (s *Elasticsearch) IndexExists(ctx context.Context, idx string) (bool, erro
 func (s *Elasticsearch) IndexCreate(ctx context.Context, idx string) error {
 	req := esapi.IndicesCreateRequest{Index: idx}
 
-	res, err := req.Do(ctx, s.Client)
+	res, err := req.Do(ctx, s.Transport)
 	if err != nil {
 		return fmt.Errorf("creating ES index %q: %w", idx, err)
 	}
@@ -169,7 +242,7 @@ func (s *Elasticsearch) IndexDelete(ctx context.Context, idx string) error {
 		IgnoreUnavailable: esapi.BoolPtr(true),
 	}
 
-	res, err := req.Do(ctx, s.Client)
+	res, err := req.Do(ctx, s.Transport)
 	if err != nil {
 		return fmt.Errorf("deleting ES index %q: %w", idx, err)
 	}
@@ -187,22 +260,51 @@ func (s *Elasticsearch) IndexDelete(ctx context.Context, idx string) error {
 	return nil
 }
 
-// getMigrations retrieves all migrations that have run.
-func (s *Elasticsearch) getMigrations(ctx context.Context, r any) error {
-	req := esapi.SearchRequest{
-		Index: []string{s.MigrationsIndex},
-	}
+// getMigrations retrieves all migrations that have run in order. Records
+// without sequence number are sorted by timestamp before all others.
+func (s *Elasticsearch) getMigrations(ctx context.Context) ([]MigrationRecord, error) {
+	b, _ := json.Marshal(map[string]any{
+		"size":             maxMigrations,
+		"track_total_hits": true,
+		"sort": []any{
+			map[string]any{"sequence": map[string]any{"order": "asc", "missing": "_first", "unmapped_type": "integer"}},
+			map[string]any{"timestamp": map[string]any{"order": "asc", "unmapped_type": "date"}},
+		},
+	})
 
-	res, err := req.Do(ctx, s.Client)
+	res, err := esapi.SearchRequest{
+		Index: []string{s.migrationsIndex()},
+		Body:  bytes.NewReader(b),
+	}.Do(ctx, s.Transport)
 	if err != nil {
-		return fmt.Errorf("search all ES documents in index %q: %w", s.MigrationsIndex, err)
+		return nil, fmt.Errorf("search all ES documents in index %q: %w", s.migrationsIndex(), err)
 	}
 
-	if res.StatusCode != http.StatusOK {
-		return fmt.Errorf("index %q does not exist", res.StatusCode)
+	if res.StatusCode == http.StatusNotFound {
+		_ = res.Body.Close()
+
+		return nil, fmt.Errorf("index %q does not exist", s.migrationsIndex()) //nolint:goerr113
 	}
 
-	err = s.ParseResponse(res, &r)
+	var result struct {
+		Hits struct {
+			Total Total                  `json:"total"`
+			Hits  []Hit[MigrationRecord] `json:"hits"`
+		} `json:"hits"`
+	}
+	if err := s.parseJSON(res, &result); err != nil {
+		return nil, err
+	}
+
+	if result.Hits.Total.Value != int64(len(result.Hits.Hits)) {
+		return nil, fmt.Errorf("retrieved %d of %d ES migration records", //nolint:goerr113
+			len(result.Hits.Hits), result.Hits.Total.Value)
+	}
+
+	records := make([]MigrationRecord, len(result.Hits.Hits))
+	for i, hit := range result.Hits.Hits {
+		records[i] = hit.Source
+	}
 
-	return err
+	return records, nil
 }
@@ -0,0 +1,130 @@
+package esboot
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/nielskrijger/goboot"
+)
+
+// MigrateDryRun is the migrate subcommand logging the migrations up would
+// run, in addition to the subcommands of goboot.ParseMigrateCommand.
+const MigrateDryRun = "dry-run"
+
+var errInvalidMigrateCommand = fmt.Errorf("%w, Elasticsearch supports up|status|dry-run", goboot.ErrInvalidMigrateCommand)
+
+// MigrationStatus describes a single migration.
+type MigrationStatus struct {
+	ID      string
+	Applied bool
+
+	// AppliedAt and Duration are only set when the migration has been applied.
+	AppliedAt *time.Time
+	Duration  string
+}
+
+// MigrationStatus returns the applied and pending migrations in order.
+func (s *Elasticsearch) MigrationStatus(ctx context.Context) ([]*MigrationStatus, error) {
+	records := map[string]MigrationRecord{}
+
+	exists, err := s.IndexExists(ctx, s.migrationsIndex())
+	if err != nil {
+		return nil, err
+	}
+
+	if exists {
+		list, err := s.getMigrations(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, record := range list {
+			records[record.ID] = record
+		}
+	}
+
+	result := make([]*MigrationStatus, 0, len(s.Migrations))
+
+	for _, migration := range s.Migrations {
+		status := &MigrationStatus{ID: migration.ID}
+
+		if record, ok := records[migration.ID]; ok {
+			timestamp := record.Timestamp
+			status.Applied = true
+			status.AppliedAt = &timestamp
+			status.Duration = record.Duration
+		}
+
+		result = append(result, status)
+	}
+
+	return result, nil
+}
+
+// PrintMigrationStatus writes a human-readable table of MigrationStatus to w.
+func (s *Elasticsearch) PrintMigrationStatus(ctx context.Context, w io.Writer) error {
+	status, err := s.MigrationStatus(ctx)
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0) //nolint:gomnd
+	_, _ = fmt.Fprintln(tw, "ID\tSTATUS\tAPPLIED AT\tDURATION")
+
+	for _, m := range status {
+		state, appliedAt := "pending", ""
+
+		if m.Applied {
+			state = "applied"
+			appliedAt = m.AppliedAt.Format(time.RFC3339)
+		}
+
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", m.ID, state, appliedAt, m.Duration)
+	}
+
+	if err := tw.Flush(); err != nil {
+		return fmt.Errorf("printing Elasticsearch migration status: %w", err)
+	}
+
+	return nil
+}
+
+// RunMigrateCommand runs a migrate subcommand, see goboot.ParseMigrateCommand.
+// Supported commands are:
+//
+//   - up: run all pending migrations
+//   - status: print the applied and pending migrations
+//   - dry-run: log the migrations up would run without running them
+//
+// Other commands return an error matching goboot.ErrInvalidMigrateCommand.
+//
+// The service must be configured before running a command. Don't call Init
+// as it runs all pending migrations.
+func (s *Elasticsearch) RunMigrateCommand(args []string) error {
+	if len(args) > 0 && args[0] == MigrateDryRun {
+		dryRun := s.DryRun
+		s.DryRun = true
+
+		defer func() { s.DryRun = dryRun }()
+
+		return s.Migrate(context.Background())
+	}
+
+	cmd, err := goboot.ParseMigrateCommand(args)
+	if err != nil {
+		return errInvalidMigrateCommand
+	}
+
+	switch cmd.Name {
+	case goboot.MigrateUp:
+		return s.Migrate(context.Background())
+	case goboot.MigrateStatus:
+		return s.PrintMigrationStatus(context.Background(), os.Stdout)
+	default:
+		return errInvalidMigrateCommand
+	}
+}
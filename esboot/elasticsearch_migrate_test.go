@@ -4,11 +4,13 @@ package esboot_test
 import (
 	"context"
 	"io"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/elastic/go-elasticsearch/v7/esapi"
+	"github.com/nielskrijger/goboot"
 	"github.com/nielskrijger/goboot/esboot"
 	"github.com/stretchr/testify/assert"
 	"github.com/tidwall/gjson"
@@ -130,3 +132,87 @@ func TestElasticsearchMigrate_ErrorMigrationMissing(t *testing.T) {
 		`running Elasticsearch migrations: missing migration "1"; you're not allowed to delete migrations that have already run`, //nolint:lll
 	)
 }
+
+func TestElasticsearchMigrate_StatusAndDryRun(t *testing.T) {
+	s := &esboot.Elasticsearch{
+		Migrations: []*esboot.Migration{
+			{ID: "1", Migrate: func(es *esboot.Elasticsearch) error { return nil }},
+		},
+	}
+	setupElasticsearchEnv(t, s)
+	assert.Nil(t, s.Init())
+
+	ran := false
+	s.Migrations = append(s.Migrations, &esboot.Migration{
+		ID: "2",
+		Migrate: func(es *esboot.Elasticsearch) error {
+			ran = true
+
+			return nil
+		},
+	})
+
+	assert.Nil(t, s.RunMigrateCommand([]string{"dry-run"}))
+	assert.False(t, ran)
+
+	status, err := s.MigrationStatus(context.Background())
+	assert.Nil(t, err)
+	assert.Len(t, status, 2)
+	assert.True(t, status[0].Applied)
+	assert.NotNil(t, status[0].AppliedAt)
+	assert.False(t, status[1].Applied)
+
+	var buf strings.Builder
+	assert.Nil(t, s.PrintMigrationStatus(context.Background(), &buf))
+	assert.Contains(t, buf.String(), "pending")
+}
+
+func TestElasticsearchMigrate_InvalidCommand(t *testing.T) {
+	s := &esboot.Elasticsearch{}
+
+	for _, args := range [][]string{{"down"}, {"sideways"}, nil} {
+		err := s.RunMigrateCommand(args)
+		assert.ErrorIs(t, err, goboot.ErrInvalidMigrateCommand)
+		assert.EqualError(t, err, "usage: migrate up|down [steps]|to <version>|status, "+
+			"Elasticsearch supports up|status|dry-run")
+	}
+}
+
+func TestElasticsearchMigrate_HistoryOrderedBySequence(t *testing.T) {
+	s := &esboot.Elasticsearch{}
+	setupElasticsearchEnv(t, s)
+
+	// more than the default search size of 10 records
+	for i := 0; i < 15; i++ {
+		id := strconv.Itoa(15 - i)
+		s.Migrations = append(s.Migrations, &esboot.Migration{
+			ID:      id,
+			Migrate: func(es *esboot.Elasticsearch) error { return nil },
+		})
+	}
+
+	assert.Nil(t, s.Init())
+	assert.Nil(t, s.Init())
+
+	status, err := s.MigrationStatus(context.Background())
+	assert.Nil(t, err)
+	assert.Len(t, status, 15)
+
+	for _, m := range status {
+		assert.True(t, m.Applied)
+	}
+}
+
+func TestElasticsearchMigrate_StrictMapping(t *testing.T) {
+	s := &esboot.Elasticsearch{}
+	setupElasticsearchEnv(t, s)
+	assert.Nil(t, s.Init())
+
+	res, err := esapi.IndexRequest{
+		Index: s.IndexName(s.MigrationsIndex),
+		Body:  strings.NewReader(`{"unknown": "field"}`),
+	}.Do(context.Background(), s.Transport)
+	assert.Nil(t, err)
+	_, err = s.ParseResponseBytes(res)
+	assert.ErrorContains(t, err, "strict_dynamic_mapping_exception")
+}
@@ -0,0 +1,399 @@
+package esboot
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/elastic/go-elasticsearch/v7/esapi"
+)
+
+// Index declares an index, the name is prefixed with IndexPrefix. Body
+// contains the settings, mappings and aliases like the create index API, e.g.
+// {"settings": {...}, "mappings": {...}}.
+//
+// Body is either a Go value marshalled to JSON, or raw JSON using json.RawMessage.
+type Index struct {
+	Name string
+	Body any
+}
+
+// IndexTemplate declares a composable index template, Body is the body of the
+// put index template API, e.g. {"index_patterns": ["logs-*"], "template": {...}}.
+//
+// ILMPolicy optionally attaches a lifecycle policy to the indices created by
+// the template by setting "index.lifecycle.name". DataStream creates data
+// streams instead of indices for matching names, see CreateDataStream.
+type IndexTemplate struct {
+	Name       string
+	Body       any
+	ILMPolicy  string
+	DataStream bool
+}
+
+// ILMPolicy declares an index lifecycle policy, Body is the body of the put
+// lifecycle API, e.g. {"policy": {"phases": {...}}}. ILM is not available in OpenSearch.
+type ILMPolicy struct {
+	Name string
+	Body any
+}
+
+// Provision creates or updates the declared ILM policies, index templates and
+// indices in that order, so templates can refer to policies and indices are
+// created using the templates.
+//
+// Resources are compared to the declared state and only updated when they
+// differ. Existing indices are updated in place, changes that require a
+// reindex return an error, see EnsureIndex.
+func (s *Elasticsearch) Provision(ctx context.Context) error {
+	d := &declarations{
+		policies:  append(append([]*ILMPolicy{}, s.ILMPolicies...), s.configPolicies...),
+		templates: append([]*IndexTemplate{}, s.IndexTemplates...),
+		indices:   append([]*Index{}, s.Indices...),
+	}
+
+	if err := s.loadProvisionDir(d); err != nil {
+		return err
+	}
+
+	if len(d.policies) > 0 && s.backend == BackendOpenSearch {
+		s.log.Warn().Msg("OpenSearch doesn't support ILM policies, skipping ILM provisioning")
+
+		d.policies = nil
+	}
+
+	for _, p := range d.policies {
+		if err := s.provisionILMPolicy(ctx, p); err != nil {
+			return err
+		}
+	}
+
+	for _, t := range d.templates {
+		if err := s.provisionIndexTemplate(ctx, t); err != nil {
+			return err
+		}
+	}
+
+	for _, idx := range d.indices {
+		if err := s.provisionIndex(ctx, idx); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+type declarations struct {
+	policies  []*ILMPolicy
+	templates []*IndexTemplate
+	indices   []*Index
+}
+
+// loadProvisionDir reads the declarations in ProvisionDir, where the file name
+// without extension is the resource name:
+//
+//	{ProvisionDir}/ilm_policies/*.json
+//	{ProvisionDir}/index_templates/*.json
+//	{ProvisionDir}/indices/*.json
+func (s *Elasticsearch) loadProvisionDir(d *declarations) error {
+	if s.ProvisionDir == "" {
+		return nil
+	}
+
+	return s.readDeclarations(map[string]func(name string, body json.RawMessage){
+		"ilm_policies": func(name string, body json.RawMessage) {
+			d.policies = append(d.policies, &ILMPolicy{Name: name, Body: body})
+		},
+		"index_templates": func(name string, body json.RawMessage) {
+			d.templates = append(d.templates, &IndexTemplate{Name: name, Body: body})
+		},
+		"indices": func(name string, body json.RawMessage) {
+			d.indices = append(d.indices, &Index{Name: name, Body: body})
+		},
+	})
+}
+
+func (s *Elasticsearch) readDeclarations(kinds map[string]func(name string, body json.RawMessage)) error {
+	for _, dir := range []string{"ilm_policies", "index_templates", "indices"} {
+		add := kinds[dir]
+
+		files, err := filepath.Glob(filepath.Join(s.ProvisionDir, dir, "*.json"))
+		if err != nil {
+			return fmt.Errorf("reading %s declarations: %w", dir, err)
+		}
+
+		for _, file := range files {
+			b, err := os.ReadFile(file)
+			if err != nil {
+				return fmt.Errorf("reading %q: %w", file, err)
+			}
+
+			if !json.Valid(b) {
+				return fmt.Errorf("invalid JSON in %q", file) //nolint:goerr113
+			}
+
+			add(strings.TrimSuffix(filepath.Base(file), ".json"), b)
+		}
+	}
+
+	return nil
+}
+
+func (s *Elasticsearch) provisionILMPolicy(ctx context.Context, p *ILMPolicy) error {
+	desired, body, err := toJSON(p.Body)
+	if err != nil {
+		return fmt.Errorf("ILM policy %q: %w", p.Name, err)
+	}
+
+	current, err := s.getJSON(ctx, esapi.ILMGetLifecycleRequest{Policy: p.Name})
+	if err != nil {
+		return fmt.Errorf("reading ILM policy %q: %w", p.Name, err)
+	}
+
+	if current != nil && containsJSON(current[p.Name], desired) {
+		return nil
+	}
+
+	if err := s.do(ctx, esapi.ILMPutLifecycleRequest{Policy: p.Name, Body: bytes.NewReader(body)}); err != nil {
+		return fmt.Errorf("updating ILM policy %q: %w", p.Name, err)
+	}
+
+	s.log.Info().Msgf("provisioned Elasticsearch ILM policy %q", p.Name)
+
+	return nil
+}
+
+func (s *Elasticsearch) provisionIndexTemplate(ctx context.Context, t *IndexTemplate) error {
+	desired, _, err := toJSON(t.Body)
+	if err != nil {
+		return fmt.Errorf("index template %q: %w", t.Name, err)
+	}
+
+	template, _ := desired.(map[string]any)["template"].(map[string]any)
+	if template == nil {
+		template = map[string]any{}
+		desired.(map[string]any)["template"] = template
+	}
+
+	settings, _ := template["settings"].(map[string]any)
+	if settings != nil || (t.ILMPolicy != "" && s.backend != BackendOpenSearch) {
+		settings = normalizeSettings(settings)
+		template["settings"] = settings
+	}
+
+	if t.ILMPolicy != "" && s.backend != BackendOpenSearch {
+		index := settings["index"].(map[string]any)
+		index["lifecycle"] = map[string]any{"name": t.ILMPolicy}
+	}
+
+	if t.DataStream {
+		desired.(map[string]any)["data_stream"] = map[string]any{}
+	}
+
+	body, _ := json.Marshal(desired)
+
+	current, err := s.getJSON(ctx, esapi.IndicesGetIndexTemplateRequest{Name: t.Name})
+	if err != nil {
+		return fmt.Errorf("reading index template %q: %w", t.Name, err)
+	}
+
+	if templates, ok := current["index_templates"].([]any); ok && len(templates) == 1 {
+		if existing, ok := templates[0].(map[string]any); ok && containsJSON(existing["index_template"], desired) {
+			return nil
+		}
+	}
+
+	if err := s.do(ctx, esapi.IndicesPutIndexTemplateRequest{Name: t.Name, Body: bytes.NewReader(body)}); err != nil {
+		return fmt.Errorf("updating index template %q: %w", t.Name, err)
+	}
+
+	s.log.Info().Msgf("provisioned Elasticsearch index template %q", t.Name)
+
+	return nil
+}
+
+func (s *Elasticsearch) provisionIndex(ctx context.Context, idx *Index) error {
+	desired, body, err := toJSON(idx.Body)
+	if err != nil {
+		return fmt.Errorf("index %q: %w", idx.Name, err)
+	}
+
+	idx = &Index{Name: s.IndexName(idx.Name), Body: idx.Body}
+
+	exists, err := s.IndexExists(ctx, idx.Name)
+	if err != nil {
+		return err
+	}
+
+	if !exists {
+		if err := s.do(ctx, esapi.IndicesCreateRequest{Index: idx.Name, Body: bytes.NewReader(body)}); err != nil {
+			return fmt.Errorf("creating index %q: %w", idx.Name, err)
+		}
+
+		s.log.Info().Msgf("provisioned Elasticsearch index %q", idx.Name)
+
+		return nil
+	}
+
+	settings, _ := desired.(map[string]any)["settings"].(map[string]any)
+	mappings, _ := desired.(map[string]any)["mappings"].(map[string]any)
+
+	return s.updateIndex(ctx, idx.Name, settings, mappings)
+}
+
+// do performs the request and returns an error for any error response.
+func (s *Elasticsearch) do(ctx context.Context, req esapi.Request) error {
+	res, err := req.Do(ctx, s.Transport)
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+
+	_, err = s.ParseResponseBytes(res)
+
+	return err
+}
+
+// getJSON performs the request and decodes the response, returns nil if the
+// resource was not found.
+func (s *Elasticsearch) getJSON(ctx context.Context, req esapi.Request) (map[string]any, error) {
+	res, err := req.Do(ctx, s.Transport)
+	if err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+
+	if res.StatusCode == http.StatusNotFound {
+		_ = res.Body.Close()
+
+		return nil, nil //nolint:nilnil
+	}
+
+	b, err := s.ParseResponseBytes(res)
+	if err != nil {
+		return nil, err
+	}
+
+	var result map[string]any
+	if err := json.Unmarshal(b, &result); err != nil {
+		return nil, fmt.Errorf("parsing Elasticsearch response body: %w", err)
+	}
+
+	return result, nil
+}
+
+// toJSON returns the body as generic JSON value for comparison and as bytes.
+func toJSON(body any) (any, []byte, error) {
+	b, ok := body.(json.RawMessage)
+	if !ok {
+		var err error
+		if b, err = json.Marshal(body); err != nil {
+			return nil, nil, fmt.Errorf("marshalling body: %w", err)
+		}
+	}
+
+	var v any
+	if err := json.Unmarshal(b, &v); err != nil {
+		return nil, nil, fmt.Errorf("parsing body: %w", err)
+	}
+
+	if _, ok := v.(map[string]any); !ok {
+		return nil, nil, fmt.Errorf("body must be a JSON object") //nolint:goerr113
+	}
+
+	return v, b, nil
+}
+
+// containsJSON returns true if all values of desired are present in actual.
+// Elasticsearch adds defaults and returns numbers and booleans in settings as
+// strings, so scalars are compared by their string representation.
+func containsJSON(actual any, desired any) bool {
+	switch d := desired.(type) {
+	case map[string]any:
+		a, ok := actual.(map[string]any)
+		if !ok {
+			return false
+		}
+
+		for k, v := range d {
+			if !containsJSON(a[k], v) {
+				return false
+			}
+		}
+
+		return true
+	case []any:
+		a, ok := actual.([]any)
+		if !ok || len(a) != len(d) {
+			return false
+		}
+
+		for i := range d {
+			if !containsJSON(a[i], d[i]) {
+				return false
+			}
+		}
+
+		return true
+	default:
+		if actual == nil || desired == nil {
+			return actual == desired
+		}
+
+		return reflect.DeepEqual(actual, desired) || fmt.Sprint(actual) == fmt.Sprint(desired)
+	}
+}
+
+// normalizeSettings returns index settings the way Elasticsearch returns
+// them: nested objects under "index", e.g. {"number_of_shards": 1} and
+// {"index.number_of_shards": 1} become {"index": {"number_of_shards": 1}}.
+func normalizeSettings(settings map[string]any) map[string]any {
+	index := map[string]any{}
+
+	for key, value := range settings {
+		key = strings.TrimPrefix(key, "index.")
+		if key == "index" {
+			if m, ok := value.(map[string]any); ok {
+				for k, v := range normalizeSettings(m)["index"].(map[string]any) {
+					index[k] = v
+				}
+
+				continue
+			}
+		}
+
+		setPath(index, strings.Split(key, "."), value)
+	}
+
+	return map[string]any{"index": index}
+}
+
+// setPath sets the value in nested maps, merging with existing maps.
+func setPath(m map[string]any, path []string, value any) {
+	for _, key := range path[:len(path)-1] {
+		next, ok := m[key].(map[string]any)
+		if !ok {
+			next = map[string]any{}
+			m[key] = next
+		}
+
+		m = next
+	}
+
+	last := path[len(path)-1]
+	if existing, ok := m[last].(map[string]any); ok {
+		if v, ok := value.(map[string]any); ok {
+			for k, vv := range v {
+				setPath(existing, strings.Split(k, "."), vv)
+			}
+
+			return
+		}
+	}
+
+	m[last] = value
+}
@@ -0,0 +1,119 @@
+package esboot_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/elastic/go-elasticsearch/v7/esapi"
+	"github.com/nielskrijger/goboot"
+	"github.com/nielskrijger/goboot/esboot"
+	"github.com/stretchr/testify/assert"
+	"github.com/tidwall/gjson"
+)
+
+func TestElasticsearch_ProvisionDir(t *testing.T) {
+	s := &esboot.Elasticsearch{ProvisionDir: "./testdata/provision"}
+	setupElasticsearchEnv(t, s)
+	_ = s.IndexDelete(context.Background(), "provision-test")
+
+	assert.Nil(t, s.Init())
+
+	exists, err := s.IndexExists(context.Background(), "provision-test")
+	assert.Nil(t, err)
+	assert.True(t, exists)
+
+	// provisioning again is a no-op
+	assert.Nil(t, s.Provision(context.Background()))
+}
+
+func TestElasticsearch_ProvisionAddsMappingFields(t *testing.T) {
+	s := &esboot.Elasticsearch{}
+	setupElasticsearchEnv(t, s)
+	_ = s.IndexDelete(context.Background(), "provision-test")
+
+	s.Indices = []*esboot.Index{{
+		Name: "provision-test",
+		Body: json.RawMessage(`{"mappings": {"properties": {"name": {"type": "keyword"}}}}`),
+	}}
+	assert.Nil(t, s.Provision(context.Background()))
+
+	s.Indices[0].Body = map[string]any{
+		"mappings": map[string]any{
+			"properties": map[string]any{
+				"name": map[string]any{"type": "keyword"},
+				"age":  map[string]any{"type": "integer"},
+			},
+		},
+	}
+	assert.Nil(t, s.Provision(context.Background()))
+}
+
+func TestElasticsearch_ProvisionIncompatibleMapping(t *testing.T) {
+	s := &esboot.Elasticsearch{}
+	setupElasticsearchEnv(t, s)
+	_ = s.IndexDelete(context.Background(), "provision-test")
+
+	s.Indices = []*esboot.Index{{
+		Name: "provision-test",
+		Body: json.RawMessage(`{"mappings": {"properties": {"name": {"type": "keyword"}}}}`),
+	}}
+	assert.Nil(t, s.Provision(context.Background()))
+
+	s.Indices[0].Body = json.RawMessage(`{"mappings": {"properties": {"name": {"type": "integer"}}}}`)
+	err := s.Provision(context.Background())
+	assert.ErrorIs(t, err, esboot.ErrReindexRequired)
+}
+
+func TestElasticsearch_ProvisionInvalidBody(t *testing.T) {
+	s := &esboot.Elasticsearch{}
+	s.Indices = []*esboot.Index{{Name: "provision-test", Body: json.RawMessage(`[]`)}}
+	err := s.Provision(context.Background())
+	assert.EqualError(t, err, "index \"provision-test\": body must be a JSON object")
+}
+
+func TestElasticsearch_ProvisionILMPolicy(t *testing.T) {
+	s := &esboot.Elasticsearch{
+		IndexTemplates: []*esboot.IndexTemplate{{
+			Name:      "provision-logs",
+			ILMPolicy: "provision-logs",
+			Body: map[string]any{
+				"index_patterns": []string{"provision-logs-*"},
+				"template": map[string]any{
+					"settings": map[string]any{"number_of_shards": 1},
+				},
+			},
+		}},
+	}
+	assert.Nil(t, s.Configure(goboot.NewAppEnv("./testdata", "ilm")))
+	assert.Nil(t, s.Provision(context.Background()))
+
+	res, err := esapi.ILMGetLifecycleRequest{Policy: "provision-logs"}.Do(context.Background(), s.Transport)
+	assert.Nil(t, err)
+	b, err := s.ParseResponseBytes(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "90d", gjson.GetBytes(b, "provision-logs.policy.phases.delete.min_age").String())
+
+	res, err = esapi.IndicesGetIndexTemplateRequest{Name: "provision-logs"}.Do(context.Background(), s.Transport)
+	assert.Nil(t, err)
+	b, err = s.ParseResponseBytes(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "provision-logs",
+		gjson.GetBytes(b, "index_templates.0.index_template.template.settings.index.lifecycle.name").String())
+}
+
+func TestILMPhases_Policy(t *testing.T) {
+	phases := esboot.ILMPhases{
+		Hot:    &esboot.ILMHotPhase{MaxAge: "1d"},
+		Warm:   &esboot.ILMWarmPhase{MinAge: "7d", ForceMergeSegments: 1},
+		Delete: &esboot.ILMDeletePhase{MinAge: "30d"},
+	}
+
+	b, err := json.Marshal(phases.Policy())
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{"policy": {"phases": {
+		"hot": {"min_age": "0ms", "actions": {"rollover": {"max_age": "1d"}}},
+		"warm": {"min_age": "7d", "actions": {"forcemerge": {"max_num_segments": 1}}},
+		"delete": {"min_age": "30d", "actions": {"delete": {}}}
+	}}}`, string(b))
+}
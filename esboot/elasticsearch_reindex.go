@@ -0,0 +1,197 @@
+package esboot
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/elastic/go-elasticsearch/v7/esapi"
+)
+
+const reindexPollInterval = time.Second
+
+var versionSuffix = regexp.MustCompile(`_v(\d+)$`)
+
+var errMissingReindexTask = errors.New("reindex response is missing the task ID")
+
+// ReindexMigration returns a migration that runs ReindexWithAlias.
+func ReindexMigration(id string, alias string, newSettings any, transform string) *Migration {
+	return &Migration{
+		ID: id,
+		Migrate: func(es *Elasticsearch) error {
+			return es.ReindexWithAlias(context.Background(), alias, newSettings, transform)
+		},
+	}
+}
+
+// ReindexWithAlias moves the documents of the index behind alias to a new
+// index created with newSettings (a create index body containing settings and
+// mappings), swaps the alias atomically and deletes the old index.
+//
+// New indices are named {alias}_v{N}. When alias is a concrete index rather
+// than an alias, the index is replaced by alias {alias} pointing to {alias}_v1.
+//
+// The optional transform is a painless script applied to every document, e.g.
+// "ctx._source.count = Integer.parseInt(ctx._source.count)".
+//
+// Documents written to the old index while reindexing are lost; pause writers
+// or replay them after the swap.
+func (s *Elasticsearch) ReindexWithAlias(ctx context.Context, alias string, newSettings any, transform string) error {
+	oldIndex, isAlias, err := s.resolveAlias(ctx, alias)
+	if err != nil {
+		return err
+	}
+
+	newIndex := nextIndexVersion(alias, oldIndex)
+
+	_, body, err := toJSON(newSettings)
+	if err != nil {
+		return fmt.Errorf("index %q: %w", newIndex, err)
+	}
+
+	if err := s.do(ctx, esapi.IndicesCreateRequest{Index: newIndex, Body: bytes.NewReader(body)}); err != nil {
+		return fmt.Errorf("creating ES index %q: %w", newIndex, err)
+	}
+
+	if err := s.reindex(ctx, oldIndex, newIndex, transform); err != nil {
+		return err
+	}
+
+	if err := s.swapAlias(ctx, alias, oldIndex, newIndex, isAlias); err != nil {
+		return err
+	}
+
+	if isAlias {
+		if err := s.IndexDelete(ctx, oldIndex); err != nil {
+			return err
+		}
+	}
+
+	s.log.Info().Msgf("reindexed ES alias %q from %q to %q", alias, oldIndex, newIndex)
+
+	return nil
+}
+
+// resolveAlias returns the index alias points to, or alias itself when it is
+// a concrete index.
+func (s *Elasticsearch) resolveAlias(ctx context.Context, alias string) (string, bool, error) {
+	current, err := s.getJSON(ctx, esapi.IndicesGetAliasRequest{Name: []string{alias}})
+	if err != nil {
+		return "", false, fmt.Errorf("reading ES alias %q: %w", alias, err)
+	}
+
+	switch len(current) {
+	case 0:
+		exists, err := s.IndexExists(ctx, alias)
+		if err != nil {
+			return "", false, err
+		}
+
+		if !exists {
+			return "", false, fmt.Errorf("ES index or alias %q not found", alias) //nolint:goerr113
+		}
+
+		return alias, false, nil
+	case 1:
+		for idx := range current {
+			return idx, true, nil
+		}
+	}
+
+	return "", false, fmt.Errorf("ES alias %q points to %d indices, expected 1", alias, len(current)) //nolint:goerr113
+}
+
+func nextIndexVersion(alias string, current string) string {
+	version := 1
+
+	if m := versionSuffix.FindStringSubmatch(current); m != nil {
+		n, _ := strconv.Atoi(m[1])
+		version = n + 1
+	}
+
+	return fmt.Sprintf("%s_v%d", alias, version)
+}
+
+// reindex starts a reindex task and waits for it to complete.
+func (s *Elasticsearch) reindex(ctx context.Context, source string, dest string, transform string) error {
+	body := map[string]any{
+		"source": map[string]any{"index": source},
+		"dest":   map[string]any{"index": dest},
+	}
+
+	if transform != "" {
+		body["script"] = map[string]any{"lang": "painless", "source": transform}
+	}
+
+	b, _ := json.Marshal(body)
+
+	res, err := s.getJSON(ctx, esapi.ReindexRequest{
+		Body:              bytes.NewReader(b),
+		Refresh:           esapi.BoolPtr(true),
+		WaitForCompletion: esapi.BoolPtr(false),
+	})
+	if err != nil {
+		return fmt.Errorf("reindexing %q to %q: %w", source, dest, err)
+	}
+
+	taskID, _ := res["task"].(string)
+	if taskID == "" {
+		return fmt.Errorf("reindexing %q to %q: %w", source, dest, errMissingReindexTask)
+	}
+
+	for {
+		task, err := s.getJSON(ctx, esapi.TasksGetRequest{TaskID: taskID})
+		if err != nil {
+			return fmt.Errorf("waiting for reindex task %q: %w", taskID, err)
+		}
+
+		if completed, _ := task["completed"].(bool); !completed {
+			select {
+			case <-ctx.Done():
+				return fmt.Errorf("waiting for reindex task %q: %w", taskID, ctx.Err())
+			case <-time.After(reindexPollInterval):
+				continue
+			}
+		}
+
+		if task["error"] != nil {
+			return fmt.Errorf("reindexing %q to %q: %v", source, dest, task["error"]) //nolint:goerr113
+		}
+
+		if response, ok := task["response"].(map[string]any); ok {
+			if failures, _ := response["failures"].([]any); len(failures) > 0 {
+				return fmt.Errorf("reindexing %q to %q: %d failures, first: %v", //nolint:goerr113
+					source, dest, len(failures), failures[0])
+			}
+		}
+
+		return nil
+	}
+}
+
+// swapAlias points alias to newIndex in a single atomic request. When the old
+// index is a concrete index named alias, it is removed in the same request.
+func (s *Elasticsearch) swapAlias(ctx context.Context, alias string, oldIndex string, newIndex string, isAlias bool) error {
+	remove := map[string]any{"remove_index": map[string]any{"index": oldIndex}}
+	if isAlias {
+		remove = map[string]any{"remove": map[string]any{"index": oldIndex, "alias": alias}}
+	}
+
+	b, _ := json.Marshal(map[string]any{
+		"actions": []any{
+			map[string]any{"add": map[string]any{"index": newIndex, "alias": alias}},
+			remove,
+		},
+	})
+
+	if err := s.do(ctx, esapi.IndicesUpdateAliasesRequest{Body: bytes.NewReader(b)}); err != nil {
+		return fmt.Errorf("swapping ES alias %q to %q: %w", alias, newIndex, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,110 @@
+package esboot_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/elastic/go-elasticsearch/v7/esapi"
+	"github.com/nielskrijger/goboot"
+	"github.com/nielskrijger/goboot/esboot"
+	"github.com/stretchr/testify/assert"
+	"github.com/tidwall/gjson"
+)
+
+func TestElasticsearch_ReindexWithAlias(t *testing.T) {
+	s := &esboot.Elasticsearch{
+		Migrations: []*esboot.Migration{
+			{
+				ID: "1",
+				Migrate: func(es *esboot.Elasticsearch) error {
+					req := &esapi.IndexRequest{
+						Index:      "reindex-test",
+						DocumentID: "1",
+						Body:       strings.NewReader(`{"count": "5"}`),
+						Refresh:    "true",
+					}
+					_, err := req.Do(context.Background(), es.Transport)
+
+					return err //nolint:wrapcheck
+				},
+			},
+			esboot.ReindexMigration("2", "reindex-test", map[string]any{
+				"mappings": map[string]any{
+					"properties": map[string]any{"count": map[string]any{"type": "integer"}},
+				},
+			}, "ctx._source.count = Integer.parseInt(ctx._source.count)"),
+			esboot.ReindexMigration("3", "reindex-test", map[string]any{}, ""),
+		},
+	}
+	setupElasticsearchEnv(t, s)
+
+	for _, idx := range []string{"reindex-test", "reindex-test_v1", "reindex-test_v2"} {
+		_ = s.IndexDelete(context.Background(), idx)
+	}
+
+	assert.Nil(t, s.Init())
+
+	res, err := esapi.IndicesGetAliasRequest{Name: []string{"reindex-test"}}.Do(context.Background(), s.Transport)
+	assert.Nil(t, err)
+	b, err := s.ParseResponseBytes(res)
+	assert.Nil(t, err)
+	assert.True(t, gjson.GetBytes(b, "reindex-test_v2").Exists())
+	assert.False(t, gjson.GetBytes(b, "reindex-test_v1").Exists())
+
+	res, err = esapi.GetRequest{Index: "reindex-test", DocumentID: "1"}.Do(context.Background(), s.Transport)
+	assert.Nil(t, err)
+	b, err = s.ParseResponseBytes(res)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(5), gjson.GetBytes(b, "_source.count").Int())
+	assert.Equal(t, "Number", gjson.GetBytes(b, "_source.count").Type.String())
+}
+
+func TestElasticsearch_ReindexWithAliasNotFound(t *testing.T) {
+	s := &esboot.Elasticsearch{}
+	setupElasticsearchEnv(t, s)
+
+	err := s.ReindexWithAlias(context.Background(), "reindex-unknown", map[string]any{}, "")
+	assert.EqualError(t, err, "ES index or alias \"reindex-unknown\" not found")
+}
+
+func TestElasticsearch_ReindexWithAliasErrorMissingTask(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		paths []string
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		paths = append(paths, r.URL.Path)
+		mu.Unlock()
+
+		if r.URL.Path == "/_alias/test" {
+			_, _ = w.Write([]byte(`{"test_v1": {"aliases": {"test": {}}}}`))
+
+			return
+		}
+
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	env := goboot.NewAppEnv("./testdata", "opensearch")
+	env.Config.Set("elasticsearch.addresses", []string{server.URL})
+
+	s := &esboot.Elasticsearch{}
+	assert.Nil(t, s.Configure(env))
+
+	err := s.ReindexWithAlias(context.Background(), "test", map[string]any{}, "")
+	assert.EqualError(t, err, `reindexing "test_v1" to "test_v2": reindex response is missing the task ID`)
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	for _, path := range paths {
+		assert.NotContains(t, path, "/_tasks")
+	}
+}
@@ -0,0 +1,244 @@
+package esboot
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/elastic/go-elasticsearch/v7/esapi"
+)
+
+const (
+	searchAllPageSize  = 1000
+	searchAllKeepAlive = time.Minute
+)
+
+// esDuration formats d as an Elasticsearch time unit, which doesn't accept
+// the format of time.Duration.String like "1m0s".
+func esDuration(d time.Duration) string {
+	return strconv.FormatInt(d.Milliseconds(), 10) + "ms"
+}
+
+type searchPage struct {
+	ScrollID string `json:"_scroll_id"`
+	PitID    string `json:"pit_id"`
+	Hits     struct {
+		Hits []json.RawMessage `json:"hits"`
+	} `json:"hits"`
+}
+
+// SearchAll calls fn for every hit matching query, e.g. {"match_all": {}}, in
+// no particular order. Unlike a regular search it's not limited to 10,000
+// hits.
+//
+// Elasticsearch pages through a point in time using search_after sorted by
+// _shard_doc, which requires Elasticsearch 7.12 or later. OpenSearch uses the
+// scroll API. Both see a consistent snapshot of the index.
+//
+// Stops and returns the error when fn returns an error.
+func (s *Elasticsearch) SearchAll(ctx context.Context, index string, query any, fn func(hit json.RawMessage) error) error {
+	if query == nil {
+		query = map[string]any{"match_all": map[string]any{}}
+	}
+
+	if s.backend == BackendOpenSearch {
+		return s.scrollAll(ctx, index, query, fn)
+	}
+
+	return s.searchAfterAll(ctx, index, query, fn)
+}
+
+func (s *Elasticsearch) searchAfterAll(ctx context.Context, index string, query any, fn func(hit json.RawMessage) error) error {
+	var pit struct {
+		ID string `json:"id"`
+	}
+
+	res, err := esapi.OpenPointInTimeRequest{
+		Index:     []string{index},
+		KeepAlive: esDuration(searchAllKeepAlive),
+	}.Do(ctx, s.Transport)
+	if err != nil {
+		return fmt.Errorf("opening point in time on ES index %q: %w", index, err)
+	}
+
+	if err := s.parseJSON(res, &pit); err != nil {
+		return err
+	}
+
+	defer func() {
+		b, _ := json.Marshal(map[string]string{"id": pit.ID})
+		if err := s.do(context.Background(), esapi.ClosePointInTimeRequest{Body: bytes.NewReader(b)}); err != nil {
+			s.log.Warn().Err(err).Msg("failed to close ES point in time")
+		}
+	}()
+
+	var searchAfter []any
+
+	for {
+		body := map[string]any{
+			"size":  searchAllPageSize,
+			"query": query,
+			"pit":   map[string]any{"id": pit.ID, "keep_alive": esDuration(searchAllKeepAlive)},
+			"sort":  []any{"_shard_doc"},
+		}
+
+		if searchAfter != nil {
+			body["search_after"] = searchAfter
+		}
+
+		b, _ := json.Marshal(body)
+
+		res, err := esapi.SearchRequest{Body: bytes.NewReader(b)}.Do(ctx, s.Transport)
+		if err != nil {
+			return fmt.Errorf("searching ES index %q: %w", index, err)
+		}
+
+		var page searchPage
+		if err := s.parseJSON(res, &page); err != nil {
+			return err
+		}
+
+		if len(page.Hits.Hits) == 0 {
+			return nil
+		}
+
+		// the point in time id may change between requests
+		pit.ID = page.PitID
+
+		for _, hit := range page.Hits.Hits {
+			if err := fn(hit); err != nil {
+				return err
+			}
+		}
+
+		var last struct {
+			Sort []any `json:"sort"`
+		}
+		if err := json.Unmarshal(page.Hits.Hits[len(page.Hits.Hits)-1], &last); err != nil {
+			return fmt.Errorf("parsing ES search hit: %w", err)
+		}
+
+		searchAfter = last.Sort
+	}
+}
+
+func (s *Elasticsearch) scrollAll(ctx context.Context, index string, query any, fn func(hit json.RawMessage) error) error {
+	b, _ := json.Marshal(map[string]any{
+		"size":  searchAllPageSize,
+		"query": query,
+		"sort":  []any{"_doc"},
+	})
+
+	res, err := esapi.SearchRequest{
+		Index:  []string{index},
+		Body:   bytes.NewReader(b),
+		Scroll: searchAllKeepAlive,
+	}.Do(ctx, s.Transport)
+	if err != nil {
+		return fmt.Errorf("searching ES index %q: %w", index, err)
+	}
+
+	var page searchPage
+	if err := s.parseJSON(res, &page); err != nil {
+		return err
+	}
+
+	scrollID := page.ScrollID
+
+	defer func() {
+		req := esapi.ClearScrollRequest{ScrollID: []string{scrollID}}
+		if err := s.do(context.Background(), req); err != nil {
+			s.log.Warn().Err(err).Msg("failed to clear ES scroll")
+		}
+	}()
+
+	for len(page.Hits.Hits) > 0 {
+		for _, hit := range page.Hits.Hits {
+			if err := fn(hit); err != nil {
+				return err
+			}
+		}
+
+		res, err := esapi.ScrollRequest{ScrollID: scrollID, Scroll: searchAllKeepAlive}.Do(ctx, s.Transport)
+		if err != nil {
+			return fmt.Errorf("scrolling ES index %q: %w", index, err)
+		}
+
+		page = searchPage{}
+		if err := s.parseJSON(res, &page); err != nil {
+			return err
+		}
+
+		scrollID = page.ScrollID
+	}
+
+	return nil
+}
+
+// parseJSON decodes the response body into v.
+func (s *Elasticsearch) parseJSON(res *esapi.Response, v any) error {
+	b, err := s.ParseResponseBytes(res)
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(b, v); err != nil {
+		return fmt.Errorf("parsing Elasticsearch response body: %w", err)
+	}
+
+	return nil
+}
+
+// Hit is a search hit with its source decoded into T.
+type Hit[T any] struct {
+	Index     string              `json:"_index"`
+	ID        string              `json:"_id"`
+	Score     *float64            `json:"_score"`
+	Source    T                   `json:"_source"`
+	Sort      []any               `json:"sort,omitempty"`
+	Highlight map[string][]string `json:"highlight,omitempty"`
+}
+
+// Total is the total number of hits, Relation is "eq" when Value is exact
+// and "gte" when it's a lower bound.
+type Total struct {
+	Value    int64  `json:"value"`
+	Relation string `json:"relation"`
+}
+
+// Search runs a search request on index and decodes the _source of the hits
+// into T. The body is a search request body, e.g.:
+//
+//	map[string]any{
+//		"query":     map[string]any{"match": map[string]any{"title": "goboot"}},
+//		"highlight": map[string]any{"fields": map[string]any{"title": map[string]any{}}},
+//	}
+func Search[T any](ctx context.Context, s *Elasticsearch, index string, body any) ([]Hit[T], Total, error) {
+	b, err := json.Marshal(body)
+	if err != nil {
+		return nil, Total{}, fmt.Errorf("marshalling ES search body: %w", err)
+	}
+
+	res, err := esapi.SearchRequest{
+		Index: []string{index},
+		Body:  bytes.NewReader(b),
+	}.Do(ctx, s.Transport)
+	if err != nil {
+		return nil, Total{}, fmt.Errorf("searching ES index %q: %w", index, err)
+	}
+
+	var result struct {
+		Hits struct {
+			Total Total    `json:"total"`
+			Hits  []Hit[T] `json:"hits"`
+		} `json:"hits"`
+	}
+	if err := s.parseJSON(res, &result); err != nil {
+		return nil, Total{}, err
+	}
+
+	return result.Hits.Hits, result.Hits.Total, nil
+}
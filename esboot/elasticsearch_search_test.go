@@ -0,0 +1,96 @@
+package esboot_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/nielskrijger/goboot"
+	"github.com/nielskrijger/goboot/esboot"
+	"github.com/stretchr/testify/assert"
+)
+
+func indexTestDocuments(t *testing.T, s *esboot.Elasticsearch, n int) {
+	t.Helper()
+
+	bi := s.NewBulkIndexer(esboot.BulkIndexerConfig{Index: "test", Refresh: "true"})
+	for i := 0; i < n; i++ {
+		assert.Nil(t, bi.Add(context.Background(), esboot.BulkItem{Body: map[string]any{"n": i}}))
+	}
+
+	assert.Nil(t, bi.Close(context.Background()))
+}
+
+func TestElasticsearch_SearchAll(t *testing.T) {
+	s := &esboot.Elasticsearch{}
+	setupElasticsearchEnv(t, s)
+	indexTestDocuments(t, s, 2500)
+
+	count := 0
+	err := s.SearchAll(context.Background(), "test", nil, func(hit json.RawMessage) error {
+		count++
+
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 2500, count)
+}
+
+func TestElasticsearch_SearchAllOpenSearch(t *testing.T) {
+	s := &esboot.Elasticsearch{}
+	assert.Nil(t, s.Configure(goboot.NewAppEnv("./testdata", "opensearch")))
+	_ = s.IndexDelete(context.Background(), "test")
+	indexTestDocuments(t, s, 1500)
+
+	count := 0
+	query := map[string]any{"range": map[string]any{"n": map[string]any{"gte": 1000}}}
+	err := s.SearchAll(context.Background(), "test", query, func(hit json.RawMessage) error {
+		count++
+
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 500, count)
+}
+
+func TestElasticsearch_SearchAllStop(t *testing.T) {
+	s := &esboot.Elasticsearch{}
+	setupElasticsearchEnv(t, s)
+	indexTestDocuments(t, s, 10)
+
+	errStop := errors.New("stop")
+	err := s.SearchAll(context.Background(), "test", nil, func(hit json.RawMessage) error {
+		return errStop
+	})
+	assert.ErrorIs(t, err, errStop)
+}
+
+type testDocument struct {
+	N int `json:"n"`
+}
+
+func TestSearch(t *testing.T) {
+	s := &esboot.Elasticsearch{}
+	setupElasticsearchEnv(t, s)
+	indexTestDocuments(t, s, 20)
+
+	hits, total, err := esboot.Search[testDocument](context.Background(), s, "test", map[string]any{
+		"query": map[string]any{"range": map[string]any{"n": map[string]any{"lt": 5}}},
+		"sort":  []any{map[string]any{"n": "desc"}},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, esboot.Total{Value: 5, Relation: "eq"}, total)
+	assert.Len(t, hits, 5)
+	assert.Equal(t, 4, hits[0].Source.N)
+	assert.Equal(t, []any{float64(4)}, hits[0].Sort)
+	assert.NotEmpty(t, hits[0].ID)
+}
+
+func TestSearch_Error(t *testing.T) {
+	s := &esboot.Elasticsearch{}
+	setupElasticsearchEnv(t, s)
+
+	_, _, err := esboot.Search[testDocument](context.Background(), s, "unknown-index", nil)
+	assert.ErrorContains(t, err, "index_not_found_exception")
+}
@@ -0,0 +1,127 @@
+package esboot
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/elastic/go-elasticsearch/v7/esapi"
+)
+
+// Repository types of RegisterSnapshotRepository. GCS and S3 require the
+// corresponding plugin, fs requires "path.repo" to include the location.
+const (
+	RepositoryFS  = "fs"
+	RepositoryGCS = "gcs"
+	RepositoryS3  = "s3"
+)
+
+// RegisterSnapshotRepository creates or updates a snapshot repository, e.g.:
+//
+//	es.RegisterSnapshotRepository(ctx, "backups", esboot.RepositoryGCS, map[string]any{
+//		"bucket": "my-bucket",
+//		"base_path": "elasticsearch",
+//	})
+func (s *Elasticsearch) RegisterSnapshotRepository(ctx context.Context, name string, repoType string, settings map[string]any) error {
+	b, err := json.Marshal(map[string]any{"type": repoType, "settings": settings})
+	if err != nil {
+		return fmt.Errorf("marshalling ES snapshot repository: %w", err)
+	}
+
+	req := esapi.SnapshotCreateRepositoryRequest{Repository: name, Body: bytes.NewReader(b)}
+	if err := s.do(ctx, req); err != nil {
+		return fmt.Errorf("registering ES snapshot repository %q: %w", name, err)
+	}
+
+	s.log.Info().Msgf("registered ES snapshot repository %q", name)
+
+	return nil
+}
+
+// CreateSnapshot takes a snapshot of indices, or of all indices when empty,
+// and waits for it to complete.
+func (s *Elasticsearch) CreateSnapshot(ctx context.Context, repository string, snapshot string, indices []string) error {
+	body := map[string]any{}
+	if len(indices) > 0 {
+		body["indices"] = strings.Join(indices, ",")
+	}
+
+	b, _ := json.Marshal(body)
+
+	res, err := s.getJSON(ctx, esapi.SnapshotCreateRequest{
+		Repository:        repository,
+		Snapshot:          snapshot,
+		Body:              bytes.NewReader(b),
+		WaitForCompletion: esapi.BoolPtr(true),
+	})
+	if err != nil {
+		return fmt.Errorf("creating ES snapshot %q: %w", snapshot, err)
+	}
+
+	if state := stringAt(res, "snapshot", "state"); state != "SUCCESS" {
+		return fmt.Errorf("creating ES snapshot %q: snapshot state is %s", snapshot, state) //nolint:goerr113
+	}
+
+	s.log.Info().Msgf("created ES snapshot %q in repository %q", snapshot, repository)
+
+	return nil
+}
+
+// RestoreSnapshot restores indices, or all indices when empty, from a
+// snapshot and waits for it to complete. Indices that exist must be closed
+// or deleted first.
+func (s *Elasticsearch) RestoreSnapshot(ctx context.Context, repository string, snapshot string, indices []string) error {
+	body := map[string]any{}
+	if len(indices) > 0 {
+		body["indices"] = strings.Join(indices, ",")
+	}
+
+	b, _ := json.Marshal(body)
+
+	req := esapi.SnapshotRestoreRequest{
+		Repository:        repository,
+		Snapshot:          snapshot,
+		Body:              bytes.NewReader(b),
+		WaitForCompletion: esapi.BoolPtr(true),
+	}
+	if err := s.do(ctx, req); err != nil {
+		return fmt.Errorf("restoring ES snapshot %q: %w", snapshot, err)
+	}
+
+	s.log.Info().Msgf("restored ES snapshot %q from repository %q", snapshot, repository)
+
+	return nil
+}
+
+// snapshotBeforeMigrate snapshots all indices to PreMigrationSnapshotRepository
+// if set, so destructive migrations have a rollback point.
+func (s *Elasticsearch) snapshotBeforeMigrate(ctx context.Context) error {
+	if s.PreMigrationSnapshotRepository == "" {
+		return nil
+	}
+
+	snapshot := "pre-migration-" + strings.ToLower(time.Now().UTC().Format("20060102t150405z"))
+
+	return s.CreateSnapshot(ctx, s.PreMigrationSnapshotRepository, snapshot, nil)
+}
+
+// stringAt returns the string at path in nested maps.
+func stringAt(m map[string]any, path ...string) string {
+	var v any = m
+
+	for _, key := range path {
+		obj, ok := v.(map[string]any)
+		if !ok {
+			return ""
+		}
+
+		v = obj[key]
+	}
+
+	str, _ := v.(string)
+
+	return str
+}
@@ -0,0 +1,50 @@
+package esboot_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/nielskrijger/goboot/esboot"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestElasticsearch_SnapshotAndRestore(t *testing.T) {
+	s := &esboot.Elasticsearch{}
+	setupElasticsearchEnv(t, s)
+	indexTestDocuments(t, s, 10)
+
+	ctx := context.Background()
+	assert.Nil(t, s.RegisterSnapshotRepository(ctx, "test-backups", esboot.RepositoryFS, map[string]any{
+		"location": "/usr/share/elasticsearch/snapshots/test",
+	}))
+	assert.Nil(t, s.CreateSnapshot(ctx, "test-backups", "snapshot-1", []string{"test"}))
+	assert.Nil(t, s.IndexDelete(ctx, "test"))
+	assert.Nil(t, s.RestoreSnapshot(ctx, "test-backups", "snapshot-1", []string{"test"}))
+
+	exists, err := s.IndexExists(ctx, "test")
+	assert.Nil(t, err)
+	assert.True(t, exists)
+}
+
+func TestElasticsearch_PreMigrationSnapshot(t *testing.T) {
+	s := &esboot.Elasticsearch{
+		PreMigrationSnapshotRepository: "test-backups",
+		Migrations: []*esboot.Migration{
+			{ID: "1", Migrate: func(es *esboot.Elasticsearch) error { return nil }},
+		},
+	}
+	setupElasticsearchEnv(t, s)
+
+	assert.Nil(t, s.RegisterSnapshotRepository(context.Background(), "test-backups", esboot.RepositoryFS, map[string]any{
+		"location": "/usr/share/elasticsearch/snapshots/test",
+	}))
+	assert.Nil(t, s.Init())
+}
+
+func TestElasticsearch_SnapshotUnknownRepository(t *testing.T) {
+	s := &esboot.Elasticsearch{}
+	setupElasticsearchEnv(t, s)
+
+	err := s.CreateSnapshot(context.Background(), "unknown", "snapshot-1", nil)
+	assert.ErrorContains(t, err, "repository_missing_exception")
+}
@@ -2,8 +2,13 @@ package esboot_test
 
 import (
 	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
 	"testing"
 
+	"github.com/elastic/go-elasticsearch/v7"
 	"github.com/nielskrijger/goboot"
 	"github.com/nielskrijger/goboot/esboot"
 	"github.com/stretchr/testify/assert"
@@ -15,7 +20,7 @@ func setupElasticsearchEnv(t *testing.T, es *esboot.Elasticsearch) *goboot.AppEn
 	env := goboot.NewAppEnv("./testdata", "valid")
 	assert.Nil(t, es.Configure(env))
 	_ = es.IndexDelete(context.Background(), "test")
-	_ = es.IndexDelete(context.Background(), es.MigrationsIndex)
+	_ = es.IndexDelete(context.Background(), es.IndexName(es.MigrationsIndex))
 
 	return env
 }
@@ -42,7 +47,7 @@ func TestElasticsearch_SuccessEnvs(t *testing.T) {
 func TestElasticsearch_ErrorNoAddresses(t *testing.T) {
 	s := &esboot.Elasticsearch{}
 	err := s.Configure(goboot.NewAppEnv("./testdata", "no-addresses"))
-	assert.EqualError(t, err, "config \"elasticsearch.addresses\" is required")
+	assert.EqualError(t, err, "config \"elasticsearch.addresses\" or \"elasticsearch.cloudID\" is required")
 }
 
 func TestElasticsearch_ErrorOnConnect(t *testing.T) {
@@ -50,3 +55,220 @@ func TestElasticsearch_ErrorOnConnect(t *testing.T) {
 	err := s.Configure(goboot.NewAppEnv("./testdata", "invalid-password"))
 	assert.Contains(t, err.Error(), "expected 200 OK but got \"401 Unauthorized\" while retrieving Elasticsearch info")
 }
+
+func TestElasticsearch_ErrorUnknownBackend(t *testing.T) {
+	s := &esboot.Elasticsearch{}
+	err := s.Configure(goboot.NewAppEnv("./testdata", "invalid-backend"))
+	assert.EqualError(t, err, "unknown Elasticsearch backend \"solr\", "+
+		"expected one of elasticsearch7, elasticsearch8 or opensearch")
+}
+
+func TestElasticsearch_OpenSearch(t *testing.T) {
+	s := &esboot.Elasticsearch{}
+	assert.Nil(t, s.Configure(goboot.NewAppEnv("./testdata", "opensearch")))
+	_ = s.IndexDelete(context.Background(), s.IndexName(s.MigrationsIndex))
+	assert.Nil(t, s.Init())
+	assert.Nil(t, s.Client)
+	assert.NotNil(t, s.Transport)
+}
+
+func TestElasticsearch_WaitForStatus(t *testing.T) {
+	s := &esboot.Elasticsearch{}
+	assert.Nil(t, s.Configure(goboot.NewAppEnv("./testdata", "wait-for-status")))
+}
+
+func TestElasticsearch_ErrorInvalidWaitForStatus(t *testing.T) {
+	s := &esboot.Elasticsearch{}
+	err := s.Configure(goboot.NewAppEnv("./testdata", "invalid-wait-for-status"))
+	assert.EqualError(t, err, "config \"elasticsearch.waitForStatus\" must be yellow or green")
+}
+
+func TestElasticsearch_RetryTransientErrors(t *testing.T) {
+	var calls int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) <= 2 {
+			w.WriteHeader(http.StatusTooManyRequests)
+
+			return
+		}
+
+		_, _ = w.Write([]byte(`{"cluster_name": "test"}`))
+	}))
+	defer server.Close()
+
+	env := goboot.NewAppEnv("./testdata", "opensearch")
+	env.Config.Set("elasticsearch.addresses", []string{server.URL})
+	env.Config.Set("elasticsearch.retryBackoff", "1ms")
+
+	s := &esboot.Elasticsearch{}
+	assert.Nil(t, s.Configure(env))
+	assert.GreaterOrEqual(t, atomic.LoadInt32(&calls), int32(3))
+}
+
+func TestElasticsearch_MigrationsIndexPrefixedOnce(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		paths []string
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		paths = append(paths, r.URL.Path)
+		mu.Unlock()
+
+		if r.Method == http.MethodHead {
+			w.WriteHeader(http.StatusNotFound)
+
+			return
+		}
+
+		_, _ = w.Write([]byte(`{"cluster_name": "test"}`))
+	}))
+	defer server.Close()
+
+	env := goboot.NewAppEnv("./testdata", "opensearch")
+	env.Config.Set("elasticsearch.addresses", []string{server.URL})
+
+	// the configured name starting with the prefix is prefixed nonetheless
+	s := &esboot.Elasticsearch{IndexPrefix: "app_", MigrationsIndex: "app_migrations", DryRun: true}
+	assert.Nil(t, s.Configure(env))
+	assert.Nil(t, s.Configure(env))
+	assert.Equal(t, "app_migrations", s.MigrationsIndex)
+
+	assert.Nil(t, s.Migrate(context.Background()))
+	assert.Contains(t, paths, "/app_app_migrations")
+}
+
+// roundTripperFunc implements http.RoundTripper.
+type roundTripperFunc func(r *http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+func TestElasticsearch_ConnectionSettingsOfAllBackends(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Elastic-Product", "Elasticsearch")
+		_, _ = w.Write([]byte(`{"cluster_name": "test", "version": {"number": "8.5.0"}}`))
+	}))
+	defer server.Close()
+
+	for _, backend := range []string{esboot.BackendElasticsearch8, esboot.BackendOpenSearch} {
+		env := goboot.NewAppEnv("./testdata", "opensearch")
+		env.Config.Set("elasticsearch.backend", backend)
+		env.Config.Set("elasticsearch.addresses", []string{server.URL})
+
+		var headers []string
+
+		s := &esboot.Elasticsearch{Config: &elasticsearch.Config{
+			Header: http.Header{"X-Tenant": []string{"acme"}},
+			Transport: roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+				headers = append(headers, r.Header.Get("X-Tenant"))
+
+				return http.DefaultTransport.RoundTrip(r)
+			}),
+		}}
+		assert.Nil(t, s.Configure(env), backend)
+		assert.NotEmpty(t, headers, backend)
+		assert.NotContains(t, headers, "", backend)
+	}
+}
+
+func TestElasticsearch_Metrics(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"cluster_name": "test"}`))
+	}))
+	defer server.Close()
+
+	env := goboot.NewAppEnv("./testdata", "opensearch")
+	env.Config.Set("elasticsearch.addresses", []string{server.URL})
+
+	s := &esboot.Elasticsearch{}
+	assert.Nil(t, s.Configure(env))
+
+	families, err := env.Metrics.Gather()
+	assert.Nil(t, err)
+
+	names := make([]string, 0, len(families))
+	for _, f := range families {
+		names = append(names, f.GetName())
+	}
+
+	assert.Contains(t, names, "elasticsearch_request_duration_seconds")
+	assert.Contains(t, names, "elasticsearch_requests_total")
+}
+
+func TestElasticsearch_MetricsOfClientRequests(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Elastic-Product", "Elasticsearch")
+		_, _ = w.Write([]byte(`{"cluster_name": "test", "version": {"number": "7.17.0", "build_flavor": "default"}, "tagline": "You Know, for Search"}`))
+	}))
+	defer server.Close()
+
+	env := goboot.NewAppEnv("./testdata", "opensearch")
+	env.Config.Set("elasticsearch.backend", esboot.BackendElasticsearch7)
+	env.Config.Set("elasticsearch.addresses", []string{server.URL})
+
+	s := &esboot.Elasticsearch{}
+	assert.Nil(t, s.Configure(env))
+
+	before := countRequests(t, env)
+
+	res, err := s.Client.Info()
+	assert.Nil(t, err)
+	_ = res.Body.Close()
+
+	assert.Equal(t, before+1, countRequests(t, env))
+}
+
+func countRequests(t *testing.T, env *goboot.AppEnv) float64 {
+	t.Helper()
+
+	families, err := env.Metrics.Gather()
+	assert.Nil(t, err)
+
+	var requests float64
+
+	for _, f := range families {
+		if f.GetName() == "elasticsearch_requests_total" {
+			for _, m := range f.GetMetric() {
+				requests += m.GetCounter().GetValue()
+			}
+		}
+	}
+
+	return requests
+}
+
+func TestElasticsearch_APIKey(t *testing.T) {
+	var authorization string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		w.Header().Set("X-Elastic-Product", "Elasticsearch")
+		_, _ = w.Write([]byte(`{"cluster_name": "test", "version": {"number": "7.17.0", "build_flavor": "default"}, "tagline": "You Know, for Search"}`))
+	}))
+	defer server.Close()
+
+	t.Setenv("TEST_ES_API_KEY", "c2VjcmV0")
+
+	env := goboot.NewAppEnv("./testdata", "api-key")
+	env.Config.Set("elasticsearch.addresses", []string{server.URL})
+
+	s := &esboot.Elasticsearch{}
+	assert.Nil(t, s.Configure(env))
+	assert.Equal(t, "APIKey c2VjcmV0", authorization)
+}
+
+func TestElasticsearch_ErrorMissingAPIKeySecret(t *testing.T) {
+	s := &esboot.Elasticsearch{}
+	err := s.Configure(goboot.NewAppEnv("./testdata", "api-key"))
+	assert.ErrorContains(t, err, "resolving config \"elasticsearch.apiKey\"")
+}
+
+func TestElasticsearch_ErrorOpenSearchToken(t *testing.T) {
+	s := &esboot.Elasticsearch{}
+	err := s.Configure(goboot.NewAppEnv("./testdata", "opensearch-token"))
+	assert.EqualError(t, err, "OpenSearch only supports username and password authentication")
+}
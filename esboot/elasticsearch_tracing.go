@@ -0,0 +1,102 @@
+package esboot
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/tidwall/gjson"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.12.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/nielskrijger/goboot/esboot"
+
+// maxTracedBodySize is the number of response bytes read to find the took
+// time, which Elasticsearch writes at the start of the response.
+const maxTracedBodySize = 4 << 10
+
+// tracingTransport records a span for every request attempt, parented to the
+// span in the request context, if any.
+type tracingTransport struct {
+	next   http.RoundTripper
+	tracer trace.Tracer
+}
+
+func (t *tracingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	op := operation(req)
+
+	ctx, span := t.tracer.Start(req.Context(), "elasticsearch."+op,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemElasticsearch,
+			semconv.DBOperationKey.String(op),
+			semconv.HTTPMethodKey.String(req.Method),
+		),
+	)
+	defer span.End()
+
+	if idx := indexName(req); idx != "" {
+		span.SetAttributes(attribute.String("db.elasticsearch.index", idx))
+	}
+
+	res, err := t.next.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+
+		return nil, err //nolint:wrapcheck
+	}
+
+	span.SetAttributes(semconv.HTTPStatusCodeKey.Int(res.StatusCode))
+
+	if res.StatusCode >= http.StatusBadRequest {
+		span.SetStatus(codes.Error, res.Status)
+	}
+
+	// the took time is only available in the response body, of which the
+	// start is buffered so the caller can still read the whole body
+	if res.Body != nil && strings.HasPrefix(res.Header.Get("Content-Type"), "application/json") {
+		b, err := io.ReadAll(io.LimitReader(res.Body, maxTracedBodySize+1))
+		if err != nil {
+			_ = res.Body.Close()
+
+			return nil, err //nolint:wrapcheck
+		}
+
+		res.Body = &prefixedBody{Reader: io.MultiReader(bytes.NewReader(b), res.Body), Closer: res.Body}
+
+		if len(b) > maxTracedBodySize {
+			b = b[:maxTracedBodySize]
+			span.SetAttributes(attribute.Bool("db.elasticsearch.body_truncated", true))
+		}
+
+		if took := gjson.GetBytes(b, "took"); took.Exists() {
+			span.SetAttributes(attribute.Int64("db.elasticsearch.took_ms", took.Int()))
+		}
+	}
+
+	return res, nil
+}
+
+// prefixedBody reads the buffered start of a response body followed by the
+// rest of the body.
+type prefixedBody struct {
+	io.Reader
+	io.Closer
+}
+
+// indexName returns the index or comma-separated indices of the request path.
+func indexName(req *http.Request) string {
+	path := strings.TrimPrefix(req.URL.Path, "/")
+	if path == "" || strings.HasPrefix(path, "_") {
+		return ""
+	}
+
+	idx, _, _ := strings.Cut(path, "/")
+
+	return idx
+}
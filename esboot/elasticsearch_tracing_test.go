@@ -0,0 +1,131 @@
+package esboot_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/elastic/go-elasticsearch/v7/esapi"
+	"github.com/nielskrijger/goboot"
+	"github.com/nielskrijger/goboot/esboot"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestElasticsearch_TraceRequests(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"cluster_name": "test", "took": 12, "hits": {"hits": []}}`))
+	}))
+	defer server.Close()
+
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	env := goboot.NewAppEnv("./testdata", "opensearch")
+	env.Config.Set("elasticsearch.addresses", []string{server.URL})
+	env.TracerProvider = tp
+
+	s := &esboot.Elasticsearch{}
+	assert.Nil(t, s.Configure(env))
+
+	ctx, parent := tp.Tracer("test").Start(context.Background(), "request")
+	res, err := esapi.SearchRequest{Index: []string{"test"}}.Do(ctx, s.Transport)
+	assert.Nil(t, err)
+	_, err = s.ParseResponseBytes(res)
+	assert.Nil(t, err)
+	parent.End()
+
+	var search sdktrace.ReadOnlySpan
+
+	for _, span := range recorder.Ended() {
+		if span.Name() == "elasticsearch._search" {
+			search = span
+		}
+	}
+
+	if assert.NotNil(t, search) {
+		assert.Equal(t, parent.SpanContext().SpanID(), search.Parent().SpanID())
+		assert.Contains(t, search.Attributes(), attribute.String("db.elasticsearch.index", "test"))
+		assert.Contains(t, search.Attributes(), attribute.Int64("db.elasticsearch.took_ms", 12))
+		assert.Contains(t, search.Attributes(), attribute.Int("http.status_code", 200))
+	}
+}
+
+func TestElasticsearch_TraceLargeResponse(t *testing.T) {
+	body := `{"took": 12, "hits": {"hits": ["` + strings.Repeat("x", 1<<20) + `"]}}`
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(body))
+	}))
+	defer server.Close()
+
+	recorder := tracetest.NewSpanRecorder()
+
+	env := goboot.NewAppEnv("./testdata", "opensearch")
+	env.Config.Set("elasticsearch.addresses", []string{server.URL})
+	env.TracerProvider = sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	s := &esboot.Elasticsearch{}
+	assert.Nil(t, s.Configure(env))
+
+	res, err := esapi.SearchRequest{Index: []string{"test"}}.Do(context.Background(), s.Transport)
+	assert.Nil(t, err)
+	b, err := s.ParseResponseBytes(res)
+	assert.Nil(t, err)
+	assert.Equal(t, body, string(b))
+
+	var search sdktrace.ReadOnlySpan
+
+	for _, span := range recorder.Ended() {
+		if span.Name() == "elasticsearch._search" {
+			search = span
+		}
+	}
+
+	if assert.NotNil(t, search) {
+		assert.Contains(t, search.Attributes(), attribute.Int64("db.elasticsearch.took_ms", 12))
+		assert.Contains(t, search.Attributes(), attribute.Bool("db.elasticsearch.body_truncated", true))
+	}
+}
+
+func TestElasticsearch_TraceClientRequests(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Elastic-Product", "Elasticsearch")
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"took": 3, "hits": {"hits": []}, "version": {"number": "7.17.0", "build_flavor": "default"}, "tagline": "You Know, for Search"}`))
+	}))
+	defer server.Close()
+
+	recorder := tracetest.NewSpanRecorder()
+
+	env := goboot.NewAppEnv("./testdata", "opensearch")
+	env.Config.Set("elasticsearch.backend", esboot.BackendElasticsearch7)
+	env.Config.Set("elasticsearch.addresses", []string{server.URL})
+	env.TracerProvider = sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	s := &esboot.Elasticsearch{}
+	assert.Nil(t, s.Configure(env))
+
+	res, err := s.Client.Search(s.Client.Search.WithIndex("test"))
+	assert.Nil(t, err)
+	_ = res.Body.Close()
+
+	var search sdktrace.ReadOnlySpan
+
+	for _, span := range recorder.Ended() {
+		if span.Name() == "elasticsearch._search" {
+			search = span
+		}
+	}
+
+	if assert.NotNil(t, search) {
+		assert.Contains(t, search.Attributes(), attribute.String("db.elasticsearch.index", "test"))
+		assert.Contains(t, search.Attributes(), attribute.Int64("db.elasticsearch.took_ms", 3))
+	}
+}
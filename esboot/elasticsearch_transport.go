@@ -0,0 +1,224 @@
+package esboot
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+
+	elastictransport "github.com/elastic/elastic-transport-go/v8/elastictransport"
+	elasticsearch7 "github.com/elastic/go-elasticsearch/v7"
+	"github.com/elastic/go-elasticsearch/v7/esapi"
+	"github.com/elastic/go-elasticsearch/v7/estransport"
+	elasticsearch8 "github.com/elastic/go-elasticsearch/v8"
+	"github.com/nielskrijger/goboot"
+	"github.com/opensearch-project/opensearch-go"
+	"github.com/opensearch-project/opensearch-go/opensearchtransport"
+)
+
+const (
+	defaultMaxRetries   = 3
+	defaultRetryBackoff = 100 * time.Millisecond
+	maxRetryBackoff     = 10 * time.Second
+)
+
+var (
+	errCACertTransport = errors.New("unable to set CA certificate for transport of type")
+	errInvalidCACert   = errors.New("unable to add CA certificate")
+)
+
+// retryOnStatus are the transient errors retried by the transport.
+var retryOnStatus = []int{
+	http.StatusTooManyRequests,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+}
+
+// configureRetries sets the retry policy of the transport: responses with
+// status 429, 502, 503 and 504 are retried up to "elasticsearch.maxRetries"
+// times (default 3, 0 disables retries) using exponential backoff starting
+// at "elasticsearch.retryBackoff" (default 100ms). Retry settings set on
+// Config before calling Configure are kept unless overridden by config.
+func (s *Elasticsearch) configureRetries(env *goboot.AppEnv) {
+	switch {
+	case env.Config.IsSet("elasticsearch.maxRetries"):
+		s.Config.MaxRetries = env.Config.GetInt("elasticsearch.maxRetries")
+	case s.Config.MaxRetries == 0 && !s.Config.DisableRetry:
+		s.Config.MaxRetries = defaultMaxRetries
+	}
+
+	if s.Config.MaxRetries <= 0 {
+		s.Config.DisableRetry = true
+	}
+
+	if s.Config.RetryOnStatus == nil {
+		s.Config.RetryOnStatus = retryOnStatus
+	}
+
+	if s.Config.RetryBackoff != nil && !env.Config.IsSet("elasticsearch.retryBackoff") {
+		return
+	}
+
+	initial := defaultRetryBackoff
+	if env.Config.IsSet("elasticsearch.retryBackoff") {
+		initial = env.Config.GetDuration("elasticsearch.retryBackoff")
+	}
+
+	s.Config.RetryBackoff = func(attempt int) time.Duration {
+		backoff := initial << (attempt - 1)
+		if backoff > maxRetryBackoff || backoff <= 0 {
+			return maxRetryBackoff
+		}
+
+		return backoff
+	}
+}
+
+// roundTripper returns the HTTP transport of the clients with the CA
+// certificate applied. It records metrics and traces of every request
+// attempt, so requests sent using Client are instrumented the same as using
+// Transport.
+func (s *Elasticsearch) roundTripper(env *goboot.AppEnv) (http.RoundTripper, error) {
+	rt := s.Config.Transport
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+
+	// the clients can't set the CA certificate on a wrapped transport
+	if s.Config.CACert != nil {
+		httpTransport, ok := rt.(*http.Transport)
+		if !ok {
+			return nil, fmt.Errorf("%w %T", errCACertTransport, rt)
+		}
+
+		httpTransport = httpTransport.Clone()
+		if httpTransport.TLSClientConfig == nil {
+			httpTransport.TLSClientConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+		}
+
+		httpTransport.TLSClientConfig.RootCAs = x509.NewCertPool()
+		if !httpTransport.TLSClientConfig.RootCAs.AppendCertsFromPEM(s.Config.CACert) {
+			return nil, errInvalidCACert
+		}
+
+		rt = httpTransport
+	}
+
+	if s.metrics != nil {
+		rt = &metricsTransport{next: rt, metrics: s.metrics}
+	}
+
+	if env.TracerProvider != nil {
+		rt = &tracingTransport{next: rt, tracer: env.TracerProvider.Tracer(tracerName)}
+	}
+
+	return rt, nil
+}
+
+// newTransport creates the client of the configured backend sending requests
+// using rt.
+func (s *Elasticsearch) newTransport(env *goboot.AppEnv, rt http.RoundTripper) (esapi.Transport, error) {
+	debug := env.Log.Debug().Enabled()
+	human := env.Config.Get("log.human") == "true"
+
+	switch s.backend {
+	case BackendElasticsearch7:
+		cfg := *s.Config
+		cfg.CACert = nil
+		cfg.Transport = rt
+
+		if debug {
+			cfg.Logger = v7Logger(os.Stdout, human)
+		}
+
+		client, err := elasticsearch7.NewClient(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("creating Elasticsearch client: %w", err)
+		}
+
+		s.Client = client
+
+		return client, nil
+	case BackendElasticsearch8:
+		cfg := elasticsearch8.Config{
+			Addresses:     s.Config.Addresses,
+			CloudID:       s.Config.CloudID,
+			Username:      s.Config.Username,
+			Password:      s.Config.Password,
+			APIKey:        s.Config.APIKey,
+			ServiceToken:  s.Config.ServiceToken,
+			Header:        s.Config.Header,
+			Transport:     rt,
+			MaxRetries:    s.Config.MaxRetries,
+			DisableRetry:  s.Config.DisableRetry,
+			RetryOnStatus: s.Config.RetryOnStatus,
+			RetryBackoff:  s.Config.RetryBackoff,
+		}
+
+		if debug {
+			cfg.Logger = v8Logger(os.Stdout, human)
+		}
+
+		client, err := elasticsearch8.NewClient(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("creating Elasticsearch 8 client: %w", err)
+		}
+
+		return client, nil
+	case BackendOpenSearch:
+		cfg := opensearch.Config{
+			Addresses:     s.Config.Addresses,
+			Username:      s.Config.Username,
+			Password:      s.Config.Password,
+			Header:        s.Config.Header,
+			Transport:     rt,
+			MaxRetries:    s.Config.MaxRetries,
+			DisableRetry:  s.Config.DisableRetry,
+			RetryOnStatus: s.Config.RetryOnStatus,
+			RetryBackoff:  s.Config.RetryBackoff,
+		}
+
+		if debug {
+			cfg.Logger = openSearchLogger(os.Stdout, human)
+		}
+
+		client, err := opensearch.NewClient(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("creating OpenSearch client: %w", err)
+		}
+
+		return client, nil
+	default:
+		return nil, fmt.Errorf("%w %q, expected one of %s, %s or %s", errUnknownBackend, s.backend,
+			BackendElasticsearch7, BackendElasticsearch8, BackendOpenSearch)
+	}
+}
+
+func v7Logger(w io.Writer, human bool) estransport.Logger {
+	if human {
+		return &estransport.ColorLogger{Output: w, EnableRequestBody: true, EnableResponseBody: true}
+	}
+
+	return &estransport.JSONLogger{Output: w, EnableRequestBody: true, EnableResponseBody: true}
+}
+
+func v8Logger(w io.Writer, human bool) elastictransport.Logger {
+	if human {
+		return &elastictransport.ColorLogger{Output: w, EnableRequestBody: true, EnableResponseBody: true}
+	}
+
+	return &elastictransport.JSONLogger{Output: w, EnableRequestBody: true, EnableResponseBody: true}
+}
+
+func openSearchLogger(w io.Writer, human bool) opensearchtransport.Logger {
+	if human {
+		return &opensearchtransport.ColorLogger{Output: w, EnableRequestBody: true, EnableResponseBody: true}
+	}
+
+	return &opensearchtransport.JSONLogger{Output: w, EnableRequestBody: true, EnableResponseBody: true}
+}
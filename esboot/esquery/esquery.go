@@ -0,0 +1,210 @@
+// Package esquery builds Elasticsearch queries that marshal to the query DSL,
+// e.g.:
+//
+//	q := esquery.Bool().
+//		Must(esquery.Match("title", "goboot")).
+//		Filter(esquery.Term("status", "published"), esquery.Range("year").Gte(2020))
+//
+//	hits, total, err := esboot.Search[Article](ctx, es, "articles", map[string]any{"query": q})
+package esquery
+
+import "encoding/json"
+
+// Query is a query clause that marshals to the query DSL.
+type Query interface {
+	json.Marshaler
+
+	// Map returns the query as it's marshalled to JSON.
+	Map() map[string]any
+}
+
+type query map[string]any
+
+func (q query) Map() map[string]any {
+	return q
+}
+
+func (q query) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]any(q)) //nolint:wrapcheck
+}
+
+// MatchAll matches all documents.
+func MatchAll() Query {
+	return query{"match_all": map[string]any{}}
+}
+
+// Term matches documents that contain the exact value in field.
+func Term(field string, value any) Query {
+	return query{"term": map[string]any{field: value}}
+}
+
+// Terms matches documents that contain one or more of the exact values in field.
+func Terms(field string, values ...any) Query {
+	return query{"terms": map[string]any{field: values}}
+}
+
+// Exists matches documents that have a value for field.
+func Exists(field string) Query {
+	return query{"exists": map[string]any{"field": field}}
+}
+
+// IDs matches documents by their ID.
+func IDs(ids ...string) Query {
+	return query{"ids": map[string]any{"values": ids}}
+}
+
+// MatchQuery is a full text query, see Match.
+type MatchQuery struct {
+	field  string
+	params map[string]any
+}
+
+// Match analyzes text and matches documents with field containing any of the
+// terms, or all of them with Operator("and").
+func Match(field string, text any) *MatchQuery {
+	return &MatchQuery{field: field, params: map[string]any{"query": text}}
+}
+
+// Operator is either "or" (default) or "and".
+func (q *MatchQuery) Operator(op string) *MatchQuery {
+	q.params["operator"] = op
+
+	return q
+}
+
+// Fuzziness allows matching terms within an edit distance, e.g. "AUTO".
+func (q *MatchQuery) Fuzziness(fuzziness string) *MatchQuery {
+	q.params["fuzziness"] = fuzziness
+
+	return q
+}
+
+func (q *MatchQuery) Map() map[string]any {
+	return map[string]any{"match": map[string]any{q.field: q.params}}
+}
+
+func (q *MatchQuery) MarshalJSON() ([]byte, error) {
+	return json.Marshal(q.Map()) //nolint:wrapcheck
+}
+
+// RangeQuery matches a range of values, see Range.
+type RangeQuery struct {
+	field  string
+	params map[string]any
+}
+
+// Range matches documents with field within the bounds set by Gt, Gte, Lt and Lte.
+func Range(field string) *RangeQuery {
+	return &RangeQuery{field: field, params: map[string]any{}}
+}
+
+func (q *RangeQuery) Gt(value any) *RangeQuery {
+	q.params["gt"] = value
+
+	return q
+}
+
+func (q *RangeQuery) Gte(value any) *RangeQuery {
+	q.params["gte"] = value
+
+	return q
+}
+
+func (q *RangeQuery) Lt(value any) *RangeQuery {
+	q.params["lt"] = value
+
+	return q
+}
+
+func (q *RangeQuery) Lte(value any) *RangeQuery {
+	q.params["lte"] = value
+
+	return q
+}
+
+// Format is the date format of the bounds, e.g. "yyyy-MM-dd".
+func (q *RangeQuery) Format(format string) *RangeQuery {
+	q.params["format"] = format
+
+	return q
+}
+
+func (q *RangeQuery) Map() map[string]any {
+	return map[string]any{"range": map[string]any{q.field: q.params}}
+}
+
+func (q *RangeQuery) MarshalJSON() ([]byte, error) {
+	return json.Marshal(q.Map()) //nolint:wrapcheck
+}
+
+// BoolQuery combines queries, see Bool.
+type BoolQuery struct {
+	must               []Query
+	filter             []Query
+	should             []Query
+	mustNot            []Query
+	minimumShouldMatch any
+}
+
+// Bool matches documents matching the combination of queries. Must and Should
+// contribute to the score, Filter and MustNot don't.
+func Bool() *BoolQuery {
+	return &BoolQuery{}
+}
+
+func (q *BoolQuery) Must(queries ...Query) *BoolQuery {
+	q.must = append(q.must, queries...)
+
+	return q
+}
+
+func (q *BoolQuery) Filter(queries ...Query) *BoolQuery {
+	q.filter = append(q.filter, queries...)
+
+	return q
+}
+
+func (q *BoolQuery) Should(queries ...Query) *BoolQuery {
+	q.should = append(q.should, queries...)
+
+	return q
+}
+
+func (q *BoolQuery) MustNot(queries ...Query) *BoolQuery {
+	q.mustNot = append(q.mustNot, queries...)
+
+	return q
+}
+
+// MinimumShouldMatch is the number or percentage of Should clauses that must
+// match, e.g. 1 or "75%".
+func (q *BoolQuery) MinimumShouldMatch(value any) *BoolQuery {
+	q.minimumShouldMatch = value
+
+	return q
+}
+
+func (q *BoolQuery) Map() map[string]any {
+	params := map[string]any{}
+
+	for key, queries := range map[string][]Query{
+		"must":     q.must,
+		"filter":   q.filter,
+		"should":   q.should,
+		"must_not": q.mustNot,
+	} {
+		if len(queries) > 0 {
+			params[key] = queries
+		}
+	}
+
+	if q.minimumShouldMatch != nil {
+		params["minimum_should_match"] = q.minimumShouldMatch
+	}
+
+	return map[string]any{"bool": params}
+}
+
+func (q *BoolQuery) MarshalJSON() ([]byte, error) {
+	return json.Marshal(q.Map()) //nolint:wrapcheck
+}
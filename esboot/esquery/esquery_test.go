@@ -0,0 +1,40 @@
+package esquery_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/nielskrijger/goboot/esboot/esquery"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBool(t *testing.T) {
+	q := esquery.Bool().
+		Must(esquery.Match("title", "goboot").Operator("and")).
+		Filter(esquery.Term("status", "published"), esquery.Range("year").Gte(2020).Lt(2023)).
+		Should(esquery.Terms("tags", "go", "elasticsearch")).
+		MustNot(esquery.Exists("deletedAt")).
+		MinimumShouldMatch(1)
+
+	b, err := json.Marshal(q)
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{"bool": {
+		"must": [{"match": {"title": {"query": "goboot", "operator": "and"}}}],
+		"filter": [{"term": {"status": "published"}}, {"range": {"year": {"gte": 2020, "lt": 2023}}}],
+		"should": [{"terms": {"tags": ["go", "elasticsearch"]}}],
+		"must_not": [{"exists": {"field": "deletedAt"}}],
+		"minimum_should_match": 1
+	}}`, string(b))
+}
+
+func TestEmptyBool(t *testing.T) {
+	b, err := json.Marshal(map[string]any{"query": esquery.Bool()})
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{"query": {"bool": {}}}`, string(b))
+}
+
+func TestMatchAllAndIDs(t *testing.T) {
+	b, err := json.Marshal([]esquery.Query{esquery.MatchAll(), esquery.IDs("1", "2")})
+	assert.Nil(t, err)
+	assert.JSONEq(t, `[{"match_all": {}}, {"ids": {"values": ["1", "2"]}}]`, string(b))
+}
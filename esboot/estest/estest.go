@@ -0,0 +1,112 @@
+// Package estest provides helpers for tests using the Elasticsearch service.
+//
+// Each test gets its own index prefix, so tests don't share indices and can
+// run in parallel against a single cluster.
+package estest
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/elastic/go-elasticsearch/v7/esapi"
+	"github.com/nielskrijger/goboot"
+	"github.com/nielskrijger/goboot/esboot"
+)
+
+// maxTestNameLength keeps index names well within the 255 byte limit.
+const maxTestNameLength = 40
+
+// NewElasticsearch configures and initializes es for the duration of test t
+// using a unique IndexPrefix, creates an empty service if es is nil. All
+// indices with the prefix are deleted on cleanup.
+//
+// Use es.IndexName to refer to indices, declared Indices and the migrations
+// index are prefixed automatically.
+func NewElasticsearch(t testing.TB, confDir string, env string, es *esboot.Elasticsearch) *esboot.Elasticsearch {
+	t.Helper()
+
+	if es == nil {
+		es = &esboot.Elasticsearch{}
+	}
+
+	es.IndexPrefix = IndexPrefix(t)
+
+	if err := es.Configure(goboot.NewAppEnv(confDir, env)); err != nil {
+		t.Fatalf("configuring Elasticsearch: %v", err)
+	}
+
+	t.Cleanup(func() {
+		if err := deleteIndices(es, es.IndexPrefix+"*"); err != nil {
+			t.Errorf("deleting test indices: %v", err)
+		}
+	})
+
+	if err := es.Init(); err != nil {
+		t.Fatalf("initializing Elasticsearch: %v", err)
+	}
+
+	return es
+}
+
+// IndexPrefix returns a unique index prefix based on the test name, e.g.
+// "testsearch-3f2a9c1b7d4e-".
+func IndexPrefix(t testing.TB) string {
+	t.Helper()
+
+	name := strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			return r
+		}
+
+		return '-'
+	}, strings.ToLower(t.Name()))
+
+	if len(name) > maxTestNameLength {
+		name = name[:maxTestNameLength]
+	}
+
+	b := make([]byte, 6)
+	if _, err := rand.Read(b); err != nil {
+		t.Fatalf("generating index prefix: %v", err)
+	}
+
+	return name + "-" + hex.EncodeToString(b) + "-"
+}
+
+// deleteIndices deletes all indices matching pattern. Indices are resolved
+// first because deleting by wildcard may be disallowed by
+// "action.destructive_requires_name".
+func deleteIndices(es *esboot.Elasticsearch, pattern string) error {
+	ctx := context.Background()
+
+	res, err := esapi.IndicesGetRequest{
+		Index:           []string{pattern},
+		ExpandWildcards: "all",
+		FilterPath:      []string{"*.settings.index.provided_name"},
+	}.Do(ctx, es.Transport)
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+
+	b, err := es.ParseResponseBytes(res)
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+
+	var indices map[string]any
+	if err := json.Unmarshal(b, &indices); err != nil {
+		return err //nolint:wrapcheck
+	}
+
+	for idx := range indices {
+		if err := es.IndexDelete(ctx, idx); err != nil {
+			return err //nolint:wrapcheck
+		}
+	}
+
+	return nil
+}
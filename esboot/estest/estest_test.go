@@ -0,0 +1,42 @@
+package estest_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/elastic/go-elasticsearch/v7/esapi"
+	"github.com/nielskrijger/goboot/esboot"
+	"github.com/nielskrijger/goboot/esboot/estest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewElasticsearch_Isolated(t *testing.T) {
+	t.Parallel()
+
+	for i := 0; i < 2; i++ {
+		es := estest.NewElasticsearch(t, "./testdata", "test", &esboot.Elasticsearch{
+			Indices: []*esboot.Index{{Name: "documents", Body: map[string]any{}}},
+		})
+
+		exists, err := es.IndexExists(context.Background(), es.IndexName(es.MigrationsIndex))
+		assert.Nil(t, err)
+		assert.True(t, exists)
+
+		res, err := esapi.IndexRequest{
+			Index:      es.IndexName("documents"),
+			DocumentID: "1",
+			Body:       strings.NewReader(`{"n": 1}`),
+			OpType:     "create",
+		}.Do(context.Background(), es.Transport)
+		assert.Nil(t, err)
+		_, err = es.ParseResponseBytes(res)
+		assert.Nil(t, err)
+	}
+}
+
+func TestIndexPrefix(t *testing.T) {
+	prefix := estest.IndexPrefix(t)
+	assert.Regexp(t, `^testindexprefix-[0-9a-f]{12}-$`, prefix)
+	assert.NotEqual(t, prefix, estest.IndexPrefix(t))
+}
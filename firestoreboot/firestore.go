@@ -0,0 +1,140 @@
+package firestoreboot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"cloud.google.com/go/firestore"
+	"github.com/nielskrijger/goboot"
+	"github.com/rs/zerolog"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// EmulatorHostEnv is the environment variable pointing the client to the
+// Firestore emulator, e.g. "localhost:8080".
+const EmulatorHostEnv = "FIRESTORE_EMULATOR_HOST"
+
+var (
+	ErrNotFound = errors.New("document not found")
+
+	errUnavailable = errors.New("firestore is unavailable")
+)
+
+// Firestore wraps the Google Cloud Firestore client.
+type Firestore struct {
+	*firestore.Client
+
+	projectID string
+	log       zerolog.Logger
+}
+
+// NewFirestoreService creates a new Firestore service for specified project.
+func NewFirestoreService(projectID string) *Firestore {
+	return &Firestore{projectID: projectID}
+}
+
+func (s *Firestore) Name() string {
+	return "Firestore"
+}
+
+// Configure implements the AppService interface and instantiates the client.
+//
+// The client connects to the emulator when FIRESTORE_EMULATOR_HOST is set.
+func (s *Firestore) Configure(env *goboot.AppEnv) error {
+	s.log = env.Log
+
+	client, err := firestore.NewClient(context.Background(), s.projectID)
+	if err != nil {
+		return fmt.Errorf("connecting to Firestore: %w", err)
+	}
+
+	if host := os.Getenv(EmulatorHostEnv); host != "" {
+		s.log.Info().Msgf("connected to %s Firestore emulator at %s", s.projectID, host)
+	} else {
+		s.log.Info().Msgf("connected to %s Firestore", s.projectID)
+	}
+
+	s.Client = client
+
+	return nil
+}
+
+// Init implements the AppService interface, Firestore requires no setup.
+func (s *Firestore) Init() error {
+	return nil
+}
+
+// HealthCheck lists a single collection to verify Firestore is reachable.
+// It implements goboot.HealthChecker.
+func (s *Firestore) HealthCheck(ctx context.Context) error {
+	_, err := s.Collections(ctx).Next()
+	if err != nil && !errors.Is(err, iterator.Done) {
+		return fmt.Errorf("%w: %v", errUnavailable, err)
+	}
+
+	return nil
+}
+
+// Close releases the client connection.
+func (s *Firestore) Close() error {
+	if err := s.Client.Close(); err != nil {
+		return fmt.Errorf("closing %s service: %w", s.Name(), err)
+	}
+
+	return nil
+}
+
+// Get reads a document and decodes it into T, returns ErrNotFound if the
+// document doesn't exist.
+func Get[T any](ctx context.Context, doc *firestore.DocumentRef) (*T, error) {
+	snap, err := doc.Get(ctx)
+	if status.Code(err) == codes.NotFound {
+		return nil, ErrNotFound
+	} else if err != nil {
+		return nil, fmt.Errorf("reading document %q: %w", doc.Path, err)
+	}
+
+	var result T
+	if err := snap.DataTo(&result); err != nil {
+		return nil, fmt.Errorf("decoding document %q: %w", doc.Path, err)
+	}
+
+	return &result, nil
+}
+
+// Set creates or overwrites a document with data.
+func Set[T any](ctx context.Context, doc *firestore.DocumentRef, data *T) error {
+	if _, err := doc.Set(ctx, data); err != nil {
+		return fmt.Errorf("writing document %q: %w", doc.Path, err)
+	}
+
+	return nil
+}
+
+// GetAll runs query and decodes all documents into T.
+func GetAll[T any](ctx context.Context, query firestore.Query) ([]*T, error) {
+	iter := query.Documents(ctx)
+	defer iter.Stop()
+
+	var result []*T
+
+	for {
+		snap, err := iter.Next()
+		if errors.Is(err, iterator.Done) {
+			return result, nil
+		} else if err != nil {
+			return nil, fmt.Errorf("querying documents: %w", err)
+		}
+
+		var doc T
+		if err := snap.DataTo(&doc); err != nil {
+			return nil, fmt.Errorf("decoding document %q: %w", snap.Ref.Path, err)
+		}
+
+		result = append(result, &doc)
+	}
+}
@@ -0,0 +1,56 @@
+package firestoreboot_test
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/nielskrijger/goboot"
+	"github.com/nielskrijger/goboot/firestoreboot"
+	"github.com/stretchr/testify/assert"
+)
+
+type testDoc struct {
+	Name  string `firestore:"name"`
+	Count int    `firestore:"count"`
+}
+
+func newFirestoreEmulatorService(t *testing.T) *firestoreboot.Firestore {
+	t.Helper()
+
+	if _, exists := os.LookupEnv(firestoreboot.EmulatorHostEnv); !exists {
+		t.Setenv(firestoreboot.EmulatorHostEnv, "localhost:8081")
+	}
+
+	s := firestoreboot.NewFirestoreService("goboot-test")
+	assert.Nil(t, s.Configure(goboot.NewAppEnv("./testdata", "")))
+
+	return s
+}
+
+func TestFirestore_GetAndSet(t *testing.T) {
+	s := newFirestoreEmulatorService(t)
+	ctx := context.Background()
+	doc := s.Collection("tests").Doc("doc-1")
+
+	assert.Nil(t, firestoreboot.Set(ctx, doc, &testDoc{Name: "test", Count: 3}))
+
+	result, err := firestoreboot.Get[testDoc](ctx, doc)
+	assert.Nil(t, err)
+	assert.Equal(t, &testDoc{Name: "test", Count: 3}, result)
+
+	all, err := firestoreboot.GetAll[testDoc](ctx, s.Collection("tests").Where("name", "==", "test"))
+	assert.Nil(t, err)
+	assert.Len(t, all, 1)
+
+	assert.Nil(t, s.HealthCheck(ctx))
+	assert.Nil(t, s.Close())
+}
+
+func TestFirestore_GetNotFound(t *testing.T) {
+	s := newFirestoreEmulatorService(t)
+
+	_, err := firestoreboot.Get[testDoc](context.Background(), s.Collection("tests").Doc("unknown"))
+	assert.ErrorIs(t, err, firestoreboot.ErrNotFound)
+	assert.Nil(t, s.Close())
+}
@@ -0,0 +1,27 @@
+package goboot
+
+import "context"
+
+// HealthChecker is implemented by services that report their health, e.g. to
+// fail readiness probes while a database is unavailable.
+type HealthChecker interface {
+	// HealthCheck returns an error when the service is unhealthy.
+	HealthCheck(ctx context.Context) error
+}
+
+// HealthCheck runs the health check of all services implementing HealthChecker
+// and returns the errors of unhealthy services by service name. Returns an
+// empty map when all services are healthy.
+func (ctx *AppEnv) HealthCheck(c context.Context) map[string]error {
+	result := make(map[string]error)
+
+	for _, service := range ctx.Services {
+		if checker, ok := service.(HealthChecker); ok {
+			if err := checker.HealthCheck(c); err != nil {
+				result[service.Name()] = err
+			}
+		}
+	}
+
+	return result
+}
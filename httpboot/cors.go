@@ -0,0 +1,144 @@
+package httpboot
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var errCORSCredentials = errors.New("config \"http.cors.allowCredentials\" requires explicit allowedOrigins instead of \"*\"")
+
+type CORSConfig struct {
+	// Origins allowed to make cross-origin requests, e.g.
+	// "https://example.com", or "*" to allow all origins.
+	AllowedOrigins []string `yaml:"allowedOrigins"`
+
+	// Default is GET, HEAD, POST, PUT, PATCH and DELETE.
+	AllowedMethods []string `yaml:"allowedMethods"`
+
+	// Request headers allowed in cross-origin requests. Default is
+	// Content-Type, Authorization and X-Request-ID.
+	AllowedHeaders []string `yaml:"allowedHeaders"`
+
+	// Response headers readable by clients, e.g. "X-Request-ID".
+	ExposedHeaders []string `yaml:"exposedHeaders"`
+
+	// AllowCredentials allows cookies and authorization headers, it requires
+	// explicit AllowedOrigins.
+	AllowCredentials bool `yaml:"allowCredentials"`
+
+	// Time browsers cache preflight responses. Default is 10 minutes.
+	MaxAge time.Duration `yaml:"maxAge"`
+}
+
+func (c *CORSConfig) setDefaults() {
+	if len(c.AllowedMethods) == 0 {
+		c.AllowedMethods = []string{
+			http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete,
+		}
+	}
+
+	if len(c.AllowedHeaders) == 0 {
+		c.AllowedHeaders = []string{"Content-Type", "Authorization", HeaderRequestID}
+	}
+
+	if c.MaxAge == 0 {
+		c.MaxAge = 10 * time.Minute //nolint:gomnd
+	}
+}
+
+// validate rejects allowing credentials of all origins, which would allow
+// any website to make requests using the cookies of the user.
+func (c *CORSConfig) validate() error {
+	if !c.AllowCredentials {
+		return nil
+	}
+
+	for _, origin := range c.AllowedOrigins {
+		if origin == "*" {
+			return errCORSCredentials
+		}
+	}
+
+	return nil
+}
+
+// CORS adds the CORS headers of allowed origins to responses and responds to
+// preflight requests, see
+// https://developer.mozilla.org/en-US/docs/Web/HTTP/CORS. Requests of other
+// origins are handled without CORS headers, so browsers block them.
+//
+// AllowCredentials is ignored when all origins are allowed.
+func CORS(config CORSConfig) Func {
+	config.setDefaults()
+
+	allowAll := false
+	origins := make(map[string]bool, len(config.AllowedOrigins))
+
+	for _, origin := range config.AllowedOrigins {
+		allowAll = allowAll || origin == "*"
+		origins[origin] = true
+	}
+
+	methods := strings.Join(config.AllowedMethods, ", ")
+	headers := strings.Join(config.AllowedHeaders, ", ")
+	exposed := strings.Join(config.ExposedHeaders, ", ")
+	maxAge := strconv.Itoa(int(config.MaxAge.Seconds()))
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			origin := r.Header.Get("Origin")
+			if origin == "" {
+				next.ServeHTTP(w, r)
+
+				return
+			}
+
+			h := w.Header()
+			h.Add("Vary", "Origin")
+
+			preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+
+			if !allowAll && !origins[origin] {
+				if preflight {
+					w.WriteHeader(http.StatusNoContent)
+
+					return
+				}
+
+				next.ServeHTTP(w, r)
+
+				return
+			}
+
+			if allowAll {
+				h.Set("Access-Control-Allow-Origin", "*")
+			} else {
+				h.Set("Access-Control-Allow-Origin", origin)
+
+				if config.AllowCredentials {
+					h.Set("Access-Control-Allow-Credentials", "true")
+				}
+			}
+
+			if !preflight {
+				if exposed != "" {
+					h.Set("Access-Control-Expose-Headers", exposed)
+				}
+
+				next.ServeHTTP(w, r)
+
+				return
+			}
+
+			h.Add("Vary", "Access-Control-Request-Method")
+			h.Add("Vary", "Access-Control-Request-Headers")
+			h.Set("Access-Control-Allow-Methods", methods)
+			h.Set("Access-Control-Allow-Headers", headers)
+			h.Set("Access-Control-Max-Age", maxAge)
+			w.WriteHeader(http.StatusNoContent)
+		})
+	}
+}
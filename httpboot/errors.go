@@ -0,0 +1,167 @@
+// Package httpboot contains helpers and middleware for HTTP services built
+// on net/http.
+package httpboot
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/nielskrijger/goboot/validate"
+	"github.com/rs/zerolog"
+)
+
+// ErrNotFound is responded as 404 Not Found by WriteError, wrap it to add
+// details, e.g. fmt.Errorf("user %q: %w", id, httpboot.ErrNotFound).
+var ErrNotFound = errors.New("not found")
+
+var (
+	statusesMu sync.RWMutex
+
+	// statuses are the HTTP statuses of errors, see RegisterStatus
+	statuses = []errorStatus{{err: ErrNotFound, status: http.StatusNotFound}}
+)
+
+type errorStatus struct {
+	err    error
+	status int
+}
+
+// RegisterStatus responds errors matching target with status, e.g.
+// RegisterStatus(pgboot.ErrNotFound, http.StatusNotFound) or
+// RegisterStatus(errEmailTaken, http.StatusConflict). The error message is
+// used as detail of the response, so only register errors whose message is
+// safe to show to clients.
+func RegisterStatus(target error, status int) {
+	statusesMu.Lock()
+	defer statusesMu.Unlock()
+
+	statuses = append(statuses, errorStatus{err: target, status: status})
+}
+
+// HandlerFunc is an http.HandlerFunc returning an error, which is responded
+// using WriteError, e.g.:
+//
+//	mux.Handle("/users/", httpboot.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+//		user, err := users.Get(r.Context(), id)
+//		if err != nil {
+//			return err
+//		}
+//		...
+//	}))
+type HandlerFunc func(w http.ResponseWriter, r *http.Request) error
+
+func (f HandlerFunc) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if err := f(w, r); err != nil {
+		WriteError(w, r, err)
+	}
+}
+
+// WriteError writes err as RFC 7807 problem, see ToProblem.
+func WriteError(w http.ResponseWriter, r *http.Request, err error) {
+	ToProblem(r, err).Write(w)
+}
+
+// ToProblem returns the problem response of err, e.g. for the error
+// presenter of a GraphQL server:
+//
+//   - a validate.ValidationResult or validate.FieldError is a 400 Bad Request
+//     listing the invalid fields;
+//   - an *http.MaxBytesError of reading a body limited by MaxBodySize is a
+//     413 Request Entity Too Large;
+//   - ErrNotFound is a 404 Not Found, see RegisterStatus for other errors;
+//   - other errors are a 500 Internal Server Error, which are logged with
+//     the request ID that is returned as correlation ID. The error is logged
+//     using the logger of the request context, see zerolog.Ctx.
+func ToProblem(r *http.Request, err error) *validate.Problem {
+	if result := validationResult(err); result != nil {
+		return validate.ToProblem(result)
+	}
+
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return &validate.Problem{
+			Type:   "about:blank",
+			Title:  http.StatusText(http.StatusRequestEntityTooLarge),
+			Status: http.StatusRequestEntityTooLarge,
+			Detail: fmt.Sprintf("The request body exceeds the maximum size of %d bytes.", maxBytesErr.Limit),
+		}
+	}
+
+	if status, ok := lookupStatus(err); ok {
+		return &validate.Problem{
+			Type:   "about:blank",
+			Title:  http.StatusText(status),
+			Status: status,
+			Detail: err.Error(),
+		}
+	}
+
+	id := correlationID(r)
+
+	zerolog.Ctx(r.Context()).Error().Err(err).
+		Str("correlationId", id).
+		Str("method", r.Method).
+		Str("path", r.URL.Path).
+		Msg("internal server error")
+
+	return internalError(id)
+}
+
+// correlationID returns the request ID of r, or a new ID if it has none.
+func correlationID(r *http.Request) string {
+	if id := RequestID(r.Context()); id != "" {
+		return id
+	}
+
+	if id := r.Header.Get(HeaderRequestID); id != "" {
+		return id
+	}
+
+	return newRequestID()
+}
+
+func internalError(correlationID string) *validate.Problem {
+	return &validate.Problem{
+		Type:          "about:blank",
+		Title:         http.StatusText(http.StatusInternalServerError),
+		Status:        http.StatusInternalServerError,
+		CorrelationID: correlationID,
+	}
+}
+
+// validationResult returns the validation errors of err, or nil if err is
+// no validation error.
+func validationResult(err error) *validate.ValidationResult {
+	var (
+		result   *validate.ValidationResult
+		fieldErr validate.FieldError
+		fieldPtr *validate.FieldError
+	)
+
+	switch {
+	case errors.As(err, &result):
+		return result
+	case errors.As(err, &fieldPtr) && fieldPtr != nil:
+		return &validate.ValidationResult{Errors: []validate.FieldError{*fieldPtr}}
+	case errors.As(err, &fieldErr):
+		return &validate.ValidationResult{Errors: []validate.FieldError{fieldErr}}
+	default:
+		return nil
+	}
+}
+
+// lookupStatus returns the status of the last registered error matching err.
+func lookupStatus(err error) (int, bool) {
+	statusesMu.RLock()
+	defer statusesMu.RUnlock()
+
+	for i := len(statuses) - 1; i >= 0; i-- {
+		if errors.Is(err, statuses[i].err) {
+			return statuses[i].status, true
+		}
+	}
+
+	return 0, false
+}
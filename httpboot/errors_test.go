@@ -0,0 +1,101 @@
+package httpboot_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/nielskrijger/goboot/httpboot"
+	"github.com/nielskrijger/goboot/validate"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+)
+
+var errEmailTaken = errors.New("email is already taken")
+
+func init() {
+	httpboot.RegisterStatus(errEmailTaken, http.StatusConflict)
+}
+
+func serve(r *http.Request, err error) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+
+	httpboot.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		return err
+	}).ServeHTTP(rec, r)
+
+	return rec
+}
+
+func TestWriteError_Validation(t *testing.T) {
+	result := validate.NewResult()
+	result.AddError("email", "required", "is required")
+
+	rec := serve(httptest.NewRequest(http.MethodPost, "/users", nil), fmt.Errorf("creating user: %w", result))
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Equal(t, "application/problem+json", rec.Header().Get("Content-Type"))
+	assert.Contains(t, rec.Body.String(), `"errors":[{"field":"email","code":"required","message":"is required"}]`)
+
+	rec = serve(httptest.NewRequest(http.MethodPost, "/users", nil), validate.NewError("email", validate.CodeRequired, nil))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"field":"email"`)
+}
+
+func TestWriteError_RegisteredStatuses(t *testing.T) {
+	rec := serve(httptest.NewRequest(http.MethodGet, "/users/1", nil), fmt.Errorf("user %q: %w", "1", httpboot.ErrNotFound))
+
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.JSONEq(t, `{"type":"about:blank","title":"Not Found","status":404,"detail":"user \"1\": not found"}`, rec.Body.String())
+
+	rec = serve(httptest.NewRequest(http.MethodPost, "/users", nil), errEmailTaken)
+	assert.Equal(t, http.StatusConflict, rec.Code)
+}
+
+func TestWriteError_InternalServerError(t *testing.T) {
+	var logs bytes.Buffer
+
+	log := zerolog.New(&logs)
+	req := httptest.NewRequest(http.MethodGet, "/users", nil)
+	req.Header.Set(httpboot.HeaderRequestID, "abc123")
+	req = req.WithContext(log.WithContext(req.Context()))
+
+	rec := serve(req, errors.New("connection refused"))
+
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.JSONEq(t, `{
+		"type": "about:blank",
+		"title": "Internal Server Error",
+		"status": 500,
+		"correlationId": "abc123"
+	}`, rec.Body.String())
+	assert.Contains(t, logs.String(), `"correlationId":"abc123"`)
+	assert.Contains(t, logs.String(), `"error":"connection refused"`)
+}
+
+func TestWriteError_GeneratesCorrelationID(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	problem := httpboot.ToProblem(req, errors.New("boom"))
+
+	assert.Len(t, problem.CorrelationID, 32)
+
+	req = req.WithContext(httpboot.WithRequestID(req.Context(), "from-context"))
+	assert.Equal(t, "from-context", httpboot.ToProblem(req, errors.New("boom")).CorrelationID)
+}
+
+func TestWriteError_NilFieldError(t *testing.T) {
+	var logs bytes.Buffer
+
+	// a nil *FieldError returned as error isn't a validation error
+	var fieldErr *validate.FieldError
+
+	req := httptest.NewRequest(http.MethodPost, "/users", nil)
+	req = req.WithContext(zerolog.New(&logs).WithContext(req.Context()))
+	rec := serve(req, fieldErr)
+
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.Contains(t, logs.String(), "internal server error")
+}
@@ -0,0 +1,132 @@
+package httpboot
+
+import (
+	"compress/gzip"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+var gzipWriters = sync.Pool{
+	New: func() any { return gzip.NewWriter(nil) },
+}
+
+// Gzip compresses responses of clients accepting gzip, except responses
+// without body or with a Content-Encoding already. Upgrade requests like
+// websockets aren't compressed.
+func Gzip() Func {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !acceptsGzip(r) || r.Header.Get("Upgrade") != "" {
+				next.ServeHTTP(w, r)
+
+				return
+			}
+
+			w.Header().Add("Vary", "Accept-Encoding")
+
+			gw := &gzipWriter{ResponseWriter: w}
+			next.ServeHTTP(gw, r)
+
+			// not deferred, closing while a panic unwinds would write a 200
+			// header before Recover responds the panic
+			gw.close()
+		})
+	}
+}
+
+func acceptsGzip(r *http.Request) bool {
+	for _, enc := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(enc), ";")
+		if strings.EqualFold(strings.TrimSpace(name), "gzip") && strings.ReplaceAll(params, " ", "") != "q=0" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// gzipWriter starts compressing on the first write, so the status and
+// headers can be checked.
+type gzipWriter struct {
+	http.ResponseWriter
+	gz          *gzip.Writer
+	status      int
+	wroteHeader bool
+}
+
+func (w *gzipWriter) WriteHeader(status int) {
+	if !w.wroteHeader && w.status == 0 {
+		w.status = status
+	}
+}
+
+func (w *gzipWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.start(b)
+	}
+
+	if w.gz != nil {
+		return w.gz.Write(b) //nolint:wrapcheck
+	}
+
+	return w.ResponseWriter.Write(b) //nolint:wrapcheck
+}
+
+// start writes the header and starts compressing if the response has a body
+// that isn't encoded already.
+func (w *gzipWriter) start(b []byte) {
+	w.wroteHeader = true
+
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+
+	h := w.Header()
+
+	if len(b) > 0 && h.Get("Content-Encoding") == "" &&
+		w.status != http.StatusNoContent && w.status != http.StatusNotModified {
+		if h.Get("Content-Type") == "" {
+			// otherwise the compressed content is sniffed
+			h.Set("Content-Type", http.DetectContentType(b))
+		}
+
+		h.Set("Content-Encoding", "gzip")
+		h.Del("Content-Length")
+
+		w.gz, _ = gzipWriters.Get().(*gzip.Writer)
+		w.gz.Reset(w.ResponseWriter)
+	}
+
+	w.ResponseWriter.WriteHeader(w.status)
+}
+
+func (w *gzipWriter) Flush() {
+	if !w.wroteHeader {
+		w.start(nil)
+	}
+
+	if w.gz != nil {
+		_ = w.gz.Flush()
+	}
+
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *gzipWriter) close() {
+	if !w.wroteHeader {
+		w.start(nil)
+	}
+
+	if w.gz != nil {
+		_ = w.gz.Close()
+		gzipWriters.Put(w.gz)
+	}
+}
+
+// Unwrap supports http.ResponseController.
+func (w *gzipWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
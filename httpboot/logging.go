@@ -0,0 +1,113 @@
+package httpboot
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// Logging logs requests after they're handled. The request ID of the
+// X-Request-ID header, or a new one, is added to the response headers and to
+// the request context along with a logger including it, see zerolog.Ctx.
+// Requests of skipPaths aren't logged.
+func Logging(log zerolog.Logger, skipPaths ...string) Func {
+	skip := make(map[string]bool, len(skipPaths))
+	for _, path := range skipPaths {
+		skip[path] = true
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+
+			id := r.Header.Get(HeaderRequestID)
+			if id == "" || len(id) > 128 { //nolint:gomnd
+				id = newRequestID()
+			}
+
+			w.Header().Set(HeaderRequestID, id)
+
+			reqLog := log.With().Str("requestId", id).Logger()
+			ctx := reqLog.WithContext(WithRequestID(r.Context(), id))
+
+			sw := &statusWriter{ResponseWriter: w}
+			next.ServeHTTP(sw, r.WithContext(ctx))
+
+			if skip[r.URL.Path] {
+				return
+			}
+
+			status := sw.status
+			if status == 0 {
+				status = http.StatusOK
+			}
+
+			event := reqLog.Info()
+			if status >= http.StatusInternalServerError {
+				event = reqLog.Error()
+			}
+
+			event.Str("method", r.Method).
+				Str("path", r.URL.Path).
+				Int("status", status).
+				Int64("size", sw.size).
+				Dur("duration", time.Since(start)).
+				Str("remoteAddr", r.RemoteAddr).
+				Str("userAgent", r.UserAgent()).
+				Msg("handled request")
+		})
+	}
+}
+
+// statusWriter records the status and size of a response.
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+	size   int64
+}
+
+func (w *statusWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *statusWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+
+	n, err := w.ResponseWriter.Write(b)
+	w.size += int64(n)
+
+	return n, err //nolint:wrapcheck
+}
+
+func (w *statusWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack supports websocket connections.
+func (w *statusWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("%T doesn't support hijacking", w.ResponseWriter) //nolint:goerr113
+	}
+
+	w.status = http.StatusSwitchingProtocols
+
+	return h.Hijack() //nolint:wrapcheck
+}
+
+// Unwrap supports http.ResponseController.
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
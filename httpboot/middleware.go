@@ -0,0 +1,182 @@
+package httpboot
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/nielskrijger/goboot"
+	"github.com/rs/zerolog"
+)
+
+const defaultMaxBodySize = 1 << 20 // 1 MiB
+
+// Func wraps an http.Handler, e.g. to log requests.
+type Func func(next http.Handler) http.Handler
+
+// Chain returns middleware applying middlewares in order, the first is the
+// outermost.
+func Chain(middlewares ...Func) Func {
+	return func(next http.Handler) http.Handler {
+		for i := len(middlewares) - 1; i >= 0; i-- {
+			next = middlewares[i](next)
+		}
+
+		return next
+	}
+}
+
+type HTTPConfig struct {
+	// Maximum size of request bodies in bytes, -1 disables the limit.
+	// Default is 1 MiB.
+	MaxBodySize int64 `yaml:"maxBodySize"`
+
+	// Timeout of requests, 0 disables the timeout. Default is 0.
+	RequestTimeout time.Duration `yaml:"requestTimeout"`
+
+	// Timeouts of routes by path prefix, e.g. "/reports/", overriding
+	// RequestTimeout. The longest matching prefix is used.
+	RouteTimeouts []RouteTimeout `yaml:"routeTimeouts"`
+
+	// Gzip compresses responses of clients accepting gzip.
+	Gzip bool `yaml:"gzip"`
+
+	// Paths of requests that aren't logged, e.g. "/healthz".
+	LogSkipPaths []string `yaml:"logSkipPaths"`
+
+	// CORS is disabled unless AllowedOrigins is set.
+	CORS CORSConfig `yaml:"cors"`
+}
+
+type RouteTimeout struct {
+	Path    string        `yaml:"path"`
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+// Middleware implements the AppService interface and wraps handlers with
+// the middleware configured in "http":
+//
+//	http:
+//	  maxBodySize: 1048576
+//	  requestTimeout: 30s
+//	  routeTimeouts:
+//	    - path: /reports/
+//	      timeout: 2m
+//	  gzip: true
+//	  logSkipPaths: [/healthz]
+//	  cors:
+//	    allowedOrigins: [https://example.com]
+//
+// See Handler for the order in which they're applied.
+type Middleware struct {
+	config *HTTPConfig
+	log    zerolog.Logger
+}
+
+func (m *Middleware) Name() string {
+	return "HTTP middleware"
+}
+
+// Configure loads the optional "http" configuration.
+func (m *Middleware) Configure(env *goboot.AppEnv) error {
+	m.log = env.Log
+	m.config = &HTTPConfig{}
+
+	if env.Config.InConfig("http") {
+		if err := env.Config.Sub("http").Unmarshal(m.config); err != nil {
+			return fmt.Errorf("parsing http configuration: %w", err)
+		}
+	}
+
+	if m.config.MaxBodySize == 0 {
+		m.config.MaxBodySize = defaultMaxBodySize
+	}
+
+	m.config.CORS.setDefaults()
+
+	return m.config.CORS.validate()
+}
+
+func (m *Middleware) Init() error {
+	return nil
+}
+
+func (m *Middleware) Close() error {
+	return nil
+}
+
+// Handler wraps next with the configured middleware, from outer to inner:
+// Logging, Recover, CORS, MaxBodySize, Timeout and Gzip.
+func (m *Middleware) Handler(next http.Handler) http.Handler {
+	middlewares := []Func{Logging(m.log, m.config.LogSkipPaths...), Recover()}
+
+	if len(m.config.CORS.AllowedOrigins) > 0 {
+		middlewares = append(middlewares, CORS(m.config.CORS))
+	}
+
+	if m.config.MaxBodySize > 0 {
+		middlewares = append(middlewares, MaxBodySize(m.config.MaxBodySize))
+	}
+
+	if m.config.RequestTimeout > 0 || len(m.config.RouteTimeouts) > 0 {
+		middlewares = append(middlewares, RouteTimeouts(m.config.RequestTimeout, m.config.RouteTimeouts...))
+	}
+
+	if m.config.Gzip {
+		middlewares = append(middlewares, Gzip())
+	}
+
+	return Chain(middlewares...)(next)
+}
+
+// MaxBodySize limits request bodies to n bytes. Requests with a larger
+// Content-Length are rejected with 413 Request Entity Too Large, reading more
+// of other requests fails with an *http.MaxBytesError.
+func MaxBodySize(n int64) Func {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.ContentLength > n {
+				WriteError(w, r, &http.MaxBytesError{Limit: n})
+
+				return
+			}
+
+			if r.Body != nil {
+				r.Body = http.MaxBytesReader(w, r.Body, n)
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RouteTimeouts sets the deadline of request contexts using the timeout of
+// the longest matching route path prefix, or timeout if no route matches.
+// Handlers must stop when the context is done, which doesn't interrupt
+// streaming responses like http.TimeoutHandler does.
+func RouteTimeouts(timeout time.Duration, routes ...RouteTimeout) Func {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			d := timeout
+			longest := -1
+
+			for _, route := range routes {
+				if strings.HasPrefix(r.URL.Path, route.Path) && len(route.Path) > longest {
+					d = route.Timeout
+					longest = len(route.Path)
+				}
+			}
+
+			if d > 0 {
+				ctx, cancel := context.WithTimeout(r.Context(), d)
+				defer cancel()
+
+				r = r.WithContext(ctx)
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,295 @@
+package httpboot_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nielskrijger/goboot"
+	"github.com/nielskrijger/goboot/httpboot"
+	"github.com/nielskrijger/goboot/validate"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+)
+
+func newMiddleware(t *testing.T, logs io.Writer, next http.Handler) http.Handler {
+	t.Helper()
+
+	env := goboot.NewAppEnv("./testdata", "")
+	env.Log = zerolog.New(logs)
+
+	m := &httpboot.Middleware{}
+	assert.Nil(t, m.Configure(env))
+	assert.Nil(t, m.Init())
+
+	t.Cleanup(func() { _ = m.Close() })
+
+	return m.Handler(next)
+}
+
+func TestMiddleware_Logging(t *testing.T) {
+	var logs bytes.Buffer
+
+	var requestID string
+
+	h := newMiddleware(t, &logs, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID = httpboot.RequestID(r.Context())
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte("ok"))
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/users", nil)
+	req.Header.Set(httpboot.HeaderRequestID, "abc123")
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	assert.Equal(t, "abc123", requestID)
+	assert.Equal(t, "abc123", rec.Header().Get(httpboot.HeaderRequestID))
+	assert.Contains(t, logs.String(), `"level":"info","requestId":"abc123","method":"POST","path":"/users","status":201,"size":2`)
+	assert.Contains(t, logs.String(), `"message":"handled request"`)
+
+	logs.Reset()
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	assert.Empty(t, logs.String())
+}
+
+func TestMiddleware_Recover(t *testing.T) {
+	var logs bytes.Buffer
+
+	h := newMiddleware(t, &logs, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	id := rec.Header().Get(httpboot.HeaderRequestID)
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"correlationId":"`+id+`"`)
+	assert.Contains(t, logs.String(), `"message":"recovered from panic: boom"`)
+	assert.Contains(t, logs.String(), `"stack":"goroutine`)
+	assert.Contains(t, logs.String(), `"level":"error","requestId":"`+id+`","method":"GET","path":"/","status":500`)
+}
+
+func TestMiddleware_RecoverGzip(t *testing.T) {
+	h := newMiddleware(t, io.Discard, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.Equal(t, "application/problem+json", rec.Header().Get("Content-Type"))
+	assert.Empty(t, rec.Header().Get("Content-Encoding"))
+	assert.Contains(t, rec.Body.String(), `"status":500`)
+}
+
+func TestRecover_AbortsWrittenResponse(t *testing.T) {
+	h := httpboot.Recover()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("partial"))
+
+		panic("boom")
+	}))
+
+	assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	})
+}
+
+func TestMiddleware_CORS(t *testing.T) {
+	called := false
+	h := newMiddleware(t, io.Discard, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+
+	req := httptest.NewRequest(http.MethodOptions, "/users", nil)
+	req.Header.Set("Origin", "https://example.com")
+	req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	assert.False(t, called)
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Equal(t, "https://example.com", rec.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "true", rec.Header().Get("Access-Control-Allow-Credentials"))
+	assert.Equal(t, "GET, HEAD, POST, PUT, PATCH, DELETE", rec.Header().Get("Access-Control-Allow-Methods"))
+	assert.Equal(t, "Content-Type, Authorization, X-Request-ID", rec.Header().Get("Access-Control-Allow-Headers"))
+	assert.Equal(t, "600", rec.Header().Get("Access-Control-Max-Age"))
+
+	req = httptest.NewRequest(http.MethodGet, "/users", nil)
+	req.Header.Set("Origin", "https://example.com")
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	assert.True(t, called)
+	assert.Equal(t, "https://example.com", rec.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "X-Request-ID", rec.Header().Get("Access-Control-Expose-Headers"))
+
+	req = httptest.NewRequest(http.MethodGet, "/users", nil)
+	req.Header.Set("Origin", "https://evil.com")
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	assert.Empty(t, rec.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, []string{"Origin"}, rec.Header().Values("Vary"))
+}
+
+func TestCORS_AllowAll(t *testing.T) {
+	h := httpboot.CORS(httpboot.CORSConfig{AllowedOrigins: []string{"*"}})(http.NotFoundHandler())
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Origin", "https://example.com")
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	assert.Equal(t, "*", rec.Header().Get("Access-Control-Allow-Origin"))
+	assert.Empty(t, rec.Header().Get("Access-Control-Allow-Credentials"))
+}
+
+func TestCORS_IgnoresCredentialsOfAllOrigins(t *testing.T) {
+	h := httpboot.CORS(httpboot.CORSConfig{
+		AllowedOrigins:   []string{"*"},
+		AllowCredentials: true,
+	})(http.NotFoundHandler())
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Origin", "https://evil.example.com")
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	assert.Equal(t, "*", rec.Header().Get("Access-Control-Allow-Origin"))
+	assert.Empty(t, rec.Header().Get("Access-Control-Allow-Credentials"))
+}
+
+func TestMiddleware_ErrorCORSCredentialsOfAllOrigins(t *testing.T) {
+	env := goboot.NewAppEnv("./testdata", "")
+	env.Config.Set("http.cors.allowedOrigins", []string{"*"})
+
+	m := &httpboot.Middleware{}
+	err := m.Configure(env)
+	assert.EqualError(t, err, `config "http.cors.allowCredentials" requires explicit allowedOrigins instead of "*"`)
+}
+
+func TestMiddleware_Gzip(t *testing.T) {
+	body := strings.Repeat("hello ", 100)
+
+	h := newMiddleware(t, io.Discard, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/empty" {
+			w.WriteHeader(http.StatusNoContent)
+
+			return
+		}
+
+		w.Header().Set("Content-Length", "600")
+		_, _ = w.Write([]byte(body))
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept-Encoding", "br, gzip;q=0.8")
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	assert.Equal(t, "gzip", rec.Header().Get("Content-Encoding"))
+	assert.Equal(t, "text/plain; charset=utf-8", rec.Header().Get("Content-Type"))
+	assert.Empty(t, rec.Header().Get("Content-Length"))
+
+	gz, err := gzip.NewReader(rec.Body)
+	assert.Nil(t, err)
+
+	b, err := io.ReadAll(gz)
+	assert.Nil(t, err)
+	assert.Equal(t, body, string(b))
+
+	req = httptest.NewRequest(http.MethodGet, "/empty", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Empty(t, rec.Header().Get("Content-Encoding"))
+	assert.Zero(t, rec.Body.Len())
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.Empty(t, rec.Header().Get("Content-Encoding"))
+	assert.Equal(t, body, rec.Body.String())
+}
+
+type createUserRequest struct {
+	Name string `json:"name"`
+}
+
+func TestMiddleware_MaxBodySize(t *testing.T) {
+	h := newMiddleware(t, io.Discard, validate.JSON(func(w http.ResponseWriter, r *http.Request, req createUserRequest) {
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(`{"name":"John"}`)))
+	assert.Equal(t, http.StatusCreated, rec.Code)
+
+	// exceeding Content-Length
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(`{"name":"John Doe"}`)))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"detail":"The request body exceeds the maximum size of 16 bytes."`)
+
+	// unknown length
+	req := httptest.NewRequest(http.MethodPost, "/users", io.MultiReader(strings.NewReader(`{"name":"John Doe"}`)))
+	req.ContentLength = -1
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"detail":"The request body is too large."`)
+}
+
+func TestMiddleware_RouteTimeouts(t *testing.T) {
+	var deadline time.Time
+
+	h := newMiddleware(t, io.Discard, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		deadline, _ = r.Context().Deadline()
+	}))
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users", nil))
+	assert.WithinDuration(t, time.Now().Add(time.Second), deadline, 100*time.Millisecond)
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/reports/monthly", nil))
+	assert.WithinDuration(t, time.Now().Add(time.Minute), deadline, 100*time.Millisecond)
+}
+
+func TestChain(t *testing.T) {
+	var order []string
+
+	mw := func(name string) httpboot.Func {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				order = append(order, name)
+				next.ServeHTTP(w, r)
+			})
+		}
+	}
+
+	httpboot.Chain(mw("a"), mw("b"))(http.NotFoundHandler()).
+		ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.Equal(t, []string{"a", "b"}, order)
+}
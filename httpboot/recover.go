@@ -0,0 +1,46 @@
+package httpboot
+
+import (
+	"net/http"
+	"runtime/debug"
+
+	"github.com/rs/zerolog"
+)
+
+// Recover responds panics of handlers as 500 Internal Server Error and logs
+// them with their stack trace. Panics with http.ErrAbortHandler abort the
+// response as usual, as do panics after the response headers were written.
+func Recover() Func {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			sw := &statusWriter{ResponseWriter: w}
+
+			defer func() {
+				p := recover()
+				if p == nil {
+					return
+				}
+
+				if p == http.ErrAbortHandler { //nolint:errorlint,goerr113
+					panic(p)
+				}
+
+				id := correlationID(r)
+
+				zerolog.Ctx(r.Context()).Error().
+					Str("correlationId", id).
+					Str("stack", string(debug.Stack())).
+					Msgf("recovered from panic: %v", p)
+
+				if sw.status != 0 {
+					// the client mustn't receive the partial response as if it succeeded
+					panic(http.ErrAbortHandler)
+				}
+
+				internalError(id).Write(w)
+			}()
+
+			next.ServeHTTP(sw, r)
+		})
+	}
+}
@@ -0,0 +1,33 @@
+package httpboot
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+)
+
+// HeaderRequestID identifies a request across services.
+const HeaderRequestID = "X-Request-ID"
+
+const requestIDBytes = 16
+
+type requestIDKey struct{}
+
+// WithRequestID returns a context with the ID of the request.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID returns the ID of the request of ctx, or "" if it has none.
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+
+	return id
+}
+
+func newRequestID() string {
+	b := make([]byte, requestIDBytes)
+	_, _ = rand.Read(b)
+
+	return hex.EncodeToString(b)
+}
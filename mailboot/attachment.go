@@ -0,0 +1,205 @@
+package mailboot
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/textproto"
+	"path/filepath"
+	"strings"
+)
+
+const defaultMaxAttachmentSize = 10 << 20
+
+// base64LineLength is the maximum line length of base64 encoded content, see RFC 2045.
+const base64LineLength = 76
+
+var (
+	errAttachmentTooLarge  = errors.New("email attachments exceed the maximum size")
+	errMissingAttachReader = errors.New("email attachment requires a reader")
+	errInvalidContentID    = errors.New("email attachment content id must not contain line breaks or angle brackets")
+)
+
+// Attachment is a file attached to a message. Set ContentID to embed an
+// image in the HTML body, e.g. <img src="cid:logo"> for ContentID "logo".
+type Attachment struct {
+	Filename string
+
+	// ContentType defaults to the type of the Filename extension, e.g.
+	// "image/png", or "application/octet-stream" if unknown.
+	ContentType string
+
+	// Reader is read when sending the message. Readers implementing
+	// io.Seeker like *os.File are streamed and rewound when retrying, other
+	// readers are read into memory once.
+	Reader io.Reader
+
+	ContentID string
+}
+
+// prepareAttachments validates the attachments don't exceed maxSize in total
+// and returns copies that are re-readable for retries, the attachments
+// themselves are left unchanged.
+func prepareAttachments(attachments []*Attachment, maxSize int64) ([]*Attachment, error) {
+	var total int64
+
+	prepared := make([]*Attachment, len(attachments))
+
+	for i, a := range attachments {
+		if a.Reader == nil {
+			return nil, fmt.Errorf("%w: %q", errMissingAttachReader, a.Filename)
+		}
+
+		if strings.ContainsAny(a.ContentID, "\r\n<>") {
+			return nil, fmt.Errorf("%w: %q", errInvalidContentID, a.ContentID)
+		}
+
+		r, size, err := a.prepare(maxSize - total)
+		if err != nil {
+			return nil, err
+		}
+
+		total += size
+		if total > maxSize {
+			return nil, fmt.Errorf("%w of %d bytes", errAttachmentTooLarge, maxSize)
+		}
+
+		c := *a
+		c.Reader = r
+		prepared[i] = &c
+	}
+
+	return prepared, nil
+}
+
+// prepare returns a re-readable reader and the size of the attachment,
+// reading at most limit+1 bytes into memory if the reader isn't an io.Seeker.
+func (a *Attachment) prepare(limit int64) (io.Reader, int64, error) {
+	if s, ok := a.Reader.(io.Seeker); ok {
+		size, err := s.Seek(0, io.SeekEnd)
+		if err != nil {
+			return nil, 0, fmt.Errorf("reading attachment %q: %w", a.Filename, err)
+		}
+
+		if _, err := s.Seek(0, io.SeekStart); err != nil {
+			return nil, 0, fmt.Errorf("reading attachment %q: %w", a.Filename, err)
+		}
+
+		return a.Reader, size, nil
+	}
+
+	b, err := io.ReadAll(io.LimitReader(a.Reader, limit+1))
+	if err != nil {
+		return nil, 0, fmt.Errorf("reading attachment %q: %w", a.Filename, err)
+	}
+
+	return bytes.NewReader(b), int64(len(b)), nil
+}
+
+// open returns the reader rewound to the start.
+func (a *Attachment) open() (io.Reader, error) {
+	if s, ok := a.Reader.(io.Seeker); ok {
+		if _, err := s.Seek(0, io.SeekStart); err != nil {
+			return nil, fmt.Errorf("reading attachment %q: %w", a.Filename, err)
+		}
+	}
+
+	return a.Reader, nil
+}
+
+func (a *Attachment) contentType() string {
+	if a.ContentType != "" {
+		return a.ContentType
+	}
+
+	if t := mime.TypeByExtension(filepath.Ext(a.Filename)); t != "" {
+		return t
+	}
+
+	return "application/octet-stream"
+}
+
+func (a *Attachment) disposition() string {
+	if a.ContentID != "" {
+		return "inline"
+	}
+
+	return "attachment"
+}
+
+func (a *Attachment) part() *mimePart {
+	var name, filename map[string]string
+	if a.Filename != "" {
+		name, filename = map[string]string{"name": a.Filename}, map[string]string{"filename": a.Filename}
+	}
+
+	header := textproto.MIMEHeader{
+		"Content-Type":              {mime.FormatMediaType(a.contentType(), name)},
+		"Content-Disposition":       {mime.FormatMediaType(a.disposition(), filename)},
+		"Content-Transfer-Encoding": {"base64"},
+	}
+
+	if a.ContentID != "" {
+		header.Set("Content-Id", "<"+a.ContentID+">")
+	}
+
+	return &mimePart{
+		header: header,
+		content: func(w io.Writer) error {
+			r, err := a.open()
+			if err != nil {
+				return err
+			}
+
+			enc := base64.NewEncoder(base64.StdEncoding, &lineWriter{w: w})
+
+			if _, err := io.Copy(enc, r); err != nil {
+				return fmt.Errorf("writing attachment %q: %w", a.Filename, err)
+			}
+
+			if err := enc.Close(); err != nil {
+				return fmt.Errorf("writing attachment %q: %w", a.Filename, err)
+			}
+
+			return nil
+		},
+	}
+}
+
+// lineWriter breaks lines after base64LineLength characters.
+type lineWriter struct {
+	w   io.Writer
+	col int
+}
+
+func (lw *lineWriter) Write(p []byte) (int, error) {
+	n := 0
+
+	for len(p) > 0 {
+		chunk := base64LineLength - lw.col
+		if chunk > len(p) {
+			chunk = len(p)
+		}
+
+		if _, err := lw.w.Write(p[:chunk]); err != nil {
+			return n, err //nolint:wrapcheck
+		}
+
+		n += chunk
+		p = p[chunk:]
+		lw.col += chunk
+
+		if lw.col == base64LineLength {
+			if _, err := lw.w.Write([]byte("\r\n")); err != nil {
+				return n, err //nolint:wrapcheck
+			}
+
+			lw.col = 0
+		}
+	}
+
+	return n, nil
+}
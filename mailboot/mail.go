@@ -0,0 +1,331 @@
+// Package mailboot sends emails over SMTP or using the API of an email vendor,
+// see Mail.
+package mailboot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/nielskrijger/goboot"
+	"github.com/nielskrijger/goboot/tmplboot"
+	"github.com/rs/zerolog"
+)
+
+const (
+	defaultPort           = 587
+	defaultPoolSize       = 2
+	defaultMaxAttempts    = 3
+	defaultInitialBackoff = time.Second
+	defaultMaxBackoff     = 30 * time.Second
+	defaultTimeout        = 10 * time.Second
+)
+
+// TLS modes of MailConfig.TLS.
+const (
+	TLSStartTLS = "starttls"
+	TLSImplicit = "tls"
+	TLSNone     = "none"
+)
+
+var (
+	errMissingConfig    = errors.New("missing \"mail\" configuration")
+	errMissingHost      = errors.New("config \"mail.host\" is required")
+	errInvalidTLS       = errors.New("config \"mail.tls\" must be \"starttls\", \"tls\" or \"none\"")
+	errMissingTemplates = errors.New("mail templates are not configured")
+	errServiceClosed    = errors.New("mail service has been closed")
+)
+
+type MailConfig struct {
+	// Provider is "smtp", "sendgrid", "mailgun" or "ses". Default is "smtp".
+	Provider string `yaml:"provider"`
+
+	// Host of the SMTP server, required when using SMTP.
+	Host string `yaml:"host"`
+
+	// Port of the SMTP server. Default is 587.
+	Port int `yaml:"port"`
+
+	// Username and Password enable PLAIN authentication, which requires TLS
+	// unless the host is localhost. The password is read from a file,
+	// environment variable or secret manager if prefixed, see
+	// goboot.ResolveSecret.
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+
+	// TLS is "starttls" to upgrade the connection using STARTTLS, "tls" to
+	// connect using TLS, usually on port 465, or "none" for local
+	// development. Default is "starttls".
+	TLS string `yaml:"tls"`
+
+	TLSInsecureSkipVerify bool `yaml:"tlsInsecureSkipVerify"`
+
+	// From is the sender of messages without From, e.g. "App <noreply@example.com>".
+	From string `yaml:"from"`
+
+	// Maximum number of idle connections kept open for reuse. Default is 2.
+	PoolSize int `yaml:"poolSize"`
+
+	// Maximum number of send attempts. Default is 3.
+	MaxAttempts int `yaml:"maxAttempts"`
+
+	// Backoff before the first retry, doubles every attempt. Default is 1 second.
+	InitialBackoff time.Duration `yaml:"initialBackoff"`
+
+	// Maximum backoff between retries. Default is 30 seconds.
+	MaxBackoff time.Duration `yaml:"maxBackoff"`
+
+	// Timeout of connecting and of sending a single message. Default is 10 seconds.
+	Timeout time.Duration `yaml:"timeout"`
+
+	// Maximum total size in bytes of the attachments of a message. Default
+	// is 10 MiB.
+	MaxAttachmentSize int64 `yaml:"maxAttachmentSize"`
+
+	SendGrid SendGridConfig `yaml:"sendgrid"`
+	Mailgun  MailgunConfig  `yaml:"mailgun"`
+	SES      SESConfig      `yaml:"ses"`
+}
+
+// Mail implements the AppService interface. It sends messages using the
+// provider of config "mail.provider" and retries temporary failures with
+// exponential backoff.
+type Mail struct {
+	// Templates renders the emails of SendTemplate, optional.
+	Templates *tmplboot.Loader
+
+	// Provider defaults to the provider of config "mail.provider".
+	Provider Provider
+
+	config  *MailConfig
+	metrics *mailMetrics
+	log     zerolog.Logger
+	ctx     context.Context //nolint:containedctx
+	cancel  context.CancelFunc
+}
+
+// NewMail creates a mail service rendering templates using loader, which
+// may be nil when not using SendTemplate.
+func NewMail(loader *tmplboot.Loader) *Mail {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &Mail{Templates: loader, ctx: ctx, cancel: cancel}
+}
+
+func (s *Mail) Name() string {
+	return "Mail"
+}
+
+func (s *Mail) Configure(env *goboot.AppEnv) error {
+	s.log = env.Log
+	s.config = &MailConfig{}
+
+	if !env.Config.IsSet("mail") {
+		return errMissingConfig
+	}
+
+	if err := env.Config.Sub("mail").Unmarshal(s.config); err != nil {
+		return fmt.Errorf("parsing mail configuration: %w", err)
+	}
+
+	if err := s.setDefaults(); err != nil {
+		return err
+	}
+
+	if err := s.resolveSecrets(); err != nil {
+		return err
+	}
+
+	if s.ctx == nil {
+		s.ctx, s.cancel = context.WithCancel(context.Background())
+	}
+
+	if s.Provider == nil {
+		provider, err := s.newProvider()
+		if err != nil {
+			return err
+		}
+
+		s.Provider = provider
+	}
+
+	return s.registerMetrics(env.Metrics)
+}
+
+func (s *Mail) setDefaults() error {
+	if s.config.Provider == "" {
+		s.config.Provider = ProviderSMTP
+	}
+
+	if s.config.Port == 0 {
+		s.config.Port = defaultPort
+	}
+
+	switch s.config.TLS {
+	case "":
+		s.config.TLS = TLSStartTLS
+	case TLSStartTLS, TLSImplicit, TLSNone:
+	default:
+		return errInvalidTLS
+	}
+
+	if s.config.PoolSize == 0 {
+		s.config.PoolSize = defaultPoolSize
+	}
+
+	if s.config.MaxAttempts == 0 {
+		s.config.MaxAttempts = defaultMaxAttempts
+	}
+
+	if s.config.InitialBackoff == 0 {
+		s.config.InitialBackoff = defaultInitialBackoff
+	}
+
+	if s.config.MaxBackoff == 0 {
+		s.config.MaxBackoff = defaultMaxBackoff
+	}
+
+	if s.config.Timeout == 0 {
+		s.config.Timeout = defaultTimeout
+	}
+
+	if s.config.MaxAttachmentSize == 0 {
+		s.config.MaxAttachmentSize = defaultMaxAttachmentSize
+	}
+
+	return nil
+}
+
+// resolveSecrets resolves the SMTP password and the API keys of the vendors,
+// see goboot.ResolveSecret.
+func (s *Mail) resolveSecrets() error {
+	for key, target := range map[string]*string{
+		"password":        &s.config.Password,
+		"sendgrid.apiKey": &s.config.SendGrid.APIKey,
+		"mailgun.apiKey":  &s.config.Mailgun.APIKey,
+	} {
+		secret, err := goboot.ResolveSecret(*target)
+		if err != nil {
+			return fmt.Errorf("resolving config \"mail.%s\": %w", key, err)
+		}
+
+		*target = secret
+	}
+
+	return nil
+}
+
+func (s *Mail) Init() error {
+	return nil
+}
+
+// Close stops retrying and closes the provider, e.g. idle SMTP connections.
+func (s *Mail) Close() error {
+	s.cancel()
+
+	if closer, ok := s.Provider.(io.Closer); ok {
+		return closer.Close() //nolint:wrapcheck
+	}
+
+	return nil
+}
+
+// Send sends msg and retries temporary failures like network errors, 4xx
+// SMTP replies and rate limiting. Failures after the SMTP server accepted the
+// message data aren't retried to avoid sending the email twice. Closing the
+// service stops any further retries.
+func (s *Mail) Send(ctx context.Context, msg *Message) error {
+	return s.send(ctx, msg, "")
+}
+
+// send sends msg recording the outcome for template.
+func (s *Mail) send(ctx context.Context, msg *Message, template string) error {
+	err := s.sendWithRetries(ctx, msg)
+	s.metrics.observe(template, err)
+
+	return err
+}
+
+func (s *Mail) sendWithRetries(ctx context.Context, msg *Message) error {
+	if s.ctx.Err() != nil {
+		return errServiceClosed
+	}
+
+	// copy msg to leave the caller's message unchanged
+	m := *msg
+	msg = &m
+
+	if msg.From == "" {
+		msg.From = s.config.From
+	}
+
+	if err := msg.validate(); err != nil {
+		return err
+	}
+
+	attachments, err := prepareAttachments(msg.Attachments, s.config.MaxAttachmentSize)
+	if err != nil {
+		return err
+	}
+
+	msg.Attachments = attachments
+
+	backoff := s.config.InitialBackoff
+
+	for attempt := 1; ; attempt++ {
+		err := s.Provider.Send(ctx, msg)
+		if err == nil {
+			s.log.Debug().Msgf("sent email %q to %q", msg.Subject, msg.To)
+
+			return nil
+		}
+
+		if !isTemporary(err) || attempt >= s.config.MaxAttempts {
+			return fmt.Errorf("sending email %q after %d attempts: %w", msg.Subject, attempt, err)
+		}
+
+		s.log.Warn().Err(err).Msgf("failed to send email %q, retrying in %s", msg.Subject, backoff)
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("sending email %q: %w", msg.Subject, ctx.Err())
+		case <-s.ctx.Done():
+			return fmt.Errorf("sending email %q: %w", msg.Subject, errServiceClosed)
+		case <-time.After(backoff):
+		}
+
+		backoff *= 2
+		if backoff > s.config.MaxBackoff {
+			backoff = s.config.MaxBackoff
+		}
+	}
+}
+
+// SendTemplate renders email template name using data and sends it to
+// recipient to, see tmplboot.Loader.LoadEmail.
+func (s *Mail) SendTemplate(ctx context.Context, name string, to string, data any) error {
+	if s.Templates == nil {
+		return errMissingTemplates
+	}
+
+	email, err := s.Templates.LoadEmail(name, "")
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+
+	rendered, err := email.Render(data)
+	if err != nil {
+		s.metrics.observe(name, err)
+
+		return err //nolint:wrapcheck
+	}
+
+	return s.send(ctx, &Message{
+		To:      []string{to},
+		Subject: rendered.Subject,
+		HTML:    rendered.HTML,
+		Text:    rendered.Text,
+	}, name)
+}
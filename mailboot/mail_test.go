@@ -0,0 +1,453 @@
+package mailboot_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/nielskrijger/goboot"
+	"github.com/nielskrijger/goboot/mailboot"
+	"github.com/nielskrijger/goboot/tmplboot"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// smtpServer is a minimal SMTP server storing the received messages.
+type smtpServer struct {
+	ln net.Listener
+
+	mu       sync.Mutex
+	messages []string
+	conns    int
+
+	// rcptReplies are replied to RCPT commands before accepting, e.g. "451 try again"
+	rcptReplies []string
+
+	// dataReplies are replied after receiving the message data instead of "250 OK"
+	dataReplies []string
+}
+
+func newLoader(t *testing.T, env *goboot.AppEnv) *tmplboot.Loader {
+	t.Helper()
+
+	l, err := tmplboot.NewLoader(env)
+	require.Nil(t, err)
+
+	return l
+}
+
+func newSMTPServer(t *testing.T) *smtpServer {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+
+	s := &smtpServer{ln: ln}
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+
+			s.mu.Lock()
+			s.conns++
+			s.mu.Unlock()
+
+			go s.serve(conn)
+		}
+	}()
+
+	t.Cleanup(func() { _ = ln.Close() })
+
+	return s
+}
+
+func (s *smtpServer) serve(conn net.Conn) {
+	defer conn.Close()
+
+	r := textproto.NewReader(bufio.NewReader(conn))
+	reply := func(line string) { _, _ = conn.Write([]byte(line + "\r\n")) }
+
+	reply("220 localhost ESMTP")
+
+	for {
+		line, err := r.ReadLine()
+		if err != nil {
+			return
+		}
+
+		switch cmd := strings.ToUpper(strings.Fields(line)[0]); cmd {
+		case "EHLO", "HELO":
+			reply("250-localhost")
+			reply("250 8BITMIME")
+		case "RCPT":
+			s.mu.Lock()
+			if len(s.rcptReplies) > 0 {
+				reply(s.rcptReplies[0])
+				s.rcptReplies = s.rcptReplies[1:]
+			} else {
+				reply("250 OK")
+			}
+			s.mu.Unlock()
+		case "DATA":
+			reply("354 go ahead")
+
+			b, err := r.ReadDotBytes()
+			if err != nil {
+				return
+			}
+
+			s.mu.Lock()
+			s.messages = append(s.messages, string(b))
+			if len(s.dataReplies) > 0 {
+				reply(s.dataReplies[0])
+				s.dataReplies = s.dataReplies[1:]
+			} else {
+				reply("250 OK")
+			}
+			s.mu.Unlock()
+		case "QUIT":
+			reply("221 bye")
+
+			return
+		default:
+			reply("250 OK")
+		}
+	}
+}
+
+func (s *smtpServer) received() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]string{}, s.messages...)
+}
+
+func (s *smtpServer) connections() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.conns
+}
+
+func newMail(t *testing.T, srv *smtpServer, settings ...string) *mailboot.Mail {
+	t.Helper()
+
+	env := goboot.NewAppEnv("./testdata", "")
+
+	for i := 0; i+1 < len(settings); i += 2 {
+		env.Config.Set(settings[i], settings[i+1])
+	}
+
+	env.Config.Set("mail.port", srv.ln.Addr().(*net.TCPAddr).Port)
+	env.Config.Set("mail.host", "127.0.0.1")
+
+	s := mailboot.NewMail(newLoader(t, env))
+	assert.Nil(t, s.Configure(env))
+	assert.Nil(t, s.Init())
+
+	t.Cleanup(func() { _ = s.Close() })
+
+	return s
+}
+
+func TestMail_Send(t *testing.T) {
+	srv := newSMTPServer(t)
+	s := newMail(t, srv)
+
+	msg := &mailboot.Message{
+		To:      []string{"John <john@example.com>", "Jöhn <johns@example.com>"},
+		Bcc:     []string{"audit@example.com"},
+		Subject: "Héllo",
+		Text:    "Hello John",
+		Headers: map[string]string{"X-Greeting": "Héllo"},
+	}
+	err := s.Send(context.Background(), msg)
+	assert.Nil(t, err)
+	assert.Empty(t, msg.From)
+
+	messages := srv.received()
+	assert.Len(t, messages, 1)
+	assert.Contains(t, messages[0], "From: App <noreply@example.com>\n")
+	assert.Contains(t, messages[0], "To: John <john@example.com>, =?utf-8?q?J=C3=B6hn?= <johns@example.com>\n")
+	assert.Contains(t, messages[0], "X-Greeting: =?utf-8?q?H=C3=A9llo?=\n")
+	assert.Contains(t, messages[0], "Subject: =?utf-8?q?H=C3=A9llo?=\n")
+	assert.Contains(t, messages[0], "Content-Type: text/plain; charset=utf-8\n")
+	assert.NotContains(t, messages[0], "audit@example.com")
+	assert.True(t, strings.HasSuffix(messages[0], "\n\nHello John\n"))
+}
+
+func TestMail_SendTemplate(t *testing.T) {
+	srv := newSMTPServer(t)
+	s := newMail(t, srv)
+	assert.Nil(t, s.Templates.Load())
+
+	err := s.SendTemplate(context.Background(), "welcome", "john@example.com", map[string]string{"Name": "John"})
+	assert.Nil(t, err)
+
+	messages := srv.received()
+	assert.Len(t, messages, 1)
+	assert.Contains(t, messages[0], "Subject: Welcome John\n")
+	assert.Contains(t, messages[0], "Content-Type: multipart/alternative; boundary=")
+	assert.Contains(t, messages[0], "Content-Type: text/plain; charset=utf-8\n")
+	assert.Contains(t, messages[0], "Hello John\n")
+	assert.Contains(t, messages[0], "Content-Type: text/html; charset=utf-8\n")
+	assert.Contains(t, messages[0], "<p>Hello John</p>\n")
+}
+
+func TestMail_SentMetrics(t *testing.T) {
+	srv := newSMTPServer(t)
+	srv.rcptReplies = []string{"550 no such user"}
+
+	env := goboot.NewAppEnv("./testdata", "")
+	env.Config.Set("mail.port", srv.ln.Addr().(*net.TCPAddr).Port)
+
+	s := mailboot.NewMail(newLoader(t, env))
+	assert.Nil(t, s.Configure(env))
+
+	defer s.Close()
+
+	data := map[string]string{"Name": "John"}
+	assert.NotNil(t, s.SendTemplate(context.Background(), "welcome", "unknown@example.com", data))
+	assert.Nil(t, s.SendTemplate(context.Background(), "welcome", "john@example.com", data))
+
+	families, err := env.Metrics.Gather()
+	assert.Nil(t, err)
+
+	results := map[string]float64{}
+
+	for _, f := range families {
+		if f.GetName() != "mail_sent_total" {
+			continue
+		}
+
+		for _, m := range f.GetMetric() {
+			labels := m.GetLabel()
+			results[labels[0].GetValue()+"/"+labels[1].GetValue()] = m.GetCounter().GetValue()
+		}
+	}
+
+	assert.Equal(t, map[string]float64{"failure/welcome": 1, "success/welcome": 1}, results)
+}
+
+func TestMail_SharedMetricsRegistry(t *testing.T) {
+	env := goboot.NewAppEnv("./testdata", "")
+
+	first := mailboot.NewMail(newLoader(t, env))
+	assert.Nil(t, first.Configure(env))
+
+	defer first.Close()
+
+	second := mailboot.NewMail(newLoader(t, env))
+	assert.Nil(t, second.Configure(env))
+
+	defer second.Close()
+}
+
+func TestMail_ReusesConnections(t *testing.T) {
+	srv := newSMTPServer(t)
+	s := newMail(t, srv)
+
+	for i := 0; i < 3; i++ {
+		assert.Nil(t, s.Send(context.Background(), &mailboot.Message{
+			To:   []string{"john@example.com"},
+			Text: "Hello",
+		}))
+	}
+
+	assert.Len(t, srv.received(), 3)
+	assert.Equal(t, 1, srv.connections())
+}
+
+func TestMail_RetriesTemporaryFailure(t *testing.T) {
+	srv := newSMTPServer(t)
+	srv.rcptReplies = []string{"451 try again later"}
+	s := newMail(t, srv)
+
+	assert.Nil(t, s.Send(context.Background(), &mailboot.Message{
+		To:   []string{"john@example.com"},
+		Text: "Hello",
+	}))
+	assert.Len(t, srv.received(), 1)
+}
+
+func TestMail_NoRetryAfterData(t *testing.T) {
+	srv := newSMTPServer(t)
+	srv.dataReplies = []string{"451 try again later"}
+	s := newMail(t, srv)
+
+	err := s.Send(context.Background(), &mailboot.Message{
+		To:      []string{"john@example.com"},
+		Subject: "Hello",
+		Text:    "Hello",
+	})
+	assert.EqualError(t, err, `sending email "Hello" after 1 attempts: 451 "try again later"`)
+	assert.Len(t, srv.received(), 1)
+}
+
+func TestMail_ErrorPermanentFailure(t *testing.T) {
+	srv := newSMTPServer(t)
+	srv.rcptReplies = []string{"550 no such user"}
+	s := newMail(t, srv)
+
+	err := s.Send(context.Background(), &mailboot.Message{
+		To:      []string{"unknown@example.com"},
+		Subject: "Hello",
+		Text:    "Hello",
+	})
+	assert.EqualError(t, err, `sending email "Hello" after 1 attempts: 550 "no such user"`)
+	assert.Empty(t, srv.received())
+}
+
+func TestMail_ErrorInvalidMessage(t *testing.T) {
+	s := newMail(t, newSMTPServer(t))
+
+	err := s.Send(context.Background(), &mailboot.Message{Text: "Hello"})
+	assert.EqualError(t, err, "email requires at least one recipient")
+
+	err = s.Send(context.Background(), &mailboot.Message{To: []string{"john@example.com"}})
+	assert.EqualError(t, err, "email requires an HTML or text body")
+
+	err = s.Send(context.Background(), &mailboot.Message{
+		To:      []string{"john@example.com"},
+		Text:    "Hello",
+		Headers: map[string]string{"X-Campaign": "a\r\nBcc: evil@example.com"},
+	})
+	assert.EqualError(t, err, `email header must not contain line breaks: "X-Campaign"`)
+
+	err = s.Send(context.Background(), &mailboot.Message{
+		To:      []string{"john@example.com"},
+		ReplyTo: "john@example.com\r\nBcc: evil@example.com",
+		Text:    "Hello",
+	})
+	assert.NotNil(t, err)
+}
+
+func TestMail_ErrorInvalidConfig(t *testing.T) {
+	s := mailboot.NewMail(nil)
+	err := s.Configure(goboot.NewAppEnv("./testdata", "invalid"))
+	assert.EqualError(t, err, `config "mail.tls" must be "starttls", "tls" or "none"`)
+}
+
+func TestMail_SendAttachments(t *testing.T) {
+	srv := newSMTPServer(t)
+	srv.rcptReplies = []string{"451 try again later"}
+	s := newMail(t, srv)
+
+	// not an io.Seeker, must still be sent completely when retrying
+	invoice := io.MultiReader(strings.NewReader(strings.Repeat("a", 100)))
+	attachments := []*mailboot.Attachment{
+		{Filename: "logo.png", Reader: strings.NewReader("png"), ContentID: "logo"},
+		{Filename: "invoice.pdf", Reader: invoice},
+	}
+
+	err := s.Send(context.Background(), &mailboot.Message{
+		To:          []string{"john@example.com"},
+		Subject:     "Invoice",
+		Text:        "See attached",
+		HTML:        `<img src="cid:logo"> See attached`,
+		Attachments: attachments,
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, invoice, attachments[1].Reader)
+
+	messages := srv.received()
+	assert.Len(t, messages, 1)
+
+	msg, err := mail.ReadMessage(strings.NewReader(messages[0]))
+	assert.Nil(t, err)
+
+	mixed := readParts(t, msg.Header.Get("Content-Type"), msg.Body)
+	assert.Len(t, mixed, 2)
+	assert.Equal(t, `attachment; filename=invoice.pdf`, mixed[1].header.Get("Content-Disposition"))
+	assert.Equal(t, strings.Repeat("a", 100), mixed[1].body)
+
+	related := readParts(t, mixed[0].header.Get("Content-Type"), strings.NewReader(mixed[0].body))
+	assert.Len(t, related, 2)
+	assert.Equal(t, "<logo>", related[1].header.Get("Content-Id"))
+	assert.Equal(t, "image/png; name=logo.png", related[1].header.Get("Content-Type"))
+	assert.Equal(t, "png", related[1].body)
+
+	alternative := readParts(t, related[0].header.Get("Content-Type"), strings.NewReader(related[0].body))
+	assert.Len(t, alternative, 2)
+	assert.Equal(t, `<img src="cid:logo"> See attached`, alternative[1].body)
+}
+
+func TestMail_ErrorInvalidContentID(t *testing.T) {
+	s := newMail(t, newSMTPServer(t))
+
+	err := s.Send(context.Background(), &mailboot.Message{
+		To:   []string{"john@example.com"},
+		Text: "Hello",
+		Attachments: []*mailboot.Attachment{
+			{Filename: "logo.png", Reader: strings.NewReader("png"), ContentID: "logo>\r\nBcc: evil@example.com"},
+		},
+	})
+	assert.EqualError(t, err, `email attachment content id must not contain line breaks or angle brackets: "logo>\r\nBcc: evil@example.com"`)
+}
+
+func TestMail_ErrorAttachmentTooLarge(t *testing.T) {
+	s := newMail(t, newSMTPServer(t), "mail.maxAttachmentSize", "10")
+
+	err := s.Send(context.Background(), &mailboot.Message{
+		To:   []string{"john@example.com"},
+		Text: "See attached",
+		Attachments: []*mailboot.Attachment{
+			{Filename: "a.txt", Reader: strings.NewReader("123456")},
+			{Filename: "b.txt", Reader: io.MultiReader(strings.NewReader("123456"))},
+		},
+	})
+	assert.EqualError(t, err, "email attachments exceed the maximum size of 10 bytes")
+}
+
+type part struct {
+	header textproto.MIMEHeader
+	body   string
+}
+
+// readParts returns the decoded parts of a multipart body.
+func readParts(t *testing.T, contentType string, body io.Reader) []part {
+	t.Helper()
+
+	_, params, err := mime.ParseMediaType(contentType)
+	assert.Nil(t, err)
+
+	var parts []part
+
+	r := multipart.NewReader(body, params["boundary"])
+
+	for {
+		p, err := r.NextRawPart()
+		if err == io.EOF {
+			return parts
+		}
+
+		assert.Nil(t, err)
+
+		var content io.Reader = p
+
+		switch p.Header.Get("Content-Transfer-Encoding") {
+		case "base64":
+			content = base64.NewDecoder(base64.StdEncoding, p)
+		case "quoted-printable":
+			content = quotedprintable.NewReader(p)
+		}
+
+		b, err := io.ReadAll(content)
+		assert.Nil(t, err)
+
+		parts = append(parts, part{header: p.Header, body: string(b)})
+	}
+}
@@ -0,0 +1,98 @@
+package mailboot
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"strings"
+)
+
+const defaultMailgunURL = "https://api.mailgun.net"
+
+var (
+	errMissingMailgunAPIKey = errors.New("config \"mail.mailgun.apiKey\" is required")
+	errMissingMailgunDomain = errors.New("config \"mail.mailgun.domain\" is required")
+)
+
+type MailgunConfig struct {
+	// APIKey is read from a file, environment variable or secret manager if
+	// prefixed, see goboot.ResolveSecret.
+	APIKey string `yaml:"apiKey"`
+
+	// Domain is the sending domain, e.g. "mg.example.com".
+	Domain string `yaml:"domain"`
+
+	// BaseURL of the API. Default is "https://api.mailgun.net", use
+	// "https://api.eu.mailgun.net" for domains in the EU region.
+	BaseURL string `yaml:"baseUrl"`
+}
+
+// mailgun implements Provider sending MIME messages using the Mailgun API.
+type mailgun struct {
+	config MailgunConfig
+	client *http.Client
+}
+
+func newMailgun(config *MailConfig) (*mailgun, error) {
+	if config.Mailgun.APIKey == "" {
+		return nil, errMissingMailgunAPIKey
+	}
+
+	if config.Mailgun.Domain == "" {
+		return nil, errMissingMailgunDomain
+	}
+
+	if config.Mailgun.BaseURL == "" {
+		config.Mailgun.BaseURL = defaultMailgunURL
+	}
+
+	return &mailgun{config: config.Mailgun, client: &http.Client{Timeout: config.Timeout}}, nil
+}
+
+func (p *mailgun) Send(ctx context.Context, msg *Message) error {
+	data, err := msg.bytes()
+	if err != nil {
+		return err
+	}
+
+	recipients, err := msg.recipients()
+	if err != nil {
+		return err
+	}
+
+	var body bytes.Buffer
+
+	mw := multipart.NewWriter(&body)
+
+	if err := mw.WriteField("to", strings.Join(recipients, ",")); err != nil {
+		return fmt.Errorf("creating Mailgun request: %w", err)
+	}
+
+	w, err := mw.CreateFormFile("message", "message.mime")
+	if err != nil {
+		return fmt.Errorf("creating Mailgun request: %w", err)
+	}
+
+	if _, err := w.Write(data); err != nil {
+		return fmt.Errorf("creating Mailgun request: %w", err)
+	}
+
+	if err := mw.Close(); err != nil {
+		return fmt.Errorf("creating Mailgun request: %w", err)
+	}
+
+	url := p.config.BaseURL + "/v3/" + p.config.Domain + "/messages.mime"
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, &body)
+	if err != nil {
+		return fmt.Errorf("creating Mailgun request: %w", err)
+	}
+
+	req.SetBasicAuth("api", p.config.APIKey)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+
+	return doAPIRequest(p.client, "Mailgun", req)
+}
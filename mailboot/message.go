@@ -0,0 +1,375 @@
+package mailboot
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+var (
+	errMissingSender     = errors.New("email requires a sender")
+	errMissingRecipients = errors.New("email requires at least one recipient")
+	errMissingBody       = errors.New("email requires an HTML or text body")
+	errInvalidHeader     = errors.New("email header must not contain line breaks")
+)
+
+// Message is an email, addresses are formatted like "john@example.com" or
+// "John <john@example.com>".
+type Message struct {
+	// From defaults to config "mail.from".
+	From    string
+	To      []string
+	Cc      []string
+	Bcc     []string
+	ReplyTo string
+	Subject string
+
+	// HTML and/or Text body. Messages with both are sent as
+	// multipart/alternative so clients can choose.
+	HTML string
+	Text string
+
+	// Headers are additional headers, e.g. "List-Unsubscribe".
+	Headers map[string]string
+
+	// Attachments including inline images, see Attachment.
+	Attachments []*Attachment
+}
+
+// sender returns the envelope sender address.
+func (m *Message) sender() (string, error) {
+	if m.From == "" {
+		return "", errMissingSender
+	}
+
+	addr, err := mail.ParseAddress(m.From)
+	if err != nil {
+		return "", fmt.Errorf("parsing sender %q: %w", m.From, err)
+	}
+
+	return addr.Address, nil
+}
+
+// recipients returns the envelope recipient addresses, including Bcc.
+func (m *Message) recipients() ([]string, error) {
+	var recipients []string
+
+	for _, list := range [][]string{m.To, m.Cc, m.Bcc} {
+		for _, r := range list {
+			addr, err := mail.ParseAddress(r)
+			if err != nil {
+				return nil, fmt.Errorf("parsing recipient %q: %w", r, err)
+			}
+
+			recipients = append(recipients, addr.Address)
+		}
+	}
+
+	if len(recipients) == 0 {
+		return nil, errMissingRecipients
+	}
+
+	return recipients, nil
+}
+
+// validate returns an error if the message can't be sent.
+func (m *Message) validate() error {
+	if _, err := m.sender(); err != nil {
+		return err
+	}
+
+	if _, err := m.recipients(); err != nil {
+		return err
+	}
+
+	if m.ReplyTo != "" {
+		if _, err := mail.ParseAddress(m.ReplyTo); err != nil {
+			return fmt.Errorf("parsing reply-to %q: %w", m.ReplyTo, err)
+		}
+	}
+
+	if err := validateHeader("Subject", m.Subject); err != nil {
+		return err
+	}
+
+	for k, v := range m.Headers {
+		if err := validateHeader(k, v); err != nil {
+			return err
+		}
+	}
+
+	if m.HTML == "" && m.Text == "" {
+		return errMissingBody
+	}
+
+	return nil
+}
+
+// validateHeader returns an error if the header can be used to inject other
+// headers.
+func validateHeader(key string, value string) error {
+	if key == "" || strings.ContainsAny(key, "\r\n: ") || strings.ContainsAny(value, "\r\n") {
+		return fmt.Errorf("%w: %q", errInvalidHeader, key)
+	}
+
+	return nil
+}
+
+// bytes returns the message formatted according to RFC 5322, see writeTo.
+func (m *Message) bytes() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := m.writeTo(&buf); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// writeTo writes the message formatted according to RFC 5322, Bcc is
+// omitted. Attachments are encoded while reading them.
+func (m *Message) writeTo(w io.Writer) error {
+	sender, err := m.sender()
+	if err != nil {
+		return err
+	}
+
+	header := textproto.MIMEHeader{}
+	header.Set("From", formatAddress(m.From))
+	header.Set("To", formatAddresses(m.To))
+	header.Set("Subject", mime.QEncoding.Encode("utf-8", m.Subject))
+	header.Set("Date", time.Now().Format(time.RFC1123Z))
+	header.Set("Message-Id", messageID(sender))
+	header.Set("MIME-Version", "1.0")
+
+	if len(m.Cc) > 0 {
+		header.Set("Cc", formatAddresses(m.Cc))
+	}
+
+	if m.ReplyTo != "" {
+		header.Set("Reply-To", formatAddress(m.ReplyTo))
+	}
+
+	for k, v := range m.Headers {
+		header.Set(k, mime.QEncoding.Encode("utf-8", v))
+	}
+
+	return m.body().write(w, header)
+}
+
+// formatAddress returns addr with a non-ASCII name encoded according to
+// RFC 2047, ASCII addresses are returned as is.
+func formatAddress(addr string) string {
+	for _, r := range addr {
+		if r >= utf8.RuneSelf {
+			if parsed, err := mail.ParseAddress(addr); err == nil {
+				return parsed.String()
+			}
+
+			break
+		}
+	}
+
+	return addr
+}
+
+func formatAddresses(list []string) string {
+	formatted := make([]string, len(list))
+	for i, addr := range list {
+		formatted[i] = formatAddress(addr)
+	}
+
+	return strings.Join(formatted, ", ")
+}
+
+// body returns the MIME structure of the message:
+//
+//	multipart/mixed, if there are attachments
+//	  multipart/related, if there are inline images
+//	    multipart/alternative, if there are both a text and HTML body
+//	      text/plain
+//	      text/html
+//	    inline images
+//	  attachments
+func (m *Message) body() *mimePart {
+	var body *mimePart
+
+	switch {
+	case m.HTML != "" && m.Text != "":
+		body = &mimePart{subtype: "alternative", parts: []*mimePart{textPart("text/plain", m.Text), textPart("text/html", m.HTML)}}
+	case m.HTML != "":
+		body = textPart("text/html", m.HTML)
+	default:
+		body = textPart("text/plain", m.Text)
+	}
+
+	var inline, attached []*mimePart
+
+	for _, a := range m.Attachments {
+		if a.ContentID != "" {
+			inline = append(inline, a.part())
+		} else {
+			attached = append(attached, a.part())
+		}
+	}
+
+	if len(inline) > 0 {
+		body = &mimePart{subtype: "related", parts: append([]*mimePart{body}, inline...)}
+	}
+
+	if len(attached) > 0 {
+		body = &mimePart{subtype: "mixed", parts: append([]*mimePart{body}, attached...)}
+	}
+
+	return body
+}
+
+// mimePart is a leaf part with content, or a multipart of parts.
+type mimePart struct {
+	header  textproto.MIMEHeader
+	content func(w io.Writer) error
+
+	subtype string
+	parts   []*mimePart
+}
+
+func textPart(contentType string, body string) *mimePart {
+	return &mimePart{
+		header: textproto.MIMEHeader{
+			"Content-Type":              {contentType + "; charset=utf-8"},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		},
+		content: func(w io.Writer) error {
+			return writeQuotedPrintable(w, body)
+		},
+	}
+}
+
+// write writes the part including header, which is merged with the part
+// header.
+func (p *mimePart) write(w io.Writer, header textproto.MIMEHeader) error {
+	for k, v := range p.header {
+		header[k] = v
+	}
+
+	if p.parts == nil {
+		if _, err := w.Write(headerBytes(header)); err != nil {
+			return fmt.Errorf("writing email: %w", err)
+		}
+
+		return p.content(w)
+	}
+
+	mw := multipart.NewWriter(w)
+	header.Set("Content-Type", "multipart/"+p.subtype+"; boundary="+mw.Boundary())
+
+	if _, err := w.Write(headerBytes(header)); err != nil {
+		return fmt.Errorf("writing email: %w", err)
+	}
+
+	for _, part := range p.parts {
+		if err := part.writeTo(mw); err != nil {
+			return err
+		}
+	}
+
+	if err := mw.Close(); err != nil {
+		return fmt.Errorf("writing email: %w", err)
+	}
+
+	return nil
+}
+
+// writeTo writes the part as part of multipart mw.
+func (p *mimePart) writeTo(mw *multipart.Writer) error {
+	if p.parts == nil {
+		w, err := mw.CreatePart(p.header)
+		if err != nil {
+			return fmt.Errorf("writing email: %w", err)
+		}
+
+		return p.content(w)
+	}
+
+	// the boundary of a nested multipart is part of its header
+	nested := multipart.NewWriter(nil)
+
+	w, err := mw.CreatePart(textproto.MIMEHeader{
+		"Content-Type": {"multipart/" + p.subtype + "; boundary=" + nested.Boundary()},
+	})
+	if err != nil {
+		return fmt.Errorf("writing email: %w", err)
+	}
+
+	nw := multipart.NewWriter(w)
+	if err := nw.SetBoundary(nested.Boundary()); err != nil {
+		return fmt.Errorf("writing email: %w", err)
+	}
+
+	for _, part := range p.parts {
+		if err := part.writeTo(nw); err != nil {
+			return err
+		}
+	}
+
+	if err := nw.Close(); err != nil {
+		return fmt.Errorf("writing email: %w", err)
+	}
+
+	return nil
+}
+
+// headerBytes formats header sorted by key followed by an empty line.
+func headerBytes(header textproto.MIMEHeader) []byte {
+	keys := make([]string, 0, len(header))
+	for k := range header {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	var buf bytes.Buffer
+
+	for _, k := range keys {
+		for _, v := range header[k] {
+			fmt.Fprintf(&buf, "%s: %s\r\n", k, v)
+		}
+	}
+
+	buf.WriteString("\r\n")
+
+	return buf.Bytes()
+}
+
+func writeQuotedPrintable(w io.Writer, body string) error {
+	qp := quotedprintable.NewWriter(w)
+
+	if _, err := qp.Write([]byte(body)); err != nil {
+		return fmt.Errorf("writing email: %w", err)
+	}
+
+	if err := qp.Close(); err != nil {
+		return fmt.Errorf("writing email: %w", err)
+	}
+
+	return nil
+}
+
+// messageID returns a unique message id at the domain of the sender.
+func messageID(sender string) string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+
+	return "<" + hex.EncodeToString(b) + sender[strings.LastIndex(sender, "@"):] + ">"
+}
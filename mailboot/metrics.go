@@ -0,0 +1,47 @@
+package mailboot
+
+import (
+	"fmt"
+
+	"github.com/nielskrijger/goboot"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type mailMetrics struct {
+	sent *prometheus.CounterVec
+}
+
+func (s *Mail) registerMetrics(reg *prometheus.Registry) error {
+	if reg == nil {
+		return nil
+	}
+
+	m := &mailMetrics{
+		sent: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "mail_sent_total",
+			Help: "Number of emails sent by template name and result: success or failure. " +
+				"Emails not sent using SendTemplate have an empty template name.",
+		}, []string{"template", "result"}),
+	}
+
+	if err := goboot.RegisterCollector(reg, &m.sent); err != nil {
+		return fmt.Errorf("registering mail metrics: %w", err)
+	}
+
+	s.metrics = m
+
+	return nil
+}
+
+func (m *mailMetrics) observe(template string, err error) {
+	if m == nil {
+		return
+	}
+
+	result := "success"
+	if err != nil {
+		result = "failure"
+	}
+
+	m.sent.WithLabelValues(template, result).Inc()
+}
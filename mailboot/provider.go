@@ -0,0 +1,118 @@
+package mailboot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/textproto"
+)
+
+// Providers of MailConfig.Provider.
+const (
+	ProviderSMTP     = "smtp"
+	ProviderSendGrid = "sendgrid"
+	ProviderMailgun  = "mailgun"
+	ProviderSES      = "ses"
+)
+
+const maxErrorBodySize = 1024
+
+var errUnknownProvider = errors.New("config \"mail.provider\" must be \"smtp\", \"sendgrid\", \"mailgun\" or \"ses\"")
+
+// Provider delivers messages, e.g. over SMTP or using the API of an email
+// vendor. Returned errors are retried unless they're an SMTP 5xx reply, an
+// SMTP error after the message data was sent or an APIError with a 4xx status
+// other than 429.
+type Provider interface {
+	Send(ctx context.Context, msg *Message) error
+}
+
+// APIError is returned by providers using an HTTP API when the API responds
+// with an error status.
+type APIError struct {
+	Provider   string
+	StatusCode int
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("%s responded with status %d: %s", e.Provider, e.StatusCode, e.Message)
+}
+
+// newProvider creates the provider of config "mail.provider".
+func (s *Mail) newProvider() (Provider, error) {
+	switch s.config.Provider {
+	case ProviderSMTP:
+		if s.config.Host == "" {
+			return nil, errMissingHost
+		}
+
+		return newSMTPPool(s.config), nil
+	case ProviderSendGrid:
+		return newSendGrid(s.config)
+	case ProviderMailgun:
+		return newMailgun(s.config)
+	case ProviderSES:
+		return newSES(s.config)
+	default:
+		return nil, errUnknownProvider
+	}
+}
+
+// doAPIRequest performs req and returns an APIError for non-2xx responses.
+func doAPIRequest(client *http.Client, provider string, req *http.Request) error {
+	res, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("calling %s: %w", provider, err)
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode >= 200 && res.StatusCode < 300 {
+		_, _ = io.Copy(io.Discard, res.Body)
+
+		return nil
+	}
+
+	body, _ := io.ReadAll(io.LimitReader(res.Body, maxErrorBodySize))
+
+	return &APIError{Provider: provider, StatusCode: res.StatusCode, Message: string(body)}
+}
+
+// dataSentError wraps errors after the SMTP server accepted the DATA
+// command, the message may have been delivered so it's never retried.
+type dataSentError struct {
+	err error
+}
+
+func (e *dataSentError) Error() string {
+	return e.err.Error()
+}
+
+func (e *dataSentError) Unwrap() error {
+	return e.err
+}
+
+// isTemporary returns true for errors worth retrying: network errors, 4xx
+// SMTP replies and API errors like rate limiting, as opposed to permanent
+// errors like an unknown recipient.
+func isTemporary(err error) bool {
+	var dataErr *dataSentError
+	if errors.As(err, &dataErr) {
+		return false
+	}
+
+	var protoErr *textproto.Error
+	if errors.As(err, &protoErr) {
+		return protoErr.Code >= 400 && protoErr.Code < 500
+	}
+
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode == http.StatusTooManyRequests || apiErr.StatusCode >= 500
+	}
+
+	return true
+}
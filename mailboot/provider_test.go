@@ -0,0 +1,163 @@
+package mailboot_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/nielskrijger/goboot"
+	"github.com/nielskrijger/goboot/mailboot"
+	"github.com/stretchr/testify/assert"
+)
+
+func newProviderMail(t *testing.T, provider string, settings map[string]any) *mailboot.Mail {
+	t.Helper()
+
+	env := goboot.NewAppEnv("./testdata", "")
+	env.Config.Set("mail.provider", provider)
+
+	for k, v := range settings {
+		env.Config.Set("mail."+provider+"."+k, v)
+	}
+
+	s := mailboot.NewMail(nil)
+	assert.Nil(t, s.Configure(env))
+
+	t.Cleanup(func() { _ = s.Close() })
+
+	return s
+}
+
+func TestMail_SendGrid(t *testing.T) {
+	var (
+		body   map[string]any
+		header http.Header
+	)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v3/mail/send", r.URL.Path)
+
+		header = r.Header
+		_ = json.NewDecoder(r.Body).Decode(&body)
+
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer srv.Close()
+
+	s := newProviderMail(t, "sendgrid", map[string]any{"apiKey": "secret", "baseUrl": srv.URL})
+
+	err := s.Send(context.Background(), &mailboot.Message{
+		To:      []string{"John <john@example.com>"},
+		Subject: "Hello",
+		Text:    "Hello John",
+		HTML:    "<p>Hello John</p>",
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "Bearer secret", header.Get("Authorization"))
+
+	b, _ := json.Marshal(body)
+	assert.JSONEq(t, `{
+		"personalizations": [{"to": [{"email": "john@example.com", "name": "John"}]}],
+		"from": {"email": "noreply@example.com", "name": "App"},
+		"subject": "Hello",
+		"content": [
+			{"type": "text/plain", "value": "Hello John"},
+			{"type": "text/html", "value": "<p>Hello John</p>"}
+		]
+	}`, string(b))
+}
+
+func TestMail_SendGridRetriesRateLimit(t *testing.T) {
+	requests := 0
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+
+		switch requests {
+		case 1:
+			w.WriteHeader(http.StatusTooManyRequests)
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"errors":[{"message":"invalid"}]}`))
+		}
+	}))
+	defer srv.Close()
+
+	s := newProviderMail(t, "sendgrid", map[string]any{"apiKey": "secret", "baseUrl": srv.URL})
+
+	err := s.Send(context.Background(), &mailboot.Message{To: []string{"john@example.com"}, Subject: "Hello", Text: "Hello"})
+	assert.EqualError(t, err, `sending email "Hello" after 2 attempts: SendGrid responded with status 400: `+
+		`{"errors":[{"message":"invalid"}]}`)
+	assert.Equal(t, 2, requests)
+}
+
+func TestMail_Mailgun(t *testing.T) {
+	var (
+		to, message string
+		user, pass  string
+	)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v3/mg.example.com/messages.mime", r.URL.Path)
+
+		user, pass, _ = r.BasicAuth()
+		to = r.FormValue("to")
+
+		f, _, err := r.FormFile("message")
+		assert.Nil(t, err)
+
+		b, _ := io.ReadAll(f)
+		message = string(b)
+	}))
+	defer srv.Close()
+
+	t.Setenv("TEST_MAILGUN_API_KEY", "secret")
+
+	s := newProviderMail(t, "mailgun", map[string]any{
+		"apiKey":  "env:TEST_MAILGUN_API_KEY",
+		"domain":  "mg.example.com",
+		"baseUrl": srv.URL,
+	})
+
+	err := s.Send(context.Background(), &mailboot.Message{
+		To:      []string{"John <john@example.com>"},
+		Bcc:     []string{"audit@example.com"},
+		Subject: "Hello",
+		Text:    "Hello John",
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "api", user)
+	assert.Equal(t, "secret", pass)
+	assert.Equal(t, "john@example.com,audit@example.com", to)
+	assert.Contains(t, message, "To: John <john@example.com>\r\n")
+	assert.NotContains(t, message, "audit@example.com")
+}
+
+func TestMail_ErrorProviderConfig(t *testing.T) {
+	for _, tc := range []struct {
+		provider string
+		expected string
+	}{
+		{provider: "postmark", expected: `config "mail.provider" must be "smtp", "sendgrid", "mailgun" or "ses"`},
+		{provider: "sendgrid", expected: `config "mail.sendgrid.apiKey" is required`},
+		{provider: "mailgun", expected: `config "mail.mailgun.apiKey" is required`},
+		{provider: "ses", expected: `config "mail.ses.region" is required`},
+	} {
+		env := goboot.NewAppEnv("./testdata", "")
+		env.Config.Set("mail.provider", tc.provider)
+
+		assert.EqualError(t, mailboot.NewMail(nil).Configure(env), tc.expected, tc.provider)
+	}
+}
+
+func TestMail_ErrorMissingSecret(t *testing.T) {
+	env := goboot.NewAppEnv("./testdata", "")
+	env.Config.Set("mail.provider", "sendgrid")
+	env.Config.Set("mail.sendgrid.apiKey", "file:./testdata/missing-api-key")
+
+	err := mailboot.NewMail(nil).Configure(env)
+	assert.ErrorContains(t, err, "resolving config \"mail.sendgrid.apiKey\": reading secret file")
+}
@@ -0,0 +1,183 @@
+package mailboot
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/mail"
+)
+
+const defaultSendGridURL = "https://api.sendgrid.com"
+
+var errMissingSendGridAPIKey = errors.New("config \"mail.sendgrid.apiKey\" is required")
+
+type SendGridConfig struct {
+	// APIKey is read from a file, environment variable or secret manager if
+	// prefixed, see goboot.ResolveSecret.
+	APIKey string `yaml:"apiKey"`
+
+	// BaseURL of the API. Default is "https://api.sendgrid.com".
+	BaseURL string `yaml:"baseUrl"`
+}
+
+// sendGrid implements Provider using the SendGrid v3 mail send API.
+type sendGrid struct {
+	config SendGridConfig
+	client *http.Client
+}
+
+type sendGridAddress struct {
+	Email string `json:"email"`
+	Name  string `json:"name,omitempty"`
+}
+
+type sendGridPersonalization struct {
+	To  []sendGridAddress `json:"to"`
+	Cc  []sendGridAddress `json:"cc,omitempty"`
+	Bcc []sendGridAddress `json:"bcc,omitempty"`
+}
+
+type sendGridContent struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+type sendGridAttachment struct {
+	Content     string `json:"content"`
+	Type        string `json:"type"`
+	Filename    string `json:"filename"`
+	Disposition string `json:"disposition"`
+	ContentID   string `json:"content_id,omitempty"`
+}
+
+type sendGridRequest struct {
+	Personalizations []sendGridPersonalization `json:"personalizations"`
+	From             sendGridAddress           `json:"from"`
+	ReplyTo          *sendGridAddress          `json:"reply_to,omitempty"`
+	Subject          string                    `json:"subject"`
+	Content          []sendGridContent         `json:"content"`
+	Headers          map[string]string         `json:"headers,omitempty"`
+	Attachments      []sendGridAttachment      `json:"attachments,omitempty"`
+}
+
+func newSendGrid(config *MailConfig) (*sendGrid, error) {
+	if config.SendGrid.APIKey == "" {
+		return nil, errMissingSendGridAPIKey
+	}
+
+	if config.SendGrid.BaseURL == "" {
+		config.SendGrid.BaseURL = defaultSendGridURL
+	}
+
+	return &sendGrid{config: config.SendGrid, client: &http.Client{Timeout: config.Timeout}}, nil
+}
+
+func (p *sendGrid) Send(ctx context.Context, msg *Message) error {
+	body, err := p.request(msg)
+	if err != nil {
+		return err
+	}
+
+	b, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("encoding SendGrid request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.config.BaseURL+"/v3/mail/send", bytes.NewReader(b))
+	if err != nil {
+		return fmt.Errorf("creating SendGrid request: %w", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+p.config.APIKey)
+	req.Header.Set("Content-Type", "application/json")
+
+	return doAPIRequest(p.client, "SendGrid", req)
+}
+
+func (p *sendGrid) request(msg *Message) (*sendGridRequest, error) {
+	from, err := sendGridAddresses(msg.From)
+	if err != nil {
+		return nil, err
+	}
+
+	var personalization sendGridPersonalization
+
+	if personalization.To, err = sendGridAddresses(msg.To...); err != nil {
+		return nil, err
+	}
+
+	if personalization.Cc, err = sendGridAddresses(msg.Cc...); err != nil {
+		return nil, err
+	}
+
+	if personalization.Bcc, err = sendGridAddresses(msg.Bcc...); err != nil {
+		return nil, err
+	}
+
+	req := &sendGridRequest{
+		Personalizations: []sendGridPersonalization{personalization},
+		From:             from[0],
+		Subject:          msg.Subject,
+		Headers:          msg.Headers,
+	}
+
+	if msg.ReplyTo != "" {
+		replyTo, err := sendGridAddresses(msg.ReplyTo)
+		if err != nil {
+			return nil, err
+		}
+
+		req.ReplyTo = &replyTo[0]
+	}
+
+	// text/plain must precede text/html
+	if msg.Text != "" {
+		req.Content = append(req.Content, sendGridContent{Type: "text/plain", Value: msg.Text})
+	}
+
+	if msg.HTML != "" {
+		req.Content = append(req.Content, sendGridContent{Type: "text/html", Value: msg.HTML})
+	}
+
+	for _, a := range msg.Attachments {
+		r, err := a.open()
+		if err != nil {
+			return nil, err
+		}
+
+		b, err := io.ReadAll(r)
+		if err != nil {
+			return nil, fmt.Errorf("reading attachment %q: %w", a.Filename, err)
+		}
+
+		req.Attachments = append(req.Attachments, sendGridAttachment{
+			Content:     base64.StdEncoding.EncodeToString(b),
+			Type:        a.contentType(),
+			Filename:    a.Filename,
+			Disposition: a.disposition(),
+			ContentID:   a.ContentID,
+		})
+	}
+
+	return req, nil
+}
+
+func sendGridAddresses(addresses ...string) ([]sendGridAddress, error) {
+	var result []sendGridAddress
+
+	for _, a := range addresses {
+		addr, err := mail.ParseAddress(a)
+		if err != nil {
+			return nil, fmt.Errorf("parsing address %q: %w", a, err)
+		}
+
+		result = append(result, sendGridAddress{Email: addr.Address, Name: addr.Name})
+	}
+
+	return result, nil
+}
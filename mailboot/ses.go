@@ -0,0 +1,66 @@
+package mailboot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/sesv2"
+	"github.com/aws/aws-sdk-go-v2/service/sesv2/types"
+)
+
+var errMissingSESRegion = errors.New("config \"mail.ses.region\" is required")
+
+type SESConfig struct {
+	// The AWS region of SES, credentials are loaded from the environment.
+	Region string `yaml:"region"`
+}
+
+// ses implements Provider sending MIME messages using Amazon SES.
+type ses struct {
+	client *sesv2.Client
+}
+
+func newSES(cfg *MailConfig) (*ses, error) {
+	if cfg.SES.Region == "" {
+		return nil, errMissingSESRegion
+	}
+
+	awsCfg, err := config.LoadDefaultConfig(context.Background(), config.WithRegion(cfg.SES.Region))
+	if err != nil {
+		return nil, fmt.Errorf("creating SES client: %w", err)
+	}
+
+	return &ses{client: sesv2.NewFromConfig(awsCfg)}, nil
+}
+
+func (p *ses) Send(ctx context.Context, msg *Message) error {
+	data, err := msg.bytes()
+	if err != nil {
+		return err
+	}
+
+	// Bcc isn't part of the raw message
+	recipients, err := msg.recipients()
+	if err != nil {
+		return err
+	}
+
+	_, err = p.client.SendEmail(ctx, &sesv2.SendEmailInput{
+		Destination: &types.Destination{ToAddresses: recipients},
+		Content:     &types.EmailContent{Raw: &types.RawMessage{Data: data}},
+	})
+
+	var resErr *awshttp.ResponseError
+	if errors.As(err, &resErr) {
+		return &APIError{Provider: "SES", StatusCode: resErr.HTTPStatusCode(), Message: resErr.Err.Error()}
+	}
+
+	if err != nil {
+		return fmt.Errorf("calling SES: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,239 @@
+package mailboot
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"sync"
+	"time"
+)
+
+var errStartTLSNotSupported = errors.New("SMTP server doesn't support STARTTLS")
+
+// smtpPool implements Provider sending messages over SMTP, it keeps up to
+// PoolSize idle connections open for reuse.
+type smtpPool struct {
+	config *MailConfig
+	mu     sync.Mutex
+	idle   []*smtpConn
+	closed bool
+}
+
+type smtpConn struct {
+	client *smtp.Client
+	conn   net.Conn
+}
+
+func newSMTPPool(config *MailConfig) *smtpPool {
+	return &smtpPool{config: config}
+}
+
+// Send sends the message using an idle or new connection.
+func (p *smtpPool) Send(ctx context.Context, msg *Message) error {
+	from, err := msg.sender()
+	if err != nil {
+		return err
+	}
+
+	recipients, err := msg.recipients()
+	if err != nil {
+		return err
+	}
+
+	c, err := p.get(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := c.send(p.deadline(ctx), from, recipients, msg); err != nil {
+		// the connection remains usable after an SMTP error reply
+		var protoErr *textproto.Error
+		if errors.As(err, &protoErr) && c.client.Reset() == nil {
+			p.put(c)
+		} else {
+			c.close()
+		}
+
+		return err
+	}
+
+	p.put(c)
+
+	return nil
+}
+
+func (c *smtpConn) send(deadline time.Time, from string, recipients []string, msg *Message) error {
+	_ = c.conn.SetDeadline(deadline)
+
+	if err := c.client.Mail(from); err != nil {
+		return err //nolint:wrapcheck
+	}
+
+	for _, r := range recipients {
+		if err := c.client.Rcpt(r); err != nil {
+			return err //nolint:wrapcheck
+		}
+	}
+
+	w, err := c.client.Data()
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+
+	if err := msg.writeTo(w); err != nil {
+		return &dataSentError{err: err}
+	}
+
+	if err := w.Close(); err != nil {
+		return &dataSentError{err: err}
+	}
+
+	return nil
+}
+
+// get returns an idle connection that is still open, or a new connection.
+func (p *smtpPool) get(ctx context.Context) (*smtpConn, error) {
+	for {
+		p.mu.Lock()
+		if len(p.idle) == 0 {
+			p.mu.Unlock()
+
+			return p.dial(ctx)
+		}
+
+		c := p.idle[len(p.idle)-1]
+		p.idle = p.idle[:len(p.idle)-1]
+		p.mu.Unlock()
+
+		// the server may have closed the idle connection
+		_ = c.conn.SetDeadline(p.deadline(ctx))
+		if err := c.client.Noop(); err == nil {
+			return c, nil
+		}
+
+		_ = c.conn.Close()
+	}
+}
+
+// put returns the connection to the pool, or closes it when the pool is full.
+func (p *smtpPool) put(c *smtpConn) {
+	p.mu.Lock()
+	full := p.closed || len(p.idle) >= p.config.PoolSize
+
+	if !full {
+		p.idle = append(p.idle, c)
+	}
+
+	p.mu.Unlock()
+
+	if full {
+		c.close()
+	}
+}
+
+func (p *smtpPool) dial(ctx context.Context) (*smtpConn, error) {
+	addr := net.JoinHostPort(p.config.Host, strconv.Itoa(p.config.Port))
+	tlsConfig := &tls.Config{
+		ServerName:         p.config.Host,
+		InsecureSkipVerify: p.config.TLSInsecureSkipVerify, //nolint:gosec
+		MinVersion:         tls.VersionTLS12,
+	}
+
+	dialer := &net.Dialer{Timeout: p.config.Timeout}
+
+	var (
+		conn net.Conn
+		err  error
+	)
+
+	if p.config.TLS == TLSImplicit {
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: tlsConfig}).DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("connecting to SMTP server %q: %w", addr, err)
+	}
+
+	_ = conn.SetDeadline(p.deadline(ctx))
+
+	client, err := smtp.NewClient(conn, p.config.Host)
+	if err != nil {
+		_ = conn.Close()
+
+		return nil, fmt.Errorf("connecting to SMTP server %q: %w", addr, err)
+	}
+
+	c := &smtpConn{client: client, conn: conn}
+
+	if err := p.handshake(c, tlsConfig); err != nil {
+		c.close()
+
+		return nil, fmt.Errorf("connecting to SMTP server %q: %w", addr, err)
+	}
+
+	return c, nil
+}
+
+// handshake upgrades the connection using STARTTLS and authenticates.
+func (p *smtpPool) handshake(c *smtpConn, tlsConfig *tls.Config) error {
+	if p.config.TLS == TLSStartTLS {
+		if ok, _ := c.client.Extension("STARTTLS"); !ok {
+			return errStartTLSNotSupported
+		}
+
+		if err := c.client.StartTLS(tlsConfig); err != nil {
+			return err //nolint:wrapcheck
+		}
+	}
+
+	if p.config.Username != "" {
+		auth := smtp.PlainAuth("", p.config.Username, p.config.Password, p.config.Host)
+		if err := c.client.Auth(auth); err != nil {
+			return err //nolint:wrapcheck
+		}
+	}
+
+	return nil
+}
+
+// deadline returns the deadline of a single send, which is Timeout from now
+// or the deadline of ctx if earlier.
+func (p *smtpPool) deadline(ctx context.Context) time.Time {
+	deadline := time.Now().Add(p.config.Timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		return d
+	}
+
+	return deadline
+}
+
+// Close closes all idle connections.
+func (p *smtpPool) Close() error {
+	p.mu.Lock()
+	idle := p.idle
+	p.idle = nil
+	p.closed = true
+	p.mu.Unlock()
+
+	for _, c := range idle {
+		c.close()
+	}
+
+	return nil
+}
+
+// close quits the session, or closes the connection if that fails.
+func (c *smtpConn) close() {
+	_ = c.conn.SetDeadline(time.Now().Add(time.Second))
+
+	if err := c.client.Quit(); err != nil {
+		_ = c.client.Close()
+	}
+}
@@ -0,0 +1,25 @@
+package goboot
+
+import (
+	"errors"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// RegisterCollector registers the collector c points to with reg. When an
+// equal collector is registered already, e.g. by another instance of the same
+// service, c is set to the registered collector instead.
+func RegisterCollector[T prometheus.Collector](reg prometheus.Registerer, c *T) error {
+	err := reg.Register(*c)
+
+	var are prometheus.AlreadyRegisteredError
+	if errors.As(err, &are) {
+		if existing, ok := are.ExistingCollector.(T); ok {
+			*c = existing
+
+			return nil
+		}
+	}
+
+	return err
+}
@@ -0,0 +1,31 @@
+package goboot_test
+
+import (
+	"testing"
+
+	"github.com/nielskrijger/goboot"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegisterCollector_ReusesRegisteredCollector(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	opts := prometheus.CounterOpts{Name: "test_total", Help: "Test counter."}
+
+	first := prometheus.NewCounter(opts)
+	assert.Nil(t, goboot.RegisterCollector(reg, &first))
+
+	second := prometheus.NewCounter(opts)
+	assert.Nil(t, goboot.RegisterCollector(reg, &second))
+	assert.Same(t, first, second)
+}
+
+func TestRegisterCollector_ErrorConflictingCollector(t *testing.T) {
+	reg := prometheus.NewRegistry()
+
+	counter := prometheus.NewCounter(prometheus.CounterOpts{Name: "test_total", Help: "Test counter."})
+	assert.Nil(t, goboot.RegisterCollector(reg, &counter))
+
+	gauge := prometheus.NewGauge(prometheus.GaugeOpts{Name: "test_total", Help: "Test gauge."})
+	assert.NotNil(t, goboot.RegisterCollector(reg, &gauge))
+}
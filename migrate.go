@@ -0,0 +1,107 @@
+package goboot
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// Migrate subcommands, see ParseMigrateCommand.
+const (
+	MigrateUp     = "up"
+	MigrateDown   = "down"
+	MigrateTo     = "to"
+	MigrateStatus = "status"
+)
+
+// ErrInvalidMigrateCommand is returned by ParseMigrateCommand when the
+// arguments aren't a valid migrate subcommand.
+var ErrInvalidMigrateCommand = errors.New("usage: migrate up|down [steps]|to <version>|status")
+
+// Migrator is implemented by services running migration files, e.g.
+// pgboot.Postgres, mysqlboot.MySQL and sqliteboot.SQLite, so the CLI can
+// migrate any of them.
+type Migrator interface {
+	// MigrateDown rolls back specified number of migrations.
+	MigrateDown(steps int) error
+
+	// MigrateTo migrates up or down to specified version, version 0 rolls
+	// back all migrations.
+	MigrateTo(version uint) error
+
+	// PrintMigrationStatus writes the applied and pending migrations to w.
+	PrintMigrationStatus(w io.Writer) error
+
+	// RunMigrateCommand runs a subcommand parsed by ParseMigrateCommand.
+	RunMigrateCommand(args []string) error
+}
+
+// MigrateCommand is a parsed migrate subcommand.
+type MigrateCommand struct {
+	// Name is MigrateUp, MigrateDown, MigrateTo or MigrateStatus.
+	Name string
+
+	// Steps is the number of migrations rolled back by MigrateDown.
+	Steps int
+
+	// Version is the target version of MigrateTo.
+	Version uint
+}
+
+// ParseMigrateCommand parses the arguments of a migrate subcommand, intended
+// to be wired into the application's CLI, e.g. "myapp migrate down 1".
+// Supported commands are:
+//
+//   - up: run all pending migrations
+//   - down [steps]: roll back specified number of migrations, default is 1
+//   - to <version>: migrate up or down to specified version
+//   - status: print the applied and pending migrations
+func ParseMigrateCommand(args []string) (*MigrateCommand, error) {
+	if len(args) == 0 {
+		return nil, ErrInvalidMigrateCommand
+	}
+
+	cmd := &MigrateCommand{Name: args[0]}
+
+	switch cmd.Name {
+	case MigrateUp, MigrateStatus:
+		if len(args) > 1 {
+			return nil, fmt.Errorf("unexpected arguments %q: %w", args[1:], ErrInvalidMigrateCommand)
+		}
+	case MigrateDown:
+		if len(args) > 2 { //nolint:gomnd
+			return nil, fmt.Errorf("unexpected arguments %q: %w", args[2:], ErrInvalidMigrateCommand)
+		}
+
+		cmd.Steps = 1
+
+		if len(args) > 1 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("invalid number of steps %q: %w", args[1], ErrInvalidMigrateCommand)
+			}
+
+			cmd.Steps = n
+		}
+	case MigrateTo:
+		if len(args) < 2 { //nolint:gomnd
+			return nil, ErrInvalidMigrateCommand
+		}
+
+		if len(args) > 2 { //nolint:gomnd
+			return nil, fmt.Errorf("unexpected arguments %q: %w", args[2:], ErrInvalidMigrateCommand)
+		}
+
+		version, err := strconv.ParseUint(args[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid version %q: %w", args[1], ErrInvalidMigrateCommand)
+		}
+
+		cmd.Version = uint(version)
+	default:
+		return nil, ErrInvalidMigrateCommand
+	}
+
+	return cmd, nil
+}
@@ -0,0 +1,139 @@
+package goboot
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/golang-migrate/migrate/v4/source"
+)
+
+// MigrationStatus describes a single migration file.
+type MigrationStatus struct {
+	Version uint
+	Name    string
+	Applied bool
+
+	// AppliedAt is nil if the migration is pending or the service doesn't
+	// record when migrations were applied.
+	AppliedAt *time.Time
+}
+
+// MigrationsStatus describes the state of the database schema.
+type MigrationsStatus struct {
+	// Version is the current schema version, 0 if no migrations have run.
+	Version uint
+
+	// Dirty is true when a migration failed halfway, this requires manual
+	// intervention before any other migration can run.
+	Dirty bool
+
+	Migrations []*MigrationStatus
+}
+
+// NewMigrationsStatus reads the migration files in dir and marks the ones up
+// to version as applied, except for version itself when dirty.
+func NewMigrationsStatus(dir string, version uint, dirty bool) (*MigrationsStatus, error) {
+	migrations, err := ReadMigrationFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, m := range migrations {
+		m.Applied = m.Version <= version && !(dirty && m.Version == version)
+	}
+
+	return &MigrationsStatus{Version: version, Dirty: dirty, Migrations: migrations}, nil
+}
+
+// ReadMigrationFiles returns the up migrations of the golang-migrate files in
+// dir ordered by version.
+func ReadMigrationFiles(dir string) ([]*MigrationStatus, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("reading migrations directory: %w", err)
+	}
+
+	var result []*MigrationStatus
+
+	for _, entry := range entries {
+		m, err := source.Parse(entry.Name())
+		if err != nil || m.Direction != source.Up {
+			continue
+		}
+
+		result = append(result, &MigrationStatus{Version: m.Version, Name: m.Identifier})
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Version < result[j].Version
+	})
+
+	return result, nil
+}
+
+// Pending returns all migrations that haven't been applied yet.
+func (ms *MigrationsStatus) Pending() []*MigrationStatus {
+	var pending []*MigrationStatus
+
+	for _, m := range ms.Migrations {
+		if !m.Applied {
+			pending = append(pending, m)
+		}
+	}
+
+	return pending
+}
+
+// Print writes a human-readable table of the migrations to w. The APPLIED AT
+// column is left out when none of the applied times are known.
+func (ms *MigrationsStatus) Print(w io.Writer) error {
+	withAppliedAt := false
+
+	for _, m := range ms.Migrations {
+		if m.AppliedAt != nil {
+			withAppliedAt = true
+		}
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0) //nolint:gomnd
+
+	if withAppliedAt {
+		_, _ = fmt.Fprintln(tw, "VERSION\tNAME\tSTATUS\tAPPLIED AT")
+	} else {
+		_, _ = fmt.Fprintln(tw, "VERSION\tNAME\tSTATUS")
+	}
+
+	for _, m := range ms.Migrations {
+		state := "pending"
+
+		switch {
+		case ms.Dirty && m.Version == ms.Version:
+			state = "dirty"
+		case m.Applied:
+			state = "applied"
+		}
+
+		if !withAppliedAt {
+			_, _ = fmt.Fprintf(tw, "%d\t%s\t%s\n", m.Version, m.Name, state)
+
+			continue
+		}
+
+		appliedAt := ""
+		if m.AppliedAt != nil {
+			appliedAt = m.AppliedAt.Format(time.RFC3339)
+		}
+
+		_, _ = fmt.Fprintf(tw, "%d\t%s\t%s\t%s\n", m.Version, m.Name, state, appliedAt)
+	}
+
+	if err := tw.Flush(); err != nil {
+		return fmt.Errorf("printing migration status: %w", err)
+	}
+
+	return nil
+}
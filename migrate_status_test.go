@@ -0,0 +1,46 @@
+package goboot_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/nielskrijger/goboot"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewMigrationsStatus(t *testing.T) {
+	status, err := goboot.NewMigrationsStatus("./testdata/migrations", 2, true)
+	assert.Nil(t, err)
+	assert.Len(t, status.Migrations, 3)
+	assert.True(t, status.Migrations[0].Applied)
+	assert.False(t, status.Migrations[1].Applied)
+	assert.Len(t, status.Pending(), 2)
+
+	var buf bytes.Buffer
+	assert.Nil(t, status.Print(&buf))
+	assert.Equal(t, "VERSION  NAME           STATUS\n"+
+		"1        create_users   applied\n"+
+		"2        add_email      dirty\n"+
+		"3        create_orders  pending\n", buf.String())
+}
+
+func TestMigrationsStatusPrint_AppliedAt(t *testing.T) {
+	status, err := goboot.NewMigrationsStatus("./testdata/migrations", 1, false)
+	assert.Nil(t, err)
+
+	appliedAt := time.Date(2022, 11, 1, 12, 0, 0, 0, time.UTC)
+	status.Migrations[0].AppliedAt = &appliedAt
+
+	var buf bytes.Buffer
+	assert.Nil(t, status.Print(&buf))
+	assert.Equal(t, "VERSION  NAME           STATUS   APPLIED AT\n"+
+		"1        create_users   applied  2022-11-01T12:00:00Z\n"+
+		"2        add_email      pending  \n"+
+		"3        create_orders  pending  \n", buf.String())
+}
+
+func TestNewMigrationsStatus_ErrorMissingDir(t *testing.T) {
+	_, err := goboot.NewMigrationsStatus("./testdata/unknown", 0, false)
+	assert.EqualError(t, err, "reading migrations directory: open ./testdata/unknown: no such file or directory")
+}
@@ -0,0 +1,36 @@
+package goboot_test
+
+import (
+	"testing"
+
+	"github.com/nielskrijger/goboot"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseMigrateCommand(t *testing.T) {
+	cmd, err := goboot.ParseMigrateCommand([]string{"down"})
+	assert.Nil(t, err)
+	assert.Equal(t, &goboot.MigrateCommand{Name: goboot.MigrateDown, Steps: 1}, cmd)
+
+	cmd, err = goboot.ParseMigrateCommand([]string{"down", "3"})
+	assert.Nil(t, err)
+	assert.Equal(t, &goboot.MigrateCommand{Name: goboot.MigrateDown, Steps: 3}, cmd)
+
+	cmd, err = goboot.ParseMigrateCommand([]string{"to", "2"})
+	assert.Nil(t, err)
+	assert.Equal(t, &goboot.MigrateCommand{Name: goboot.MigrateTo, Version: 2}, cmd)
+
+	cmd, err = goboot.ParseMigrateCommand([]string{"status"})
+	assert.Nil(t, err)
+	assert.Equal(t, &goboot.MigrateCommand{Name: goboot.MigrateStatus}, cmd)
+}
+
+func TestParseMigrateCommand_Errors(t *testing.T) {
+	for _, args := range [][]string{
+		nil, {"sideways"}, {"down", "0"}, {"down", "x"}, {"to"}, {"to", "-1"},
+		{"up", "1"}, {"status", "foo"}, {"down", "2", "3"}, {"to", "2", "3"},
+	} {
+		_, err := goboot.ParseMigrateCommand(args)
+		assert.ErrorIs(t, err, goboot.ErrInvalidMigrateCommand, args)
+	}
+}
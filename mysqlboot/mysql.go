@@ -0,0 +1,304 @@
+package mysqlboot
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/jmoiron/sqlx"
+	"github.com/nielskrijger/goboot"
+	"github.com/rs/zerolog"
+)
+
+const (
+	defaultMySQLConnectMaxRetries    = 5
+	defaultMySQLConnectRetryDuration = 5 * time.Second
+)
+
+// tlsConfigs numbers the TLS configs registered with the MySQL driver, as
+// their names are global.
+var tlsConfigs atomic.Uint64
+
+var (
+	errMissingConfig = errors.New("missing MySQL configuration")
+	errMissingDSN    = errors.New("config \"mysql.dsn\" is required")
+	errInvalidRootCA = errors.New("no certificates found in root CA file")
+	errUnavailable   = errors.New("database is unavailable")
+)
+
+type MySQLConfig struct {
+	// DSN contains the MySQL data source name, e.g. user:password@tcp(host:3306)/dbname?parseTime=true
+	// see also https://github.com/go-sql-driver/mysql#dsn-data-source-name
+	//
+	// Add "multiStatements=true" when migration files contain multiple statements.
+	DSN string `yaml:"dsn"`
+
+	// Password overrides the DSN password. Use "file:/path" or "env:NAME" to
+	// read the password from a file or environment variable, or a secret
+	// manager reference like "gcp:db-password", see goboot.ResolveSecret.
+	Password string `yaml:"password"`
+
+	// Number of retries upon initial connect. Default is 5 times. Set -1 to disable
+	ConnectMaxRetries int `yaml:"connectMaxRetries"`
+
+	// Time between retries for initial connect attempts. Default is 5 seconds.
+	ConnectRetryDuration time.Duration `yaml:"connectRetryDuration"`
+
+	// Maximum number of open connections. Default is 0 (unlimited).
+	PoolSize int `yaml:"poolSize"`
+
+	// Number of idle connections kept in the pool. Default is 2.
+	MaxIdleConns int `yaml:"maxIdleConns"`
+
+	// Maximum time a connection may be reused. Default is 0 (forever).
+	MaxConnAge time.Duration `yaml:"maxConnAge"`
+
+	// Path to the root CA file used to verify the server certificate. Enables TLS.
+	TLSRootCert string `yaml:"tlsRootCert"`
+
+	// Paths to the client certificate and key for client certificate authentication.
+	TLSCert string `yaml:"tlsCert"`
+	TLSKey  string `yaml:"tlsKey"`
+
+	// Server name to verify the server certificate against when it differs from
+	// the DSN host.
+	TLSServerName string `yaml:"tlsServerName"`
+
+	// Queries taking longer than this threshold are logged at warn level.
+	// Default is 0 (disabled).
+	SlowQueryThreshold time.Duration `yaml:"slowQueryThreshold"`
+}
+
+// MySQL implements the AppService interface.
+//
+// The connection pool is a sqlx.DB using the go-sql-driver database/sql driver,
+// see https://github.com/go-sql-driver/mysql
+type MySQL struct {
+	MigrationsDir string // relative path to migrations directory, leave empty when no migrations
+
+	DB *sqlx.DB
+
+	config *MySQLConfig
+	log    zerolog.Logger
+
+	// driverConfig is the parsed DSN with the password and TLS settings
+	// applied, shared by the connections of the service.
+	driverConfig *mysql.Config
+
+	// tlsConfigName is the name of the TLS config registered with the MySQL
+	// driver, empty when TLS isn't configured.
+	tlsConfigName string
+}
+
+func (s *MySQL) Name() string {
+	return "MySQL"
+}
+
+// Configure connects to MySQL.
+func (s *MySQL) Configure(env *goboot.AppEnv) error {
+	s.log = env.Log
+
+	// unmarshal config and set defaults
+	s.config = &MySQLConfig{}
+
+	if !env.Config.InConfig("mysql") {
+		return errMissingConfig
+	}
+
+	if !env.Config.IsSet("mysql.dsn") {
+		return errMissingDSN
+	}
+
+	if err := env.Config.Sub("mysql").Unmarshal(s.config); err != nil {
+		return fmt.Errorf("parsing MySQL configuration: %w", err)
+	}
+
+	if s.config.ConnectMaxRetries == 0 {
+		s.config.ConnectMaxRetries = defaultMySQLConnectMaxRetries
+	}
+
+	if s.config.ConnectRetryDuration == 0 {
+		s.config.ConnectRetryDuration = defaultMySQLConnectRetryDuration
+	}
+
+	if err := s.connect(); err != nil {
+		// release the pool and TLS config registered before the error
+		_ = s.Close()
+
+		return err
+	}
+
+	return nil
+}
+
+func (s *MySQL) connect() error {
+	cfg, err := s.parseDSN()
+	if err != nil {
+		return err
+	}
+
+	s.driverConfig = cfg
+
+	db, err := s.open()
+	if err != nil {
+		return err
+	}
+
+	s.DB = db
+
+	return s.testConnectivity()
+}
+
+// parseDSN parses the DSN and applies the password and TLS settings. The TLS
+// config is registered with the driver until Close.
+func (s *MySQL) parseDSN() (*mysql.Config, error) {
+	cfg, err := mysql.ParseDSN(s.config.DSN)
+	if err != nil {
+		return nil, fmt.Errorf("invalid MySQL dsn: %w", err)
+	}
+
+	if s.config.Password != "" {
+		if cfg.Passwd, err = goboot.ResolveSecret(s.config.Password); err != nil {
+			return nil, fmt.Errorf("resolving MySQL password: %w", err)
+		}
+	}
+
+	if s.config.TLSRootCert != "" || s.config.TLSCert != "" {
+		tlsConfig, err := s.tlsConfig()
+		if err != nil {
+			return nil, err
+		}
+
+		name := fmt.Sprintf("goboot-%d", tlsConfigs.Add(1))
+
+		if err := mysql.RegisterTLSConfig(name, tlsConfig); err != nil {
+			return nil, fmt.Errorf("registering MySQL TLS config: %w", err)
+		}
+
+		s.tlsConfigName = name
+		cfg.TLSConfig = name
+	}
+
+	return cfg, nil
+}
+
+func (s *MySQL) tlsConfig() (*tls.Config, error) {
+	tlsConfig := &tls.Config{ServerName: s.config.TLSServerName} //nolint:gosec
+
+	if s.config.TLSRootCert != "" {
+		pem, err := os.ReadFile(s.config.TLSRootCert)
+		if err != nil {
+			return nil, fmt.Errorf("reading MySQL root CA: %w", err)
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, errInvalidRootCA
+		}
+
+		tlsConfig.RootCAs = pool
+	}
+
+	if s.config.TLSCert != "" {
+		cert, err := tls.LoadX509KeyPair(s.config.TLSCert, s.config.TLSKey)
+		if err != nil {
+			return nil, fmt.Errorf("loading MySQL client certificate: %w", err)
+		}
+
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
+
+func (s *MySQL) open() (*sqlx.DB, error) {
+	connector, err := mysql.NewConnector(s.driverConfig)
+	if err != nil {
+		return nil, fmt.Errorf("connection to MySQL: %w", err)
+	}
+
+	db := sqlx.NewDb(sql.OpenDB(&loggingConnector{Connector: connector, s: s}), "mysql")
+	db.SetMaxOpenConns(s.config.PoolSize)
+	db.SetConnMaxLifetime(s.config.MaxConnAge)
+
+	if s.config.MaxIdleConns != 0 {
+		db.SetMaxIdleConns(s.config.MaxIdleConns)
+	}
+
+	return db, nil
+}
+
+func (s *MySQL) testConnectivity() error {
+	cfg, _ := mysql.ParseDSN(s.config.DSN)
+	s.log.Info().Msgf("connecting to MySQL %s/%s", cfg.Addr, cfg.DBName)
+
+	for retries := 1; ; retries++ {
+		err := s.DB.Ping()
+		if err == nil {
+			s.log.Info().Msg("successfully connected to MySQL")
+
+			return nil
+		}
+
+		if retries >= s.config.ConnectMaxRetries {
+			return fmt.Errorf("connecting to MySQL: %w", err)
+		}
+
+		s.log.Warn().
+			Err(err).
+			Str("addr", cfg.Addr).
+			Msgf("failed to connect to MySQL, retrying in %s", s.config.ConnectRetryDuration)
+
+		time.Sleep(s.config.ConnectRetryDuration)
+	}
+}
+
+// Init runs the migrations.
+func (s *MySQL) Init() error {
+	if s.MigrationsDir == "" {
+		s.log.Info().Msg("skipping db migrations; no migrations directory set")
+
+		return nil
+	}
+
+	if err := s.Migrate(); err != nil {
+		return fmt.Errorf("running MySQL migrations: %w", err)
+	}
+
+	return nil
+}
+
+// HealthCheck returns an error when MySQL is unreachable. It implements goboot.HealthChecker.
+func (s *MySQL) HealthCheck(ctx context.Context) error {
+	if err := s.DB.PingContext(ctx); err != nil {
+		return fmt.Errorf("%w: %v", errUnavailable, err)
+	}
+
+	return nil
+}
+
+// Close closes the connection pool and deregisters the TLS config. It's safe
+// to call when Configure failed.
+func (s *MySQL) Close() error {
+	if s.tlsConfigName != "" {
+		mysql.DeregisterTLSConfig(s.tlsConfigName)
+		s.tlsConfigName = ""
+	}
+
+	if s.DB == nil {
+		return nil
+	}
+
+	if err := s.DB.Close(); err != nil {
+		return fmt.Errorf("closing %s service: %w", s.Name(), err)
+	}
+
+	return nil
+}
@@ -0,0 +1,131 @@
+package mysqlboot
+
+import (
+	"context"
+	"database/sql/driver"
+	"strings"
+	"time"
+)
+
+// loggingConnector wraps a driver.Connector to log queries. Unlike pgx the
+// MySQL driver has no logging hook, so connections and statements are wrapped.
+type loggingConnector struct {
+	driver.Connector
+	s *MySQL
+}
+
+func (c *loggingConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+
+	return &loggingConn{Conn: conn, s: c.s}, nil
+}
+
+// loggingConn implements the optional driver interfaces implemented by the
+// MySQL driver so database/sql behaves the same as without logging.
+type loggingConn struct {
+	driver.Conn
+	s *MySQL
+}
+
+func (c *loggingConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	stmt, err := c.Conn.(driver.ConnPrepareContext).PrepareContext(ctx, query) //nolint:forcetypeassert
+	if err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+
+	return &loggingStmt{Stmt: stmt, query: query, s: c.s}, nil
+}
+
+func (c *loggingConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	return c.Conn.(driver.ConnBeginTx).BeginTx(ctx, opts) //nolint:forcetypeassert,wrapcheck
+}
+
+func (c *loggingConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	start := time.Now()
+	res, err := c.Conn.(driver.ExecerContext).ExecContext(ctx, query, args) //nolint:forcetypeassert
+
+	c.s.logQuery(query, time.Since(start), err)
+
+	return res, err //nolint:wrapcheck
+}
+
+func (c *loggingConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	start := time.Now()
+	rows, err := c.Conn.(driver.QueryerContext).QueryContext(ctx, query, args) //nolint:forcetypeassert
+
+	c.s.logQuery(query, time.Since(start), err)
+
+	return rows, err //nolint:wrapcheck
+}
+
+func (c *loggingConn) Ping(ctx context.Context) error {
+	return c.Conn.(driver.Pinger).Ping(ctx) //nolint:forcetypeassert,wrapcheck
+}
+
+func (c *loggingConn) ResetSession(ctx context.Context) error {
+	return c.Conn.(driver.SessionResetter).ResetSession(ctx) //nolint:forcetypeassert,wrapcheck
+}
+
+func (c *loggingConn) IsValid() bool {
+	return c.Conn.(driver.Validator).IsValid() //nolint:forcetypeassert
+}
+
+func (c *loggingConn) CheckNamedValue(nv *driver.NamedValue) error {
+	return c.Conn.(driver.NamedValueChecker).CheckNamedValue(nv) //nolint:forcetypeassert,wrapcheck
+}
+
+type loggingStmt struct {
+	driver.Stmt
+	query string
+	s     *MySQL
+}
+
+func (st *loggingStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	start := time.Now()
+	res, err := st.Stmt.(driver.StmtExecContext).ExecContext(ctx, args) //nolint:forcetypeassert
+
+	st.s.logQuery(st.query, time.Since(start), err)
+
+	return res, err //nolint:wrapcheck
+}
+
+func (st *loggingStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	start := time.Now()
+	rows, err := st.Stmt.(driver.StmtQueryContext).QueryContext(ctx, args) //nolint:forcetypeassert
+
+	st.s.logQuery(st.query, time.Since(start), err)
+
+	return rows, err //nolint:wrapcheck
+}
+
+func (st *loggingStmt) CheckNamedValue(nv *driver.NamedValue) error {
+	return st.Stmt.(driver.NamedValueChecker).CheckNamedValue(nv) //nolint:forcetypeassert,wrapcheck
+}
+
+// logQuery logs queries at debug level and slow queries at warn level.
+// driver.ErrSkip is not an error; database/sql retries using a prepared statement.
+func (s *MySQL) logQuery(query string, duration time.Duration, err error) {
+	if err == driver.ErrSkip { //nolint:errorlint
+		return
+	}
+
+	if err != nil {
+		s.log.Debug().Err(err).Str("sql", query).Msg("MySQL query failed")
+
+		return
+	}
+
+	if threshold := s.config.SlowQueryThreshold; threshold > 0 && duration >= threshold {
+		s.log.Warn().
+			Str("sql", strings.Join(strings.Fields(query), " ")).
+			Dur("duration", duration).
+			Msgf("slow MySQL query took %s", duration)
+
+		return
+	}
+
+	s.log.Debug().Str("sql", query).Dur("duration", duration).Msg("MySQL query")
+}
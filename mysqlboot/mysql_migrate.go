@@ -0,0 +1,181 @@
+package mysqlboot
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database/mysql"
+	_ "github.com/golang-migrate/migrate/v4/source/file" // Load file-loader for migration files.
+	"github.com/nielskrijger/goboot"
+	"github.com/rs/zerolog"
+)
+
+var errMissingMigrationsDir = errors.New("no MySQL migrations directory set")
+
+// logger implements the Logger interface of golang-migrate.
+type logger struct {
+	logger zerolog.Logger
+}
+
+// Printf is like fmt.Printf.
+func (log *logger) Printf(format string, v ...any) {
+	log.logger.Info().Msgf(format, v...)
+}
+
+// Verbose should return true when verbose logging output is wanted.
+func (log *logger) Verbose() bool {
+	return true
+}
+
+// Migrate runs all pending migrations in MigrationsDir. The migrate driver
+// takes a MySQL named lock so only one instance runs migrations at a time.
+func (s *MySQL) Migrate() error {
+	return s.runMigrations(func(m *migrate.Migrate) error {
+		return m.Up()
+	})
+}
+
+// MigrateDown rolls back specified number of migrations using the down
+// migration files in the migrations directory.
+func (s *MySQL) MigrateDown(steps int) error {
+	return s.runMigrations(func(m *migrate.Migrate) error {
+		return m.Steps(-steps)
+	})
+}
+
+// MigrateTo migrates up or down to specified migration version. Version 0
+// rolls back all migrations.
+func (s *MySQL) MigrateTo(version uint) error {
+	return s.runMigrations(func(m *migrate.Migrate) error {
+		if version == 0 {
+			return m.Down()
+		}
+
+		return m.Migrate(version)
+	})
+}
+
+var _ goboot.Migrator = (*MySQL)(nil)
+
+// RunMigrateCommand runs a migrate subcommand, see
+// goboot.ParseMigrateCommand for the supported commands.
+//
+// The service must be configured before running a command. Don't call Init
+// as it runs all pending migrations.
+func (s *MySQL) RunMigrateCommand(args []string) error {
+	cmd, err := goboot.ParseMigrateCommand(args)
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+
+	switch cmd.Name {
+	case goboot.MigrateDown:
+		return s.MigrateDown(cmd.Steps)
+	case goboot.MigrateTo:
+		return s.MigrateTo(cmd.Version)
+	case goboot.MigrateStatus:
+		return s.PrintMigrationStatus(os.Stdout)
+	default:
+		return s.Migrate()
+	}
+}
+
+// MigrationStatus returns the applied and pending migrations of the migrations
+// directory.
+func (s *MySQL) MigrationStatus() (*goboot.MigrationsStatus, error) {
+	if s.MigrationsDir == "" {
+		return nil, errMissingMigrationsDir
+	}
+
+	m, err := s.newMigrate()
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() { _, _ = m.Close() }()
+
+	version, dirty, err := m.Version()
+	if err != nil && !errors.Is(err, migrate.ErrNilVersion) {
+		return nil, fmt.Errorf("reading MySQL migrations version: %w", err)
+	}
+
+	return goboot.NewMigrationsStatus(s.MigrationsDir, version, dirty) //nolint:wrapcheck
+}
+
+// PrintMigrationStatus writes a human-readable table of the applied and
+// pending migrations in MigrationsDir to w.
+func (s *MySQL) PrintMigrationStatus(w io.Writer) error {
+	status, err := s.MigrationStatus()
+	if err != nil {
+		return err
+	}
+
+	return status.Print(w) //nolint:wrapcheck
+}
+
+func (s *MySQL) runMigrations(run func(m *migrate.Migrate) error) error {
+	if s.MigrationsDir == "" {
+		return errMissingMigrationsDir
+	}
+
+	log := logger{logger: s.log}
+	log.Printf("running MySQL migrations from %s", s.MigrationsDir)
+
+	m, err := s.newMigrate()
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if srcErr, dbErr := m.Close(); srcErr != nil || dbErr != nil {
+			log.logger.Warn().Msgf("closing MySQL migrations: %v, %v", srcErr, dbErr)
+		}
+	}()
+
+	if err := run(m); err != nil {
+		if errors.Is(err, migrate.ErrNoChange) {
+			log.Printf("MySQL database is up-to-date")
+
+			return nil
+		}
+
+		return fmt.Errorf("running MySQL migrations: %w", err)
+	}
+
+	log.Printf("completed MySQL migrations")
+
+	return nil
+}
+
+func (s *MySQL) newMigrate() (*migrate.Migrate, error) {
+	dir, err := filepath.Abs(s.MigrationsDir)
+	if err != nil {
+		return nil, fmt.Errorf("reading migrations path: %w", err)
+	}
+
+	// the driver closes the connection when done
+	db, err := s.open()
+	if err != nil {
+		return nil, err
+	}
+
+	driver, err := mysql.WithInstance(db.DB, &mysql.Config{})
+	if err != nil {
+		_ = db.Close()
+
+		return nil, fmt.Errorf("open MySQL connection for golang-migrate: %w", err)
+	}
+
+	m, err := migrate.NewWithDatabaseInstance("file://"+dir, "mysql", driver)
+	if err != nil {
+		return nil, fmt.Errorf("connecting to MySQL for migrations: %w", err)
+	}
+
+	m.Log = &logger{logger: s.log}
+
+	return m, nil
+}
@@ -0,0 +1,84 @@
+package mysqlboot_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/nielskrijger/goboot"
+	"github.com/nielskrijger/goboot/mysqlboot"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMySQL_Success(t *testing.T) {
+	s := &mysqlboot.MySQL{MigrationsDir: "./testdata/migrations"}
+	assert.Nil(t, s.Configure(goboot.NewAppEnv("./testdata", "valid")))
+	assert.Nil(t, s.Init())
+	assert.Nil(t, s.HealthCheck(context.Background()))
+
+	_, err := s.DB.Exec("INSERT INTO test_table (name) VALUES (?) ON DUPLICATE KEY UPDATE name = name", "test")
+	assert.Nil(t, err)
+
+	assert.Nil(t, s.MigrateDown(1))
+	assert.Nil(t, s.Close())
+}
+
+func TestMySQL_MigrateCommand(t *testing.T) {
+	s := &mysqlboot.MySQL{MigrationsDir: "./testdata/migrations"}
+	assert.Nil(t, s.Configure(goboot.NewAppEnv("./testdata", "valid")))
+	assert.Nil(t, s.RunMigrateCommand([]string{"to", "1"}))
+
+	var status strings.Builder
+	assert.Nil(t, s.PrintMigrationStatus(&status))
+	assert.Equal(t, "VERSION  NAME          STATUS\n"+
+		"1        create_table  applied\n"+
+		"2        insert_data   pending\n", status.String())
+
+	assert.Nil(t, s.RunMigrateCommand([]string{"up"}))
+
+	var count int
+	assert.Nil(t, s.DB.Get(&count, "SELECT COUNT(*) FROM test_table WHERE name = 'migrated'"))
+	assert.Equal(t, 1, count)
+
+	assert.Nil(t, s.RunMigrateCommand([]string{"down", "1"}))
+	assert.EqualError(t, s.RunMigrateCommand([]string{"sideways"}),
+		"usage: migrate up|down [steps]|to <version>|status")
+	assert.Nil(t, s.Close())
+}
+
+func TestMySQL_ErrorMissingMigrationsDir(t *testing.T) {
+	s := &mysqlboot.MySQL{}
+
+	_, err := s.MigrationStatus()
+	assert.EqualError(t, err, "no MySQL migrations directory set")
+}
+
+func TestMySQL_CloseAfterFailedConfigure(t *testing.T) {
+	s := &mysqlboot.MySQL{}
+	err := s.Configure(goboot.NewAppEnv("./testdata", "unreachable"))
+	assert.ErrorContains(t, err, "connecting to MySQL")
+	assert.Nil(t, s.Close())
+
+	s = &mysqlboot.MySQL{}
+	err = s.Configure(goboot.NewAppEnv("./testdata", "missing-root-cert"))
+	assert.ErrorContains(t, err, "reading MySQL root CA")
+	assert.Nil(t, s.Close())
+}
+
+func TestMySQL_ErrorMissingConfig(t *testing.T) {
+	s := &mysqlboot.MySQL{}
+	err := s.Configure(goboot.NewAppEnv("./testdata", ""))
+	assert.EqualError(t, err, "missing MySQL configuration")
+}
+
+func TestMySQL_ErrorMissingDSN(t *testing.T) {
+	s := &mysqlboot.MySQL{}
+	err := s.Configure(goboot.NewAppEnv("./testdata", "no-dsn"))
+	assert.EqualError(t, err, "config \"mysql.dsn\" is required")
+}
+
+func TestMySQL_ErrorInvalidDSN(t *testing.T) {
+	s := &mysqlboot.MySQL{}
+	err := s.Configure(goboot.NewAppEnv("./testdata", "invalid-dsn"))
+	assert.ErrorContains(t, err, "invalid MySQL dsn")
+}
@@ -0,0 +1,333 @@
+package pgboot
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/nielskrijger/goboot"
+	"github.com/rs/zerolog"
+)
+
+// OutboxTable is the table containing events to be published by the OutboxRelay.
+const OutboxTable = "outbox"
+
+const (
+	defaultOutboxPollInterval = time.Second
+	defaultOutboxBatchSize    = 100
+	defaultOutboxMaxAttempts  = 5
+)
+
+var errMissingOutboxChannel = errors.New("config \"outbox.channel\" is required")
+
+// outboxLockID is the Postgres advisory lock key held while relaying.
+var outboxLockID = advisoryLockID(OutboxTable)
+
+// Publisher publishes events, e.g. *pubsubboot.PubSub.
+type Publisher interface {
+	PublishEvent(ctx context.Context, channel string, eventName string, payload any) error
+}
+
+type OutboxConfig struct {
+	// Channel to publish events to.
+	Channel string `yaml:"channel"`
+
+	// Time between polling the outbox table. Default is 1 second. Set a
+	// negative interval, e.g. -1s, to disable polling and call Relay instead.
+	PollInterval time.Duration `yaml:"pollInterval"`
+
+	// Maximum number of events published per poll. Default is 100.
+	BatchSize int `yaml:"batchSize"`
+
+	// Number of publish attempts before an event is marked as failed and
+	// skipped from then on. Default is 5.
+	MaxAttempts int `yaml:"maxAttempts"`
+}
+
+// WriteOutbox stores an event in the outbox table as part of transaction tx.
+// The event is published by the OutboxRelay once the transaction commits,
+// which guarantees events are published if and only if the transaction succeeds.
+//
+// Events may be published more than once so consumers must be idempotent.
+func WriteOutbox(ctx context.Context, tx *sqlx.Tx, eventName string, payload any) error {
+	b, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("marshalling outbox event %q: %w", eventName, err)
+	}
+
+	_, err = tx.ExecContext(ctx, `INSERT INTO `+OutboxTable+` (event, payload) VALUES ($1, $2)`, eventName, b)
+	if err != nil {
+		return fmt.Errorf("writing outbox event %q: %w", eventName, err)
+	}
+
+	return nil
+}
+
+// OutboxRelay implements the AppService interface. It polls the outbox table
+// and publishes events in insertion order, a failed event is retried on the
+// next poll before any later events. Only one relay publishes at a time, other
+// instances skip their poll while a batch is in progress.
+//
+// Each event is marked as sent right after it's published, an event is
+// published again when marking it fails or the process stops in between.
+//
+// Events failing to publish "outbox.maxAttempts" times are poison events; they're
+// marked as failed and logged, and require manual intervention. Later events
+// are published without waiting for them.
+type OutboxRelay struct {
+	// Elector restricts polling to the leader when set, e.g. the Elector of
+	// Postgres.NewElector. By default all instances poll.
+	Elector goboot.Elector
+
+	config    *OutboxConfig
+	pg        *Postgres
+	publisher Publisher
+	log       zerolog.Logger
+	stop      chan struct{}
+	stopOnce  sync.Once
+	wg        sync.WaitGroup
+}
+
+type outboxEvent struct {
+	ID       int64           `db:"id"`
+	Event    string          `db:"event"`
+	Payload  json.RawMessage `db:"payload"`
+	Attempts int             `db:"attempts"`
+}
+
+// NewOutboxRelay creates a relay publishing outbox events of pg using publisher.
+func NewOutboxRelay(pg *Postgres, publisher Publisher) *OutboxRelay {
+	return &OutboxRelay{
+		pg:        pg,
+		publisher: publisher,
+		stop:      make(chan struct{}),
+	}
+}
+
+func (s *OutboxRelay) Name() string {
+	return "OutboxRelay"
+}
+
+func (s *OutboxRelay) Configure(env *goboot.AppEnv) error {
+	s.log = env.Log
+	s.config = &OutboxConfig{}
+
+	if env.Config.InConfig("outbox") {
+		if err := env.Config.Sub("outbox").Unmarshal(s.config); err != nil {
+			return fmt.Errorf("parsing outbox configuration: %w", err)
+		}
+	}
+
+	if s.config.Channel == "" {
+		return errMissingOutboxChannel
+	}
+
+	if s.config.PollInterval == 0 {
+		s.config.PollInterval = defaultOutboxPollInterval
+	}
+
+	if s.config.BatchSize == 0 {
+		s.config.BatchSize = defaultOutboxBatchSize
+	}
+
+	if s.config.MaxAttempts == 0 {
+		s.config.MaxAttempts = defaultOutboxMaxAttempts
+	}
+
+	return nil
+}
+
+// Init creates the outbox table if it doesn't exist and starts polling unless
+// polling is disabled.
+func (s *OutboxRelay) Init() error {
+	_, err := s.pg.DB.Exec(`CREATE TABLE IF NOT EXISTS ` + OutboxTable + ` (
+		id bigserial PRIMARY KEY,
+		event text NOT NULL,
+		payload jsonb NOT NULL,
+		created_at timestamptz NOT NULL DEFAULT now(),
+		sent_at timestamptz,
+		failed_at timestamptz,
+		attempts int NOT NULL DEFAULT 0,
+		last_error text
+	)`)
+	if err != nil {
+		return fmt.Errorf("creating %s table: %w", OutboxTable, err)
+	}
+
+	if s.config.PollInterval < 0 {
+		return nil
+	}
+
+	s.wg.Add(1)
+
+	go s.poll()
+
+	return nil
+}
+
+// Close stops polling and waits for the current batch to finish. Calling
+// Close more than once has no effect.
+func (s *OutboxRelay) Close() error {
+	s.stopOnce.Do(func() {
+		close(s.stop)
+	})
+
+	s.wg.Wait()
+
+	return nil
+}
+
+func (s *OutboxRelay) poll() {
+	defer s.wg.Done()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go func() {
+		<-s.stop
+		cancel()
+	}()
+
+	if s.Elector == nil {
+		s.relayLoop(ctx)
+
+		return
+	}
+
+	err := s.Elector.RunWhenLeader(ctx, func(ctx context.Context) error {
+		s.relayLoop(ctx)
+
+		return nil
+	})
+	if err != nil {
+		s.log.Error().Err(err).Msg("failed to elect outbox relay leader")
+	}
+}
+
+// relayLoop relays outbox events until ctx is done.
+func (s *OutboxRelay) relayLoop(ctx context.Context) {
+	for {
+		n, err := s.Relay(ctx)
+		if err != nil && ctx.Err() == nil {
+			s.log.Error().Err(err).Msg("failed to relay outbox events")
+		}
+
+		// continue immediately when there may be more events waiting
+		if err == nil && n == s.config.BatchSize {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(s.config.PollInterval):
+		}
+	}
+}
+
+// Relay publishes a single batch of pending outbox events and returns the
+// number of events published or marked as failed. The batch stops at the
+// first event that fails to publish and will be retried, which isn't counted.
+// An advisory lock is held while publishing, when another relay holds it Relay
+// returns without publishing to keep the insertion order.
+func (s *OutboxRelay) Relay(ctx context.Context) (int, error) {
+	conn, err := s.pg.DB.Connx(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("acquiring connection for %s lock: %w", OutboxTable, err)
+	}
+
+	defer func() {
+		_ = conn.Close()
+	}()
+
+	var locked bool
+
+	if err := conn.GetContext(ctx, &locked, "SELECT pg_try_advisory_lock($1)", outboxLockID); err != nil {
+		return 0, fmt.Errorf("acquiring %s lock: %w", OutboxTable, err)
+	}
+
+	if !locked {
+		return 0, nil
+	}
+
+	defer s.unlock(conn)
+
+	var events []*outboxEvent
+
+	err = conn.SelectContext(ctx, &events, `SELECT id, event, payload, attempts FROM `+OutboxTable+`
+		WHERE sent_at IS NULL AND failed_at IS NULL
+		ORDER BY id
+		LIMIT $1`, s.config.BatchSize)
+	if err != nil {
+		return 0, fmt.Errorf("reading %s: %w", OutboxTable, err)
+	}
+
+	n := 0
+
+	for _, e := range events {
+		retry, err := s.publish(ctx, conn, e)
+		if err != nil {
+			return n, err
+		}
+
+		// later events wait for the retry to keep the insertion order
+		if retry {
+			break
+		}
+
+		n++
+	}
+
+	return n, nil
+}
+
+// unlock releases the outbox lock of conn. The connection is discarded when
+// that fails, it would keep the lock while idle in the pool otherwise.
+func (s *OutboxRelay) unlock(conn *sqlx.Conn) {
+	if _, err := conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", outboxLockID); err != nil {
+		s.log.Warn().Err(err).Msgf("failed to release %s lock", OutboxTable)
+
+		_ = conn.Raw(func(any) error {
+			return driver.ErrBadConn
+		})
+	}
+}
+
+// publish publishes the event and records the result, it returns true if the
+// event failed to publish and will be retried.
+func (s *OutboxRelay) publish(ctx context.Context, conn *sqlx.Conn, e *outboxEvent) (bool, error) {
+	pubErr := s.publisher.PublishEvent(ctx, s.config.Channel, e.Event, e.Payload)
+	if pubErr == nil {
+		_, err := conn.ExecContext(ctx, `UPDATE `+OutboxTable+` SET sent_at = now(), attempts = attempts + 1
+			WHERE id = $1`, e.ID)
+		if err != nil {
+			return false, fmt.Errorf("marking outbox event %d as sent: %w", e.ID, err)
+		}
+
+		return false, nil
+	}
+
+	attempts := e.Attempts + 1
+	failed := attempts >= s.config.MaxAttempts
+
+	if failed {
+		s.log.Error().Err(pubErr).Int64("id", e.ID).Msgf("giving up publishing outbox event %q after %d attempts",
+			e.Event, attempts)
+	} else {
+		s.log.Warn().Err(pubErr).Int64("id", e.ID).Msgf("failed to publish outbox event %q", e.Event)
+	}
+
+	_, err := conn.ExecContext(ctx, `UPDATE `+OutboxTable+`
+		SET attempts = $2, last_error = $3, failed_at = CASE WHEN $4 THEN now() END
+		WHERE id = $1`, e.ID, attempts, pubErr.Error(), failed)
+	if err != nil {
+		return false, fmt.Errorf("recording outbox event %d failure: %w", e.ID, err)
+	}
+
+	return !failed, nil
+}
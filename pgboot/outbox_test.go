@@ -0,0 +1,237 @@
+package pgboot_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/nielskrijger/goboot"
+	"github.com/nielskrijger/goboot/pgboot"
+	"github.com/stretchr/testify/assert"
+)
+
+type publishedEvent struct {
+	channel string
+	event   string
+	payload string
+}
+
+type testPublisher struct {
+	mu        sync.Mutex
+	published []publishedEvent
+	err       error
+	failEvent string
+
+	// publishing receives each event before it waits for block, when set
+	publishing chan string
+	block      chan struct{}
+}
+
+func (p *testPublisher) PublishEvent(_ context.Context, channel string, event string, payload any) error {
+	if p.block != nil {
+		p.publishing <- event
+		<-p.block
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.err != nil {
+		return p.err
+	}
+
+	if event == p.failEvent {
+		return errors.New("publish failed")
+	}
+
+	b, _ := json.Marshal(payload)
+	p.published = append(p.published, publishedEvent{channel, event, string(b)})
+
+	return nil
+}
+
+func (p *testPublisher) events() []publishedEvent {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return append([]publishedEvent{}, p.published...)
+}
+
+func setupOutbox(t *testing.T, publisher pgboot.Publisher) (*pgboot.Postgres, *pgboot.OutboxRelay) {
+	t.Helper()
+
+	env := goboot.NewAppEnv("./testdata", "outbox")
+	pg := &pgboot.Postgres{}
+	assert.Nil(t, pg.Configure(env))
+
+	relay := pgboot.NewOutboxRelay(pg, publisher)
+	assert.Nil(t, relay.Configure(env))
+	assert.Nil(t, relay.Init())
+
+	_, err := pg.DB.Exec("TRUNCATE " + pgboot.OutboxTable)
+	assert.Nil(t, err)
+
+	return pg, relay
+}
+
+func writeOutbox(t *testing.T, pg *pgboot.Postgres, event string, payload any) {
+	t.Helper()
+
+	err := pg.RunInTx(context.Background(), nil, func(ctx context.Context, tx *sqlx.Tx) error {
+		return pgboot.WriteOutbox(ctx, tx, event, payload)
+	})
+	assert.Nil(t, err)
+}
+
+func TestOutboxRelay_Publish(t *testing.T) {
+	publisher := &testPublisher{}
+	pg, relay := setupOutbox(t, publisher)
+
+	writeOutbox(t, pg, "user.created", map[string]string{"id": "1"})
+	writeOutbox(t, pg, "user.deleted", map[string]string{"id": "1"})
+
+	n, err := relay.Relay(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, []publishedEvent{
+		{"events", "user.created", `{"id":"1"}`},
+		{"events", "user.deleted", `{"id":"1"}`},
+	}, publisher.events())
+
+	n, err = relay.Relay(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, 0, n)
+
+	assert.Nil(t, relay.Close())
+	assert.Nil(t, pg.Close())
+}
+
+func TestOutboxRelay_PoisonEvent(t *testing.T) {
+	publisher := &testPublisher{err: errors.New("publish failed")}
+	pg, relay := setupOutbox(t, publisher)
+
+	writeOutbox(t, pg, "user.created", map[string]string{"id": "1"})
+
+	for i := 0; i < 3; i++ {
+		_, err := relay.Relay(context.Background())
+		assert.Nil(t, err)
+	}
+
+	var lastError string
+	assert.Nil(t, pg.DB.Get(&lastError, "SELECT last_error FROM "+pgboot.OutboxTable+" WHERE failed_at IS NOT NULL"))
+	assert.Equal(t, "publish failed", lastError)
+
+	assert.Nil(t, relay.Close())
+	assert.Nil(t, pg.Close())
+}
+
+func TestOutboxRelay_StopsBatchOnFailure(t *testing.T) {
+	publisher := &testPublisher{failEvent: "user.created"}
+	pg, relay := setupOutbox(t, publisher)
+
+	writeOutbox(t, pg, "user.created", map[string]string{"id": "1"})
+	writeOutbox(t, pg, "user.deleted", map[string]string{"id": "1"})
+
+	// the retried event isn't counted, so the relay loop waits before retrying
+	n, err := relay.Relay(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, 0, n)
+	assert.Empty(t, publisher.events())
+
+	assert.Nil(t, relay.Close())
+	assert.Nil(t, relay.Close())
+	assert.Nil(t, pg.Close())
+}
+
+func TestOutboxRelay_SkipsWhileLocked(t *testing.T) {
+	// the blocking publisher keeps the lock of the first relay while the other polls
+	publisher := &testPublisher{publishing: make(chan string), block: make(chan struct{})}
+	pg, relay := setupOutbox(t, publisher)
+
+	writeOutbox(t, pg, "user.created", map[string]string{"id": "1"})
+
+	other := pgboot.NewOutboxRelay(pg, publisher)
+	assert.Nil(t, other.Configure(goboot.NewAppEnv("./testdata", "outbox")))
+
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		n, err := relay.Relay(context.Background())
+		assert.Nil(t, err)
+		assert.Equal(t, 1, n)
+	}()
+
+	<-publisher.publishing
+
+	n, err := other.Relay(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, 0, n)
+
+	close(publisher.block)
+	<-done
+
+	assert.Len(t, publisher.events(), 1)
+	assert.Nil(t, relay.Close())
+	assert.Nil(t, pg.Close())
+}
+
+func TestOutboxRelay_Poll(t *testing.T) {
+	env := goboot.NewAppEnv("./testdata", "outbox")
+	env.Config.Set("outbox.pollInterval", "10ms")
+
+	pg := &pgboot.Postgres{}
+	assert.Nil(t, pg.Configure(env))
+
+	_, _ = pg.DB.Exec("TRUNCATE " + pgboot.OutboxTable)
+
+	publisher := &testPublisher{}
+	relay := pgboot.NewOutboxRelay(pg, publisher)
+	assert.Nil(t, relay.Configure(env))
+	assert.Nil(t, relay.Init())
+
+	writeOutbox(t, pg, "user.created", map[string]string{"id": "1"})
+
+	assert.Eventually(t, func() bool {
+		return len(publisher.events()) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+
+	assert.Nil(t, relay.Close())
+	assert.Nil(t, pg.Close())
+}
+
+func TestOutboxRelay_ErrorMissingChannel(t *testing.T) {
+	relay := pgboot.NewOutboxRelay(&pgboot.Postgres{}, &testPublisher{})
+	err := relay.Configure(goboot.NewAppEnv("./testdata", "valid"))
+	assert.EqualError(t, err, "config \"outbox.channel\" is required")
+}
+
+func TestOutboxRelay_PollWithElector(t *testing.T) {
+	env := goboot.NewAppEnv("./testdata", "outbox")
+	env.Config.Set("outbox.pollInterval", "10ms")
+
+	pg := &pgboot.Postgres{}
+	assert.Nil(t, pg.Configure(env))
+
+	_, _ = pg.DB.Exec("TRUNCATE " + pgboot.OutboxTable)
+
+	publisher := &testPublisher{}
+	relay := pgboot.NewOutboxRelay(pg, publisher)
+	relay.Elector = pg.NewElector("test-outbox-relay", 100*time.Millisecond)
+	assert.Nil(t, relay.Configure(env))
+	assert.Nil(t, relay.Init())
+
+	writeOutbox(t, pg, "user.created", map[string]string{"id": "1"})
+
+	assert.Eventually(t, func() bool {
+		return len(publisher.events()) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+
+	assert.Nil(t, relay.Close())
+	assert.Nil(t, pg.Close())
+}
@@ -0,0 +1,149 @@
+package pgtest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jmoiron/sqlx"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	errUnsupportedFixture = errors.New("unsupported fixture file")
+	errInvalidFixture     = errors.New("fixture must contain a map of tables")
+)
+
+// LoadFixtures loads fixture files into db in the order specified. Files are
+// either SQL files (.sql) which are executed as-is, or YAML files (.yaml/.yml)
+// containing rows per table:
+//
+//	users:
+//	  - id: 1
+//	    name: Alice
+//	  - id: 2
+//	    name: Bob
+//
+// Tables in YAML files are inserted in document order.
+func LoadFixtures(t testing.TB, db *sqlx.DB, files ...string) {
+	t.Helper()
+
+	for _, file := range files {
+		var err error
+
+		switch filepath.Ext(file) {
+		case ".sql":
+			err = loadSQLFixture(db, file)
+		case ".yaml", ".yml":
+			err = loadYAMLFixture(db, file)
+		default:
+			err = fmt.Errorf("%w: %q", errUnsupportedFixture, file)
+		}
+
+		if err != nil {
+			t.Fatalf("loading fixtures: %v", err)
+		}
+	}
+}
+
+func loadSQLFixture(db *sqlx.DB, file string) error {
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return fmt.Errorf("reading fixture %q: %w", file, err)
+	}
+
+	if _, err := db.Exec(string(b)); err != nil {
+		return fmt.Errorf("executing fixture %q: %w", file, err)
+	}
+
+	return nil
+}
+
+func loadYAMLFixture(db *sqlx.DB, file string) error {
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return fmt.Errorf("reading fixture %q: %w", file, err)
+	}
+
+	// decode into a node to preserve the table order
+	var doc yaml.Node
+	if err := yaml.Unmarshal(b, &doc); err != nil {
+		return fmt.Errorf("parsing fixture %q: %w", file, err)
+	}
+
+	if len(doc.Content) == 0 {
+		return nil
+	}
+
+	tables := doc.Content[0]
+	if tables.Kind != yaml.MappingNode {
+		return fmt.Errorf("%w: %q", errInvalidFixture, file)
+	}
+
+	tx, err := db.Beginx()
+	if err != nil {
+		return fmt.Errorf("starting transaction: %w", err)
+	}
+
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	for i := 0; i < len(tables.Content); i += 2 {
+		table := tables.Content[i].Value
+
+		var rows []map[string]any
+		if err := tables.Content[i+1].Decode(&rows); err != nil {
+			return fmt.Errorf("parsing rows of table %q in fixture %q: %w", table, file, err)
+		}
+
+		for _, row := range rows {
+			if err := insertRow(tx, table, row); err != nil {
+				return fmt.Errorf("fixture %q: %w", file, err)
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("committing fixture %q: %w", file, err)
+	}
+
+	return nil
+}
+
+func insertRow(tx *sqlx.Tx, table string, row map[string]any) error {
+	columns := make([]string, 0, len(row))
+	for column := range row {
+		columns = append(columns, column)
+	}
+
+	sort.Strings(columns)
+
+	quoted := make([]string, len(columns))
+	placeholders := make([]string, len(columns))
+	args := make([]any, len(columns))
+
+	for i, column := range columns {
+		quoted[i] = pgx.Identifier{column}.Sanitize()
+		placeholders[i] = fmt.Sprintf("$%d", i+1)
+		args[i] = row[column]
+	}
+
+	query := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)",
+		pgx.Identifier(strings.Split(table, ".")).Sanitize(),
+		strings.Join(quoted, ", "),
+		strings.Join(placeholders, ", "),
+	)
+
+	if _, err := tx.ExecContext(context.Background(), query, args...); err != nil {
+		return fmt.Errorf("inserting into %q: %w", table, err)
+	}
+
+	return nil
+}
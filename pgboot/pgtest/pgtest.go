@@ -0,0 +1,288 @@
+// Package pgtest provides helpers for tests using the Postgres service.
+//
+// Each test gets a throwaway database created from a migrated template
+// database, so tests don't share mutable state and can run in parallel.
+package pgtest
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"hash/fnv"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jmoiron/sqlx"
+	"github.com/nielskrijger/goboot"
+	"github.com/nielskrijger/goboot/pgboot"
+)
+
+// maxBaseNameLength keeps database names within the 63 character limit.
+const maxBaseNameLength = 30
+
+var (
+	templatesMu sync.Mutex
+	templates   = make(map[string]bool)
+)
+
+// NewDatabase creates a new database for the duration of test t and returns a
+// Postgres service connected to it. The database is dropped on cleanup.
+//
+// The "postgres" config of confDir and env is used to connect to the server.
+// The migrations in migrationsDir run once per test binary in a template
+// database, subsequent databases are copies of the template which is a lot
+// faster than running migrations for each test.
+func NewDatabase(t testing.TB, confDir string, env string, migrationsDir string) *pgboot.Postgres {
+	t.Helper()
+
+	admin := configure(t, confDir, env, "")
+	defer func() { _ = admin.Close() }()
+
+	template, err := ensureTemplate(t, admin, confDir, env, migrationsDir)
+	if err != nil {
+		t.Fatalf("creating template database: %v", err)
+	}
+
+	name := template + "_" + randomSuffix(t)
+
+	_, err = admin.DB.Exec("CREATE DATABASE " + pgx.Identifier{name}.Sanitize() +
+		" TEMPLATE " + pgx.Identifier{template}.Sanitize())
+	if err != nil {
+		t.Fatalf("creating test database: %v", err)
+	}
+
+	pg := configure(t, confDir, env, withDatabase(t, admin.DSN(), name))
+
+	t.Cleanup(func() {
+		_ = pg.Close()
+
+		admin := configure(t, confDir, env, "")
+		defer func() { _ = admin.Close() }()
+
+		if err := dropDatabase(admin.DB, name); err != nil {
+			t.Errorf("dropping test database: %v", err)
+		}
+	})
+
+	return pg
+}
+
+// configure returns a configured Postgres service, overriding the DSN when set.
+func configure(t testing.TB, confDir string, env string, dsn string) *pgboot.Postgres {
+	t.Helper()
+
+	appEnv := goboot.NewAppEnv(confDir, env)
+
+	if dsn != "" {
+		cfg := appEnv.Config.GetStringMap("postgres")
+		cfg["dsn"] = dsn
+		appEnv.Config.Set("postgres", cfg)
+	}
+
+	pg := &pgboot.Postgres{}
+	if err := pg.Configure(appEnv); err != nil {
+		t.Fatalf("configuring Postgres: %v", err)
+	}
+
+	return pg
+}
+
+// ensureTemplate creates and migrates the template database if it doesn't
+// exist yet. The template name contains a hash of the migrations so changing
+// the migrations creates a new template.
+func ensureTemplate(t testing.TB, admin *pgboot.Postgres, confDir, env, migrationsDir string) (string, error) {
+	t.Helper()
+
+	config, err := pgx.ParseConfig(admin.DSN())
+	if err != nil {
+		return "", fmt.Errorf("invalid Postgres dsn: %w", err)
+	}
+
+	hash, err := hashMigrations(migrationsDir)
+	if err != nil {
+		return "", err
+	}
+
+	name := config.Database
+	if len(name) > maxBaseNameLength {
+		name = name[:maxBaseNameLength]
+	}
+
+	name += "_tmpl_" + hash
+
+	templatesMu.Lock()
+	defer templatesMu.Unlock()
+
+	if templates[name] {
+		return name, nil
+	}
+
+	// Tests of other packages run in separate processes and may create the
+	// same template concurrently.
+	ctx := context.Background()
+
+	conn, err := admin.DB.Connx(ctx)
+	if err != nil {
+		return "", fmt.Errorf("acquiring connection: %w", err)
+	}
+	defer conn.Close()
+
+	lockID := int64(fnvHash(name))
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", lockID); err != nil {
+		return "", fmt.Errorf("acquiring template lock: %w", err)
+	}
+
+	defer func() {
+		_, _ = conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", lockID)
+	}()
+
+	var exists bool
+	if err := conn.GetContext(ctx, &exists, "SELECT EXISTS (SELECT 1 FROM pg_database WHERE datname = $1)", name); err != nil {
+		return "", fmt.Errorf("checking template database: %w", err)
+	}
+
+	if !exists {
+		if err := createTemplate(t, conn, admin.DSN(), confDir, env, migrationsDir, name); err != nil {
+			return "", err
+		}
+	}
+
+	templates[name] = true
+
+	return name, nil
+}
+
+func createTemplate(t testing.TB, conn *sqlx.Conn, adminDSN, confDir, env, migrationsDir, name string) error {
+	t.Helper()
+
+	if _, err := conn.ExecContext(context.Background(), "CREATE DATABASE "+pgx.Identifier{name}.Sanitize()); err != nil {
+		return fmt.Errorf("creating database %q: %w", name, err)
+	}
+
+	pg := configure(t, confDir, env, withDatabase(t, adminDSN, name))
+	pg.MigrationsDir = migrationsDir
+	err := pg.Init()
+	_ = pg.Close()
+
+	if err != nil {
+		_ = dropDatabase(conn, name)
+
+		return fmt.Errorf("migrating template database: %w", err)
+	}
+
+	// a database can't be copied while other sessions are connected to it
+	if err := terminateConnections(conn, name); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// execer is implemented by both *sqlx.DB and *sqlx.Conn.
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+func terminateConnections(db execer, name string) error {
+	_, err := db.ExecContext(context.Background(), `SELECT pg_terminate_backend(pid) FROM pg_stat_activity
+		WHERE datname = $1 AND pid <> pg_backend_pid()`, name)
+	if err != nil {
+		return fmt.Errorf("terminating connections to %q: %w", name, err)
+	}
+
+	return nil
+}
+
+func dropDatabase(db execer, name string) error {
+	if err := terminateConnections(db, name); err != nil {
+		return err
+	}
+
+	if _, err := db.ExecContext(context.Background(), "DROP DATABASE IF EXISTS "+pgx.Identifier{name}.Sanitize()); err != nil {
+		return fmt.Errorf("dropping database %q: %w", name, err)
+	}
+
+	return nil
+}
+
+// withDatabase returns dsn connecting to database instead. Both URL and
+// keyword/value DSNs accept a "dbname" parameter overriding the database.
+func withDatabase(t testing.TB, dsn string, database string) string {
+	t.Helper()
+
+	if _, err := pgx.ParseConfig(dsn); err != nil {
+		t.Fatalf("invalid Postgres dsn: %v", err)
+	}
+
+	if !strings.HasPrefix(dsn, "postgres://") && !strings.HasPrefix(dsn, "postgresql://") {
+		return dsn + " dbname='" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(database) + "'"
+	}
+
+	u, err := url.Parse(dsn)
+	if err != nil {
+		t.Fatalf("invalid Postgres dsn: %v", err)
+	}
+
+	q := u.Query()
+	q.Set("dbname", database)
+	u.RawQuery = q.Encode()
+
+	return u.String()
+}
+
+func hashMigrations(dir string) (string, error) {
+	h := sha256.New()
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", fmt.Errorf("reading migrations directory: %w", err)
+	}
+
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			names = append(names, entry.Name())
+		}
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		b, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return "", fmt.Errorf("reading migration %q: %w", name, err)
+		}
+
+		_, _ = h.Write([]byte(name))
+		_, _ = h.Write(b)
+	}
+
+	return hex.EncodeToString(h.Sum(nil))[:12], nil
+}
+
+func fnvHash(s string) uint32 {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(s))
+
+	return h.Sum32()
+}
+
+func randomSuffix(t testing.TB) string {
+	t.Helper()
+
+	b := make([]byte, 6)
+	if _, err := rand.Read(b); err != nil {
+		t.Fatalf("generating database name: %v", err)
+	}
+
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,34 @@
+package pgtest_test
+
+import (
+	"testing"
+
+	"github.com/nielskrijger/goboot/pgboot/pgtest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewDatabase_Isolated(t *testing.T) {
+	for i := 0; i < 2; i++ {
+		pg := pgtest.NewDatabase(t, "./testdata", "test", "../testdata/migrations")
+
+		_, err := pg.DB.Exec("INSERT INTO test_table (name) VALUES ('isolated')")
+		assert.Nil(t, err)
+	}
+}
+
+func TestNewDatabase_KeywordDSN(t *testing.T) {
+	pg := pgtest.NewDatabase(t, "./testdata", "keyword-dsn", "../testdata/migrations")
+
+	var name string
+	assert.Nil(t, pg.DB.Get(&name, "SELECT current_database()"))
+	assert.Regexp(t, "^goboot_tmpl_", name)
+}
+
+func TestLoadFixtures(t *testing.T) {
+	pg := pgtest.NewDatabase(t, "./testdata", "test", "../testdata/migrations")
+	pgtest.LoadFixtures(t, pg.DB, "./testdata/fixtures.yaml", "./testdata/fixtures.sql")
+
+	var names []string
+	assert.Nil(t, pg.DB.Select(&names, "SELECT name FROM test_table WHERE name LIKE 'fixture-%' ORDER BY name"))
+	assert.Equal(t, []string{"fixture-1", "fixture-2", "fixture-3"}, names)
+}
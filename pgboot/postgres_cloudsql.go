@@ -0,0 +1,59 @@
+package pgboot
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	"cloud.google.com/go/cloudsqlconn"
+	"github.com/jackc/pgx/v4"
+)
+
+// newCloudSQLDialer creates a Cloud SQL connector dialer when "postgres.cloudSQLInstance"
+// is set. The dialer uses Application Default Credentials to authenticate.
+func (s *Postgres) newCloudSQLDialer() error {
+	if s.config.CloudSQLInstance == "" {
+		return nil
+	}
+
+	var opts []cloudsqlconn.Option
+
+	if s.config.CloudSQLIAMAuth {
+		opts = append(opts, cloudsqlconn.WithIAMAuthN())
+	}
+
+	dialer, err := cloudsqlconn.NewDialer(context.Background(), opts...)
+	if err != nil {
+		return fmt.Errorf("creating Cloud SQL dialer: %w", err)
+	}
+
+	s.dialer = dialer
+
+	s.log.Info().
+		Bool("iamAuth", s.config.CloudSQLIAMAuth).
+		Msgf("connecting to Cloud SQL instance %s through the Cloud SQL connector", s.config.CloudSQLInstance)
+
+	return nil
+}
+
+// useCloudSQLDialer routes connections through the Cloud SQL connector. The
+// connector encrypts the connection itself so the pgx TLS settings are cleared.
+func (s *Postgres) useCloudSQLDialer(connConfig *pgx.ConnConfig) {
+	connConfig.DialFunc = func(ctx context.Context, _ string, _ string) (net.Conn, error) {
+		return s.dialer.Dial(ctx, s.config.CloudSQLInstance) //nolint:wrapcheck
+	}
+	connConfig.TLSConfig = nil
+	connConfig.Fallbacks = nil
+}
+
+func (s *Postgres) closeCloudSQLDialer() error {
+	if s.dialer == nil {
+		return nil
+	}
+
+	if err := s.dialer.Close(); err != nil {
+		return fmt.Errorf("closing Cloud SQL dialer: %w", err)
+	}
+
+	return nil
+}
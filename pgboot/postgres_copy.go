@@ -0,0 +1,68 @@
+package pgboot
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/stdlib"
+)
+
+// CopyFrom bulk inserts rows into table using the COPY protocol and returns
+// the number of rows inserted. This is much faster than inserting rows one by
+// one. Use pgx.CopyFromRows or pgx.CopyFromSlice to create rows from a slice,
+// or implement pgx.CopyFromSource to stream rows.
+//
+// Table may be schema-qualified, e.g. "public.users".
+func (s *Postgres) CopyFrom(ctx context.Context, table string, columns []string, rows pgx.CopyFromSource) (int64, error) {
+	var n int64
+
+	err := s.withConn(ctx, func(conn *pgx.Conn) error {
+		var err error
+
+		n, err = conn.CopyFrom(ctx, pgx.Identifier(strings.Split(table, ".")), columns, rows)
+
+		return err //nolint:wrapcheck
+	})
+	if err != nil {
+		return n, fmt.Errorf("copying rows into %q: %w", table, err)
+	}
+
+	return n, nil
+}
+
+// CopyTo exports the results of query as CSV including a header row to w
+// using the COPY protocol and returns the number of rows written.
+//
+// The query can't contain parameters, e.g. "SELECT id, name FROM users".
+func (s *Postgres) CopyTo(ctx context.Context, w io.Writer, query string) (int64, error) {
+	var n int64
+
+	err := s.withConn(ctx, func(conn *pgx.Conn) error {
+		tag, err := conn.PgConn().CopyTo(ctx, w, "COPY ("+query+") TO STDOUT WITH (FORMAT csv, HEADER)")
+		n = tag.RowsAffected()
+
+		return err //nolint:wrapcheck
+	})
+	if err != nil {
+		return n, fmt.Errorf("copying rows: %w", err)
+	}
+
+	return n, nil
+}
+
+// withConn runs fn with the underlying pgx connection of a pooled connection.
+func (s *Postgres) withConn(ctx context.Context, fn func(conn *pgx.Conn) error) error {
+	conn, err := s.DB.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("acquiring connection: %w", err)
+	}
+
+	defer conn.Close()
+
+	return conn.Raw(func(driverConn any) error { //nolint:wrapcheck
+		return fn(driverConn.(*stdlib.Conn).Conn()) //nolint:forcetypeassert
+	})
+}
@@ -0,0 +1,29 @@
+package pgboot_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostgres_CopyFromAndTo(t *testing.T) {
+	pg := setupRepository(t)
+	ctx := context.Background()
+
+	n, err := pg.CopyFrom(ctx, "users", []string{"email", "name", "version"}, pgx.CopyFromRows([][]any{
+		{"alice@example.com", "Alice", 1},
+		{"bob@example.com", "Bob", 1},
+	}))
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), n)
+
+	var buf bytes.Buffer
+
+	n, err = pg.CopyTo(ctx, &buf, "SELECT email, name FROM users ORDER BY email")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), n)
+	assert.Equal(t, "email,name\nalice@example.com,Alice\nbob@example.com,Bob\n", buf.String())
+}
@@ -0,0 +1,76 @@
+package pgboot
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// dsnPasswordRegexp matches the password of a keyword/value DSN, which may be
+// quoted, e.g. "password=secret" or "password='my secret'".
+var dsnPasswordRegexp = regexp.MustCompile(`password\s*=\s*('(?:[^'\\]|\\.)*'|\S+)`)
+
+// isURLDSN returns true if dsn is a URL like "postgres://user@host/db" rather
+// than keyword/value pairs like "host=localhost dbname=app".
+func isURLDSN(dsn string) bool {
+	return strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://")
+}
+
+// setDSNParams returns dsn with the non-empty params set, replacing the
+// values in the DSN. Query parameters of URL DSNs starting with "x-" are
+// reserved for golang-migrate and removed, see migrateConfig.
+func setDSNParams(dsn string, params [][2]string) (string, error) {
+	if !isURLDSN(dsn) {
+		// later keywords take precedence over earlier ones
+		for _, p := range params {
+			if p[1] != "" {
+				dsn += " " + p[0] + "=" + quoteDSNValue(p[1])
+			}
+		}
+
+		return dsn, nil
+	}
+
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return "", err //nolint:wrapcheck
+	}
+
+	q := u.Query()
+	for k := range q {
+		if strings.HasPrefix(k, "x-") {
+			q.Del(k)
+		}
+	}
+
+	for _, p := range params {
+		if p[1] != "" {
+			q.Set(p[0], p[1])
+		}
+	}
+
+	u.RawQuery = q.Encode()
+
+	return u.String(), nil
+}
+
+// quoteDSNValue quotes a value of a keyword/value DSN.
+func quoteDSNValue(value string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(value) + "'"
+}
+
+// redactDSN returns dsn with its password replaced by "REDACTED" for logging.
+func redactDSN(dsn string) (string, error) {
+	if !isURLDSN(dsn) {
+		return dsnPasswordRegexp.ReplaceAllString(dsn, "password=REDACTED"), nil
+	}
+
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return "", err //nolint:wrapcheck
+	}
+
+	u.User = url.UserPassword(u.User.Username(), "REDACTED")
+
+	return u.String(), nil
+}
@@ -0,0 +1,186 @@
+package pgboot
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"hash/fnv"
+	"time"
+)
+
+const defaultElectorInterval = 5 * time.Second
+
+// Elector implements goboot.Elector electing a single leader among all
+// instances using a Postgres advisory lock, e.g. to run an OutboxRelay on one
+// instance only. The leader holds the lock on a dedicated connection, which
+// is released by Postgres when the leader crashes.
+type Elector struct {
+	// OnElected is called when this instance becomes the leader, optional.
+	OnElected func()
+
+	// OnLost is called when this instance loses leadership because the
+	// connection holding the lock broke, optional.
+	OnLost func()
+
+	pg       *Postgres
+	key      string
+	lockID   int64
+	interval time.Duration
+}
+
+// NewElector creates an elector for key. Followers try to obtain the lock and
+// the leader checks its connection every interval; default is 5 seconds.
+func (s *Postgres) NewElector(key string, interval time.Duration) *Elector {
+	if interval == 0 {
+		interval = defaultElectorInterval
+	}
+
+	return &Elector{pg: s, key: key, lockID: advisoryLockID(key), interval: interval}
+}
+
+// RunWhenLeader waits until this instance becomes the leader and runs fn.
+// When the connection holding the lock breaks the context of fn is cancelled
+// and RunWhenLeader campaigns again after fn returns.
+//
+// Returns the result of fn when it returns while still leader, or nil when
+// ctx is done. The lock is released when returning.
+func (e *Elector) RunWhenLeader(ctx context.Context, fn func(ctx context.Context) error) error {
+	for {
+		conn, err := e.campaign(ctx)
+		if err != nil {
+			e.pg.log.Warn().Err(err).Msgf("failed to campaign for leadership of %q", e.key)
+		}
+
+		if conn == nil {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-time.After(e.interval):
+			}
+
+			continue
+		}
+
+		e.pg.log.Info().Msgf("elected leader of %q", e.key)
+
+		if e.OnElected != nil {
+			e.OnElected()
+		}
+
+		lost, err := e.lead(ctx, conn, fn)
+		if !lost {
+			return err
+		}
+
+		if ctx.Err() != nil {
+			return nil
+		}
+	}
+}
+
+// campaign returns the connection holding the lock, or nil if another
+// instance is the leader.
+func (e *Elector) campaign(ctx context.Context) (*sql.Conn, error) {
+	conn, err := e.pg.DB.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("acquiring connection: %w", err)
+	}
+
+	var locked bool
+
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", e.lockID).Scan(&locked); err != nil {
+		_ = conn.Close()
+
+		return nil, fmt.Errorf("acquiring Postgres advisory lock: %w", err)
+	}
+
+	if !locked {
+		_ = conn.Close()
+
+		return nil, nil //nolint:nilnil
+	}
+
+	return conn, nil
+}
+
+// lead runs fn while checking the connection holding the lock, returns true
+// if the lock was lost.
+func (e *Elector) lead(ctx context.Context, conn *sql.Conn, fn func(ctx context.Context) error) (bool, error) {
+	leaderCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	lost := make(chan struct{})
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		for {
+			select {
+			case <-leaderCtx.Done():
+				return
+			case <-time.After(e.interval):
+			}
+
+			if err := conn.PingContext(leaderCtx); err != nil {
+				if leaderCtx.Err() != nil {
+					return
+				}
+
+				e.pg.log.Warn().Err(err).Msgf("lost leadership of %q", e.key)
+				close(lost)
+				cancel()
+
+				if e.OnLost != nil {
+					e.OnLost()
+				}
+
+				return
+			}
+		}
+	}()
+
+	err := fn(leaderCtx)
+
+	cancel()
+	<-done
+
+	select {
+	case <-lost:
+		discardConn(conn)
+
+		return true, nil
+	default:
+	}
+
+	if _, unlockErr := conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", e.lockID); unlockErr != nil {
+		e.pg.log.Warn().Err(unlockErr).Msgf("failed to release leadership of %q", e.key)
+		discardConn(conn)
+	} else {
+		_ = conn.Close()
+	}
+
+	if ctx.Err() != nil {
+		return true, nil
+	}
+
+	return false, err
+}
+
+// discardConn closes conn instead of returning it to the pool, which releases
+// any advisory lock it may still hold.
+func discardConn(conn *sql.Conn) {
+	_ = conn.Raw(func(any) error {
+		return driver.ErrBadConn
+	})
+	_ = conn.Close()
+}
+
+// advisoryLockID returns the Postgres advisory lock key of name.
+func advisoryLockID(name string) int64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(name))
+
+	return int64(h.Sum64())
+}
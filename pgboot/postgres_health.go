@@ -0,0 +1,103 @@
+package pgboot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const defaultHealthCheckInterval = 10 * time.Second
+
+var errUnavailable = errors.New("primary database is unavailable")
+
+// health keeps track of the primary connection state.
+type health struct {
+	mu      sync.RWMutex
+	lastErr error
+	stop    chan struct{}
+	wg      sync.WaitGroup
+}
+
+// HealthCheck returns an error while the primary database is unreachable.
+// It implements goboot.HealthChecker.
+func (s *Postgres) HealthCheck(_ context.Context) error {
+	s.health.mu.RLock()
+	defer s.health.mu.RUnlock()
+
+	if s.health.lastErr != nil {
+		return fmt.Errorf("%w: %v", errUnavailable, s.health.lastErr)
+	}
+
+	return nil
+}
+
+// superviseConnection periodically pings the primary after the initial connect.
+// When a ping fails the service is marked unhealthy and the connection is
+// retried using the startup retry policy until it recovers. database/sql
+// replaces broken connections in the pool so queries succeed again as soon as
+// the database is back.
+func (s *Postgres) superviseConnection() {
+	if s.config.HealthCheckInterval < 0 {
+		return
+	}
+
+	s.health.stop = make(chan struct{})
+	s.health.wg.Add(1)
+
+	go func() {
+		defer s.health.wg.Done()
+
+		interval := s.config.HealthCheckInterval
+
+		for {
+			select {
+			case <-s.health.stop:
+				return
+			case <-time.After(interval):
+			}
+
+			if s.checkConnection() {
+				interval = s.config.HealthCheckInterval
+			} else {
+				interval = s.config.ConnectRetryDuration
+			}
+		}
+	}()
+}
+
+// checkConnection pings the primary and returns true when it's reachable.
+func (s *Postgres) checkConnection() bool {
+	ctx, cancel := context.WithTimeout(context.Background(), s.config.ConnectRetryDuration)
+	defer cancel()
+
+	err := s.DB.PingContext(ctx)
+
+	s.health.mu.Lock()
+	wasHealthy := s.health.lastErr == nil
+	s.health.lastErr = err
+	s.health.mu.Unlock()
+
+	s.metrics.setUp(err == nil)
+
+	switch {
+	case err != nil && wasHealthy:
+		s.log.Error().Err(err).Msgf("lost connection to Postgres, reconnecting every %s", s.config.ConnectRetryDuration)
+	case err != nil:
+		s.log.Warn().Err(err).Msgf("failed to reconnect to Postgres, retrying in %s", s.config.ConnectRetryDuration)
+	case !wasHealthy:
+		s.log.Info().Msg("successfully reconnected to Postgres")
+	}
+
+	return err == nil
+}
+
+func (s *Postgres) stopSupervision() {
+	if s.health.stop == nil {
+		return
+	}
+
+	close(s.health.stop)
+	s.health.wg.Wait()
+}
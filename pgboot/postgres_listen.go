@@ -0,0 +1,128 @@
+package pgboot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+)
+
+// Listen subscribes to notifications on channel and calls fn with the payload
+// of each notification, in order. Listening stops when ctx is done or the
+// service is closed.
+//
+// Listen uses a dedicated connection outside the pool. When the connection is
+// lost it reconnects and subscribes again after "postgres.connectRetryDuration";
+// notifications sent while disconnected are lost.
+func (s *Postgres) Listen(ctx context.Context, channel string, fn func(payload string)) error {
+	conn, err := s.listen(ctx, channel)
+	if err != nil {
+		return err
+	}
+
+	s.closeMu.Lock()
+	select {
+	case <-s.closing:
+		s.closeMu.Unlock()
+		_ = conn.Close(context.Background())
+
+		return fmt.Errorf("listening on Postgres channel %q: %w", channel, errClosed)
+	default:
+		s.listeners.Add(1)
+		s.closeMu.Unlock()
+	}
+
+	go func() {
+		defer s.listeners.Done()
+
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		go func() {
+			select {
+			case <-s.closing:
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+
+		s.receiveNotifications(ctx, conn, channel, fn)
+	}()
+
+	return nil
+}
+
+// Notify sends a notification with payload to all listeners of channel.
+func (s *Postgres) Notify(ctx context.Context, channel string, payload string) error {
+	if _, err := s.DB.ExecContext(ctx, "SELECT pg_notify($1, $2)", channel, payload); err != nil {
+		return fmt.Errorf("notifying Postgres channel %q: %w", channel, err)
+	}
+
+	return nil
+}
+
+func (s *Postgres) listen(ctx context.Context, channel string) (*pgx.Conn, error) {
+	connConfig, err := s.parseConnConfig(s.config.DSN, true)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := pgx.ConnectConfig(ctx, connConfig)
+	if err != nil {
+		return nil, fmt.Errorf("connecting Postgres listener: %w", err)
+	}
+
+	if _, err := conn.Exec(ctx, "LISTEN "+pgx.Identifier{channel}.Sanitize()); err != nil {
+		_ = conn.Close(context.Background())
+
+		return nil, fmt.Errorf("listening on Postgres channel %q: %w", channel, err)
+	}
+
+	return conn, nil
+}
+
+func (s *Postgres) receiveNotifications(ctx context.Context, conn *pgx.Conn, channel string, fn func(payload string)) {
+	defer func() {
+		if conn != nil {
+			_ = conn.Close(context.Background())
+		}
+	}()
+
+	for {
+		if conn == nil {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(s.config.ConnectRetryDuration):
+			}
+
+			var err error
+			if conn, err = s.listen(ctx, channel); err != nil {
+				s.log.Warn().Err(err).Msgf("failed to resubscribe to Postgres channel %q, retrying in %s",
+					channel, s.config.ConnectRetryDuration)
+
+				continue
+			}
+
+			s.log.Info().Msgf("resubscribed to Postgres channel %q", channel)
+		}
+
+		notification, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			if ctx.Err() != nil || errors.Is(err, context.Canceled) {
+				return
+			}
+
+			s.log.Warn().Err(err).Msgf("lost connection listening on Postgres channel %q", channel)
+
+			_ = conn.Close(context.Background())
+			conn = nil
+
+			continue
+		}
+
+		fn(notification.Payload)
+	}
+}
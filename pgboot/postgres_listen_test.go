@@ -0,0 +1,54 @@
+package pgboot_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nielskrijger/goboot"
+	"github.com/nielskrijger/goboot/pgboot"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostgres_ListenNotify(t *testing.T) {
+	s := &pgboot.Postgres{}
+	assert.Nil(t, s.Configure(goboot.NewAppEnv("./testdata", "valid")))
+
+	payloads := make(chan string, 1)
+	err := s.Listen(context.Background(), "test-channel", func(payload string) {
+		payloads <- payload
+	})
+	assert.Nil(t, err)
+
+	assert.Nil(t, s.Notify(context.Background(), "test-channel", "hello"))
+
+	select {
+	case payload := <-payloads:
+		assert.Equal(t, "hello", payload)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for notification")
+	}
+
+	assert.Nil(t, s.Close())
+}
+
+func TestPostgres_ListenStopsOnContextDone(t *testing.T) {
+	s := &pgboot.Postgres{}
+	assert.Nil(t, s.Configure(goboot.NewAppEnv("./testdata", "valid")))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	assert.Nil(t, s.Listen(ctx, "test-channel", func(string) {}))
+	cancel()
+
+	assert.Nil(t, s.Close())
+}
+
+func TestPostgres_CloseTwice(t *testing.T) {
+	s := &pgboot.Postgres{}
+	assert.Nil(t, s.Configure(goboot.NewAppEnv("./testdata", "valid")))
+	assert.Nil(t, s.Listen(context.Background(), "test-channel", func(string) {}))
+
+	assert.Nil(t, s.Close())
+	assert.Nil(t, s.Close())
+	assert.NotNil(t, s.Listen(context.Background(), "test-channel", func(string) {}))
+}
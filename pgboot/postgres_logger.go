@@ -0,0 +1,68 @@
+package pgboot
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+)
+
+// dbLogger implements the pgx.Logger interface. pgx logs every query which
+// makes it a convenient hook to record query metrics, spans and slow queries.
+type dbLogger struct {
+	s *Postgres
+}
+
+func (l *dbLogger) Log(ctx context.Context, level pgx.LogLevel, msg string, data map[string]any) {
+	if msg != "Query" && msg != "Exec" {
+		return
+	}
+
+	sql, _ := data["sql"].(string)
+	op := operation(sql)
+	duration, _ := data["time"].(time.Duration)
+	err, _ := data["err"].(error)
+
+	l.s.traceQuery(ctx, op, sql, duration, data, err)
+
+	if err != nil && level <= pgx.LogLevelError {
+		l.s.metrics.observeError(op)
+		l.s.log.Debug().Err(err).Str("sql", sql).Msg("Postgres query failed")
+
+		return
+	}
+
+	l.s.metrics.observeQuery(op, duration)
+
+	if threshold := l.s.config.SlowQueryThreshold; threshold > 0 && duration >= threshold {
+		l.s.log.Warn().
+			Str("sql", formatSQL(sql)).
+			Dur("duration", duration).
+			Msgf("slow Postgres query took %s", duration)
+
+		return
+	}
+
+	l.s.log.Debug().Str("sql", sql).Dur("duration", duration).Msg("Postgres query")
+}
+
+// operation returns the lowercase SQL command of a query, e.g. "select".
+func operation(sql string) string {
+	fields := strings.Fields(sql)
+	if len(fields) == 0 {
+		return "unknown"
+	}
+
+	switch op := strings.ToLower(fields[0]); op {
+	case "select", "insert", "update", "delete", "with", "begin", "commit", "rollback", "copy":
+		return op
+	default:
+		return "other"
+	}
+}
+
+// formatSQL collapses whitespace so multi-line queries fit on a single log line.
+func formatSQL(sql string) string {
+	return strings.Join(strings.Fields(sql), " ")
+}
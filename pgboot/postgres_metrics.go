@@ -0,0 +1,124 @@
+package pgboot
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/nielskrijger/goboot"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+)
+
+type postgresMetrics struct {
+	queryDuration *prometheus.HistogramVec
+	queryErrors   *prometheus.CounterVec
+	up            prometheus.Gauge
+}
+
+func newPostgresMetrics() *postgresMetrics {
+	return &postgresMetrics{
+		queryDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "postgres_query_duration_seconds",
+			Help:    "Duration of Postgres queries by operation.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"operation"}),
+		queryErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "postgres_query_errors_total",
+			Help: "Number of failed Postgres queries by operation.",
+		}, []string{"operation"}),
+		up: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "postgres_up",
+			Help: "Whether the Postgres primary is reachable (1) or not (0).",
+		}),
+	}
+}
+
+func (m *postgresMetrics) setUp(up bool) {
+	if m == nil {
+		return
+	}
+
+	if up {
+		m.up.Set(1)
+	} else {
+		m.up.Set(0)
+	}
+}
+
+func (m *postgresMetrics) observeQuery(op string, duration time.Duration) {
+	if m != nil {
+		m.queryDuration.WithLabelValues(op).Observe(duration.Seconds())
+	}
+}
+
+func (m *postgresMetrics) observeError(op string) {
+	if m != nil {
+		m.queryErrors.WithLabelValues(op).Inc()
+	}
+}
+
+// registerMetrics registers the query metrics and the connection pool
+// statistics of the primary and replicas with the shared metrics registry.
+func (s *Postgres) registerMetrics(reg *prometheus.Registry) error {
+	if reg == nil {
+		return nil
+	}
+
+	if err := goboot.RegisterCollector(reg, &s.metrics.queryDuration); err != nil {
+		return fmt.Errorf("registering Postgres metrics: %w", err)
+	}
+
+	if err := goboot.RegisterCollector(reg, &s.metrics.queryErrors); err != nil {
+		return fmt.Errorf("registering Postgres metrics: %w", err)
+	}
+
+	if err := goboot.RegisterCollector(reg, &s.metrics.up); err != nil {
+		return fmt.Errorf("registering Postgres metrics: %w", err)
+	}
+
+	connConfig, err := s.parseConnConfig(s.config.DSN, true)
+	if err != nil {
+		return err
+	}
+
+	if err := s.registerDBStats(reg, s.DB.DB, connConfig.Host, connConfig.Database); err != nil {
+		return fmt.Errorf("registering Postgres pool metrics: %w", err)
+	}
+
+	if s.replicas != nil {
+		for i, r := range s.replicas.replicas {
+			replicaConfig, err := s.parseConnConfig(s.config.Replicas[i], false)
+			if err != nil {
+				return err
+			}
+
+			name := fmt.Sprintf("%s-replica-%d", replicaConfig.Database, i)
+			if err := s.registerDBStats(reg, r.db.DB, replicaConfig.Host, name); err != nil {
+				return fmt.Errorf("registering Postgres replica pool metrics: %w", err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// registerDBStats registers the pool statistics of db labelled with the host
+// and name of the database. When another service registered the statistics
+// of the same database already they're skipped with a warning, as there's no
+// label to tell both pools apart.
+func (s *Postgres) registerDBStats(reg prometheus.Registerer, db *sql.DB, host string, name string) error {
+	reg = prometheus.WrapRegistererWith(prometheus.Labels{"db_host": host}, reg)
+
+	err := reg.Register(collectors.NewDBStatsCollector(db, name))
+
+	var are prometheus.AlreadyRegisteredError
+	if errors.As(err, &are) {
+		s.log.Warn().Msgf("skipping pool metrics of Postgres database %q on %s, they're registered already", name, host)
+
+		return nil
+	}
+
+	return err //nolint:wrapcheck
+}
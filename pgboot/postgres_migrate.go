@@ -4,14 +4,20 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"net/url"
 	"path/filepath"
+	"strconv"
+	"time"
 
 	"github.com/golang-migrate/migrate/v4"
 	"github.com/golang-migrate/migrate/v4/database/pgx"
 	_ "github.com/golang-migrate/migrate/v4/source/file" // Load file-loader for migration files.
+	"github.com/jackc/pgx/v4/stdlib"
 	"github.com/rs/zerolog"
 )
 
+var errMissingMigrationsDir = errors.New("no Postgres migrations directory set")
+
 type PostgresMigratePrinter interface {
 	Printf(format string, v ...any)
 }
@@ -32,36 +38,129 @@ func (log *logger) Verbose() bool {
 }
 
 // Migrate runs Postgres migration files from specified migrations directory.
-//
-// Panics if anything went wrong.
+// The dsn connects through the Cloud SQL connector when configured, like the
+// primary. When another instance holds the migration lock for longer than
+// "migrationLockTimeout", Migrate succeeds if the schema is up-to-date.
 func (s *Postgres) Migrate(dsn string, migrations string) error {
-	log := logger{logger: s.log}
+	err := s.runMigrations(dsn, migrations, func(m *migrate.Migrate) error {
+		return m.Up()
+	})
+	if errors.Is(err, errMigrationLockTimeout) {
+		return s.verifyMigrated(dsn, migrations, err)
+	}
 
-	dir, err := filepath.Abs(migrations)
-	if err != nil {
-		return fmt.Errorf("reading migrations path: %w", err)
+	return err
+}
+
+// MigrateDown rolls back specified number of migrations using the down
+// migration files in the migrations directory.
+func (s *Postgres) MigrateDown(steps int) error {
+	return s.runMigrations(s.config.DSN, s.MigrationsDir, func(m *migrate.Migrate) error {
+		return m.Steps(-steps)
+	})
+}
+
+// MigrateTo migrates up or down to specified migration version. Version 0
+// rolls back all migrations.
+func (s *Postgres) MigrateTo(version uint) error {
+	return s.runMigrations(s.config.DSN, s.MigrationsDir, func(m *migrate.Migrate) error {
+		if version == 0 {
+			return m.Down()
+		}
+
+		return m.Migrate(version)
+	})
+}
+
+func (s *Postgres) runMigrations(dsn string, migrations string, run func(m *migrate.Migrate) error) error {
+	if migrations == "" {
+		return errMissingMigrationsDir
 	}
 
-	log.Printf("running Postgres migrations from %s", dir)
+	return s.withMigrationLock(func(conn *sql.Conn) error {
+		return s.runMigrationsLocked(conn, dsn, migrations, run)
+	})
+}
+
+func (s *Postgres) runMigrationsLocked(
+	conn *sql.Conn,
+	dsn string,
+	migrations string,
+	run func(m *migrate.Migrate) error,
+) error {
+	log := logger{logger: s.log}
+	log.Printf("running Postgres migrations from %s", migrations)
 
-	// connect to postgres
-	db, err := sql.Open("pgx", dsn)
+	m, err := s.newMigrate(dsn, migrations)
 	if err != nil {
-		return fmt.Errorf("connecting to postgres: %w", err)
+		return err
 	}
 
 	defer func() {
-		err := db.Close()
-		if err != nil {
-			panic(err)
+		if srcErr, dbErr := m.Close(); srcErr != nil || dbErr != nil {
+			log.logger.Warn().Msgf("closing Postgres migrations: %v, %v", srcErr, dbErr)
 		}
 	}()
 
-	p := &pgx.Postgres{}
+	from, _, _ := m.Version()
+
+	runErr := run(m)
+
+	// a failed run still records the migrations applied before the failure,
+	// the dirty version itself isn't applied
+	to, dirty, _ := m.Version()
+	if dirty && to > from {
+		to--
+	}
+
+	historyErr := s.recordMigrationsHistory(conn, dsn, migrations, from, to)
+
+	switch {
+	case errors.Is(runErr, migrate.ErrNoChange):
+		log.Printf("Postgres database is up-to-date")
+	case runErr != nil:
+		if historyErr != nil {
+			log.logger.Warn().Err(historyErr).Msg("failed to record Postgres migrations history")
+		}
+
+		return fmt.Errorf("running Postgres migrations: %w", runErr)
+	default:
+		log.Printf("completed Postgres migrations")
+	}
+
+	return historyErr
+}
+
+func (s *Postgres) newMigrate(dsn string, migrations string) (*migrate.Migrate, error) {
+	log := logger{logger: s.log}
+
+	dir, err := filepath.Abs(migrations)
+	if err != nil {
+		return nil, fmt.Errorf("reading migrations path: %w", err)
+	}
+
+	config, err := migrateConfig(dsn)
+	if err != nil {
+		return nil, err
+	}
 
-	driver, err := p.Open(dsn)
+	// connect to postgres, the driver closes the connection when done
+	connConfig, err := s.parseConnConfig(dsn, true)
 	if err != nil {
-		return fmt.Errorf("open Postgres connection for golang-migrate: %w", err)
+		return nil, fmt.Errorf("connecting to postgres: %w", err)
+	}
+
+	// migrations may take longer than the statement timeout of the app,
+	// "x-statement-timeout" limits migrations instead
+	delete(connConfig.RuntimeParams, "statement_timeout")
+
+	db := stdlib.OpenDB(*connConfig)
+
+	driver, err := pgx.WithInstance(db, config)
+	if err != nil {
+		_ = db.Close()
+
+		return nil, fmt.Errorf("open Postgres connection for golang-migrate: %w", err)
 	}
 
 	// setup migrations connection
@@ -71,21 +170,66 @@ func (s *Postgres) Migrate(dsn string, migrations string) error {
 		driver,
 	)
 	if err != nil {
-		return fmt.Errorf("connecting to Postgres for migrations: %w", err)
+		return nil, fmt.Errorf("connecting to Postgres for migrations: %w", err)
 	}
 
 	m.Log = &log
+	m.LockTimeout = s.config.MigrationLockTimeout
+
+	return m, nil
+}
+
+// migrateConfig returns the golang-migrate settings of the "x-" query
+// parameters in the DSN, like "x-migrations-table" and "x-multi-statement".
+// These parameters are removed from the DSN of the connection itself.
+// Keyword/value DSNs have no such parameters and use the defaults.
+func migrateConfig(dsn string) (*pgx.Config, error) {
+	if !isURLDSN(dsn) {
+		return &pgx.Config{MultiStatementMaxSize: pgx.DefaultMultiStatementMaxSize}, nil
+	}
 
-	err = m.Up()
+	u, err := url.Parse(dsn)
 	if err != nil {
-		if errors.Is(err, migrate.ErrNoChange) {
-			log.Printf("Postgres database is up-to-date")
-		} else {
-			return fmt.Errorf("running Postgres migrations: %w", err)
+		return nil, fmt.Errorf("invalid Postgres dsn: %w", err)
+	}
+
+	q := u.Query()
+	config := &pgx.Config{
+		MigrationsTable:       q.Get("x-migrations-table"),
+		MultiStatementMaxSize: pgx.DefaultMultiStatementMaxSize,
+	}
+
+	if v := q.Get("x-migrations-table-quoted"); v != "" {
+		if config.MigrationsTableQuoted, err = strconv.ParseBool(v); err != nil {
+			return nil, fmt.Errorf("invalid Postgres dsn parameter \"x-migrations-table-quoted\": %w", err)
+		}
+	}
+
+	if v := q.Get("x-statement-timeout"); v != "" {
+		ms, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid Postgres dsn parameter \"x-statement-timeout\": %w", err)
+		}
+
+		config.StatementTimeout = time.Duration(ms) * time.Millisecond
+	}
+
+	if v := q.Get("x-multi-statement"); v != "" {
+		if config.MultiStatementEnabled, err = strconv.ParseBool(v); err != nil {
+			return nil, fmt.Errorf("invalid Postgres dsn parameter \"x-multi-statement\": %w", err)
+		}
+	}
+
+	if v := q.Get("x-multi-statement-max-size"); v != "" {
+		size, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid Postgres dsn parameter \"x-multi-statement-max-size\": %w", err)
+		}
+
+		if size > 0 {
+			config.MultiStatementMaxSize = size
 		}
-	} else {
-		log.Printf("completed Postgres migrations")
 	}
 
-	return nil
+	return config, nil
 }
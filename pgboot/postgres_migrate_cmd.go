@@ -0,0 +1,33 @@
+package pgboot
+
+import (
+	"os"
+
+	"github.com/nielskrijger/goboot"
+)
+
+var _ goboot.Migrator = (*Postgres)(nil)
+
+// RunMigrateCommand runs a migrate subcommand, intended to be wired into the
+// application's CLI, e.g. "myapp migrate down 1". See
+// goboot.ParseMigrateCommand for the supported commands.
+//
+// The service must be configured before running a command. Don't call Init
+// as it runs all pending migrations.
+func (s *Postgres) RunMigrateCommand(args []string) error {
+	cmd, err := goboot.ParseMigrateCommand(args)
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+
+	switch cmd.Name {
+	case goboot.MigrateDown:
+		return s.MigrateDown(cmd.Steps)
+	case goboot.MigrateTo:
+		return s.MigrateTo(cmd.Version)
+	case goboot.MigrateStatus:
+		return s.PrintMigrationStatus(os.Stdout)
+	default:
+		return s.Migrate(s.config.DSN, s.MigrationsDir)
+	}
+}
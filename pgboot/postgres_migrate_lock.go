@@ -0,0 +1,188 @@
+package pgboot
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+
+	migratepgx "github.com/golang-migrate/migrate/v4/database/pgx"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v4"
+	"github.com/nielskrijger/goboot"
+)
+
+const (
+	defaultMigrationLockTimeout = 5 * time.Minute
+	migrationLockRetryInterval  = time.Second
+	migrationLockName           = "goboot-migrations"
+)
+
+var (
+	errMigrationLockTimeout   = errors.New("timed out waiting for Postgres migration lock")
+	errInvalidMigrationsTable = errors.New("invalid Postgres dsn parameter \"x-migrations-table\", expected a quoted identifier")
+)
+
+// quotedIdentifierRegex matches the parts of a quoted "x-migrations-table".
+var quotedIdentifierRegex = regexp.MustCompile(`"(.*?)"`)
+
+// migrationLockID is the Postgres advisory lock key used while running migrations.
+// It differs from the lock golang-migrate takes to avoid blocking on our own lock.
+var migrationLockID = advisoryLockID(migrationLockName)
+
+// withMigrationLock runs fn while holding a Postgres advisory lock, ensuring only
+// one instance runs migrations at a time. Statements of fn must use the locked
+// conn, the pool may have no other connections available.
+//
+// Instances that start simultaneously wait until the lock is released or the
+// "migrationLockTimeout" expires, after which errMigrationLockTimeout is returned.
+func (s *Postgres) withMigrationLock(fn func(conn *sql.Conn) error) error {
+	ctx, cancel := context.WithTimeout(context.Background(), s.config.MigrationLockTimeout)
+	defer cancel()
+
+	conn, err := s.DB.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("acquiring connection for migration lock: %w", err)
+	}
+
+	defer func() {
+		_ = conn.Close()
+	}()
+
+	if s.config.StatementTimeout > 0 {
+		// statements of fn like recording the migration history wait for the
+		// migrations, which may take longer than the statement timeout
+		if _, err := conn.ExecContext(ctx, "SET statement_timeout = 0"); err != nil {
+			return fmt.Errorf("disabling statement timeout for migrations: %w", err)
+		}
+
+		defer func() {
+			// restores the statement timeout of the connection settings
+			if _, err := conn.ExecContext(context.Background(), "RESET statement_timeout"); err != nil {
+				s.log.Warn().Err(err).Msg("failed to reset Postgres statement timeout")
+			}
+		}()
+	}
+
+	if err := s.acquireMigrationLock(ctx, conn); err != nil {
+		return err
+	}
+
+	defer func() {
+		// the lock is released when the connection closes as well
+		if _, err := conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", migrationLockID); err != nil {
+			s.log.Warn().Err(err).Msg("failed to release Postgres migration lock")
+		}
+	}()
+
+	return fn(conn)
+}
+
+func (s *Postgres) acquireMigrationLock(ctx context.Context, conn *sql.Conn) error {
+	for attempt := 1; ; attempt++ {
+		var locked bool
+
+		err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", migrationLockID).Scan(&locked)
+		if err != nil {
+			return fmt.Errorf("acquiring Postgres migration lock: %w", err)
+		}
+
+		if locked {
+			return nil
+		}
+
+		if attempt == 1 {
+			s.log.Info().Msg("another instance is running Postgres migrations, waiting for it to finish")
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%w after %s", errMigrationLockTimeout, s.config.MigrationLockTimeout)
+		case <-time.After(migrationLockRetryInterval):
+		}
+	}
+}
+
+// verifyMigrated succeeds when the schema is up-to-date with the migrations
+// directory, used instead of failing when the migration lock times out. The
+// instance holding the lock may still be running slow migrations that were
+// applied by the time this instance gave up waiting.
+func (s *Postgres) verifyMigrated(dsn string, migrations string, lockErr error) error {
+	version, dirty, err := s.migrationsVersion(dsn)
+	if err != nil {
+		return err
+	}
+
+	status, err := goboot.NewMigrationsStatus(migrations, version, dirty)
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+
+	if pending := status.Pending(); dirty || len(pending) > 0 {
+		return fmt.Errorf("%w, Postgres schema has %d pending migrations", lockErr, len(pending))
+	}
+
+	s.log.Warn().Err(lockErr).Msg("Postgres schema is up-to-date, skipping migrations")
+
+	return nil
+}
+
+// migrationsVersion reads the version of the golang-migrate migrations table
+// directly, golang-migrate blocks on its own lock held by the instance running
+// migrations.
+func (s *Postgres) migrationsVersion(dsn string) (uint, bool, error) {
+	table, err := migrationsTable(dsn)
+	if err != nil {
+		return 0, false, err
+	}
+
+	var (
+		version uint
+		dirty   bool
+		pgErr   *pgconn.PgError
+	)
+
+	err = s.DB.QueryRow(`SELECT version, dirty FROM `+table.Sanitize()+` LIMIT 1`).Scan(&version, &dirty)
+
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return 0, false, nil
+	case errors.As(err, &pgErr) && pgErr.Code == pgerrcode.UndefinedTable:
+		return 0, false, nil
+	case err != nil:
+		return 0, false, fmt.Errorf("reading Postgres migrations version: %w", err)
+	}
+
+	return version, dirty, nil
+}
+
+// migrationsTable returns the golang-migrate version table configured by the
+// "x-migrations-table" and "x-migrations-table-quoted" parameters of the DSN.
+func migrationsTable(dsn string) (pgx.Identifier, error) {
+	config, err := migrateConfig(dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	if config.MigrationsTable == "" {
+		return pgx.Identifier{migratepgx.DefaultMigrationsTable}, nil
+	}
+
+	if !config.MigrationsTableQuoted {
+		return pgx.Identifier{config.MigrationsTable}, nil
+	}
+
+	table := pgx.Identifier{}
+	for _, match := range quotedIdentifierRegex.FindAllStringSubmatch(config.MigrationsTable, -1) {
+		table = append(table, match[1])
+	}
+
+	if len(table) == 0 {
+		return nil, errInvalidMigrationsTable
+	}
+
+	return table, nil
+}
@@ -0,0 +1,187 @@
+package pgboot
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/jackc/pgx/v4"
+	"github.com/jmoiron/sqlx"
+	"github.com/nielskrijger/goboot"
+)
+
+// MigrationStatus describes a single migration file.
+type MigrationStatus = goboot.MigrationStatus
+
+// MigrationsStatus describes the state of the database schema.
+type MigrationsStatus = goboot.MigrationsStatus
+
+// MigrationStatus returns the applied and pending migrations of the migrations
+// directory. AppliedAt is nil for migrations applied before the migration
+// history was recorded.
+func (s *Postgres) MigrationStatus() (*MigrationsStatus, error) {
+	if s.MigrationsDir == "" {
+		return nil, errMissingMigrationsDir
+	}
+
+	m, err := s.newMigrate(s.config.DSN, s.MigrationsDir)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() { _, _ = m.Close() }()
+
+	version, dirty, err := m.Version()
+	if err != nil && !errors.Is(err, migrate.ErrNilVersion) {
+		return nil, fmt.Errorf("reading Postgres migrations version: %w", err)
+	}
+
+	status, err := goboot.NewMigrationsStatus(s.MigrationsDir, version, dirty)
+	if err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+
+	history, err := s.migrationsHistory(s.config.DSN)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, migration := range status.Migrations {
+		if appliedAt, ok := history[migration.Version]; ok && migration.Applied {
+			migration.AppliedAt = &appliedAt
+		}
+	}
+
+	return status, nil
+}
+
+// PrintMigrationStatus writes a human-readable table of MigrationStatus to w.
+func (s *Postgres) PrintMigrationStatus(w io.Writer) error {
+	status, err := s.MigrationStatus()
+	if err != nil {
+		return err
+	}
+
+	return status.Print(w) //nolint:wrapcheck
+}
+
+// migrationsHistoryTable returns the table recording when each migration was
+// applied, as golang-migrate only keeps track of the current version. It is
+// named after the migrations table so separate migration sets in one database
+// keep separate histories, e.g. "schema_migrations_history".
+func migrationsHistoryTable(dsn string) (string, error) {
+	table, err := migrationsTable(dsn)
+	if err != nil {
+		return "", err
+	}
+
+	history := append(pgx.Identifier{}, table...)
+	history[len(history)-1] += "_history"
+
+	return history.Sanitize(), nil
+}
+
+func ensureMigrationsHistory(ctx context.Context, db sqlx.ExecerContext, table string) error {
+	_, err := db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS `+table+` (
+		version bigint PRIMARY KEY,
+		applied_at timestamptz NOT NULL DEFAULT now()
+	)`)
+	if err != nil {
+		return fmt.Errorf("creating %s table: %w", table, err)
+	}
+
+	return nil
+}
+
+// migrationsHistory returns when each migration was applied. It doesn't create
+// the history table so read-only users can read the status, the history is
+// empty when no migrations have been recorded yet.
+func (s *Postgres) migrationsHistory(dsn string) (map[uint]time.Time, error) {
+	table, err := migrationsHistoryTable(dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	var exists bool
+
+	if err := s.DB.Get(&exists, `SELECT to_regclass($1) IS NOT NULL`, table); err != nil {
+		return nil, fmt.Errorf("checking %s exists: %w", table, err)
+	}
+
+	if !exists {
+		return map[uint]time.Time{}, nil
+	}
+
+	var rows []struct {
+		Version   uint      `db:"version"`
+		AppliedAt time.Time `db:"applied_at"`
+	}
+
+	if err := s.DB.Select(&rows, `SELECT version, applied_at FROM `+table); err != nil {
+		return nil, fmt.Errorf("reading %s: %w", table, err)
+	}
+
+	history := make(map[uint]time.Time, len(rows))
+	for _, row := range rows {
+		history[row.Version] = row.AppliedAt
+	}
+
+	return history, nil
+}
+
+// recordMigrationsHistory updates the history after migrating from one version
+// to another, using the connection holding the migration lock.
+func (s *Postgres) recordMigrationsHistory(
+	conn *sql.Conn,
+	dsn string,
+	migrationsDir string,
+	from uint,
+	to uint,
+) error {
+	if from == to {
+		return nil
+	}
+
+	table, err := migrationsHistoryTable(dsn)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+
+	if err := ensureMigrationsHistory(ctx, conn, table); err != nil {
+		return err
+	}
+
+	if to < from {
+		_, err := conn.ExecContext(ctx, `DELETE FROM `+table+` WHERE version > $1`, to)
+		if err != nil {
+			return fmt.Errorf("updating %s: %w", table, err)
+		}
+
+		return nil
+	}
+
+	migrations, err := goboot.ReadMigrationFiles(migrationsDir)
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+
+	for _, m := range migrations {
+		if m.Version <= from || m.Version > to {
+			continue
+		}
+
+		_, err := conn.ExecContext(ctx, `INSERT INTO `+table+` (version) VALUES ($1)
+			ON CONFLICT (version) DO UPDATE SET applied_at = now()`, m.Version)
+		if err != nil {
+			return fmt.Errorf("updating %s: %w", table, err)
+		}
+	}
+
+	return nil
+}
@@ -1,8 +1,12 @@
 package pgboot_test
 
 import (
+	"context"
+	"errors"
+	"hash/fnv"
 	"testing"
 
+	"github.com/jmoiron/sqlx"
 	"github.com/nielskrijger/goboot"
 	"github.com/nielskrijger/goboot/pgboot"
 	"github.com/nielskrijger/goboot/test"
@@ -21,6 +25,7 @@ func TestPostgresMigrate_Success(t *testing.T) {
 	assert.Nil(t, s.Configure(env))
 	_, _ = s.DB.Exec("DROP TABLE IF EXISTS test_table")
 	_, _ = s.DB.Exec("DROP TABLE IF EXISTS schema_migrations")
+	_, _ = s.DB.Exec("DROP TABLE IF EXISTS schema_migrations_history")
 	assert.Nil(t, s.Init())
 
 	var records []Record
@@ -31,6 +36,59 @@ func TestPostgresMigrate_Success(t *testing.T) {
 	assert.Equal(t, "Second record", records[1].Name)
 }
 
+func TestPostgresMigrate_SingleConnection(t *testing.T) {
+	s := &pgboot.Postgres{MigrationsDir: "./testdata/migrations"}
+	env := goboot.NewAppEnv("./testdata", "valid")
+	env.Config.Set("postgres.poolSize", 1)
+	assert.Nil(t, s.Configure(env))
+	_, _ = s.DB.Exec("DROP TABLE IF EXISTS test_table")
+	_, _ = s.DB.Exec("DROP TABLE IF EXISTS schema_migrations")
+	_, _ = s.DB.Exec("DROP TABLE IF EXISTS schema_migrations_history")
+
+	// the migration history is recorded using the connection holding the lock
+	assert.Nil(t, s.Init())
+
+	var count int
+
+	assert.Nil(t, s.DB.Get(&count, "SELECT COUNT(*) FROM schema_migrations_history"))
+	assert.Equal(t, 2, count)
+}
+
+func TestPostgresMigrate_MigrateParams(t *testing.T) {
+	s := &pgboot.Postgres{}
+	env := goboot.NewAppEnv("./testdata", "valid")
+	assert.Nil(t, s.Configure(env))
+	_, _ = s.DB.Exec("DROP TABLE IF EXISTS test_table")
+	_, _ = s.DB.Exec("DROP TABLE IF EXISTS custom_migrations")
+	_, _ = s.DB.Exec("DROP TABLE IF EXISTS custom_migrations_history")
+
+	dsn := env.Config.GetString("postgres.dsn") + "&x-migrations-table=custom_migrations&x-multi-statement=true"
+	assert.Nil(t, s.Migrate(dsn, "./testdata/migrations"))
+
+	var version int
+
+	assert.Nil(t, s.DB.Get(&version, "SELECT version FROM custom_migrations"))
+	assert.Equal(t, 2, version)
+
+	err := s.Migrate(env.Config.GetString("postgres.dsn")+"&x-multi-statement=maybe", "./testdata/migrations")
+	assert.ErrorContains(t, err, `invalid Postgres dsn parameter "x-multi-statement"`)
+}
+
+func TestPostgresMigrate_KeywordValueDSN(t *testing.T) {
+	s := &pgboot.Postgres{}
+	assert.Nil(t, s.Configure(goboot.NewAppEnv("./testdata", "keyword-dsn")))
+
+	// a colon in a keyword/value DSN isn't valid in a URL
+	_, err := s.DB.Exec("DROP ROLE IF EXISTS goboot_colon")
+	assert.Nil(t, err)
+	_, err = s.DB.Exec("CREATE ROLE goboot_colon LOGIN SUPERUSER PASSWORD 'se:cret'")
+	assert.Nil(t, err)
+
+	dsn := "host=localhost port=5432 user=goboot_colon password='se:cret' dbname=goboot sslmode=disable"
+	assert.Nil(t, s.Migrate(dsn, "./testdata/migrations"))
+	assert.Nil(t, s.Close())
+}
+
 func TestPostgresMigrate_SkipMigrationsWhenDirEmpty(t *testing.T) {
 	log := &test.Logger{}
 	s := &pgboot.Postgres{}
@@ -41,3 +99,227 @@ func TestPostgresMigrate_SkipMigrationsWhenDirEmpty(t *testing.T) {
 
 	assert.Equal(t, "skipping db migrations; no migrations directory set", log.LastLine()["message"])
 }
+
+func TestPostgresMigrate_DownAndTo(t *testing.T) {
+	s := &pgboot.Postgres{MigrationsDir: "./testdata/migrations"}
+	env := goboot.NewAppEnv("./testdata", "valid")
+	assert.Nil(t, s.Configure(env))
+	_, _ = s.DB.Exec("DROP TABLE IF EXISTS test_table")
+	_, _ = s.DB.Exec("DROP TABLE IF EXISTS schema_migrations")
+	assert.Nil(t, s.Init())
+
+	var count int
+
+	assert.Nil(t, s.RunMigrateCommand([]string{"down"}))
+	assert.Nil(t, s.DB.Get(&count, "SELECT COUNT(*) FROM test_table"))
+	assert.Equal(t, 0, count)
+
+	assert.Nil(t, s.RunMigrateCommand([]string{"to", "2"}))
+	assert.Nil(t, s.DB.Get(&count, "SELECT COUNT(*) FROM test_table"))
+	assert.Equal(t, 2, count)
+
+	assert.Nil(t, s.MigrateTo(0))
+	assert.NotNil(t, s.DB.Get(&count, "SELECT COUNT(*) FROM test_table"))
+}
+
+func TestPostgresMigrate_ErrorInvalidCommand(t *testing.T) {
+	s := &pgboot.Postgres{}
+	assert.EqualError(t, s.RunMigrateCommand([]string{"sideways"}), "usage: migrate up|down [steps]|to <version>|status")
+}
+
+func TestPostgresMigrate_Status(t *testing.T) {
+	s := &pgboot.Postgres{MigrationsDir: "./testdata/migrations"}
+	env := goboot.NewAppEnv("./testdata", "valid")
+	assert.Nil(t, s.Configure(env))
+	_, _ = s.DB.Exec("DROP TABLE IF EXISTS test_table")
+	_, _ = s.DB.Exec("DROP TABLE IF EXISTS schema_migrations")
+	_, _ = s.DB.Exec("DROP TABLE IF EXISTS schema_migrations_history")
+	assert.Nil(t, s.MigrateTo(1))
+
+	status, err := s.MigrationStatus()
+	assert.Nil(t, err)
+	assert.Equal(t, uint(1), status.Version)
+	assert.False(t, status.Dirty)
+	assert.Len(t, status.Migrations, 2)
+	assert.Equal(t, "create_table", status.Migrations[0].Name)
+	assert.True(t, status.Migrations[0].Applied)
+	assert.NotNil(t, status.Migrations[0].AppliedAt)
+	assert.Len(t, status.Pending(), 1)
+	assert.Equal(t, uint(2), status.Pending()[0].Version)
+}
+
+func TestPostgresMigrate_StatusWithoutHistory(t *testing.T) {
+	s := &pgboot.Postgres{MigrationsDir: "./testdata/migrations"}
+	assert.Nil(t, s.Configure(goboot.NewAppEnv("./testdata", "valid")))
+	_, _ = s.DB.Exec("DROP TABLE IF EXISTS schema_migrations_history")
+
+	status, err := s.MigrationStatus()
+	assert.Nil(t, err)
+
+	for _, m := range status.Migrations {
+		assert.Nil(t, m.AppliedAt)
+	}
+
+	// reading the status doesn't create the history table
+	var exists bool
+
+	assert.Nil(t, s.DB.Get(&exists, "SELECT to_regclass('schema_migrations_history') IS NOT NULL"))
+	assert.False(t, exists)
+}
+
+func TestPostgresMigrate_HistoryPerMigrationsTable(t *testing.T) {
+	s := &pgboot.Postgres{MigrationsDir: "./testdata/migrations"}
+	env := goboot.NewAppEnv("./testdata", "valid")
+	assert.Nil(t, s.Configure(env))
+
+	for _, table := range []string{
+		"test_table", "schema_migrations", "schema_migrations_history",
+		"users", "audit_entries", "repository_migrations", "repository_migrations_history",
+	} {
+		_, _ = s.DB.Exec("DROP TABLE IF EXISTS " + table)
+	}
+
+	assert.Nil(t, s.Init())
+
+	// a second migration set with the same versions in the same database
+	other := &pgboot.Postgres{MigrationsDir: "./testdata/repository_migrations"}
+	otherEnv := goboot.NewAppEnv("./testdata", "valid")
+	otherEnv.Config.Set("postgres.dsn", env.Config.GetString("postgres.dsn")+"&x-migrations-table=repository_migrations")
+	assert.Nil(t, other.Configure(otherEnv))
+	assert.Nil(t, other.Init())
+
+	var count int
+
+	assert.Nil(t, s.DB.Get(&count, "SELECT COUNT(*) FROM repository_migrations_history"))
+	assert.Equal(t, 2, count)
+
+	// rolling back the second set keeps the history of the first
+	assert.Nil(t, other.MigrateTo(0))
+	assert.Nil(t, s.DB.Get(&count, "SELECT COUNT(*) FROM repository_migrations_history"))
+	assert.Equal(t, 0, count)
+
+	status, err := s.MigrationStatus()
+	assert.Nil(t, err)
+
+	for _, m := range status.Migrations {
+		assert.NotNil(t, m.AppliedAt)
+	}
+
+	assert.Nil(t, other.Close())
+}
+
+func TestPostgresMigrate_RecordsHistoryOnFailure(t *testing.T) {
+	s := &pgboot.Postgres{MigrationsDir: "./testdata/failing_migrations"}
+	assert.Nil(t, s.Configure(goboot.NewAppEnv("./testdata", "valid")))
+	_, _ = s.DB.Exec("DROP TABLE IF EXISTS failing_table")
+	_, _ = s.DB.Exec("DROP TABLE IF EXISTS schema_migrations")
+	_, _ = s.DB.Exec("DROP TABLE IF EXISTS schema_migrations_history")
+
+	assert.ErrorContains(t, s.Init(), "running Postgres migrations")
+
+	var versions []int
+
+	assert.Nil(t, s.DB.Select(&versions, "SELECT version FROM schema_migrations_history ORDER BY version"))
+	assert.Equal(t, []int{1, 2}, versions)
+
+	_, _ = s.DB.Exec("DROP TABLE IF EXISTS failing_table")
+	_, _ = s.DB.Exec("DROP TABLE IF EXISTS schema_migrations")
+	_, _ = s.DB.Exec("DROP TABLE IF EXISTS schema_migrations_history")
+}
+
+func TestPostgresMigrate_ConcurrentInit(t *testing.T) {
+	s1 := &pgboot.Postgres{MigrationsDir: "./testdata/migrations"}
+	s2 := &pgboot.Postgres{MigrationsDir: "./testdata/migrations"}
+	assert.Nil(t, s1.Configure(goboot.NewAppEnv("./testdata", "valid")))
+	assert.Nil(t, s2.Configure(goboot.NewAppEnv("./testdata", "valid")))
+	_, _ = s1.DB.Exec("DROP TABLE IF EXISTS test_table")
+	_, _ = s1.DB.Exec("DROP TABLE IF EXISTS schema_migrations")
+
+	errs := make(chan error, 2)
+
+	for _, s := range []*pgboot.Postgres{s1, s2} {
+		go func(s *pgboot.Postgres) { errs <- s.Init() }(s)
+	}
+
+	assert.Nil(t, <-errs)
+	assert.Nil(t, <-errs)
+
+	var count int
+
+	assert.Nil(t, s1.DB.Get(&count, "SELECT COUNT(*) FROM test_table"))
+	assert.Equal(t, 2, count)
+}
+
+func TestPostgresMigrate_Seeds(t *testing.T) {
+	goSeedCount := 0
+
+	s := &pgboot.Postgres{
+		MigrationsDir: "./testdata/migrations",
+		SeedsDir:      "./testdata/seeds",
+		Seeds: []*pgboot.Seed{
+			{
+				ID: "go-seed",
+				Seed: func(ctx context.Context, tx *sqlx.Tx) error {
+					goSeedCount++
+
+					return nil
+				},
+			},
+			{
+				ID:   "other-env",
+				Envs: []string{"other"},
+				Seed: func(ctx context.Context, tx *sqlx.Tx) error {
+					return errors.New("should not run") //nolint:goerr113
+				},
+			},
+		},
+	}
+	assert.Nil(t, s.Configure(goboot.NewAppEnv("./testdata", "seeded")))
+	_, _ = s.DB.Exec("DROP TABLE IF EXISTS test_table")
+	_, _ = s.DB.Exec("DROP TABLE IF EXISTS schema_migrations")
+	_, _ = s.DB.Exec("DROP TABLE IF EXISTS schema_seeds")
+
+	// Seeds only run once
+	assert.Nil(t, s.Init())
+	assert.Nil(t, s.Init())
+
+	var count int
+
+	assert.Nil(t, s.DB.Get(&count, "SELECT COUNT(*) FROM test_table"))
+	assert.Equal(t, 3, count)
+	assert.Equal(t, 1, goSeedCount)
+}
+
+func TestPostgresMigrate_LockTimeout(t *testing.T) {
+	s := &pgboot.Postgres{MigrationsDir: "./testdata/migrations"}
+	env := goboot.NewAppEnv("./testdata", "valid")
+	env.Config.Set("postgres.migrationLockTimeout", "500ms")
+	assert.Nil(t, s.Configure(env))
+	_, _ = s.DB.Exec("DROP TABLE IF EXISTS test_table")
+	_, _ = s.DB.Exec("DROP TABLE IF EXISTS schema_migrations")
+	_, _ = s.DB.Exec("DROP TABLE IF EXISTS schema_migrations_history")
+	assert.Nil(t, s.Init())
+
+	// hold the migration lock as if another instance is running migrations
+	conn, err := s.DB.Conn(context.Background())
+	assert.Nil(t, err)
+
+	defer func() {
+		_ = conn.Close()
+	}()
+
+	h := fnv.New64a()
+	_, _ = h.Write([]byte("goboot-migrations"))
+	_, err = conn.ExecContext(context.Background(), "SELECT pg_advisory_lock($1)", int64(h.Sum64()))
+	assert.Nil(t, err)
+
+	dsn := env.Config.GetString("postgres.dsn")
+
+	// the schema is up-to-date
+	assert.Nil(t, s.Migrate(dsn, "./testdata/migrations"))
+
+	// the third migration is pending
+	err = s.Migrate(dsn, "./testdata/failing_migrations")
+	assert.EqualError(t, err, "timed out waiting for Postgres migration lock after 500ms, "+
+		"Postgres schema has 1 pending migrations")
+}
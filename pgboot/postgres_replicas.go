@@ -0,0 +1,191 @@
+package pgboot
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	ReplicaStrategyRoundRobin = "roundRobin"
+	ReplicaStrategyLeastConn  = "leastConn"
+
+	defaultReplicaCheckInterval = 10 * time.Second
+)
+
+type replica struct {
+	db      *sqlx.DB
+	url     string // redacted url for logging purposes
+	healthy atomic.Bool
+}
+
+type replicaSet struct {
+	replicas []*replica
+	next     atomic.Uint64
+	stop     chan struct{}
+	wg       sync.WaitGroup
+}
+
+// ReadDB returns a connection pool to a healthy read replica. Falls back to
+// the primary DB when no replicas are configured or all replicas are down.
+//
+// Replicas are selected using the configured "replicaStrategy".
+func (s *Postgres) ReadDB() *sqlx.DB {
+	if s.replicas == nil {
+		return s.DB
+	}
+
+	var selected *replica
+
+	switch s.config.ReplicaStrategy {
+	case ReplicaStrategyLeastConn:
+		selected = s.replicas.leastConn()
+	default:
+		selected = s.replicas.roundRobin()
+	}
+
+	if selected == nil {
+		return s.DB
+	}
+
+	return selected.db
+}
+
+func (rs *replicaSet) roundRobin() *replica {
+	n := uint64(len(rs.replicas))
+
+	for i := uint64(0); i < n; i++ {
+		r := rs.replicas[rs.next.Add(1)%n]
+		if r.healthy.Load() {
+			return r
+		}
+	}
+
+	return nil
+}
+
+func (rs *replicaSet) leastConn() *replica {
+	var selected *replica
+
+	for _, r := range rs.replicas {
+		if !r.healthy.Load() {
+			continue
+		}
+
+		if selected == nil || r.db.Stats().InUse < selected.db.Stats().InUse {
+			selected = r
+		}
+	}
+
+	return selected
+}
+
+// connectReplicas opens a pool for each replica. Unlike the primary, replicas
+// that are down on startup don't fail the boot; they're picked up by the health
+// check once available.
+func (s *Postgres) connectReplicas() error {
+	if len(s.config.Replicas) == 0 {
+		return nil
+	}
+
+	rs := &replicaSet{stop: make(chan struct{})}
+
+	for _, dsn := range s.config.Replicas {
+		logURL, err := redactDSN(dsn)
+		if err != nil {
+			rs.closeAll()
+
+			return fmt.Errorf("invalid Postgres replica dsn: %w", err)
+		}
+
+		db, err := s.open(dsn, false)
+		if err != nil {
+			rs.closeAll()
+
+			return fmt.Errorf("connection to postgres replica: %w", err)
+		}
+
+		s.configurePool(db)
+
+		r := &replica{db: db, url: logURL}
+		r.healthy.Store(true)
+		s.checkReplica(r)
+		rs.replicas = append(rs.replicas, r)
+	}
+
+	s.replicas = rs
+
+	rs.wg.Add(1)
+
+	go s.monitorReplicas()
+
+	return nil
+}
+
+func (s *Postgres) monitorReplicas() {
+	defer s.replicas.wg.Done()
+
+	ticker := time.NewTicker(s.config.ReplicaCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.replicas.stop:
+			return
+		case <-ticker.C:
+			for _, r := range s.replicas.replicas {
+				s.checkReplica(r)
+			}
+		}
+	}
+}
+
+// checkReplica pings the replica and logs any change in health.
+func (s *Postgres) checkReplica(r *replica) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.config.ReplicaCheckInterval)
+	defer cancel()
+
+	err := r.db.PingContext(ctx)
+	healthy := err == nil
+
+	if r.healthy.Swap(healthy) == healthy {
+		return
+	}
+
+	if healthy {
+		s.log.Info().Msgf("Postgres replica %s is available", r.url)
+	} else {
+		s.log.Warn().Err(err).Msgf("Postgres replica %s is unavailable", r.url)
+	}
+}
+
+// closeAll closes the replicas opened so far when connecting fails.
+func (rs *replicaSet) closeAll() {
+	for _, r := range rs.replicas {
+		_ = r.db.Close()
+	}
+}
+
+func (s *Postgres) closeReplicas() error {
+	if s.replicas == nil {
+		return nil
+	}
+
+	close(s.replicas.stop)
+	s.replicas.wg.Wait()
+
+	// closes every replica, returning the first error
+	var firstErr error
+
+	for _, r := range s.replicas.replicas {
+		if err := r.db.Close(); err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("closing replica %s: %w", r.url, err)
+		}
+	}
+
+	return firstErr
+}
@@ -0,0 +1,153 @@
+package pgboot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// seedsTable keeps track of seeds that have run, separate from the migration history.
+const seedsTable = "schema_seeds"
+
+// Seed inserts fixture data using Go code.
+type Seed struct {
+	ID string
+
+	// Envs limits the seed to specified environments, leave empty to run in
+	// all environments listed in "postgres.seedEnvs".
+	Envs []string
+
+	Seed func(ctx context.Context, tx *sqlx.Tx) error
+}
+
+// RunSeeds runs all seeds for the current environment that haven't run yet.
+//
+// SQL seeds are read from "{SeedsDir}/{env}/*.sql" in alphabetical order,
+// followed by the Go seeds in Seeds. Each seed runs once in its own transaction.
+func (s *Postgres) RunSeeds(ctx context.Context) error {
+	if err := s.ensureSeedsTable(ctx); err != nil {
+		return err
+	}
+
+	if s.SeedsDir != "" {
+		if err := s.runSQLSeeds(ctx, filepath.Join(s.SeedsDir, s.env)); err != nil {
+			return err
+		}
+	}
+
+	for _, seed := range s.Seeds {
+		if len(seed.Envs) > 0 && !contains(seed.Envs, s.env) {
+			continue
+		}
+
+		if err := s.runSeed(ctx, seed.ID, seed.Seed); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// seedsEnabled returns true if the current environment is listed in "postgres.seedEnvs".
+func (s *Postgres) seedsEnabled() bool {
+	return s.env != "" && contains(s.config.SeedEnvs, s.env)
+}
+
+func (s *Postgres) runSQLSeeds(ctx context.Context, dir string) error {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		s.log.Info().Msgf("no Postgres seeds found in %s", dir)
+
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("reading seeds directory: %w", err)
+	}
+
+	var files []string
+
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".sql") {
+			files = append(files, entry.Name())
+		}
+	}
+
+	sort.Strings(files)
+
+	for _, file := range files {
+		query, err := os.ReadFile(filepath.Join(dir, file))
+		if err != nil {
+			return fmt.Errorf("reading seed %q: %w", file, err)
+		}
+
+		err = s.runSeed(ctx, s.env+"/"+file, func(ctx context.Context, tx *sqlx.Tx) error {
+			_, err := tx.ExecContext(ctx, string(query))
+
+			return err //nolint:wrapcheck
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *Postgres) runSeed(ctx context.Context, id string, seed func(context.Context, *sqlx.Tx) error) error {
+	tx, err := s.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("starting transaction for seed %q: %w", id, err)
+	}
+
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	res, err := tx.ExecContext(ctx, `INSERT INTO `+seedsTable+` (id) VALUES ($1) ON CONFLICT DO NOTHING`, id)
+	if err != nil {
+		return fmt.Errorf("recording seed %q: %w", id, err)
+	}
+
+	if n, _ := res.RowsAffected(); n == 0 {
+		return nil // already ran
+	}
+
+	if err := seed(ctx, tx); err != nil {
+		return fmt.Errorf("seed %q failed: %w", id, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("committing seed %q: %w", id, err)
+	}
+
+	s.log.Info().Msgf("applied Postgres seed %q", id)
+
+	return nil
+}
+
+func (s *Postgres) ensureSeedsTable(ctx context.Context) error {
+	_, err := s.DB.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS `+seedsTable+` (
+		id text PRIMARY KEY,
+		applied_at timestamptz NOT NULL DEFAULT now()
+	)`)
+	if err != nil {
+		return fmt.Errorf("creating %s table: %w", seedsTable, err)
+	}
+
+	return nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,114 @@
+package pgboot
+
+import (
+	"crypto/tls"
+	"database/sql"
+	"fmt"
+	"strconv"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/stdlib"
+	"github.com/jmoiron/sqlx"
+)
+
+// open creates a connection pool for the DSN with the configured TLS settings applied,
+// see parseConnConfig.
+func (s *Postgres) open(dsn string, primary bool) (*sqlx.DB, error) {
+	db, err := s.openDB(dsn, primary)
+	if err != nil {
+		return nil, err
+	}
+
+	return sqlx.NewDb(db, "pgx"), nil
+}
+
+func (s *Postgres) openDB(dsn string, primary bool) (*sql.DB, error) {
+	connConfig, err := s.parseConnConfig(dsn, primary)
+	if err != nil {
+		return nil, err
+	}
+
+	return stdlib.OpenDB(*connConfig), nil
+}
+
+// parseConnConfig parses the DSN, either a URL or keyword/value pairs, and
+// applies the password and TLS settings from the config.
+//
+// The ssl* config settings take precedence over the DSN parameters. Query
+// parameters starting with "x-" are reserved for golang-migrate and removed,
+// see migrateConfig. Connections to the primary use the Cloud SQL
+// connector when configured instead.
+func (s *Postgres) parseConnConfig(dsn string, primary bool) (*pgx.ConnConfig, error) {
+	dsn, err := setDSNParams(dsn, [][2]string{
+		{"sslmode", s.config.SSLMode},
+		{"sslrootcert", s.config.SSLRootCert},
+		{"sslcert", s.config.SSLCert},
+		{"sslkey", s.config.SSLKey},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("invalid Postgres dsn: %w", err)
+	}
+
+	connConfig, err := pgx.ParseConfig(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("parsing Postgres dsn: %w", err)
+	}
+
+	connConfig.Logger = &dbLogger{s: s}
+
+	if s.password != "" {
+		connConfig.Password = s.password
+	}
+
+	if s.config.StatementTimeout > 0 {
+		connConfig.RuntimeParams["statement_timeout"] = strconv.FormatInt(s.config.StatementTimeout.Milliseconds(), 10)
+	}
+
+	if s.dialer != nil && primary {
+		s.useCloudSQLDialer(connConfig)
+
+		return connConfig, nil
+	}
+
+	if s.TLSConfig != nil {
+		s.useTLSConfig(connConfig)
+	}
+
+	if s.config.SSLServerName != "" {
+		if connConfig.TLSConfig != nil {
+			connConfig.TLSConfig.ServerName = s.config.SSLServerName
+		}
+
+		for _, fallback := range connConfig.Fallbacks {
+			if fallback.TLSConfig != nil {
+				fallback.TLSConfig.ServerName = s.config.SSLServerName
+			}
+		}
+	}
+
+	return connConfig, nil
+}
+
+// useTLSConfig replaces the TLS settings of the connection and its
+// fallbacks that use TLS with TLSConfig, verifying their host unless
+// TLSConfig has a ServerName.
+func (s *Postgres) useTLSConfig(connConfig *pgx.ConnConfig) {
+	tlsConfig := func(host string) *tls.Config {
+		cfg := s.TLSConfig.Clone()
+		if cfg.ServerName == "" {
+			cfg.ServerName = host
+		}
+
+		return cfg
+	}
+
+	if connConfig.TLSConfig != nil {
+		connConfig.TLSConfig = tlsConfig(connConfig.Host)
+	}
+
+	for _, fallback := range connConfig.Fallbacks {
+		if fallback.TLSConfig != nil {
+			fallback.TLSConfig = tlsConfig(fallback.Host)
+		}
+	}
+}
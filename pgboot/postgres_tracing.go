@@ -0,0 +1,78 @@
+package pgboot
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgconn"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.12.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/nielskrijger/goboot/pgboot"
+
+// sqlLiterals matches $1 placeholders and string and numeric literals.
+var sqlLiterals = regexp.MustCompile(`\$\d+|'(?:[^']|'')*'|\b\d+(?:\.\d+)?\b`)
+
+// traceQuery records a span for a completed query. pgx only reports queries
+// after they've finished, so the span start time is derived from the duration.
+// The span is parented to the span in the query context, if any.
+func (s *Postgres) traceQuery(ctx context.Context, op string, sql string, duration time.Duration, data map[string]any, err error) {
+	if s.tracer == nil {
+		return
+	}
+
+	end := time.Now()
+
+	_, span := s.tracer.Start(ctx, "postgres."+op,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithTimestamp(end.Add(-duration)),
+		trace.WithAttributes(
+			semconv.DBSystemPostgreSQL,
+			semconv.DBOperationKey.String(strings.ToUpper(op)),
+			semconv.DBStatementKey.String(sanitizeSQL(sql)),
+		),
+	)
+
+	if rows, ok := rowsAffected(data); ok {
+		span.SetAttributes(attribute.Int64("db.rows_affected", rows))
+	}
+
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	span.End(trace.WithTimestamp(end))
+}
+
+func rowsAffected(data map[string]any) (int64, bool) {
+	switch v := data["rowCount"].(type) {
+	case int:
+		return int64(v), true
+	case int64:
+		return v, true
+	}
+
+	if tag, ok := data["commandTag"].(pgconn.CommandTag); ok {
+		return tag.RowsAffected(), true
+	}
+
+	return 0, false
+}
+
+// sanitizeSQL replaces literals with "?" so span attributes don't contain
+// sensitive values. Query arguments are never recorded.
+func sanitizeSQL(sql string) string {
+	return sqlLiterals.ReplaceAllStringFunc(formatSQL(sql), func(match string) string {
+		if strings.HasPrefix(match, "$") {
+			return match
+		}
+
+		return "?"
+	})
+}
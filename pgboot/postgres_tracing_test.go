@@ -0,0 +1,43 @@
+package pgboot_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/nielskrijger/goboot"
+	"github.com/nielskrijger/goboot/pgboot"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestPostgres_TraceQueries(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	env := goboot.NewAppEnv("./testdata", "valid")
+	env.TracerProvider = tp
+	s := &pgboot.Postgres{}
+	assert.Nil(t, s.Configure(env))
+
+	ctx, parent := tp.Tracer("test").Start(context.Background(), "request")
+	_, err := s.DB.ExecContext(ctx, "SELECT 42 WHERE 'secret' = $1", "secret")
+	assert.Nil(t, err)
+	parent.End()
+
+	var query sdktrace.ReadOnlySpan
+
+	for _, span := range recorder.Ended() {
+		if span.Name() == "postgres.select" {
+			query = span
+		}
+	}
+
+	if assert.NotNil(t, query) {
+		assert.Equal(t, parent.SpanContext().SpanID(), query.Parent().SpanID())
+		assert.Contains(t, query.Attributes(), attribute.String("db.statement", "SELECT ? WHERE ? = $1"))
+	}
+
+	assert.Nil(t, s.Close())
+}
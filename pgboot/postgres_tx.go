@@ -0,0 +1,112 @@
+package pgboot
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgerrcode"
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	defaultTxMaxRetries = 3
+	defaultTxTimeout    = 30 * time.Second
+
+	txInitialBackoff = 10 * time.Millisecond
+	txMaxBackoff     = time.Second
+)
+
+// TxOptions configures a RunInTx transaction. Zero values use the defaults
+// from the Postgres configuration.
+type TxOptions struct {
+	Isolation sql.IsolationLevel
+	ReadOnly  bool
+
+	// Overrides "postgres.txMaxRetries", set -1 to disable retries.
+	MaxRetries int
+
+	// Overrides "postgres.txTimeout".
+	Timeout time.Duration
+}
+
+// RunInTx runs fn in a transaction. The transaction is committed when fn
+// returns nil and rolled back otherwise.
+//
+// Transactions failing on a serialization failure or deadlock are retried
+// with exponential backoff, so fn must be safe to run multiple times. Each
+// attempt is cancelled when exceeding the transaction timeout.
+func (s *Postgres) RunInTx(ctx context.Context, opts *TxOptions, fn func(ctx context.Context, tx *sqlx.Tx) error) error {
+	if opts == nil {
+		opts = &TxOptions{}
+	}
+
+	maxRetries := opts.MaxRetries
+	if maxRetries == 0 {
+		maxRetries = s.config.TxMaxRetries
+	}
+
+	backoff := txInitialBackoff
+
+	for attempt := 0; ; attempt++ {
+		err := s.runTx(ctx, opts, fn)
+		if err == nil || !isRetryableTxError(err) || attempt >= maxRetries {
+			return err
+		}
+
+		s.log.Debug().Err(err).Msgf("retrying Postgres transaction in %s", backoff)
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("retrying transaction: %w", ctx.Err())
+		case <-time.After(backoff):
+		}
+
+		backoff *= 2
+		if backoff > txMaxBackoff {
+			backoff = txMaxBackoff
+		}
+	}
+}
+
+func (s *Postgres) runTx(ctx context.Context, opts *TxOptions, fn func(ctx context.Context, tx *sqlx.Tx) error) error {
+	timeout := opts.Timeout
+	if timeout == 0 {
+		timeout = s.config.TxTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	tx, err := s.DB.BeginTxx(ctx, &sql.TxOptions{Isolation: opts.Isolation, ReadOnly: opts.ReadOnly})
+	if err != nil {
+		return fmt.Errorf("starting transaction: %w", err)
+	}
+
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	if err := fn(ctx, tx); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("committing transaction: %w", err)
+	}
+
+	return nil
+}
+
+// isRetryableTxError returns true for serialization failures and deadlocks.
+func isRetryableTxError(err error) bool {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return false
+	}
+
+	return pgErr.Code == pgerrcode.SerializationFailure || pgErr.Code == pgerrcode.DeadlockDetected
+}
@@ -0,0 +1,69 @@
+package pgboot_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgconn"
+	"github.com/jmoiron/sqlx"
+	"github.com/nielskrijger/goboot"
+	"github.com/nielskrijger/goboot/pgboot"
+	"github.com/stretchr/testify/assert"
+)
+
+var errTest = errors.New("test error")
+
+func TestPostgres_RunInTxRetriesSerializationFailure(t *testing.T) {
+	s := &pgboot.Postgres{}
+	assert.Nil(t, s.Configure(goboot.NewAppEnv("./testdata", "valid")))
+
+	attempts := 0
+	err := s.RunInTx(context.Background(), nil, func(ctx context.Context, tx *sqlx.Tx) error {
+		attempts++
+		if attempts < 3 {
+			return &pgconn.PgError{Code: "40001"}
+		}
+
+		_, err := tx.ExecContext(ctx, "SELECT 1")
+
+		return err
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, 3, attempts)
+	assert.Nil(t, s.Close())
+}
+
+func TestPostgres_RunInTxMaxRetries(t *testing.T) {
+	s := &pgboot.Postgres{}
+	assert.Nil(t, s.Configure(goboot.NewAppEnv("./testdata", "valid")))
+
+	attempts := 0
+	err := s.RunInTx(context.Background(), &pgboot.TxOptions{MaxRetries: 1}, func(ctx context.Context, tx *sqlx.Tx) error {
+		attempts++
+
+		return &pgconn.PgError{Code: "40P01"}
+	})
+
+	var pgErr *pgconn.PgError
+	assert.ErrorAs(t, err, &pgErr)
+	assert.Equal(t, 2, attempts)
+	assert.Nil(t, s.Close())
+}
+
+func TestPostgres_RunInTxNoRetryOnOtherErrors(t *testing.T) {
+	s := &pgboot.Postgres{}
+	assert.Nil(t, s.Configure(goboot.NewAppEnv("./testdata", "valid")))
+
+	attempts := 0
+	err := s.RunInTx(context.Background(), nil, func(ctx context.Context, tx *sqlx.Tx) error {
+		attempts++
+
+		return errTest
+	})
+
+	assert.ErrorIs(t, err, errTest)
+	assert.Equal(t, 1, attempts)
+	assert.Nil(t, s.Close())
+}
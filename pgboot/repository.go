@@ -0,0 +1,401 @@
+package pgboot
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jmoiron/sqlx"
+	"github.com/jmoiron/sqlx/reflectx"
+)
+
+// Options of the "db" struct tag used by the repository helpers, e.g. `db:"id,pk,generated"`.
+const (
+	// TagPrimaryKey marks the primary key column, defaults to the "id" column.
+	TagPrimaryKey = "pk"
+
+	// TagGenerated marks a column generated by the database, e.g. a serial id
+	// or a column with a default value. Generated columns are not inserted or
+	// updated; their values are read back after an insert.
+	TagGenerated = "generated"
+
+	// TagVersion marks an integer column used for optimistic locking. Updates
+	// fail with ErrStaleVersion when the row was modified in the meantime.
+	TagVersion = "version"
+)
+
+const defaultPageLimit = 50
+
+var (
+	ErrNotFound     = errors.New("record not found")
+	ErrStaleVersion = errors.New("record was modified concurrently")
+
+	errMissingPrimaryKey = errors.New("missing primary key column")
+	errNothingToUpdate   = errors.New("no columns to update")
+	errInvalidCursor     = errors.New("invalid cursor")
+)
+
+// Table is implemented by structs mapped to a database table.
+type Table interface {
+	TableName() string
+}
+
+// Page contains a page of records and the cursor of the next page. NextCursor
+// is empty on the last page.
+type Page[T Table] struct {
+	Items      []T
+	NextCursor string
+}
+
+// PageOptions configures FindPage.
+type PageOptions struct {
+	// Cursor of the page to retrieve, leave empty for the first page.
+	Cursor string
+
+	// Maximum number of records per page. Default is 50.
+	Limit int
+
+	// Optional filter, e.g. "status = $1" with Args []any{"active"}.
+	Where string
+	Args  []any
+}
+
+var repoMapper = reflectx.NewMapperFunc("db", sqlx.NameMapper)
+
+type column struct {
+	name  string
+	field *reflectx.FieldInfo
+}
+
+type tableInfo struct {
+	name     string
+	pk       *column
+	version  *column
+	writable []*column
+	columns  []string
+}
+
+func tableOf[T Table]() (*tableInfo, error) {
+	var zero T
+
+	info := &tableInfo{name: pgx.Identifier(strings.Split(zero.TableName(), ".")).Sanitize()}
+
+	var id *column
+
+	for _, fi := range repoMapper.TypeMap(reflect.TypeOf(zero)).Index {
+		if fi.Embedded || strings.Contains(fi.Path, ".") || fi.Name == "" {
+			continue
+		}
+
+		c := &column{name: fi.Name, field: fi}
+		info.columns = append(info.columns, pgx.Identifier{fi.Name}.Sanitize())
+
+		if _, ok := fi.Options[TagPrimaryKey]; ok {
+			info.pk = c
+		}
+
+		if fi.Name == "id" {
+			id = c
+		}
+
+		if _, ok := fi.Options[TagVersion]; ok {
+			info.version = c
+
+			continue
+		}
+
+		if _, ok := fi.Options[TagGenerated]; !ok {
+			info.writable = append(info.writable, c)
+		}
+	}
+
+	if info.pk == nil {
+		info.pk = id
+	}
+
+	if info.pk == nil {
+		return nil, fmt.Errorf("%w in %T", errMissingPrimaryKey, zero)
+	}
+
+	return info, nil
+}
+
+func (c *column) value(v reflect.Value) any {
+	return reflectx.FieldByIndexesReadOnly(reflect.Indirect(v), c.field.Index).Interface()
+}
+
+func (c *column) quoted() string {
+	return pgx.Identifier{c.name}.Sanitize()
+}
+
+// FindByID returns the record with specified primary key or ErrNotFound.
+func FindByID[T Table](ctx context.Context, db sqlx.ExtContext, id any) (*T, error) {
+	info, err := tableOf[T]()
+	if err != nil {
+		return nil, err
+	}
+
+	var result T
+
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE %s = $1", strings.Join(info.columns, ", "), info.name, info.pk.quoted())
+	if err := sqlx.GetContext(ctx, db, &result, query, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+
+		return nil, fmt.Errorf("finding %s: %w", info.name, err)
+	}
+
+	return &result, nil
+}
+
+// Find returns all records matching the optional where clause, e.g.
+//
+//	users, err := pgboot.Find[User](ctx, pg.DB, "email = $1", email)
+func Find[T Table](ctx context.Context, db sqlx.ExtContext, where string, args ...any) ([]T, error) {
+	info, err := tableOf[T]()
+	if err != nil {
+		return nil, err
+	}
+
+	query := fmt.Sprintf("SELECT %s FROM %s", strings.Join(info.columns, ", "), info.name)
+	if where != "" {
+		query += " WHERE " + where
+	}
+
+	var result []T
+	if err := sqlx.SelectContext(ctx, db, &result, query+" ORDER BY "+info.pk.quoted(), args...); err != nil {
+		return nil, fmt.Errorf("finding %s: %w", info.name, err)
+	}
+
+	return result, nil
+}
+
+// FindPage returns a page of records ordered by primary key. Pass the
+// NextCursor of a page as PageOptions.Cursor to retrieve the next page.
+//
+// Cursor pagination is stable when records are inserted or deleted between
+// requests, unlike offset pagination.
+func FindPage[T Table](ctx context.Context, db sqlx.ExtContext, opts PageOptions) (*Page[T], error) {
+	info, err := tableOf[T]()
+	if err != nil {
+		return nil, err
+	}
+
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = defaultPageLimit
+	}
+
+	var conditions []string
+
+	// copy so appending doesn't write into the backing array of opts.Args
+	args := append([]any{}, opts.Args...)
+
+	if opts.Where != "" {
+		conditions = append(conditions, "("+opts.Where+")")
+	}
+
+	if opts.Cursor != "" {
+		after, err := decodeCursor(opts.Cursor)
+		if err != nil {
+			return nil, err
+		}
+
+		args = append(args, after)
+		conditions = append(conditions, fmt.Sprintf("%s > $%d", info.pk.quoted(), len(args)))
+	}
+
+	query := fmt.Sprintf("SELECT %s FROM %s", strings.Join(info.columns, ", "), info.name)
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	// fetch one more to know whether there is a next page
+	args = append(args, limit+1)
+	query += fmt.Sprintf(" ORDER BY %s LIMIT $%d", info.pk.quoted(), len(args))
+
+	page := &Page[T]{}
+	if err := sqlx.SelectContext(ctx, db, &page.Items, query, args...); err != nil {
+		return nil, fmt.Errorf("finding %s: %w", info.name, err)
+	}
+
+	if len(page.Items) > limit {
+		page.Items = page.Items[:limit]
+
+		page.NextCursor, err = encodeCursor(info.pk.value(reflect.ValueOf(page.Items[limit-1])))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return page, nil
+}
+
+// Insert inserts the record and updates its generated columns, e.g. a serial id.
+// The version column, if any, starts at 1. A record without any other columns
+// than generated ones is inserted using the column defaults.
+func Insert[T Table](ctx context.Context, db sqlx.ExtContext, record *T) error {
+	info, err := tableOf[T]()
+	if err != nil {
+		return err
+	}
+
+	v := reflect.ValueOf(record)
+	columns := make([]string, 0, len(info.writable)+1)
+	placeholders := make([]string, 0, len(info.writable)+1)
+	args := make([]any, 0, len(info.writable))
+
+	for _, c := range info.writable {
+		args = append(args, c.value(v))
+		columns = append(columns, c.quoted())
+		placeholders = append(placeholders, fmt.Sprintf("$%d", len(args)))
+	}
+
+	if info.version != nil {
+		columns = append(columns, info.version.quoted())
+		placeholders = append(placeholders, "1")
+	}
+
+	values := "DEFAULT VALUES"
+	if len(columns) > 0 {
+		values = fmt.Sprintf("(%s) VALUES (%s)", strings.Join(columns, ", "), strings.Join(placeholders, ", "))
+	}
+
+	query := fmt.Sprintf("INSERT INTO %s %s RETURNING %s", info.name, values, strings.Join(info.columns, ", "))
+
+	if err := sqlx.GetContext(ctx, db, record, query, args...); err != nil {
+		return fmt.Errorf("inserting %s: %w", info.name, err)
+	}
+
+	return nil
+}
+
+// Update updates all non-generated columns of the record by primary key. It
+// returns an error if the record has no such columns besides the primary key.
+//
+// When the record has a version column the update only succeeds if the
+// version is unchanged since the record was read, otherwise ErrStaleVersion
+// is returned. The version is incremented on success. ErrNotFound is returned
+// when no record with the primary key exists.
+func Update[T Table](ctx context.Context, db sqlx.ExtContext, record *T) error {
+	info, err := tableOf[T]()
+	if err != nil {
+		return err
+	}
+
+	v := reflect.ValueOf(record)
+	sets := make([]string, 0, len(info.writable)+1)
+	args := make([]any, 0, len(info.writable)+2) //nolint:gomnd
+
+	for _, c := range info.writable {
+		if c == info.pk {
+			continue
+		}
+
+		args = append(args, c.value(v))
+		sets = append(sets, fmt.Sprintf("%s = $%d", c.quoted(), len(args)))
+	}
+
+	args = append(args, info.pk.value(v))
+	where := fmt.Sprintf("%s = $%d", info.pk.quoted(), len(args))
+
+	if info.version != nil {
+		sets = append(sets, fmt.Sprintf("%s = %s + 1", info.version.quoted(), info.version.quoted()))
+		args = append(args, info.version.value(v))
+		where += fmt.Sprintf(" AND %s = $%d", info.version.quoted(), len(args))
+	}
+
+	if len(sets) == 0 {
+		return fmt.Errorf("updating %s: %w in %T", info.name, errNothingToUpdate, *record)
+	}
+
+	query := fmt.Sprintf("UPDATE %s SET %s WHERE %s RETURNING %s", info.name,
+		strings.Join(sets, ", "), where, strings.Join(info.columns, ", "))
+
+	if err := sqlx.GetContext(ctx, db, record, query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			if info.version != nil {
+				return staleOrNotFound(ctx, db, info, info.pk.value(v))
+			}
+
+			return ErrNotFound
+		}
+
+		return fmt.Errorf("updating %s: %w", info.name, err)
+	}
+
+	return nil
+}
+
+// staleOrNotFound returns ErrStaleVersion when the record with primary key id
+// exists after a versioned update didn't match, so callers only retry updates
+// of existing records.
+func staleOrNotFound(ctx context.Context, db sqlx.ExtContext, info *tableInfo, id any) error {
+	var exists bool
+
+	query := fmt.Sprintf("SELECT EXISTS (SELECT 1 FROM %s WHERE %s = $1)", info.name, info.pk.quoted())
+	if err := sqlx.GetContext(ctx, db, &exists, query, id); err != nil {
+		return fmt.Errorf("updating %s: %w", info.name, err)
+	}
+
+	if !exists {
+		return ErrNotFound
+	}
+
+	return ErrStaleVersion
+}
+
+// Delete deletes the record with specified primary key, returns ErrNotFound
+// if it doesn't exist.
+func Delete[T Table](ctx context.Context, db sqlx.ExtContext, id any) error {
+	info, err := tableOf[T]()
+	if err != nil {
+		return err
+	}
+
+	res, err := db.ExecContext(ctx, fmt.Sprintf("DELETE FROM %s WHERE %s = $1", info.name, info.pk.quoted()), id)
+	if err != nil {
+		return fmt.Errorf("deleting %s: %w", info.name, err)
+	}
+
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func encodeCursor(v any) (string, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", fmt.Errorf("encoding cursor: %w", err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func decodeCursor(cursor string) (any, error) {
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, errInvalidCursor
+	}
+
+	// decode numbers as json.Number to keep the precision of bigint keys
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, errInvalidCursor
+	}
+
+	return v, nil
+}
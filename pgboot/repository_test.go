@@ -0,0 +1,156 @@
+package pgboot_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/nielskrijger/goboot/pgboot"
+	"github.com/nielskrijger/goboot/pgboot/pgtest"
+	"github.com/stretchr/testify/assert"
+)
+
+type user struct {
+	ID        int64     `db:"id,generated"`
+	Email     string    `db:"email"`
+	Name      string    `db:"name"`
+	Version   int       `db:"version,version"`
+	CreatedAt time.Time `db:"created_at,generated"`
+}
+
+func (user) TableName() string {
+	return "users"
+}
+
+func setupRepository(t *testing.T) *pgboot.Postgres {
+	t.Helper()
+
+	return pgtest.NewDatabase(t, "./testdata", "valid", "./testdata/repository_migrations")
+}
+
+func TestRepository_InsertAndFind(t *testing.T) {
+	pg := setupRepository(t)
+	ctx := context.Background()
+
+	u := &user{Email: "alice@example.com", Name: "Alice"}
+	assert.Nil(t, pgboot.Insert(ctx, pg.DB, u))
+	assert.NotZero(t, u.ID)
+	assert.Equal(t, 1, u.Version)
+	assert.False(t, u.CreatedAt.IsZero())
+
+	found, err := pgboot.FindByID[user](ctx, pg.DB, u.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, "Alice", found.Name)
+
+	users, err := pgboot.Find[user](ctx, pg.DB, "email = $1", "alice@example.com")
+	assert.Nil(t, err)
+	assert.Len(t, users, 1)
+
+	_, err = pgboot.FindByID[user](ctx, pg.DB, -1)
+	assert.ErrorIs(t, err, pgboot.ErrNotFound)
+}
+
+func TestRepository_UpdateOptimisticLocking(t *testing.T) {
+	pg := setupRepository(t)
+	ctx := context.Background()
+
+	u := &user{Email: "bob@example.com", Name: "Bob"}
+	assert.Nil(t, pgboot.Insert(ctx, pg.DB, u))
+
+	stale := *u
+
+	u.Name = "Bobby"
+	assert.Nil(t, pgboot.Update(ctx, pg.DB, u))
+	assert.Equal(t, 2, u.Version)
+
+	stale.Name = "Robert"
+	assert.ErrorIs(t, pgboot.Update(ctx, pg.DB, &stale), pgboot.ErrStaleVersion)
+
+	found, err := pgboot.FindByID[user](ctx, pg.DB, u.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, "Bobby", found.Name)
+}
+
+func TestRepository_UpdateDeletedVersioned(t *testing.T) {
+	pg := setupRepository(t)
+	ctx := context.Background()
+
+	u := &user{Email: "dave@example.com", Name: "Dave"}
+	assert.Nil(t, pgboot.Insert(ctx, pg.DB, u))
+	assert.Nil(t, pgboot.Delete[user](ctx, pg.DB, u.ID))
+
+	u.Name = "David"
+	assert.ErrorIs(t, pgboot.Update(ctx, pg.DB, u), pgboot.ErrNotFound)
+}
+
+type auditEntry struct {
+	ID        int64     `db:"id,generated"`
+	CreatedAt time.Time `db:"created_at,generated"`
+}
+
+func (auditEntry) TableName() string {
+	return "audit_entries"
+}
+
+func TestRepository_InsertDefaultValues(t *testing.T) {
+	pg := setupRepository(t)
+
+	entry := &auditEntry{}
+	assert.Nil(t, pgboot.Insert(context.Background(), pg.DB, entry))
+	assert.NotZero(t, entry.ID)
+	assert.False(t, entry.CreatedAt.IsZero())
+}
+
+func TestRepository_UpdateErrorNothingToUpdate(t *testing.T) {
+	err := pgboot.Update(context.Background(), nil, &auditEntry{ID: 1})
+	assert.EqualError(t, err, `updating "audit_entries": no columns to update in pgboot_test.auditEntry`)
+}
+
+func TestRepository_Delete(t *testing.T) {
+	pg := setupRepository(t)
+	ctx := context.Background()
+
+	u := &user{Email: "carol@example.com", Name: "Carol"}
+	assert.Nil(t, pgboot.Insert(ctx, pg.DB, u))
+	assert.Nil(t, pgboot.Delete[user](ctx, pg.DB, u.ID))
+	assert.ErrorIs(t, pgboot.Delete[user](ctx, pg.DB, u.ID), pgboot.ErrNotFound)
+}
+
+func TestRepository_FindPage(t *testing.T) {
+	pg := setupRepository(t)
+	ctx := context.Background()
+
+	for i := 0; i < 5; i++ {
+		u := &user{Email: fmt.Sprintf("user%d@example.com", i), Name: "User"}
+		assert.Nil(t, pgboot.Insert(ctx, pg.DB, u))
+	}
+
+	var emails []string
+
+	// spare capacity must not be written to by FindPage
+	args := append(make([]any, 0, 3), "User")
+	opts := pgboot.PageOptions{Limit: 2, Where: "name = $1", Args: args}
+
+	for pages := 1; ; pages++ {
+		page, err := pgboot.FindPage[user](ctx, pg.DB, opts)
+		assert.Nil(t, err)
+
+		for _, u := range page.Items {
+			emails = append(emails, u.Email)
+		}
+
+		if page.NextCursor == "" {
+			assert.Equal(t, 3, pages)
+
+			break
+		}
+
+		opts.Cursor = page.NextCursor
+	}
+
+	assert.Equal(t, []string{
+		"user0@example.com", "user1@example.com", "user2@example.com", "user3@example.com", "user4@example.com",
+	}, emails)
+	assert.Equal(t, []any{"User", nil, nil}, args[:cap(args)])
+}
@@ -0,0 +1 @@
+CREATE TABLE IF NOT EXISTS failing_table (id int);
@@ -0,0 +1 @@
+DELETE FROM failing_table;
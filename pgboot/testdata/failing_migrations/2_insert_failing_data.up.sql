@@ -0,0 +1 @@
+INSERT INTO failing_table (id) VALUES (1);
@@ -0,0 +1 @@
+INSERT INTO missing_table (id) VALUES (1);
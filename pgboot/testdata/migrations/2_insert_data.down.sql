@@ -0,0 +1 @@
+DELETE FROM test_table WHERE name IN ('First record', 'Second record');
@@ -0,0 +1,7 @@
+CREATE TABLE users (
+    id bigserial PRIMARY KEY,
+    email text NOT NULL UNIQUE,
+    name text NOT NULL,
+    version int NOT NULL,
+    created_at timestamptz NOT NULL DEFAULT now()
+);
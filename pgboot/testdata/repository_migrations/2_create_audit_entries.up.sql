@@ -0,0 +1,4 @@
+CREATE TABLE audit_entries (
+    id bigserial PRIMARY KEY,
+    created_at timestamptz NOT NULL DEFAULT now()
+);
@@ -0,0 +1,701 @@
+package redisboot
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/nielskrijger/goboot"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog"
+)
+
+// DefaultQueue is the queue of jobs enqueued without JobOptions.Queue.
+const DefaultQueue = "default"
+
+const (
+	defaultJobsConcurrency  = 10
+	defaultJobsMaxRetries   = 3
+	defaultJobsRetryBackoff = time.Second
+	defaultJobsPollInterval = time.Second
+	maxJobsRetryBackoff     = time.Hour
+	defaultJobsDeadMaxSize  = 10000
+
+	// jobsPopTimeout is the time a worker blocks waiting for a job before
+	// checking whether the service is closing.
+	jobsPopTimeout = time.Second
+
+	// jobsScheduleBatchSize is the maximum number of due jobs moved to their
+	// queue per poll.
+	jobsScheduleBatchSize = 100
+)
+
+var (
+	errNoJobHandler      = errors.New("no handler registered for job type")
+	errUnknownQueue      = errors.New("queue not configured in \"jobs.queues\"")
+	errInvalidDeadLimits = errors.New("config \"jobs.deadMaxSize\" and \"jobs.deadTTL\" must not be negative")
+
+	// ErrDeadJobNotFound is returned by RetryDead and DeleteDead when the id
+	// isn't in the dead set.
+	ErrDeadJobNotFound = errors.New("dead job not found")
+)
+
+// enqueueDueScript moves due jobs from the scheduled set KEYS[1] to the
+// queue KEYS[2], removing the job first so multiple instances don't enqueue
+// it twice.
+var enqueueDueScript = redis.NewScript(`
+local jobs = redis.call("zrangebyscore", KEYS[1], "-inf", ARGV[1], "limit", 0, ARGV[2])
+for _, job in ipairs(jobs) do
+	if redis.call("zrem", KEYS[1], job) == 1 then
+		redis.call("lpush", KEYS[2], job)
+	end
+end
+return #jobs`)
+
+// addDeadScript adds the job ARGV[3] with id ARGV[1] to the dead set KEYS[1]
+// and its jobs KEYS[2], then removes the oldest jobs beyond ARGV[4] jobs and
+// the jobs added before ARGV[5].
+var addDeadScript = redis.NewScript(`
+redis.call("zadd", KEYS[1], ARGV[2], ARGV[1])
+redis.call("hset", KEYS[2], ARGV[1], ARGV[3])
+local function remove(ids)
+	for _, id in ipairs(ids) do
+		redis.call("zrem", KEYS[1], id)
+		redis.call("hdel", KEYS[2], id)
+	end
+end
+remove(redis.call("zrangebyscore", KEYS[1], "-inf", "(" .. ARGV[5]))
+remove(redis.call("zrange", KEYS[1], 0, -tonumber(ARGV[4]) - 1))
+return 0`)
+
+// removeDeadScript removes the job with id ARGV[1] from the dead set KEYS[1]
+// and its jobs KEYS[2], returning the job or nil when not found.
+var removeDeadScript = redis.NewScript(`
+if redis.call("zrem", KEYS[1], ARGV[1]) == 0 then
+	return false
+end
+local job = redis.call("hget", KEYS[2], ARGV[1])
+redis.call("hdel", KEYS[2], ARGV[1])
+return job`)
+
+type JobsConfig struct {
+	// Queues maps queue names to the number of concurrent workers processing
+	// jobs of that queue. Default is {"default": 10}.
+	Queues map[string]int `yaml:"queues"`
+
+	// Number of retries of failed jobs before moving them to the dead set.
+	// Default is 3, 0 disables retries. Use JobOptions.MaxRetries to override
+	// per job.
+	MaxRetries int `yaml:"maxRetries"`
+
+	// Initial delay before retrying a failed job, doubled on every retry up
+	// to an hour. Default is 1 second.
+	RetryBackoff time.Duration `yaml:"retryBackoff"`
+
+	// Time between checks for scheduled and retried jobs that are due.
+	// Default is 1 second.
+	PollInterval time.Duration `yaml:"pollInterval"`
+
+	// Maximum number of jobs in the dead set, the oldest jobs are removed
+	// when full. Default is 10000.
+	DeadMaxSize int `yaml:"deadMaxSize"`
+
+	// Time jobs are kept in the dead set. Default is 0, keeping them until
+	// the dead set is full.
+	DeadTTL time.Duration `yaml:"deadTTL"`
+}
+
+// Job is a unit of work enqueued by Enqueue and processed by the handler of
+// its type.
+type Job struct {
+	ID         string          `json:"id"`
+	Type       string          `json:"type"`
+	Queue      string          `json:"queue"`
+	Payload    json.RawMessage `json:"payload"`
+	MaxRetries int             `json:"maxRetries"`
+
+	// Attempt is the number of times the job ran before, 0 on the first run.
+	Attempt    int       `json:"attempt"`
+	EnqueuedAt time.Time `json:"enqueuedAt"`
+
+	// Error is the error of the last failed attempt.
+	Error string `json:"error,omitempty"`
+}
+
+// Decode unmarshals the JSON payload into v.
+func (j *Job) Decode(v any) error {
+	if err := json.Unmarshal(j.Payload, v); err != nil {
+		return fmt.Errorf("decoding payload of job %q: %w", j.ID, err)
+	}
+
+	return nil
+}
+
+// JobOptions are the options of Enqueue, all are optional.
+type JobOptions struct {
+	// Queue defaults to DefaultQueue, it must be one of "jobs.queues".
+	Queue string
+
+	// MaxRetries overrides config "jobs.maxRetries", -1 disables retries.
+	MaxRetries int
+
+	// Delay or RunAt schedules the job instead of running it immediately.
+	Delay time.Duration
+	RunAt time.Time
+}
+
+// JobHandler processes a job, returning an error retries the job.
+type JobHandler func(ctx context.Context, job *Job) error
+
+// Jobs implements the AppService interface. It runs a background job queue
+// stored in Redis: jobs are enqueued in a list per queue and processed by a
+// pool of workers per queue. Delayed jobs are moved to their queue by every
+// instance, or by the leader only when Elector is set. Failed jobs are retried
+// with exponential backoff and moved to the dead set when they keep failing.
+//
+// Close waits for running jobs to finish, jobs running while the process
+// crashes are lost.
+type Jobs struct {
+	// Elector restricts the scheduler of delayed and retried jobs to the
+	// leader when set, e.g. the Elector of Redis.NewElector.
+	Elector goboot.Elector
+
+	config     *JobsConfig
+	redis      *Redis
+	handlers   map[string]JobHandler
+	handlersMu sync.RWMutex
+	metrics    *jobsMetrics
+	log        zerolog.Logger
+	stop       chan struct{}
+	stopOnce   sync.Once
+	wg         sync.WaitGroup
+}
+
+// NewJobs creates a job queue stored in redis. Register handlers using Handle
+// before Init starts the workers.
+func NewJobs(redis *Redis) *Jobs {
+	return &Jobs{
+		redis:    redis,
+		handlers: make(map[string]JobHandler),
+		stop:     make(chan struct{}),
+	}
+}
+
+func (s *Jobs) Name() string {
+	return "Jobs"
+}
+
+func (s *Jobs) Configure(env *goboot.AppEnv) error {
+	s.log = env.Log
+	s.config = &JobsConfig{}
+
+	if env.Config.InConfig("jobs") {
+		if err := env.Config.Sub("jobs").Unmarshal(s.config); err != nil {
+			return fmt.Errorf("parsing jobs configuration: %w", err)
+		}
+	}
+
+	if len(s.config.Queues) == 0 {
+		s.config.Queues = map[string]int{DefaultQueue: defaultJobsConcurrency}
+	}
+
+	if !env.Config.IsSet("jobs.maxRetries") {
+		s.config.MaxRetries = defaultJobsMaxRetries
+	}
+
+	if s.config.MaxRetries == 0 {
+		// stored like JobOptions.MaxRetries, where 0 means using the config
+		s.config.MaxRetries = -1
+	}
+
+	if s.config.RetryBackoff == 0 {
+		s.config.RetryBackoff = defaultJobsRetryBackoff
+	}
+
+	if s.config.PollInterval == 0 {
+		s.config.PollInterval = defaultJobsPollInterval
+	}
+
+	if s.config.DeadMaxSize == 0 {
+		s.config.DeadMaxSize = defaultJobsDeadMaxSize
+	}
+
+	if s.config.DeadMaxSize < 0 || s.config.DeadTTL < 0 {
+		return errInvalidDeadLimits
+	}
+
+	return s.registerMetrics(env.Metrics)
+}
+
+// Handle registers the handler of jobType.
+func (s *Jobs) Handle(jobType string, handler JobHandler) {
+	s.handlersMu.Lock()
+	defer s.handlersMu.Unlock()
+
+	s.handlers[jobType] = handler
+}
+
+func (s *Jobs) handler(jobType string) (JobHandler, bool) {
+	s.handlersMu.RLock()
+	defer s.handlersMu.RUnlock()
+
+	handler, ok := s.handlers[jobType]
+
+	return handler, ok
+}
+
+// Init starts the workers and the scheduler of delayed and retried jobs.
+func (s *Jobs) Init() error {
+	for queue, concurrency := range s.config.Queues {
+		for i := 0; i < concurrency; i++ {
+			s.wg.Add(1)
+
+			go s.work(queue)
+		}
+	}
+
+	s.wg.Add(1)
+
+	go s.runScheduler()
+
+	return nil
+}
+
+// Close stops fetching new jobs and waits for running jobs to finish.
+// Calling Close more than once has no effect.
+func (s *Jobs) Close() error {
+	s.stopOnce.Do(func() {
+		close(s.stop)
+	})
+
+	s.wg.Wait()
+
+	return nil
+}
+
+// Enqueue adds a job of jobType with the JSON-encoded payload and returns
+// its id. opts may be nil. Jobs can only be added to configured queues as no
+// worker would process them otherwise.
+func (s *Jobs) Enqueue(ctx context.Context, jobType string, payload any, opts *JobOptions) (string, error) {
+	if opts == nil {
+		opts = &JobOptions{}
+	}
+
+	queue := opts.Queue
+	if queue == "" {
+		queue = DefaultQueue
+	}
+
+	if _, ok := s.config.Queues[queue]; !ok {
+		return "", fmt.Errorf("enqueueing job %q: %w: %q", jobType, errUnknownQueue, queue)
+	}
+
+	b, err := json.Marshal(payload)
+	if err != nil {
+		return "", fmt.Errorf("encoding payload of job %q: %w", jobType, err)
+	}
+
+	id, err := randomID()
+	if err != nil {
+		return "", err
+	}
+
+	job := &Job{
+		ID:         id,
+		Type:       jobType,
+		Queue:      queue,
+		Payload:    b,
+		MaxRetries: opts.MaxRetries,
+		EnqueuedAt: time.Now().UTC(),
+	}
+
+	if job.MaxRetries == 0 {
+		job.MaxRetries = s.config.MaxRetries
+	}
+
+	runAt := opts.RunAt
+	if opts.Delay > 0 {
+		runAt = time.Now().Add(opts.Delay)
+	}
+
+	if runAt.After(time.Now()) {
+		err = s.scheduleJob(ctx, job, runAt)
+	} else {
+		err = s.push(ctx, job)
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	return job.ID, nil
+}
+
+// DeadJobs returns at most limit jobs that failed after all retries, most
+// recent first, skipping the first offset jobs.
+func (s *Jobs) DeadJobs(ctx context.Context, offset, limit int) ([]*Job, error) {
+	if limit <= 0 {
+		return []*Job{}, nil
+	}
+
+	ids, err := s.redis.Client.ZRevRange(ctx, s.deadKey(), int64(offset), int64(offset+limit-1)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("reading dead jobs: %w", err)
+	}
+
+	if len(ids) == 0 {
+		return []*Job{}, nil
+	}
+
+	values, err := s.redis.Client.HMGet(ctx, s.deadJobsKey(), ids...).Result()
+	if err != nil {
+		return nil, fmt.Errorf("reading dead jobs: %w", err)
+	}
+
+	jobs := make([]*Job, 0, len(values))
+
+	for _, v := range values {
+		// removed since reading the dead set
+		str, ok := v.(string)
+		if !ok {
+			continue
+		}
+
+		job := &Job{}
+		if err := json.Unmarshal([]byte(str), job); err != nil {
+			return nil, fmt.Errorf("decoding dead job: %w", err)
+		}
+
+		jobs = append(jobs, job)
+	}
+
+	return jobs, nil
+}
+
+// RetryDead removes the job with id from the dead set and enqueues it again,
+// resetting its attempts. Returns ErrDeadJobNotFound when it isn't dead.
+func (s *Jobs) RetryDead(ctx context.Context, id string) error {
+	job, err := s.removeDead(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	retry := *job
+	retry.Attempt = 0
+	retry.Error = ""
+
+	if err := s.push(ctx, &retry); err != nil {
+		// put it back so the job isn't lost
+		if err := s.addDead(ctx, job); err != nil {
+			s.log.Error().Err(err).Str("id", job.ID).Msgf("failed to restore dead job %q", job.Type)
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+// DeleteDead removes the job with id from the dead set. Returns
+// ErrDeadJobNotFound when it isn't dead.
+func (s *Jobs) DeleteDead(ctx context.Context, id string) error {
+	_, err := s.removeDead(ctx, id)
+
+	return err
+}
+
+func (s *Jobs) removeDead(ctx context.Context, id string) (*Job, error) {
+	str, err := removeDeadScript.Run(ctx, s.redis.Client, []string{s.deadKey(), s.deadJobsKey()}, id).Text()
+	if errors.Is(err, redis.Nil) {
+		return nil, fmt.Errorf("%w: %q", ErrDeadJobNotFound, id)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("removing dead job %q: %w", id, err)
+	}
+
+	job := &Job{}
+	if err := json.Unmarshal([]byte(str), job); err != nil {
+		return nil, fmt.Errorf("decoding dead job %q: %w", id, err)
+	}
+
+	return job, nil
+}
+
+// addDead adds job to the dead set and removes the jobs exceeding
+// "jobs.deadMaxSize" or "jobs.deadTTL".
+func (s *Jobs) addDead(ctx context.Context, job *Job) error {
+	b, _ := json.Marshal(job)
+	now := time.Now()
+
+	var minScore int64
+	if s.config.DeadTTL > 0 {
+		minScore = now.Add(-s.config.DeadTTL).UnixMilli()
+	}
+
+	err := addDeadScript.Run(ctx, s.redis.Client, []string{s.deadKey(), s.deadJobsKey()},
+		job.ID, now.UnixMilli(), b, s.config.DeadMaxSize, minScore).Err()
+	if err != nil {
+		return fmt.Errorf("storing dead job %q: %w", job.Type, err)
+	}
+
+	return nil
+}
+
+func (s *Jobs) push(ctx context.Context, job *Job) error {
+	b, _ := json.Marshal(job)
+
+	if err := s.redis.Client.LPush(ctx, s.queueKey(job.Queue), b).Err(); err != nil {
+		return fmt.Errorf("enqueueing job %q: %w", job.Type, err)
+	}
+
+	return nil
+}
+
+func (s *Jobs) scheduleJob(ctx context.Context, job *Job, runAt time.Time) error {
+	b, _ := json.Marshal(job)
+
+	err := s.redis.Client.ZAdd(ctx, s.scheduledKey(job.Queue), redis.Z{Score: float64(runAt.UnixMilli()), Member: b}).Err()
+	if err != nil {
+		return fmt.Errorf("scheduling job %q: %w", job.Type, err)
+	}
+
+	return nil
+}
+
+func (s *Jobs) work(queue string) {
+	defer s.wg.Done()
+
+	for {
+		select {
+		case <-s.stop:
+			return
+		default:
+		}
+
+		res, err := s.redis.Client.BRPop(context.Background(), jobsPopTimeout, s.queueKey(queue)).Result()
+		if errors.Is(err, redis.Nil) {
+			continue
+		}
+
+		if err != nil {
+			s.log.Error().Err(err).Msgf("failed to fetch job from queue %q", queue)
+
+			select {
+			case <-s.stop:
+				return
+			case <-time.After(s.config.PollInterval):
+			}
+
+			continue
+		}
+
+		job := &Job{}
+		if err := json.Unmarshal([]byte(res[1]), job); err != nil {
+			s.log.Error().Err(err).Msgf("discarding invalid job in queue %q", queue)
+
+			continue
+		}
+
+		s.process(job)
+	}
+}
+
+func (s *Jobs) process(job *Job) {
+	ctx := context.Background()
+	start := time.Now()
+
+	err := s.run(ctx, job)
+
+	s.metrics.observeDuration(job, time.Since(start))
+
+	if err == nil {
+		s.metrics.observeResult(job, "succeeded")
+
+		return
+	}
+
+	job.Error = err.Error()
+
+	if job.Attempt < job.MaxRetries && !errors.Is(err, errNoJobHandler) {
+		backoff := s.config.RetryBackoff << job.Attempt
+		if backoff > maxJobsRetryBackoff || backoff <= 0 {
+			backoff = maxJobsRetryBackoff
+		}
+
+		job.Attempt++
+
+		s.log.Warn().Err(err).Str("id", job.ID).Msgf("job %q failed, retrying in %s", job.Type, backoff)
+		s.metrics.observeResult(job, "retried")
+
+		if err := s.scheduleJob(ctx, job, time.Now().Add(backoff)); err != nil {
+			s.log.Error().Err(err).Str("id", job.ID).Msgf("failed to retry job %q", job.Type)
+		}
+
+		return
+	}
+
+	s.log.Error().Err(err).Str("id", job.ID).Msgf("job %q failed after %d attempts", job.Type, job.Attempt+1)
+	s.metrics.observeResult(job, "dead")
+
+	if err := s.addDead(ctx, job); err != nil {
+		s.log.Error().Err(err).Str("id", job.ID).Msgf("failed to store dead job %q", job.Type)
+	}
+}
+
+func (s *Jobs) run(ctx context.Context, job *Job) (err error) {
+	handler, ok := s.handler(job.Type)
+	if !ok {
+		return fmt.Errorf("%w %q", errNoJobHandler, job.Type)
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job panicked: %v", r) //nolint:goerr113
+		}
+	}()
+
+	return handler(ctx, job)
+}
+
+// runScheduler runs the scheduler until Close, on the leader only when
+// Elector is set.
+func (s *Jobs) runScheduler() {
+	defer s.wg.Done()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go func() {
+		<-s.stop
+		cancel()
+	}()
+
+	if s.Elector == nil {
+		s.scheduleAll(ctx)
+
+		return
+	}
+
+	err := s.Elector.RunWhenLeader(ctx, func(ctx context.Context) error {
+		s.scheduleAll(ctx)
+
+		return nil
+	})
+	if err != nil {
+		s.log.Error().Err(err).Msg("failed to elect jobs scheduler leader")
+	}
+}
+
+// scheduleAll runs the scheduler of every queue until ctx is done.
+func (s *Jobs) scheduleAll(ctx context.Context) {
+	var wg sync.WaitGroup
+
+	for queue := range s.config.Queues {
+		wg.Add(1)
+
+		go func(queue string) {
+			defer wg.Done()
+
+			s.schedule(ctx, queue)
+		}(queue)
+	}
+
+	wg.Wait()
+}
+
+// schedule periodically moves scheduled and retried jobs of queue that are
+// due to the queue until ctx is done.
+func (s *Jobs) schedule(ctx context.Context, queue string) {
+	for {
+		n, err := enqueueDueScript.Run(ctx, s.redis.Client,
+			[]string{s.scheduledKey(queue), s.queueKey(queue)},
+			strconv.FormatInt(time.Now().UnixMilli(), 10), jobsScheduleBatchSize).Int()
+		if err != nil && ctx.Err() == nil {
+			s.log.Error().Err(err).Msgf("failed to enqueue scheduled jobs of queue %q", queue)
+		}
+
+		// continue immediately when there may be more jobs due
+		if err == nil && n == jobsScheduleBatchSize {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(s.config.PollInterval):
+		}
+	}
+}
+
+// queueKey and scheduledKey share the {queue} hash tag, so enqueueDueScript
+// can use both in a cluster.
+func (s *Jobs) queueKey(queue string) string {
+	return s.redis.Key("jobs:{" + queue + "}:pending")
+}
+
+func (s *Jobs) scheduledKey(queue string) string {
+	return s.redis.Key("jobs:{" + queue + "}:scheduled")
+}
+
+// deadKey holds the ids of dead jobs by the time they died, deadJobsKey the
+// jobs by id. They share the {dead} hash tag for the scripts.
+func (s *Jobs) deadKey() string {
+	return s.redis.Key("jobs:{dead}")
+}
+
+func (s *Jobs) deadJobsKey() string {
+	return s.redis.Key("jobs:{dead}:jobs")
+}
+
+type jobsMetrics struct {
+	duration  *prometheus.HistogramVec
+	processed *prometheus.CounterVec
+}
+
+func (s *Jobs) registerMetrics(reg *prometheus.Registry) error {
+	if reg == nil {
+		return nil
+	}
+
+	m := &jobsMetrics{
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "jobs_duration_seconds",
+			Help:    "Duration of job attempts by queue and job type.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"queue", "type"}),
+		processed: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "jobs_processed_total",
+			Help: "Number of job attempts by queue, job type and result: succeeded, retried or dead.",
+		}, []string{"queue", "type", "result"}),
+	}
+
+	if err := goboot.RegisterCollector(reg, &m.duration); err != nil {
+		return fmt.Errorf("registering jobs metrics: %w", err)
+	}
+
+	if err := goboot.RegisterCollector(reg, &m.processed); err != nil {
+		return fmt.Errorf("registering jobs metrics: %w", err)
+	}
+
+	s.metrics = m
+
+	return nil
+}
+
+func (m *jobsMetrics) observeDuration(job *Job, duration time.Duration) {
+	if m != nil {
+		m.duration.WithLabelValues(job.Queue, job.Type).Observe(duration.Seconds())
+	}
+}
+
+func (m *jobsMetrics) observeResult(job *Job, result string) {
+	if m != nil {
+		m.processed.WithLabelValues(job.Queue, job.Type, result).Inc()
+	}
+}
@@ -0,0 +1,255 @@
+package redisboot_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/nielskrijger/goboot"
+	"github.com/nielskrijger/goboot/redisboot"
+	"github.com/stretchr/testify/assert"
+)
+
+type welcomeEmail struct {
+	To string `json:"to"`
+}
+
+func setupJobs(t *testing.T, settings ...any) (*redisboot.Redis, *redisboot.Jobs) {
+	t.Helper()
+
+	env := goboot.NewAppEnv("./testdata", "jobs")
+
+	for i := 0; i+1 < len(settings); i += 2 {
+		env.Config.Set(settings[i].(string), settings[i+1])
+	}
+
+	r := &redisboot.Redis{}
+	assert.Nil(t, r.Configure(env))
+	assert.Nil(t, r.Client.FlushDB(context.Background()).Err())
+
+	jobs := redisboot.NewJobs(r)
+	assert.Nil(t, jobs.Configure(env))
+
+	return r, jobs
+}
+
+func TestJobs_Process(t *testing.T) {
+	r, jobs := setupJobs(t)
+
+	received := make(chan string, 2)
+	jobs.Handle("welcome", func(ctx context.Context, job *redisboot.Job) error {
+		var email welcomeEmail
+		if err := job.Decode(&email); err != nil {
+			return err
+		}
+
+		received <- email.To
+
+		return nil
+	})
+	assert.Nil(t, jobs.Init())
+
+	ctx := context.Background()
+	_, err := jobs.Enqueue(ctx, "welcome", welcomeEmail{To: "john@example.com"}, nil)
+	assert.Nil(t, err)
+	_, err = jobs.Enqueue(ctx, "welcome", welcomeEmail{To: "jane@example.com"}, &redisboot.JobOptions{
+		Delay: 50 * time.Millisecond,
+	})
+	assert.Nil(t, err)
+
+	for _, expected := range []string{"john@example.com", "jane@example.com"} {
+		select {
+		case to := <-received:
+			assert.Equal(t, expected, to)
+		case <-time.After(5 * time.Second):
+			t.Fatal("job not processed")
+		}
+	}
+
+	assert.Nil(t, jobs.Close())
+	assert.Nil(t, r.Close())
+}
+
+func TestJobs_RetryAndDead(t *testing.T) {
+	r, jobs := setupJobs(t)
+
+	attempts := make(chan int, 2)
+	jobs.Handle("fail", func(ctx context.Context, job *redisboot.Job) error {
+		attempts <- job.Attempt
+
+		return errors.New("boom")
+	})
+	assert.Nil(t, jobs.Init())
+
+	ctx := context.Background()
+	id, err := jobs.Enqueue(ctx, "fail", nil, nil)
+	assert.Nil(t, err)
+
+	for _, expected := range []int{0, 1} {
+		select {
+		case attempt := <-attempts:
+			assert.Equal(t, expected, attempt)
+		case <-time.After(5 * time.Second):
+			t.Fatal("job not processed")
+		}
+	}
+
+	assert.Nil(t, jobs.Close())
+
+	dead, err := jobs.DeadJobs(ctx, 0, 10)
+	assert.Nil(t, err)
+
+	if assert.Len(t, dead, 1) {
+		assert.Equal(t, id, dead[0].ID)
+		assert.Equal(t, "boom", dead[0].Error)
+	}
+
+	assert.Nil(t, r.Close())
+}
+
+func TestJobs_NoRetries(t *testing.T) {
+	r, jobs := setupJobs(t, "jobs.maxRetries", 0)
+
+	attempts := make(chan int, 2)
+	jobs.Handle("fail", func(ctx context.Context, job *redisboot.Job) error {
+		attempts <- job.Attempt
+
+		return errors.New("boom")
+	})
+	assert.Nil(t, jobs.Init())
+
+	ctx := context.Background()
+	_, err := jobs.Enqueue(ctx, "fail", nil, nil)
+	assert.Nil(t, err)
+
+	assert.Eventually(t, func() bool {
+		dead, err := jobs.DeadJobs(ctx, 0, 10)
+
+		return err == nil && len(dead) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+
+	assert.Nil(t, jobs.Close())
+	assert.Nil(t, jobs.Close())
+	assert.Len(t, attempts, 1)
+
+	assert.Nil(t, r.Close())
+}
+
+func TestJobs_RetryAndDeleteDead(t *testing.T) {
+	r, jobs := setupJobs(t, "jobs.maxRetries", 0, "jobs.deadMaxSize", 2)
+
+	var fail atomic.Bool
+
+	fail.Store(true)
+
+	succeeded := make(chan string, 1)
+	jobs.Handle("fail", func(ctx context.Context, job *redisboot.Job) error {
+		if fail.Load() {
+			return errors.New("boom")
+		}
+
+		succeeded <- job.ID
+
+		return nil
+	})
+	assert.Nil(t, jobs.Init())
+
+	ctx := context.Background()
+	ids := make([]string, 3)
+
+	for i := range ids {
+		id, err := jobs.Enqueue(ctx, "fail", nil, nil)
+		assert.Nil(t, err)
+
+		ids[i] = id
+
+		// wait for each job to die so their order in the dead set is known
+		assert.Eventually(t, func() bool {
+			dead, err := jobs.DeadJobs(ctx, 0, 1)
+
+			return err == nil && len(dead) == 1 && dead[0].ID == id
+		}, 5*time.Second, 10*time.Millisecond)
+	}
+
+	// the oldest job is removed as deadMaxSize is 2
+	dead, err := jobs.DeadJobs(ctx, 0, 10)
+	assert.Nil(t, err)
+
+	if assert.Len(t, dead, 2) {
+		assert.Equal(t, ids[2], dead[0].ID)
+		assert.Equal(t, ids[1], dead[1].ID)
+	}
+
+	dead, err = jobs.DeadJobs(ctx, 1, 10)
+	assert.Nil(t, err)
+
+	if assert.Len(t, dead, 1) {
+		assert.Equal(t, ids[1], dead[0].ID)
+	}
+
+	assert.Nil(t, jobs.DeleteDead(ctx, ids[1]))
+	assert.ErrorIs(t, jobs.DeleteDead(ctx, ids[1]), redisboot.ErrDeadJobNotFound)
+
+	fail.Store(false)
+
+	assert.Nil(t, jobs.RetryDead(ctx, ids[2]))
+	assert.ErrorIs(t, jobs.RetryDead(ctx, ids[2]), redisboot.ErrDeadJobNotFound)
+
+	select {
+	case id := <-succeeded:
+		assert.Equal(t, ids[2], id)
+	case <-time.After(5 * time.Second):
+		t.Fatal("job not retried")
+	}
+
+	dead, err = jobs.DeadJobs(ctx, 0, 10)
+	assert.Nil(t, err)
+	assert.Empty(t, dead)
+
+	assert.Nil(t, jobs.Close())
+	assert.Nil(t, r.Close())
+}
+
+func TestJobs_ScheduleWithElector(t *testing.T) {
+	r, jobs := setupJobs(t)
+	jobs.Elector = r.NewElector("test-jobs-scheduler", 100*time.Millisecond)
+
+	received := make(chan struct{}, 1)
+	jobs.Handle("welcome", func(ctx context.Context, job *redisboot.Job) error {
+		received <- struct{}{}
+
+		return nil
+	})
+	assert.Nil(t, jobs.Init())
+
+	_, err := jobs.Enqueue(context.Background(), "welcome", welcomeEmail{To: "jane@example.com"}, &redisboot.JobOptions{
+		Delay: 50 * time.Millisecond,
+	})
+	assert.Nil(t, err)
+
+	select {
+	case <-received:
+	case <-time.After(5 * time.Second):
+		t.Fatal("job not processed")
+	}
+
+	assert.Nil(t, jobs.Close())
+	assert.Nil(t, r.Close())
+}
+
+func TestJobs_ErrorUnknownQueue(t *testing.T) {
+	env := goboot.NewAppEnv("./testdata", "jobs")
+	env.Config.Set("jobs.queues", map[string]int{"emails": 1})
+
+	// the queue is validated before connecting to Redis
+	jobs := redisboot.NewJobs(&redisboot.Redis{})
+	assert.Nil(t, jobs.Configure(env))
+
+	_, err := jobs.Enqueue(context.Background(), "welcome", welcomeEmail{}, &redisboot.JobOptions{Queue: "unknown"})
+	assert.EqualError(t, err, `enqueueing job "welcome": queue not configured in "jobs.queues": "unknown"`)
+
+	_, err = jobs.Enqueue(context.Background(), "welcome", welcomeEmail{}, nil)
+	assert.EqualError(t, err, `enqueueing job "welcome": queue not configured in "jobs.queues": "default"`)
+}
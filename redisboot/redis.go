@@ -1,13 +1,17 @@
 package redisboot
 
 import (
+	"context"
+	"crypto/tls"
 	"errors"
 	"fmt"
+	"sync"
 	"time"
 
-	"github.com/go-redis/redis"
 	"github.com/nielskrijger/goboot"
+	"github.com/redis/go-redis/v9"
 	"github.com/rs/zerolog"
+	"golang.org/x/sync/singleflight"
 )
 
 const (
@@ -17,14 +21,22 @@ const (
 
 var (
 	errMissingConfig = errors.New("missing Redis configuration")
-	errMissingURL    = errors.New("config \"redis.url\" is required")
+	errClosed        = errors.New("redis service is closed")
 )
 
 type RedisConfig struct {
 	// Url contains hostname:port, e.g. localhost:6379
 	URL string `yaml:"url"`
 
-	// Password if left empty uses no empty
+	// Addrs lists the seed addresses of a cluster or the sentinel addresses
+	// when MasterName is set, used instead of URL.
+	Addrs []string `yaml:"addrs"`
+
+	// MasterName is the name of the master when using Redis Sentinel.
+	MasterName string `yaml:"masterName"`
+
+	// Password if left empty uses no password. Read from a file, environment
+	// variable or secret manager if prefixed, see goboot.ResolveSecret.
 	Password string `yaml:"password"`
 
 	// DB defaults to db 0
@@ -42,41 +54,133 @@ type RedisConfig struct {
 
 	// Time between retries for initial connect attempts. Default is 5 seconds.
 	ConnectRetryDuration time.Duration `yaml:"connectRetryDuration"`
+
+	// TLS enables TLS using the system root CAs, implied by the TLS file settings.
+	TLS bool `yaml:"tls"`
+
+	// Path to the CA file used to verify the server certificate.
+	TLSCAFile string `yaml:"tlsCAFile"`
+
+	// Paths to the client certificate and key for mutual TLS.
+	TLSCert string `yaml:"tlsCert"`
+	TLSKey  string `yaml:"tlsKey"`
+
+	// Server name to verify the certificate against when connecting by IP
+	// address, e.g. Memorystore.
+	TLSServerName string `yaml:"tlsServerName"`
+
+	// Skips certificate verification, only for local development.
+	TLSInsecureSkipVerify bool `yaml:"tlsInsecureSkipVerify"`
+
+	// KeyPrefix is prepended to the keys and channels used by the helpers,
+	// see Redis.KeyPrefix.
+	KeyPrefix string `yaml:"keyPrefix"`
+
+	// Time GetOrSet caches ErrNotFound results. Default is 1 minute.
+	NegativeCacheTTL time.Duration `yaml:"negativeCacheTTL"`
 }
 
 // Redis implements the AppService interface.
+//
+// Client is a single node, cluster or sentinel client depending on the
+// config, all commands take a context so calls respect request deadlines.
+//
+// Register multiple Redis services with a different Namespace to use
+// independent databases or clusters, e.g. for a cache with an LRU eviction
+// policy and for sessions that must never be evicted:
+//
+//	env.AddService(&redisboot.Redis{Namespace: "cache"})
+//	env.AddService(&redisboot.Redis{Namespace: "sessions"})
 type Redis struct {
-	Client *redis.Client
-
-	log zerolog.Logger
+	Client redis.UniversalClient
+
+	// Namespace reads the config from "redis.{Namespace}" instead of "redis",
+	// e.g. "redis.cache.url".
+	Namespace string
+
+	// KeyPrefix is prepended by Key to the keys and channels used by the
+	// helpers such as GetOrSet, Lock and Publish, so multiple applications and
+	// environments can share a Redis instance. Commands sent using Client
+	// directly should use Key as well. Defaults to config "redis.keyPrefix",
+	// or "{app.name}:{env}:" when config "app.name" is set.
+	KeyPrefix string
+
+	// TLSConfig enables TLS instead of the tls* settings, e.g.
+	// tlsboot.TLS.ClientConfig to reload rotated client certificates.
+	TLSConfig *tls.Config
+
+	log              zerolog.Logger
+	closing          chan struct{}
+	closeOnce        sync.Once
+	closeMu          sync.Mutex // guards adding subscribers while closing
+	subscribers      sync.WaitGroup
+	group            singleflight.Group
+	negativeCacheTTL time.Duration
 }
 
 func (s *Redis) Name() string {
+	if s.Namespace != "" {
+		return "Redis " + s.Namespace
+	}
+
 	return "Redis"
 }
 
+// configKey returns the config key of the service.
+func (s *Redis) configKey() string {
+	if s.Namespace != "" {
+		return "redis." + s.Namespace
+	}
+
+	return "redis"
+}
+
 func (s *Redis) Configure(env *goboot.AppEnv) error {
 	s.log = env.Log
+	s.closing = make(chan struct{})
 	redisCfg := &RedisConfig{}
 
-	if !env.Config.InConfig("redis") {
+	key := s.configKey()
+
+	if !env.Config.IsSet(key) {
+		if s.Namespace != "" {
+			return fmt.Errorf("%w %q", errMissingConfig, key)
+		}
+
 		return errMissingConfig
 	}
 
-	if !env.Config.IsSet("redis.url") {
-		return errMissingURL
+	if !env.Config.IsSet(key+".url") && !env.Config.IsSet(key+".addrs") {
+		return fmt.Errorf("config %q or %q is required", key+".url", key+".addrs") //nolint:goerr113
 	}
 
-	if err := env.Config.Sub("redis").Unmarshal(redisCfg); err != nil {
+	if err := env.Config.Sub(key).Unmarshal(redisCfg); err != nil {
 		return fmt.Errorf("parsing redis configuration: %w", err)
 	}
 
-	s.log.Info().Msgf("connecting to redis %q, db %d", redisCfg.URL, redisCfg.DB)
+	tlsConfig, err := s.tlsConfig(redisCfg)
+	if err != nil {
+		return err
+	}
+
+	password, err := goboot.ResolveSecret(redisCfg.Password)
+	if err != nil {
+		return fmt.Errorf("reading redis password: %w", err)
+	}
+
+	addrs := redisCfg.Addrs
+	if len(addrs) == 0 {
+		addrs = []string{redisCfg.URL}
+	}
+
+	s.log.Info().Msgf("connecting to %s %q, db %d", s.Name(), addrs, redisCfg.DB)
 
-	opts := &redis.Options{
-		Addr:     redisCfg.URL,
-		Password: redisCfg.Password,
-		DB:       redisCfg.DB,
+	opts := &redis.UniversalOptions{
+		Addrs:      addrs,
+		MasterName: redisCfg.MasterName,
+		Password:   password,
+		DB:         redisCfg.DB,
+		TLSConfig:  tlsConfig,
 	}
 	if redisCfg.DialTimeout != 0 {
 		opts.DialTimeout = redisCfg.DialTimeout
@@ -86,7 +190,30 @@ func (s *Redis) Configure(env *goboot.AppEnv) error {
 		opts.PoolSize = redisCfg.PoolSize
 	}
 
-	s.Client = redis.NewClient(opts)
+	s.Client = redis.NewUniversalClient(opts)
+
+	if s.KeyPrefix == "" {
+		s.KeyPrefix = redisCfg.KeyPrefix
+	}
+
+	if s.KeyPrefix == "" && env.Config.IsSet("app.name") {
+		s.KeyPrefix = fmt.Sprintf("%s:%s:", env.Config.GetString("app.name"), env.Env)
+	}
+
+	s.negativeCacheTTL = redisCfg.NegativeCacheTTL
+	if s.negativeCacheTTL == 0 {
+		s.negativeCacheTTL = defaultNegativeCacheTTL
+	}
+
+	if err := s.registerMetrics(env.Metrics); err != nil {
+		_ = s.Client.Close()
+
+		return err
+	}
+
+	if env.TracerProvider != nil {
+		s.Client.AddHook(&tracingHook{tracer: env.TracerProvider.Tracer(tracerName)})
+	}
 
 	if redisCfg.ConnectMaxRetries == 0 {
 		redisCfg.ConnectMaxRetries = defaultRedisConnectMaxRetries
@@ -96,16 +223,28 @@ func (s *Redis) Configure(env *goboot.AppEnv) error {
 		redisCfg.ConnectRetryDuration = defaultRedisConnectRetryDuration
 	}
 
-	return s.testConnectivity(redisCfg)
+	if err := s.testConnectivity(redisCfg); err != nil {
+		_ = s.Client.Close()
+
+		return err
+	}
+
+	return nil
+}
+
+// Key returns the key including KeyPrefix.
+func (s *Redis) Key(key string) string {
+	return s.KeyPrefix + key
 }
 
 func (s *Redis) testConnectivity(cfg *RedisConfig) error {
 	for retries := 1; ; retries++ {
-		if err := s.Client.Ping().Err(); err != nil {
+		if err := s.Client.Ping(context.Background()).Err(); err != nil {
 			if retries < cfg.ConnectMaxRetries {
 				s.log.Warn().
 					Err(err).
 					Str("url", cfg.URL).
+					Strs("addrs", cfg.Addrs).
 					Int("db", cfg.DB).
 					Msgf("failed to connect to redis, retrying in %s", cfg.ConnectRetryDuration)
 			} else {
@@ -132,11 +271,22 @@ func (s *Redis) Init() error {
 	return nil
 }
 
-// Close is run right before shutdown. The app waits until close resolves.
+// Close is run right before shutdown. The app waits until close resolves,
+// stopping the subscriptions. Calling Close more than once has no effect.
 func (s *Redis) Close() error {
-	if err := s.Client.Close(); err != nil {
-		return fmt.Errorf("closing %s service: %w", s.Name(), err)
-	}
+	var err error
 
-	return nil
+	s.closeOnce.Do(func() {
+		s.closeMu.Lock()
+		close(s.closing)
+		s.closeMu.Unlock()
+
+		s.subscribers.Wait()
+
+		if closeErr := s.Client.Close(); closeErr != nil {
+			err = fmt.Errorf("closing %s service: %w", s.Name(), closeErr)
+		}
+	})
+
+	return err
 }
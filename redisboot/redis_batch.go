@@ -0,0 +1,143 @@
+package redisboot
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// MGetJSON returns the JSON-decoded values of keys including KeyPrefix in a
+// single round trip, missing keys are omitted from the result. Works with
+// cluster clients as well, unlike MGET.
+func MGetJSON[T any](ctx context.Context, s *Redis, keys ...string) (map[string]T, error) {
+	b := s.Batch()
+
+	results := make([]*Result[T], len(keys))
+	for i, key := range keys {
+		results[i] = BatchGet[T](b, key)
+	}
+
+	if err := b.Exec(ctx); err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]T, len(keys))
+
+	for i, r := range results {
+		v, err := r.Value()
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		values[keys[i]] = v
+	}
+
+	return values, nil
+}
+
+// MSetJSON stores the JSON-encoded values by key including KeyPrefix in a
+// single round trip, a ttl of 0 never expires.
+func MSetJSON[T any](ctx context.Context, s *Redis, values map[string]T, ttl time.Duration) error {
+	b := s.Batch()
+
+	for key, v := range values {
+		if err := b.Set(key, v, ttl); err != nil {
+			return err
+		}
+	}
+
+	return b.Exec(ctx)
+}
+
+// Batch queues commands and sends them in a single round trip by Exec.
+// Results of BatchGet are available after Exec.
+type Batch struct {
+	redis *Redis
+	pipe  redis.Pipeliner
+}
+
+// Batch starts a new batch.
+func (s *Redis) Batch() *Batch {
+	return &Batch{redis: s, pipe: s.Client.Pipeline()}
+}
+
+// Result is the typed result of a batched command.
+type Result[T any] struct {
+	key string
+	cmd *redis.StringCmd
+}
+
+// BatchGet queues a GET of key including KeyPrefix, decoding the value as
+// JSON into T.
+func BatchGet[T any](b *Batch, key string) *Result[T] {
+	return &Result[T]{key: key, cmd: b.pipe.Get(context.Background(), b.redis.Key(key))}
+}
+
+// Value returns the decoded value after Exec, or ErrNotFound if the key
+// doesn't exist.
+func (r *Result[T]) Value() (T, error) {
+	var value T
+
+	bytes, err := r.cmd.Bytes()
+	if errors.Is(err, redis.Nil) || (err == nil && string(bytes) == negativeCacheValue) {
+		return value, ErrNotFound
+	}
+
+	if err != nil {
+		return value, fmt.Errorf("reading Redis key %q: %w", r.key, err)
+	}
+
+	if err := json.Unmarshal(bytes, &value); err != nil {
+		return value, fmt.Errorf("decoding Redis key %q: %w", r.key, err)
+	}
+
+	return value, nil
+}
+
+// Set queues a SET of key including KeyPrefix to the JSON-encoded value, a
+// ttl of 0 never expires.
+func (b *Batch) Set(key string, value any, ttl time.Duration) error {
+	bytes, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("encoding Redis key %q: %w", key, err)
+	}
+
+	b.pipe.Set(context.Background(), b.redis.Key(key), bytes, ttl)
+
+	return nil
+}
+
+// Delete queues a DEL of key including KeyPrefix.
+func (b *Batch) Delete(key string) {
+	b.pipe.Del(context.Background(), b.redis.Key(key))
+}
+
+// Exec sends the queued commands and returns the first error of a command,
+// missing keys are not an error.
+func (b *Batch) Exec(ctx context.Context) error {
+	cmds, err := b.pipe.Exec(ctx)
+	if err == nil {
+		return nil
+	}
+
+	// Exec returns the first failed command, which may be a missing key
+	for _, cmd := range cmds {
+		if err := cmd.Err(); err != nil && !errors.Is(err, redis.Nil) {
+			return fmt.Errorf("executing Redis batch: %w", err)
+		}
+	}
+
+	if !errors.Is(err, redis.Nil) {
+		return fmt.Errorf("executing Redis batch: %w", err)
+	}
+
+	return nil
+}
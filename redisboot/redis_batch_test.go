@@ -0,0 +1,57 @@
+package redisboot_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nielskrijger/goboot"
+	"github.com/nielskrijger/goboot/redisboot"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMGetJSON(t *testing.T) {
+	s := &redisboot.Redis{}
+	assert.Nil(t, s.Configure(goboot.NewAppEnv("./testdata", "valid")))
+
+	ctx := context.Background()
+	assert.Nil(t, redisboot.MSetJSON(ctx, s, map[string]cachedUser{
+		"user:1": {Name: "John"},
+		"user:2": {Name: "Jane"},
+	}, time.Minute))
+
+	users, err := redisboot.MGetJSON[cachedUser](ctx, s, "user:1", "user:2", "user:missing")
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]cachedUser{"user:1": {Name: "John"}, "user:2": {Name: "Jane"}}, users)
+}
+
+func TestBatch(t *testing.T) {
+	s := &redisboot.Redis{}
+	assert.Nil(t, s.Configure(goboot.NewAppEnv("./testdata", "valid")))
+
+	ctx := context.Background()
+	b := s.Batch()
+	assert.Nil(t, b.Set("batch:1", cachedUser{Name: "John"}, time.Minute))
+	b.Delete("batch:2")
+	assert.Nil(t, b.Exec(ctx))
+
+	b = s.Batch()
+	user := redisboot.BatchGet[cachedUser](b, "batch:1")
+	missing := redisboot.BatchGet[cachedUser](b, "batch:2")
+	assert.Nil(t, b.Exec(ctx))
+
+	v, err := user.Value()
+	assert.Nil(t, err)
+	assert.Equal(t, "John", v.Name)
+
+	_, err = missing.Value()
+	assert.ErrorIs(t, err, redisboot.ErrNotFound)
+
+	// a missing key doesn't hide the error of a later command
+	assert.Nil(t, s.Client.LPush(ctx, s.Key("batch:list"), "a").Err())
+
+	b = s.Batch()
+	redisboot.BatchGet[cachedUser](b, "batch:2")
+	redisboot.BatchGet[cachedUser](b, "batch:list")
+	assert.ErrorContains(t, b.Exec(ctx), "WRONGTYPE")
+}
@@ -0,0 +1,140 @@
+package redisboot
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/nielskrijger/goboot/cacheboot"
+	"github.com/redis/go-redis/v9"
+)
+
+const defaultNegativeCacheTTL = time.Minute
+
+// negativeCacheValue marks a cached ErrNotFound result.
+const negativeCacheValue = "\x00notfound"
+
+// ErrNotFound is returned by Get for missing keys, and by a GetOrSet fill
+// function to cache the absence of a value, see GetOrSet.
+var ErrNotFound = cacheboot.ErrNotFound
+
+var errUnexpectedType = errors.New("unexpected type of shared fill result")
+
+var _ cacheboot.Cache = (*Redis)(nil)
+
+// GetOrSet returns the JSON-encoded value of key including KeyPrefix, or calls
+// fill and caches its result for ttl when the key doesn't exist. Concurrent
+// calls for the same key and type T within this process share a single fill
+// call. The result is cached even when ctx is cancelled while filling.
+//
+// When fill returns an error wrapping ErrNotFound the absence is cached for
+// "redis.negativeCacheTTL" (default 1m) and subsequent calls return ErrNotFound
+// without calling fill. Other errors are not cached.
+//
+// Redis errors are logged and fall back to calling fill so an unavailable
+// cache doesn't fail the request.
+func GetOrSet[T any](ctx context.Context, s *Redis, key string, ttl time.Duration, fill func() (T, error)) (T, error) {
+	var value T
+
+	key = s.Key(key)
+
+	b, err := s.Client.Get(ctx, key).Bytes()
+
+	switch {
+	case err == nil && string(b) == negativeCacheValue:
+		return value, fmt.Errorf("cached %q: %w", key, ErrNotFound)
+	case err == nil:
+		if err := json.Unmarshal(b, &value); err == nil {
+			return value, nil
+		}
+
+		s.log.Warn().Err(err).Msgf("invalid cached value for Redis key %q", key)
+	case !errors.Is(err, redis.Nil):
+		s.log.Warn().Err(err).Msgf("failed to get Redis key %q", key)
+	}
+
+	// the fill is shared with callers that may have a different ctx
+	fillCtx := detachedContext{ctx}
+
+	v, err, _ := s.group.Do(fmt.Sprintf("%s:%s", reflect.TypeOf(&value).Elem(), key), func() (any, error) {
+		value, err := fill()
+		if errors.Is(err, ErrNotFound) {
+			s.setCache(fillCtx, key, negativeCacheValue, s.negativeCacheTTL)
+
+			return value, err
+		}
+
+		if err != nil {
+			return value, err
+		}
+
+		b, err := json.Marshal(value)
+		if err != nil {
+			return value, fmt.Errorf("encoding cached value of %q: %w", key, err)
+		}
+
+		s.setCache(fillCtx, key, b, ttl)
+
+		return value, nil
+	})
+
+	value, ok := v.(T)
+	if !ok && v != nil && err == nil {
+		return value, fmt.Errorf("filling %q: %w", key, errUnexpectedType)
+	}
+
+	return value, err //nolint:wrapcheck
+}
+
+// detachedContext keeps the values of a context without its cancellation.
+type detachedContext struct {
+	parent context.Context //nolint:containedctx
+}
+
+func (detachedContext) Deadline() (time.Time, bool) { return time.Time{}, false }
+func (detachedContext) Done() <-chan struct{}       { return nil }
+func (detachedContext) Err() error                  { return nil }
+func (c detachedContext) Value(key any) any         { return c.parent.Value(key) }
+
+func (s *Redis) setCache(ctx context.Context, key string, value any, ttl time.Duration) {
+	if err := s.Client.Set(ctx, key, value, ttl).Err(); err != nil {
+		s.log.Warn().Err(err).Msgf("failed to cache Redis key %q", key)
+	}
+}
+
+// Get returns the value of key including KeyPrefix, or ErrNotFound.
+// It implements cacheboot.Cache.
+func (s *Redis) Get(ctx context.Context, key string) ([]byte, error) {
+	b, err := s.Client.Get(ctx, s.Key(key)).Bytes()
+	if errors.Is(err, redis.Nil) || (err == nil && string(b) == negativeCacheValue) {
+		return nil, ErrNotFound
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("reading Redis key %q: %w", key, err)
+	}
+
+	return b, nil
+}
+
+// Set stores the value of key including KeyPrefix, a ttl of 0 never expires.
+// It implements cacheboot.Cache.
+func (s *Redis) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	if err := s.Client.Set(ctx, s.Key(key), value, ttl).Err(); err != nil {
+		return fmt.Errorf("writing Redis key %q: %w", key, err)
+	}
+
+	return nil
+}
+
+// Delete removes key including KeyPrefix. It implements cacheboot.Cache.
+func (s *Redis) Delete(ctx context.Context, key string) error {
+	if err := s.Client.Del(ctx, s.Key(key)).Err(); err != nil {
+		return fmt.Errorf("deleting Redis key %q: %w", key, err)
+	}
+
+	return nil
+}
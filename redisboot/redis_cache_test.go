@@ -0,0 +1,191 @@
+package redisboot_test
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/nielskrijger/goboot"
+	"github.com/nielskrijger/goboot/cacheboot"
+	"github.com/nielskrijger/goboot/redisboot"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+type cachedUser struct {
+	Name string `json:"name"`
+}
+
+// getCounter counts the GET commands of key.
+type getCounter struct {
+	key string
+	n   atomic.Int32
+}
+
+func (h *getCounter) DialHook(next redis.DialHook) redis.DialHook {
+	return next
+}
+
+func (h *getCounter) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		err := next(ctx, cmd)
+		if cmd.Name() == "get" && cmd.Args()[1] == h.key {
+			h.n.Add(1)
+		}
+
+		return err
+	}
+}
+
+func (h *getCounter) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return next
+}
+
+func TestGetOrSet(t *testing.T) {
+	s := &redisboot.Redis{}
+	assert.Nil(t, s.Configure(goboot.NewAppEnv("./testdata", "valid")))
+
+	ctx := context.Background()
+	_ = s.Client.Del(ctx, s.Key("test-cache"))
+
+	gets := &getCounter{key: s.Key("test-cache")}
+	s.Client.AddHook(gets)
+
+	var calls atomic.Int32
+
+	fill := func() (*cachedUser, error) {
+		calls.Add(1)
+
+		// all callers missed the cache and wait for this fill
+		for gets.n.Load() < 10 {
+			runtime.Gosched()
+		}
+
+		return &cachedUser{Name: "John"}, nil
+	}
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			user, err := redisboot.GetOrSet(ctx, s, "test-cache", time.Minute, fill)
+			assert.Nil(t, err)
+			assert.Equal(t, "John", user.Name)
+		}()
+	}
+
+	wg.Wait()
+
+	user, err := redisboot.GetOrSet(ctx, s, "test-cache", time.Minute, fill)
+	assert.Nil(t, err)
+	assert.Equal(t, "John", user.Name)
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestGetOrSet_DifferentTypes(t *testing.T) {
+	s := &redisboot.Redis{}
+	assert.Nil(t, s.Configure(goboot.NewAppEnv("./testdata", "valid")))
+
+	ctx := context.Background()
+	_ = s.Client.Del(ctx, s.Key("test-cache-types"))
+
+	started, release, done := make(chan struct{}), make(chan struct{}), make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		user, err := redisboot.GetOrSet(ctx, s, "test-cache-types", time.Minute, func() (*cachedUser, error) {
+			close(started)
+			<-release
+
+			return &cachedUser{Name: "John"}, nil
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, "John", user.Name)
+	}()
+
+	<-started
+
+	// doesn't share the fill of another type
+	name, err := redisboot.GetOrSet(ctx, s, "test-cache-types", time.Minute, func() (string, error) {
+		return "Jane", nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "Jane", name)
+
+	close(release)
+	<-done
+}
+
+func TestGetOrSet_CachesWhenCancelled(t *testing.T) {
+	s := &redisboot.Redis{}
+	assert.Nil(t, s.Configure(goboot.NewAppEnv("./testdata", "valid")))
+
+	_ = s.Client.Del(context.Background(), s.Key("test-cache-cancelled"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	calls := 0
+	fill := func() (*cachedUser, error) {
+		calls++
+
+		return &cachedUser{Name: "John"}, nil
+	}
+
+	user, err := redisboot.GetOrSet(ctx, s, "test-cache-cancelled", time.Minute, fill)
+	assert.Nil(t, err)
+	assert.Equal(t, "John", user.Name)
+
+	user, err = redisboot.GetOrSet(context.Background(), s, "test-cache-cancelled", time.Minute, fill)
+	assert.Nil(t, err)
+	assert.Equal(t, "John", user.Name)
+	assert.Equal(t, 1, calls)
+}
+
+func TestGetOrSet_NegativeCaching(t *testing.T) {
+	s := &redisboot.Redis{}
+	assert.Nil(t, s.Configure(goboot.NewAppEnv("./testdata", "valid")))
+
+	ctx := context.Background()
+	_ = s.Client.Del(ctx, "test-cache-missing")
+
+	calls := 0
+	fill := func() (*cachedUser, error) {
+		calls++
+
+		return nil, fmt.Errorf("user 1: %w", redisboot.ErrNotFound)
+	}
+
+	_, err := redisboot.GetOrSet(ctx, s, "test-cache-missing", time.Minute, fill)
+	assert.ErrorIs(t, err, redisboot.ErrNotFound)
+
+	_, err = redisboot.GetOrSet(ctx, s, "test-cache-missing", time.Minute, fill)
+	assert.ErrorIs(t, err, redisboot.ErrNotFound)
+	assert.Equal(t, 1, calls)
+}
+
+func TestRedis_ImplementsCache(t *testing.T) {
+	s := &redisboot.Redis{}
+	assert.Nil(t, s.Configure(goboot.NewAppEnv("./testdata", "valid")))
+
+	ctx := context.Background()
+	assert.Nil(t, cacheboot.Set(ctx, s, "test-typed", cachedUser{Name: "John"}, time.Minute))
+
+	user, err := cacheboot.Get[cachedUser](ctx, s, "test-typed")
+	assert.Nil(t, err)
+	assert.Equal(t, "John", user.Name)
+
+	assert.Nil(t, s.Delete(ctx, "test-typed"))
+
+	_, err = s.Get(ctx, "test-typed")
+	assert.ErrorIs(t, err, redisboot.ErrNotFound)
+}
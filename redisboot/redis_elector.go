@@ -0,0 +1,135 @@
+package redisboot
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+const defaultLeaseTTL = 15 * time.Second
+
+// Elector implements goboot.Elector electing a single leader among all
+// instances using a Redis lock as lease, e.g. to run the scheduler of Jobs or
+// an outbox relay on one instance only.
+type Elector struct {
+	// OnElected is called when this instance becomes the leader, optional.
+	OnElected func()
+
+	// OnLost is called when this instance loses leadership because the lease
+	// couldn't be renewed, optional.
+	OnLost func()
+
+	redis *Redis
+	key   string
+	ttl   time.Duration
+}
+
+// NewElector creates an elector for key prefixed with KeyPrefix. The lease
+// expires after ttl when the leader stops renewing it, e.g. because it
+// crashed; default is 15 seconds.
+func (s *Redis) NewElector(key string, ttl time.Duration) *Elector {
+	if ttl == 0 {
+		ttl = defaultLeaseTTL
+	}
+
+	return &Elector{redis: s, key: key, ttl: ttl}
+}
+
+// RunWhenLeader waits until this instance becomes the leader and runs fn.
+// Followers try to obtain the lease and the leader renews it every third of
+// the TTL. When the lease is lost the context of fn is cancelled and
+// RunWhenLeader campaigns again after fn returns.
+//
+// Returns the result of fn when it returns while still leader, or nil when
+// ctx is done. The lease is released when returning.
+func (e *Elector) RunWhenLeader(ctx context.Context, fn func(ctx context.Context) error) error {
+	for {
+		lock, err := e.redis.TryLock(ctx, e.key, e.ttl)
+		if err != nil {
+			if !errors.Is(err, ErrNotObtained) {
+				e.redis.log.Warn().Err(err).Msgf("failed to campaign for leadership of %q", e.key)
+			}
+
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-time.After(e.ttl / 3):
+			}
+
+			continue
+		}
+
+		e.redis.log.Info().Msgf("elected leader of %q", e.key)
+
+		if e.OnElected != nil {
+			e.OnElected()
+		}
+
+		lost, err := e.lead(ctx, lock, fn)
+		if !lost {
+			return err
+		}
+
+		if ctx.Err() != nil {
+			return nil
+		}
+	}
+}
+
+// lead runs fn while renewing the lease, returns true if the lease was lost.
+func (e *Elector) lead(ctx context.Context, lock *Lock, fn func(ctx context.Context) error) (bool, error) {
+	leaderCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	lost := make(chan struct{})
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		for {
+			select {
+			case <-leaderCtx.Done():
+				return
+			case <-time.After(e.ttl / 3):
+			}
+
+			if err := lock.Extend(leaderCtx, e.ttl); err != nil {
+				if leaderCtx.Err() != nil {
+					return
+				}
+
+				e.redis.log.Warn().Err(err).Msgf("lost leadership of %q", e.key)
+				close(lost)
+				cancel()
+
+				if e.OnLost != nil {
+					e.OnLost()
+				}
+
+				return
+			}
+		}
+	}()
+
+	err := fn(leaderCtx)
+
+	cancel()
+	<-done
+
+	select {
+	case <-lost:
+		return true, nil
+	default:
+	}
+
+	if unlockErr := lock.Unlock(context.Background()); unlockErr != nil && !errors.Is(unlockErr, ErrNotHeld) {
+		e.redis.log.Warn().Err(unlockErr).Msgf("failed to release leadership of %q", e.key)
+	}
+
+	if ctx.Err() != nil {
+		return true, nil
+	}
+
+	return false, err
+}
@@ -0,0 +1,65 @@
+package redisboot_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/nielskrijger/goboot"
+	"github.com/nielskrijger/goboot/redisboot"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestElector_SingleLeader(t *testing.T) {
+	r := &redisboot.Redis{}
+	assert.Nil(t, r.Configure(goboot.NewAppEnv("./testdata", "valid")))
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	var leaders int32
+
+	run := func(ctx context.Context) error {
+		atomic.AddInt32(&leaders, 1)
+		<-ctx.Done()
+
+		return nil
+	}
+
+	done := make(chan struct{}, 2)
+
+	for i := 0; i < 2; i++ {
+		elector := r.NewElector("test-leader", 300*time.Millisecond)
+
+		go func() {
+			assert.Nil(t, elector.RunWhenLeader(ctx, run))
+			done <- struct{}{}
+		}()
+	}
+
+	time.Sleep(time.Second)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&leaders))
+
+	cancel()
+	<-done
+	<-done
+}
+
+func TestElector_ReturnsResultOfFn(t *testing.T) {
+	r := &redisboot.Redis{}
+	assert.Nil(t, r.Configure(goboot.NewAppEnv("./testdata", "valid")))
+
+	elected := false
+	elector := r.NewElector("test-leader-result", time.Second)
+	elector.OnElected = func() { elected = true }
+
+	err := elector.RunWhenLeader(context.Background(), func(ctx context.Context) error {
+		return context.DeadlineExceeded
+	})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.True(t, elected)
+
+	// lease is released so another elector is elected immediately
+	_, err = r.TryLock(context.Background(), "test-leader-result", time.Second)
+	assert.Nil(t, err)
+}
@@ -0,0 +1,16 @@
+package redisboot
+
+import (
+	"context"
+	"fmt"
+)
+
+// HealthCheck returns an error when Redis doesn't respond to PING.
+// It implements goboot.HealthChecker.
+func (s *Redis) HealthCheck(ctx context.Context) error {
+	if err := s.Client.Ping(ctx).Err(); err != nil {
+		return fmt.Errorf("pinging Redis: %w", err)
+	}
+
+	return nil
+}
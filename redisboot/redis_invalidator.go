@@ -0,0 +1,50 @@
+package redisboot
+
+import (
+	"context"
+
+	"github.com/nielskrijger/goboot/cacheboot"
+)
+
+type invalidation struct {
+	Key    string `json:"key"`
+	Origin string `json:"origin"`
+}
+
+// invalidator broadcasts invalidated cache keys over a Redis channel.
+type invalidator struct {
+	redis   *Redis
+	channel string
+	origin  string
+}
+
+// Invalidator returns a cacheboot.Invalidator broadcasting invalidated keys
+// over channel, e.g. for a cacheboot.Tiered cache. Messages sent by the
+// invalidator itself are ignored.
+func (s *Redis) Invalidator(channel string) cacheboot.Invalidator {
+	origin, err := randomID()
+	if err != nil {
+		origin = channel
+	}
+
+	return &invalidator{redis: s, channel: channel, origin: origin}
+}
+
+func (i *invalidator) Invalidate(ctx context.Context, key string) error {
+	return i.redis.Publish(ctx, i.channel, invalidation{Key: key, Origin: i.origin})
+}
+
+func (i *invalidator) OnInvalidate(ctx context.Context, fn func(key string)) error {
+	return i.redis.Subscribe(ctx, i.channel, func(ctx context.Context, msg *Message) error {
+		var inv invalidation
+		if err := msg.Decode(&inv); err != nil {
+			return err
+		}
+
+		if inv.Origin != i.origin {
+			fn(inv.Key)
+		}
+
+		return nil
+	})
+}
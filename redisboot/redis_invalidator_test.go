@@ -0,0 +1,52 @@
+package redisboot_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nielskrijger/goboot"
+	"github.com/nielskrijger/goboot/cacheboot"
+	"github.com/nielskrijger/goboot/redisboot"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTieredCache creates a tiered cache like it would run in a separate instance.
+func newTieredCache(t *testing.T, r *redisboot.Redis) *cacheboot.Tiered {
+	t.Helper()
+
+	env := goboot.NewAppEnv("./testdata", "valid")
+	local := &cacheboot.Memory{}
+	assert.Nil(t, local.Configure(env))
+
+	tiered := cacheboot.NewTiered(local, r, r.Invalidator("cache:invalidate"))
+	assert.Nil(t, tiered.Configure(env))
+	assert.Nil(t, tiered.Init())
+
+	return tiered
+}
+
+func TestTieredCache_InvalidatesOtherInstances(t *testing.T) {
+	r := &redisboot.Redis{}
+	assert.Nil(t, r.Configure(goboot.NewAppEnv("./testdata", "valid")))
+
+	ctx := context.Background()
+	instance1 := newTieredCache(t, r)
+	instance2 := newTieredCache(t, r)
+
+	assert.Nil(t, instance1.Set(ctx, "product:1", []byte("v1"), time.Minute))
+
+	b, err := instance2.Get(ctx, "product:1")
+	assert.Nil(t, err)
+	assert.Equal(t, "v1", string(b))
+
+	assert.Nil(t, instance1.Set(ctx, "product:1", []byte("v2"), time.Minute))
+
+	assert.Eventually(t, func() bool {
+		b, err := instance2.Get(ctx, "product:1")
+
+		return err == nil && string(b) == "v2"
+	}, 5*time.Second, 10*time.Millisecond)
+
+	assert.Nil(t, r.Close())
+}
@@ -0,0 +1,184 @@
+package redisboot
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	lockRetryInterval = 50 * time.Millisecond
+
+	// lockClockDrift is the fraction of the TTL subtracted from the lock
+	// validity to account for clock drift between Redis nodes.
+	lockClockDrift = 100
+)
+
+var (
+	ErrNotObtained = errors.New("lock not obtained")
+	ErrNotHeld     = errors.New("lock not held")
+)
+
+var (
+	unlockScript = redis.NewScript(`
+if redis.call("get", KEYS[1]) == ARGV[1] then
+	return redis.call("del", KEYS[1])
+end
+return 0`)
+
+	extendScript = redis.NewScript(`
+if redis.call("get", KEYS[1]) == ARGV[1] then
+	return redis.call("pexpire", KEYS[1], ARGV[2])
+end
+return 0`)
+)
+
+// Lock is a held distributed lock, see Redis.Lock and Redlock.Lock.
+type Lock struct {
+	key    string
+	token  string
+	nodes  []*Redis
+	quorum int
+}
+
+// Key returns the locked key without KeyPrefix.
+func (l *Lock) Key() string {
+	return l.key
+}
+
+// Extend resets the TTL of the lock, returns ErrNotHeld if the lock expired
+// or was obtained by someone else in the meantime.
+func (l *Lock) Extend(ctx context.Context, ttl time.Duration) error {
+	n := 0
+
+	for _, node := range l.nodes {
+		res, err := extendScript.Run(ctx, node.Client, []string{node.Key(l.key)}, l.token, ttl.Milliseconds()).Int()
+		if err == nil && res == 1 {
+			n++
+		}
+	}
+
+	if n < l.quorum {
+		return fmt.Errorf("extending lock %q: %w", l.key, ErrNotHeld)
+	}
+
+	return nil
+}
+
+// Unlock releases the lock, returns ErrNotHeld if the lock expired or was
+// obtained by someone else in the meantime.
+func (l *Lock) Unlock(ctx context.Context) error {
+	n := 0
+
+	for _, node := range l.nodes {
+		res, err := unlockScript.Run(ctx, node.Client, []string{node.Key(l.key)}, l.token).Int()
+		if err == nil && res == 1 {
+			n++
+		}
+	}
+
+	if n < l.quorum {
+		return fmt.Errorf("releasing lock %q: %w", l.key, ErrNotHeld)
+	}
+
+	return nil
+}
+
+// Lock obtains an exclusive lock on key prefixed with KeyPrefix that expires
+// after ttl unless extended, retrying until ctx is done. Use TryLock to fail
+// immediately.
+//
+// The lock is safe as long as the Redis node doesn't fail over, use Redlock
+// for stronger guarantees across independent nodes.
+func (s *Redis) Lock(ctx context.Context, key string, ttl time.Duration) (*Lock, error) {
+	return lock(ctx, []*Redis{s}, key, ttl, true)
+}
+
+// TryLock is like Lock but returns ErrNotObtained if the lock is held.
+func (s *Redis) TryLock(ctx context.Context, key string, ttl time.Duration) (*Lock, error) {
+	return lock(ctx, []*Redis{s}, key, ttl, false)
+}
+
+// Redlock obtains locks on a majority of independent Redis nodes using the
+// Redlock algorithm, see https://redis.io/docs/manual/patterns/distributed-locks/.
+type Redlock struct {
+	nodes []*Redis
+}
+
+// NewRedlock creates a Redlock using independent Redis services, usually an
+// odd number like 3 or 5.
+func NewRedlock(services ...*Redis) *Redlock {
+	return &Redlock{nodes: services}
+}
+
+// Lock obtains a lock on a majority of nodes, retrying until ctx is done.
+func (r *Redlock) Lock(ctx context.Context, key string, ttl time.Duration) (*Lock, error) {
+	return lock(ctx, r.nodes, key, ttl, true)
+}
+
+// TryLock is like Lock but returns ErrNotObtained if the lock is held.
+func (r *Redlock) TryLock(ctx context.Context, key string, ttl time.Duration) (*Lock, error) {
+	return lock(ctx, r.nodes, key, ttl, false)
+}
+
+func lock(ctx context.Context, nodes []*Redis, key string, ttl time.Duration, retry bool) (*Lock, error) {
+	token, err := randomID()
+	if err != nil {
+		return nil, err
+	}
+
+	l := &Lock{key: key, token: token, nodes: nodes, quorum: len(nodes)/2 + 1}
+
+	for {
+		if l.obtain(ctx, ttl) {
+			return l, nil
+		}
+
+		if !retry {
+			return nil, fmt.Errorf("locking %q: %w", key, ErrNotObtained)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("locking %q: %w: %v", key, ErrNotObtained, ctx.Err())
+		case <-time.After(lockRetryInterval):
+		}
+	}
+}
+
+// obtain sets the lock on all nodes and returns true if a quorum was
+// obtained within the TTL, otherwise it releases the partially obtained lock.
+func (l *Lock) obtain(ctx context.Context, ttl time.Duration) bool {
+	start := time.Now()
+	n := 0
+
+	for _, node := range l.nodes {
+		ok, err := node.Client.SetNX(ctx, node.Key(l.key), l.token, ttl).Result()
+		if err == nil && ok {
+			n++
+		}
+	}
+
+	validity := ttl - time.Since(start) - ttl/lockClockDrift
+	if n >= l.quorum && validity > 0 {
+		return true
+	}
+
+	_ = l.Unlock(context.Background())
+
+	return false
+}
+
+func randomID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generating random id: %w", err)
+	}
+
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,60 @@
+package redisboot_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nielskrijger/goboot"
+	"github.com/nielskrijger/goboot/redisboot"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedis_Lock(t *testing.T) {
+	s := &redisboot.Redis{}
+	assert.Nil(t, s.Configure(goboot.NewAppEnv("./testdata", "valid")))
+
+	ctx := context.Background()
+	lock, err := s.Lock(ctx, "test-lock", time.Second)
+	assert.Nil(t, err)
+
+	_, err = s.TryLock(ctx, "test-lock", time.Second)
+	assert.ErrorIs(t, err, redisboot.ErrNotObtained)
+
+	assert.Nil(t, lock.Extend(ctx, time.Second))
+	assert.Nil(t, lock.Unlock(ctx))
+	assert.ErrorIs(t, lock.Unlock(ctx), redisboot.ErrNotHeld)
+
+	lock, err = s.TryLock(ctx, "test-lock", time.Second)
+	assert.Nil(t, err)
+	assert.Nil(t, lock.Unlock(ctx))
+}
+
+func TestRedis_LockWaitsUntilExpired(t *testing.T) {
+	s := &redisboot.Redis{}
+	assert.Nil(t, s.Configure(goboot.NewAppEnv("./testdata", "valid")))
+
+	ctx := context.Background()
+	_, err := s.Lock(ctx, "test-lock-expire", 200*time.Millisecond)
+	assert.Nil(t, err)
+
+	lock, err := s.Lock(ctx, "test-lock-expire", time.Second)
+	assert.Nil(t, err)
+	assert.Nil(t, lock.Unlock(ctx))
+}
+
+func TestRedlock(t *testing.T) {
+	s := &redisboot.Redis{}
+	assert.Nil(t, s.Configure(goboot.NewAppEnv("./testdata", "valid")))
+
+	redlock := redisboot.NewRedlock(s)
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	lock, err := redlock.Lock(ctx, "test-redlock", time.Second)
+	assert.Nil(t, err)
+
+	_, err = redlock.Lock(ctx, "test-redlock", time.Second)
+	assert.ErrorIs(t, err, redisboot.ErrNotObtained)
+	assert.Nil(t, lock.Unlock(context.Background()))
+}
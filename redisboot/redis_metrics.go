@@ -0,0 +1,152 @@
+package redisboot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/nielskrijger/goboot"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/redis/go-redis/v9"
+)
+
+type redisMetrics struct {
+	commandDuration *prometheus.HistogramVec
+	commandErrors   *prometheus.CounterVec
+}
+
+func newRedisMetrics() *redisMetrics {
+	return &redisMetrics{
+		commandDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "redis_command_duration_seconds",
+			Help:    "Duration of Redis commands by command name, pipelines are recorded as \"pipeline\".",
+			Buckets: []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1},
+		}, []string{"command"}),
+		commandErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "redis_command_errors_total",
+			Help: "Number of failed Redis commands by command name, excluding missing keys.",
+		}, []string{"command"}),
+	}
+}
+
+// registerMetrics registers the command metrics and the connection pool
+// statistics with the shared metrics registry. The pool statistics are
+// labeled by namespace so multiple connections can share a registry.
+func (s *Redis) registerMetrics(reg *prometheus.Registry) error {
+	if reg == nil {
+		return nil
+	}
+
+	m := newRedisMetrics()
+
+	if err := goboot.RegisterCollector(reg, &m.commandDuration); err != nil {
+		return fmt.Errorf("registering Redis metrics: %w", err)
+	}
+
+	if err := goboot.RegisterCollector(reg, &m.commandErrors); err != nil {
+		return fmt.Errorf("registering Redis metrics: %w", err)
+	}
+
+	name := s.Namespace
+	if name == "" {
+		name = "default"
+	}
+
+	pool := newPoolCollector(s.Client, name)
+	if err := goboot.RegisterCollector(reg, &pool); err != nil {
+		return fmt.Errorf("registering Redis pool metrics: %w", err)
+	}
+
+	s.Client.AddHook(&metricsHook{metrics: m})
+
+	return nil
+}
+
+// metricsHook records the duration and errors of every command.
+type metricsHook struct {
+	metrics *redisMetrics
+}
+
+func (h *metricsHook) DialHook(next redis.DialHook) redis.DialHook {
+	return next
+}
+
+func (h *metricsHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		start := time.Now()
+		err := next(ctx, cmd)
+		h.observe(cmd.Name(), time.Since(start), err)
+
+		return err
+	}
+}
+
+func (h *metricsHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		start := time.Now()
+		err := next(ctx, cmds)
+		h.observe("pipeline", time.Since(start), err)
+
+		return err
+	}
+}
+
+func (h *metricsHook) observe(command string, duration time.Duration, err error) {
+	h.metrics.commandDuration.WithLabelValues(command).Observe(duration.Seconds())
+
+	if err != nil && !errors.Is(err, redis.Nil) {
+		h.metrics.commandErrors.WithLabelValues(command).Inc()
+	}
+}
+
+// poolCollector exports the connection pool statistics of a client.
+type poolCollector struct {
+	client     redis.UniversalClient
+	hits       *prometheus.Desc
+	misses     *prometheus.Desc
+	timeouts   *prometheus.Desc
+	staleConns *prometheus.Desc
+	totalConns *prometheus.Desc
+	idleConns  *prometheus.Desc
+}
+
+func newPoolCollector(client redis.UniversalClient, name string) *poolCollector {
+	labels := prometheus.Labels{"client": name}
+
+	return &poolCollector{
+		client: client,
+		hits: prometheus.NewDesc("redis_pool_hits_total",
+			"Number of times a free connection was found in the pool.", nil, labels),
+		misses: prometheus.NewDesc("redis_pool_misses_total",
+			"Number of times a free connection was not found in the pool.", nil, labels),
+		timeouts: prometheus.NewDesc("redis_pool_timeouts_total",
+			"Number of times waiting for a connection timed out.", nil, labels),
+		staleConns: prometheus.NewDesc("redis_pool_stale_connections_total",
+			"Number of stale connections removed from the pool.", nil, labels),
+		totalConns: prometheus.NewDesc("redis_pool_connections",
+			"Number of connections in the pool.", nil, labels),
+		idleConns: prometheus.NewDesc("redis_pool_idle_connections",
+			"Number of idle connections in the pool.", nil, labels),
+	}
+}
+
+func (c *poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.hits
+	ch <- c.misses
+	ch <- c.timeouts
+	ch <- c.staleConns
+	ch <- c.totalConns
+	ch <- c.idleConns
+}
+
+func (c *poolCollector) Collect(ch chan<- prometheus.Metric) {
+	stats := c.client.PoolStats()
+
+	ch <- prometheus.MustNewConstMetric(c.hits, prometheus.CounterValue, float64(stats.Hits))
+	ch <- prometheus.MustNewConstMetric(c.misses, prometheus.CounterValue, float64(stats.Misses))
+	ch <- prometheus.MustNewConstMetric(c.timeouts, prometheus.CounterValue, float64(stats.Timeouts))
+	ch <- prometheus.MustNewConstMetric(c.staleConns, prometheus.CounterValue, float64(stats.StaleConns))
+	ch <- prometheus.MustNewConstMetric(c.totalConns, prometheus.GaugeValue, float64(stats.TotalConns))
+	ch <- prometheus.MustNewConstMetric(c.idleConns, prometheus.GaugeValue, float64(stats.IdleConns))
+}
@@ -0,0 +1,130 @@
+package redisboot
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Message is a message received by Subscribe.
+type Message struct {
+	// Channel is the name of the channel without KeyPrefix.
+	Channel string
+
+	// Pattern is the pattern that matched the channel, empty when
+	// subscribed to a channel name.
+	Pattern string
+	Payload string
+}
+
+// Decode unmarshals the JSON payload into v.
+func (m *Message) Decode(v any) error {
+	if err := json.Unmarshal([]byte(m.Payload), v); err != nil {
+		return fmt.Errorf("decoding Redis message on channel %q: %w", m.Channel, err)
+	}
+
+	return nil
+}
+
+// Publish sends v to all subscribers of channel prefixed with KeyPrefix.
+// Strings and byte slices are sent as-is, other values are encoded as JSON.
+func (s *Redis) Publish(ctx context.Context, channel string, v any) error {
+	var payload any
+
+	switch v := v.(type) {
+	case string, []byte:
+		payload = v
+	default:
+		b, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Errorf("encoding Redis message: %w", err)
+		}
+
+		payload = b
+	}
+
+	if err := s.Client.Publish(ctx, s.Key(channel), payload).Err(); err != nil {
+		return fmt.Errorf("publishing to Redis channel %q: %w", channel, err)
+	}
+
+	return nil
+}
+
+// Subscribe calls handler for every message published to channels matching
+// pattern, e.g. "cache:invalidate" or "presence:*". The pattern is prefixed
+// with KeyPrefix. Messages are handled in order, handler errors are logged.
+// Subscribing stops when ctx is done or the service is closed, the ctx passed
+// to handler is cancelled as well.
+//
+// The subscription is restored automatically after reconnecting; messages
+// published while disconnected are lost.
+func (s *Redis) Subscribe(ctx context.Context, pattern string, handler func(ctx context.Context, msg *Message) error) error {
+	var pubsub *redis.PubSub
+	if strings.ContainsAny(pattern, "*?[") {
+		pubsub = s.Client.PSubscribe(ctx, s.Key(pattern))
+	} else {
+		pubsub = s.Client.Subscribe(ctx, s.Key(pattern))
+	}
+
+	// wait for confirmation so messages published after Subscribe returns are received
+	if _, err := pubsub.Receive(ctx); err != nil {
+		_ = pubsub.Close()
+
+		return fmt.Errorf("subscribing to Redis channel %q: %w", pattern, err)
+	}
+
+	s.closeMu.Lock()
+	select {
+	case <-s.closing:
+		s.closeMu.Unlock()
+		_ = pubsub.Close()
+
+		return fmt.Errorf("subscribing to Redis channel %q: %w", pattern, errClosed)
+	default:
+		s.subscribers.Add(1)
+		s.closeMu.Unlock()
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+
+	go func() {
+		select {
+		case <-s.closing:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	go func() {
+		defer s.subscribers.Done()
+		defer cancel()
+		defer func() { _ = pubsub.Close() }()
+
+		ch := pubsub.Channel()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case m, ok := <-ch:
+				if !ok {
+					return
+				}
+
+				msg := &Message{
+					Channel: strings.TrimPrefix(m.Channel, s.KeyPrefix),
+					Pattern: strings.TrimPrefix(m.Pattern, s.KeyPrefix),
+					Payload: m.Payload,
+				}
+				if err := handler(ctx, msg); err != nil {
+					s.log.Error().Err(err).Str("channel", m.Channel).Msg("failed to handle Redis message")
+				}
+			}
+		}
+	}()
+
+	return nil
+}
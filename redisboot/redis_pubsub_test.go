@@ -0,0 +1,69 @@
+package redisboot_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nielskrijger/goboot"
+	"github.com/nielskrijger/goboot/redisboot"
+	"github.com/stretchr/testify/assert"
+)
+
+type presence struct {
+	User   string `json:"user"`
+	Online bool   `json:"online"`
+}
+
+func TestRedis_PublishSubscribe(t *testing.T) {
+	s := &redisboot.Redis{}
+	assert.Nil(t, s.Configure(goboot.NewAppEnv("./testdata", "valid")))
+
+	received := make(chan *redisboot.Message, 1)
+	err := s.Subscribe(context.Background(), "presence:*", func(ctx context.Context, msg *redisboot.Message) error {
+		received <- msg
+
+		return nil
+	})
+	assert.Nil(t, err)
+
+	assert.Nil(t, s.Publish(context.Background(), "presence:room1", presence{User: "john", Online: true}))
+
+	select {
+	case msg := <-received:
+		assert.Equal(t, "presence:room1", msg.Channel)
+		assert.Equal(t, "presence:*", msg.Pattern)
+
+		var p presence
+		assert.Nil(t, msg.Decode(&p))
+		assert.Equal(t, presence{User: "john", Online: true}, p)
+	case <-time.After(5 * time.Second):
+		t.Fatal("message not received")
+	}
+
+	assert.Nil(t, s.Close())
+}
+
+func TestRedis_CloseStopsSubscriptions(t *testing.T) {
+	s := &redisboot.Redis{}
+	assert.Nil(t, s.Configure(goboot.NewAppEnv("./testdata", "valid")))
+
+	handling := make(chan struct{})
+	err := s.Subscribe(context.Background(), "close", func(ctx context.Context, msg *redisboot.Message) error {
+		close(handling)
+		<-ctx.Done()
+
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Nil(t, s.Publish(context.Background(), "close", "message"))
+	<-handling
+
+	assert.Nil(t, s.Close())
+	assert.Nil(t, s.Close())
+
+	err = s.Subscribe(context.Background(), "close", func(ctx context.Context, msg *redisboot.Message) error {
+		return nil
+	})
+	assert.NotNil(t, err)
+}
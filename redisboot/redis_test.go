@@ -1,10 +1,13 @@
 package redisboot_test
 
 import (
+	"context"
 	"testing"
 
 	"github.com/nielskrijger/goboot"
 	"github.com/nielskrijger/goboot/redisboot"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/redis/go-redis/v9"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -12,7 +15,7 @@ func TestRedis_Success(t *testing.T) {
 	s := &redisboot.Redis{}
 	assert.Nil(t, s.Configure(goboot.NewAppEnv("./testdata", "valid")))
 	assert.Nil(t, s.Init())
-	assert.Equal(t, "Redis<0.0.0.0:6379 db:3>", s.Client.String())
+	assert.Equal(t, "Redis<0.0.0.0:6379 db:3>", s.Client.(*redis.Client).String())
 }
 
 func TestRedis_ErrorMissingConfig(t *testing.T) {
@@ -24,11 +27,82 @@ func TestRedis_ErrorMissingConfig(t *testing.T) {
 func TestRedis_ErrorEmptyURL(t *testing.T) {
 	s := &redisboot.Redis{}
 	err := s.Configure(goboot.NewAppEnv("./testdata", "no-url"))
-	assert.EqualError(t, err, "config \"redis.url\" is required")
+	assert.EqualError(t, err, "config \"redis.url\" or \"redis.addrs\" is required")
 }
 
 func TestRedis_ErrorOnConnect(t *testing.T) {
 	s := &redisboot.Redis{}
 	err := s.Configure(goboot.NewAppEnv("./testdata", "invalid"))
 	assert.EqualError(t, err, "failed to connect to redis after 5 retries: dial tcp 1.2.3.4:6379: i/o timeout")
+	assert.ErrorIs(t, s.Client.Ping(context.Background()).Err(), redis.ErrClosed)
+}
+
+func TestRedis_ClosesClientOnMetricsError(t *testing.T) {
+	env := goboot.NewAppEnv("./testdata", "named")
+	env.Metrics.MustRegister(prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "redis_command_errors_total",
+		Help: "Conflicting counter.",
+	}))
+
+	s := &redisboot.Redis{Namespace: "cache"}
+	err := s.Configure(env)
+	assert.ErrorContains(t, err, "registering Redis metrics")
+	assert.ErrorIs(t, s.Client.Ping(context.Background()).Err(), redis.ErrClosed)
+}
+
+func TestRedis_ErrorMissingTLSCAFile(t *testing.T) {
+	s := &redisboot.Redis{}
+	err := s.Configure(goboot.NewAppEnv("./testdata", "tls-missing-ca"))
+	assert.ErrorContains(t, err, "reading Redis CA file: open ./testdata/missing-ca.pem")
+}
+
+func TestRedis_ErrorInvalidTLSCAFile(t *testing.T) {
+	s := &redisboot.Redis{}
+	err := s.Configure(goboot.NewAppEnv("./testdata", "tls-invalid-ca"))
+	assert.EqualError(t, err, "no certificates found in Redis CA file")
+}
+
+func TestRedis_KeyPrefixDefaultsToAppNameAndEnv(t *testing.T) {
+	s := &redisboot.Redis{}
+	assert.Nil(t, s.Configure(goboot.NewAppEnv("./testdata", "prefix")))
+	assert.Equal(t, "myapp:prefix:", s.KeyPrefix)
+	assert.Equal(t, "myapp:prefix:session:1", s.Key("session:1"))
+}
+
+func TestRedis_HealthCheckAndMetrics(t *testing.T) {
+	env := goboot.NewAppEnv("./testdata", "valid")
+	s := &redisboot.Redis{}
+	assert.Nil(t, s.Configure(env))
+	assert.Nil(t, s.HealthCheck(context.Background()))
+
+	families, err := env.Metrics.Gather()
+	assert.Nil(t, err)
+
+	names := make([]string, len(families))
+	for i, f := range families {
+		names[i] = f.GetName()
+	}
+
+	assert.Contains(t, names, "redis_command_duration_seconds")
+	assert.Contains(t, names, "redis_pool_hits_total")
+	assert.Contains(t, names, "redis_pool_idle_connections")
+}
+
+func TestRedis_NamedConnections(t *testing.T) {
+	env := goboot.NewAppEnv("./testdata", "named")
+	cache := &redisboot.Redis{Namespace: "cache"}
+	sessions := &redisboot.Redis{Namespace: "sessions"}
+
+	assert.Nil(t, cache.Configure(env))
+	assert.Nil(t, sessions.Configure(env))
+	assert.Equal(t, "Redis cache", cache.Name())
+	assert.Equal(t, "Redis<0.0.0.0:6379 db:4>", cache.Client.(*redis.Client).String())
+	assert.Equal(t, "Redis<0.0.0.0:6379 db:5>", sessions.Client.(*redis.Client).String())
+	assert.Nil(t, (&redisboot.Redis{Namespace: "cache"}).Configure(env))
+}
+
+func TestRedis_ErrorMissingNamedConfig(t *testing.T) {
+	s := &redisboot.Redis{Namespace: "queues"}
+	err := s.Configure(goboot.NewAppEnv("./testdata", "named"))
+	assert.EqualError(t, err, "missing Redis configuration \"redis.queues\"")
 }
@@ -0,0 +1,59 @@
+package redisboot
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+)
+
+var errInvalidCA = errors.New("no certificates found in Redis CA file")
+
+// tlsConfig returns the TLS settings of the config, nil if TLS is disabled.
+// Setting any of the TLS file options enables TLS, Redis.TLSConfig takes
+// precedence.
+func (s *Redis) tlsConfig(cfg *RedisConfig) (*tls.Config, error) {
+	if s.TLSConfig != nil {
+		return s.TLSConfig, nil
+	}
+
+	if !cfg.TLS && cfg.TLSCAFile == "" && cfg.TLSCert == "" {
+		return nil, nil //nolint:nilnil
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		ServerName:         cfg.TLSServerName,
+		InsecureSkipVerify: cfg.TLSInsecureSkipVerify, //nolint:gosec
+	}
+
+	if cfg.TLSInsecureSkipVerify {
+		s.log.Warn().Msg("Redis TLS certificate verification is disabled, don't use this in production")
+	}
+
+	if cfg.TLSCAFile != "" {
+		pem, err := os.ReadFile(cfg.TLSCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("reading Redis CA file: %w", err)
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, errInvalidCA
+		}
+
+		tlsConfig.RootCAs = pool
+	}
+
+	if cfg.TLSCert != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.TLSCert, cfg.TLSKey)
+		if err != nil {
+			return nil, fmt.Errorf("loading Redis client certificate: %w", err)
+		}
+
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
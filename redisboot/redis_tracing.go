@@ -0,0 +1,113 @@
+package redisboot
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.12.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/nielskrijger/goboot/redisboot"
+
+// keyIdentifiers matches key segments that identify a record: numbers, UUIDs
+// and long hexadecimal strings.
+var keyIdentifiers = regexp.MustCompile(
+	`^(?:\d+|[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}|[0-9a-fA-F]{16,})$`)
+
+// tracingHook records a span for every command, parented to the span in the
+// command context, if any.
+type tracingHook struct {
+	tracer trace.Tracer
+}
+
+func (h *tracingHook) DialHook(next redis.DialHook) redis.DialHook {
+	return next
+}
+
+func (h *tracingHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		ctx, span := h.tracer.Start(ctx, "redis."+cmd.Name(),
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(
+				semconv.DBSystemRedis,
+				semconv.DBOperationKey.String(cmd.Name()),
+			),
+		)
+		defer span.End()
+
+		if pattern := keyPattern(cmd); pattern != "" {
+			span.SetAttributes(attribute.String("db.redis.key_pattern", pattern))
+		}
+
+		err := next(ctx, cmd)
+		recordError(span, err)
+
+		return err
+	}
+}
+
+func (h *tracingHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		names := make([]string, len(cmds))
+		for i, cmd := range cmds {
+			names[i] = cmd.Name()
+		}
+
+		ctx, span := h.tracer.Start(ctx, "redis.pipeline",
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(
+				semconv.DBSystemRedis,
+				semconv.DBOperationKey.String("pipeline"),
+				attribute.StringSlice("db.redis.commands", names),
+			),
+		)
+		defer span.End()
+
+		err := next(ctx, cmds)
+		recordError(span, err)
+
+		return err
+	}
+}
+
+func recordError(span trace.Span, err error) {
+	if err != nil && !errors.Is(err, redis.Nil) {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+}
+
+// keyPattern returns the first key of the command with identifiers replaced
+// by "*" to keep the values out of the trace, e.g. "user:123:profile" becomes
+// "user:*:profile". Returns an empty string for commands without key.
+func keyPattern(cmd redis.Cmder) string {
+	args := cmd.Args()
+	if len(args) < 2 {
+		return ""
+	}
+
+	switch strings.ToLower(cmd.Name()) {
+	case "ping", "echo", "auth", "select", "hello", "client", "info", "config", "eval", "evalsha", "script", "publish":
+		return ""
+	}
+
+	key, ok := args[1].(string)
+	if !ok {
+		return ""
+	}
+
+	segments := strings.Split(key, ":")
+	for i, segment := range segments {
+		if keyIdentifiers.MatchString(segment) {
+			segments[i] = "*"
+		}
+	}
+
+	return strings.Join(segments, ":")
+}
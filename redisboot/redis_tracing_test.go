@@ -0,0 +1,42 @@
+package redisboot_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/nielskrijger/goboot"
+	"github.com/nielskrijger/goboot/redisboot"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestRedis_TraceCommands(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	env := goboot.NewAppEnv("./testdata", "valid")
+	env.TracerProvider = tp
+	s := &redisboot.Redis{}
+	assert.Nil(t, s.Configure(env))
+
+	ctx, parent := tp.Tracer("test").Start(context.Background(), "request")
+	assert.Nil(t, s.Client.Set(ctx, "user:123:profile", "secret", 0).Err())
+	parent.End()
+
+	var set sdktrace.ReadOnlySpan
+
+	for _, span := range recorder.Ended() {
+		if span.Name() == "redis.set" {
+			set = span
+		}
+	}
+
+	if assert.NotNil(t, set) {
+		assert.Equal(t, parent.SpanContext().SpanID(), set.Parent().SpanID())
+		assert.Contains(t, set.Attributes(), attribute.String("db.redis.key_pattern", "user:*:profile"))
+	}
+
+	assert.Nil(t, s.Close())
+}
@@ -0,0 +1,50 @@
+package redisboot
+
+import (
+	"context"
+
+	"github.com/nielskrijger/goboot/wsboot"
+)
+
+type broadcast struct {
+	Group   string `json:"group"`
+	Payload []byte `json:"payload"`
+	Origin  string `json:"origin"`
+}
+
+// wsBroker fans out websocket messages over a Redis channel.
+type wsBroker struct {
+	redis   *Redis
+	channel string
+	origin  string
+}
+
+// WebSocketBroker returns a wsboot.Broker fanning out websocket messages
+// over channel. Messages sent by the broker itself are ignored.
+func (s *Redis) WebSocketBroker(channel string) (wsboot.Broker, error) {
+	origin, err := randomID()
+	if err != nil {
+		return nil, err
+	}
+
+	return &wsBroker{redis: s, channel: channel, origin: origin}, nil
+}
+
+func (b *wsBroker) Publish(ctx context.Context, group string, msg []byte) error {
+	return b.redis.Publish(ctx, b.channel, broadcast{Group: group, Payload: msg, Origin: b.origin})
+}
+
+func (b *wsBroker) Subscribe(ctx context.Context, fn func(group string, msg []byte)) error {
+	return b.redis.Subscribe(ctx, b.channel, func(ctx context.Context, msg *Message) error {
+		var bc broadcast
+		if err := msg.Decode(&bc); err != nil {
+			return err
+		}
+
+		if bc.Origin != b.origin {
+			fn(bc.Group, bc.Payload)
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,223 @@
+package redisboot
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	defaultSessionTTL        = 24 * time.Hour
+	defaultSessionCookieName = "session"
+)
+
+var ErrSessionNotFound = errors.New("session not found")
+
+type SessionOptions struct {
+	// TTL is the time a session remains valid. Default is 24 hours.
+	TTL time.Duration
+
+	// Rolling resets the TTL every time the session is loaded, so sessions
+	// only expire after TTL of inactivity.
+	Rolling bool
+
+	// CookieName is the name of the session cookie. Default is "session".
+	CookieName string
+
+	// InsecureCookie allows sending the cookie over plain HTTP, only for local development.
+	InsecureCookie bool
+}
+
+// Session is a session with typed payload Data.
+type Session[T any] struct {
+	ID        string    `json:"id"`
+	Data      T         `json:"data"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// SessionStore stores sessions with payload of type T in Redis.
+type SessionStore[T any] struct {
+	redis *Redis
+	opts  SessionOptions
+}
+
+type sessionContextKey struct{}
+
+// NewSessionStore creates a session store in redis.
+func NewSessionStore[T any](redis *Redis, opts SessionOptions) *SessionStore[T] {
+	if opts.TTL == 0 {
+		opts.TTL = defaultSessionTTL
+	}
+
+	if opts.CookieName == "" {
+		opts.CookieName = defaultSessionCookieName
+	}
+
+	return &SessionStore[T]{redis: redis, opts: opts}
+}
+
+// Create stores a new session with a random id.
+func (s *SessionStore[T]) Create(ctx context.Context, data T) (*Session[T], error) {
+	id, err := randomID()
+	if err != nil {
+		return nil, err
+	}
+
+	sess := &Session[T]{ID: id, Data: data, CreatedAt: time.Now().UTC()}
+
+	b, err := json.Marshal(sess)
+	if err != nil {
+		return nil, fmt.Errorf("encoding session: %w", err)
+	}
+
+	if err := s.redis.Client.Set(ctx, s.key(id), b, s.opts.TTL).Err(); err != nil {
+		return nil, fmt.Errorf("creating session: %w", err)
+	}
+
+	return sess, nil
+}
+
+// Get returns the session, or ErrSessionNotFound if it doesn't exist or
+// expired. Resets the TTL when Rolling is set.
+func (s *SessionStore[T]) Get(ctx context.Context, id string) (*Session[T], error) {
+	var cmd *redis.StringCmd
+	if s.opts.Rolling {
+		cmd = s.redis.Client.GetEx(ctx, s.key(id), s.opts.TTL)
+	} else {
+		cmd = s.redis.Client.Get(ctx, s.key(id))
+	}
+
+	b, err := cmd.Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrSessionNotFound
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("reading session: %w", err)
+	}
+
+	sess := &Session[T]{}
+	if err := json.Unmarshal(b, sess); err != nil {
+		return nil, fmt.Errorf("decoding session: %w", err)
+	}
+
+	return sess, nil
+}
+
+// Save updates the data of an existing session without changing its TTL.
+func (s *SessionStore[T]) Save(ctx context.Context, sess *Session[T]) error {
+	b, err := json.Marshal(sess)
+	if err != nil {
+		return fmt.Errorf("encoding session: %w", err)
+	}
+
+	err = s.redis.Client.SetArgs(ctx, s.key(sess.ID), b, redis.SetArgs{Mode: "XX", KeepTTL: true}).Err()
+	if errors.Is(err, redis.Nil) {
+		return ErrSessionNotFound
+	}
+
+	if err != nil {
+		return fmt.Errorf("saving session: %w", err)
+	}
+
+	return nil
+}
+
+// Refresh resets the TTL of the session.
+func (s *SessionStore[T]) Refresh(ctx context.Context, id string) error {
+	ok, err := s.redis.Client.Expire(ctx, s.key(id), s.opts.TTL).Result()
+	if err != nil {
+		return fmt.Errorf("refreshing session: %w", err)
+	}
+
+	if !ok {
+		return ErrSessionNotFound
+	}
+
+	return nil
+}
+
+// Destroy deletes the session, e.g. when logging out.
+func (s *SessionStore[T]) Destroy(ctx context.Context, id string) error {
+	if err := s.redis.Client.Del(ctx, s.key(id)).Err(); err != nil {
+		return fmt.Errorf("destroying session: %w", err)
+	}
+
+	return nil
+}
+
+// SetCookie sets the session cookie on the response, e.g. after logging in.
+func (s *SessionStore[T]) SetCookie(w http.ResponseWriter, sess *Session[T]) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     s.opts.CookieName,
+		Value:    sess.ID,
+		Path:     "/",
+		MaxAge:   int(s.opts.TTL.Seconds()),
+		Secure:   !s.opts.InsecureCookie,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// ClearCookie removes the session cookie, e.g. after logging out.
+func (s *SessionStore[T]) ClearCookie(w http.ResponseWriter) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     s.opts.CookieName,
+		Value:    "",
+		Path:     "/",
+		MaxAge:   -1,
+		Secure:   !s.opts.InsecureCookie,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// Middleware loads the session of the session cookie into the request
+// context, see SessionFromContext. Requests without valid session are passed
+// on without session, the cookie of an expired session is cleared.
+func (s *SessionStore[T]) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cookie, err := r.Cookie(s.opts.CookieName)
+		if err != nil || cookie.Value == "" {
+			next.ServeHTTP(w, r)
+
+			return
+		}
+
+		sess, err := s.Get(r.Context(), cookie.Value)
+
+		switch {
+		case errors.Is(err, ErrSessionNotFound):
+			s.ClearCookie(w)
+		case err != nil:
+			s.redis.log.Error().Err(err).Msg("failed to load session")
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+
+			return
+		default:
+			if s.opts.Rolling {
+				s.SetCookie(w, sess)
+			}
+
+			r = r.WithContext(context.WithValue(r.Context(), sessionContextKey{}, sess))
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// SessionFromContext returns the session loaded by SessionStore.Middleware.
+func SessionFromContext[T any](ctx context.Context) (*Session[T], bool) {
+	sess, ok := ctx.Value(sessionContextKey{}).(*Session[T])
+
+	return sess, ok
+}
+
+func (s *SessionStore[T]) key(id string) string {
+	return s.redis.Key("session:" + id)
+}
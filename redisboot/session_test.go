@@ -0,0 +1,71 @@
+package redisboot_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/nielskrijger/goboot"
+	"github.com/nielskrijger/goboot/redisboot"
+	"github.com/stretchr/testify/assert"
+)
+
+type userSession struct {
+	UserID string `json:"userId"`
+}
+
+func TestSessionStore(t *testing.T) {
+	r := &redisboot.Redis{}
+	assert.Nil(t, r.Configure(goboot.NewAppEnv("./testdata", "valid")))
+
+	store := redisboot.NewSessionStore[userSession](r, redisboot.SessionOptions{TTL: time.Minute, Rolling: true})
+	ctx := context.Background()
+
+	sess, err := store.Create(ctx, userSession{UserID: "123"})
+	assert.Nil(t, err)
+
+	sess.Data.UserID = "456"
+	assert.Nil(t, store.Save(ctx, sess))
+
+	loaded, err := store.Get(ctx, sess.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, "456", loaded.Data.UserID)
+
+	assert.Nil(t, store.Refresh(ctx, sess.ID))
+	assert.Nil(t, store.Destroy(ctx, sess.ID))
+
+	_, err = store.Get(ctx, sess.ID)
+	assert.ErrorIs(t, err, redisboot.ErrSessionNotFound)
+	assert.ErrorIs(t, store.Save(ctx, sess), redisboot.ErrSessionNotFound)
+}
+
+func TestSessionStore_Middleware(t *testing.T) {
+	r := &redisboot.Redis{}
+	assert.Nil(t, r.Configure(goboot.NewAppEnv("./testdata", "valid")))
+
+	store := redisboot.NewSessionStore[userSession](r, redisboot.SessionOptions{})
+	sess, err := store.Create(context.Background(), userSession{UserID: "123"})
+	assert.Nil(t, err)
+
+	var userID string
+
+	handler := store.Middleware(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if sess, ok := redisboot.SessionFromContext[userSession](req.Context()); ok {
+			userID = sess.Data.UserID
+		}
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(&http.Cookie{Name: "session", Value: sess.ID})
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	assert.Equal(t, "123", userID)
+
+	// expired session clears the cookie
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(&http.Cookie{Name: "session", Value: "unknown"})
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	assert.Contains(t, rec.Header().Get("Set-Cookie"), "session=;")
+}
@@ -0,0 +1,107 @@
+package goboot
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+const (
+	secretFilePrefix = "file:"
+	secretEnvPrefix  = "env:"
+)
+
+var errSecretEnvNotSet = errors.New("environment variable not set")
+
+var (
+	secretResolversMu sync.RWMutex
+	secretResolvers   = map[string]*secretResolverFunc{}
+)
+
+type secretResolverFunc struct {
+	resolve func(ref string) (string, error)
+}
+
+// RegisterSecretResolver resolves secret references prefixed with
+// "{scheme}:" using resolve, which receives the reference including the
+// prefix. For example secretboot registers "vault", so config settings
+// resolved by ResolveSecret can refer to "vault:secret/db#password".
+// The "file" and "env" schemes can't be overridden.
+//
+// A later registration of the same scheme replaces resolve. The returned
+// function unregisters resolve, unless it has been replaced.
+func RegisterSecretResolver(scheme string, resolve func(ref string) (string, error)) func() {
+	secretResolversMu.Lock()
+	defer secretResolversMu.Unlock()
+
+	registered := &secretResolverFunc{resolve: resolve}
+	secretResolvers[scheme] = registered
+
+	return func() {
+		secretResolversMu.Lock()
+		defer secretResolversMu.Unlock()
+
+		if secretResolvers[scheme] == registered {
+			delete(secretResolvers, scheme)
+		}
+	}
+}
+
+// secretResolver returns the registered resolver of the scheme of value.
+func secretResolver(value string) (func(ref string) (string, error), bool) {
+	scheme, _, ok := strings.Cut(value, ":")
+	if !ok {
+		return nil, false
+	}
+
+	secretResolversMu.RLock()
+	defer secretResolversMu.RUnlock()
+
+	registered, ok := secretResolvers[scheme]
+	if !ok {
+		return nil, false
+	}
+
+	return registered.resolve, true
+}
+
+// ResolveSecret returns the secret value of a config setting. Secrets are
+// either specified inline or as a reference:
+//
+//   - "file:/run/secrets/db-password" reads the secret from a file, e.g. a
+//     mounted Kubernetes secret. Trailing newlines are removed.
+//   - "env:DB_PASSWORD" reads the secret from an environment variable.
+//   - a reference of a scheme registered with RegisterSecretResolver, e.g.
+//     "gcp:db-password" when using secretboot.
+//
+// Any other value is returned as-is.
+func ResolveSecret(value string) (string, error) {
+	switch {
+	case strings.HasPrefix(value, secretFilePrefix):
+		path := strings.TrimPrefix(value, secretFilePrefix)
+
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("reading secret file %q: %w", path, err)
+		}
+
+		return strings.TrimRight(string(b), "\r\n"), nil
+	case strings.HasPrefix(value, secretEnvPrefix):
+		name := strings.TrimPrefix(value, secretEnvPrefix)
+
+		secret, ok := os.LookupEnv(name)
+		if !ok {
+			return "", fmt.Errorf("reading secret %q: %w", name, errSecretEnvNotSet)
+		}
+
+		return secret, nil
+	default:
+		if resolve, ok := secretResolver(value); ok {
+			return resolve(value)
+		}
+
+		return value, nil
+	}
+}
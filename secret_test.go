@@ -0,0 +1,69 @@
+package goboot_test
+
+import (
+	"testing"
+
+	"github.com/nielskrijger/goboot"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolveSecret_Inline(t *testing.T) {
+	secret, err := goboot.ResolveSecret("my-secret")
+	assert.Nil(t, err)
+	assert.Equal(t, "my-secret", secret)
+}
+
+func TestResolveSecret_File(t *testing.T) {
+	secret, err := goboot.ResolveSecret("file:./testdata/secret.txt")
+	assert.Nil(t, err)
+	assert.Equal(t, "file-secret", secret)
+}
+
+func TestResolveSecret_Env(t *testing.T) {
+	t.Setenv("GOBOOT_TEST_SECRET", "env-secret")
+
+	secret, err := goboot.ResolveSecret("env:GOBOOT_TEST_SECRET")
+	assert.Nil(t, err)
+	assert.Equal(t, "env-secret", secret)
+}
+
+func TestResolveSecret_ErrorMissingEnv(t *testing.T) {
+	_, err := goboot.ResolveSecret("env:GOBOOT_TEST_UNKNOWN")
+	assert.EqualError(t, err, "reading secret \"GOBOOT_TEST_UNKNOWN\": environment variable not set")
+}
+
+func TestRegisterSecretResolver_UnregisterReplaced(t *testing.T) {
+	unregister := goboot.RegisterSecretResolver("replaced", func(ref string) (string, error) {
+		return "first", nil
+	})
+	defer goboot.RegisterSecretResolver("replaced", func(ref string) (string, error) {
+		return "second", nil
+	})()
+
+	// unregistering a replaced resolver keeps the replacement
+	unregister()
+
+	secret, err := goboot.ResolveSecret("replaced:db-password")
+	assert.Nil(t, err)
+	assert.Equal(t, "second", secret)
+}
+
+func TestResolveSecret_RegisteredResolver(t *testing.T) {
+	unregister := goboot.RegisterSecretResolver("test", func(ref string) (string, error) {
+		return "resolved " + ref, nil
+	})
+
+	secret, err := goboot.ResolveSecret("test:db-password")
+	assert.Nil(t, err)
+	assert.Equal(t, "resolved test:db-password", secret)
+
+	unregister()
+
+	secret, err = goboot.ResolveSecret("test:db-password")
+	assert.Nil(t, err)
+	assert.Equal(t, "test:db-password", secret)
+
+	secret, err = goboot.ResolveSecret("unknown:db-password")
+	assert.Nil(t, err)
+	assert.Equal(t, "unknown:db-password", secret)
+}
@@ -0,0 +1,71 @@
+package secretboot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager/types"
+)
+
+type AWSConfig struct {
+	// Region of the secrets. Defaults to the region of the AWS config, e.g.
+	// environment variable AWS_REGION.
+	Region string `yaml:"region"`
+
+	// Endpoint of the Secrets Manager API. Defaults to
+	// "https://secretsmanager.{region}.amazonaws.com".
+	Endpoint string `yaml:"endpoint"`
+}
+
+// awsBackend reads secrets from AWS Secrets Manager using the default
+// credentials chain.
+type awsBackend struct {
+	client *secretsmanager.Client
+}
+
+func newAWSBackend(ctx context.Context, cfg *AWSConfig, timeout time.Duration) (*awsBackend, error) {
+	var opts []func(*config.LoadOptions) error
+	if cfg.Region != "" {
+		opts = append(opts, config.WithRegion(cfg.Region))
+	}
+
+	awsCfg, err := config.LoadDefaultConfig(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("loading AWS config: %w", err)
+	}
+
+	client := secretsmanager.NewFromConfig(awsCfg, func(o *secretsmanager.Options) {
+		o.HTTPClient = awshttp.NewBuildableClient().WithTimeout(timeout)
+
+		if cfg.Endpoint != "" {
+			o.EndpointResolver = secretsmanager.EndpointResolverFromURL(cfg.Endpoint)
+		}
+	})
+
+	return &awsBackend{client: client}, nil
+}
+
+// GetSecret reads the current version of the secret with ID or ARN name.
+func (b *awsBackend) GetSecret(ctx context.Context, name string) (string, error) {
+	out, err := b.client.GetSecretValue(ctx, &secretsmanager.GetSecretValueInput{SecretId: aws.String(name)})
+	if err != nil {
+		var notFound *types.ResourceNotFoundException
+		if errors.As(err, &notFound) {
+			return "", ErrNotFound
+		}
+
+		return "", fmt.Errorf("requesting secret: %w", err)
+	}
+
+	if out.SecretBinary != nil {
+		return string(out.SecretBinary), nil
+	}
+
+	return aws.ToString(out.SecretString), nil
+}
@@ -0,0 +1,88 @@
+package secretboot
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/option"
+	secretmanager "google.golang.org/api/secretmanager/v1"
+)
+
+var errMissingGCPProject = errors.New("config \"secrets.gcp.project\" is required for secret names without project")
+
+type GCPConfig struct {
+	// Project of secrets referred to by name, e.g. "gcp:db-password".
+	Project string `yaml:"project"`
+
+	// Endpoint of the Secret Manager API, e.g. for a private endpoint.
+	Endpoint string `yaml:"endpoint"`
+
+	// WithoutAuthentication disables authentication, e.g. for an emulator.
+	WithoutAuthentication bool `yaml:"withoutAuthentication"`
+}
+
+// gcpBackend reads secrets from GCP Secret Manager using the application
+// default credentials.
+type gcpBackend struct {
+	service *secretmanager.Service
+	project string
+}
+
+func newGCPBackend(ctx context.Context, config *GCPConfig) (*gcpBackend, error) {
+	var opts []option.ClientOption
+
+	if config.Endpoint != "" {
+		opts = append(opts, option.WithEndpoint(config.Endpoint))
+	}
+
+	if config.WithoutAuthentication {
+		opts = append(opts, option.WithoutAuthentication())
+	}
+
+	service, err := secretmanager.NewService(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("creating GCP Secret Manager client: %w", err)
+	}
+
+	return &gcpBackend{service: service, project: config.Project}, nil
+}
+
+// GetSecret reads secret name, which is either a secret ID of the configured
+// project or the resource name of a secret or secret version, e.g.
+// "projects/p/secrets/db-password/versions/2". The latest version is read
+// unless the name has a version.
+func (b *gcpBackend) GetSecret(ctx context.Context, name string) (string, error) {
+	if !strings.HasPrefix(name, "projects/") {
+		if b.project == "" {
+			return "", errMissingGCPProject
+		}
+
+		name = "projects/" + b.project + "/secrets/" + name
+	}
+
+	if !strings.Contains(name, "/versions/") {
+		name += "/versions/latest"
+	}
+
+	resp, err := b.service.Projects.Secrets.Versions.Access(name).Context(ctx).Do()
+	if err != nil {
+		var apiErr *googleapi.Error
+		if errors.As(err, &apiErr) && apiErr.Code == http.StatusNotFound {
+			return "", ErrNotFound
+		}
+
+		return "", err //nolint:wrapcheck
+	}
+
+	b64, err := base64.StdEncoding.DecodeString(resp.Payload.Data)
+	if err != nil {
+		return "", fmt.Errorf("decoding secret: %w", err)
+	}
+
+	return string(b64), nil
+}
@@ -0,0 +1,390 @@
+// Package secretboot reads secrets from secret managers, see Secrets.
+package secretboot
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nielskrijger/goboot"
+	"github.com/rs/zerolog"
+)
+
+const (
+	defaultCacheTTL = 5 * time.Minute
+	defaultTimeout  = 10 * time.Second
+	maxResponseSize = 1 << 20 // 1 MiB
+)
+
+var (
+	// ErrNotFound is returned when a secret doesn't exist.
+	ErrNotFound = errors.New("secret not found")
+
+	errUnknownScheme = errors.New("unknown secret scheme")
+	errInvalidRef    = errors.New("secret reference must be formatted as \"{scheme}:{name}\"")
+	errMissingKey    = errors.New("secret has no key")
+)
+
+// Backend reads secrets from a secret manager.
+type Backend interface {
+	// GetSecret returns the latest value of secret name, or an error wrapping
+	// ErrNotFound if it doesn't exist.
+	GetSecret(ctx context.Context, name string) (string, error)
+}
+
+type SecretsConfig struct {
+	// Time secrets are cached. Default is 5 minutes.
+	CacheTTL time.Duration `yaml:"cacheTTL"`
+
+	// Interval of checking the secrets watched using OnRotate for new
+	// values. Default is CacheTTL.
+	RotationInterval time.Duration `yaml:"rotationInterval"`
+
+	// Timeout of reading a secret. Default is 10 seconds.
+	Timeout time.Duration `yaml:"timeout"`
+
+	// Backends, enabled when configured.
+	GCP   *GCPConfig   `yaml:"gcp"`
+	AWS   *AWSConfig   `yaml:"aws"`
+	Vault *VaultConfig `yaml:"vault"`
+}
+
+// Secrets implements the AppService interface and reads secrets from the
+// backends configured in "secrets":
+//
+//	secrets:
+//	  gcp:
+//	    project: my-project
+//	  aws:
+//	    region: eu-west-1
+//	  vault:
+//	    address: https://vault.example.com:8200
+//	    token: env:VAULT_TOKEN
+//
+// Secrets are referred to as "{scheme}:{name}", optionally followed by
+// "#{key}" to read a field of a JSON secret:
+//
+//   - "gcp:db-password", or "gcp:projects/p/secrets/db-password/versions/2";
+//   - "aws:prod/db#password";
+//   - "vault:db#password", the KV v2 secret at path "db";
+//   - "env:DB_PASSWORD" and "file:/run/secrets/db-password", which are read
+//     using goboot.ResolveSecret and not cached.
+//
+// Configure registers the schemes with goboot.RegisterSecretResolver, so
+// the secret settings of services configured afterwards can refer to
+// secrets, e.g. "postgres.password: gcp:db-password". Add Secrets as first
+// service for this. Close unregisters the schemes.
+type Secrets struct {
+	// Backends by scheme, added to the configured backends, e.g. for other
+	// secret managers.
+	Backends map[string]Backend
+
+	config *SecretsConfig
+	log    zerolog.Logger
+
+	mu         sync.Mutex
+	cache      map[string]*cachedSecret
+	watchers   []*watcher
+	unregister []func()
+
+	wg     sync.WaitGroup
+	ctx    context.Context //nolint:containedctx
+	cancel context.CancelFunc
+}
+
+type cachedSecret struct {
+	value   string
+	expires time.Time
+}
+
+type watcher struct {
+	ref   string
+	value string
+	fn    func(value string)
+}
+
+func (s *Secrets) Name() string {
+	return "Secrets"
+}
+
+// Configure loads the optional "secrets" configuration, creates the
+// configured backends and registers their schemes.
+func (s *Secrets) Configure(env *goboot.AppEnv) error {
+	s.log = env.Log
+	s.config = &SecretsConfig{}
+	s.cache = make(map[string]*cachedSecret)
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+
+	if env.Config.InConfig("secrets") {
+		if err := env.Config.Sub("secrets").Unmarshal(s.config); err != nil {
+			return fmt.Errorf("parsing secrets configuration: %w", err)
+		}
+	}
+
+	s.setDefaults()
+
+	if s.Backends == nil {
+		s.Backends = make(map[string]Backend)
+	}
+
+	if err := s.configureBackends(); err != nil {
+		return err
+	}
+
+	for scheme := range s.Backends {
+		s.unregister = append(s.unregister, goboot.RegisterSecretResolver(scheme, s.resolve))
+	}
+
+	return nil
+}
+
+func (s *Secrets) setDefaults() {
+	if s.config.CacheTTL == 0 {
+		s.config.CacheTTL = defaultCacheTTL
+	}
+
+	if s.config.RotationInterval == 0 {
+		s.config.RotationInterval = s.config.CacheTTL
+	}
+
+	if s.config.Timeout == 0 {
+		s.config.Timeout = defaultTimeout
+	}
+}
+
+func (s *Secrets) configureBackends() error {
+	ctx, cancel := context.WithTimeout(s.ctx, s.config.Timeout)
+	defer cancel()
+
+	if s.config.GCP != nil {
+		backend, err := newGCPBackend(ctx, s.config.GCP)
+		if err != nil {
+			return err
+		}
+
+		s.Backends["gcp"] = backend
+	}
+
+	if s.config.AWS != nil {
+		backend, err := newAWSBackend(ctx, s.config.AWS, s.config.Timeout)
+		if err != nil {
+			return err
+		}
+
+		s.Backends["aws"] = backend
+	}
+
+	if s.config.Vault != nil {
+		backend, err := newVaultBackend(s.config.Vault, s.config.Timeout)
+		if err != nil {
+			return err
+		}
+
+		s.Backends["vault"] = backend
+	}
+
+	return nil
+}
+
+// resolve reads a secret for goboot.ResolveSecret.
+func (s *Secrets) resolve(ref string) (string, error) {
+	ctx, cancel := context.WithTimeout(s.ctx, s.config.Timeout)
+	defer cancel()
+
+	return s.Get(ctx, ref)
+}
+
+// Init checks the secrets watched using OnRotate every RotationInterval.
+func (s *Secrets) Init() error {
+	s.wg.Add(1)
+
+	go s.watch()
+
+	return nil
+}
+
+// Get returns the secret of ref, e.g. "vault:db#password", which is cached
+// for CacheTTL.
+func (s *Secrets) Get(ctx context.Context, ref string) (string, error) {
+	scheme, name, key, err := parseRef(ref)
+	if err != nil {
+		return "", err
+	}
+
+	s.mu.Lock()
+	cached, ok := s.cache[scheme+":"+name]
+	s.mu.Unlock()
+
+	value := ""
+
+	if ok && time.Now().Before(cached.expires) {
+		value = cached.value
+	} else if value, err = s.read(ctx, scheme, name); err != nil {
+		return "", err
+	}
+
+	return extractKey(value, key)
+}
+
+// read returns the latest value of secret name. Env and file secrets are read
+// using goboot.ResolveSecret and aren't cached, the others are fetched from
+// their backend.
+func (s *Secrets) read(ctx context.Context, scheme string, name string) (string, error) {
+	if scheme == "env" || scheme == "file" {
+		return goboot.ResolveSecret(scheme + ":" + name) //nolint:wrapcheck
+	}
+
+	return s.fetch(ctx, scheme, name)
+}
+
+// fetch reads the latest value of secret name and caches it.
+func (s *Secrets) fetch(ctx context.Context, scheme string, name string) (string, error) {
+	backend, ok := s.Backends[scheme]
+	if !ok {
+		return "", fmt.Errorf("%w %q", errUnknownScheme, scheme)
+	}
+
+	value, err := backend.GetSecret(ctx, name)
+	if err != nil {
+		return "", fmt.Errorf("reading secret \"%s:%s\": %w", scheme, name, err)
+	}
+
+	s.mu.Lock()
+	s.cache[scheme+":"+name] = &cachedSecret{value: value, expires: time.Now().Add(s.config.CacheTTL)}
+	s.mu.Unlock()
+
+	return value, nil
+}
+
+// parseRef splits "{scheme}:{name}#{key}" into its parts, the key is
+// optional.
+func parseRef(ref string) (string, string, string, error) {
+	scheme, name, ok := strings.Cut(ref, ":")
+	if !ok || scheme == "" || name == "" {
+		return "", "", "", fmt.Errorf("%w: %q", errInvalidRef, ref)
+	}
+
+	name, key, _ := strings.Cut(name, "#")
+
+	return scheme, name, key, nil
+}
+
+// extractKey returns field key of a JSON object value, or value if key is
+// empty.
+func extractKey(value string, key string) (string, error) {
+	if key == "" {
+		return value, nil
+	}
+
+	var fields map[string]any
+	if err := json.Unmarshal([]byte(value), &fields); err != nil {
+		return "", fmt.Errorf("%w %q: secret isn't a JSON object", errMissingKey, key)
+	}
+
+	field, ok := fields[key]
+	if !ok {
+		return "", fmt.Errorf("%w %q", errMissingKey, key)
+	}
+
+	if str, ok := field.(string); ok {
+		return str, nil
+	}
+
+	b, err := json.Marshal(field)
+	if err != nil {
+		return "", fmt.Errorf("encoding secret key %q: %w", key, err)
+	}
+
+	return string(b), nil
+}
+
+// OnRotate calls fn with the new value when the secret of ref changes, e.g.
+// to reconnect with a rotated password. Changes are detected every
+// RotationInterval.
+func (s *Secrets) OnRotate(ctx context.Context, ref string, fn func(value string)) error {
+	value, err := s.Get(ctx, ref)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.watchers = append(s.watchers, &watcher{ref: ref, value: value, fn: fn})
+	s.mu.Unlock()
+
+	return nil
+}
+
+func (s *Secrets) watch() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(s.config.RotationInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+			s.checkRotated()
+		}
+	}
+}
+
+// checkRotated reads the latest values of the watched secrets, bypassing
+// the cache, and calls the callbacks of changed secrets.
+func (s *Secrets) checkRotated() {
+	s.mu.Lock()
+	watchers := append([]*watcher{}, s.watchers...)
+	s.mu.Unlock()
+
+	// secrets of watchers of the same secret are read once
+	latest := make(map[string]string)
+
+	for _, w := range watchers {
+		scheme, name, key, _ := parseRef(w.ref)
+		id := scheme + ":" + name
+
+		value, ok := latest[id]
+		if !ok {
+			var err error
+
+			ctx, cancel := context.WithTimeout(s.ctx, s.config.Timeout)
+			value, err = s.read(ctx, scheme, name)
+
+			cancel()
+
+			if err != nil {
+				s.log.Warn().Err(err).Msg("failed to check rotated secret")
+
+				continue
+			}
+
+			latest[id] = value
+		}
+
+		if value, err := extractKey(value, key); err == nil && value != w.value {
+			s.log.Info().Msgf("secret %q rotated", id)
+			w.value = value
+			w.fn(value)
+		}
+	}
+}
+
+func (s *Secrets) Close() error {
+	for _, unregister := range s.unregister {
+		unregister()
+	}
+
+	s.unregister = nil
+
+	if s.cancel != nil {
+		s.cancel()
+		s.wg.Wait()
+	}
+
+	return nil
+}
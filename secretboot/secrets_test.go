@@ -0,0 +1,304 @@
+package secretboot_test
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nielskrijger/goboot"
+	"github.com/nielskrijger/goboot/secretboot"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeBackend struct {
+	mu      sync.Mutex
+	secrets map[string]string
+	reads   int
+}
+
+func (b *fakeBackend) GetSecret(_ context.Context, name string) (string, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.reads++
+
+	secret, ok := b.secrets[name]
+	if !ok {
+		return "", secretboot.ErrNotFound
+	}
+
+	return secret, nil
+}
+
+func (b *fakeBackend) set(name string, value string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.secrets[name] = value
+}
+
+func newSecrets(t *testing.T, backend secretboot.Backend) *secretboot.Secrets {
+	t.Helper()
+
+	s := &secretboot.Secrets{Backends: map[string]secretboot.Backend{"fake": backend}}
+	assert.Nil(t, s.Configure(goboot.NewAppEnv("./testdata", "")))
+	assert.Nil(t, s.Init())
+
+	t.Cleanup(func() { _ = s.Close() })
+
+	return s
+}
+
+func TestSecrets_Get(t *testing.T) {
+	backend := &fakeBackend{secrets: map[string]string{
+		"db-password": "secret",
+		"db":          `{"username":"app","password":"secret","port":5432}`,
+	}}
+	s := newSecrets(t, backend)
+	ctx := context.Background()
+
+	for _, test := range []struct {
+		ref   string
+		value string
+	}{
+		{"fake:db-password", "secret"},
+		{"fake:db#password", "secret"},
+		{"fake:db#username", "app"},
+		{"fake:db#port", "5432"},
+	} {
+		value, err := s.Get(ctx, test.ref)
+		assert.Nil(t, err)
+		assert.Equal(t, test.value, value, test.ref)
+	}
+
+	// cached
+	assert.Equal(t, 2, backend.reads)
+
+	t.Setenv("GOBOOT_TEST_SECRET", "env-secret")
+
+	value, err := s.Get(ctx, "env:GOBOOT_TEST_SECRET")
+	assert.Nil(t, err)
+	assert.Equal(t, "env-secret", value)
+
+	t.Setenv("GOBOOT_TEST_SECRET", `{"password":"env-secret"}`)
+
+	value, err = s.Get(ctx, "env:GOBOOT_TEST_SECRET#password")
+	assert.Nil(t, err)
+	assert.Equal(t, "env-secret", value)
+}
+
+func TestSecrets_CloseUnregistersSchemes(t *testing.T) {
+	backend := &fakeBackend{secrets: map[string]string{"db-password": "secret"}}
+	s := newSecrets(t, backend)
+
+	value, err := goboot.ResolveSecret("fake:db-password")
+	assert.Nil(t, err)
+	assert.Equal(t, "secret", value)
+
+	assert.Nil(t, s.Close())
+
+	value, err = goboot.ResolveSecret("fake:db-password")
+	assert.Nil(t, err)
+	assert.Equal(t, "fake:db-password", value)
+}
+
+func TestSecrets_GetErrors(t *testing.T) {
+	s := newSecrets(t, &fakeBackend{secrets: map[string]string{"db": `{"password":"secret"}`}})
+	ctx := context.Background()
+
+	_, err := s.Get(ctx, "fake:unknown")
+	assert.EqualError(t, err, `reading secret "fake:unknown": secret not found`)
+	assert.ErrorIs(t, err, secretboot.ErrNotFound)
+
+	_, err = s.Get(ctx, "fake:db#username")
+	assert.EqualError(t, err, `secret has no key "username"`)
+
+	_, err = s.Get(ctx, "other:db")
+	assert.EqualError(t, err, `unknown secret scheme "other"`)
+
+	_, err = s.Get(ctx, "db")
+	assert.EqualError(t, err, `secret reference must be formatted as "{scheme}:{name}": "db"`)
+}
+
+func TestSecrets_ResolveSecret(t *testing.T) {
+	newSecrets(t, &fakeBackend{secrets: map[string]string{"db-password": "secret"}})
+
+	value, err := goboot.ResolveSecret("fake:db-password")
+	assert.Nil(t, err)
+	assert.Equal(t, "secret", value)
+}
+
+func TestSecrets_OnRotate(t *testing.T) {
+	backend := &fakeBackend{secrets: map[string]string{"db": `{"password":"v1","username":"app"}`}}
+	s := newSecrets(t, backend)
+
+	rotated := make(chan string, 2)
+	assert.Nil(t, s.OnRotate(context.Background(), "fake:db#password", func(value string) {
+		rotated <- value
+	}))
+
+	username := make(chan string, 1)
+	assert.Nil(t, s.OnRotate(context.Background(), "fake:db#username", func(value string) {
+		username <- value
+	}))
+
+	backend.set("db", `{"password":"v2","username":"app"}`)
+
+	select {
+	case value := <-rotated:
+		assert.Equal(t, "v2", value)
+	case <-time.After(time.Second):
+		t.Fatal("rotation not detected")
+	}
+
+	value, err := s.Get(context.Background(), "fake:db#password")
+	assert.Nil(t, err)
+	assert.Equal(t, "v2", value)
+	assert.Empty(t, username)
+}
+
+func TestSecrets_OnRotateFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "db-password")
+	assert.Nil(t, os.WriteFile(path, []byte("v1"), 0o600))
+
+	s := newSecrets(t, &fakeBackend{})
+
+	rotated := make(chan string, 1)
+	assert.Nil(t, s.OnRotate(context.Background(), "file:"+path, func(value string) {
+		rotated <- value
+	}))
+
+	assert.Nil(t, os.WriteFile(path, []byte("v2"), 0o600))
+
+	select {
+	case value := <-rotated:
+		assert.Equal(t, "v2", value)
+	case <-time.After(time.Second):
+		t.Fatal("rotation not detected")
+	}
+}
+
+func newBackendSecrets(t *testing.T, backend string, settings ...string) *secretboot.Secrets {
+	t.Helper()
+
+	env := goboot.NewAppEnv("./testdata", backend)
+
+	for i := 0; i+1 < len(settings); i += 2 {
+		env.Config.Set(settings[i], settings[i+1])
+	}
+
+	s := &secretboot.Secrets{}
+	assert.Nil(t, s.Configure(env))
+
+	t.Cleanup(func() { _ = s.Close() })
+
+	return s
+}
+
+func TestSecrets_Vault(t *testing.T) {
+	t.Setenv("GOBOOT_TEST_VAULT_TOKEN", "token-1")
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "token-1" {
+			w.WriteHeader(http.StatusForbidden)
+
+			return
+		}
+
+		if r.URL.Path != "/v1/secret/data/app/db" {
+			w.WriteHeader(http.StatusNotFound)
+
+			return
+		}
+
+		_, _ = w.Write([]byte(`{"data":{"data":{"password":"vault-secret"},"metadata":{"version":3}}}`))
+	}))
+	defer srv.Close()
+
+	s := newBackendSecrets(t, "vault", "secrets.vault.address", srv.URL)
+
+	value, err := s.Get(context.Background(), "vault:app/db#password")
+	assert.Nil(t, err)
+	assert.Equal(t, "vault-secret", value)
+
+	_, err = s.Get(context.Background(), "vault:app/unknown")
+	assert.ErrorIs(t, err, secretboot.ErrNotFound)
+}
+
+func TestSecrets_AWS(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKID")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "SECRET")
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "secretsmanager.GetSecretValue", r.Header.Get("X-Amz-Target"))
+		assert.True(t, strings.HasPrefix(r.Header.Get("Authorization"),
+			"AWS4-HMAC-SHA256 Credential=AKID/"), r.Header.Get("Authorization"))
+		assert.Contains(t, r.Header.Get("Authorization"), "/eu-west-1/secretsmanager/aws4_request")
+
+		var req struct {
+			SecretID string `json:"SecretId"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+
+		switch req.SecretID {
+		case "prod/db":
+			_, _ = w.Write([]byte(`{"Name":"prod/db","SecretString":"{\"password\":\"aws-secret\"}"}`))
+		case "prod/cert":
+			_, _ = fmt.Fprintf(w, `{"Name":"prod/cert","SecretBinary":%q}`, base64.StdEncoding.EncodeToString([]byte("binary")))
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"__type":"ResourceNotFoundException","message":"Secrets Manager can't find the specified secret."}`))
+		}
+	}))
+	defer srv.Close()
+
+	s := newBackendSecrets(t, "aws", "secrets.aws.endpoint", srv.URL)
+
+	value, err := s.Get(context.Background(), "aws:prod/db#password")
+	assert.Nil(t, err)
+	assert.Equal(t, "aws-secret", value)
+
+	value, err = s.Get(context.Background(), "aws:prod/cert")
+	assert.Nil(t, err)
+	assert.Equal(t, "binary", value)
+
+	_, err = s.Get(context.Background(), "aws:unknown")
+	assert.ErrorIs(t, err, secretboot.ErrNotFound)
+}
+
+func TestSecrets_GCP(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/projects/my-project/secrets/db-password/versions/latest:access",
+			"/v1/projects/other/secrets/db-password/versions/2:access":
+			_, _ = io.WriteString(w, `{"payload":{"data":"`+base64.StdEncoding.EncodeToString([]byte("gcp-secret"))+`"}}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error":{"code":404,"message":"not found"}}`))
+		}
+	}))
+	defer srv.Close()
+
+	s := newBackendSecrets(t, "gcp", "secrets.gcp.endpoint", srv.URL, "secrets.gcp.withoutAuthentication", "true")
+
+	value, err := s.Get(context.Background(), "gcp:db-password")
+	assert.Nil(t, err)
+	assert.Equal(t, "gcp-secret", value)
+
+	value, err = s.Get(context.Background(), "gcp:projects/other/secrets/db-password/versions/2")
+	assert.Nil(t, err)
+	assert.Equal(t, "gcp-secret", value)
+
+	_, err = s.Get(context.Background(), "gcp:unknown")
+	assert.ErrorIs(t, err, secretboot.ErrNotFound)
+}
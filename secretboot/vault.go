@@ -0,0 +1,104 @@
+package secretboot
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/nielskrijger/goboot"
+)
+
+const defaultVaultMount = "secret"
+
+var (
+	errMissingVaultAddress = errors.New("config \"secrets.vault.address\" is required")
+	errStatus              = errors.New("unexpected status")
+)
+
+type VaultConfig struct {
+	// Address of Vault, e.g. "https://vault.example.com:8200".
+	Address string `yaml:"address"`
+
+	// Token to authenticate, read from a file or environment variable if
+	// prefixed, e.g. "env:VAULT_TOKEN", see goboot.ResolveSecret.
+	Token string `yaml:"token"`
+
+	// Mount path of the KV version 2 secrets engine. Default is "secret".
+	Mount string `yaml:"mount"`
+
+	// Namespace of Vault Enterprise, optional.
+	Namespace string `yaml:"namespace"`
+}
+
+// vaultBackend reads secrets from the KV version 2 secrets engine of
+// HashiCorp Vault.
+type vaultBackend struct {
+	client *http.Client
+	config *VaultConfig
+	token  string
+}
+
+func newVaultBackend(config *VaultConfig, timeout time.Duration) (*vaultBackend, error) {
+	if config.Address == "" {
+		return nil, errMissingVaultAddress
+	}
+
+	if config.Mount == "" {
+		config.Mount = defaultVaultMount
+	}
+
+	token, err := goboot.ResolveSecret(config.Token)
+	if err != nil {
+		return nil, fmt.Errorf("reading vault token: %w", err)
+	}
+
+	return &vaultBackend{client: &http.Client{Timeout: timeout}, config: config, token: token}, nil
+}
+
+// GetSecret returns the data of the latest version of the secret at path
+// name as JSON object, select a field using a key, e.g. "vault:db#password".
+func (b *vaultBackend) GetSecret(ctx context.Context, name string) (string, error) {
+	url := strings.TrimSuffix(b.config.Address, "/") + "/v1/" + b.config.Mount + "/data/" + strings.TrimPrefix(name, "/")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", fmt.Errorf("creating request: %w", err)
+	}
+
+	req.Header.Set("X-Vault-Token", b.token)
+
+	if b.config.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", b.config.Namespace)
+	}
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("requesting secret: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return "", ErrNotFound
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%w %d", errStatus, resp.StatusCode)
+	}
+
+	var result struct {
+		Data struct {
+			Data json.RawMessage `json:"data"`
+		} `json:"data"`
+	}
+
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxResponseSize)).Decode(&result); err != nil {
+		return "", fmt.Errorf("decoding response: %w", err)
+	}
+
+	return string(result.Data.Data), nil
+}
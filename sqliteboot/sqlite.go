@@ -0,0 +1,117 @@
+package sqliteboot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+	_ "github.com/mattn/go-sqlite3" // Register the sqlite3 database/sql driver.
+	"github.com/nielskrijger/goboot"
+	"github.com/rs/zerolog"
+)
+
+var (
+	errMissingConfig = errors.New("missing SQLite configuration")
+	errMissingDSN    = errors.New("config \"sqlite.dsn\" is required")
+	errUnavailable   = errors.New("database is unavailable")
+)
+
+type SQLiteConfig struct {
+	// DSN contains the SQLite data source name, e.g. file:data/app.db?_foreign_keys=on
+	// see also https://github.com/mattn/go-sqlite3#connection-string
+	//
+	// Use "file::memory:?cache=shared" for an in-memory database; a plain
+	// ":memory:" database is not shared between connections and migrations.
+	DSN string `yaml:"dsn"`
+
+	// Maximum number of open connections. Default is 0 (unlimited). SQLite
+	// allows a single writer, set to 1 to avoid "database is locked" errors.
+	PoolSize int `yaml:"poolSize"`
+}
+
+// SQLite implements the AppService interface. It's a lightweight alternative
+// to the Postgres service for local development and CI, using the same
+// migrations conventions.
+//
+// SQLite requires cgo, see https://github.com/mattn/go-sqlite3
+type SQLite struct {
+	MigrationsDir string // relative path to migrations directory, leave empty when no migrations
+
+	DB *sqlx.DB
+
+	config *SQLiteConfig
+	log    zerolog.Logger
+}
+
+func (s *SQLite) Name() string {
+	return "SQLite"
+}
+
+// Configure opens the SQLite database.
+func (s *SQLite) Configure(env *goboot.AppEnv) error {
+	s.log = env.Log
+
+	// unmarshal config and set defaults
+	s.config = &SQLiteConfig{}
+
+	if !env.Config.InConfig("sqlite") {
+		return errMissingConfig
+	}
+
+	if !env.Config.IsSet("sqlite.dsn") {
+		return errMissingDSN
+	}
+
+	if err := env.Config.Sub("sqlite").Unmarshal(s.config); err != nil {
+		return fmt.Errorf("parsing SQLite configuration: %w", err)
+	}
+
+	db, err := sqlx.Open("sqlite3", s.config.DSN)
+	if err != nil {
+		return fmt.Errorf("opening SQLite database: %w", err)
+	}
+
+	db.SetMaxOpenConns(s.config.PoolSize)
+	s.DB = db
+
+	if err := db.Ping(); err != nil {
+		return fmt.Errorf("opening SQLite database: %w", err)
+	}
+
+	s.log.Info().Msgf("opened SQLite database %s", s.config.DSN)
+
+	return nil
+}
+
+// Init runs the migrations.
+func (s *SQLite) Init() error {
+	if s.MigrationsDir == "" {
+		s.log.Info().Msg("skipping db migrations; no migrations directory set")
+
+		return nil
+	}
+
+	if err := s.Migrate(); err != nil {
+		return fmt.Errorf("running SQLite migrations: %w", err)
+	}
+
+	return nil
+}
+
+// HealthCheck implements goboot.HealthChecker.
+func (s *SQLite) HealthCheck(ctx context.Context) error {
+	if err := s.DB.PingContext(ctx); err != nil {
+		return fmt.Errorf("%w: %v", errUnavailable, err)
+	}
+
+	return nil
+}
+
+func (s *SQLite) Close() error {
+	if err := s.DB.Close(); err != nil {
+		return fmt.Errorf("closing %s service: %w", s.Name(), err)
+	}
+
+	return nil
+}
@@ -0,0 +1,58 @@
+package sqliteboot_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/nielskrijger/goboot"
+	"github.com/nielskrijger/goboot/sqliteboot"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSQLite_Success(t *testing.T) {
+	s := &sqliteboot.SQLite{MigrationsDir: "./testdata/migrations"}
+	assert.Nil(t, s.Configure(goboot.NewAppEnv("./testdata", "valid")))
+	assert.Nil(t, s.Init())
+	assert.Nil(t, s.HealthCheck(context.Background()))
+
+	var count int
+	assert.Nil(t, s.DB.Get(&count, "SELECT COUNT(*) FROM test_table"))
+	assert.Equal(t, 1, count)
+
+	assert.Nil(t, s.Close())
+}
+
+func TestSQLite_MigrateCommand(t *testing.T) {
+	s := &sqliteboot.SQLite{MigrationsDir: "./testdata/migrations"}
+	assert.Nil(t, s.Configure(goboot.NewAppEnv("./testdata", "valid")))
+	assert.Nil(t, s.RunMigrateCommand([]string{"up"}))
+	assert.Nil(t, s.RunMigrateCommand([]string{"down", "1"}))
+
+	var count int
+	assert.Nil(t, s.DB.Get(&count, "SELECT COUNT(*) FROM test_table"))
+	assert.Equal(t, 0, count)
+
+	var status strings.Builder
+	assert.Nil(t, s.PrintMigrationStatus(&status))
+	assert.Equal(t, "VERSION  NAME          STATUS\n"+
+		"1        create_table  applied\n"+
+		"2        insert_data   pending\n", status.String())
+
+	assert.Nil(t, s.RunMigrateCommand([]string{"to", "0"}))
+	assert.EqualError(t, s.RunMigrateCommand([]string{"sideways"}),
+		"usage: migrate up|down [steps]|to <version>|status")
+	assert.Nil(t, s.Close())
+}
+
+func TestSQLite_ErrorMissingConfig(t *testing.T) {
+	s := &sqliteboot.SQLite{}
+	err := s.Configure(goboot.NewAppEnv("./testdata", ""))
+	assert.EqualError(t, err, "missing SQLite configuration")
+}
+
+func TestSQLite_ErrorMissingDSN(t *testing.T) {
+	s := &sqliteboot.SQLite{}
+	err := s.Configure(goboot.NewAppEnv("./testdata", "no-dsn"))
+	assert.EqualError(t, err, "config \"sqlite.dsn\" is required")
+}
@@ -0,0 +1,4 @@
+CREATE TABLE IF NOT EXISTS test_table (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL UNIQUE
+);
@@ -0,0 +1 @@
+-- 1_create_users
@@ -0,0 +1 @@
+-- 2_add_email
@@ -0,0 +1 @@
+-- 3_create_orders
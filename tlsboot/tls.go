@@ -0,0 +1,401 @@
+// Package tlsboot loads TLS certificates from files and reloads them when
+// they're renewed, see TLS.
+package tlsboot
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/nielskrijger/goboot"
+	"github.com/rs/zerolog"
+	"google.golang.org/grpc/credentials"
+)
+
+const (
+	// ClientAuthRequire requires clients to present a certificate signed by
+	// the CA.
+	ClientAuthRequire = "require"
+
+	// ClientAuthVerifyIfGiven verifies client certificates if presented.
+	ClientAuthVerifyIfGiven = "verifyIfGiven"
+
+	// ClientAuthNone doesn't request client certificates.
+	ClientAuthNone = "none"
+)
+
+var (
+	errMissingConfig     = errors.New("missing \"tls\" configuration")
+	errMissingFiles      = errors.New("requires a certFile or caFile")
+	errMissingKeyFile    = errors.New("requires a keyFile with certFile")
+	errInvalidClientAuth = errors.New("clientAuth must be \"require\", \"verifyIfGiven\" or \"none\"")
+	errMissingClientCA   = errors.New("requires a caFile")
+	errInvalidCA         = errors.New("no certificates found in CA file")
+	errNoCertificate     = errors.New("no certificate configured")
+	errNoServerName      = errors.New("TLS client requires a server name")
+)
+
+type TLSConfig struct {
+	// Paths of the PEM encoded certificate and private key, e.g. the
+	// "tls.crt" and "tls.key" of a cert-manager secret volume.
+	CertFile string `yaml:"certFile"`
+	KeyFile  string `yaml:"keyFile"`
+
+	// Path of the PEM encoded CA certificates verifying peers, e.g.
+	// "ca.crt". Defaults to the system root CAs for clients.
+	CAFile string `yaml:"caFile"`
+
+	// Client certificates required by servers: "require", "verifyIfGiven"
+	// or "none". Default is "require" when CAFile is set, both "require"
+	// and "verifyIfGiven" need a CAFile.
+	ClientAuth string `yaml:"clientAuth"`
+}
+
+// TLS implements the AppService interface and loads the certificates
+// configured in "tls", or "tls.{Namespace}":
+//
+//	tls:
+//	  certFile: /etc/tls/tls.crt
+//	  keyFile: /etc/tls/tls.key
+//	  caFile: /etc/tls/ca.crt
+//
+// The files are reloaded when they change, e.g. when cert-manager renews the
+// certificate. The configs returned by ServerConfig and ClientConfig always
+// use the latest certificates, so rotation doesn't require restarts:
+//
+//	srv := &http.Server{TLSConfig: certs.ServerConfig()}
+//	srv.ListenAndServeTLS("", "")
+//
+//	env.AddService(&redisboot.Redis{TLSConfig: certs.ClientConfig("")})
+//
+// Existing connections keep using the certificate they were established
+// with.
+type TLS struct {
+	// Namespace reads the config from "tls.{Namespace}" instead of "tls",
+	// e.g. for separate server and client certificates.
+	Namespace string
+
+	config  *TLSConfig
+	log     zerolog.Logger
+	watcher *watcher
+
+	mu       sync.RWMutex
+	cert     *tls.Certificate
+	pool     *x509.CertPool
+	onReload []func()
+}
+
+func (t *TLS) Name() string {
+	if t.Namespace != "" {
+		return "TLS " + t.Namespace
+	}
+
+	return "TLS"
+}
+
+func (t *TLS) configKey() string {
+	if t.Namespace != "" {
+		return "tls." + t.Namespace
+	}
+
+	return "tls"
+}
+
+// Configure loads the certificates, so the configs can be passed to services
+// configured afterwards.
+func (t *TLS) Configure(env *goboot.AppEnv) error {
+	t.log = env.Log
+	t.config = &TLSConfig{}
+
+	key := t.configKey()
+
+	if !env.Config.IsSet(key) {
+		if t.Namespace != "" {
+			return fmt.Errorf("%w %q", errMissingConfig, key)
+		}
+
+		return errMissingConfig
+	}
+
+	if err := env.Config.Sub(key).Unmarshal(t.config); err != nil {
+		return fmt.Errorf("parsing %s configuration: %w", key, err)
+	}
+
+	if err := t.validate(); err != nil {
+		return fmt.Errorf("config %q %w", key, err)
+	}
+
+	return t.load()
+}
+
+func (t *TLS) validate() error {
+	if t.config.CertFile == "" && t.config.CAFile == "" {
+		return errMissingFiles
+	}
+
+	if t.config.CertFile != "" && t.config.KeyFile == "" {
+		return errMissingKeyFile
+	}
+
+	if t.config.ClientAuth == "" {
+		t.config.ClientAuth = ClientAuthNone
+		if t.config.CAFile != "" {
+			t.config.ClientAuth = ClientAuthRequire
+		}
+	}
+
+	switch t.config.ClientAuth {
+	case ClientAuthRequire, ClientAuthVerifyIfGiven:
+		// without a CA client certificates would be verified against the
+		// system roots, accepting any publicly trusted certificate
+		if t.config.CAFile == "" {
+			return fmt.Errorf("clientAuth %q %w", t.config.ClientAuth, errMissingClientCA)
+		}
+
+		return nil
+	case ClientAuthNone:
+		return nil
+	default:
+		return errInvalidClientAuth
+	}
+}
+
+// load reads the certificate files, keeping the current certificates if
+// they can't be read.
+func (t *TLS) load() error {
+	var (
+		cert *tls.Certificate
+		pool *x509.CertPool
+	)
+
+	if t.config.CertFile != "" {
+		c, err := tls.LoadX509KeyPair(t.config.CertFile, t.config.KeyFile)
+		if err != nil {
+			return fmt.Errorf("loading TLS certificate: %w", err)
+		}
+
+		if c.Leaf, err = x509.ParseCertificate(c.Certificate[0]); err != nil {
+			return fmt.Errorf("parsing TLS certificate: %w", err)
+		}
+
+		cert = &c
+	}
+
+	if t.config.CAFile != "" {
+		pem, err := os.ReadFile(t.config.CAFile)
+		if err != nil {
+			return fmt.Errorf("reading TLS CA file: %w", err)
+		}
+
+		pool = x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return errInvalidCA
+		}
+	}
+
+	t.mu.Lock()
+	t.cert = cert
+	t.pool = pool
+	t.mu.Unlock()
+
+	if cert != nil {
+		t.log.Info().Msgf("loaded TLS certificate %q valid until %s",
+			cert.Leaf.Subject.CommonName, cert.Leaf.NotAfter.Format(time.RFC3339))
+	}
+
+	return nil
+}
+
+// Init watches the certificate files for changes.
+func (t *TLS) Init() error {
+	w, err := t.watch()
+	if err != nil {
+		return err
+	}
+
+	t.watcher = w
+
+	return nil
+}
+
+// OnReload calls fn after the certificates are reloaded, e.g. to close
+// long-lived connections using the old certificate.
+func (t *TLS) OnReload(fn func()) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.onReload = append(t.onReload, fn)
+}
+
+// reload loads the changed files and calls the OnReload callbacks.
+func (t *TLS) reload() {
+	if err := t.load(); err != nil {
+		// e.g. when only the certificate is written yet, retried on the next change
+		t.log.Error().Err(err).Msg("failed to reload TLS certificates")
+
+		return
+	}
+
+	t.mu.RLock()
+	callbacks := append([]func(){}, t.onReload...)
+	t.mu.RUnlock()
+
+	for _, fn := range callbacks {
+		fn()
+	}
+}
+
+// Certificate returns the current certificate, or nil if none is
+// configured.
+func (t *TLS) Certificate() *tls.Certificate {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return t.cert
+}
+
+// CertPool returns the current CA certificates, or nil if none are
+// configured.
+func (t *TLS) CertPool() *x509.CertPool {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return t.pool
+}
+
+func (t *TLS) certificate() (*tls.Certificate, error) {
+	if cert := t.Certificate(); cert != nil {
+		return cert, nil
+	}
+
+	return nil, errNoCertificate
+}
+
+// ServerConfig returns the config of TLS servers, e.g. of an http.Server,
+// using the current certificate and verifying client certificates using
+// the current CA as configured by ClientAuth.
+//
+// The config doesn't use GetConfigForClient, so settings added by servers
+// such as the "h2" NextProtos of http.Server and gRPC are used.
+func (t *TLS) ServerConfig() *tls.Config {
+	// client certificates are verified by VerifyConnection using the current
+	// CA, ClientCAs would keep using the CA of when it's created
+	clientAuth := tls.NoClientCert
+
+	switch t.config.ClientAuth {
+	case ClientAuthRequire:
+		clientAuth = tls.RequireAnyClientCert
+	case ClientAuthVerifyIfGiven:
+		clientAuth = tls.RequestClientCert
+	}
+
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ClientAuth: clientAuth,
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			return t.certificate()
+		},
+		VerifyConnection: t.verifyClient,
+	}
+}
+
+// ClientConfig returns the config of TLS clients presenting the current
+// certificate, if any, and verifying servers using the current CA. The
+// serverName is verified instead of the host connected to if not empty.
+func (t *TLS) ClientConfig(serverName string) *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ServerName: serverName,
+		GetClientCertificate: func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			if cert := t.Certificate(); cert != nil {
+				return cert, nil
+			}
+
+			// no certificate, the server decides if that's allowed
+			return &tls.Certificate{}, nil
+		},
+		// the server certificate is verified by VerifyConnection using the
+		// current CA, RootCAs would keep using the CA of when it's created
+		InsecureSkipVerify: true, //nolint:gosec
+		VerifyConnection:   t.verifyServer,
+	}
+}
+
+// verifyServer verifies the server certificate chain and name using the
+// current CA, or the system roots if no CA is configured.
+func (t *TLS) verifyServer(cs tls.ConnectionState) error {
+	if cs.ServerName == "" {
+		return errNoServerName
+	}
+
+	if len(cs.PeerCertificates) == 0 {
+		return errNoCertificate
+	}
+
+	err := t.verifyChain(cs.PeerCertificates, x509.VerifyOptions{
+		DNSName:   cs.ServerName,
+		KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	})
+	if err != nil {
+		return fmt.Errorf("verifying server certificate: %w", err)
+	}
+
+	return nil
+}
+
+// verifyClient verifies the client certificate chain using the current CA,
+// if a certificate is presented. ClientAuth ensures it's presented when
+// required.
+func (t *TLS) verifyClient(cs tls.ConnectionState) error {
+	if len(cs.PeerCertificates) == 0 {
+		return nil
+	}
+
+	err := t.verifyChain(cs.PeerCertificates, x509.VerifyOptions{
+		KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	})
+	if err != nil {
+		return fmt.Errorf("verifying client certificate: %w", err)
+	}
+
+	return nil
+}
+
+// verifyChain verifies the leaf of the peer certificates is signed by the
+// current CA, the other certificates are used as intermediates.
+func (t *TLS) verifyChain(certs []*x509.Certificate, opts x509.VerifyOptions) error {
+	opts.Roots = t.CertPool()
+	opts.Intermediates = x509.NewCertPool()
+
+	for _, cert := range certs[1:] {
+		opts.Intermediates.AddCert(cert)
+	}
+
+	_, err := certs[0].Verify(opts)
+
+	return err //nolint:wrapcheck
+}
+
+// ServerCredentials returns the transport credentials of gRPC servers, see
+// ServerConfig.
+func (t *TLS) ServerCredentials() credentials.TransportCredentials {
+	return credentials.NewTLS(t.ServerConfig())
+}
+
+// ClientCredentials returns the transport credentials of gRPC clients, see
+// ClientConfig.
+func (t *TLS) ClientCredentials(serverName string) credentials.TransportCredentials {
+	return credentials.NewTLS(t.ClientConfig(serverName))
+}
+
+func (t *TLS) Close() error {
+	if t.watcher != nil {
+		return t.watcher.close()
+	}
+
+	return nil
+}
@@ -0,0 +1,331 @@
+package tlsboot_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/nielskrijger/goboot"
+	"github.com/nielskrijger/goboot/tlsboot"
+	"github.com/stretchr/testify/assert"
+)
+
+type testCert struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	der  []byte
+}
+
+// newCert returns a certificate signed by parent, or a self-signed CA
+// certificate if parent is nil.
+func newCert(t *testing.T, cn string, parent *testCert) *testCert {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(t, err)
+
+	serial, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	assert.Nil(t, err)
+
+	tmpl := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+
+	signer, signerKey := tmpl, key
+
+	if parent == nil {
+		tmpl.IsCA = true
+		tmpl.BasicConstraintsValid = true
+		tmpl.KeyUsage = x509.KeyUsageCertSign
+	} else {
+		tmpl.DNSNames = []string{"localhost"}
+		tmpl.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth}
+		signer, signerKey = parent.cert, parent.key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, signer, &key.PublicKey, signerKey)
+	assert.Nil(t, err)
+
+	cert, err := x509.ParseCertificate(der)
+	assert.Nil(t, err)
+
+	return &testCert{cert: cert, key: key, der: der}
+}
+
+// writeFiles writes the certificate files the way Kubernetes updates secret
+// volumes: into a new directory that replaces the "..data" symlink.
+func writeFiles(t *testing.T, dir string, ca *testCert, cert *testCert) {
+	t.Helper()
+
+	data, err := os.MkdirTemp(dir, "..data_")
+	assert.Nil(t, err)
+
+	write := func(name string, typ string, b []byte) {
+		assert.Nil(t, os.WriteFile(filepath.Join(data, name), pem.EncodeToMemory(&pem.Block{Type: typ, Bytes: b}), 0o600))
+		_ = os.Symlink(filepath.Join("..data", name), filepath.Join(dir, name))
+	}
+
+	write("ca.crt", "CERTIFICATE", ca.der)
+
+	if cert != nil {
+		key, err := x509.MarshalECPrivateKey(cert.key)
+		assert.Nil(t, err)
+
+		write("tls.crt", "CERTIFICATE", cert.der)
+		write("tls.key", "EC PRIVATE KEY", key)
+	}
+
+	link := filepath.Join(dir, "..data_tmp")
+	assert.Nil(t, os.Symlink(filepath.Base(data), link))
+	assert.Nil(t, os.Rename(link, filepath.Join(dir, "..data")))
+}
+
+func newTLS(t *testing.T, namespace string, dir string, withCert bool, settings ...string) *tlsboot.TLS {
+	t.Helper()
+
+	key := "tls." + namespace
+
+	env := goboot.NewAppEnv("./testdata", "")
+
+	for i := 0; i+1 < len(settings); i += 2 {
+		env.Config.Set(key+"."+settings[i], settings[i+1])
+	}
+
+	env.Config.Set(key+".caFile", filepath.Join(dir, "ca.crt"))
+
+	if withCert {
+		env.Config.Set(key+".certFile", filepath.Join(dir, "tls.crt"))
+		env.Config.Set(key+".keyFile", filepath.Join(dir, "tls.key"))
+	}
+
+	s := &tlsboot.TLS{Namespace: namespace}
+	assert.Nil(t, s.Configure(env))
+	assert.Nil(t, s.Init())
+
+	t.Cleanup(func() { _ = s.Close() })
+
+	return s
+}
+
+// listen starts a TLS server writing "ok" to each connection.
+func listen(t *testing.T, config *tls.Config) string {
+	t.Helper()
+
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", config)
+	assert.Nil(t, err)
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+
+			go func() {
+				defer conn.Close()
+
+				_, _ = conn.Write([]byte("ok"))
+			}()
+		}
+	}()
+
+	t.Cleanup(func() { _ = ln.Close() })
+
+	return ln.Addr().String()
+}
+
+// connect returns the common name of the server certificate.
+func connect(addr string, config *tls.Config) (string, error) {
+	conn, err := tls.Dial("tcp", addr, config)
+	if err != nil {
+		return "", err
+	}
+
+	defer conn.Close()
+
+	// client certificates are verified after the TLS 1.3 handshake
+	if _, err := io.ReadAll(conn); err != nil {
+		return "", err
+	}
+
+	return conn.ConnectionState().PeerCertificates[0].Subject.CommonName, nil
+}
+
+func TestTLS_ReloadsCertificate(t *testing.T) {
+	ca := newCert(t, "ca", nil)
+	serverDir, clientDir := t.TempDir(), t.TempDir()
+	writeFiles(t, serverDir, ca, newCert(t, "server-1", ca))
+	writeFiles(t, clientDir, ca, newCert(t, "client", ca))
+
+	server := newTLS(t, "server", serverDir, true)
+	client := newTLS(t, "client", clientDir, true)
+	addr := listen(t, server.ServerConfig())
+
+	cn, err := connect(addr, client.ClientConfig("localhost"))
+	assert.Nil(t, err)
+	assert.Equal(t, "server-1", cn)
+
+	reloaded := make(chan struct{}, 1)
+	server.OnReload(func() { reloaded <- struct{}{} })
+
+	writeFiles(t, serverDir, ca, newCert(t, "server-2", ca))
+
+	select {
+	case <-reloaded:
+	case <-time.After(5 * time.Second):
+		t.Fatal("certificate not reloaded")
+	}
+
+	cn, err = connect(addr, client.ClientConfig("localhost"))
+	assert.Nil(t, err)
+	assert.Equal(t, "server-2", cn)
+	assert.Equal(t, "server-2", server.Certificate().Leaf.Subject.CommonName)
+}
+
+func TestTLS_ReloadsCA(t *testing.T) {
+	ca1, ca2 := newCert(t, "ca-1", nil), newCert(t, "ca-2", nil)
+	serverDir, clientDir := t.TempDir(), t.TempDir()
+	writeFiles(t, serverDir, ca1, newCert(t, "server", ca2))
+	writeFiles(t, clientDir, ca1, nil)
+
+	server := newTLS(t, "server", serverDir, true, "clientAuth", "none")
+	client := newTLS(t, "client", clientDir, false)
+	addr := listen(t, server.ServerConfig())
+
+	_, err := connect(addr, client.ClientConfig("localhost"))
+	assert.ErrorContains(t, err, "verifying server certificate: x509: certificate signed by unknown authority")
+
+	writeFiles(t, clientDir, ca2, nil)
+
+	assert.Eventually(t, func() bool {
+		_, err := connect(addr, client.ClientConfig("localhost"))
+
+		return err == nil
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestTLS_NegotiatesHTTP2(t *testing.T) {
+	ca := newCert(t, "ca", nil)
+	serverDir, clientDir := t.TempDir(), t.TempDir()
+	writeFiles(t, serverDir, ca, newCert(t, "server", ca))
+	writeFiles(t, clientDir, ca, newCert(t, "client", ca))
+
+	server := newTLS(t, "server", serverDir, true)
+	client := newTLS(t, "client", clientDir, true)
+
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.TLS.NegotiatedProtocol))
+	}))
+	srv.EnableHTTP2 = true
+	srv.TLS = server.ServerConfig()
+	srv.StartTLS()
+	defer srv.Close()
+
+	httpClient := &http.Client{Transport: &http.Transport{
+		TLSClientConfig:   client.ClientConfig("localhost"),
+		ForceAttemptHTTP2: true,
+	}}
+
+	res, err := httpClient.Get(srv.URL)
+	assert.Nil(t, err)
+
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+	assert.Nil(t, err)
+	assert.Equal(t, "HTTP/2.0", res.Proto)
+	assert.Equal(t, "h2", string(body))
+}
+
+func TestTLS_ErrorUnknownClientCA(t *testing.T) {
+	ca1, ca2 := newCert(t, "ca-1", nil), newCert(t, "ca-2", nil)
+	serverDir, clientDir := t.TempDir(), t.TempDir()
+	writeFiles(t, serverDir, ca1, newCert(t, "server", ca1))
+	writeFiles(t, clientDir, ca1, newCert(t, "client", ca2))
+
+	server := newTLS(t, "server", serverDir, true)
+	client := newTLS(t, "client", clientDir, true)
+	addr := listen(t, server.ServerConfig())
+
+	_, err := connect(addr, client.ClientConfig("localhost"))
+	assert.NotNil(t, err)
+}
+
+func TestTLS_ErrorServerName(t *testing.T) {
+	ca := newCert(t, "ca", nil)
+	serverDir, clientDir := t.TempDir(), t.TempDir()
+	writeFiles(t, serverDir, ca, newCert(t, "server", ca))
+	writeFiles(t, clientDir, ca, newCert(t, "client", ca))
+
+	server := newTLS(t, "server", serverDir, true)
+	client := newTLS(t, "client", clientDir, true)
+	addr := listen(t, server.ServerConfig())
+
+	_, err := connect(addr, client.ClientConfig("example.com"))
+	assert.ErrorContains(t, err, "certificate is valid for localhost, not example.com")
+}
+
+func TestTLS_ErrorMissingClientCertificate(t *testing.T) {
+	ca := newCert(t, "ca", nil)
+	serverDir, clientDir := t.TempDir(), t.TempDir()
+	writeFiles(t, serverDir, ca, newCert(t, "server", ca))
+	writeFiles(t, clientDir, ca, nil)
+
+	server := newTLS(t, "server", serverDir, true)
+	client := newTLS(t, "client", clientDir, false)
+	addr := listen(t, server.ServerConfig())
+
+	_, err := connect(addr, client.ClientConfig("localhost"))
+	assert.ErrorContains(t, err, "certificate required")
+}
+
+func TestTLS_ErrorMissingConfig(t *testing.T) {
+	s := &tlsboot.TLS{Namespace: "unknown"}
+	err := s.Configure(goboot.NewAppEnv("./testdata", ""))
+	assert.EqualError(t, err, `missing "tls" configuration "tls.unknown"`)
+}
+
+func TestTLS_ErrorInvalidConfig(t *testing.T) {
+	s := &tlsboot.TLS{}
+	err := s.Configure(goboot.NewAppEnv("./testdata", "invalid"))
+	assert.EqualError(t, err, `config "tls" clientAuth must be "require", "verifyIfGiven" or "none"`)
+}
+
+func TestTLS_ErrorClientAuthWithoutCA(t *testing.T) {
+	for _, clientAuth := range []string{tlsboot.ClientAuthRequire, tlsboot.ClientAuthVerifyIfGiven} {
+		env := goboot.NewAppEnv("./testdata", "")
+		env.Config.Set("tls.certFile", "tls.crt")
+		env.Config.Set("tls.keyFile", "tls.key")
+		env.Config.Set("tls.clientAuth", clientAuth)
+
+		s := &tlsboot.TLS{}
+		err := s.Configure(env)
+		assert.EqualError(t, err, `config "tls" clientAuth "`+clientAuth+`" requires a caFile`)
+	}
+}
+
+func TestTLS_ErrorMissingFiles(t *testing.T) {
+	env := goboot.NewAppEnv("./testdata", "")
+	env.Config.Set("tls.certFile", "missing.crt")
+	env.Config.Set("tls.keyFile", "missing.key")
+	env.Config.Set("tls.clientAuth", tlsboot.ClientAuthNone)
+
+	s := &tlsboot.TLS{}
+	err := s.Configure(env)
+	assert.EqualError(t, err, "loading TLS certificate: open missing.crt: no such file or directory")
+}
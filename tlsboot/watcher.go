@@ -0,0 +1,82 @@
+package tlsboot
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// reloadDelay groups the events of updating multiple files, e.g. the
+// certificate and key, into a single reload.
+const reloadDelay = 100 * time.Millisecond
+
+type watcher struct {
+	fs   *fsnotify.Watcher
+	done chan struct{}
+}
+
+// watch reloads the certificates when a file in their directories changes.
+// Directories are watched instead of files, because Kubernetes updates
+// secret volumes by replacing a symlink.
+func (t *TLS) watch() (*watcher, error) {
+	fw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("watching TLS certificates: %w", err)
+	}
+
+	dirs := make(map[string]bool)
+
+	for _, file := range []string{t.config.CertFile, t.config.KeyFile, t.config.CAFile} {
+		if dir := filepath.Dir(file); file != "" && !dirs[dir] {
+			dirs[dir] = true
+
+			if err := fw.Add(dir); err != nil {
+				_ = fw.Close()
+
+				return nil, fmt.Errorf("watching TLS certificates in %q: %w", dir, err)
+			}
+		}
+	}
+
+	w := &watcher{fs: fw, done: make(chan struct{})}
+
+	go func() {
+		defer close(w.done)
+
+		var reload <-chan time.Time
+
+		for {
+			select {
+			case _, ok := <-fw.Events:
+				if !ok {
+					return
+				}
+
+				reload = time.After(reloadDelay)
+			case err, ok := <-fw.Errors:
+				if !ok {
+					return
+				}
+
+				t.log.Warn().Err(err).Msg("failed to watch TLS certificates")
+			case <-reload:
+				t.reload()
+			}
+		}
+	}()
+
+	return w, nil
+}
+
+func (w *watcher) close() error {
+	err := w.fs.Close()
+	<-w.done
+
+	if err != nil {
+		return fmt.Errorf("closing TLS certificates watcher: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,56 @@
+package tmplboot
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+var errAssetNotFound = errors.New("asset not found in manifest")
+
+// loadAssetManifest reads AssetManifest, which maps asset paths to content
+// hashes, e.g. {"css/app.css": "3f2a1b"}. Returns nil if not configured.
+func (l *Loader) loadAssetManifest() (map[string]string, error) {
+	if l.AssetManifest == "" {
+		return nil, nil //nolint:nilnil
+	}
+
+	b, err := os.ReadFile(l.AssetManifest)
+	if err != nil {
+		return nil, fmt.Errorf("reading asset manifest: %w", err)
+	}
+
+	var manifest map[string]string
+	if err := json.Unmarshal(b, &manifest); err != nil {
+		return nil, fmt.Errorf("parsing asset manifest %q: %w", l.AssetManifest, err)
+	}
+
+	return manifest, nil
+}
+
+// asset returns the "asset" template function resolving an asset path like
+// {{asset "css/app.css"}} against AssetsURL, e.g.
+// "https://cdn.example.com/css/app.css?v=3f2a1b". The content hash of the
+// manifest is appended for cache busting, assets missing from the manifest
+// return an error so Validate detects them.
+func (l *Loader) asset(manifest map[string]string) func(path string) (string, error) {
+	base := strings.TrimSuffix(l.AssetsURL, "/")
+
+	return func(path string) (string, error) {
+		path = strings.TrimPrefix(path, "/")
+		url := base + "/" + path
+
+		if manifest == nil {
+			return url, nil
+		}
+
+		hash, ok := manifest[path]
+		if !ok {
+			return "", fmt.Errorf("%w: %q", errAssetNotFound, path)
+		}
+
+		return url + "?v=" + hash, nil
+	}
+}
@@ -0,0 +1,42 @@
+package tmplboot_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/nielskrijger/goboot"
+	"github.com/nielskrijger/goboot/tmplboot"
+	"github.com/stretchr/testify/assert"
+)
+
+func newAssetsLoader(t *testing.T, manifest string) *tmplboot.Loader {
+	t.Helper()
+
+	env := goboot.NewAppEnv("./testdata", "")
+	env.Config.Set("templates.dir", "./testdata/assets")
+	env.Config.Set("templates.html", true)
+	env.Config.Set("templates.assetsUrl", "https://cdn.example.com/static/")
+	env.Config.Set("templates.assetManifest", manifest)
+
+	return newLoader(t, env)
+}
+
+func TestLoader_Asset(t *testing.T) {
+	l := newAssetsLoader(t, "./testdata/assets/manifest.json")
+
+	var buf strings.Builder
+	assert.Nil(t, l.Execute(&buf, "page", nil))
+	assert.Equal(t, `<link href="https://cdn.example.com/static/css/app.css?v=3f2a1b">`+
+		`<img src="https://cdn.example.com/static/img/logo.png?v=9c8d7e">`, buf.String())
+
+	l.Samples = map[string]any{"broken": nil}
+	assert.ErrorContains(t, l.Validate(), `asset not found in manifest: "img/missing.png"`)
+}
+
+func TestLoader_AssetWithoutManifest(t *testing.T) {
+	l := newAssetsLoader(t, "")
+
+	var buf strings.Builder
+	assert.Nil(t, l.Execute(&buf, "broken", nil))
+	assert.Equal(t, `<img src="https://cdn.example.com/static/img/missing.png">`, buf.String())
+}
@@ -0,0 +1,195 @@
+package tmplboot
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/vanng822/go-premailer/premailer"
+)
+
+// mjmlTimeout is the maximum duration of compiling MJML using MJMLCommand.
+const mjmlTimeout = 30 * time.Second
+
+var (
+	errMissingEmailBody   = errors.New("email template requires an HTML or text body")
+	errMissingMJMLCommand = errors.New("compiling MJML requires a command")
+)
+
+// EmailTemplate contains the subject, HTML body and text body templates of
+// an email, see LoadEmail.
+type EmailTemplate struct {
+	Name    string
+	Subject Template
+
+	// HTML or Text is nil if the email has no such body.
+	HTML Template
+	Text Template
+
+	// pipeline processes the rendered HTML body, e.g. to inline CSS
+	pipeline []func(html string) (string, error)
+
+	// generateText generates the text body from the HTML body
+	generateText bool
+
+	loader *Loader
+}
+
+// RenderedEmail is the result of EmailTemplate.Render, HTML or Text is empty
+// if the email has no such body.
+type RenderedEmail struct {
+	Subject string
+	HTML    string
+	Text    string
+}
+
+// LoadEmail returns the templates of email name in locale:
+//
+//	{name}.subject.txt
+//	{name}.html or {name}.mjml
+//	{name}.txt
+//
+// The subject is required, as is at least one of the bodies. Use an empty
+// locale for DefaultLocale, see LookupLocalized.
+//
+// An MJML body is compiled to HTML using MJMLCommand after rendering, which
+// is aborted after 30 seconds. When InlineCSS is set the CSS of the HTML body
+// is inlined after that. When GenerateText is set and the email has no text
+// body it is generated from the HTML body.
+func (l *Loader) LoadEmail(name string, locale string) (*EmailTemplate, error) {
+	if locale == "" {
+		locale = l.DefaultLocale
+	}
+
+	subject, err := l.LookupLocalized(name+".subject.txt", locale)
+	if err != nil {
+		return nil, fmt.Errorf("loading subject of email %q: %w", name, err)
+	}
+
+	email := &EmailTemplate{Name: name, Subject: subject, loader: l}
+
+	if t, err := l.LookupLocalized(name+".html", locale); err == nil {
+		email.HTML = t
+	} else if t, err := l.LookupLocalized(name+".mjml", locale); err == nil {
+		email.HTML = t
+		email.pipeline = append(email.pipeline, compileMJML(l.MJMLCommand))
+	}
+
+	if l.InlineCSS {
+		email.pipeline = append(email.pipeline, inlineCSS)
+	}
+
+	if t, err := l.LookupLocalized(name+".txt", locale); err == nil {
+		email.Text = t
+	}
+
+	if email.HTML == nil && email.Text == nil {
+		return nil, fmt.Errorf("%w: %q", errMissingEmailBody, name)
+	}
+
+	email.generateText = l.GenerateText && email.Text == nil
+
+	return email, nil
+}
+
+// Render renders the subject and bodies using data. Line breaks and
+// surrounding whitespace are removed from the subject.
+func (e *EmailTemplate) Render(data any) (*RenderedEmail, error) {
+	start := time.Now()
+	result, err := e.render(data)
+
+	if e.loader != nil {
+		e.loader.observe(e.Name, start, err)
+	}
+
+	return result, err
+}
+
+func (e *EmailTemplate) render(data any) (*RenderedEmail, error) {
+	var (
+		result RenderedEmail
+		buf    strings.Builder
+	)
+
+	if err := e.Subject.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("rendering subject of email %q: %w", e.Name, err)
+	}
+
+	result.Subject = strings.Join(strings.Fields(buf.String()), " ")
+
+	if e.HTML != nil {
+		buf.Reset()
+
+		if err := e.HTML.Execute(&buf, data); err != nil {
+			return nil, fmt.Errorf("rendering HTML body of email %q: %w", e.Name, err)
+		}
+
+		result.HTML = buf.String()
+
+		for _, process := range e.pipeline {
+			var err error
+			if result.HTML, err = process(result.HTML); err != nil {
+				return nil, fmt.Errorf("processing HTML body of email %q: %w", e.Name, err)
+			}
+		}
+	}
+
+	if e.Text != nil {
+		buf.Reset()
+
+		if err := e.Text.Execute(&buf, data); err != nil {
+			return nil, fmt.Errorf("rendering text body of email %q: %w", e.Name, err)
+		}
+
+		result.Text = buf.String()
+	} else if e.generateText {
+		result.Text = htmlToText(result.HTML)
+	}
+
+	return &result, nil
+}
+
+// compileMJML returns a pipeline step compiling MJML to HTML using command.
+func compileMJML(command string) func(string) (string, error) {
+	return func(mjml string) (string, error) {
+		args := strings.Fields(command)
+		if len(args) == 0 {
+			return "", errMissingMJMLCommand
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), mjmlTimeout)
+		defer cancel()
+
+		var stdout, stderr bytes.Buffer
+
+		cmd := exec.CommandContext(ctx, args[0], args[1:]...) //nolint:gosec
+		cmd.Stdin = strings.NewReader(mjml)
+		cmd.Stdout = &stdout
+		cmd.Stderr = &stderr
+
+		if err := cmd.Run(); err != nil {
+			return "", fmt.Errorf("compiling MJML: %w: %s", err, strings.TrimSpace(stderr.String()))
+		}
+
+		return stdout.String(), nil
+	}
+}
+
+// inlineCSS moves the CSS of <style> elements into style attributes.
+func inlineCSS(html string) (string, error) {
+	p, err := premailer.NewPremailerFromString(html, premailer.NewOptions())
+	if err != nil {
+		return "", fmt.Errorf("parsing HTML: %w", err)
+	}
+
+	html, err = p.Transform()
+	if err != nil {
+		return "", fmt.Errorf("inlining CSS: %w", err)
+	}
+
+	return html, nil
+}
@@ -0,0 +1,149 @@
+package tmplboot_test
+
+import (
+	"testing"
+
+	"github.com/nielskrijger/goboot"
+	"github.com/nielskrijger/goboot/tmplboot"
+	"github.com/stretchr/testify/assert"
+)
+
+func newEmailLoader(t *testing.T) *tmplboot.Loader {
+	t.Helper()
+
+	env := goboot.NewAppEnv("./testdata", "")
+	env.Config.Set("templates.dir", "./testdata/email")
+	env.Config.Set("templates.html", true)
+
+	l := newLoader(t, env)
+	assert.Nil(t, l.Load())
+
+	return l
+}
+
+func TestLoadEmail_Render(t *testing.T) {
+	l := newEmailLoader(t)
+
+	email, err := l.LoadEmail("signup", "")
+	assert.Nil(t, err)
+
+	result, err := email.Render(welcomeData{Name: "<John>"})
+	assert.Nil(t, err)
+	assert.Equal(t, &tmplboot.RenderedEmail{
+		Subject: "Welcome <John>",
+		HTML:    "<p>Hello &lt;John&gt;</p>",
+		Text:    "Hello <John>",
+	}, result)
+}
+
+func TestLoadEmail_Localized(t *testing.T) {
+	l := newEmailLoader(t)
+
+	email, err := l.LoadEmail("signup", "nl")
+	assert.Nil(t, err)
+
+	result, err := email.Render(welcomeData{Name: "Jan"})
+	assert.Nil(t, err)
+	assert.Equal(t, &tmplboot.RenderedEmail{
+		Subject: "Welkom Jan",
+		HTML:    "<p>Hallo Jan</p>",
+		Text:    "Hello Jan",
+	}, result)
+}
+
+func TestLoadEmail_ErrorMissingBody(t *testing.T) {
+	l := newEmailLoader(t)
+
+	_, err := l.LoadEmail("reset", "")
+	assert.EqualError(t, err, `email template requires an HTML or text body: "reset"`)
+}
+
+func TestLoadEmail_InlineCSS(t *testing.T) {
+	l := newEmailLoader(t)
+	l.InlineCSS = true
+
+	email, err := l.LoadEmail("styled", "")
+	assert.Nil(t, err)
+
+	result, err := email.Render(welcomeData{Name: "John"})
+	assert.Nil(t, err)
+	assert.Contains(t, result.HTML, `<p style="color:red">Hello John</p>`)
+	assert.NotContains(t, result.HTML, "<style>")
+}
+
+func TestLoadEmail_MJML(t *testing.T) {
+	l := newEmailLoader(t)
+	l.MJMLCommand = "cat" // outputs the rendered MJML as is
+
+	email, err := l.LoadEmail("promo", "")
+	assert.Nil(t, err)
+
+	result, err := email.Render(welcomeData{Name: "<John>"})
+	assert.Nil(t, err)
+	assert.Equal(t, "<mjml><mj-body><mj-text>Hello &lt;John&gt;</mj-text></mj-body></mjml>", result.HTML)
+}
+
+func TestLoadEmail_MJMLEscapesData(t *testing.T) {
+	env := goboot.NewAppEnv("./testdata", "")
+	env.Config.Set("templates.dir", "./testdata/email")
+	env.Config.Set("templates.mjmlCommand", "cat")
+
+	// MJML is parsed using html/template even if config "templates.html" isn't set
+	email, err := newLoader(t, env).LoadEmail("promo", "")
+	assert.Nil(t, err)
+
+	result, err := email.Render(welcomeData{Name: "<John>"})
+	assert.Nil(t, err)
+	assert.Equal(t, "<mjml><mj-body><mj-text>Hello &lt;John&gt;</mj-text></mj-body></mjml>", result.HTML)
+}
+
+func TestLoadEmail_ErrorBlankMJMLCommand(t *testing.T) {
+	l := newEmailLoader(t)
+	l.MJMLCommand = " "
+
+	email, err := l.LoadEmail("promo", "")
+	assert.Nil(t, err)
+
+	_, err = email.Render(welcomeData{Name: "John"})
+	assert.EqualError(t, err, `processing HTML body of email "promo": compiling MJML requires a command`)
+}
+
+func TestLoadEmail_ErrorMJMLCommand(t *testing.T) {
+	l := newEmailLoader(t)
+	l.MJMLCommand = "false"
+
+	email, err := l.LoadEmail("promo", "")
+	assert.Nil(t, err)
+
+	_, err = email.Render(welcomeData{Name: "John"})
+	assert.ErrorContains(t, err, `processing HTML body of email "promo": compiling MJML: exit status 1`)
+}
+
+func TestLoadEmail_GenerateText(t *testing.T) {
+	l := newEmailLoader(t)
+	l.GenerateText = true
+
+	email, err := l.LoadEmail("newsletter", "")
+	assert.Nil(t, err)
+
+	result, err := email.Render(welcomeData{Name: "John"})
+	assert.Nil(t, err)
+	assert.Equal(t, "Hello John\n\n"+
+		"This week: new features.\n\n"+
+		"- Faster search\n"+
+		"- Dark mode\n\n"+
+		"Read more (https://example.com/news) or visit https://example.com.\n"+
+		"Thanks!", result.Text)
+}
+
+func TestLoadEmail_GenerateTextKeepsTextBody(t *testing.T) {
+	l := newEmailLoader(t)
+	l.GenerateText = true
+
+	email, err := l.LoadEmail("signup", "")
+	assert.Nil(t, err)
+
+	result, err := email.Render(welcomeData{Name: "John"})
+	assert.Nil(t, err)
+	assert.Equal(t, "Hello John", result.Text)
+}
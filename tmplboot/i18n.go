@@ -0,0 +1,169 @@
+package tmplboot
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/nicksnyder/go-i18n/v2/i18n"
+	"golang.org/x/text/language"
+	"gopkg.in/yaml.v3"
+)
+
+const defaultLocale = "en"
+
+// loadMessages loads the translations in {Dir}/locales. Message files are
+// named by locale, e.g. "nl.yaml" or "active.nl.json", see
+// https://github.com/nicksnyder/go-i18n.
+func (l *Loader) loadMessages() (*i18n.Bundle, error) {
+	tag, err := language.Parse(l.DefaultLocale)
+	if err != nil {
+		return nil, fmt.Errorf("parsing default locale %q: %w", l.DefaultLocale, err)
+	}
+
+	bundle := i18n.NewBundle(tag)
+	bundle.RegisterUnmarshalFunc("yaml", yaml.Unmarshal)
+	bundle.RegisterUnmarshalFunc("yml", yaml.Unmarshal)
+
+	files, err := l.files(LocalesDir)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, file := range files {
+		if _, err := bundle.LoadMessageFile(file); err != nil {
+			return nil, fmt.Errorf("loading messages %q: %w", filepath.Base(file), err)
+		}
+	}
+
+	return bundle, nil
+}
+
+// locales returns DefaultLocale followed by the locales of the message files.
+func (l *Loader) locales(bundle *i18n.Bundle) []string {
+	locales := []string{l.DefaultLocale}
+
+	for _, tag := range bundle.LanguageTags() {
+		if locale := tag.String(); locale != l.DefaultLocale {
+			locales = append(locales, locale)
+		}
+	}
+
+	return locales
+}
+
+// translate returns the "t" template function translating a message id,
+// e.g. {{t "welcome_title" .}}. The optional argument is the message template
+// data; its "Count" value selects the plural form when it's a map.
+func translate(localizer *i18n.Localizer) func(id string, data ...any) (string, error) {
+	return func(id string, data ...any) (string, error) {
+		cfg := &i18n.LocalizeConfig{MessageID: id}
+
+		if len(data) > 0 {
+			cfg.TemplateData = data[0]
+
+			if m, ok := data[0].(map[string]any); ok {
+				cfg.PluralCount = m["Count"]
+			}
+		}
+
+		msg, err := localizer.Localize(cfg)
+		if err != nil {
+			return "", fmt.Errorf("translating %q: %w", id, err)
+		}
+
+		return msg, nil
+	}
+}
+
+// LookupLocalized returns the template with name in locale, e.g. "nl", see
+// LoadLocalized.
+func (l *Loader) LookupLocalized(name string, locale string) (Template, error) {
+	return l.LoadLocalized(name, locale)
+}
+
+// LoadLocalized returns the template with name for the first supported
+// locale of the fallback chain of locales, e.g. "nl-BE", "nl" and
+// DefaultLocale for "nl-BE".
+//
+// A template file for a locale like welcome.nl.html takes precedence over
+// welcome.html, both when referred to as "welcome" or "welcome.html". The
+// template uses the translations of the first locale in the chain that has
+// messages.
+func (l *Loader) LoadLocalized(name string, locales ...string) (Template, error) {
+	cache, err := l.cache()
+	if err != nil {
+		return nil, err
+	}
+
+	chain := l.fallbackChain(locales)
+
+	templates := cache[l.DefaultLocale]
+
+	for _, locale := range chain {
+		if t, ok := cache[locale]; ok {
+			templates = t
+
+			break
+		}
+	}
+
+	// welcome.nl and welcome.nl.html for both "welcome" and "welcome.html"
+	ext := filepath.Ext(name)
+
+	for _, locale := range chain {
+		for _, localized := range []string{name + "." + locale, strings.TrimSuffix(name, ext) + "." + locale + ext} {
+			if t, ok := templates[localized]; ok {
+				return t, nil
+			}
+		}
+	}
+
+	if t, ok := templates[name]; ok {
+		return t, nil
+	}
+
+	return nil, fmt.Errorf("%w: %q", errTemplateNotFound, name)
+}
+
+// fallbackChain returns the locales followed by their parents and
+// DefaultLocale, e.g. "nl-BE", "nl", "fr" and "en" for "nl-BE" and "fr".
+func (l *Loader) fallbackChain(locales []string) []string {
+	var chain []string
+
+	seen := make(map[string]bool)
+	add := func(locale string) {
+		if !seen[locale] {
+			seen[locale] = true
+			chain = append(chain, locale)
+		}
+	}
+
+	for _, locale := range locales {
+		tag, err := language.Parse(locale)
+		if err != nil {
+			add(locale)
+
+			continue
+		}
+
+		for ; tag != language.Und; tag = tag.Parent() {
+			add(tag.String())
+		}
+	}
+
+	add(l.DefaultLocale)
+
+	return chain
+}
+
+// ExecuteLocalized renders the template with name in locale, see LookupLocalized.
+func (l *Loader) ExecuteLocalized(w io.Writer, name string, locale string, data any) error {
+	t, err := l.LookupLocalized(name, locale)
+	if err != nil {
+		return err
+	}
+
+	return l.execute(t, w, name, data)
+}
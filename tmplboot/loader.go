@@ -0,0 +1,601 @@
+// Package tmplboot loads text templates from disk, see Loader.
+package tmplboot
+
+import (
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/Masterminds/sprig/v3"
+	"github.com/nicksnyder/go-i18n/v2/i18n"
+	"github.com/nielskrijger/goboot"
+	"github.com/rs/zerolog"
+)
+
+const (
+	defaultTemplatesDir = "templates"
+	defaultMJMLCommand  = "mjml -i -s"
+)
+
+// Subdirectories of the templates directory.
+const (
+	TemplatesDir = "templates"
+	LayoutsDir   = "layouts"
+	PartialsDir  = "partials"
+	LocalesDir   = "locales"
+	SamplesDir   = "samples"
+)
+
+var errTemplateNotFound = errors.New("template not found")
+
+// Loader loads the templates in Dir:
+//
+//	{Dir}/templates/welcome.html
+//	{Dir}/templates/emails/billing/invoice.html
+//	{Dir}/layouts/*
+//	{Dir}/partials/*
+//	{Dir}/locales/*, see ExecuteLocalized
+//	{Dir}/samples/*, see Validate
+//
+// Every template is parsed together with all layouts and partials so it can
+// refer to them by file name, e.g. {{template "base.html" .}}. The template
+// name is its path without extension, e.g. "welcome" or
+// "emails/billing/invoice". Templates can be referred to by file name as
+// well, which is required when multiple files share a name like welcome.html
+// and welcome.txt.
+//
+// Layouts and partials in subdirectories matching the directory of a
+// template override those with the same file name in parent directories,
+// e.g. {Dir}/layouts/emails/base.html overrides {Dir}/layouts/base.html for
+// all templates in {Dir}/templates/emails.
+//
+// Besides the functions registered using Funcs, templates can use "t" to
+// translate, see ExecuteLocalized, "markdown" to render markdown like
+// {{markdown .Body}} to sanitized HTML, and "asset" to refer to static
+// assets, see AssetsURL.
+//
+// Templates are parsed using text/template unless HTML is set, in which case
+// .html and .htm templates are parsed using html/template. MJML templates are
+// always parsed using html/template.
+//
+// Templates are parsed once and cached until Invalidate is called. Load or
+// MustLoadAll pre-warms the cache, otherwise the templates are loaded on first
+// use. When DevMode is set the directories are watched and the cache
+// invalidated on every change, so templates can be edited without restarting
+// the app.
+type Loader struct {
+	// Dir defaults to config "templates.dir", or "templates" if not set.
+	Dir string
+
+	// DevMode defaults to config "templates.devMode".
+	DevMode bool
+
+	// Sprig adds the Sprig function library to all templates, e.g. "default",
+	// "trim", "date" and "dict", see https://masterminds.github.io/sprig/.
+	// Functions registered using Funcs take precedence. Defaults to config
+	// "templates.sprig".
+	Sprig bool
+
+	// HTML parses .html and .htm templates using html/template, which escapes
+	// data depending on its context to prevent injection of user-supplied
+	// data. Other templates such as plaintext email bodies are parsed using
+	// text/template. Defaults to config "templates.html".
+	HTML bool
+
+	// DefaultLocale is the locale of Execute and the fallback of
+	// ExecuteLocalized. Defaults to config "templates.defaultLocale", or "en"
+	// if not set.
+	DefaultLocale string
+
+	// InlineCSS moves the CSS of <style> elements in HTML email bodies into
+	// style attributes, as many email clients ignore stylesheets. Defaults to
+	// config "templates.inlineCSS".
+	InlineCSS bool
+
+	// MJMLCommand compiles rendered MJML email bodies to HTML, it reads MJML
+	// from stdin and writes HTML to stdout. Defaults to config
+	// "templates.mjmlCommand", or "mjml -i -s" if not set, which requires
+	// https://github.com/mjmlio/mjml to be installed.
+	MJMLCommand string
+
+	// GenerateText generates the text body of emails that only have an HTML
+	// body from the rendered HTML, keeping the URLs of links. Defaults to
+	// config "templates.generateText".
+	GenerateText bool
+
+	// Samples contains sample data by template name used by Validate, in
+	// addition to the files in {Dir}/samples.
+	Samples map[string]any
+
+	// AssetsURL is the base URL of the "asset" function, e.g. the URL of a
+	// CDN. Defaults to config "templates.assetsUrl", use an absolute URL for
+	// emails.
+	AssetsURL string
+
+	// AssetManifest is the path of a JSON file mapping asset paths to
+	// content hashes appended by the "asset" function, e.g.
+	// {"css/app.css": "3f2a1b"}. Defaults to config "templates.assetManifest".
+	AssetManifest string
+
+	funcs template.FuncMap
+	mu    sync.RWMutex
+
+	// loadMu ensures concurrent lookups after Invalidate load only once
+	loadMu sync.Mutex
+
+	// templates are the parsed templates by locale and name, nil when not
+	// loaded. They're stale when loaded differs from generation, which
+	// Invalidate increments.
+	templates  map[string]map[string]Template
+	loaded     uint64
+	generation uint64
+	watcher    *watcher
+	metrics    *renderMetrics
+	log        zerolog.Logger
+}
+
+// NewLoader creates a loader configured using env, call Load to load the
+// templates. Alternatively register the Loader as AppService, which loads
+// and validates the templates on Init.
+func NewLoader(env *goboot.AppEnv) (*Loader, error) {
+	l := &Loader{}
+	if err := l.Configure(env); err != nil {
+		return nil, err
+	}
+
+	return l, nil
+}
+
+func (l *Loader) Name() string {
+	return "Templates"
+}
+
+// Configure sets the fields that aren't set from config. Boolean fields are
+// set from config only when configured, so config can both enable and
+// disable them.
+func (l *Loader) Configure(env *goboot.AppEnv) error {
+	l.log = env.Log
+
+	if l.Dir == "" {
+		l.Dir = env.Config.GetString("templates.dir")
+	}
+
+	if l.Dir == "" {
+		l.Dir = defaultTemplatesDir
+	}
+
+	configureBool(env, "templates.devMode", &l.DevMode)
+	configureBool(env, "templates.sprig", &l.Sprig)
+	configureBool(env, "templates.html", &l.HTML)
+	configureBool(env, "templates.inlineCSS", &l.InlineCSS)
+	configureBool(env, "templates.generateText", &l.GenerateText)
+
+	if l.DefaultLocale == "" {
+		l.DefaultLocale = env.Config.GetString("templates.defaultLocale")
+	}
+
+	if l.DefaultLocale == "" {
+		l.DefaultLocale = defaultLocale
+	}
+
+	if l.MJMLCommand == "" {
+		l.MJMLCommand = env.Config.GetString("templates.mjmlCommand")
+	}
+
+	if l.MJMLCommand == "" {
+		l.MJMLCommand = defaultMJMLCommand
+	}
+
+	if l.AssetsURL == "" {
+		l.AssetsURL = env.Config.GetString("templates.assetsUrl")
+	}
+
+	if l.AssetManifest == "" {
+		l.AssetManifest = env.Config.GetString("templates.assetManifest")
+	}
+
+	return l.registerMetrics(env.Metrics)
+}
+
+// configureBool sets field to config key if it's set.
+func configureBool(env *goboot.AppEnv, key string, field *bool) {
+	if env.Config.IsSet(key) {
+		*field = env.Config.GetBool(key)
+	}
+}
+
+// Init loads the templates and validates them, see Validate, so broken
+// templates fail startup rather than the first request using them.
+func (l *Loader) Init() error {
+	if err := l.Load(); err != nil {
+		return err
+	}
+
+	return l.Validate()
+}
+
+// Funcs adds the functions to the function map of all templates, e.g. to
+// format currencies or dates. Must be called before Load.
+func (l *Loader) Funcs(funcs template.FuncMap) *Loader {
+	if l.funcs == nil {
+		l.funcs = template.FuncMap{}
+	}
+
+	for name, fn := range funcs {
+		l.funcs[name] = fn
+	}
+
+	return l
+}
+
+// Load loads all templates and starts watching them in DevMode.
+func (l *Loader) Load() error {
+	if _, err := l.cache(); err != nil {
+		return err
+	}
+
+	if l.DevMode && l.watcher == nil {
+		w, err := l.watch()
+		if err != nil {
+			return err
+		}
+
+		l.watcher = w
+	}
+
+	return nil
+}
+
+// MustLoadAll is like Load but panics on error, e.g. to pre-warm the cache
+// in main.
+func (l *Loader) MustLoadAll() {
+	if err := l.Load(); err != nil {
+		panic(err)
+	}
+}
+
+// Invalidate clears the template cache, the templates are loaded from disk
+// again on next use. The previously loaded templates remain in use if
+// loading them again fails.
+func (l *Loader) Invalidate() {
+	l.mu.Lock()
+	l.generation++
+	l.mu.Unlock()
+}
+
+// cache returns the cached templates, loading them if needed. When reloading
+// fails the previous templates are kept and the error is logged.
+func (l *Loader) cache() (map[string]map[string]Template, error) {
+	templates, err := l.reload()
+	if err != nil {
+		if templates == nil {
+			return nil, err
+		}
+
+		l.log.Error().Err(err).Msg("failed to reload templates, keeping the previous templates")
+	}
+
+	return templates, nil
+}
+
+// reload returns the cached templates, loading them if needed. When reloading
+// fails it returns the previous templates, if any, along with the error.
+func (l *Loader) reload() (map[string]map[string]Template, error) {
+	l.mu.RLock()
+	templates, current := l.templates, l.loaded == l.generation
+	l.mu.RUnlock()
+
+	if templates != nil && current {
+		return templates, nil
+	}
+
+	l.loadMu.Lock()
+	defer l.loadMu.Unlock()
+
+	// another goroutine may have loaded the templates while waiting
+	l.mu.RLock()
+	templates, generation := l.templates, l.generation
+	current = l.loaded == generation
+	l.mu.RUnlock()
+
+	if templates != nil && current {
+		return templates, nil
+	}
+
+	loaded, err := l.load()
+	if err != nil {
+		if templates == nil {
+			return nil, err
+		}
+
+		loaded = templates
+	}
+
+	l.mu.Lock()
+	// discard the templates if invalidated while loading, they may be stale
+	if l.generation == generation {
+		l.templates = loaded
+		l.loaded = generation
+	}
+	l.mu.Unlock()
+
+	return loaded, err
+}
+
+// load parses all templates by locale and name.
+func (l *Loader) load() (map[string]map[string]Template, error) {
+	files, err := l.files(TemplatesDir)
+	if err != nil {
+		return nil, err
+	}
+
+	shared, err := l.sharedFiles(files)
+	if err != nil {
+		return nil, err
+	}
+
+	bundle, err := l.loadMessages()
+	if err != nil {
+		return nil, err
+	}
+
+	manifest, err := l.loadAssetManifest()
+	if err != nil {
+		return nil, err
+	}
+
+	templates := make(map[string]map[string]Template)
+
+	for _, locale := range l.locales(bundle) {
+		funcs := template.FuncMap{}
+		if l.Sprig {
+			funcs = sprig.TxtFuncMap()
+		}
+
+		funcs["markdown"] = markdown
+		funcs["asset"] = l.asset(manifest)
+
+		for name, fn := range l.funcs {
+			funcs[name] = fn
+		}
+
+		funcs["t"] = translate(i18n.NewLocalizer(bundle, locale, l.DefaultLocale))
+
+		if templates[locale], err = l.parseAll(files, shared, funcs); err != nil {
+			return nil, err
+		}
+	}
+
+	return templates, nil
+}
+
+// parseAll parses the template files with their layouts and partials using funcs.
+func (l *Loader) parseAll(files []string, shared map[string][]string, funcs template.FuncMap) (map[string]Template, error) {
+	var err error
+
+	templates := make(map[string]Template, len(files))
+
+	for _, file := range files {
+		ext := filepath.Ext(file)
+		name := strings.TrimSuffix(l.relPath(TemplatesDir, file), ext)
+
+		var t Template
+		if ext == ".mjml" || (l.HTML && (ext == ".html" || ext == ".htm")) {
+			t, err = parseHTML(name, file, shared[filepath.Dir(file)], funcs)
+		} else {
+			t, err = parseText(name, file, shared[filepath.Dir(file)], funcs)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		templates[name] = t
+		templates[name+ext] = t
+	}
+
+	return templates, nil
+}
+
+// Template is a parsed *text/template.Template or *html/template.Template.
+type Template interface {
+	Name() string
+	Execute(w io.Writer, data any) error
+}
+
+// Lookup returns the template with name in DefaultLocale.
+func (l *Loader) Lookup(name string) (Template, error) {
+	templates, err := l.cache()
+	if err != nil {
+		return nil, err
+	}
+
+	t, ok := templates[l.DefaultLocale][name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", errTemplateNotFound, name)
+	}
+
+	return t, nil
+}
+
+// Execute renders the template with name.
+func (l *Loader) Execute(w io.Writer, name string, data any) error {
+	t, err := l.Lookup(name)
+	if err != nil {
+		return err
+	}
+
+	return l.execute(t, w, name, data)
+}
+
+// execute renders t recording metrics, see observe.
+func (l *Loader) execute(t Template, w io.Writer, name string, data any) error {
+	start := time.Now()
+	err := t.Execute(w, data)
+	l.observe(name, start, err)
+
+	if err != nil {
+		return fmt.Errorf("executing template %q: %w", name, err)
+	}
+
+	return nil
+}
+
+// Close stops watching the templates in DevMode.
+func (l *Loader) Close() error {
+	if l.watcher != nil {
+		return l.watcher.close()
+	}
+
+	return nil
+}
+
+// files returns the files in the subdirectories of Dir including nested
+// directories, missing subdirectories are ignored.
+func (l *Loader) files(dirs ...string) ([]string, error) {
+	var files []string
+
+	for _, dir := range dirs {
+		err := filepath.WalkDir(filepath.Join(l.Dir, dir), func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+
+			if !d.IsDir() {
+				files = append(files, path)
+			}
+
+			return nil
+		})
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("reading templates: %w", err)
+		}
+	}
+
+	return files, nil
+}
+
+// sharedFiles returns the layouts and partials by directory of the template
+// files, files in nested directories override files with the same name in
+// parent directories.
+func (l *Loader) sharedFiles(files []string) (map[string][]string, error) {
+	shared := make(map[string][]string)
+
+	for _, file := range files {
+		dir := filepath.Dir(file)
+		if _, ok := shared[dir]; ok {
+			continue
+		}
+
+		byName := make(map[string]string)
+		rel := l.relPath(TemplatesDir, dir)
+
+		// e.g. "", "emails" and "emails/billing" for "emails/billing"
+		levels := []string{""}
+		if rel != "." {
+			parts := strings.Split(rel, "/")
+			for i := range parts {
+				levels = append(levels, filepath.Join(parts[:i+1]...))
+			}
+		}
+
+		for _, level := range levels {
+			for _, sharedDir := range []string{LayoutsDir, PartialsDir} {
+				entries, err := os.ReadDir(filepath.Join(l.Dir, sharedDir, level))
+				if errors.Is(err, os.ErrNotExist) {
+					continue
+				}
+
+				if err != nil {
+					return nil, fmt.Errorf("reading templates: %w", err)
+				}
+
+				for _, e := range entries {
+					if !e.IsDir() {
+						byName[e.Name()] = filepath.Join(l.Dir, sharedDir, level, e.Name())
+					}
+				}
+			}
+		}
+
+		paths := make([]string, 0, len(byName))
+		for _, path := range byName {
+			paths = append(paths, path)
+		}
+
+		sort.Strings(paths)
+
+		shared[dir] = paths
+	}
+
+	return shared, nil
+}
+
+// relPath returns the slash-separated path of file relative to subdirectory
+// dir of Dir, e.g. "emails/welcome.html".
+func (l *Loader) relPath(dir string, file string) string {
+	rel, err := filepath.Rel(filepath.Join(l.Dir, dir), file)
+	if err != nil {
+		return filepath.Base(file)
+	}
+
+	return filepath.ToSlash(rel)
+}
+
+// parseText parses the template file named name together with the shared
+// layouts and partials using text/template.
+func parseText(name string, file string, shared []string, funcs template.FuncMap) (*template.Template, error) {
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("reading template %q: %w", name, err)
+	}
+
+	t := template.New(name).Funcs(funcs)
+
+	if len(shared) > 0 {
+		if t, err = t.ParseFiles(shared...); err != nil {
+			return nil, fmt.Errorf("parsing layouts and partials of template %q: %w", name, err)
+		}
+	}
+
+	if t, err = t.Parse(string(b)); err != nil {
+		return nil, fmt.Errorf("parsing template %q: %w", name, err)
+	}
+
+	return t, nil
+}
+
+// parseHTML is like parseText using html/template.
+func parseHTML(name string, file string, shared []string, funcs template.FuncMap) (*htmltemplate.Template, error) {
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("reading template %q: %w", name, err)
+	}
+
+	t := htmltemplate.New(name).Funcs(htmltemplate.FuncMap(funcs))
+
+	if len(shared) > 0 {
+		if t, err = t.ParseFiles(shared...); err != nil {
+			return nil, fmt.Errorf("parsing layouts and partials of template %q: %w", name, err)
+		}
+	}
+
+	if t, err = t.Parse(string(b)); err != nil {
+		return nil, fmt.Errorf("parsing template %q: %w", name, err)
+	}
+
+	return t, nil
+}
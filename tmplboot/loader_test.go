@@ -0,0 +1,301 @@
+package tmplboot_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"text/template"
+	"time"
+
+	"github.com/nielskrijger/goboot"
+	"github.com/nielskrijger/goboot/tmplboot"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type welcomeData struct {
+	Name string
+}
+
+// newLoader creates a loader configured using env.
+func newLoader(t *testing.T, env *goboot.AppEnv) *tmplboot.Loader {
+	t.Helper()
+
+	l, err := tmplboot.NewLoader(env)
+	require.Nil(t, err)
+
+	return l
+}
+
+// newTestLoader loads the templates in testdata, which use the "upper" function.
+func newTestLoader(t *testing.T) *tmplboot.Loader {
+	t.Helper()
+
+	l := newLoader(t, goboot.NewAppEnv("./testdata", "")).Funcs(template.FuncMap{
+		"upper": strings.ToUpper,
+	})
+	assert.Nil(t, l.Load())
+
+	return l
+}
+
+func TestLoader_Execute(t *testing.T) {
+	l := newTestLoader(t)
+
+	var buf strings.Builder
+	assert.Nil(t, l.Execute(&buf, "welcome", welcomeData{Name: "John"}))
+	assert.Equal(t, "<h1>Welcome</h1>\nHello John!\n", buf.String())
+
+	assert.EqualError(t, l.Execute(&buf, "unknown", nil), `template not found: "unknown"`)
+	assert.Nil(t, l.Close())
+}
+
+func TestLoader_DevModeReloadsChangedTemplates(t *testing.T) {
+	dir := t.TempDir()
+	for _, sub := range []string{tmplboot.TemplatesDir, tmplboot.LayoutsDir, tmplboot.PartialsDir} {
+		assert.Nil(t, os.Mkdir(filepath.Join(dir, sub), 0o755))
+	}
+
+	welcome := filepath.Join(dir, tmplboot.TemplatesDir, "welcome.txt")
+	assert.Nil(t, os.WriteFile(welcome, []byte("Hello {{.Name}}"), 0o600))
+
+	env := goboot.NewAppEnv("./testdata", "dev")
+	env.Config.Set("templates.dir", dir)
+
+	l := newLoader(t, env)
+	assert.Nil(t, l.Load())
+
+	defer l.Close()
+
+	assert.Nil(t, os.WriteFile(welcome, []byte("Hi {{.Name}}"), 0o600))
+
+	assert.Eventually(t, func() bool {
+		var buf strings.Builder
+
+		return l.Execute(&buf, "welcome", welcomeData{Name: "John"}) == nil && buf.String() == "Hi John"
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestLoader_Funcs(t *testing.T) {
+	l := newTestLoader(t)
+
+	var buf strings.Builder
+	assert.Nil(t, l.Execute(&buf, "shout", welcomeData{Name: "John"}))
+	assert.Equal(t, "HELLO JOHN\n", buf.String())
+}
+
+func TestLoader_Sprig(t *testing.T) {
+	env := goboot.NewAppEnv("./testdata", "")
+	env.Config.Set("templates.dir", "./testdata/sprig")
+	env.Config.Set("templates.sprig", true)
+
+	l := newLoader(t, env)
+	assert.Nil(t, l.Load())
+
+	var buf strings.Builder
+	assert.Nil(t, l.Execute(&buf, "greet", welcomeData{}))
+	assert.Equal(t, "Stranger", buf.String())
+}
+
+func TestLoader_HTMLEscapesData(t *testing.T) {
+	env := goboot.NewAppEnv("./testdata", "")
+	env.Config.Set("templates.dir", "./testdata/html")
+	env.Config.Set("templates.html", true)
+
+	l := newLoader(t, env)
+	assert.Nil(t, l.Load())
+
+	data := welcomeData{Name: "<script>"}
+
+	var buf strings.Builder
+	assert.Nil(t, l.Execute(&buf, "profile", data))
+	assert.Equal(t, "<p>Hello &lt;script&gt;</p>", buf.String())
+
+	buf.Reset()
+	assert.Nil(t, l.Execute(&buf, "profile-text", data))
+	assert.Equal(t, "Hello <script>", buf.String())
+}
+
+func TestLoader_ExecuteLocalized(t *testing.T) {
+	env := goboot.NewAppEnv("./testdata", "")
+	env.Config.Set("templates.dir", "./testdata/i18n")
+
+	l := newLoader(t, env)
+	assert.Nil(t, l.Load())
+
+	data := welcomeData{Name: "John"}
+
+	for _, tc := range []struct {
+		name     string
+		locale   string
+		expected string
+	}{
+		{name: "welcome", locale: "en", expected: "Hello John"},
+		{name: "welcome", locale: "de", expected: "Hallo John"},
+		{name: "welcome", locale: "nl", expected: "Welkom terug John"},
+		{name: "goodbye", locale: "nl", expected: "Hello John"},
+		{name: "goodbye", locale: "fr", expected: "Hello John"},
+	} {
+		var buf strings.Builder
+		assert.Nil(t, l.ExecuteLocalized(&buf, tc.name, tc.locale, data))
+		assert.Equal(t, tc.expected, buf.String(), tc.locale)
+	}
+}
+
+func TestLoader_LoadLocalizedFallbackChain(t *testing.T) {
+	env := goboot.NewAppEnv("./testdata", "")
+	env.Config.Set("templates.dir", "./testdata/i18n")
+
+	l := newLoader(t, env)
+	assert.Nil(t, l.Load())
+
+	data := welcomeData{Name: "John"}
+
+	for _, tc := range []struct {
+		name     string
+		locales  []string
+		expected string
+	}{
+		{name: "welcome", locales: []string{"nl-BE"}, expected: "Welkom terug John"},
+		{name: "welcome", locales: []string{"de-AT"}, expected: "Hallo John"},
+		{name: "welcome", locales: []string{"fr", "de"}, expected: "Hallo John"},
+		{name: "welcome", locales: []string{"fr-CA", "nl"}, expected: "Welkom terug John"},
+		{name: "welcome.txt", locales: []string{"nl-BE"}, expected: "Welkom terug John"},
+		{name: "goodbye", locales: []string{"de-CH"}, expected: "Hallo John"},
+		{name: "goodbye", locales: nil, expected: "Hello John"},
+	} {
+		tmpl, err := l.LoadLocalized(tc.name, tc.locales...)
+		assert.Nil(t, err)
+
+		var buf strings.Builder
+		assert.Nil(t, tmpl.Execute(&buf, data))
+		assert.Equal(t, tc.expected, buf.String(), tc.locales)
+	}
+
+	_, err := l.LoadLocalized("unknown", "nl-BE")
+	assert.ErrorContains(t, err, `template not found: "unknown"`)
+}
+
+func TestLoader_InvalidateReloadsOnNextUse(t *testing.T) {
+	dir := t.TempDir()
+	assert.Nil(t, os.Mkdir(filepath.Join(dir, tmplboot.TemplatesDir), 0o755))
+
+	welcome := filepath.Join(dir, tmplboot.TemplatesDir, "welcome.txt")
+	assert.Nil(t, os.WriteFile(welcome, []byte("Hello {{.Name}}"), 0o600))
+
+	l := &tmplboot.Loader{Dir: dir}
+	assert.Nil(t, l.Configure(goboot.NewAppEnv("./testdata", "")))
+
+	// loaded on first use and cached until invalidated
+	var buf strings.Builder
+	assert.Nil(t, l.Execute(&buf, "welcome", welcomeData{Name: "John"}))
+	assert.Nil(t, os.WriteFile(welcome, []byte("Hi {{.Name}}"), 0o600))
+	assert.Nil(t, l.Execute(&buf, "welcome", welcomeData{Name: "John"}))
+	assert.Equal(t, "Hello JohnHello John", buf.String())
+
+	l.Invalidate()
+
+	buf.Reset()
+	assert.Nil(t, l.Execute(&buf, "welcome", welcomeData{Name: "John"}))
+	assert.Equal(t, "Hi John", buf.String())
+}
+
+func TestLoader_InvalidateKeepsTemplatesOnError(t *testing.T) {
+	dir := t.TempDir()
+	assert.Nil(t, os.Mkdir(filepath.Join(dir, tmplboot.TemplatesDir), 0o755))
+
+	welcome := filepath.Join(dir, tmplboot.TemplatesDir, "welcome.txt")
+	assert.Nil(t, os.WriteFile(welcome, []byte("Hello {{.Name}}"), 0o600))
+
+	l := &tmplboot.Loader{Dir: dir}
+	assert.Nil(t, l.Configure(goboot.NewAppEnv("./testdata", "")))
+	assert.Nil(t, l.Load())
+
+	assert.Nil(t, os.WriteFile(welcome, []byte("Hi {{.Name"), 0o600))
+	l.Invalidate()
+
+	var buf strings.Builder
+	assert.Nil(t, l.Execute(&buf, "welcome", welcomeData{Name: "John"}))
+	assert.Equal(t, "Hello John", buf.String())
+
+	// loaded again after the next change
+	assert.Nil(t, os.WriteFile(welcome, []byte("Hi {{.Name}}"), 0o600))
+	l.Invalidate()
+
+	buf.Reset()
+	assert.Nil(t, l.Execute(&buf, "welcome", welcomeData{Name: "John"}))
+	assert.Equal(t, "Hi John", buf.String())
+}
+
+func TestLoader_ConcurrentLookups(t *testing.T) {
+	l := newTestLoader(t)
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for j := 0; j < 10; j++ {
+				l.Invalidate()
+
+				var buf strings.Builder
+				assert.Nil(t, l.Execute(&buf, "shout", welcomeData{Name: "John"}))
+				assert.Equal(t, "HELLO JOHN\n", buf.String())
+			}
+		}()
+	}
+
+	wg.Wait()
+}
+
+func TestLoader_MustLoadAllPanicsOnError(t *testing.T) {
+	// the templates in testdata use the "upper" function that isn't registered
+	l := newLoader(t, goboot.NewAppEnv("./testdata", ""))
+
+	assert.Panics(t, l.MustLoadAll)
+}
+
+func TestLoader_NestedDirectories(t *testing.T) {
+	env := goboot.NewAppEnv("./testdata", "")
+	env.Config.Set("templates.dir", "./testdata/nested")
+
+	l := newLoader(t, env)
+	assert.Nil(t, l.Load())
+
+	data := welcomeData{Name: "John"}
+
+	// layouts/emails/base.txt overrides layouts/base.txt
+	var buf strings.Builder
+	assert.Nil(t, l.Execute(&buf, "emails/billing/invoice", data))
+	assert.Equal(t, "<Invoice John Regards>", buf.String())
+
+	buf.Reset()
+	assert.Nil(t, l.Execute(&buf, "welcome", data))
+	assert.Equal(t, "[Hello John]", buf.String())
+}
+
+func TestLoader_ConfigDisablesDevMode(t *testing.T) {
+	env := goboot.NewAppEnv("./testdata", "")
+	env.Config.Set("templates.devMode", false)
+
+	l := &tmplboot.Loader{DevMode: true}
+	assert.Nil(t, l.Configure(env))
+	assert.False(t, l.DevMode)
+}
+
+func TestNewLoader_ErrorConfigure(t *testing.T) {
+	env := goboot.NewAppEnv("./testdata", "")
+	env.Metrics.MustRegister(prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "template_render_errors_total",
+		Help: "Conflicting collector.",
+	}))
+
+	_, err := tmplboot.NewLoader(env)
+	assert.ErrorContains(t, err, "registering template metrics")
+}
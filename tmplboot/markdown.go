@@ -0,0 +1,32 @@
+package tmplboot
+
+import (
+	"bytes"
+	"fmt"
+	htmltemplate "html/template"
+
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+)
+
+var (
+	md = goldmark.New(goldmark.WithExtensions(extension.GFM))
+
+	// markdownPolicy allows the HTML of user-generated content, e.g. links
+	// and images, but no scripts, styles or event handlers.
+	markdownPolicy = bluemonday.UGCPolicy()
+)
+
+// markdown is the "markdown" template function rendering GitHub Flavored
+// Markdown to sanitized HTML, e.g. {{markdown .Body}}. Raw HTML in the
+// markdown is removed, so the result is safe for user and CMS content.
+func markdown(source string) (htmltemplate.HTML, error) {
+	var buf bytes.Buffer
+	if err := md.Convert([]byte(source), &buf); err != nil {
+		return "", fmt.Errorf("rendering markdown: %w", err)
+	}
+
+	// sanitized, so it's safe to use unescaped
+	return htmltemplate.HTML(markdownPolicy.SanitizeBytes(buf.Bytes())), nil //nolint:gosec
+}
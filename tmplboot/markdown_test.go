@@ -0,0 +1,31 @@
+package tmplboot_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/nielskrijger/goboot"
+	"github.com/stretchr/testify/assert"
+)
+
+type notificationData struct {
+	Body string
+}
+
+func TestLoader_Markdown(t *testing.T) {
+	env := goboot.NewAppEnv("./testdata", "")
+	env.Config.Set("templates.dir", "./testdata/markdown")
+	env.Config.Set("templates.html", true)
+
+	l := newLoader(t, env)
+	assert.Nil(t, l.Load())
+
+	var buf strings.Builder
+	err := l.Execute(&buf, "notification", notificationData{
+		Body: "Hello **John**, see [this](https://example.com)<script>alert(1)</script>",
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, `<div><p>Hello <strong>John</strong>, see `+
+		`<a href="https://example.com" rel="nofollow">this</a>alert(1)</p>
+</div>`, buf.String())
+}
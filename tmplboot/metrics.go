@@ -0,0 +1,70 @@
+package tmplboot
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/nielskrijger/goboot"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// errorPathRegexp matches the path of the failing action in template
+// execution errors, e.g. ".User.Name" in `executing "welcome" at <.User.Name>: ...`.
+var errorPathRegexp = regexp.MustCompile(`executing "[^"]*" at <([^>]*)>`)
+
+type renderMetrics struct {
+	duration *prometheus.HistogramVec
+	errors   *prometheus.CounterVec
+}
+
+func (l *Loader) registerMetrics(reg *prometheus.Registry) error {
+	if reg == nil || l.metrics != nil {
+		return nil
+	}
+
+	m := &renderMetrics{
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "template_render_duration_seconds",
+			Help:    "Duration of rendering templates by template name.",
+			Buckets: []float64{.0001, .00025, .0005, .001, .0025, .005, .01, .025, .05, .1},
+		}, []string{"template"}),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "template_render_errors_total",
+			Help: "Number of failed template renders by template name.",
+		}, []string{"template"}),
+	}
+
+	if err := goboot.RegisterCollector(reg, &m.duration); err != nil {
+		return fmt.Errorf("registering template metrics: %w", err)
+	}
+
+	if err := goboot.RegisterCollector(reg, &m.errors); err != nil {
+		return fmt.Errorf("registering template metrics: %w", err)
+	}
+
+	l.metrics = m
+
+	return nil
+}
+
+// observe records a render of template name that started at start, and logs
+// the error including the path of the failing action if any.
+func (l *Loader) observe(name string, start time.Time, err error) {
+	if l.metrics != nil {
+		l.metrics.duration.WithLabelValues(name).Observe(time.Since(start).Seconds())
+
+		if err != nil {
+			l.metrics.errors.WithLabelValues(name).Inc()
+		}
+	}
+
+	if err != nil {
+		event := l.log.Error().Err(err).Str("template", name)
+		if m := errorPathRegexp.FindStringSubmatch(err.Error()); m != nil {
+			event = event.Str("path", m[1])
+		}
+
+		event.Msg("failed to render template")
+	}
+}
@@ -0,0 +1,38 @@
+package tmplboot_test
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/nielskrijger/goboot"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoader_RenderMetricsAndErrorLogging(t *testing.T) {
+	var buf bytes.Buffer
+
+	env := goboot.NewAppEnv("./testdata", "")
+	env.Config.Set("templates.dir", "./testdata/validate")
+	env.Log = zerolog.New(&buf)
+
+	l := newLoader(t, env)
+	assert.Nil(t, l.Execute(io.Discard, "tags", map[string]any{"Tags": []string{"go"}}))
+	assert.NotNil(t, l.Execute(io.Discard, "tags", nil))
+	assert.Contains(t, buf.String(), `"template":"tags","path":"index .Tags 0","message":"failed to render template"`)
+
+	families, err := env.Metrics.Gather()
+	assert.Nil(t, err)
+
+	counts := map[string]float64{}
+	for _, f := range families {
+		for _, m := range f.GetMetric() {
+			counts[f.GetName()] += m.GetCounter().GetValue()
+			counts[f.GetName()] += float64(m.GetHistogram().GetSampleCount())
+		}
+	}
+
+	assert.Equal(t, float64(2), counts["template_render_duration_seconds"])
+	assert.Equal(t, float64(1), counts["template_render_errors_total"])
+}
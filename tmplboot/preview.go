@@ -0,0 +1,155 @@
+package tmplboot
+
+import (
+	htmltemplate "html/template"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+var previewIndex = htmltemplate.Must(htmltemplate.New("index").Parse(`<!DOCTYPE html>
+<html>
+<head><title>Email previews</title></head>
+<body>
+<h1>Email previews</h1>
+<ul>
+{{- range $email := .Emails}}
+<li>{{$email}}{{range $.Locales}} <a href="{{$.Base}}{{$email}}?locale={{.}}">{{.}}</a>{{end}}</li>
+{{- end}}
+</ul>
+</body>
+</html>
+`))
+
+var previewEmail = htmltemplate.Must(htmltemplate.New("email").Parse(`<!DOCTYPE html>
+<html>
+<head><title>{{.Name}} ({{.Locale}})</title></head>
+<body>
+<p><a href="{{.Base}}">All emails</a></p>
+<h1>{{.Email.Subject}}</h1>
+{{- if .Email.HTML}}
+<h2>HTML</h2>
+<iframe src="{{.Base}}{{.Name}}/html?locale={{.Locale}}" style="width: 100%; height: 600px"></iframe>
+{{- end}}
+{{- if .Email.Text}}
+<h2>Text</h2>
+<pre>{{.Email.Text}}</pre>
+{{- end}}
+</body>
+</html>
+`))
+
+// PreviewHandler returns a handler for previewing emails, see LoadEmail,
+// which renders them using their sample data, see Validate. Mount it in
+// development only, it responds with 404 unless DevMode is set.
+//
+//	GET /                      lists the emails
+//	GET /{name}?locale=nl      shows the subject, HTML and text body
+//	GET /{name}/html?locale=nl renders the HTML body
+//	GET /{name}/text?locale=nl renders the text body
+//
+// Use http.StripPrefix when mounting it on a path, e.g.
+//
+//	mux.Handle("/emails/", http.StripPrefix("/emails", loader.PreviewHandler()))
+func (l *Loader) PreviewHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !l.DevMode {
+			http.NotFound(w, r)
+
+			return
+		}
+
+		// the prefix stripped by http.StripPrefix, so links work when mounted on a path
+		base := strings.TrimSuffix(strings.SplitN(r.RequestURI, "?", 2)[0], r.URL.EscapedPath()) + "/"
+
+		path := strings.Trim(r.URL.Path, "/")
+		if path == "" {
+			l.previewIndex(w, base)
+
+			return
+		}
+
+		name, part := path, ""
+		if i := strings.LastIndex(path, "/"); i >= 0 && (path[i+1:] == "html" || path[i+1:] == "text") {
+			name, part = path[:i], path[i+1:]
+		}
+
+		l.previewEmail(w, r, base, name, part)
+	})
+}
+
+func (l *Loader) previewIndex(w http.ResponseWriter, base string) {
+	cache, err := l.cache()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+
+		return
+	}
+
+	var emails, locales []string
+
+	for name := range cache[l.DefaultLocale] {
+		if strings.HasSuffix(name, ".subject.txt") {
+			emails = append(emails, strings.TrimSuffix(name, ".subject.txt"))
+		}
+	}
+
+	for locale := range cache {
+		locales = append(locales, locale)
+	}
+
+	sort.Strings(emails)
+	sort.Strings(locales)
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+
+	_ = previewIndex.Execute(w, map[string]any{"Base": base, "Emails": emails, "Locales": locales})
+}
+
+func (l *Loader) previewEmail(w http.ResponseWriter, r *http.Request, base string, name string, part string) {
+	locale := r.URL.Query().Get("locale")
+	if locale == "" {
+		locale = l.DefaultLocale
+	}
+
+	email, err := l.LoadEmail(name, locale)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+
+		return
+	}
+
+	samples, err := l.loadSamples()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+
+		return
+	}
+
+	data, _ := sampleFor(samples, name)
+
+	rendered, err := email.Render(data)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+
+		return
+	}
+
+	switch part {
+	case "html":
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write([]byte(rendered.HTML))
+	case "text":
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		_, _ = w.Write([]byte(rendered.Text))
+	default:
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+
+		_ = previewEmail.Execute(w, map[string]any{
+			"Base":   base,
+			"Name":   name,
+			"Locale": locale,
+			"Email":  rendered,
+		})
+	}
+}
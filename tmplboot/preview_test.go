@@ -0,0 +1,75 @@
+package tmplboot_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/nielskrijger/goboot"
+	"github.com/stretchr/testify/assert"
+)
+
+func newPreviewServer(t *testing.T, devMode bool) *httptest.Server {
+	t.Helper()
+
+	env := goboot.NewAppEnv("./testdata", "")
+	env.Config.Set("templates.dir", "./testdata/email")
+	env.Config.Set("templates.html", true)
+
+	l := newLoader(t, env)
+	l.DevMode = devMode
+
+	mux := http.NewServeMux()
+	mux.Handle("/emails/", http.StripPrefix("/emails", l.PreviewHandler()))
+
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+func get(t *testing.T, url string) (int, string) {
+	t.Helper()
+
+	res, err := http.Get(url) //nolint:noctx
+	assert.Nil(t, err)
+
+	defer res.Body.Close()
+
+	b, _ := io.ReadAll(res.Body)
+
+	return res.StatusCode, string(b)
+}
+
+func TestLoader_PreviewHandler(t *testing.T) {
+	srv := newPreviewServer(t, true)
+
+	status, body := get(t, srv.URL+"/emails/")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Contains(t, body, `<li>signup <a href="/emails/signup?locale=en">en</a></li>`)
+
+	status, body = get(t, srv.URL+"/emails/signup?locale=nl")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Contains(t, body, "<h1>Welkom John</h1>")
+	assert.Contains(t, body, `<iframe src="/emails/signup/html?locale=nl"`)
+	assert.Contains(t, body, "<pre>Hello John</pre>")
+
+	status, body = get(t, srv.URL+"/emails/signup/html?locale=nl")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "<p>Hallo John</p>", body)
+
+	status, body = get(t, srv.URL+"/emails/signup/text")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "Hello John", body)
+
+	status, _ = get(t, srv.URL+"/emails/unknown")
+	assert.Equal(t, http.StatusNotFound, status)
+}
+
+func TestLoader_PreviewHandlerDevModeOnly(t *testing.T) {
+	srv := newPreviewServer(t, false)
+
+	status, _ := get(t, srv.URL+"/emails/")
+	assert.Equal(t, http.StatusNotFound, status)
+}
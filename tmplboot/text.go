@@ -0,0 +1,127 @@
+package tmplboot
+
+import (
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+var (
+	whitespaceRegex = regexp.MustCompile(`\s+`)
+	spacesRegex     = regexp.MustCompile(`[ \t]+`)
+	newlinesRegex   = regexp.MustCompile(`\n{3,}`)
+)
+
+// htmlToText converts HTML to plain text: elements are removed, block
+// elements start on a new line, list items are prefixed with "- " and link
+// URLs are added after the link text, e.g. "Reset password
+// (https://example.com/reset)".
+func htmlToText(s string) string {
+	var (
+		buf   strings.Builder
+		hrefs []string // of the open <a> elements, empty without URL
+		skip  int      // number of open elements whose content isn't text
+	)
+
+	z := html.NewTokenizer(strings.NewReader(s))
+
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			break // io.EOF, the tokenizer doesn't fail on invalid HTML
+		}
+
+		token := z.Token()
+
+		switch tt { //nolint:exhaustive
+		case html.TextToken:
+			if skip == 0 {
+				buf.WriteString(whitespaceRegex.ReplaceAllString(token.Data, " "))
+			}
+		case html.StartTagToken, html.SelfClosingTagToken:
+			switch token.DataAtom { //nolint:exhaustive
+			case atom.Head, atom.Script, atom.Style, atom.Title:
+				if tt == html.StartTagToken {
+					skip++
+				}
+			case atom.Br:
+				buf.WriteString("\n")
+			case atom.Li:
+				buf.WriteString("\n- ")
+			case atom.Td, atom.Th:
+				buf.WriteString(" ")
+			case atom.Img:
+				if alt := attr(token, "alt"); alt != "" {
+					buf.WriteString(alt)
+				}
+			case atom.A:
+				hrefs = append(hrefs, linkURL(attr(token, "href")))
+			default:
+				if isBlock(token.DataAtom) {
+					buf.WriteString("\n\n")
+				}
+			}
+		case html.EndTagToken:
+			switch token.DataAtom { //nolint:exhaustive
+			case atom.Head, atom.Script, atom.Style, atom.Title:
+				if skip > 0 {
+					skip--
+				}
+			case atom.A:
+				if len(hrefs) > 0 {
+					if href := hrefs[len(hrefs)-1]; href != "" && !strings.HasSuffix(strings.TrimSpace(buf.String()), href) {
+						buf.WriteString(" (" + href + ")")
+					}
+
+					hrefs = hrefs[:len(hrefs)-1]
+				}
+			default:
+				if isBlock(token.DataAtom) {
+					buf.WriteString("\n\n")
+				}
+			}
+		}
+	}
+
+	lines := strings.Split(buf.String(), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(spacesRegex.ReplaceAllString(line, " "))
+	}
+
+	return strings.TrimSpace(newlinesRegex.ReplaceAllString(strings.Join(lines, "\n"), "\n\n"))
+}
+
+// linkURL returns the URL of a link that is worth showing in text, which
+// excludes anchors and javascript.
+func linkURL(href string) string {
+	href = strings.TrimSpace(href)
+
+	if href == "" || strings.HasPrefix(href, "#") || strings.HasPrefix(strings.ToLower(href), "javascript:") {
+		return ""
+	}
+
+	return strings.TrimPrefix(href, "mailto:")
+}
+
+func attr(token html.Token, key string) string {
+	for _, a := range token.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+
+	return ""
+}
+
+func isBlock(a atom.Atom) bool {
+	switch a { //nolint:exhaustive
+	case atom.Address, atom.Article, atom.Aside, atom.Blockquote, atom.Div, atom.Dl, atom.Dt, atom.Dd,
+		atom.Footer, atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6, atom.Header, atom.Hr,
+		atom.Main, atom.Nav, atom.Ol, atom.P, atom.Pre, atom.Section, atom.Table, atom.Tr, atom.Ul:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,117 @@
+package tmplboot
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+var errInvalidTemplates = errors.New("invalid templates")
+
+// Validate executes all templates in all locales using their sample data to
+// detect errors that only occur when executing a template, like a missing
+// partial or calling a method that doesn't exist.
+//
+// Sample data is read from Samples and {Dir}/samples/{name}.yaml or .json,
+// e.g. {Dir}/samples/emails/billing/invoice.yaml, which use the field names
+// of the template data like "Name: John".
+// Templates use the sample of the longest matching name, e.g. the subject
+// "welcome.subject.nl" uses the sample "welcome" when it is the only one.
+// Templates without sample data are skipped, as they'd fail on data the
+// template requires rather than on actual errors.
+func (l *Loader) Validate() error {
+	samples, err := l.loadSamples()
+	if err != nil {
+		return err
+	}
+
+	cache, err := l.cache()
+	if err != nil {
+		return err
+	}
+
+	var failures []string
+
+	for locale, templates := range cache {
+		validated := make(map[Template]bool, len(templates))
+
+		for _, t := range templates {
+			if validated[t] {
+				continue // referred to by name with and without extension
+			}
+
+			validated[t] = true
+
+			data, ok := sampleFor(samples, t.Name())
+			if !ok {
+				continue
+			}
+
+			if err := t.Execute(io.Discard, data); err != nil {
+				failures = append(failures, fmt.Sprintf("locale %q: %s", locale, err))
+			}
+		}
+	}
+
+	if len(failures) > 0 {
+		sort.Strings(failures)
+
+		return fmt.Errorf("%w:\n%s", errInvalidTemplates, strings.Join(failures, "\n"))
+	}
+
+	return nil
+}
+
+// loadSamples returns the samples in {Dir}/samples merged with Samples.
+func (l *Loader) loadSamples() (map[string]any, error) {
+	files, err := l.files(SamplesDir)
+	if err != nil {
+		return nil, err
+	}
+
+	samples := make(map[string]any, len(files)+len(l.Samples))
+
+	for _, file := range files {
+		b, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("reading sample %q: %w", file, err)
+		}
+
+		var data any
+		if err := yaml.Unmarshal(b, &data); err != nil {
+			return nil, fmt.Errorf("parsing sample %q: %w", file, err)
+		}
+
+		samples[strings.TrimSuffix(l.relPath(SamplesDir, file), filepath.Ext(file))] = data
+	}
+
+	for name, data := range l.Samples {
+		samples[name] = data
+	}
+
+	return samples, nil
+}
+
+// sampleFor returns the sample of the longest name matching the template
+// name, e.g. "welcome.nl" or "welcome" for template "welcome.nl". Returns
+// false if there is no such sample.
+func sampleFor(samples map[string]any, name string) (any, bool) {
+	for {
+		if data, ok := samples[name]; ok {
+			return data, true
+		}
+
+		i := strings.LastIndex(name, ".")
+		if i < 0 {
+			return nil, false
+		}
+
+		name = name[:i]
+	}
+}
@@ -0,0 +1,36 @@
+package tmplboot_test
+
+import (
+	"testing"
+
+	"github.com/nielskrijger/goboot"
+	"github.com/nielskrijger/goboot/tmplboot"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoader_InitValidatesTemplates(t *testing.T) {
+	env := goboot.NewAppEnv("./testdata", "")
+
+	l := &tmplboot.Loader{
+		Dir:     "./testdata/validate",
+		Samples: map[string]any{"tags": map[string]any{"Tags": []string{"go"}}},
+	}
+	assert.Nil(t, l.Configure(env))
+
+	err := l.Init()
+	assert.EqualError(t, err, "invalid templates:\n"+
+		`locale "en": template: broken:1:11: executing "broken" at <{{template "missing.txt" .}}>: `+
+		`template "missing.txt" not defined`)
+}
+
+func TestLoader_ValidateSkipsTemplatesWithoutSample(t *testing.T) {
+	env := goboot.NewAppEnv("./testdata", "")
+	env.Config.Set("templates.dir", "./testdata/validate")
+
+	l := newLoader(t, env)
+	assert.Nil(t, l.Load())
+
+	err := l.Validate()
+	assert.ErrorContains(t, err, `template "missing.txt" not defined`)
+	assert.NotContains(t, err.Error(), `"tags"`)
+}
@@ -0,0 +1,108 @@
+package tmplboot
+
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// reloadDelay groups the events of saving multiple files, or of editors
+// writing a file in multiple steps, into a single reload.
+const reloadDelay = 100 * time.Millisecond
+
+type watcher struct {
+	fs   *fsnotify.Watcher
+	done chan struct{}
+}
+
+// watch invalidates and reloads the templates when a file in one of the
+// template directories changes. When reloading fails the error is logged and
+// lookups keep using the last templates that loaded, until the next change
+// reloads them successfully.
+func (l *Loader) watch() (*watcher, error) {
+	fw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("watching templates: %w", err)
+	}
+
+	for _, dir := range []string{TemplatesDir, LayoutsDir, PartialsDir, LocalesDir} {
+		// missing directories are not watched
+		_ = addRecursive(fw, filepath.Join(l.Dir, dir))
+	}
+
+	if l.AssetManifest != "" {
+		_ = fw.Add(l.AssetManifest)
+	}
+
+	w := &watcher{fs: fw, done: make(chan struct{})}
+
+	go func() {
+		defer close(w.done)
+
+		var reload <-chan time.Time
+
+		for {
+			select {
+			case event, ok := <-fw.Events:
+				if !ok {
+					return
+				}
+
+				if event.Op&fsnotify.Create != 0 {
+					// watch new nested directories
+					_ = addRecursive(fw, event.Name)
+				}
+
+				reload = time.After(reloadDelay)
+			case err, ok := <-fw.Errors:
+				if !ok {
+					return
+				}
+
+				l.log.Warn().Err(err).Msg("failed to watch templates")
+			case <-reload:
+				l.Invalidate()
+
+				if _, err := l.reload(); err != nil {
+					l.log.Error().Err(err).Msg("failed to reload templates, keeping the previous templates")
+				} else {
+					l.log.Info().Msg("reloaded templates")
+				}
+			}
+		}
+	}()
+
+	l.log.Info().Msgf("watching templates in %q", l.Dir)
+
+	return w, nil
+}
+
+// addRecursive watches dir and its nested directories, fsnotify doesn't
+// watch nested directories.
+func addRecursive(w *fsnotify.Watcher, dir string) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error { //nolint:wrapcheck
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() {
+			return w.Add(path) //nolint:wrapcheck
+		}
+
+		return nil
+	})
+}
+
+func (w *watcher) close() error {
+	err := w.fs.Close()
+	<-w.done
+
+	if err != nil {
+		return fmt.Errorf("closing templates watcher: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,109 @@
+package validate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// AsyncValidator validates input against a data store, e.g. checks whether
+// an email address is taken. It returns a FieldError, a *FieldError or a
+// *ValidationResult if the input is invalid, and other errors if it failed
+// to validate, e.g. because the data store is unavailable:
+//
+//	func uniqueEmail(email string) validate.AsyncValidator {
+//		return func(ctx context.Context) error {
+//			exists, err := users.ExistsByEmail(ctx, email)
+//			if err != nil {
+//				return err
+//			}
+//
+//			if exists {
+//				return validate.NewError("email", "email_taken", nil)
+//			}
+//
+//			return nil
+//		}
+//	}
+type AsyncValidator func(ctx context.Context) error
+
+var errValidatorPanic = errors.New("async validator panicked")
+
+// RunAsync runs validators concurrently and adds the errors of invalid input
+// to the result in the order of validators. The validators are canceled after
+// timeout, or when ctx is done. A timeout of 0 only uses the deadline of ctx.
+//
+// RunAsync returns an error if any validator failed to validate or panicked,
+// the result contains the errors of the other validators.
+func (r *ValidationResult) RunAsync(ctx context.Context, timeout time.Duration, validators ...AsyncValidator) error {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+
+		defer cancel()
+	}
+
+	errs := make([]error, len(validators))
+
+	var wg sync.WaitGroup
+
+	for i, validator := range validators {
+		wg.Add(1)
+
+		go func(i int, validator AsyncValidator) {
+			defer wg.Done()
+
+			defer func() {
+				if p := recover(); p != nil {
+					errs[i] = fmt.Errorf("%w: %v", errValidatorPanic, p)
+				}
+			}()
+
+			errs[i] = validator(ctx)
+		}(i, validator)
+	}
+
+	wg.Wait()
+
+	var failed error
+
+	for _, err := range errs {
+		if err == nil {
+			continue
+		}
+
+		if !r.addValidationError(err) && failed == nil {
+			failed = fmt.Errorf("async validation failed: %w", err)
+		}
+	}
+
+	return failed
+}
+
+// addValidationError adds err if it's a FieldError or ValidationResult and
+// returns false otherwise. A nil *FieldError or *ValidationResult returned as
+// error, e.g. by "return validate.Email(...)", is valid input.
+func (r *ValidationResult) addValidationError(err error) bool {
+	var (
+		fieldErr    FieldError
+		fieldErrPtr *FieldError
+		result      *ValidationResult
+	)
+
+	switch {
+	case errors.As(err, &fieldErrPtr):
+		if fieldErrPtr != nil {
+			r.Add(*fieldErrPtr)
+		}
+	case errors.As(err, &fieldErr):
+		r.Add(fieldErr)
+	case errors.As(err, &result):
+		r.Merge(result)
+	default:
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,100 @@
+package validate_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/nielskrijger/goboot/validate"
+	"github.com/stretchr/testify/assert"
+)
+
+var errUnavailable = errors.New("database unavailable")
+
+func TestValidationResult_RunAsync(t *testing.T) {
+	result := validate.NewResult()
+	result.AddError("name", "required", "is required")
+
+	err := result.RunAsync(context.Background(), time.Second,
+		func(ctx context.Context) error {
+			time.Sleep(10 * time.Millisecond)
+
+			return validate.FieldError{Field: "email", Code: "email_taken", Message: "is already taken"}
+		},
+		func(ctx context.Context) error {
+			return nil
+		},
+		func(ctx context.Context) error {
+			nested := validate.NewResult()
+			nested.AddError("country", "unsupported", "is not supported")
+
+			return nested.Prefix("address")
+		},
+		func(ctx context.Context) error {
+			return &validate.FieldError{Field: "username", Code: "username_taken", Message: "is already taken"}
+		},
+	)
+	assert.Nil(t, err)
+
+	fields := make([]string, len(result.Errors))
+	for i, e := range result.Errors {
+		fields[i] = e.Field
+	}
+
+	assert.Equal(t, []string{"name", "email", "address.country", "username"}, fields)
+}
+
+func TestValidationResult_RunAsyncErrors(t *testing.T) {
+	result := validate.NewResult()
+
+	start := time.Now()
+	err := result.RunAsync(context.Background(), 20*time.Millisecond,
+		func(ctx context.Context) error {
+			<-ctx.Done()
+
+			return ctx.Err()
+		},
+		func(ctx context.Context) error {
+			return errUnavailable
+		},
+		func(ctx context.Context) error {
+			return validate.FieldError{Field: "email", Code: "email_taken", Message: "is already taken"}
+		},
+	)
+
+	assert.Less(t, time.Since(start), time.Second)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Len(t, result.Errors, 1)
+}
+
+func TestValidationResult_RunAsyncNilFieldError(t *testing.T) {
+	result := validate.NewResult()
+
+	err := result.RunAsync(context.Background(), time.Second,
+		func(ctx context.Context) error {
+			return validate.Email("email", "john@example.com")
+		},
+		func(ctx context.Context) error {
+			return validate.Email("backup", "john")
+		},
+	)
+	assert.Nil(t, err)
+	assert.Len(t, result.Errors, 1)
+	assert.Equal(t, "backup", result.Errors[0].Field)
+}
+
+func TestValidationResult_RunAsyncPanic(t *testing.T) {
+	result := validate.NewResult()
+
+	err := result.RunAsync(context.Background(), time.Second,
+		func(ctx context.Context) error {
+			panic("boom")
+		},
+		func(ctx context.Context) error {
+			return validate.FieldError{Field: "email", Code: "email_taken", Message: "is already taken"}
+		},
+	)
+	assert.EqualError(t, err, "async validation failed: async validator panicked: boom")
+	assert.Len(t, result.Errors, 1)
+}
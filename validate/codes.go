@@ -0,0 +1,79 @@
+package validate
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Codes of the built-in validators and struct tags. Clients can rely on
+// these not to change.
+const (
+	CodeRequired          = "required"
+	CodeInvalid           = "invalid"
+	CodeInvalidEmail      = "invalid_email"
+	CodeInvalidUUID       = "invalid_uuid"
+	CodeInvalidURL        = "invalid_url"
+	CodeInvalidFormat     = "invalid_format"
+	CodeInvalidTime       = "invalid_time"
+	CodeInvalidLength     = "invalid_length"
+	CodeInvalidType       = "invalid_type"
+	CodeTooShort          = "too_short"
+	CodeTooLong           = "too_long"
+	CodeTooFew            = "too_few"
+	CodeTooMany           = "too_many"
+	CodeTooSmall          = "too_small"
+	CodeTooLarge          = "too_large"
+	CodeTooSmallExclusive = "too_small_exclusive"
+	CodeTooLargeExclusive = "too_large_exclusive"
+	CodeOutOfRange        = "out_of_range"
+	CodeNotAllowed        = "not_allowed"
+	CodeMutuallyExclusive = "mutually_exclusive"
+	CodeUnknownField      = "unknown_field"
+)
+
+// RegisterCode registers an application-specific code with its DefaultLocale
+// message, e.g. RegisterCode("username_taken", "is already taken"). Add
+// translations of the message using RegisterMessages.
+//
+// RegisterCode panics if code is already registered, register codes when
+// initializing the application.
+func RegisterCode(code string, message string) {
+	catalogMu.Lock()
+	defer catalogMu.Unlock()
+
+	if _, ok := catalog[DefaultLocale][code]; ok {
+		panic(fmt.Sprintf("validate: code %q is already registered", code))
+	}
+
+	catalog[DefaultLocale][code] = message
+}
+
+// Codes returns the registered codes in alphabetical order.
+func Codes() []string {
+	catalogMu.RLock()
+	defer catalogMu.RUnlock()
+
+	codes := make([]string, 0, len(catalog[DefaultLocale]))
+	for code := range catalog[DefaultLocale] {
+		if !strings.HasSuffix(code, singularSuffix) {
+			codes = append(codes, code)
+		}
+	}
+
+	sort.Strings(codes)
+
+	return codes
+}
+
+// NewError returns an error of a registered code with the message of code
+// using params, e.g. NewError("name", CodeTooLong, map[string]any{"max": 5}).
+//
+// NewError panics if code is not registered, see RegisterCode.
+func NewError(field string, code string, params map[string]any) FieldError {
+	if _, ok := lookupMessage(DefaultLocale, code); !ok {
+		panic(fmt.Sprintf("validate: code %q is not registered", code))
+	}
+
+	return *newError(field, code, params)
+}
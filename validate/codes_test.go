@@ -0,0 +1,26 @@
+package validate_test
+
+import (
+	"testing"
+
+	"github.com/nielskrijger/goboot/validate"
+	"github.com/stretchr/testify/assert"
+)
+
+func init() {
+	validate.RegisterCode("username_taken", "is already taken by {username}")
+	validate.RegisterMessages("nl", map[string]string{"username_taken": "is al in gebruik door {username}"})
+}
+
+func TestRegisterCode(t *testing.T) {
+	assert.Contains(t, validate.Codes(), "username_taken")
+	assert.Contains(t, validate.Codes(), validate.CodeRequired)
+	assert.NotContains(t, validate.Codes(), "too_few.one")
+
+	err := validate.NewError("username", "username_taken", map[string]any{"username": "john"})
+	assert.Equal(t, "is already taken by john", err.Message)
+	assert.Equal(t, "is al in gebruik door john", err.Translate("nl").Message)
+
+	assert.Panics(t, func() { validate.RegisterCode(validate.CodeRequired, "must be set") })
+	assert.Panics(t, func() { validate.NewError("username", "unknown", nil) })
+}
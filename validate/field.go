@@ -0,0 +1,167 @@
+package validate
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+)
+
+// FieldRules validates a single value using chained rules, see Field.
+type FieldRules struct {
+	field  string
+	value  any
+	result *ValidationResult
+}
+
+// Field returns the rules of a field with value, e.g.:
+//
+//	result := validate.Field("email", req.Email).Required().Email().MaxLen(255).Result()
+//
+// The rules correspond to the validators of the same name, they add their
+// errors to the result of Result. String rules panic if value isn't a string
+// and Range panics if value isn't a number, pointers are dereferenced.
+func Field(field string, value any) *FieldRules {
+	return &FieldRules{field: field, value: value, result: NewResult()}
+}
+
+// Required adds an error if the value is the zero value of its type or nil.
+func (f *FieldRules) Required() *FieldRules {
+	if v := reflect.ValueOf(f.value); !v.IsValid() || v.IsZero() {
+		f.result.Check(newError(f.field, CodeRequired, nil))
+	}
+
+	return f
+}
+
+// Email adds an error if the value isn't an email address, see Email.
+func (f *FieldRules) Email() *FieldRules {
+	f.result.Check(Email(f.field, f.string()))
+
+	return f
+}
+
+// UUID adds an error if the value isn't a UUID, see UUID.
+func (f *FieldRules) UUID() *FieldRules {
+	f.result.Check(UUID(f.field, f.string()))
+
+	return f
+}
+
+// URL adds an error if the value isn't an absolute URL, see URL.
+func (f *FieldRules) URL() *FieldRules {
+	f.result.Check(URL(f.field, f.string()))
+
+	return f
+}
+
+// MinLen adds an error if the value has less than min characters.
+func (f *FieldRules) MinLen(min int) *FieldRules {
+	f.result.Check(MinLen(f.field, f.string(), min))
+
+	return f
+}
+
+// MaxLen adds an error if the value has more than max characters.
+func (f *FieldRules) MaxLen(max int) *FieldRules {
+	f.result.Check(MaxLen(f.field, f.string(), max))
+
+	return f
+}
+
+// Range adds an error if the value is less than min or greater than max.
+func (f *FieldRules) Range(min float64, max float64) *FieldRules {
+	f.result.Check(Range(f.field, f.number(), min, max))
+
+	return f
+}
+
+// OneOf adds an error if the value isn't empty and not one of allowed.
+func (f *FieldRules) OneOf(allowed ...any) *FieldRules {
+	v := deref(reflect.ValueOf(f.value))
+	if !v.IsValid() || v.IsZero() {
+		return f
+	}
+
+	for _, a := range allowed {
+		if reflect.DeepEqual(v.Interface(), a) {
+			return f
+		}
+	}
+
+	f.result.Check(notAllowed(f.field, allowed))
+
+	return f
+}
+
+// Regexp adds an error with message if the value doesn't match re, see Regexp.
+func (f *FieldRules) Regexp(re *regexp.Regexp, message string) *FieldRules {
+	f.result.Check(Regexp(f.field, f.string(), re, message))
+
+	return f
+}
+
+// RFC3339 adds an error if the value isn't a time formatted as RFC 3339.
+func (f *FieldRules) RFC3339() *FieldRules {
+	f.result.Check(RFC3339(f.field, f.string()))
+
+	return f
+}
+
+// Func adds the error returned by fn if it's not nil.
+func (f *FieldRules) Func(fn func(field string, value any) *FieldError) *FieldRules {
+	f.result.Check(fn(f.field, f.value))
+
+	return f
+}
+
+// Result returns the errors of the rules.
+func (f *FieldRules) Result() *ValidationResult {
+	return f.result
+}
+
+// string returns the value if it's a string, or "" if it's a nil pointer.
+func (f *FieldRules) string() string {
+	v := deref(reflect.ValueOf(f.value))
+	if !v.IsValid() {
+		return ""
+	}
+
+	if v.Kind() != reflect.String {
+		panic(fmt.Sprintf("validate: field %q is a %s, not a string", f.field, v.Type()))
+	}
+
+	return v.String()
+}
+
+// number returns the value as float64 if it's a number, or 0 if it's a nil
+// pointer.
+func (f *FieldRules) number() float64 {
+	v := deref(reflect.ValueOf(f.value))
+	if !v.IsValid() {
+		return 0
+	}
+
+	switch {
+	case v.CanInt():
+		return float64(v.Int())
+	case v.CanUint():
+		return float64(v.Uint())
+	case v.CanFloat():
+		return v.Float()
+	default:
+		panic(fmt.Sprintf("validate: field %q is a %s, not a number", f.field, v.Type()))
+	}
+}
+
+// deref returns the value v points to, or an invalid Value if v is nil.
+func deref(v reflect.Value) reflect.Value {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+
+		v = v.Elem()
+	}
+
+	return v
+}
@@ -0,0 +1,50 @@
+package validate_test
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/nielskrijger/goboot/validate"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestField(t *testing.T) {
+	role := "owner"
+
+	result := validate.NewResult()
+	result.Merge(validate.Field("email", "").Required().Email().MaxLen(255).Result())
+	result.Merge(validate.Field("name", "Johnny").MinLen(2).MaxLen(5).Result())
+	result.Merge(validate.Field("age", uint8(200)).Range(0, 150).Result())
+	result.Merge(validate.Field("role", &role).OneOf("admin", "user").Result())
+	result.Merge(validate.Field("slug", "My slug").Regexp(regexp.MustCompile(`^[a-z-]+$`), "must be a slug").Result())
+	result.Merge(validate.Field("website", (*string)(nil)).URL().UUID().RFC3339().Result())
+	result.Merge(validate.Field("id", 0).Func(func(field string, value any) *validate.FieldError {
+		return validate.Range(field, value.(int), 1, 10)
+	}).Result())
+
+	codes := make([]string, len(result.Errors))
+	for i, e := range result.Errors {
+		codes[i] = e.Field + ":" + e.Code
+	}
+
+	assert.Equal(t, []string{
+		"email:required",
+		"name:too_long",
+		"age:out_of_range",
+		"role:not_allowed",
+		"slug:invalid_format",
+		"id:out_of_range",
+	}, codes)
+	assert.Equal(t, "must be between 0 and 150", result.Errors[2].Message)
+	assert.Equal(t, "must be one of admin, user", result.Errors[3].Message)
+}
+
+func TestField_Valid(t *testing.T) {
+	result := validate.Field("email", "john@example.com").Required().Email().MaxLen(255).Result()
+	assert.True(t, result.Valid())
+}
+
+func TestField_PanicsOnWrongType(t *testing.T) {
+	assert.Panics(t, func() { validate.Field("age", 30).Email() })
+	assert.Panics(t, func() { validate.Field("name", "John").Range(0, 10) })
+}
@@ -0,0 +1,309 @@
+package validate
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/rs/zerolog"
+)
+
+// MaxBodySize is the maximum size in bytes of request bodies read by Decode,
+// defaults to 1 MiB.
+var MaxBodySize int64 = 1 << 20 //nolint:gochecknoglobals
+
+var errTrailingData = errors.New("unexpected data after the JSON value")
+
+// HandlerFunc handles a request with its decoded and validated body, see JSON.
+type HandlerFunc[T any] func(w http.ResponseWriter, r *http.Request, body T)
+
+// Validator is implemented by request bodies with hand-written checks in
+// addition to their struct tags.
+type Validator interface {
+	Validate() *ValidationResult
+}
+
+// JSON returns a handler decoding the JSON request body into T, validating it
+// and calling next with the body if it's valid, e.g.:
+//
+//	mux.Handle("/users", validate.JSON(func(w http.ResponseWriter, r *http.Request, req CreateUserRequest) {
+//		...
+//	}))
+//
+// See Decode for the responses to invalid requests.
+func JSON[T any](next HandlerFunc[T]) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if body, ok := Decode[T](w, r); ok {
+			next(w, r, body)
+		}
+	})
+}
+
+// Decode decodes the JSON request body into T, sanitizes it using Sanitize
+// and validates it using Struct, and Validate if T implements Validator. It
+// writes a problem response and returns false if the request is invalid:
+//
+//   - 415 Unsupported Media Type if the content type isn't JSON;
+//   - 400 Bad Request if the body is missing or null, isn't valid JSON or is invalid;
+//   - 413 Request Entity Too Large if the body exceeds MaxBodySize.
+//
+// Values of the wrong type and unknown fields are reported along with the
+// errors of validating the body. The values changed by Sanitize are logged at
+// debug level using the logger of the request context, see zerolog.Ctx.
+//
+// Decode panics if T is not a struct or a pointer to one.
+func Decode[T any](w http.ResponseWriter, r *http.Request) (T, bool) {
+	var body T
+
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType != "" && mediaType != "application/json" {
+		writeProblem(w, http.StatusUnsupportedMediaType, "The request body must be JSON.")
+
+		return body, false
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, MaxBodySize)
+
+	result, err := decodeJSON(r, &body)
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			writeProblem(w, http.StatusRequestEntityTooLarge, "The request body is too large.")
+		} else {
+			writeProblem(w, http.StatusBadRequest, "The request body is not valid JSON.")
+		}
+
+		return body, false
+	}
+
+	// a JSON null decodes to a nil pointer, which is a missing body
+	if isNilPointer(body) {
+		writeProblem(w, http.StatusBadRequest, "The request body is not valid JSON.")
+
+		return body, false
+	}
+
+	for _, c := range sanitizeBody(&body) {
+		zerolog.Ctx(r.Context()).Debug().
+			Str("field", c.Field).
+			Str("before", c.Before).
+			Str("after", c.After).
+			Msg("sanitized request body")
+	}
+
+	validation := Struct(body)
+	if v, ok := any(body).(Validator); ok {
+		validation.Merge(v.Validate())
+	}
+
+	// a value of the wrong type is decoded as the zero value, only report
+	// the decoding error of such fields
+	failed := make(map[string]bool, len(result.Errors))
+	for _, e := range result.Errors {
+		failed[e.Field] = true
+	}
+
+	for _, e := range validation.Errors {
+		if !failed[e.Field] {
+			result.Add(e)
+		}
+	}
+
+	if !result.Valid() {
+		WriteProblem(w, result)
+
+		return body, false
+	}
+
+	return body, true
+}
+
+// decodeJSON decodes the request body into v, it returns the errors of
+// values of the wrong type and unknown fields, or an error if the body can't
+// be read or isn't JSON.
+func decodeJSON(r *http.Request, v any) (*ValidationResult, error) {
+	result := NewResult()
+
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		return result, err //nolint:wrapcheck
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+
+	if err := dec.Decode(v); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if decodeError(err, "") == nil || (errors.As(err, &typeErr) && typeErr.Field == "") {
+			return result, err //nolint:wrapcheck
+		}
+
+		// the decoder only returns the first error, check every value
+		checkJSON(data, reflect.TypeOf(v).Elem(), "", result)
+	}
+
+	if dec.More() {
+		return result, errTrailingData
+	}
+
+	return result, nil
+}
+
+// checkJSON adds an error to result for every value in data of the wrong type
+// for t and every unknown field of structs, path is the field path of data.
+func checkJSON(data []byte, t reflect.Type, path string, result *ValidationResult) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	if !isUnmarshaler(t) {
+		switch t.Kind() { //nolint:exhaustive
+		case reflect.Struct:
+			var fields map[string]json.RawMessage
+			if json.Unmarshal(data, &fields) == nil {
+				keys := make([]string, 0, len(fields))
+				for key := range fields {
+					keys = append(keys, key)
+				}
+
+				sort.Strings(keys)
+
+				for _, key := range keys {
+					field, ok := jsonField(t, key)
+					if !ok {
+						result.Add(*newError(joinPath(path, key), CodeUnknownField, nil))
+
+						continue
+					}
+
+					checkJSON(fields[key], field.Type, joinPath(path, fieldName(field)), result)
+				}
+
+				return
+			}
+		case reflect.Slice, reflect.Array:
+			var items []json.RawMessage
+			if json.Unmarshal(data, &items) == nil {
+				for i, item := range items {
+					checkJSON(item, t.Elem(), Path(path, i), result)
+				}
+
+				return
+			}
+		}
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+
+	if err := decodeError(dec.Decode(reflect.New(t).Interface()), path); err != nil {
+		result.Add(*err)
+	}
+}
+
+// decodeError returns the error of a value of the wrong type or an unknown
+// field returned by decoding the value at path, or nil if err is neither.
+func decodeError(err error, path string) *FieldError {
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		return newError(joinPath(path, typeErr.Field), CodeInvalidType, map[string]any{"type": jsonType(typeErr.Type)})
+	}
+
+	if err != nil && strings.HasPrefix(err.Error(), "json: unknown field ") {
+		field, _ := strconv.Unquote(strings.TrimPrefix(err.Error(), "json: unknown field "))
+
+		return newError(joinPath(path, field), CodeUnknownField, nil)
+	}
+
+	return nil
+}
+
+// jsonField returns the field of struct type t decoded from JSON key, which
+// like encoding/json prefers an exact match over a case-insensitive one.
+func jsonField(t reflect.Type, key string) (reflect.StructField, bool) {
+	var (
+		match reflect.StructField
+		found bool
+	)
+
+	for _, field := range reflect.VisibleFields(t) {
+		if !field.IsExported() || (field.Anonymous && field.Tag.Get("json") == "") {
+			continue
+		}
+
+		name := fieldName(field)
+		if name == key {
+			return field, true
+		}
+
+		if !found && strings.EqualFold(name, key) {
+			match, found = field, true
+		}
+	}
+
+	return match, found
+}
+
+// isUnmarshaler returns true if values of type t decode themselves.
+func isUnmarshaler(t reflect.Type) bool {
+	p := reflect.PointerTo(t)
+
+	return p.Implements(reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()) ||
+		p.Implements(reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem())
+}
+
+// jsonType returns the name of the JSON type t decodes from.
+func jsonType(t reflect.Type) string {
+	switch t.Kind() { //nolint:exhaustive
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.String:
+		return "string"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	default:
+		return "object"
+	}
+}
+
+// sanitizeBody sanitizes the struct body points to and returns the changed
+// values, body may be a pointer to a pointer to a struct.
+func sanitizeBody(body any) []Change {
+	rv := reflect.ValueOf(body).Elem()
+	if rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return nil
+		}
+
+		body = rv.Interface()
+		rv = rv.Elem()
+	}
+
+	if rv.Kind() != reflect.Struct {
+		return nil
+	}
+
+	return Sanitize(body)
+}
+
+func isNilPointer(v any) bool {
+	rv := reflect.ValueOf(v)
+
+	return rv.Kind() == reflect.Pointer && rv.IsNil()
+}
+
+func writeProblem(w http.ResponseWriter, status int, detail string) {
+	p := &Problem{Type: "about:blank", Title: http.StatusText(status), Status: status, Detail: detail}
+	p.Write(w)
+}
@@ -0,0 +1,145 @@
+package validate_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/nielskrijger/goboot/validate"
+	"github.com/stretchr/testify/assert"
+)
+
+type createUserRequest struct {
+	Email string `json:"email" validate:"required,email"`
+	Age   int    `json:"age"`
+}
+
+func (r createUserRequest) Validate() *validate.ValidationResult {
+	result := validate.NewResult()
+	result.Check(validate.Range("age", r.Age, 0, 150))
+
+	return result
+}
+
+func TestJSON(t *testing.T) {
+	handler := validate.JSON(func(w http.ResponseWriter, r *http.Request, req createUserRequest) {
+		_, _ = w.Write([]byte("created " + req.Email))
+	})
+
+	for _, tc := range []struct {
+		contentType string
+		body        string
+		status      int
+		expected    string
+	}{
+		{
+			contentType: "application/json; charset=utf-8",
+			body:        `{"email": "john@example.com", "age": 30}`,
+			status:      http.StatusOK,
+			expected:    "created john@example.com",
+		},
+		{
+			contentType: "application/json",
+			body:        `{"email": "john", "age": 200}`,
+			status:      http.StatusBadRequest,
+			expected: `"errors":[` +
+				`{"field":"email","code":"invalid_email","message":"must be a valid email address"},` +
+				`{"field":"age","code":"out_of_range","message":"must be between 0 and 150"}]`,
+		},
+		{
+			body:     `{"email": "john@example.com", "age": 200}`,
+			status:   http.StatusBadRequest,
+			expected: `"errors":[{"field":"age","code":"out_of_range","message":"must be between 0 and 150"}]`,
+		},
+		{
+			body:     `{"email": "john@example.com", "age": "30"}`,
+			status:   http.StatusBadRequest,
+			expected: `"errors":[{"field":"age","code":"invalid_type","message":"must be a number"}]`,
+		},
+		{
+			body:   `{"age": "30", "extra": 1}`,
+			status: http.StatusBadRequest,
+			expected: `"errors":[` +
+				`{"field":"age","code":"invalid_type","message":"must be a number"},` +
+				`{"field":"extra","code":"unknown_field","message":"is not allowed"},` +
+				`{"field":"email","code":"required","message":"is required"}]`,
+		},
+		{
+			body:     `{"email": "john@example.com"} {}`,
+			status:   http.StatusBadRequest,
+			expected: `"detail":"The request body is not valid JSON."`,
+		},
+		{
+			body:     `[]`,
+			status:   http.StatusBadRequest,
+			expected: `"detail":"The request body is not valid JSON."`,
+		},
+		{
+			body:     `{"email": `,
+			status:   http.StatusBadRequest,
+			expected: `"detail":"The request body is not valid JSON."`,
+		},
+		{
+			contentType: "text/plain",
+			body:        `{}`,
+			status:      http.StatusUnsupportedMediaType,
+			expected:    `"detail":"The request body must be JSON."`,
+		},
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(tc.body))
+		if tc.contentType != "" {
+			req.Header.Set("Content-Type", tc.contentType)
+		}
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		assert.Equal(t, tc.status, rec.Code, tc.body)
+		assert.Contains(t, rec.Body.String(), tc.expected)
+	}
+}
+
+type createOrderRequest struct {
+	Items []struct {
+		SKU   string `json:"sku" validate:"required"`
+		Price int    `json:"price"`
+	} `json:"items" validate:"dive"`
+}
+
+func TestDecode_ReportsAllTypeErrors(t *testing.T) {
+	body := `{"items": [{"sku": "a", "price": "1"}, {"price": 2, "color": "red"}, {"sku": 3}]}`
+	req := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(body))
+	rec := httptest.NewRecorder()
+
+	_, ok := validate.Decode[createOrderRequest](rec, req)
+	assert.False(t, ok)
+	assert.Contains(t, rec.Body.String(), `"errors":[`+
+		`{"field":"items[0].price","code":"invalid_type","message":"must be a number"},`+
+		`{"field":"items[1].color","code":"unknown_field","message":"is not allowed"},`+
+		`{"field":"items[2].sku","code":"invalid_type","message":"must be a string"},`+
+		`{"field":"items[1].sku","code":"required","message":"is required"}]`)
+}
+
+func TestDecode_ErrorNullBody(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(`null`))
+	rec := httptest.NewRecorder()
+
+	body, ok := validate.Decode[*createUserRequest](rec, req)
+	assert.False(t, ok)
+	assert.Nil(t, body)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"detail":"The request body is not valid JSON."`)
+}
+
+func TestDecode_ErrorBodyTooLarge(t *testing.T) {
+	defer func(size int64) { validate.MaxBodySize = size }(validate.MaxBodySize)
+	validate.MaxBodySize = 16
+
+	req := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(`{"email": "john@example.com"}`))
+	rec := httptest.NewRecorder()
+
+	_, ok := validate.Decode[createUserRequest](rec, req)
+	assert.False(t, ok)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+}
@@ -0,0 +1,191 @@
+package validate
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"golang.org/x/text/language"
+)
+
+// DefaultLocale is the locale of the messages of new FieldErrors.
+const DefaultLocale = "en"
+
+// singularSuffix is the suffix of the code of a message used when the param
+// of a FieldError is 1, e.g. "too_few.one".
+const singularSuffix = ".one"
+
+var (
+	catalogMu sync.RWMutex
+
+	// catalog contains the messages by locale and code
+	catalog = map[string]map[string]string{
+		"en": {
+			CodeRequired:          "is required",
+			CodeInvalid:           "is invalid",
+			CodeInvalidEmail:      "must be a valid email address",
+			CodeInvalidUUID:       "must be a valid UUID",
+			CodeInvalidURL:        "must be a valid URL",
+			CodeInvalidFormat:     "has an invalid format",
+			CodeInvalidTime:       "must be a time formatted as RFC 3339",
+			CodeInvalidLength:     "must have a length of {len}",
+			CodeInvalidType:       "must be a {type}",
+			CodeTooShort:          "must be at least {min} characters",
+			CodeTooLong:           "must be at most {max} characters",
+			CodeTooFew:            "must contain at least {min} items",
+			CodeTooMany:           "must contain at most {max} items",
+			CodeTooSmall:          "must be at least {min}",
+			CodeTooLarge:          "must be at most {max}",
+			CodeTooSmallExclusive: "must be greater than {min}",
+			CodeTooLargeExclusive: "must be less than {max}",
+			CodeOutOfRange:        "must be between {min} and {max}",
+			CodeNotAllowed:        "must be one of {allowed}",
+			CodeMutuallyExclusive: "cannot be combined with {others}",
+			CodeUnknownField:      "is not allowed",
+
+			CodeTooShort + singularSuffix: "must be at least {min} character",
+			CodeTooLong + singularSuffix:  "must be at most {max} character",
+			CodeTooFew + singularSuffix:   "must contain at least {min} item",
+			CodeTooMany + singularSuffix:  "must contain at most {max} item",
+		},
+		"nl": {
+			CodeRequired:          "is verplicht",
+			CodeInvalid:           "is ongeldig",
+			CodeInvalidEmail:      "moet een geldig e-mailadres zijn",
+			CodeInvalidUUID:       "moet een geldige UUID zijn",
+			CodeInvalidURL:        "moet een geldige URL zijn",
+			CodeInvalidFormat:     "heeft een ongeldig formaat",
+			CodeInvalidTime:       "moet een tijd in RFC 3339-formaat zijn",
+			CodeInvalidLength:     "moet een lengte van {len} hebben",
+			CodeInvalidType:       "moet een {type} zijn",
+			CodeTooShort:          "moet minimaal {min} tekens bevatten",
+			CodeTooLong:           "mag maximaal {max} tekens bevatten",
+			CodeTooFew:            "moet minimaal {min} items bevatten",
+			CodeTooMany:           "mag maximaal {max} items bevatten",
+			CodeTooSmall:          "moet minimaal {min} zijn",
+			CodeTooLarge:          "mag maximaal {max} zijn",
+			CodeTooSmallExclusive: "moet groter zijn dan {min}",
+			CodeTooLargeExclusive: "moet kleiner zijn dan {max}",
+			CodeOutOfRange:        "moet tussen {min} en {max} liggen",
+			CodeNotAllowed:        "moet een van {allowed} zijn",
+			CodeMutuallyExclusive: "kan niet gecombineerd worden met {others}",
+			CodeUnknownField:      "is niet toegestaan",
+
+			CodeTooShort + singularSuffix: "moet minimaal {min} teken bevatten",
+			CodeTooLong + singularSuffix:  "mag maximaal {max} teken bevatten",
+			CodeTooFew + singularSuffix:   "moet minimaal {min} item bevatten",
+			CodeTooMany + singularSuffix:  "mag maximaal {max} item bevatten",
+		},
+	}
+)
+
+// RegisterMessages adds or replaces the messages of locale by code, e.g.:
+//
+//	validate.RegisterMessages("de", map[string]string{
+//		"required": "ist erforderlich",
+//		"too_long": "darf höchstens {max} Zeichen lang sein",
+//	})
+//
+// Messages refer to the Params of a FieldError by name, e.g. "{max}". Add
+// the code with suffix ".one" for the singular message used when the param
+// is 1, e.g. "too_long.one": "darf höchstens {max} Zeichen lang sein".
+func RegisterMessages(locale string, messages map[string]string) {
+	catalogMu.Lock()
+	defer catalogMu.Unlock()
+
+	if catalog[locale] == nil {
+		catalog[locale] = make(map[string]string, len(messages))
+	}
+
+	for code, msg := range messages {
+		catalog[locale][code] = msg
+	}
+}
+
+// lookupMessage returns the message of code in locale, or in its parent
+// locales like "nl" for "nl-BE".
+func lookupMessage(locale string, code string) (string, bool) {
+	catalogMu.RLock()
+	defer catalogMu.RUnlock()
+
+	if msg, ok := catalog[locale][code]; ok {
+		return msg, true
+	}
+
+	tag, err := language.Parse(locale)
+	if err != nil {
+		return "", false
+	}
+
+	for ; tag != language.Und; tag = tag.Parent() {
+		if msg, ok := catalog[tag.String()][code]; ok {
+			return msg, true
+		}
+	}
+
+	return "", false
+}
+
+// message returns the message of code in locale interpolated with params,
+// using the singular message if the only param is 1.
+func message(locale string, code string, params map[string]any) (string, bool) {
+	if len(params) == 1 {
+		for _, v := range params {
+			if fmt.Sprint(v) != "1" {
+				break
+			}
+
+			if msg, ok := lookupMessage(locale, code+singularSuffix); ok {
+				return interpolate(msg, params), true
+			}
+		}
+	}
+
+	msg, ok := lookupMessage(locale, code)
+
+	return interpolate(msg, params), ok
+}
+
+// interpolate replaces the {name} placeholders in msg by params.
+func interpolate(msg string, params map[string]any) string {
+	if len(params) == 0 {
+		return msg
+	}
+
+	pairs := make([]string, 0, len(params)*2)
+	for name, value := range params {
+		pairs = append(pairs, "{"+name+"}", fmt.Sprint(value))
+	}
+
+	return strings.NewReplacer(pairs...).Replace(msg)
+}
+
+// newError returns a FieldError with the DefaultLocale message of code.
+func newError(field string, code string, params map[string]any) *FieldError {
+	msg, _ := message(DefaultLocale, code, params)
+
+	return &FieldError{Field: field, Code: code, Message: msg, Params: params}
+}
+
+// Translate returns the error with its message in locale, e.g. "nl" or
+// "nl-BE". The message is unchanged if there is no message of its code in
+// locale.
+func (e FieldError) Translate(locale string) FieldError {
+	if msg, ok := message(locale, e.Code, e.Params); ok {
+		e.Message = msg
+	}
+
+	return e
+}
+
+// Translate returns a copy of the result with its messages in locale, see
+// FieldError.Translate.
+func (r *ValidationResult) Translate(locale string) *ValidationResult {
+	translated := &ValidationResult{Errors: make([]FieldError, len(r.Errors))}
+
+	for i, e := range r.Errors {
+		translated.Errors[i] = e.Translate(locale)
+	}
+
+	return translated
+}
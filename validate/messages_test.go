@@ -0,0 +1,59 @@
+package validate_test
+
+import (
+	"testing"
+
+	"github.com/nielskrijger/goboot/validate"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidationResult_Translate(t *testing.T) {
+	result := validate.NewResult()
+	result.Check(
+		validate.Required("email", ""),
+		validate.MaxLen("name", "Johnny", 5),
+		validate.Range("age", 200, 0, 150),
+	)
+	result.AddError("name", "taken", "is already taken")
+
+	nl := result.Translate("nl-BE")
+	assert.Equal(t, []string{
+		"is verplicht",
+		"mag maximaal 5 tekens bevatten",
+		"moet tussen 0 en 150 liggen",
+		"is already taken",
+	}, messages(nl))
+
+	// the result itself is unchanged
+	assert.Equal(t, "is required", result.Errors[0].Message)
+
+	fr := result.Translate("fr")
+	assert.Equal(t, messages(result), messages(fr))
+}
+
+func TestRegisterMessages(t *testing.T) {
+	validate.RegisterMessages("de", map[string]string{
+		"too_long": "darf höchstens {max} Zeichen lang sein",
+	})
+
+	err := validate.MaxLen("name", "Johnny", 5).Translate("de-AT")
+	assert.Equal(t, "darf höchstens 5 Zeichen lang sein", err.Message)
+}
+
+func TestFieldError_Singular(t *testing.T) {
+	err := validate.MaxLen("name", "Jo", 1)
+	assert.Equal(t, "must be at most 1 character", err.Message)
+	assert.Equal(t, "mag maximaal 1 teken bevatten", err.Translate("nl").Message)
+
+	err = validate.MaxLen("name", "Johnny", 2)
+	assert.Equal(t, "must be at most 2 characters", err.Message)
+}
+
+func messages(result *validate.ValidationResult) []string {
+	msgs := make([]string, len(result.Errors))
+	for i, e := range result.Errors {
+		msgs[i] = e.Message
+	}
+
+	return msgs
+}
@@ -0,0 +1,60 @@
+package validate
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Path joins field names and slice indices into a field path, e.g.
+// Path("items", 2, "price") returns "items[2].price". Elements are either
+// strings, which may be paths themselves, or ints.
+func Path(elems ...any) string {
+	var b strings.Builder
+
+	for _, elem := range elems {
+		switch e := elem.(type) {
+		case int:
+			b.WriteString("[" + strconv.Itoa(e) + "]")
+		case string:
+			b.WriteString(joinPath(b.String(), e)[b.Len():])
+		default:
+			panic("validate: path element must be a string or int")
+		}
+	}
+
+	return b.String()
+}
+
+// joinPath returns path prefixed with prefix, e.g. "address.zip" for prefix
+// "address" and path "zip", or "items[2]" for "items" and "[2]".
+func joinPath(prefix string, path string) string {
+	switch {
+	case prefix == "":
+		return path
+	case path == "":
+		return prefix
+	case strings.HasPrefix(path, "["):
+		return prefix + path
+	default:
+		return prefix + "." + path
+	}
+}
+
+// WithPrefix returns the error with its field prefixed with path, e.g.
+// "address.zip" for path "address" and field "zip".
+func (e FieldError) WithPrefix(path string) FieldError {
+	e.Field = joinPath(path, e.Field)
+
+	return e
+}
+
+// Prefix prefixes the fields of all errors with path and returns the result,
+// e.g. "address.zip" for path "address" and field "zip". See Prefixed to add
+// the errors of validating a nested struct to another result.
+func (r *ValidationResult) Prefix(path string) *ValidationResult {
+	for i, e := range r.Errors {
+		r.Errors[i] = e.WithPrefix(path)
+	}
+
+	return r
+}
@@ -0,0 +1,41 @@
+package validate_test
+
+import (
+	"testing"
+
+	"github.com/nielskrijger/goboot/validate"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPath(t *testing.T) {
+	assert.Equal(t, "items[2].price", validate.Path("items", 2, "price"))
+	assert.Equal(t, "address.zip", validate.Path("address", "zip"))
+	assert.Equal(t, "matrix[0][1]", validate.Path("matrix", 0, 1))
+	assert.Equal(t, "[0].name", validate.Path(0, "name"))
+	assert.Equal(t, "order.items[2].price", validate.Path("order.items", 2, "price"))
+	assert.Panics(t, func() { validate.Path(1.5) })
+}
+
+func TestValidationResult_Prefix(t *testing.T) {
+	item := validate.NewResult()
+	item.Check(
+		validate.Range("price", -1, 0, 100),
+		validate.Required("", ""),
+	)
+
+	result := validate.NewResult()
+	result.Add(item.Prefix(validate.Path("items", 2)).Errors...)
+
+	assert.Equal(t, "items[2].price", result.Errors[0].Field)
+	assert.Equal(t, "items[2]", result.Errors[1].Field)
+
+	nested := validate.Struct(createUser{Address: &address{}})
+	nested.Prefix("users[0]")
+
+	fields := make([]string, len(nested.Errors))
+	for i, e := range nested.Errors {
+		fields[i] = e.Field
+	}
+
+	assert.Contains(t, fields, "users[0].address.zip")
+}
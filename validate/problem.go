@@ -0,0 +1,80 @@
+package validate
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// ProblemContentType is the media type of Problem responses.
+const ProblemContentType = "application/problem+json"
+
+// Problem is an RFC 7807 problem details response, see
+// https://www.rfc-editor.org/rfc/rfc7807.
+type Problem struct {
+	// Type is a URI identifying the problem type, "about:blank" if the
+	// problem has no semantics beyond its HTTP status.
+	Type string `json:"type"`
+
+	// Title is a short summary of the problem type.
+	Title string `json:"title"`
+
+	// Status is the HTTP status code.
+	Status int `json:"status"`
+
+	// Detail explains this occurrence of the problem.
+	Detail string `json:"detail,omitempty"`
+
+	// Instance is a URI identifying this occurrence of the problem, e.g. the
+	// request path.
+	Instance string `json:"instance,omitempty"`
+
+	// Errors are the fields violating validation rules.
+	Errors []FieldError `json:"errors,omitempty"`
+
+	// CorrelationID identifies the request in the logs, e.g. to report an
+	// internal server error.
+	CorrelationID string `json:"correlationId,omitempty"`
+}
+
+// ToProblem returns a 400 Bad Request problem listing the errors of result.
+func ToProblem(result *ValidationResult) *Problem {
+	return &Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(http.StatusBadRequest),
+		Status: http.StatusBadRequest,
+		Detail: "The request contains invalid fields.",
+		Errors: result.Errors,
+	}
+}
+
+// ServeHTTP writes the problem as response.
+func (p *Problem) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	p.Write(w)
+}
+
+// Write writes the problem as response with content type
+// "application/problem+json".
+func (p *Problem) Write(w http.ResponseWriter) {
+	b, err := json.Marshal(p)
+	if err != nil {
+		// not possible, a Problem contains no values that fail to marshal
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", ProblemContentType)
+	w.WriteHeader(p.Status)
+	_, _ = w.Write(b)
+}
+
+// WriteProblem writes the errors of result as 400 Bad Request problem, e.g.:
+//
+//	if result := validate.Struct(req); !result.Valid() {
+//		validate.WriteProblem(w, result)
+//
+//		return
+//	}
+func WriteProblem(w http.ResponseWriter, result *ValidationResult) {
+	ToProblem(result).Write(w)
+}
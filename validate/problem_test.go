@@ -0,0 +1,49 @@
+package validate_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/nielskrijger/goboot/validate"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteProblem(t *testing.T) {
+	result := validate.NewResult()
+	result.AddError("email", "required", "is required")
+	result.AddError("items[1].price", "out_of_range", "must be between 0 and 100")
+
+	rec := httptest.NewRecorder()
+	validate.WriteProblem(rec, result)
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Equal(t, "application/problem+json", rec.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{
+		"type": "about:blank",
+		"title": "Bad Request",
+		"status": 400,
+		"detail": "The request contains invalid fields.",
+		"errors": [
+			{"field": "email", "code": "required", "message": "is required"},
+			{"field": "items[1].price", "code": "out_of_range", "message": "must be between 0 and 100"}
+		]
+	}`, rec.Body.String())
+}
+
+func TestProblem_ServeHTTP(t *testing.T) {
+	problem := validate.ToProblem(validate.NewResult())
+	problem.Instance = "/users"
+
+	rec := httptest.NewRecorder()
+	problem.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/users", nil))
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.JSONEq(t, `{
+		"type": "about:blank",
+		"title": "Bad Request",
+		"status": 400,
+		"detail": "The request contains invalid fields.",
+		"instance": "/users"
+	}`, rec.Body.String())
+}
@@ -0,0 +1,145 @@
+// Package validate validates input like request bodies and reports all
+// violations as FieldErrors in a ValidationResult, whether they're found by
+// struct tags or hand-written checks.
+package validate
+
+import (
+	"fmt"
+	"strings"
+)
+
+// FieldError is a violation of a validation rule by a field.
+type FieldError struct {
+	// Field is the path of the field, e.g. "email", or empty if the error
+	// doesn't concern a single field.
+	Field string `json:"field"`
+
+	// Code identifies the rule that was violated, e.g. "required".
+	Code string `json:"code"`
+
+	// Message describes the violation, e.g. "is required".
+	Message string `json:"message"`
+
+	// Params are the parameters of the rule used in the message, e.g. "max"
+	// in "must be at most {max} characters", see Translate.
+	Params map[string]any `json:"-"`
+}
+
+func (e FieldError) Error() string {
+	if e.Field == "" {
+		return e.Message
+	}
+
+	return e.Field + " " + e.Message
+}
+
+// ValidationResult contains the errors of validating input.
+type ValidationResult struct {
+	Errors []FieldError `json:"errors"`
+}
+
+// NewResult returns an empty ValidationResult.
+func NewResult() *ValidationResult {
+	return &ValidationResult{}
+}
+
+// Add adds errors to the result.
+func (r *ValidationResult) Add(errs ...FieldError) {
+	r.Errors = append(r.Errors, errs...)
+}
+
+// AddError adds an error of field to the result.
+func (r *ValidationResult) AddError(field string, code string, message string) {
+	r.Add(FieldError{Field: field, Code: code, Message: message})
+}
+
+// Check adds the errors returned by validators, nil errors are ignored.
+func (r *ValidationResult) Check(errs ...*FieldError) {
+	for _, err := range errs {
+		if err != nil {
+			r.Add(*err)
+		}
+	}
+}
+
+// Merge adds the errors of other, which may be nil.
+func (r *ValidationResult) Merge(other *ValidationResult) {
+	if other != nil {
+		r.Add(other.Errors...)
+	}
+}
+
+// Prefixed returns a view of the result that adds errors with their fields
+// prefixed with path, e.g. to validate a nested struct:
+//
+//	addr := result.Prefixed("address")
+//	addr.Check(validate.Required("zip", req.Address.Zip))
+//	addr.AddError("country", "unsupported_country", "is not supported")
+func (r *ValidationResult) Prefixed(path string) *PrefixedResult {
+	return &PrefixedResult{result: r, prefix: path}
+}
+
+// Valid returns true if the result has no errors.
+func (r *ValidationResult) Valid() bool {
+	return len(r.Errors) == 0
+}
+
+// Err returns the result as error, or nil if the result has no errors.
+func (r *ValidationResult) Err() error {
+	if r.Valid() {
+		return nil
+	}
+
+	return r
+}
+
+func (r *ValidationResult) Error() string {
+	msgs := make([]string, len(r.Errors))
+	for i, e := range r.Errors {
+		msgs[i] = e.Error()
+	}
+
+	return fmt.Sprintf("validation failed: %s", strings.Join(msgs, "; "))
+}
+
+// PrefixedResult adds errors to a ValidationResult with their fields
+// prefixed, see ValidationResult.Prefixed.
+type PrefixedResult struct {
+	result *ValidationResult
+	prefix string
+}
+
+// Add adds errors with their fields prefixed.
+func (p *PrefixedResult) Add(errs ...FieldError) {
+	for _, e := range errs {
+		p.result.Add(e.WithPrefix(p.prefix))
+	}
+}
+
+// AddError adds an error of field with its field prefixed.
+func (p *PrefixedResult) AddError(field string, code string, message string) {
+	p.Add(FieldError{Field: field, Code: code, Message: message})
+}
+
+// Check adds the errors returned by validators with their fields prefixed.
+func (p *PrefixedResult) Check(errs ...*FieldError) {
+	for _, err := range errs {
+		if err != nil {
+			p.Add(*err)
+		}
+	}
+}
+
+// Merge adds the errors of other, which may be nil, with their fields
+// prefixed.
+func (p *PrefixedResult) Merge(other *ValidationResult) {
+	if other != nil {
+		p.Add(other.Errors...)
+	}
+}
+
+// Prefixed returns a view adding errors with their fields prefixed with both
+// prefixes, e.g. "items[2].price" for "items", 2 and "price".
+func (p *PrefixedResult) Prefixed(path string) *PrefixedResult {
+	return &PrefixedResult{result: p.result, prefix: joinPath(p.prefix, path)}
+}
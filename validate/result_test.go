@@ -0,0 +1,52 @@
+package validate_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/nielskrijger/goboot/validate"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidationResult_Err(t *testing.T) {
+	result := validate.NewResult()
+	assert.Nil(t, result.Err())
+
+	result.AddError("email", "required", "is required")
+	result.AddError("", "conflict", "user already exists")
+
+	err := result.Err()
+	assert.EqualError(t, err, "validation failed: email is required; user already exists")
+
+	var target *validate.ValidationResult
+	assert.True(t, errors.As(err, &target))
+	assert.Len(t, target.Errors, 2)
+}
+
+func TestValidationResult_MergeAndPrefixed(t *testing.T) {
+	zip := validate.NewResult()
+	zip.AddError("zip", "required", "is required")
+
+	result := validate.NewResult()
+	result.Merge(nil)
+	result.AddError("email", "required", "is required")
+
+	addr := result.Prefixed("address")
+	addr.Merge(zip)
+	addr.Check(validate.MaxLen("city", "Amsterdam", 3), nil)
+	addr.AddError("", "unsupported", "is not supported")
+
+	items := result.Prefixed("items")
+	items.Prefixed("[2]").Prefixed("price").AddError("", "too_small", "must be at least 0")
+
+	other := validate.NewResult()
+	other.AddError("name", "required", "is required")
+	result.Merge(other)
+
+	fields := make([]string, len(result.Errors))
+	for i, e := range result.Errors {
+		fields[i] = e.Field
+	}
+
+	assert.Equal(t, []string{"email", "address.zip", "address.city", "address", "items[2].price", "name"}, fields)
+}
@@ -0,0 +1,159 @@
+package validate
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Rule validates a struct as a whole, e.g. to check fields depending on
+// other fields. Pass rules to Struct:
+//
+//	result := validate.Struct(req,
+//		validate.RequiredIf("vatNumber", "type", "company"),
+//		validate.MutuallyExclusive("email", "phone"),
+//		validate.When(req.Notify, validate.Check(validate.Required("email", req.Email))),
+//	)
+//
+// Rules refer to fields by their path like in FieldErrors, e.g. "address.zip".
+type Rule func(v reflect.Value) []FieldError
+
+// When returns a rule applying rules only if cond is true.
+func When(cond bool, rules ...Rule) Rule {
+	return func(v reflect.Value) []FieldError {
+		if !cond {
+			return nil
+		}
+
+		var errs []FieldError
+		for _, rule := range rules {
+			errs = append(errs, rule(v)...)
+		}
+
+		return errs
+	}
+}
+
+// Check returns a rule adding the errors returned by field validators, nil
+// errors are ignored.
+func Check(errs ...*FieldError) Rule {
+	return func(reflect.Value) []FieldError {
+		var result []FieldError
+
+		for _, err := range errs {
+			if err != nil {
+				result = append(result, *err)
+			}
+		}
+
+		return result
+	}
+}
+
+// RequiredIf returns a rule requiring field if otherField equals value, e.g.
+// RequiredIf("vatNumber", "type", "company").
+func RequiredIf(field string, otherField string, value any) Rule {
+	return func(v reflect.Value) []FieldError {
+		other := lookupField(v, otherField)
+		if !equalValue(other, value) || !isZero(lookupField(v, field)) {
+			return nil
+		}
+
+		return []FieldError{*newError(field, CodeRequired, map[string]any{"other": otherField, "value": value})}
+	}
+}
+
+// MutuallyExclusive returns a rule allowing at most one of fields to be set,
+// every set field gets an error if more are set.
+func MutuallyExclusive(fields ...string) Rule {
+	return func(v reflect.Value) []FieldError {
+		var set []string
+
+		for _, field := range fields {
+			if !isZero(lookupField(v, field)) {
+				set = append(set, field)
+			}
+		}
+
+		if len(set) < 2 { //nolint:gomnd
+			return nil
+		}
+
+		errs := make([]FieldError, len(set))
+
+		for i, field := range set {
+			others := make([]string, 0, len(set)-1)
+			others = append(others, set[:i]...)
+			others = append(others, set[i+1:]...)
+
+			errs[i] = *newError(field, CodeMutuallyExclusive, map[string]any{"others": strings.Join(others, ", ")})
+		}
+
+		return errs
+	}
+}
+
+// lookupField returns the field at path in struct v by the JSON or Go names of
+// the fields, nil pointers along the way return an invalid zero Value.
+//
+// lookupField panics if path doesn't exist, which is a programming error.
+func lookupField(v reflect.Value, path string) reflect.Value {
+	for _, name := range strings.Split(path, ".") {
+		for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+			if v.IsNil() {
+				return reflect.Value{}
+			}
+
+			v = v.Elem()
+		}
+
+		if v.Kind() != reflect.Struct {
+			panic(fmt.Sprintf("validate: field %q of %q is not a struct", name, path))
+		}
+
+		field, ok := structField(v.Type(), name)
+		if !ok {
+			panic(fmt.Sprintf("validate: unknown field %q in %s", path, v.Type()))
+		}
+
+		v = v.FieldByIndex(field.Index)
+	}
+
+	return v
+}
+
+// structField returns the field of struct type t by its JSON or Go name.
+func structField(t reflect.Type, name string) (reflect.StructField, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		if jsonName, _, _ := strings.Cut(field.Tag.Get("json"), ","); jsonName == name {
+			return field, true
+		}
+	}
+
+	return t.FieldByName(name)
+}
+
+// equalValue returns true if the dereferenced field equals value, value is
+// converted to the type of the field if they are of the same kind, e.g. an
+// untyped constant to a named string type.
+func equalValue(field reflect.Value, value any) bool {
+	field = deref(field)
+	if !field.IsValid() {
+		return value == nil
+	}
+
+	val := reflect.ValueOf(value)
+	if val.IsValid() && val.Kind() == field.Kind() && val.Type().ConvertibleTo(field.Type()) {
+		val = val.Convert(field.Type())
+	}
+
+	return val.IsValid() && val.Type() == field.Type() && reflect.DeepEqual(val.Interface(), field.Interface())
+}
+
+// isZero returns true if v is the zero value of its type or a field behind a
+// nil pointer.
+func isZero(v reflect.Value) bool {
+	return !v.IsValid() || v.IsZero()
+}
@@ -0,0 +1,75 @@
+package validate_test
+
+import (
+	"testing"
+
+	"github.com/nielskrijger/goboot/validate"
+	"github.com/stretchr/testify/assert"
+)
+
+type accountType string
+
+type contact struct {
+	Phone string `json:"phone"`
+}
+
+type signup struct {
+	Type      accountType `json:"type" validate:"oneof=person company"`
+	VATNumber string      `json:"vatNumber"`
+	Email     string      `json:"email"`
+	Contact   *contact    `json:"contact"`
+	Notify    bool        `json:"notify"`
+}
+
+func TestStruct_Rules(t *testing.T) {
+	req := signup{Type: "company", Email: "john@example.com", Contact: &contact{Phone: "0612345678"}, Notify: true}
+
+	result := validate.Struct(req,
+		validate.RequiredIf("vatNumber", "type", "company"),
+		validate.MutuallyExclusive("email", "contact.phone"),
+		validate.When(req.Notify, validate.Check(validate.MaxLen("email", req.Email, 10))),
+		validate.When(!req.Notify, validate.Check(validate.Required("email", ""))),
+	)
+
+	assert.Equal(t, []validate.FieldError{
+		{
+			Field:   "vatNumber",
+			Code:    "required",
+			Message: "is required",
+			Params:  map[string]any{"other": "type", "value": "company"},
+		},
+		{
+			Field:   "email",
+			Code:    "mutually_exclusive",
+			Message: "cannot be combined with contact.phone",
+			Params:  map[string]any{"others": "contact.phone"},
+		},
+		{
+			Field:   "contact.phone",
+			Code:    "mutually_exclusive",
+			Message: "cannot be combined with email",
+			Params:  map[string]any{"others": "email"},
+		},
+		{
+			Field:   "email",
+			Code:    "too_long",
+			Message: "must be at most 10 characters",
+			Params:  map[string]any{"max": 10},
+		},
+	}, result.Errors)
+}
+
+func TestStruct_RulesValid(t *testing.T) {
+	result := validate.Struct(&signup{Type: "person", Email: "john@example.com"},
+		validate.RequiredIf("vatNumber", "type", "company"),
+		validate.MutuallyExclusive("email", "contact.phone"),
+	)
+
+	assert.True(t, result.Valid())
+}
+
+func TestStruct_RulesPanicOnUnknownField(t *testing.T) {
+	assert.Panics(t, func() {
+		validate.Struct(signup{Type: "person"}, validate.MutuallyExclusive("email", "fax"))
+	})
+}
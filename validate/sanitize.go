@@ -0,0 +1,188 @@
+package validate
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Sanitizer normalizes a string, e.g. strings.TrimSpace.
+type Sanitizer func(string) string
+
+// Change is a value changed by Sanitize.
+type Change struct {
+	Field  string
+	Before string
+	After  string
+}
+
+var (
+	sanitizersMu sync.RWMutex
+
+	// sanitizers are the sanitizers by name used in `sanitize` tags
+	sanitizers = map[string]Sanitizer{
+		"trim":      strings.TrimSpace,
+		"lower":     strings.ToLower,
+		"upper":     strings.ToUpper,
+		"collapse":  collapseSpace,
+		"nfc":       norm.NFC.String,
+		"nfkc":      norm.NFKC.String,
+		"nocontrol": stripControl,
+		"email":     func(s string) string { return strings.ToLower(strings.TrimSpace(s)) },
+	}
+)
+
+// RegisterSanitizer adds a sanitizer used in `sanitize` tags by name, e.g.
+// RegisterSanitizer("digits", onlyDigits) for `sanitize:"digits"`.
+func RegisterSanitizer(name string, s Sanitizer) {
+	sanitizersMu.Lock()
+	defer sanitizersMu.Unlock()
+
+	sanitizers[name] = s
+}
+
+// Sanitize normalizes the string fields of the struct v points to using the
+// comma-separated sanitizers of their `sanitize` tags, applied in order:
+//
+//	type CreateUserRequest struct {
+//		Email string `json:"email" sanitize:"email" validate:"required,email"`
+//		Name  string `json:"name" sanitize:"nocontrol,nfc,collapse,trim"`
+//	}
+//
+// Built-in sanitizers are:
+//
+//   - trim: removes leading and trailing whitespace;
+//   - lower and upper: change the case;
+//   - collapse: trims and replaces runs of whitespace by a single space;
+//   - nfc and nfkc: normalize unicode, e.g. "e" followed by a combining
+//     accent to "é" (nfkc also normalizes compatibility characters like "ﬁ");
+//   - nocontrol: removes control characters other than newlines and tabs;
+//   - email: trims and lowercases.
+//
+// Tags apply to strings, pointers to strings and slices of strings. Nested
+// structs, pointers to structs and slices of structs are sanitized too, a
+// pointer reached more than once is followed only the first time. Sanitize
+// returns the changed values with their field paths, e.g. to log
+// them. Sanitize runs before validation in Decode.
+//
+// Sanitize panics if v is not a pointer to a struct or a tag refers to an
+// unknown sanitizer.
+func Sanitize(v any) []Change {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.Elem().Kind() != reflect.Struct {
+		panic(fmt.Sprintf("validate: cannot sanitize %T, must be a pointer to a struct", v))
+	}
+
+	st := &sanitizeState{visited: make(map[uintptr]bool)}
+	st.sanitizeStruct(rv.Elem(), "")
+
+	return st.changes
+}
+
+// sanitizeState holds the changes of a Sanitize call and the pointers it
+// followed, so cyclic pointers are sanitized once.
+type sanitizeState struct {
+	changes []Change
+	visited map[uintptr]bool
+}
+
+func (st *sanitizeState) sanitizeStruct(v reflect.Value, path string) {
+	t := v.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name := fieldName(field)
+		if name == "" {
+			continue
+		}
+
+		var fns []Sanitizer
+		if tag := field.Tag.Get("sanitize"); tag != "" {
+			fns = lookupSanitizers(tag)
+		}
+
+		st.sanitizeValue(v.Field(i), joinPath(path, name), fns)
+	}
+}
+
+func (st *sanitizeState) sanitizeValue(v reflect.Value, path string, fns []Sanitizer) {
+	switch v.Kind() { //nolint:exhaustive
+	case reflect.Pointer:
+		if v.IsNil() || st.visited[v.Pointer()] {
+			return
+		}
+
+		st.visited[v.Pointer()] = true
+		st.sanitizeValue(v.Elem(), path, fns)
+	case reflect.Struct:
+		st.sanitizeStruct(v, path)
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			st.sanitizeValue(v.Index(i), Path(path, i), fns)
+		}
+	case reflect.String:
+		if len(fns) == 0 || !v.CanSet() {
+			return
+		}
+
+		before := v.String()
+		after := before
+
+		for _, fn := range fns {
+			after = fn(after)
+		}
+
+		if after != before {
+			v.SetString(after)
+			st.changes = append(st.changes, Change{Field: path, Before: before, After: after})
+		}
+	}
+}
+
+func lookupSanitizers(tag string) []Sanitizer {
+	sanitizersMu.RLock()
+	defer sanitizersMu.RUnlock()
+
+	names := strings.Split(tag, ",")
+	fns := make([]Sanitizer, len(names))
+
+	for i, name := range names {
+		fn, ok := sanitizers[strings.TrimSpace(name)]
+		if !ok {
+			panic(fmt.Sprintf("validate: unknown sanitizer %q", name))
+		}
+
+		fns[i] = fn
+	}
+
+	return fns
+}
+
+// collapseSpace replaces runs of whitespace by a single space and trims s.
+func collapseSpace(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// stripControl removes control characters other than newlines and tabs, as
+// well as the zero width and bidirectional formatting characters.
+func stripControl(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '\n' || r == '\t' {
+			return r
+		}
+
+		if unicode.IsControl(r) || unicode.Is(unicode.Cf, r) {
+			return -1
+		}
+
+		return r
+	}, s)
+}
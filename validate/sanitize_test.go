@@ -0,0 +1,123 @@
+package validate_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/nielskrijger/goboot/validate"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+)
+
+type profile struct {
+	Email   string   `json:"email" sanitize:"email" validate:"email"`
+	Name    *string  `json:"name" sanitize:"nocontrol,nfc,collapse"`
+	Tags    []string `json:"tags" sanitize:"trim,lower"`
+	Friends []friend `json:"friends"`
+	Bio     string   `json:"bio"`
+}
+
+type friend struct {
+	Name string `json:"name" sanitize:"trim"`
+}
+
+func TestSanitize(t *testing.T) {
+	name := " José ​\x00 van  Dijk\n"
+	p := &profile{
+		Email:   "  John@Example.COM ",
+		Name:    &name,
+		Tags:    []string{" Go ", "rust"},
+		Friends: []friend{{Name: "Jane"}, {Name: " Joe "}},
+		Bio:     "  unchanged  ",
+	}
+
+	changes := validate.Sanitize(p)
+
+	assert.Equal(t, "john@example.com", p.Email)
+	assert.Equal(t, "José van Dijk", *p.Name)
+	assert.Equal(t, []string{"go", "rust"}, p.Tags)
+	assert.Equal(t, "Joe", p.Friends[1].Name)
+	assert.Equal(t, "  unchanged  ", p.Bio)
+
+	assert.Equal(t, []validate.Change{
+		{Field: "email", Before: "  John@Example.COM ", After: "john@example.com"},
+		{Field: "name", Before: " José ​\x00 van  Dijk\n", After: "José van Dijk"},
+		{Field: "tags[0]", Before: " Go ", After: "go"},
+		{Field: "friends[1].name", Before: " Joe ", After: "Joe"},
+	}, changes)
+}
+
+type node struct {
+	Name string `json:"name" sanitize:"trim"`
+	Next *node  `json:"next"`
+}
+
+func TestSanitize_CyclicPointers(t *testing.T) {
+	first := &node{Name: " first "}
+	first.Next = &node{Name: " second ", Next: first}
+
+	changes := validate.Sanitize(first)
+
+	assert.Equal(t, "first", first.Name)
+	assert.Equal(t, "second", first.Next.Name)
+	assert.Equal(t, []validate.Change{
+		{Field: "name", Before: " first ", After: "first"},
+		{Field: "next.name", Before: " second ", After: "second"},
+	}, changes)
+}
+
+func TestSanitize_Panics(t *testing.T) {
+	assert.Panics(t, func() { validate.Sanitize(profile{}) })
+	assert.Panics(t, func() {
+		validate.Sanitize(&struct {
+			Name string `sanitize:"unknown"`
+		}{})
+	})
+}
+
+func TestRegisterSanitizer(t *testing.T) {
+	validate.RegisterSanitizer("digits", func(s string) string {
+		return strings.Map(func(r rune) rune {
+			if r >= '0' && r <= '9' {
+				return r
+			}
+
+			return -1
+		}, s)
+	})
+
+	phone := struct {
+		Number string `sanitize:"digits"`
+	}{Number: "+31 (0)6-1234"}
+
+	validate.Sanitize(&phone)
+	assert.Equal(t, "31061234", phone.Number)
+}
+
+func TestJSON_SanitizesBeforeValidation(t *testing.T) {
+	handler := validate.JSON(func(w http.ResponseWriter, r *http.Request, p *profile) {
+		_, _ = w.Write([]byte(p.Email))
+	})
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"email": " John@Example.com "}`)))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "john@example.com", rec.Body.String())
+}
+
+func TestJSON_LogsSanitizedValues(t *testing.T) {
+	var buf bytes.Buffer
+
+	handler := validate.JSON(func(w http.ResponseWriter, r *http.Request, p *profile) {})
+
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"email": " john@example.com"}`))
+	req = req.WithContext(zerolog.New(&buf).WithContext(req.Context()))
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	assert.Equal(t, `{"level":"debug","field":"email","before":" john@example.com",`+
+		`"after":"john@example.com","message":"sanitized request body"}`+"\n", buf.String())
+}
@@ -0,0 +1,149 @@
+package validate
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/xeipuuv/gojsonschema"
+)
+
+// Schema is a compiled JSON Schema, see https://json-schema.org. Supports
+// drafts 4, 6 and 7.
+type Schema struct {
+	schema *gojsonschema.Schema
+}
+
+// CompileSchema parses schemaJSON, compile schemas once to validate many
+// documents.
+func CompileSchema(schemaJSON []byte) (*Schema, error) {
+	schema, err := gojsonschema.NewSchema(gojsonschema.NewBytesLoader(schemaJSON))
+	if err != nil {
+		return nil, fmt.Errorf("compiling JSON schema: %w", err)
+	}
+
+	return &Schema{schema: schema}, nil
+}
+
+// AgainstSchema validates document against schemaJSON, see Schema.Validate.
+func AgainstSchema(schemaJSON []byte, document any) (*ValidationResult, error) {
+	schema, err := CompileSchema(schemaJSON)
+	if err != nil {
+		return nil, err
+	}
+
+	return schema.Validate(document)
+}
+
+// Validate validates document, which is either JSON as []byte or
+// json.RawMessage or a Go value that marshals to JSON. Field paths are JSON
+// pointers, e.g. "/items/2/price", and schema keywords map to codes like
+// CodeRequired for "required" and CodeTooLong for "maxLength". Errors are
+// sorted by field.
+//
+// Validate returns an error if document isn't valid JSON.
+func (s *Schema) Validate(document any) (*ValidationResult, error) {
+	var loader gojsonschema.JSONLoader
+
+	switch doc := document.(type) {
+	case []byte:
+		loader = gojsonschema.NewBytesLoader(doc)
+	case json.RawMessage:
+		loader = gojsonschema.NewBytesLoader(doc)
+	default:
+		loader = gojsonschema.NewGoLoader(doc)
+	}
+
+	res, err := s.schema.Validate(loader)
+	if err != nil {
+		return nil, fmt.Errorf("validating against JSON schema: %w", err)
+	}
+
+	result := NewResult()
+
+	for _, e := range res.Errors() {
+		result.Add(*schemaError(e))
+	}
+
+	// the errors of object properties are in random order
+	sort.SliceStable(result.Errors, func(i, j int) bool {
+		return result.Errors[i].Field < result.Errors[j].Field
+	})
+
+	return result, nil
+}
+
+// schemaError returns the error of a violated schema keyword.
+func schemaError(e gojsonschema.ResultError) *FieldError { //nolint:cyclop
+	field := jsonPointer(e.Context())
+	details := e.Details()
+
+	switch e.Type() {
+	case "required":
+		return newError(field+"/"+escapePointer(fmt.Sprint(details["property"])), CodeRequired, nil)
+	case "additional_property_not_allowed":
+		return newError(field+"/"+escapePointer(fmt.Sprint(details["property"])), CodeUnknownField, nil)
+	case "invalid_type":
+		return newError(field, CodeInvalidType, map[string]any{"type": details["expected"]})
+	case "string_gte":
+		return newError(field, CodeTooShort, map[string]any{"min": details["min"]})
+	case "string_lte":
+		return newError(field, CodeTooLong, map[string]any{"max": details["max"]})
+	case "array_min_items":
+		return newError(field, CodeTooFew, map[string]any{"min": details["min"]})
+	case "array_max_items":
+		return newError(field, CodeTooMany, map[string]any{"max": details["max"]})
+	case "number_gte":
+		return newError(field, CodeTooSmall, map[string]any{"min": details["min"]})
+	case "number_gt":
+		return newError(field, CodeTooSmallExclusive, map[string]any{"min": details["min"]})
+	case "number_lte":
+		return newError(field, CodeTooLarge, map[string]any{"max": details["max"]})
+	case "number_lt":
+		return newError(field, CodeTooLargeExclusive, map[string]any{"max": details["max"]})
+	case "enum", "const":
+		return newError(field, CodeNotAllowed, map[string]any{"allowed": details["allowed"]})
+	case "pattern":
+		return newError(field, CodeInvalidFormat, nil)
+	case "format":
+		return newError(field, formatCode(fmt.Sprint(details["format"])), nil)
+	default:
+		return newError(field, CodeInvalid, nil)
+	}
+}
+
+// formatCode returns the code of a value not matching a "format".
+func formatCode(format string) string {
+	switch format {
+	case "email", "idn-email":
+		return CodeInvalidEmail
+	case "uri", "iri", "url":
+		return CodeInvalidURL
+	case "uuid":
+		return CodeInvalidUUID
+	case "date-time":
+		return CodeInvalidTime
+	default:
+		return CodeInvalidFormat
+	}
+}
+
+// jsonPointer returns the JSON pointer of context, e.g. "/items/2/price" for
+// "(root).items.2.price", or "" for the root.
+func jsonPointer(context *gojsonschema.JsonContext) string {
+	// join with a separator that doesn't occur in property names, unlike "."
+	segments := strings.Split(context.String("\x00"), "\x00")[1:]
+
+	var b strings.Builder
+	for _, segment := range segments {
+		b.WriteString("/" + escapePointer(segment))
+	}
+
+	return b.String()
+}
+
+// escapePointer escapes a JSON pointer segment, see RFC 6901.
+func escapePointer(segment string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(segment)
+}
@@ -0,0 +1,84 @@
+package validate_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/nielskrijger/goboot/validate"
+	"github.com/stretchr/testify/assert"
+)
+
+var orderSchema = []byte(`{
+	"type": "object",
+	"required": ["email", "items"],
+	"additionalProperties": false,
+	"properties": {
+		"email": {"type": "string", "format": "email"},
+		"status": {"enum": ["open", "paid"]},
+		"a/b": {"type": "string", "maxLength": 3},
+		"items": {
+			"type": "array",
+			"minItems": 1,
+			"items": {
+				"type": "object",
+				"required": ["sku"],
+				"properties": {
+					"sku": {"type": "string", "pattern": "^SKU-"},
+					"price": {"type": "number", "minimum": 0},
+					"quantity": {"type": "integer", "exclusiveMinimum": 0}
+				}
+			}
+		}
+	}
+}`)
+
+func TestAgainstSchema(t *testing.T) {
+	result, err := validate.AgainstSchema(orderSchema, []byte(`{
+		"status": "shipped",
+		"a/b": "abcd",
+		"coupon": "FREE",
+		"items": [{"sku": "SKU-1", "price": 10, "quantity": 0}, {"sku": "1", "price": -1}, {"price": "10"}]
+	}`))
+	assert.Nil(t, err)
+
+	errs := make(map[string]string, len(result.Errors))
+	for _, e := range result.Errors {
+		errs[e.Field] = e.Code + ": " + e.Message
+	}
+
+	assert.Equal(t, map[string]string{
+		"/email":            "required: is required",
+		"/status":           `not_allowed: must be one of "open", "paid"`,
+		"/a~1b":             "too_long: must be at most 3 characters",
+		"/coupon":           "unknown_field: is not allowed",
+		"/items/0/quantity": "too_small_exclusive: must be greater than 0",
+		"/items/1/sku":      "invalid_format: has an invalid format",
+		"/items/1/price":    "too_small: must be at least 0",
+		"/items/2/sku":      "required: is required",
+		"/items/2/price":    "invalid_type: must be a number",
+	}, errs)
+}
+
+func TestSchema_ValidateGoValue(t *testing.T) {
+	schema, err := validate.CompileSchema(orderSchema)
+	assert.Nil(t, err)
+
+	result, err := schema.Validate(map[string]any{
+		"email": "john@example.com",
+		"items": []any{map[string]any{"sku": "SKU-1"}},
+	})
+	assert.Nil(t, err)
+	assert.True(t, result.Valid())
+
+	result, err = schema.Validate(json.RawMessage(`{"email": "john", "items": []}`))
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"invalid_email", "too_few"}, []string{result.Errors[0].Code, result.Errors[1].Code})
+}
+
+func TestAgainstSchema_Errors(t *testing.T) {
+	_, err := validate.AgainstSchema([]byte(`{"type": 1}`), []byte(`{}`))
+	assert.ErrorContains(t, err, "compiling JSON schema")
+
+	_, err = validate.AgainstSchema(orderSchema, []byte(`{`))
+	assert.ErrorContains(t, err, "validating against JSON schema")
+}
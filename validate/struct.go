@@ -0,0 +1,162 @@
+package validate
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
+
+var (
+	// validate is safe for concurrent use and caches the rules of struct types.
+	validate = newValidator()
+
+	// tagCodes are the codes of custom tags, see RegisterValidation
+	tagCodes = make(map[string]string)
+)
+
+func newValidator() *validator.Validate {
+	v := validator.New()
+
+	// use the JSON names of fields in error paths
+	v.RegisterTagNameFunc(fieldName)
+
+	return v
+}
+
+// fieldName returns the JSON name of field, its Go name if it has no JSON
+// name, or "" if it's ignored by JSON.
+func fieldName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "-" {
+		return ""
+	}
+
+	if name == "" {
+		return field.Name
+	}
+
+	return name
+}
+
+// Struct validates v using its `validate` struct tags, see
+// https://pkg.go.dev/github.com/go-playground/validator/v10. Field paths use
+// the JSON names of fields, e.g. "address.zip" or "items[2].price". Tags map
+// to codes like CodeRequired for "required" and CodeTooLong for "max" of a
+// string, tags without a code map to CodeInvalid.
+//
+// Rules are applied after validating the tags, e.g. to validate fields
+// depending on other fields, see Rule.
+//
+// Struct panics if v is not a struct or a pointer to one.
+func Struct(v any, rules ...Rule) *ValidationResult {
+	result := NewResult()
+
+	if err := validate.Struct(v); err != nil {
+		violations, ok := err.(validator.ValidationErrors) //nolint:errorlint
+		if !ok {
+			panic(fmt.Sprintf("validate: %s", err))
+		}
+
+		for _, fe := range violations {
+			result.Add(*tagError(fieldPath(fe.Namespace()), fe))
+		}
+	}
+
+	rv := reflect.ValueOf(v)
+	for _, rule := range rules {
+		result.Add(rule(rv)...)
+	}
+
+	return result
+}
+
+// RegisterValidation adds a custom tag validated by fn whose violations
+// have code, e.g.:
+//
+//	validate.RegisterCode("invalid_sku", "must be a valid SKU")
+//	validate.RegisterValidation("sku", isSKU, "invalid_sku")
+//
+// RegisterValidation isn't safe for concurrent use with Struct, register tags
+// when initializing the application. It panics if code isn't registered or
+// the tag is invalid.
+func RegisterValidation(tag string, fn validator.Func, code string) {
+	if _, ok := lookupMessage(DefaultLocale, code); !ok {
+		panic(fmt.Sprintf("validate: code %q is not registered", code))
+	}
+
+	if err := validate.RegisterValidation(tag, fn); err != nil {
+		panic(fmt.Sprintf("validate: %s", err))
+	}
+
+	tagCodes[tag] = code
+}
+
+// fieldPath returns the namespace without the name of the validated struct,
+// e.g. "address.zip" for "User.address.zip".
+func fieldPath(namespace string) string {
+	if _, path, ok := strings.Cut(namespace, "."); ok {
+		return path
+	}
+
+	return namespace
+}
+
+// tagError returns the error of a violated tag.
+func tagError(field string, fe validator.FieldError) *FieldError { //nolint:cyclop
+	param := fe.Param()
+
+	switch fe.Tag() {
+	case "required", "required_if", "required_unless", "required_with", "required_without":
+		return newError(field, CodeRequired, nil)
+	case "email":
+		return newError(field, CodeInvalidEmail, nil)
+	case "url", "http_url", "uri":
+		return newError(field, CodeInvalidURL, nil)
+	case "uuid", "uuid4":
+		return newError(field, CodeInvalidUUID, nil)
+	case "datetime":
+		return newError(field, CodeInvalidFormat, nil)
+	case "oneof":
+		return newError(field, CodeNotAllowed, map[string]any{"allowed": strings.Join(strings.Fields(param), ", ")})
+	case "len":
+		return newError(field, CodeInvalidLength, map[string]any{"len": param})
+	case "min", "gte":
+		return newError(field, sizeCode(fe, CodeTooShort, CodeTooFew, CodeTooSmall), map[string]any{"min": param})
+	case "max", "lte":
+		return newError(field, sizeCode(fe, CodeTooLong, CodeTooMany, CodeTooLarge), map[string]any{"max": param})
+	case "gt":
+		return newError(field, CodeTooSmallExclusive, map[string]any{"min": param})
+	case "lt":
+		return newError(field, CodeTooLargeExclusive, map[string]any{"max": param})
+	}
+
+	if code, ok := tagCodes[fe.Tag()]; ok {
+		if param == "" {
+			return newError(field, code, nil)
+		}
+
+		return newError(field, code, map[string]any{"param": param})
+	}
+
+	return newError(field, CodeInvalid, nil)
+}
+
+// sizeCode returns the code of a size limit of a string, a slice or map, or
+// a number.
+func sizeCode(fe validator.FieldError, str string, items string, number string) string {
+	kind := fe.Kind()
+	if kind == reflect.Pointer {
+		kind = fe.Type().Elem().Kind()
+	}
+
+	switch kind { //nolint:exhaustive
+	case reflect.String:
+		return str
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return items
+	default:
+		return number
+	}
+}
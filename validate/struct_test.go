@@ -0,0 +1,101 @@
+package validate_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/nielskrijger/goboot/validate"
+	"github.com/stretchr/testify/assert"
+)
+
+type address struct {
+	Zip string `json:"zip" validate:"required,len=6"`
+}
+
+type item struct {
+	Price int `json:"price" validate:"gte=0"`
+}
+
+type createUser struct {
+	Email   string   `json:"email" validate:"required,email"`
+	Name    string   `json:"name,omitempty" validate:"max=5"`
+	Role    string   `validate:"oneof=admin user"`
+	Address *address `json:"address" validate:"required"`
+	Items   []item   `json:"items" validate:"min=1,dive"`
+}
+
+func TestStruct(t *testing.T) {
+	result := validate.Struct(&createUser{
+		Email:   "john",
+		Name:    "Johnny",
+		Role:    "owner",
+		Address: &address{},
+		Items:   []item{{Price: 1}, {Price: -1}},
+	})
+
+	assert.False(t, result.Valid())
+	assert.Equal(t, []validate.FieldError{
+		{Field: "email", Code: "invalid_email", Message: "must be a valid email address"},
+		{Field: "name", Code: "too_long", Message: "must be at most 5 characters", Params: map[string]any{"max": "5"}},
+		{Field: "Role", Code: "not_allowed", Message: "must be one of admin, user", Params: map[string]any{"allowed": "admin, user"}},
+		{Field: "address.zip", Code: "required", Message: "is required"},
+		{Field: "items[1].price", Code: "too_small", Message: "must be at least 0", Params: map[string]any{"min": "0"}},
+	}, result.Errors)
+}
+
+func TestStruct_Valid(t *testing.T) {
+	result := validate.Struct(createUser{
+		Email:   "john@example.com",
+		Role:    "user",
+		Address: &address{Zip: "1234AB"},
+		Items:   []item{{Price: 1}},
+	})
+
+	assert.True(t, result.Valid())
+	assert.Nil(t, result.Err())
+}
+
+func TestStruct_PanicsOnNonStruct(t *testing.T) {
+	assert.Panics(t, func() { validate.Struct("john") })
+}
+
+type order struct {
+	Items []item `json:"items" validate:"max=1"`
+	SKU   string `json:"sku" validate:"sku"`
+}
+
+func init() {
+	validate.RegisterCode("invalid_sku", "must be a valid SKU")
+	validate.RegisterValidation("sku", func(fl validator.FieldLevel) bool {
+		return strings.HasPrefix(fl.Field().String(), "SKU-")
+	}, "invalid_sku")
+}
+
+func TestStruct_Codes(t *testing.T) {
+	result := validate.Struct(order{Items: make([]item, 2), SKU: "123"})
+
+	assert.Equal(t, []validate.FieldError{
+		{Field: "items", Code: "too_many", Message: "must contain at most 1 item", Params: map[string]any{"max": "1"}},
+		{Field: "sku", Code: "invalid_sku", Message: "must be a valid SKU"},
+	}, result.Errors)
+
+	assert.Panics(t, func() { validate.RegisterValidation("isbn", nil, "invalid_isbn") })
+}
+
+type discount struct {
+	Percentage int `json:"percentage" validate:"gt=0,lt=100"`
+}
+
+func TestStruct_ExclusiveBounds(t *testing.T) {
+	result := validate.Struct(discount{Percentage: 0})
+
+	assert.Equal(t, []validate.FieldError{
+		{Field: "percentage", Code: "too_small_exclusive", Message: "must be greater than 0", Params: map[string]any{"min": "0"}},
+	}, result.Errors)
+	assert.Equal(t, "moet groter zijn dan 0", result.Translate("nl").Errors[0].Message)
+
+	result = validate.Struct(discount{Percentage: 100})
+	assert.Equal(t, "too_large_exclusive", result.Errors[0].Code)
+	assert.Equal(t, "must be less than 100", result.Errors[0].Message)
+}
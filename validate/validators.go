@@ -0,0 +1,173 @@
+package validate
+
+import (
+	"fmt"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+// Ordered is a type supporting the <, <=, >= and > operators.
+type Ordered interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr |
+		~float32 | ~float64 | ~string
+}
+
+// The validators below return a FieldError if value is invalid, or nil if
+// it's valid. Apart from Required and Range they accept empty values so they
+// can be used for optional fields, use Required for required fields. Range
+// checks zero like any other number. Add their errors to a ValidationResult
+// using Check:
+//
+//	result.Check(
+//		validate.Required("email", req.Email),
+//		validate.Email("email", req.Email),
+//		validate.MaxLen("name", req.Name, 100),
+//	)
+
+// Required returns an error if value is the zero value of its type.
+func Required[T comparable](field string, value T) *FieldError {
+	var zero T
+	if value == zero {
+		return newError(field, CodeRequired, nil)
+	}
+
+	return nil
+}
+
+// Email returns an error if value isn't an email address without a display
+// name, e.g. "john@example.com".
+func Email(field string, value string) *FieldError {
+	if value == "" {
+		return nil
+	}
+
+	if addr, err := mail.ParseAddress(value); err != nil || addr.Address != value {
+		return newError(field, CodeInvalidEmail, nil)
+	}
+
+	return nil
+}
+
+// UUID returns an error if value isn't a UUID in its canonical form, e.g.
+// "f47ac10b-58cc-4372-a567-0e02b2c3d479".
+func UUID(field string, value string) *FieldError {
+	if value == "" {
+		return nil
+	}
+
+	if _, err := uuid.Parse(value); err != nil || len(value) != 36 {
+		return newError(field, CodeInvalidUUID, nil)
+	}
+
+	return nil
+}
+
+// URL returns an error if value isn't an absolute URL, e.g.
+// "https://example.com/path".
+func URL(field string, value string) *FieldError {
+	if value == "" {
+		return nil
+	}
+
+	if u, err := url.Parse(value); err != nil || u.Scheme == "" || u.Host == "" {
+		return newError(field, CodeInvalidURL, nil)
+	}
+
+	return nil
+}
+
+// MinLen returns an error if value has less than min characters.
+func MinLen(field string, value string, min int) *FieldError {
+	if value == "" {
+		return nil
+	}
+
+	if utf8.RuneCountInString(value) < min {
+		return newError(field, CodeTooShort, map[string]any{"min": min})
+	}
+
+	return nil
+}
+
+// MaxLen returns an error if value has more than max characters.
+func MaxLen(field string, value string, max int) *FieldError {
+	if utf8.RuneCountInString(value) > max {
+		return newError(field, CodeTooLong, map[string]any{"max": max})
+	}
+
+	return nil
+}
+
+// Range returns an error if value is less than min or greater than max, the
+// zero value is rejected if it's outside the range.
+func Range[T Ordered](field string, value T, min T, max T) *FieldError {
+	if value < min || value > max {
+		return newError(field, CodeOutOfRange, map[string]any{"min": min, "max": max})
+	}
+
+	return nil
+}
+
+// OneOf returns an error if value isn't one of allowed.
+func OneOf[T comparable](field string, value T, allowed ...T) *FieldError {
+	var zero T
+	if value == zero {
+		return nil
+	}
+
+	for _, a := range allowed {
+		if value == a {
+			return nil
+		}
+	}
+
+	return notAllowed(field, allowed)
+}
+
+func notAllowed[T any](field string, allowed []T) *FieldError {
+	names := make([]string, len(allowed))
+	for i, a := range allowed {
+		names[i] = fmt.Sprint(a)
+	}
+
+	return newError(field, CodeNotAllowed, map[string]any{"allowed": strings.Join(names, ", ")})
+}
+
+// Regexp returns an error if value doesn't match re. The message describes
+// the expected format, e.g. "must contain only lowercase letters", it's
+// replaced by the "invalid_format" message when translated.
+func Regexp(field string, value string, re *regexp.Regexp, message string) *FieldError {
+	if value == "" {
+		return nil
+	}
+
+	if !re.MatchString(value) {
+		err := newError(field, CodeInvalidFormat, nil)
+		err.Message = message
+
+		return err
+	}
+
+	return nil
+}
+
+// RFC3339 returns an error if value isn't a time formatted as RFC 3339, e.g.
+// "2006-01-02T15:04:05Z" or "2006-01-02T15:04:05.999+07:00".
+func RFC3339(field string, value string) *FieldError {
+	if value == "" {
+		return nil
+	}
+
+	if _, err := time.Parse(time.RFC3339, value); err != nil {
+		return newError(field, CodeInvalidTime, nil)
+	}
+
+	return nil
+}
@@ -0,0 +1,71 @@
+package validate_test
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/nielskrijger/goboot/validate"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidators(t *testing.T) {
+	slug := regexp.MustCompile(`^[a-z-]+$`)
+
+	for _, tc := range []struct {
+		err  *validate.FieldError
+		code string
+	}{
+		{err: validate.Required("f", ""), code: "required"},
+		{err: validate.Required("f", 0), code: "required"},
+		{err: validate.Required("f", "a")},
+		{err: validate.Email("f", "john@example.com")},
+		{err: validate.Email("f", "john"), code: "invalid_email"},
+		{err: validate.Email("f", "John <john@example.com>"), code: "invalid_email"},
+		{err: validate.Email("f", "")},
+		{err: validate.UUID("f", "f47ac10b-58cc-4372-a567-0e02b2c3d479")},
+		{err: validate.UUID("f", "f47ac10b58cc4372a5670e02b2c3d479"), code: "invalid_uuid"},
+		{err: validate.URL("f", "https://example.com/a?b=c")},
+		{err: validate.URL("f", "/a"), code: "invalid_url"},
+		{err: validate.MinLen("f", "ab", 3), code: "too_short"},
+		{err: validate.MinLen("f", "abc", 3)},
+		{err: validate.MaxLen("f", "äöü", 3)},
+		{err: validate.MaxLen("f", "abcd", 3), code: "too_long"},
+		{err: validate.Range("f", 5, 1, 10)},
+		{err: validate.Range("f", 0.5, 1, 10), code: "out_of_range"},
+		{err: validate.Range("f", 0, 1, 10), code: "out_of_range"},
+		{err: validate.Range("f", "", "a", "z"), code: "out_of_range"},
+		{err: validate.Range("f", 0, 0, 10)},
+		{err: validate.OneOf("f", "user", "admin", "user")},
+		{err: validate.OneOf("f", "owner", "admin", "user"), code: "not_allowed"},
+		{err: validate.Regexp("f", "my-slug", slug, "must be a slug")},
+		{err: validate.Regexp("f", "My slug", slug, "must be a slug"), code: "invalid_format"},
+		{err: validate.RFC3339("f", "2022-11-01T10:00:00+01:00")},
+		{err: validate.RFC3339("f", "2022-11-01"), code: "invalid_time"},
+	} {
+		if tc.code == "" {
+			assert.Nil(t, tc.err)
+		} else if assert.NotNil(t, tc.err, tc.code) {
+			assert.Equal(t, tc.code, tc.err.Code)
+			assert.Equal(t, "f", tc.err.Field)
+		}
+	}
+}
+
+func TestValidationResult_Check(t *testing.T) {
+	result := validate.NewResult()
+	result.Check(
+		validate.Required("email", ""),
+		validate.MaxLen("name", "John", 10),
+		validate.OneOf("role", "owner", "admin", "user"),
+	)
+
+	assert.Equal(t, []validate.FieldError{
+		{Field: "email", Code: "required", Message: "is required"},
+		{
+			Field:   "role",
+			Code:    "not_allowed",
+			Message: "must be one of admin, user",
+			Params:  map[string]any{"allowed": "admin, user"},
+		},
+	}, result.Errors)
+}
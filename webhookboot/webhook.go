@@ -0,0 +1,506 @@
+package webhookboot
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/nielskrijger/goboot"
+	"github.com/nielskrijger/goboot/pubsubboot"
+	"github.com/rs/zerolog"
+)
+
+const (
+	defaultMaxAttempts    = 5
+	defaultInitialBackoff = time.Second
+	defaultMaxBackoff     = 5 * time.Minute
+	defaultTimeout        = 10 * time.Second
+	deliveryIDBytes       = 16
+
+	// DeadLetterEvent is the event name used when publishing a failed delivery
+	// to the dead letter channel.
+	DeadLetterEvent = "webhook.failed"
+)
+
+// Headers added to each webhook request.
+const (
+	HeaderSignature = "X-Webhook-Signature"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderDelivery  = "X-Webhook-Delivery"
+)
+
+var (
+	errEndpointNotFound = errors.New("webhook endpoint not found")
+	errServiceClosed    = errors.New("webhook service has been closed")
+)
+
+type WebhookConfig struct {
+	// Maximum number of attempts per delivery, including the first one. Default is 5.
+	MaxAttempts int `yaml:"maxAttempts"`
+
+	// Backoff before the first retry, doubles every attempt. Default is 1 second.
+	InitialBackoff time.Duration `yaml:"initialBackoff"`
+
+	// Maximum backoff between retries. Default is 5 minutes.
+	MaxBackoff time.Duration `yaml:"maxBackoff"`
+
+	// Request timeout per delivery attempt. Default is 10 seconds.
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+// Endpoint is a registered webhook receiver.
+type Endpoint struct {
+	ID     string
+	URL    string
+	Secret string
+
+	// Events the endpoint is subscribed to, leave empty to receive all events.
+	Events []string
+
+	// Headers are added to every request sent to this endpoint.
+	Headers map[string]string
+}
+
+// Delivery is a single event delivered to a single endpoint.
+type Delivery struct {
+	ID         string          `json:"id"`
+	EndpointID string          `json:"endpointId"`
+	URL        string          `json:"url"`
+	Event      string          `json:"event"`
+	Payload    json.RawMessage `json:"payload"`
+	Attempts   int             `json:"attempts"`
+	LastError  string          `json:"lastError,omitempty"`
+}
+
+// Attempt describes the outcome of a single delivery attempt.
+type Attempt struct {
+	DeliveryID string
+	EndpointID string
+	Event      string
+	Attempt    int
+	StatusCode int
+	Err        error
+	Timestamp  time.Time
+	Duration   time.Duration
+}
+
+// AttemptRecorder stores delivery attempts, e.g. in a database table.
+type AttemptRecorder interface {
+	RecordAttempt(ctx context.Context, attempt *Attempt) error
+}
+
+// Webhooks delivers signed event payloads to registered HTTP endpoints.
+//
+// Deliveries are retried with exponential backoff. When all attempts fail or
+// the endpoint responds with a non-retryable status the delivery is published
+// to the dead letter channel (if configured).
+type Webhooks struct {
+	HTTPClient *http.Client
+
+	endpoints   map[string]*Endpoint
+	endpointsMu sync.RWMutex
+
+	config            *WebhookConfig
+	pubsub            *pubsubboot.PubSub
+	deadLetterChannel string
+	recorder          AttemptRecorder
+	log               zerolog.Logger
+	options           []Option
+	wg                sync.WaitGroup
+	ctx               context.Context //nolint:containedctx
+	cancel            context.CancelFunc
+
+	// closeMu guards starting deliveries while closing
+	closeMu sync.Mutex
+	closed  bool
+}
+
+type Option func(*Webhooks)
+
+// WithEndpoint registers a webhook endpoint.
+func WithEndpoint(ep *Endpoint) func(*Webhooks) {
+	return func(s *Webhooks) {
+		s.AddEndpoint(ep)
+	}
+}
+
+// WithDeadLetter publishes permanently failed deliveries to specified PubSub channel.
+func WithDeadLetter(ps *pubsubboot.PubSub, channel string) func(*Webhooks) {
+	return func(s *Webhooks) {
+		s.pubsub = ps
+		s.deadLetterChannel = channel
+	}
+}
+
+// WithRecorder records all delivery attempts using specified recorder.
+func WithRecorder(r AttemptRecorder) func(*Webhooks) {
+	return func(s *Webhooks) {
+		s.recorder = r
+	}
+}
+
+// NewWebhookService creates a new webhook dispatcher.
+func NewWebhookService(options ...Option) *Webhooks {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &Webhooks{
+		endpoints: make(map[string]*Endpoint),
+		options:   options,
+		ctx:       ctx,
+		cancel:    cancel,
+	}
+}
+
+func (s *Webhooks) Name() string {
+	return "Webhooks"
+}
+
+// Configure loads the optional "webhooks" configuration and applies all options.
+func (s *Webhooks) Configure(env *goboot.AppEnv) error {
+	s.log = env.Log
+	s.config = &WebhookConfig{}
+
+	if env.Config.InConfig("webhooks") {
+		if err := env.Config.Sub("webhooks").Unmarshal(s.config); err != nil {
+			return fmt.Errorf("parsing webhooks configuration: %w", err)
+		}
+	}
+
+	if s.config.MaxAttempts == 0 {
+		s.config.MaxAttempts = defaultMaxAttempts
+	}
+
+	if s.config.InitialBackoff == 0 {
+		s.config.InitialBackoff = defaultInitialBackoff
+	}
+
+	if s.config.MaxBackoff == 0 {
+		s.config.MaxBackoff = defaultMaxBackoff
+	}
+
+	if s.config.Timeout == 0 {
+		s.config.Timeout = defaultTimeout
+	}
+
+	if s.HTTPClient == nil {
+		s.HTTPClient = &http.Client{Timeout: s.config.Timeout}
+	}
+
+	for _, option := range s.options {
+		option(s)
+	}
+
+	return nil
+}
+
+func (s *Webhooks) Init() error {
+	return nil
+}
+
+// Close cancels in-flight requests, stops retrying and waits for all
+// deliveries to return. Deliveries that are stopped aren't dead-lettered,
+// Deliver returns an error instead so the caller can redeliver them later.
+func (s *Webhooks) Close() error {
+	s.closeMu.Lock()
+	s.closed = true
+	s.cancel()
+	s.closeMu.Unlock()
+
+	s.wg.Wait()
+
+	return nil
+}
+
+// AddEndpoint registers a webhook endpoint at runtime, replacing the endpoint
+// with the same ID if any.
+func (s *Webhooks) AddEndpoint(ep *Endpoint) {
+	s.endpointsMu.Lock()
+	defer s.endpointsMu.Unlock()
+
+	s.endpoints[ep.ID] = ep
+}
+
+// RemoveEndpoint unregisters the endpoint with specified id. Deliveries that
+// are in progress are still retried.
+func (s *Webhooks) RemoveEndpoint(id string) {
+	s.endpointsMu.Lock()
+	defer s.endpointsMu.Unlock()
+
+	delete(s.endpoints, id)
+}
+
+// Endpoint returns the endpoint with specified id, nil if not found.
+func (s *Webhooks) Endpoint(id string) *Endpoint {
+	s.endpointsMu.RLock()
+	defer s.endpointsMu.RUnlock()
+
+	return s.endpoints[id]
+}
+
+// subscribers returns the endpoints subscribed to the event.
+func (s *Webhooks) subscribers(event string) []*Endpoint {
+	s.endpointsMu.RLock()
+	defer s.endpointsMu.RUnlock()
+
+	var result []*Endpoint
+
+	for _, ep := range s.endpoints {
+		if ep.subscribed(event) {
+			result = append(result, ep)
+		}
+	}
+
+	return result
+}
+
+// Dispatch delivers the event asynchronously to all endpoints subscribed to
+// the event. Returns an error only when the payload could not be marshaled.
+func (s *Webhooks) Dispatch(event string, payload any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("marshal payload for webhook event %q: %w", event, err)
+	}
+
+	s.closeMu.Lock()
+	defer s.closeMu.Unlock()
+
+	if s.closed {
+		return errServiceClosed
+	}
+
+	for _, ep := range s.subscribers(event) {
+		d := &Delivery{
+			ID:         newDeliveryID(),
+			EndpointID: ep.ID,
+			URL:        ep.URL,
+			Event:      event,
+			Payload:    body,
+		}
+
+		s.wg.Add(1)
+
+		go func(ep *Endpoint) {
+			defer s.wg.Done()
+
+			if err := s.Deliver(context.Background(), ep, d); err != nil {
+				s.log.Error().Err(err).Msgf("failed to deliver webhook %q to endpoint %q", event, ep.ID)
+			}
+		}(ep)
+	}
+
+	return nil
+}
+
+// DeliverTo synchronously delivers an event to the endpoint with specified id.
+func (s *Webhooks) DeliverTo(ctx context.Context, endpointID string, event string, payload any) error {
+	ep := s.Endpoint(endpointID)
+	if ep == nil {
+		return fmt.Errorf("%w: %q", errEndpointNotFound, endpointID)
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("marshal payload for webhook event %q: %w", event, err)
+	}
+
+	return s.Deliver(ctx, ep, &Delivery{
+		ID:         newDeliveryID(),
+		EndpointID: ep.ID,
+		URL:        ep.URL,
+		Event:      event,
+		Payload:    body,
+	})
+}
+
+// Deliver sends a delivery to an endpoint and retries with exponential backoff
+// until it succeeds, fails permanently or the max attempts are reached.
+//
+// Failed deliveries are dead-lettered, an error is only returned when that
+// failed as well or when the service is closed before the delivery finished.
+func (s *Webhooks) Deliver(ctx context.Context, ep *Endpoint, d *Delivery) error {
+	backoff := s.config.InitialBackoff
+
+	for {
+		d.Attempts++
+
+		retryable, err := s.attempt(ctx, ep, d)
+		if err == nil {
+			return nil
+		}
+
+		d.LastError = err.Error()
+
+		if s.ctx.Err() != nil {
+			return s.stopped(d)
+		}
+
+		if !retryable || d.Attempts >= s.config.MaxAttempts {
+			return s.deadLetter(ctx, d)
+		}
+
+		s.log.Warn().Err(err).Msgf("webhook delivery %q failed, retrying in %s", d.ID, backoff)
+
+		select {
+		case <-ctx.Done():
+			d.LastError = ctx.Err().Error()
+
+			return s.deadLetter(ctx, d)
+		case <-s.ctx.Done():
+			return s.stopped(d)
+		case <-time.After(backoff):
+		}
+
+		backoff *= 2
+		if backoff > s.config.MaxBackoff {
+			backoff = s.config.MaxBackoff
+		}
+	}
+}
+
+// stopped returns the error of a delivery stopped by Close.
+func (s *Webhooks) stopped(d *Delivery) error {
+	return fmt.Errorf("webhook delivery %q stopped after %d attempts: %w", d.ID, d.Attempts, errServiceClosed)
+}
+
+// attempt sends a single request, returns whether a failure is retryable.
+// The request is cancelled by ctx or by closing the service.
+func (s *Webhooks) attempt(ctx context.Context, ep *Endpoint, d *Delivery) (bool, error) {
+	start := time.Now()
+	timestamp := strconv.FormatInt(start.Unix(), 10)
+
+	reqCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	go func() {
+		select {
+		case <-s.ctx.Done():
+			cancel()
+		case <-reqCtx.Done():
+		}
+	}()
+
+	req, err := http.NewRequestWithContext(reqCtx, http.MethodPost, ep.URL, bytes.NewReader(d.Payload))
+	if err != nil {
+		return false, fmt.Errorf("creating webhook request: %w", err)
+	}
+
+	for k, v := range ep.Headers {
+		req.Header.Set(k, v)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEvent, d.Event)
+	req.Header.Set(HeaderDelivery, d.ID)
+	req.Header.Set(HeaderTimestamp, timestamp)
+
+	if ep.Secret != "" {
+		req.Header.Set(HeaderSignature, Sign(ep.Secret, timestamp, d.Payload))
+	}
+
+	attempt := &Attempt{
+		DeliveryID: d.ID,
+		EndpointID: ep.ID,
+		Event:      d.Event,
+		Attempt:    d.Attempts,
+		Timestamp:  start.UTC(),
+	}
+
+	retryable := true
+
+	res, err := s.HTTPClient.Do(req)
+	if err == nil {
+		_, _ = io.Copy(io.Discard, res.Body)
+		_ = res.Body.Close()
+
+		attempt.StatusCode = res.StatusCode
+		if res.StatusCode < 200 || res.StatusCode >= 300 {
+			err = fmt.Errorf("unexpected webhook response status %d", res.StatusCode) //nolint:goerr113
+			retryable = isRetryableStatus(res.StatusCode)
+		}
+	}
+
+	attempt.Err = err
+	attempt.Duration = time.Since(start)
+	s.record(ctx, attempt)
+
+	return retryable, err
+}
+
+func (s *Webhooks) record(ctx context.Context, attempt *Attempt) {
+	s.log.Debug().
+		Str("delivery", attempt.DeliveryID).
+		Str("endpoint", attempt.EndpointID).
+		Int("attempt", attempt.Attempt).
+		Int("status", attempt.StatusCode).
+		Err(attempt.Err).
+		Msgf("webhook delivery attempt took %s", attempt.Duration)
+
+	if s.recorder == nil {
+		return
+	}
+
+	if err := s.recorder.RecordAttempt(ctx, attempt); err != nil {
+		s.log.Warn().Err(err).Msgf("failed to record webhook delivery attempt %q", attempt.DeliveryID)
+	}
+}
+
+// deadLetter publishes a failed delivery to the dead letter channel. A
+// cancelled or expired ctx is often why the delivery failed, in which case
+// the delivery is published without it so it isn't lost.
+func (s *Webhooks) deadLetter(ctx context.Context, d *Delivery) error {
+	if s.pubsub == nil {
+		return fmt.Errorf("webhook delivery %q failed after %d attempts: %s", d.ID, d.Attempts, d.LastError) //nolint:goerr113
+	}
+
+	if ctx.Err() != nil {
+		ctx = context.Background() //nolint:contextcheck
+	}
+
+	if err := s.pubsub.PublishEvent(ctx, s.deadLetterChannel, DeadLetterEvent, d); err != nil {
+		return fmt.Errorf("dead-lettering webhook delivery %q: %w", d.ID, err)
+	}
+
+	s.log.Warn().Msgf("webhook delivery %q dead-lettered after %d attempts", d.ID, d.Attempts)
+
+	return nil
+}
+
+// isRetryableStatus returns false for client errors except timeouts and rate limiting.
+func isRetryableStatus(code int) bool {
+	if code == http.StatusRequestTimeout || code == http.StatusTooManyRequests {
+		return true
+	}
+
+	return code < 400 || code >= 500
+}
+
+func (ep *Endpoint) subscribed(event string) bool {
+	if len(ep.Events) == 0 {
+		return true
+	}
+
+	for _, e := range ep.Events {
+		if e == event {
+			return true
+		}
+	}
+
+	return false
+}
+
+func newDeliveryID() string {
+	b := make([]byte, deliveryIDBytes)
+	_, _ = rand.Read(b)
+
+	return hex.EncodeToString(b)
+}
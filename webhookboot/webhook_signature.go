@@ -0,0 +1,53 @@
+package webhookboot
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"time"
+)
+
+var (
+	errInvalidSignature = errors.New("invalid webhook signature")
+	errExpiredTimestamp = errors.New("webhook timestamp outside of tolerance")
+)
+
+const signaturePrefix = "sha256="
+
+// Sign returns the HMAC-SHA256 signature of the timestamp and body, formatted
+// as "sha256=<hex>". The timestamp is included to prevent replay attacks.
+func Sign(secret string, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifySignature checks a signature created by Sign. Set tolerance to zero
+// to skip the timestamp check.
+//
+// This is intended for receivers of webhooks sent by a goboot app.
+func VerifySignature(secret string, signature string, timestamp string, body []byte, tolerance time.Duration) error {
+	if !hmac.Equal([]byte(signature), []byte(Sign(secret, timestamp, body))) {
+		return errInvalidSignature
+	}
+
+	if tolerance == 0 {
+		return nil
+	}
+
+	secs, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return errExpiredTimestamp
+	}
+
+	if age := time.Since(time.Unix(secs, 0)); age > tolerance || age < -tolerance {
+		return errExpiredTimestamp
+	}
+
+	return nil
+}
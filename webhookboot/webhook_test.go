@@ -0,0 +1,275 @@
+package webhookboot_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/nielskrijger/goboot"
+	"github.com/nielskrijger/goboot/pubsubboot"
+	"github.com/nielskrijger/goboot/webhookboot"
+	"github.com/stretchr/testify/assert"
+)
+
+type testRecorder struct {
+	mu       sync.Mutex
+	attempts []*webhookboot.Attempt
+}
+
+func (r *testRecorder) RecordAttempt(_ context.Context, attempt *webhookboot.Attempt) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.attempts = append(r.attempts, attempt)
+
+	return nil
+}
+
+func newWebhookService(t *testing.T, url string, recorder *testRecorder) *webhookboot.Webhooks {
+	t.Helper()
+
+	s := webhookboot.NewWebhookService(
+		webhookboot.WithEndpoint(&webhookboot.Endpoint{ID: "test", URL: url, Secret: "secret"}),
+		webhookboot.WithRecorder(recorder),
+	)
+	assert.Nil(t, s.Configure(goboot.NewAppEnv("./testdata", "valid")))
+	assert.Nil(t, s.Init())
+
+	return s
+}
+
+func newDeadLetterPubSub(t *testing.T) *pubsubboot.PubSub {
+	t.Helper()
+
+	if _, exists := os.LookupEnv("PUBSUB_EMULATOR_HOST"); !exists {
+		t.Setenv("PUBSUB_EMULATOR_HOST", "localhost:8085")
+	}
+
+	ps := pubsubboot.NewPubSubService("metrix-io", pubsubboot.WithChannel(&pubsubboot.Channel{
+		ID:             "webhooks-failed",
+		TopicID:        "webhooks-failed-topic",
+		SubscriptionID: "webhooks-failed-subscription",
+	}))
+	assert.Nil(t, ps.Configure(goboot.NewAppEnv("./testdata", "")))
+	assert.Nil(t, ps.DeleteAll())
+	assert.Nil(t, ps.Init())
+
+	return ps
+}
+
+func TestWebhooks_DeliverSigned(t *testing.T) {
+	var body []byte
+
+	var header http.Header
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+		header = r.Header
+	}))
+	defer srv.Close()
+
+	s := newWebhookService(t, srv.URL, &testRecorder{})
+	err := s.DeliverTo(context.Background(), "test", "user.created", map[string]string{"id": "1"})
+
+	assert.Nil(t, err)
+	assert.Equal(t, `{"id":"1"}`, string(body))
+	assert.Equal(t, "user.created", header.Get(webhookboot.HeaderEvent))
+	assert.Nil(t, webhookboot.VerifySignature(
+		"secret",
+		header.Get(webhookboot.HeaderSignature),
+		header.Get(webhookboot.HeaderTimestamp),
+		body,
+		time.Minute,
+	))
+}
+
+func TestWebhooks_RetryUntilSuccess(t *testing.T) {
+	calls := 0
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+
+	recorder := &testRecorder{}
+	s := newWebhookService(t, srv.URL, recorder)
+	err := s.DeliverTo(context.Background(), "test", "user.created", "payload")
+
+	assert.Nil(t, err)
+	assert.Equal(t, 3, calls)
+	assert.Len(t, recorder.attempts, 3)
+	assert.Equal(t, http.StatusServiceUnavailable, recorder.attempts[0].StatusCode)
+	assert.Equal(t, http.StatusOK, recorder.attempts[2].StatusCode)
+}
+
+func TestWebhooks_ErrorAfterMaxAttempts(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	recorder := &testRecorder{}
+	s := newWebhookService(t, srv.URL, recorder)
+	err := s.DeliverTo(context.Background(), "test", "user.created", "payload")
+
+	assert.Contains(t, err.Error(), "failed after 3 attempts: unexpected webhook response status 500")
+	assert.Len(t, recorder.attempts, 3)
+}
+
+func TestWebhooks_DeadLetterAfterCallerContextExpired(t *testing.T) {
+	ps := newDeadLetterPubSub(t)
+	defer ps.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	calls := 0
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+
+			return
+		}
+
+		// the caller gives up during the last attempt
+		cancel()
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+
+	s := webhookboot.NewWebhookService(
+		webhookboot.WithEndpoint(&webhookboot.Endpoint{ID: "test", URL: srv.URL}),
+		webhookboot.WithDeadLetter(ps, "webhooks-failed"),
+	)
+	assert.Nil(t, s.Configure(goboot.NewAppEnv("./testdata", "valid")))
+	assert.Nil(t, s.Init())
+
+	assert.Nil(t, s.DeliverTo(ctx, "test", "user.created", "payload"))
+	assert.Equal(t, 3, calls)
+
+	msgs, err := ps.ReceiveNr(context.Background(), "webhooks-failed", 1)
+	assert.Nil(t, err)
+	assert.Len(t, msgs, 1)
+	assert.Equal(t, webhookboot.DeadLetterEvent, msgs[0].Attributes["event"])
+}
+
+func TestWebhooks_NoRetryOnClientError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusGone)
+	}))
+	defer srv.Close()
+
+	recorder := &testRecorder{}
+	s := newWebhookService(t, srv.URL, recorder)
+	err := s.DeliverTo(context.Background(), "test", "user.created", "payload")
+
+	assert.NotNil(t, err)
+	assert.Len(t, recorder.attempts, 1)
+}
+
+func TestWebhooks_CloseCancelsDelivery(t *testing.T) {
+	received := make(chan struct{})
+	unblock := make(chan struct{})
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(received)
+		<-unblock
+	}))
+	defer srv.Close()
+	defer close(unblock)
+
+	s := newWebhookService(t, srv.URL, &testRecorder{})
+
+	errs := make(chan error, 1)
+
+	go func() {
+		errs <- s.DeliverTo(context.Background(), "test", "user.deleted", "payload")
+	}()
+
+	<-received
+	assert.Nil(t, s.Close())
+
+	// the delivery is stopped rather than dead-lettered
+	err := <-errs
+	assert.ErrorContains(t, err, "stopped after 1 attempts: webhook service has been closed")
+}
+
+func TestWebhooks_DispatchWhileClosing(t *testing.T) {
+	var received int32
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&received, 1)
+	}))
+	defer srv.Close()
+
+	s := newWebhookService(t, srv.URL, &testRecorder{})
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for s.Dispatch("user.created", "payload") == nil {
+			}
+		}()
+	}
+
+	assert.Nil(t, s.Close())
+
+	delivered := atomic.LoadInt32(&received)
+
+	wg.Wait()
+	assert.EqualError(t, s.Dispatch("user.created", "payload"), "webhook service has been closed")
+	assert.Equal(t, delivered, atomic.LoadInt32(&received))
+}
+
+func TestWebhooks_AddEndpointWhileDispatching(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	s := newWebhookService(t, srv.URL, &testRecorder{})
+
+	var wg sync.WaitGroup
+
+	wg.Add(1)
+
+	go func() {
+		defer wg.Done()
+
+		for i := 0; i < 10; i++ {
+			s.AddEndpoint(&webhookboot.Endpoint{ID: strconv.Itoa(i), URL: srv.URL})
+		}
+	}()
+
+	for i := 0; i < 10; i++ {
+		assert.Nil(t, s.Dispatch("user.created", "payload"))
+	}
+
+	wg.Wait()
+	s.RemoveEndpoint("0")
+
+	assert.Nil(t, s.Close())
+	assert.Nil(t, s.Endpoint("0"))
+	assert.NotNil(t, s.Endpoint("9"))
+}
+
+func TestWebhooks_VerifySignatureInvalid(t *testing.T) {
+	err := webhookboot.VerifySignature("secret", "sha256=invalid", "0", []byte("{}"), 0)
+
+	assert.EqualError(t, err, "invalid webhook signature")
+}
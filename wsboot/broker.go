@@ -0,0 +1,14 @@
+package wsboot
+
+import "context"
+
+// Broker fans out the messages sent to groups across the instances of an
+// application, e.g. redisboot.Redis.WebSocketBroker.
+type Broker interface {
+	// Publish sends msg to the other instances.
+	Publish(ctx context.Context, group string, msg []byte) error
+
+	// Subscribe calls fn for messages published by other instances until ctx
+	// is done.
+	Subscribe(ctx context.Context, fn func(group string, msg []byte)) error
+}
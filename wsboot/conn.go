@@ -0,0 +1,214 @@
+package wsboot
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// Conn is a WebSocket connection of a Hub.
+type Conn struct {
+	// ID is a random identifier of the connection.
+	ID string
+
+	hub  *Hub
+	ws   *websocket.Conn
+	send chan []byte
+
+	// done is closed when the connection is closing
+	done        chan struct{}
+	closeOnce   sync.Once
+	closeCode   int
+	closeReason string
+
+	// groups are the groups the connection joined, guarded by hub.mu
+	groups map[string]struct{}
+
+	mu     sync.RWMutex
+	values map[string]any
+}
+
+func newConn(h *Hub, ws *websocket.Conn) *Conn {
+	return &Conn{
+		ID:     randomID(),
+		hub:    h,
+		ws:     ws,
+		send:   make(chan []byte, h.config.SendQueueSize),
+		done:   make(chan struct{}),
+		groups: make(map[string]struct{}),
+		values: make(map[string]any),
+	}
+}
+
+// Send queues msg as text message. Returns ErrQueueFull if the send queue is
+// full, which closes the connection when WebSocketConfig.Overflow is
+// "close", or ErrClosed if the connection is closed.
+func (c *Conn) Send(msg []byte) error {
+	select {
+	case <-c.done:
+		return ErrClosed
+	default:
+	}
+
+	select {
+	case c.send <- msg:
+		return nil
+	case <-c.done:
+		return ErrClosed
+	default:
+		c.hub.metrics.dropped()
+
+		if c.hub.config.Overflow == OverflowClose {
+			c.hub.log.Warn().Str("conn", c.ID).Msg("closing slow websocket connection")
+			c.closeWith(websocket.CloseTryAgainLater, "send queue full")
+		}
+
+		return ErrQueueFull
+	}
+}
+
+// SendJSON queues v encoded as JSON, see Send.
+func (c *Conn) SendJSON(v any) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("encoding websocket message: %w", err)
+	}
+
+	return c.Send(b)
+}
+
+// Join adds the connection to group, see Hub.Send.
+func (c *Conn) Join(group string) {
+	c.hub.join(c, group)
+}
+
+// Leave removes the connection from group.
+func (c *Conn) Leave(group string) {
+	c.hub.mu.Lock()
+	defer c.hub.mu.Unlock()
+
+	c.hub.leave(c, group)
+}
+
+// Groups returns the groups the connection joined in alphabetical order.
+func (c *Conn) Groups() []string {
+	c.hub.mu.RLock()
+	defer c.hub.mu.RUnlock()
+
+	groups := make([]string, 0, len(c.groups))
+	for group := range c.groups {
+		groups = append(groups, group)
+	}
+
+	sort.Strings(groups)
+
+	return groups
+}
+
+// Set stores a value of the connection, e.g. the authenticated user.
+func (c *Conn) Set(key string, value any) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.values[key] = value
+}
+
+// Get returns a value stored using Set, or nil if there is none.
+func (c *Conn) Get(key string) any {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.values[key]
+}
+
+// Close closes the connection with status "normal closure".
+func (c *Conn) Close() {
+	c.closeWith(websocket.CloseNormalClosure, "")
+}
+
+// closeWith closes the connection with a close status, the messages still in
+// the send queue are discarded.
+func (c *Conn) closeWith(code int, reason string) {
+	c.closeOnce.Do(func() {
+		c.closeCode = code
+		c.closeReason = reason
+		close(c.done)
+	})
+}
+
+// writeLoop writes queued messages and pings until the connection is closed.
+func (c *Conn) writeLoop() {
+	defer c.hub.wg.Done()
+	defer func() { _ = c.ws.Close() }() // stops the read loop
+
+	ticker := time.NewTicker(c.hub.config.PingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case msg := <-c.send:
+			_ = c.ws.SetWriteDeadline(time.Now().Add(c.hub.config.WriteTimeout))
+
+			if err := c.ws.WriteMessage(websocket.TextMessage, msg); err != nil {
+				c.hub.log.Debug().Err(err).Str("conn", c.ID).Msg("failed to write websocket message")
+				c.closeWith(websocket.CloseAbnormalClosure, "")
+			}
+		case <-ticker.C:
+			deadline := time.Now().Add(c.hub.config.WriteTimeout)
+			if err := c.ws.WriteControl(websocket.PingMessage, nil, deadline); err != nil {
+				c.closeWith(websocket.CloseAbnormalClosure, "")
+			}
+		case <-c.done:
+			if c.closeCode != websocket.CloseAbnormalClosure {
+				msg := websocket.FormatCloseMessage(c.closeCode, c.closeReason)
+				_ = c.ws.WriteControl(websocket.CloseMessage, msg, time.Now().Add(c.hub.config.WriteTimeout))
+			}
+
+			return
+		}
+	}
+}
+
+// readLoop reads messages until the connection is closed, it closes the
+// connection when no message or pong is received within PongTimeout.
+func (c *Conn) readLoop() {
+	defer c.hub.wg.Done()
+
+	timeout := c.hub.config.PongTimeout
+
+	c.ws.SetReadLimit(c.hub.config.MaxMessageSize)
+	_ = c.ws.SetReadDeadline(time.Now().Add(timeout))
+	c.ws.SetPongHandler(func(string) error {
+		return c.ws.SetReadDeadline(time.Now().Add(timeout)) //nolint:wrapcheck
+	})
+
+	for {
+		_, msg, err := c.ws.ReadMessage()
+		if err != nil {
+			var closeErr *websocket.CloseError
+			if !errors.As(err, &closeErr) {
+				c.hub.log.Debug().Err(err).Str("conn", c.ID).Msg("failed to read websocket message")
+			}
+
+			break
+		}
+
+		_ = c.ws.SetReadDeadline(time.Now().Add(timeout))
+
+		if c.hub.OnMessage != nil {
+			c.hub.OnMessage(c, msg)
+		}
+	}
+
+	c.closeWith(websocket.CloseNormalClosure, "")
+	c.hub.remove(c)
+
+	if c.hub.OnDisconnect != nil {
+		c.hub.OnDisconnect(c)
+	}
+}
@@ -0,0 +1,396 @@
+// Package wsboot serves WebSocket connections, see Hub.
+package wsboot
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/nielskrijger/goboot"
+	"github.com/rs/zerolog"
+)
+
+const (
+	defaultSendQueueSize  = 64
+	defaultPingInterval   = 30 * time.Second
+	defaultPongTimeout    = 60 * time.Second
+	defaultWriteTimeout   = 10 * time.Second
+	defaultMaxMessageSize = 64 * 1024
+	idBytes               = 16
+
+	// OverflowClose closes connections whose send queue is full.
+	OverflowClose = "close"
+
+	// OverflowDrop drops messages sent to connections whose send queue is full.
+	OverflowDrop = "drop"
+)
+
+var (
+	errInvalidOverflow = errors.New("invalid websocket overflow policy")
+	errPingInterval    = errors.New("websocket pingInterval must be less than pongTimeout")
+
+	// ErrQueueFull is returned when sending to a connection whose send queue
+	// is full, see WebSocketConfig.Overflow.
+	ErrQueueFull = errors.New("websocket send queue is full")
+
+	// ErrClosed is returned when sending to a closed connection.
+	ErrClosed = errors.New("websocket connection is closed")
+)
+
+type WebSocketConfig struct {
+	// Number of messages queued per connection before applying the Overflow
+	// policy. Default is 64.
+	SendQueueSize int `yaml:"sendQueueSize"`
+
+	// What to do when a send queue is full: "close" disconnects the slow
+	// client, "drop" drops the message. Default is "close".
+	Overflow string `yaml:"overflow"`
+
+	// Interval of pings sent to clients. Default is 30 seconds.
+	PingInterval time.Duration `yaml:"pingInterval"`
+
+	// Connections are closed when no message or pong is received within
+	// this time. Default is 60 seconds.
+	PongTimeout time.Duration `yaml:"pongTimeout"`
+
+	// Timeout of writing a message. Default is 10 seconds.
+	WriteTimeout time.Duration `yaml:"writeTimeout"`
+
+	// Maximum size of received messages in bytes, larger messages close the
+	// connection. Default is 64 KiB.
+	MaxMessageSize int64 `yaml:"maxMessageSize"`
+
+	// Origins allowed to connect, e.g. "https://example.com". Defaults to the
+	// host of the request, use "*" to allow all origins.
+	AllowedOrigins []string `yaml:"allowedOrigins"`
+}
+
+// Hub implements the AppService interface and serves WebSocket connections
+// as http.Handler, e.g. mux.Handle("/ws", hub).
+//
+// Messages are sent to a connection, a group of connections or all
+// connections. Every connection has a send queue so slow clients don't block
+// senders. With a Broker messages sent to groups are delivered to the
+// connections of all instances.
+type Hub struct {
+	// OnConnect is called for new connections, e.g. to join groups based on
+	// the authenticated user of the request. Returning an error closes the
+	// connection.
+	OnConnect func(c *Conn, r *http.Request) error
+
+	// OnMessage is called for every message received, messages of a
+	// connection are handled in order.
+	OnMessage func(c *Conn, msg []byte)
+
+	// OnDisconnect is called when a connection is closed.
+	OnDisconnect func(c *Conn)
+
+	// Broker fans out messages sent to groups across instances, optional.
+	Broker Broker
+
+	config   *WebSocketConfig
+	upgrader websocket.Upgrader
+	metrics  *hubMetrics
+	log      zerolog.Logger
+
+	mu     sync.RWMutex
+	conns  map[*Conn]struct{}
+	groups map[string]map[*Conn]struct{}
+	closed bool
+
+	wg     sync.WaitGroup
+	ctx    context.Context //nolint:containedctx
+	cancel context.CancelFunc
+}
+
+func (h *Hub) Name() string {
+	return "WebSocket"
+}
+
+// Configure loads the optional "websocket" configuration.
+func (h *Hub) Configure(env *goboot.AppEnv) error {
+	h.log = env.Log
+	h.config = &WebSocketConfig{}
+
+	if env.Config.InConfig("websocket") {
+		if err := env.Config.Sub("websocket").Unmarshal(h.config); err != nil {
+			return fmt.Errorf("parsing websocket configuration: %w", err)
+		}
+	}
+
+	if err := h.setDefaults(); err != nil {
+		return err
+	}
+
+	h.upgrader = websocket.Upgrader{CheckOrigin: h.checkOrigin}
+
+	return h.registerMetrics(env.Metrics)
+}
+
+func (h *Hub) setDefaults() error {
+	if h.config.SendQueueSize == 0 {
+		h.config.SendQueueSize = defaultSendQueueSize
+	}
+
+	if h.config.Overflow == "" {
+		h.config.Overflow = OverflowClose
+	}
+
+	if h.config.Overflow != OverflowClose && h.config.Overflow != OverflowDrop {
+		return fmt.Errorf("%w: %q", errInvalidOverflow, h.config.Overflow)
+	}
+
+	if h.config.PingInterval == 0 {
+		h.config.PingInterval = defaultPingInterval
+	}
+
+	if h.config.PongTimeout == 0 {
+		h.config.PongTimeout = defaultPongTimeout
+	}
+
+	if h.config.PingInterval >= h.config.PongTimeout {
+		return errPingInterval
+	}
+
+	if h.config.WriteTimeout == 0 {
+		h.config.WriteTimeout = defaultWriteTimeout
+	}
+
+	if h.config.MaxMessageSize == 0 {
+		h.config.MaxMessageSize = defaultMaxMessageSize
+	}
+
+	return nil
+}
+
+// Init subscribes to the Broker if set.
+func (h *Hub) Init() error {
+	h.mu.Lock()
+	h.conns = make(map[*Conn]struct{})
+	h.groups = make(map[string]map[*Conn]struct{})
+	h.ctx, h.cancel = context.WithCancel(context.Background())
+	h.mu.Unlock()
+
+	if h.Broker == nil {
+		return nil
+	}
+
+	if err := h.Broker.Subscribe(h.ctx, h.deliver); err != nil {
+		return fmt.Errorf("subscribing to websocket broker: %w", err)
+	}
+
+	return nil
+}
+
+// ServeHTTP upgrades the request to a WebSocket connection.
+func (h *Hub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mu.RLock()
+	closed := h.closed || h.conns == nil
+	h.mu.RUnlock()
+
+	if closed {
+		http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+
+		return
+	}
+
+	ws, err := h.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// the upgrader has responded with an error already
+		h.log.Debug().Err(err).Msg("failed to upgrade websocket connection")
+
+		return
+	}
+
+	c := newConn(h, ws)
+
+	if !h.add(c) {
+		_ = ws.Close()
+
+		return
+	}
+
+	go c.writeLoop()
+
+	if h.OnConnect != nil {
+		if err := h.OnConnect(c, r); err != nil {
+			h.log.Debug().Err(err).Str("conn", c.ID).Msg("rejected websocket connection")
+			c.closeWith(websocket.ClosePolicyViolation, err.Error())
+			h.remove(c)
+			h.wg.Done() // of the read loop
+
+			return
+		}
+	}
+
+	go c.readLoop()
+}
+
+// Send sends msg to the connections in group, or to all connections if group
+// is empty. With a Broker the message is sent to the connections of all
+// instances. Slow connections don't block Send, see WebSocketConfig.Overflow.
+func (h *Hub) Send(ctx context.Context, group string, msg []byte) error {
+	h.deliver(group, msg)
+
+	if h.Broker != nil {
+		if err := h.Broker.Publish(ctx, group, msg); err != nil {
+			return fmt.Errorf("publishing websocket message: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// deliver sends msg to the local connections in group, or all connections
+// if group is empty.
+func (h *Hub) deliver(group string, msg []byte) {
+	h.mu.RLock()
+
+	targets := h.conns
+	if group != "" {
+		targets = h.groups[group]
+	}
+
+	conns := make([]*Conn, 0, len(targets))
+	for c := range targets {
+		conns = append(conns, c)
+	}
+
+	h.mu.RUnlock()
+
+	for _, c := range conns {
+		_ = c.Send(msg) // the connection handles its errors
+	}
+}
+
+// Len returns the number of connections of this instance.
+func (h *Hub) Len() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	return len(h.conns)
+}
+
+// Close closes all connections with status "going away" and stops receiving
+// messages from the Broker.
+func (h *Hub) Close() error {
+	h.mu.Lock()
+	h.closed = true
+
+	conns := make([]*Conn, 0, len(h.conns))
+	for c := range h.conns {
+		conns = append(conns, c)
+	}
+
+	h.mu.Unlock()
+
+	if h.cancel != nil {
+		h.cancel()
+	}
+
+	for _, c := range conns {
+		c.closeWith(websocket.CloseGoingAway, "server shutting down")
+	}
+
+	h.wg.Wait()
+
+	return nil
+}
+
+func (h *Hub) add(c *Conn) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		return false
+	}
+
+	h.conns[c] = struct{}{}
+	h.metrics.connected()
+
+	// the read and write loop, added while locked so Close waits for them
+	h.wg.Add(2) //nolint:gomnd
+
+	return true
+}
+
+func (h *Hub) remove(c *Conn) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if _, ok := h.conns[c]; !ok {
+		return
+	}
+
+	delete(h.conns, c)
+
+	for group := range c.groups {
+		h.leave(c, group)
+	}
+
+	h.metrics.disconnected()
+}
+
+func (h *Hub) join(c *Conn, group string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if _, ok := h.conns[c]; !ok {
+		return // closed
+	}
+
+	if h.groups[group] == nil {
+		h.groups[group] = make(map[*Conn]struct{})
+	}
+
+	h.groups[group][c] = struct{}{}
+	c.groups[group] = struct{}{}
+}
+
+// leave removes c from group, h.mu must be locked.
+func (h *Hub) leave(c *Conn, group string) {
+	delete(c.groups, group)
+	delete(h.groups[group], c)
+
+	if len(h.groups[group]) == 0 {
+		delete(h.groups, group)
+	}
+}
+
+// checkOrigin allows the configured origins, or requests of the same host.
+func (h *Hub) checkOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" || len(h.config.AllowedOrigins) == 0 {
+		return origin == "" || sameHost(origin, r.Host)
+	}
+
+	for _, allowed := range h.config.AllowedOrigins {
+		if allowed == "*" || allowed == origin {
+			return true
+		}
+	}
+
+	return false
+}
+
+// sameHost returns true if the host of origin is host.
+func sameHost(origin string, host string) bool {
+	u, err := url.Parse(origin)
+
+	return err == nil && strings.EqualFold(u.Host, host)
+}
+
+func randomID() string {
+	b := make([]byte, idBytes)
+	_, _ = rand.Read(b)
+
+	return hex.EncodeToString(b)
+}
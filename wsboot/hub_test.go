@@ -0,0 +1,253 @@
+package wsboot_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/nielskrijger/goboot"
+	"github.com/nielskrijger/goboot/wsboot"
+	"github.com/stretchr/testify/assert"
+)
+
+var errUnauthorized = errors.New("unauthorized")
+
+// newHub starts a hub joining the group of the "group" query parameter.
+func newHub(t *testing.T, env string, broker wsboot.Broker) (*wsboot.Hub, *httptest.Server, chan *wsboot.Conn) {
+	t.Helper()
+
+	connected := make(chan *wsboot.Conn, 10)
+
+	hub := &wsboot.Hub{
+		Broker: broker,
+		OnConnect: func(c *wsboot.Conn, r *http.Request) error {
+			if r.URL.Query().Get("token") == "invalid" {
+				return errUnauthorized
+			}
+
+			if group := r.URL.Query().Get("group"); group != "" {
+				c.Join(group)
+			}
+
+			connected <- c
+
+			return nil
+		},
+		OnMessage: func(c *wsboot.Conn, msg []byte) {
+			_ = c.Send(append([]byte("echo: "), msg...))
+		},
+	}
+	assert.Nil(t, hub.Configure(goboot.NewAppEnv("./testdata", env)))
+	assert.Nil(t, hub.Init())
+
+	server := httptest.NewServer(hub)
+
+	t.Cleanup(func() {
+		server.Close()
+		_ = hub.Close()
+	})
+
+	return hub, server, connected
+}
+
+func dial(t *testing.T, server *httptest.Server, query string) *websocket.Conn {
+	t.Helper()
+
+	ws, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"?"+query, nil)
+	assert.Nil(t, err)
+
+	t.Cleanup(func() { _ = ws.Close() })
+
+	return ws
+}
+
+func read(t *testing.T, ws *websocket.Conn) string {
+	t.Helper()
+
+	_ = ws.SetReadDeadline(time.Now().Add(time.Second))
+	_, msg, err := ws.ReadMessage()
+	assert.Nil(t, err)
+
+	return string(msg)
+}
+
+func TestHub_Send(t *testing.T) {
+	hub, server, connected := newHub(t, "", nil)
+
+	alice := dial(t, server, "group=a")
+	<-connected
+	bob := dial(t, server, "group=b")
+	c := <-connected
+
+	assert.Equal(t, 2, hub.Len())
+	assert.Equal(t, []string{"b"}, c.Groups())
+
+	ctx := context.Background()
+	assert.Nil(t, hub.Send(ctx, "a", []byte("to a")))
+	assert.Nil(t, hub.Send(ctx, "", []byte("to all")))
+
+	assert.Equal(t, "to a", read(t, alice))
+	assert.Equal(t, "to all", read(t, alice))
+	assert.Equal(t, "to all", read(t, bob))
+
+	c.Leave("b")
+	assert.Empty(t, c.Groups())
+}
+
+func TestHub_OnMessage(t *testing.T) {
+	_, server, _ := newHub(t, "", nil)
+
+	ws := dial(t, server, "")
+	assert.Nil(t, ws.WriteMessage(websocket.TextMessage, []byte("hello")))
+	assert.Equal(t, "echo: hello", read(t, ws))
+}
+
+func TestHub_OnConnectRejects(t *testing.T) {
+	hub, server, _ := newHub(t, "", nil)
+
+	ws := dial(t, server, "token=invalid")
+
+	_, _, err := ws.ReadMessage()
+	assert.True(t, websocket.IsCloseError(err, websocket.ClosePolicyViolation), err)
+	assert.Eventually(t, func() bool { return hub.Len() == 0 }, time.Second, 5*time.Millisecond)
+}
+
+func TestHub_ClosesConnectionsWithoutPong(t *testing.T) {
+	hub, server, _ := newHub(t, "", nil)
+
+	// a client that doesn't read doesn't respond to pings
+	dial(t, server, "")
+	assert.Eventually(t, func() bool { return hub.Len() == 1 }, time.Second, 5*time.Millisecond)
+	assert.Eventually(t, func() bool { return hub.Len() == 0 }, time.Second, 5*time.Millisecond)
+
+	// a client that reads responds to pings
+	ws := dial(t, server, "")
+
+	var pings int32
+
+	ws.SetPingHandler(func(data string) error {
+		atomic.AddInt32(&pings, 1)
+
+		return ws.WriteControl(websocket.PongMessage, []byte(data), time.Now().Add(time.Second))
+	})
+
+	go func() {
+		for {
+			if _, _, err := ws.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	// pings exceeding the pong timeout of 100ms were answered
+	assert.Eventually(t, func() bool { return atomic.LoadInt32(&pings) >= 10 }, 5*time.Second, 5*time.Millisecond)
+	assert.Equal(t, 1, hub.Len())
+}
+
+func TestHub_ClosesSlowConnections(t *testing.T) {
+	_, server, connected := newHub(t, "", nil)
+
+	dial(t, server, "")
+	c := <-connected
+
+	msg := make([]byte, 32*1024)
+
+	var err error
+	for i := 0; i < 10000 && err == nil; i++ {
+		err = c.Send(msg)
+	}
+
+	assert.ErrorIs(t, err, wsboot.ErrQueueFull)
+	assert.ErrorIs(t, c.Send(msg), wsboot.ErrClosed)
+}
+
+func TestHub_DropsMessagesOfSlowConnections(t *testing.T) {
+	_, server, connected := newHub(t, "drop", nil)
+
+	dial(t, server, "")
+	c := <-connected
+
+	msg := make([]byte, 32*1024)
+
+	var err error
+	for i := 0; i < 10000 && err == nil; i++ {
+		err = c.Send(msg)
+	}
+
+	assert.ErrorIs(t, err, wsboot.ErrQueueFull)
+	assert.NotErrorIs(t, c.Send(msg), wsboot.ErrClosed)
+}
+
+func TestHub_CloseGoingAway(t *testing.T) {
+	hub, server, _ := newHub(t, "", nil)
+
+	ws := dial(t, server, "")
+	assert.Eventually(t, func() bool { return hub.Len() == 1 }, time.Second, 5*time.Millisecond)
+
+	assert.Nil(t, hub.Close())
+
+	_, _, err := ws.ReadMessage()
+	assert.True(t, websocket.IsCloseError(err, websocket.CloseGoingAway), err)
+}
+
+func TestHub_InvalidConfig(t *testing.T) {
+	hub := &wsboot.Hub{}
+	assert.EqualError(t, hub.Configure(goboot.NewAppEnv("./testdata", "invalid")),
+		`invalid websocket overflow policy: "block"`)
+}
+
+// bus connects the brokers of hubs like Redis would.
+type bus struct {
+	mu   sync.Mutex
+	subs map[*broker]func(group string, msg []byte)
+}
+
+type broker struct {
+	bus *bus
+}
+
+func (b *broker) Publish(_ context.Context, group string, msg []byte) error {
+	b.bus.mu.Lock()
+	defer b.bus.mu.Unlock()
+
+	for other, fn := range b.bus.subs {
+		if other != b {
+			fn(group, msg)
+		}
+	}
+
+	return nil
+}
+
+func (b *broker) Subscribe(_ context.Context, fn func(group string, msg []byte)) error {
+	b.bus.mu.Lock()
+	defer b.bus.mu.Unlock()
+
+	b.bus.subs[b] = fn
+
+	return nil
+}
+
+func TestHub_BrokerFansOutToOtherInstances(t *testing.T) {
+	b := &bus{subs: make(map[*broker]func(string, []byte))}
+
+	hub1, server1, connected1 := newHub(t, "", &broker{bus: b})
+	_, server2, connected2 := newHub(t, "", &broker{bus: b})
+
+	ws1 := dial(t, server1, "group=room")
+	<-connected1
+	ws2 := dial(t, server2, "group=room")
+	<-connected2
+
+	assert.Nil(t, hub1.Send(context.Background(), "room", []byte("hi")))
+
+	assert.Equal(t, "hi", read(t, ws1))
+	assert.Equal(t, "hi", read(t, ws2))
+}
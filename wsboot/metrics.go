@@ -0,0 +1,60 @@
+package wsboot
+
+import (
+	"fmt"
+
+	"github.com/nielskrijger/goboot"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type hubMetrics struct {
+	connections prometheus.Gauge
+	drops       prometheus.Counter
+}
+
+func (h *Hub) registerMetrics(reg *prometheus.Registry) error {
+	if reg == nil || h.metrics != nil {
+		return nil
+	}
+
+	m := &hubMetrics{
+		connections: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "websocket_connections",
+			Help: "Number of open websocket connections.",
+		}),
+		drops: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "websocket_send_queue_full_total",
+			Help: "Number of messages not sent because the send queue of a connection was full.",
+		}),
+	}
+
+	if err := goboot.RegisterCollector(reg, &m.connections); err != nil {
+		return fmt.Errorf("registering websocket metrics: %w", err)
+	}
+
+	if err := goboot.RegisterCollector(reg, &m.drops); err != nil {
+		return fmt.Errorf("registering websocket metrics: %w", err)
+	}
+
+	h.metrics = m
+
+	return nil
+}
+
+func (m *hubMetrics) connected() {
+	if m != nil {
+		m.connections.Inc()
+	}
+}
+
+func (m *hubMetrics) disconnected() {
+	if m != nil {
+		m.connections.Dec()
+	}
+}
+
+func (m *hubMetrics) dropped() {
+	if m != nil {
+		m.drops.Inc()
+	}
+}